@@ -0,0 +1,46 @@
+package bootstrap
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// ServeCommand starts the HTTP server. It is the default command.
+	ServeCommand = "serve"
+
+	// WorkerCommand runs the background jobs without the HTTP server, so they scale apart from it.
+	WorkerCommand = "worker"
+)
+
+// Command is the run mode of the application, parsed from the command line.
+type Command struct {
+	Name    string
+	Storage string // Overrides the configured storage when set.
+}
+
+// ParseCommand parses arguments like "serve --storage=memory" or "worker".
+func ParseCommand(args []string) (Command, error) {
+	command := Command{Name: ServeCommand}
+
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command.Name = args[0]
+		args = args[1:]
+	}
+
+	if command.Name != ServeCommand && command.Name != WorkerCommand {
+		return Command{}, fmt.Errorf("unknown command: %s", command.Name)
+	}
+
+	flags := flag.NewFlagSet(command.Name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&command.Storage, "storage", "", `storage of the repositories ("mysql", "postgres", "sqlite" or "memory")`)
+
+	if err := flags.Parse(args); err != nil {
+		return Command{}, err
+	}
+
+	return command, nil
+}
@@ -0,0 +1,62 @@
+package bootstrap
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestParseCommand_Successful(t *testing.T) {
+	tests := []struct {
+		name            string
+		args            []string
+		expectedCommand Command
+	}{
+		{
+			name:            "defaults to serve",
+			args:            []string{},
+			expectedCommand: Command{Name: ServeCommand},
+		},
+		{
+			name:            "serve with storage",
+			args:            []string{"serve", "--storage=memory"},
+			expectedCommand: Command{Name: ServeCommand, Storage: "memory"},
+		},
+		{
+			name:            "worker with storage",
+			args:            []string{"worker", "--storage=postgres"},
+			expectedCommand: Command{Name: WorkerCommand, Storage: "postgres"},
+		},
+		{
+			name:            "flags without command",
+			args:            []string{"--storage", "mysql"},
+			expectedCommand: Command{Name: ServeCommand, Storage: "mysql"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			command, err := ParseCommand(tt.args)
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedCommand, command)
+		})
+	}
+}
+
+func TestParseCommand_FailsDueToUnknownCommand(t *testing.T) {
+	// When
+	_, err := ParseCommand([]string{"unknown"})
+
+	// Then
+	require.EqualError(t, err, "unknown command: unknown")
+}
+
+func TestParseCommand_FailsDueToUnknownFlag(t *testing.T) {
+	// When
+	_, err := ParseCommand([]string{"serve", "--unknown"})
+
+	// Then
+	require.ErrorContains(t, err, "flag provided but not defined")
+}
@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"github.com/ferch5003/go-fiber-tutorial/cmd/api/router"
 	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/middlewares"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/metrics"
 	"github.com/gofiber/contrib/fiberzap/v2"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
 	"sync"
@@ -40,6 +43,8 @@ func Start(
 	server *Server,
 	app *fiber.App,
 	router *router.GeneralRouter,
+	metrics *metrics.Metrics,
+	tracerProvider trace.TracerProvider,
 	logger *zap.Logger) {
 	host := _defaultHost // Default Host
 	if cfg != nil && cfg.Host != "" {
@@ -51,13 +56,30 @@ func Start(
 		port = cfg.Port
 	}
 
+	// Accept or generate the X-Request-ID of every request.
+	app.Use(middlewares.RequestIDMiddleware())
+
 	// Log all requests.
 	app.Use(fiberzap.New(fiberzap.Config{
-		Logger: logger,
+		Logger:     logger,
+		Fields:     []string{"ip", "latency", "status", "method", "url", "error"},
+		FieldsFunc: middlewares.AccessLogFields,
 	}))
 
 	app.Use(recover.New())
 
+	// Record requests count and latency.
+	app.Use(metrics.Middleware())
+
+	// Trace all requests.
+	app.Use(middlewares.NewTracingMiddleware(tracerProvider).GetMiddleware())
+
+	// Store a per-request logger.
+	app.Use(middlewares.LoggerMiddleware(logger))
+
+	// Record where the changes of every request come from.
+	app.Use(middlewares.AuditMiddleware())
+
 	lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			logger.Info(fmt.Sprintf("Starting fiber server on %s:%s", host, port))
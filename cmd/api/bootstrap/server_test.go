@@ -4,6 +4,8 @@ import (
 	"context"
 	"github.com/ferch5003/go-fiber-tutorial/cmd/api/router"
 	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/metrics"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/tracing"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/fx"
@@ -39,8 +41,15 @@ func TestStart_Successful(t *testing.T) {
 				mur,
 				fx.As(new(router.Router))),
 		),
-		fx.Provide(router.NewRouter),
+		fx.Provide(
+			fx.Annotate(
+				router.NewRouter,
+				fx.ParamTags(``, ``, `group:"versioned_routers"`),
+			),
+		),
 		fx.Provide(zap.NewDevelopment),
+		fx.Provide(metrics.NewMetrics),
+		fx.Provide(tracing.NewTracerProvider),
 		fx.Provide(config.NewConfigurations),
 		fx.Supply(server),
 		fx.Provide(NewFiberServer),
@@ -78,8 +87,15 @@ func TestStart_FailsDueToInvalidConfiguration(t *testing.T) {
 				mur,
 				fx.As(new(router.Router))),
 		),
-		fx.Provide(router.NewRouter),
+		fx.Provide(
+			fx.Annotate(
+				router.NewRouter,
+				fx.ParamTags(``, ``, `group:"versioned_routers"`),
+			),
+		),
 		fx.Provide(zap.NewDevelopment),
+		fx.Provide(metrics.NewMetrics),
+		fx.Provide(tracing.NewTracerProvider),
 		fx.Supply(&config.EnvVars{Host: "bad_host", Port: "bad_port"}),
 		fx.Supply(server),
 		fx.Provide(NewFiberServer),
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/cmd/api/bootstrap"
+	"github.com/ferch5003/go-fiber-tutorial/cmd/api/router"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/db/seeds"
+	"go.uber.org/fx"
+)
+
+// newCommand invokes what the command runs. The worker only runs the jobs and the outbox relay, so they
+// scale apart from the web server, which also runs them unless they are disabled with JOBS_INLINE.
+func newCommand(command bootstrap.Command, configurations *config.EnvVars) fx.Option {
+	if command.Name == bootstrap.WorkerCommand {
+		return fx.Invoke(router.RunJobs, router.RunOutboxRelay)
+	}
+
+	jobs := fx.Options()
+	if configurations.JobsInline {
+		// Run the jobs and the relay before the web server is started, so they stop after it.
+		jobs = fx.Invoke(router.RunJobs, router.RunOutboxRelay)
+	}
+
+	return fx.Options(
+		fx.Invoke(seeds.Execute),
+
+		jobs,
+
+		// Start web server.
+		fx.Invoke(bootstrap.Start),
+
+		// End the event streams before the web server waits for them on stop.
+		fx.Invoke(router.CloseStreamsOnStop),
+	)
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/validations"
+	"github.com/ferch5003/go-fiber-tutorial/internal/todo"
+	"github.com/gofiber/fiber/v2"
+	"slices"
+	"time"
+)
+
+// _defaultAuditPageSize is the number of events of a feed page when the client does not choose it.
+const _defaultAuditPageSize = 50
+
+type AuditHandler struct {
+	validator      *validations.XValidator
+	sessionType    string
+	adminUserIDs   []int
+	auditService   audit.Service
+	todoService    todo.Service
+	sessionService session.Service
+}
+
+func NewAuditHandler(
+	cfg *config.EnvVars,
+	auditService audit.Service,
+	todoService todo.Service,
+	sessionService session.Service) *AuditHandler {
+	myValidator := validations.NewValidator()
+
+	return &AuditHandler{
+		validator:      myValidator,
+		sessionType:    cfg.AppSessionType,
+		adminUserIDs:   cfg.AppAdminUserIDs,
+		auditService:   auditService,
+		todoService:    todoService,
+		sessionService: sessionService,
+	}
+}
+
+// TodoHistory lists the changes of a todo of the authenticated user, from the oldest.
+func (h *AuditHandler) TodoHistory(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userID, err := getAuthUserID(c, h.sessionService, h.sessionType)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	events, err := h.auditService.History(c.UserContext(), domain.AuditTodo, id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// The owner is read from the events, so the history of a deleted todo is kept for its user.
+	ownerID := todoOwner(events)
+	if ownerID == 0 {
+		// Todos created before the audit log do not record their user until they are deleted.
+		obtainedTodo, err := h.todoService.Get(c.UserContext(), id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": domain.ErrNotFound.Error(),
+			})
+		}
+
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		ownerID = obtainedTodo.UserID
+	}
+
+	if ownerID != userID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "This todo is not from this user",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(events)
+}
+
+// todoOwner returns the user of the todo of the events, recorded when it was created or deleted, or zero
+// when none of them records it.
+func todoOwner(events []domain.AuditEvent) int {
+	for _, event := range events {
+		change, ok := event.Changes["user_id"]
+		if !ok {
+			continue
+		}
+
+		// The changes are decoded from JSON, so numbers are float64.
+		for _, value := range []any{change.After, change.Before} {
+			if userID, ok := value.(float64); ok {
+				return int(userID)
+			}
+		}
+	}
+
+	return 0
+}
+
+type auditFeed struct {
+	ActorID  int    `query:"actor_id" validate:"omitempty,min=1"`
+	Action   string `query:"action" validate:"omitempty,max=50"`
+	Entity   string `query:"entity" validate:"omitempty,oneof=todo user"`
+	EntityID int    `query:"entity_id" validate:"omitempty,min=1"`
+	Since    string `query:"since" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	Until    string `query:"until" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	BeforeID int    `query:"before_id" validate:"omitempty,min=1"`
+	Limit    int    `query:"limit" validate:"omitempty,min=1,max=200"`
+}
+
+// Feed lists the audit events matching the query parameters, from the newest. Only admins can read it.
+func (h *AuditHandler) Feed(c *fiber.Ctx) error {
+	userID, err := getAuthUserID(c, h.sessionService, h.sessionType)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if !slices.Contains(h.adminUserIDs, userID) {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Only admins can read the audit feed",
+		})
+	}
+
+	var feedData auditFeed
+	if err := c.QueryParser(&feedData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	feedValidations := h.validator.GetValidations(feedData)
+	if feedValidations != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": feedValidations,
+		})
+	}
+
+	filter := domain.AuditFilter{
+		ActorID:  feedData.ActorID,
+		Action:   feedData.Action,
+		Entity:   feedData.Entity,
+		EntityID: feedData.EntityID,
+		BeforeID: feedData.BeforeID,
+		Limit:    feedData.Limit,
+	}
+
+	if filter.Limit == 0 {
+		filter.Limit = _defaultAuditPageSize
+	}
+
+	// The dates were validated with the same layout.
+	if feedData.Since != "" {
+		filter.Since, _ = time.Parse(time.RFC3339, feedData.Since)
+	}
+
+	if feedData.Until != "" {
+		filter.Until, _ = time.Parse(time.RFC3339, feedData.Until)
+	}
+
+	page, err := h.auditService.Feed(c.UserContext(), filter)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(page)
+}
@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/middlewares"
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/sql"
+	"github.com/ferch5003/go-fiber-tutorial/internal/todo"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"io"
+	"testing"
+)
+
+const _auditPath = "/admin/audit"
+
+func createAuditServer(cfg *config.EnvVars, events audit.Repository, todoService todo.Service) *fiber.App {
+	app := fiber.New()
+
+	// Sessions are stored by the JWT middleware, like in the application.
+	sessionService := session.NewService(session.NewMemoryRepository())
+
+	auditHandler := NewAuditHandler(cfg, audit.NewService(events), todoService, sessionService)
+
+	jwtMiddleware := middlewares.NewJWTMiddleware(
+		context.Background(),
+		cfg.AppSessionType,
+		cfg.AppSecretKey,
+		sessionService,
+	)
+
+	app.Get("/todos/:id/history", jwtMiddleware.GetMiddleware(), auditHandler.TodoHistory).Name("todos.history")
+	app.Get(_auditPath, jwtMiddleware.GetMiddleware(), auditHandler.Feed).Name("admin.audit")
+
+	return app
+}
+
+// newAuditedTodoService creates a todo.Service backed by an in-memory repository, which records its
+// writes in the returned audit.Repository.
+func newAuditedTodoService(t *testing.T, todos ...domain.Todo) (todo.Service, audit.Repository) {
+	t.Helper()
+
+	events := audit.NewMemoryRepository()
+	repository := todo.NewMemoryRepository(events, outbox.NewMemoryRepository())
+
+	for _, todoData := range todos {
+		_, err := repository.Save(context.Background(), todoData)
+		require.NoError(t, err)
+	}
+
+	return todo.NewService(repository, todo.NewIndexSearcher(repository), sql.NewNopTransactor()), events
+}
+
+func TestAuditHandlerTodoHistory_Successful(t *testing.T) {
+	// Given
+	todoService, events := newAuditedTodoService(t, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: 1})
+
+	_, err := todoService.Completed(context.Background(), 1, 0)
+	require.NoError(t, err)
+
+	server := createAuditServer(_testConfigs, events, todoService)
+
+	req, err := createTodoRequest(fiber.MethodGet, "/todos/1/history", true, "")
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var history []domain.AuditEvent
+	err = json.Unmarshal(body, &history)
+	require.NoError(t, err)
+
+	require.Len(t, history, 2)
+	require.Equal(t, domain.AuditTodoCreated, history[0].Action)
+	require.Equal(t, domain.AuditTodoCompleted, history[1].Action)
+	require.Equal(t, domain.AuditChanges{"completed": {Before: false, After: true}}, history[1].Changes)
+}
+
+func TestAuditHandlerTodoHistory_SuccessfulWithDeletedTodo(t *testing.T) {
+	// Given
+	todoService, events := newAuditedTodoService(t, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: 1})
+
+	err := todoService.Delete(context.Background(), 1, 0)
+	require.NoError(t, err)
+
+	server := createAuditServer(_testConfigs, events, todoService)
+
+	req, err := createTodoRequest(fiber.MethodGet, "/todos/1/history", true, "")
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var history []domain.AuditEvent
+	err = json.Unmarshal(body, &history)
+	require.NoError(t, err)
+
+	require.Len(t, history, 2)
+	require.Equal(t, domain.AuditTodoDeleted, history[1].Action)
+}
+
+func TestAuditHandlerTodoHistory_FailsDueToTodoOfOtherUser(t *testing.T) {
+	// Given
+	todoService, events := newAuditedTodoService(t, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: 2})
+
+	server := createAuditServer(_testConfigs, events, todoService)
+
+	req, err := createTodoRequest(fiber.MethodGet, "/todos/1/history", true, "")
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+}
+
+func TestAuditHandlerTodoHistory_FailsDueToDeletedTodoOfOtherUser(t *testing.T) {
+	// Given
+	todoService, events := newAuditedTodoService(t, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: 2})
+
+	err := todoService.Delete(context.Background(), 1, 0)
+	require.NoError(t, err)
+
+	server := createAuditServer(_testConfigs, events, todoService)
+
+	req, err := createTodoRequest(fiber.MethodGet, "/todos/1/history", true, "")
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+}
+
+func TestAuditHandlerTodoHistory_FailsDueToMissingTodo(t *testing.T) {
+	// Given
+	todoService, events := newAuditedTodoService(t)
+
+	server := createAuditServer(_testConfigs, events, todoService)
+
+	req, err := createTodoRequest(fiber.MethodGet, "/todos/1/history", true, "")
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response errorResponse
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Equal(t, domain.ErrNotFound.Error(), response.Error)
+}
+
+func TestAuditHandlerTodoHistory_FailsDueToMissingToken(t *testing.T) {
+	// Given
+	todoService, events := newAuditedTodoService(t, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: 1})
+
+	server := createAuditServer(_testConfigs, events, todoService)
+
+	req, err := createTodoRequest(fiber.MethodGet, "/todos/1/history", false, "")
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestAuditHandlerFeed_Successful(t *testing.T) {
+	// Given
+	todoService, events := newAuditedTodoService(t,
+		domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: 1},
+		domain.Todo{Title: "Dolor", Description: "Sit", UserID: 2},
+	)
+
+	err := todoService.Delete(context.Background(), 2, 0)
+	require.NoError(t, err)
+
+	adminConfigs := *_testConfigs
+	adminConfigs.AppAdminUserIDs = []int{1}
+
+	server := createAuditServer(&adminConfigs, events, todoService)
+
+	req, err := createTodoRequest(fiber.MethodGet, _auditPath+"?entity=todo&action=todo.created&limit=1", true, "")
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var page domain.AuditPage
+	err = json.Unmarshal(body, &page)
+	require.NoError(t, err)
+
+	require.Len(t, page.Events, 1)
+	require.Equal(t, domain.AuditTodoCreated, page.Events[0].Action)
+	require.Equal(t, 2, page.Events[0].EntityID)
+	require.Equal(t, page.Events[0].ID, page.NextBeforeID)
+}
+
+func TestAuditHandlerFeed_FailsDueToNonAdminUser(t *testing.T) {
+	// Given
+	todoService, events := newAuditedTodoService(t)
+
+	server := createAuditServer(_testConfigs, events, todoService)
+
+	req, err := createTodoRequest(fiber.MethodGet, _auditPath, true, "")
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+}
+
+func TestAuditHandlerFeed_FailsDueToValidations(t *testing.T) {
+	// Given
+	todoService, events := newAuditedTodoService(t)
+
+	adminConfigs := *_testConfigs
+	adminConfigs.AppAdminUserIDs = []int{1}
+
+	server := createAuditServer(&adminConfigs, events, todoService)
+
+	req, err := createTodoRequest(fiber.MethodGet, _auditPath+"?entity=session&since=yesterday", true, "")
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response errorResponse
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Contains(t, response.Error, "[Entity]: 'session' | Needs to implement 'oneof'")
+	require.Contains(t, response.Error, "[Since]: 'yesterday' | Needs to implement 'datetime'")
+}
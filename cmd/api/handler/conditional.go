@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/internal/apierrors"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+	"strings"
+)
+
+// versionETag is the strong ETag of a resource at the version.
+func versionETag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// notModified sets the ETag of the resource version and reports if the copy of the client, sent in
+// If-None-Match, is still current.
+func notModified(c *fiber.Ctx, version int) bool {
+	etag := versionETag(version)
+	c.Set(fiber.HeaderETag, etag)
+
+	ifNoneMatch := c.Get(fiber.HeaderIfNoneMatch)
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	// If-None-Match uses the weak comparison.
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ifMatchVersion returns the version required by the If-Match header, or zero when any version is
+// accepted. Tags that are not a single strong ETag of a version, like weak ones, can not match.
+func ifMatchVersion(c *fiber.Ctx, required bool) (int, error) {
+	ifMatch := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+
+	switch ifMatch {
+	case "":
+		if required {
+			return 0, apierrors.ErrIfMatchRequired
+		}
+
+		return 0, nil
+	case "*":
+		return 0, nil
+	}
+
+	unquoted, ok := strings.CutPrefix(ifMatch, `"`)
+	if ok {
+		unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	}
+
+	version, err := strconv.Atoi(unquoted)
+	if !ok || err != nil || version < 1 {
+		return 0, domain.ErrVersionMismatch
+	}
+
+	return version, nil
+}
+
+// preconditionStatus returns the status of a failed precondition, or zero when err is not one.
+func preconditionStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrVersionMismatch):
+		return fiber.StatusPreconditionFailed
+	case errors.Is(err, apierrors.ErrIfMatchRequired):
+		return fiber.StatusPreconditionRequired
+	default:
+		return 0
+	}
+}
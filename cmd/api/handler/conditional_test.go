@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"errors"
+	"github.com/ferch5003/go-fiber-tutorial/internal/apierrors"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+	"testing"
+)
+
+func newConditionalCtx(t *testing.T, header string, value string) *fiber.Ctx {
+	t.Helper()
+
+	app := fiber.New()
+
+	c := app.AcquireCtx(&fasthttp.RequestCtx{})
+	t.Cleanup(func() {
+		app.ReleaseCtx(c)
+	})
+
+	if value != "" {
+		c.Request().Header.Set(header, value)
+	}
+
+	return c
+}
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		expected    bool
+	}{
+		{name: "WithoutHeader", ifNoneMatch: "", expected: false},
+		{name: "SameVersion", ifNoneMatch: `"3"`, expected: true},
+		{name: "WeakSameVersion", ifNoneMatch: `W/"3"`, expected: true},
+		{name: "AnyOfList", ifNoneMatch: `"1", "3"`, expected: true},
+		{name: "Wildcard", ifNoneMatch: "*", expected: true},
+		{name: "OtherVersion", ifNoneMatch: `"2"`, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			c := newConditionalCtx(t, fiber.HeaderIfNoneMatch, tt.ifNoneMatch)
+
+			// When
+			obtained := notModified(c, 3)
+
+			// Then
+			require.Equal(t, tt.expected, obtained)
+			require.Equal(t, `"3"`, string(c.Response().Header.Peek(fiber.HeaderETag)))
+		})
+	}
+}
+
+func TestIfMatchVersion(t *testing.T) {
+	tests := []struct {
+		name            string
+		ifMatch         string
+		required        bool
+		expectedVersion int
+		expectedErr     error
+	}{
+		{name: "WithoutHeader", ifMatch: "", expectedVersion: 0},
+		{name: "WithoutRequiredHeader", ifMatch: "", required: true, expectedErr: apierrors.ErrIfMatchRequired},
+		{name: "Wildcard", ifMatch: "*", required: true, expectedVersion: 0},
+		{name: "Version", ifMatch: `"4"`, expectedVersion: 4},
+		{name: "WeakVersion", ifMatch: `W/"4"`, expectedErr: domain.ErrVersionMismatch},
+		{name: "UnquotedVersion", ifMatch: "4", expectedErr: domain.ErrVersionMismatch},
+		{name: "ZeroVersion", ifMatch: `"0"`, expectedErr: domain.ErrVersionMismatch},
+		{name: "NotAVersion", ifMatch: `"abc"`, expectedErr: domain.ErrVersionMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			c := newConditionalCtx(t, fiber.HeaderIfMatch, tt.ifMatch)
+
+			// When
+			version, err := ifMatchVersion(c, tt.required)
+
+			// Then
+			require.ErrorIs(t, err, tt.expectedErr)
+			require.Equal(t, tt.expectedVersion, version)
+		})
+	}
+}
+
+func TestPreconditionStatus(t *testing.T) {
+	require.Equal(t, fiber.StatusPreconditionFailed, preconditionStatus(domain.ErrVersionMismatch))
+	require.Equal(t, fiber.StatusPreconditionRequired, preconditionStatus(apierrors.ErrIfMatchRequired))
+	require.Zero(t, preconditionStatus(errors.New("sql: no rows in result set")))
+}
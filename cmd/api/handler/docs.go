@@ -0,0 +1,570 @@
+package handler
+
+import (
+	_ "embed"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/middlewares"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/health"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/jsonpatch"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/openapi"
+	"github.com/ferch5003/go-fiber-tutorial/internal/todo"
+	"github.com/gofiber/fiber/v2"
+	"sync"
+)
+
+const _apiVersion = "1.0.0"
+
+//go:embed static/swagger.html
+var _swaggerUI []byte
+
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+type messageBody struct {
+	Message string `json:"message"`
+}
+
+type statusBody struct {
+	Status string `json:"status"`
+}
+
+// Operations documents every named route of the API.
+func Operations() map[string]openapi.Operation {
+	unauthorized := openapi.Response{Description: "Missing or invalid token", Body: errorBody{}}
+	badRequest := openapi.Response{Description: "Invalid request", Body: errorBody{}}
+	internalError := openapi.Response{Description: "Unexpected error", Body: errorBody{}}
+	notModified := openapi.Response{Description: "The ETag sent in If-None-Match is current"}
+	preconditionFailed := openapi.Response{Description: "The If-Match ETag is not current", Body: errorBody{}}
+	preconditionRequired := openapi.Response{Description: "The If-Match header is required", Body: errorBody{}}
+	todoNotFound := openapi.Response{Description: "The todo does not exist", Body: errorBody{}}
+	invalidRecurrence := openapi.Response{
+		Description: "The recurrence is invalid, or can not be changed like this",
+		Body:        errorBody{},
+	}
+	idempotencyConflict := openapi.Response{
+		Description: "A request with the same Idempotency-Key is in flight",
+		Body:        errorBody{},
+	}
+
+	return map[string]openapi.Operation{
+		// General.
+		"health": {
+			Summary: "Check that the application is running",
+			Tags:    []string{"health"},
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK: {ContentType: fiber.MIMETextPlainCharsetUTF8},
+			},
+		},
+		"health.livez": {
+			Summary: "Liveness probe",
+			Tags:    []string{"health"},
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK: {Body: statusBody{}},
+			},
+		},
+		"health.readyz": {
+			Summary: "Readiness probe checking every dependency",
+			Tags:    []string{"health"},
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK:                 {Body: health.Report{}},
+				fiber.StatusServiceUnavailable: {Description: "A required dependency is down", Body: health.Report{}},
+			},
+		},
+		"metrics": {
+			Summary: "Prometheus metrics",
+			Tags:    []string{"observability"},
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK:           {ContentType: fiber.MIMETextPlain},
+				fiber.StatusUnauthorized: {Description: "Missing or invalid credentials"},
+			},
+		},
+		"docs.openapi": {
+			Summary: "OpenAPI document of the API",
+			Tags:    []string{"docs"},
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK: {Body: map[string]any{}},
+			},
+		},
+		"docs.ui": {
+			Summary: "Swagger UI",
+			Tags:    []string{"docs"},
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK: {ContentType: fiber.MIMETextHTMLCharsetUTF8},
+			},
+		},
+
+		// Users.
+		"users.get": {
+			Summary: "Get a user",
+			Tags:    []string{"users"},
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK:                  {Body: showUser{}},
+				fiber.StatusNotModified:         notModified,
+				fiber.StatusBadRequest:          badRequest,
+				fiber.StatusInternalServerError: internalError,
+			},
+		},
+		"users.register": {
+			Summary: "Register a user",
+			Tags:    []string{"users"},
+			Headers: []string{middlewares.HeaderIdempotencyKey},
+			Request: registerUser{},
+			Responses: map[int]openapi.Response{
+				fiber.StatusCreated:      {Body: showUser{}},
+				fiber.StatusBadRequest:   badRequest,
+				fiber.StatusUnauthorized: unauthorized,
+				fiber.StatusConflict:     idempotencyConflict,
+				fiber.StatusUnprocessableEntity: {
+					Description: "User can not be created, or the Idempotency-Key was used with another request",
+					Body:        errorBody{},
+				},
+			},
+		},
+		"users.login": {
+			Summary: "Log in a user",
+			Tags:    []string{"users"},
+			Request: loginUser{},
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK:                  {Body: showUser{}},
+				fiber.StatusBadRequest:          badRequest,
+				fiber.StatusUnauthorized:        {Description: "Email or Password are incorrect", Body: errorBody{}},
+				fiber.StatusInternalServerError: internalError,
+			},
+		},
+		"users.update": {
+			Summary: "Update the authenticated user",
+			Tags:    []string{"users"},
+			Secured: true,
+			Request: updateUser{},
+			Requests: map[string]any{
+				jsonpatch.MergePatchContentType: patchableUser{},
+				jsonpatch.PatchContentType:      jsonpatch.Patch{},
+			},
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK:                   {Body: showUser{}},
+				fiber.StatusBadRequest:           badRequest,
+				fiber.StatusUnauthorized:         unauthorized,
+				fiber.StatusConflict:             {Description: "A test operation failed", Body: errorBody{}},
+				fiber.StatusPreconditionFailed:   preconditionFailed,
+				fiber.StatusPreconditionRequired: preconditionRequired,
+				fiber.StatusUnprocessableEntity:  {Description: "User can not be updated", Body: errorBody{}},
+				fiber.StatusInternalServerError:  internalError,
+			},
+		},
+		"users.delete": {
+			Summary: "Delete the authenticated user",
+			Tags:    []string{"users"},
+			Secured: true,
+			Responses: map[int]openapi.Response{
+				fiber.StatusNoContent:            {Description: "User deleted"},
+				fiber.StatusBadRequest:           badRequest,
+				fiber.StatusUnauthorized:         unauthorized,
+				fiber.StatusPreconditionFailed:   preconditionFailed,
+				fiber.StatusPreconditionRequired: preconditionRequired,
+				fiber.StatusInternalServerError:  internalError,
+			},
+		},
+
+		// Todos.
+		"todos.get_all": {
+			Summary: "List the todos of the authenticated user",
+			Tags:    []string{"todos"},
+			Secured: true,
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK:                  {Body: []domain.Todo{}},
+				fiber.StatusUnauthorized:        unauthorized,
+				fiber.StatusInternalServerError: internalError,
+			},
+		},
+		"todos.get": {
+			Summary: "Get a todo",
+			Tags:    []string{"todos"},
+			Secured: true,
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK:                  {Body: domain.Todo{}},
+				fiber.StatusNotModified:         notModified,
+				fiber.StatusBadRequest:          badRequest,
+				fiber.StatusUnauthorized:        unauthorized,
+				fiber.StatusNotFound:            todoNotFound,
+				fiber.StatusInternalServerError: internalError,
+			},
+		},
+		"todos.search": {
+			Summary: "Search the todos of the authenticated user by relevance",
+			Tags:    []string{"todos"},
+			Secured: true,
+			Query:   searchTodos{},
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK:                  {Body: domain.TodoSearchPage{}},
+				fiber.StatusBadRequest:          badRequest,
+				fiber.StatusUnauthorized:        unauthorized,
+				fiber.StatusInternalServerError: internalError,
+			},
+		},
+		"todos.save": {
+			Summary: "Create a todo for the authenticated user",
+			Tags:    []string{"todos"},
+			Secured: true,
+			Headers: []string{middlewares.HeaderIdempotencyKey},
+			Request: domain.Todo{},
+			Responses: map[int]openapi.Response{
+				fiber.StatusCreated:      {Body: domain.Todo{}},
+				fiber.StatusBadRequest:   badRequest,
+				fiber.StatusUnauthorized: unauthorized,
+				fiber.StatusConflict:     idempotencyConflict,
+				fiber.StatusUnprocessableEntity: {
+					Description: "Todo can not be created, or the Idempotency-Key was used with another request",
+					Body:        errorBody{},
+				},
+			},
+		},
+		"todos.batch": {
+			Summary: "Create, update, complete and delete todos of the authenticated user in one transaction",
+			Tags:    []string{"todos"},
+			Secured: true,
+			Headers: []string{middlewares.HeaderIdempotencyKey},
+			Request: batchTodos{},
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK:           {Description: "Every operation was applied", Body: batchResults{}},
+				fiber.StatusMultiStatus:  {Description: "Some operations of a best effort batch failed", Body: batchResults{}},
+				fiber.StatusBadRequest:   badRequest,
+				fiber.StatusUnauthorized: unauthorized,
+				fiber.StatusConflict:     idempotencyConflict,
+				fiber.StatusUnprocessableEntity: {
+					Description: "An operation of an atomic batch failed and none was applied",
+					Body:        batchResults{},
+				},
+				fiber.StatusInternalServerError: internalError,
+			},
+		},
+		"todos.export": {
+			Summary: "Download the todos of the authenticated user as a JSON, CSV or iCalendar file",
+			Tags:    []string{"todos"},
+			Secured: true,
+			Query:   exportTodos{},
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK: {
+					Description: "An array of todos, a CSV file with a header row, or a VCALENDAR of VTODOs",
+					ContentType: todo.FormatJSON.ContentType(),
+					Body:        []todo.ExportedTodo{},
+				},
+				fiber.StatusBadRequest:          {Description: "The format is unknown", Body: errorBody{}},
+				fiber.StatusUnauthorized:        unauthorized,
+				fiber.StatusInternalServerError: internalError,
+			},
+		},
+		"todos.import": {
+			Summary: "Create todos for the authenticated user from a JSON, CSV or iCalendar file in one transaction",
+			Tags:    []string{"todos"},
+			Secured: true,
+			Headers: []string{middlewares.HeaderIdempotencyKey},
+			Requests: map[string]any{
+				fiber.MIMEMultipartForm: importTodos{},
+			},
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK: {
+					Description: "Every row was imported, or is valid in a dry run",
+					Body:        importResults{},
+				},
+				fiber.StatusMultiStatus: {Description: "Some rows were not imported", Body: importResults{}},
+				fiber.StatusBadRequest: {
+					Description: "The file is missing, of an unknown format, or can not be read",
+					Body:        errorBody{},
+				},
+				fiber.StatusUnauthorized: unauthorized,
+				fiber.StatusConflict: {
+					Description: "A request with the same Idempotency-Key is in flight, or a concurrent import " +
+						"saved the same external IDs",
+					Body: errorBody{},
+				},
+				fiber.StatusUnprocessableEntity: {Description: "No row was imported", Body: importResults{}},
+				fiber.StatusInternalServerError: internalError,
+			},
+		},
+		"todos.completed": {
+			Summary: "Mark a todo as completed",
+			Tags:    []string{"todos"},
+			Secured: true,
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK:                   {Body: messageBody{}},
+				fiber.StatusBadRequest:           badRequest,
+				fiber.StatusUnauthorized:         unauthorized,
+				fiber.StatusPreconditionFailed:   preconditionFailed,
+				fiber.StatusPreconditionRequired: preconditionRequired,
+				fiber.StatusForbidden:            {Description: "The todo belongs to another user", Body: errorBody{}},
+				fiber.StatusNotFound:             todoNotFound,
+				fiber.StatusInternalServerError:  internalError,
+			},
+		},
+		"todos.update": {
+			Summary: "Change a todo, or the occurrences of its series selected by scope",
+			Tags:    []string{"todos"},
+			Secured: true,
+			Request: updateTodo{},
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK:                   {Body: domain.Todo{}},
+				fiber.StatusBadRequest:           badRequest,
+				fiber.StatusUnauthorized:         unauthorized,
+				fiber.StatusPreconditionFailed:   preconditionFailed,
+				fiber.StatusPreconditionRequired: preconditionRequired,
+				fiber.StatusForbidden:            {Description: "The todo belongs to another user", Body: errorBody{}},
+				fiber.StatusNotFound:             todoNotFound,
+				fiber.StatusUnprocessableEntity:  invalidRecurrence,
+				fiber.StatusInternalServerError:  internalError,
+			},
+		},
+		"todos.skip": {
+			Summary: "Move the pending occurrence of a recurring todo to the date of the next one",
+			Tags:    []string{"todos"},
+			Secured: true,
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK:                   {Body: domain.Todo{}},
+				fiber.StatusBadRequest:           badRequest,
+				fiber.StatusUnauthorized:         unauthorized,
+				fiber.StatusPreconditionFailed:   preconditionFailed,
+				fiber.StatusPreconditionRequired: preconditionRequired,
+				fiber.StatusForbidden:            {Description: "The todo belongs to another user", Body: errorBody{}},
+				fiber.StatusNotFound:             todoNotFound,
+				fiber.StatusUnprocessableEntity:  invalidRecurrence,
+				fiber.StatusInternalServerError:  internalError,
+			},
+		},
+		"todos.end_series": {
+			Summary: "Make the pending occurrence of a recurring todo the last one",
+			Tags:    []string{"todos"},
+			Secured: true,
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK:                   {Body: domain.Todo{}},
+				fiber.StatusBadRequest:           badRequest,
+				fiber.StatusUnauthorized:         unauthorized,
+				fiber.StatusPreconditionFailed:   preconditionFailed,
+				fiber.StatusPreconditionRequired: preconditionRequired,
+				fiber.StatusForbidden:            {Description: "The todo belongs to another user", Body: errorBody{}},
+				fiber.StatusNotFound:             todoNotFound,
+				fiber.StatusUnprocessableEntity:  invalidRecurrence,
+				fiber.StatusInternalServerError:  internalError,
+			},
+		},
+		"todos.move": {
+			Summary: "Place a todo right before or after another todo of the user",
+			Tags:    []string{"todos"},
+			Secured: true,
+			Request: moveTodo{},
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK:                   {Body: domain.Todo{}},
+				fiber.StatusBadRequest:           badRequest,
+				fiber.StatusUnauthorized:         unauthorized,
+				fiber.StatusPreconditionFailed:   preconditionFailed,
+				fiber.StatusPreconditionRequired: preconditionRequired,
+				fiber.StatusForbidden:            {Description: "The todo belongs to another user", Body: errorBody{}},
+				fiber.StatusNotFound:             todoNotFound,
+				fiber.StatusUnprocessableEntity: {
+					Description: "The neighbour todo is missing, or is the moved todo",
+					Body:        errorBody{},
+				},
+				fiber.StatusInternalServerError: internalError,
+			},
+		},
+		"todos.delete": {
+			Summary: "Delete a todo",
+			Tags:    []string{"todos"},
+			Secured: true,
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK:                   {Body: messageBody{}},
+				fiber.StatusBadRequest:           badRequest,
+				fiber.StatusUnauthorized:         unauthorized,
+				fiber.StatusPreconditionFailed:   preconditionFailed,
+				fiber.StatusPreconditionRequired: preconditionRequired,
+				fiber.StatusForbidden:            {Description: "The todo belongs to another user", Body: errorBody{}},
+				fiber.StatusNotFound:             todoNotFound,
+				fiber.StatusInternalServerError:  internalError,
+			},
+		},
+		"todos.history": {
+			Summary: "List the changes of a todo, from the oldest",
+			Tags:    []string{"todos", "audit"},
+			Secured: true,
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK:                  {Body: []domain.AuditEvent{}},
+				fiber.StatusBadRequest:          badRequest,
+				fiber.StatusUnauthorized:        unauthorized,
+				fiber.StatusForbidden:           {Description: "The todo belongs to another user", Body: errorBody{}},
+				fiber.StatusNotFound:            todoNotFound,
+				fiber.StatusInternalServerError: internalError,
+			},
+		},
+		"todos.stream": {
+			Summary: "Stream the changes of the todos of the authenticated user as Server-Sent Events",
+			Tags:    []string{"todos"},
+			Secured: true,
+			Headers: []string{HeaderLastEventID},
+			Query:   resumeStream{},
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK: {
+					Description: "Events named after their type, whose data is the event",
+					ContentType: _eventStreamContentType,
+					Body:        domain.TodoEvent{},
+				},
+				fiber.StatusBadRequest:          {Description: "The Last-Event-ID is invalid", Body: errorBody{}},
+				fiber.StatusUnauthorized:        unauthorized,
+				fiber.StatusInternalServerError: internalError,
+			},
+		},
+		"todos.socket": {
+			Summary: "Stream the changes of the todos of the authenticated user over a WebSocket",
+			Tags:    []string{"todos"},
+			Secured: true,
+			Headers: []string{HeaderLastEventID},
+			Query:   resumeStream{},
+			Responses: map[int]openapi.Response{
+				fiber.StatusSwitchingProtocols: {Description: "Every event is sent as a JSON text message"},
+				fiber.StatusUnauthorized:       unauthorized,
+				fiber.StatusUpgradeRequired:    {Description: "The request is not a WebSocket upgrade", Body: errorBody{}},
+			},
+		},
+
+		// Webhooks.
+		"webhooks.get_all": {
+			Summary: "List the webhooks of the authenticated user, from the oldest",
+			Tags:    []string{"webhooks"},
+			Secured: true,
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK:                  {Body: []domain.Webhook{}},
+				fiber.StatusUnauthorized:        unauthorized,
+				fiber.StatusInternalServerError: internalError,
+			},
+		},
+		"webhooks.create": {
+			Summary: "Register an endpoint notified of the events it subscribes to",
+			Tags:    []string{"webhooks"},
+			Secured: true,
+			Request: createWebhook{},
+			Responses: map[int]openapi.Response{
+				fiber.StatusCreated: {
+					Description: "The only response with the secret that signs the deliveries",
+					Body:        domain.Webhook{},
+				},
+				fiber.StatusBadRequest:   badRequest,
+				fiber.StatusUnauthorized: unauthorized,
+				fiber.StatusUnprocessableEntity: {
+					Description: "Webhook can not be created, or its URL points to a local address",
+					Body:        errorBody{},
+				},
+			},
+		},
+		"webhooks.update": {
+			Summary: "Change the URL, events or state of a webhook, enabling it forgets its failures",
+			Tags:    []string{"webhooks"},
+			Secured: true,
+			Request: updateWebhook{},
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK:           {Body: domain.Webhook{}},
+				fiber.StatusBadRequest:   badRequest,
+				fiber.StatusUnauthorized: unauthorized,
+				fiber.StatusForbidden:    {Description: "The webhook belongs to another user", Body: errorBody{}},
+				fiber.StatusNotFound:     {Description: "The webhook does not exist", Body: errorBody{}},
+				fiber.StatusUnprocessableEntity: {
+					Description: "Webhook can not be updated, or its URL points to a local address",
+					Body:        errorBody{},
+				},
+				fiber.StatusInternalServerError: internalError,
+			},
+		},
+		"webhooks.delete": {
+			Summary: "Delete a webhook along with its deliveries",
+			Tags:    []string{"webhooks"},
+			Secured: true,
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK:                  {Body: messageBody{}},
+				fiber.StatusBadRequest:          badRequest,
+				fiber.StatusUnauthorized:        unauthorized,
+				fiber.StatusForbidden:           {Description: "The webhook belongs to another user", Body: errorBody{}},
+				fiber.StatusNotFound:            {Description: "The webhook does not exist", Body: errorBody{}},
+				fiber.StatusInternalServerError: internalError,
+			},
+		},
+		"webhooks.deliveries": {
+			Summary: "List the last deliveries of a webhook, from the newest",
+			Tags:    []string{"webhooks"},
+			Secured: true,
+			Query:   listDeliveries{},
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK:                  {Body: []domain.WebhookDelivery{}},
+				fiber.StatusBadRequest:          badRequest,
+				fiber.StatusUnauthorized:        unauthorized,
+				fiber.StatusForbidden:           {Description: "The webhook belongs to another user", Body: errorBody{}},
+				fiber.StatusNotFound:            {Description: "The webhook does not exist", Body: errorBody{}},
+				fiber.StatusInternalServerError: internalError,
+			},
+		},
+		"webhooks.redeliver": {
+			Summary: "Send the event of a delivery again, as a new delivery attempted in the background",
+			Tags:    []string{"webhooks"},
+			Secured: true,
+			Responses: map[int]openapi.Response{
+				fiber.StatusAccepted:            {Body: domain.WebhookDelivery{}},
+				fiber.StatusBadRequest:          badRequest,
+				fiber.StatusUnauthorized:        unauthorized,
+				fiber.StatusForbidden:           {Description: "The webhook belongs to another user", Body: errorBody{}},
+				fiber.StatusNotFound:            {Description: "The webhook or the delivery does not exist", Body: errorBody{}},
+				fiber.StatusConflict:            {Description: "The webhook is disabled", Body: errorBody{}},
+				fiber.StatusInternalServerError: internalError,
+			},
+		},
+
+		// Admin.
+		"admin.audit": {
+			Summary: "List the audit events of every user, from the newest",
+			Tags:    []string{"audit"},
+			Secured: true,
+			Query:   auditFeed{},
+			Responses: map[int]openapi.Response{
+				fiber.StatusOK:                  {Body: domain.AuditPage{}},
+				fiber.StatusBadRequest:          badRequest,
+				fiber.StatusUnauthorized:        unauthorized,
+				fiber.StatusForbidden:           {Description: "The user is not an admin", Body: errorBody{}},
+				fiber.StatusInternalServerError: internalError,
+			},
+		},
+	}
+}
+
+type DocsHandler struct {
+	app      *fiber.App
+	info     openapi.Info
+	once     *sync.Once
+	document *openapi.Document
+}
+
+func NewDocsHandler(app *fiber.App, config *config.EnvVars) *DocsHandler {
+	return &DocsHandler{
+		app: app,
+		info: openapi.Info{
+			Title:   config.AppName,
+			Version: _apiVersion,
+		},
+		once: &sync.Once{},
+	}
+}
+
+// Document builds the OpenAPI document from the route table. It is built once, on first use, because
+// routes are registered when the server starts.
+func (h *DocsHandler) Document() *openapi.Document {
+	h.once.Do(func() {
+		h.document = openapi.NewDocument(h.info, h.app.GetRoutes(true), Operations())
+	})
+
+	return h.document
+}
+
+func (h *DocsHandler) OpenAPI(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(h.Document())
+}
+
+func (h *DocsHandler) SwaggerUI(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+
+	return c.Status(fiber.StatusOK).Send(_swaggerUI)
+}
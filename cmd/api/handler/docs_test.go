@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"encoding/json"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/openapi"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func createDocsServer() *fiber.App {
+	app := fiber.New()
+
+	docsHandler := NewDocsHandler(app, &config.EnvVars{AppName: "test"})
+
+	app.Get("/openapi.json", docsHandler.OpenAPI).Name("docs.openapi")
+	app.Get("/docs", docsHandler.SwaggerUI).Name("docs.ui")
+
+	return app
+}
+
+func TestDocsHandlerOpenAPI_Successful(t *testing.T) {
+	// Given
+	server := createDocsServer()
+
+	req := httptest.NewRequest(fiber.MethodGet, "/openapi.json", nil)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var document openapi.Document
+	err = json.Unmarshal(body, &document)
+	require.NoError(t, err)
+	require.Equal(t, openapi.Version, document.OpenAPI)
+	require.Equal(t, "test", document.Info.Title)
+	require.Contains(t, document.Paths, "/openapi.json")
+	require.Contains(t, document.Paths, "/docs")
+}
+
+func TestDocsHandlerSwaggerUI_Successful(t *testing.T) {
+	// Given
+	server := createDocsServer()
+
+	req := httptest.NewRequest(fiber.MethodGet, "/docs", nil)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, fiber.MIMETextHTMLCharsetUTF8, resp.Header.Get(fiber.HeaderContentType))
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.True(t, strings.Contains(string(body), "/openapi.json"))
+}
@@ -2,14 +2,33 @@ package handler
 
 import (
 	"github.com/ferch5003/go-fiber-tutorial/internal/apierrors"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
+	"github.com/ferch5003/go-fiber-tutorial/internal/middlewares"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/logging"
 	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"go.uber.org/zap"
 	"strconv"
 	"strings"
 )
 
+// getAuthUserID obtains the authenticated user, enriching the request logger with it and making it the
+// actor of the audit events of the request.
 func getAuthUserID(c *fiber.Ctx, sessionService session.Service, sessionType string) (int, error) {
+	userID, err := parseAuthUserID(c, sessionService, sessionType)
+	if err != nil {
+		return 0, err
+	}
+
+	c.Locals(middlewares.UserIDKey, userID)
+	c.SetUserContext(audit.WithActor(c.UserContext(), userID))
+	logging.Enrich(c, zap.String("route", c.Route().Name), zap.Int("user_id", userID))
+
+	return userID, nil
+}
+
+func parseAuthUserID(c *fiber.Ctx, sessionService session.Service, sessionType string) (int, error) {
 	switch sessionType {
 	case "fiber":
 		user, ok := c.Locals("user").(*jwt.Token)
@@ -35,7 +54,7 @@ func getAuthUserID(c *fiber.Ctx, sessionService session.Service, sessionType str
 			return 0, apierrors.ErrAuthUserNotFound
 		}
 
-		data, err := sessionService.GetSession(c.Context(), headerToken)
+		data, err := sessionService.GetSession(c.UserContext(), headerToken)
 		if err != nil {
 			return 0, apierrors.ErrAuthUserNotFound
 		}
@@ -3,8 +3,17 @@ package handler
 import (
 	"context"
 	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/middlewares"
 	"github.com/ferch5003/go-fiber-tutorial/internal/platform/jwtauth"
-	"github.com/stretchr/testify/mock"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/logging"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"net/http/httptest"
+	"testing"
 )
 
 var _testConfigs = &config.EnvVars{
@@ -36,16 +45,41 @@ func getTestUserSession() (string, error) {
 	return token, nil
 }
 
-type sessionServiceMock struct {
-	mock.Mock
-}
+func TestGetAuthUserID_SuccessfulEnrichesRequestLogger(t *testing.T) {
+	// Given
+	core, logs := observer.New(zapcore.InfoLevel)
 
-func (ssm *sessionServiceMock) SetSession(ctx context.Context, token string, claims map[string]any) error {
-	args := ssm.Called(ctx, token, claims)
-	return args.Error(0)
-}
+	sessionService := session.NewService(session.NewMemoryRepository())
+	err := sessionService.SetSession(context.Background(), "token", map[string]any{"sub": 1})
+	require.NoError(t, err)
+
+	var userID int
+
+	app := fiber.New()
+	app.Use(middlewares.LoggerMiddleware(zap.New(core)))
+	app.Get("/todos", func(c *fiber.Ctx) error {
+		var err error
+		userID, err = getAuthUserID(c, sessionService, "app")
+		if err != nil {
+			return err
+		}
+
+		logging.FromContext(c.UserContext()).Info("from service")
+
+		return c.SendStatus(fiber.StatusOK)
+	}).Name("todos.get_all")
+
+	req := httptest.NewRequest(fiber.MethodGet, "/todos", nil)
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer token")
+
+	// When
+	resp, err := app.Test(req)
 
-func (ssm *sessionServiceMock) GetSession(ctx context.Context, token string) (map[string]string, error) {
-	args := ssm.Called(ctx, token)
-	return args.Get(0).(map[string]string), args.Error(1)
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, 1, userID)
+	require.Equal(t, 1, logs.Len())
+	require.Equal(t, "todos.get_all", logs.All()[0].ContextMap()["route"])
+	require.Equal(t, int64(1), logs.All()[0].ContextMap()["user_id"])
 }
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/health"
+	"github.com/gofiber/fiber/v2"
+)
+
+type HealthHandler struct {
+	healthService health.Service
+}
+
+func NewHealthHandler(healthService health.Service) *HealthHandler {
+	return &HealthHandler{
+		healthService: healthService,
+	}
+}
+
+// Liveness only confirms that the process is able to serve requests.
+func (h *HealthHandler) Liveness(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "ok",
+	})
+}
+
+// Readiness confirms that every required dependency is reachable.
+func (h *HealthHandler) Readiness(c *fiber.Ctx) error {
+	report := h.healthService.Readiness(c.UserContext())
+	if !report.Ready {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(report)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(report)
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/health"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http/httptest"
+	"testing"
+)
+
+type healthServiceMock struct {
+	mock.Mock
+}
+
+func (hsm *healthServiceMock) Readiness(ctx context.Context) health.Report {
+	args := hsm.Called(ctx)
+	return args.Get(0).(health.Report)
+}
+
+func createHealthServer(hsm *healthServiceMock) *fiber.App {
+	app := fiber.New()
+
+	healthHandler := NewHealthHandler(hsm)
+
+	app.Get("/livez", healthHandler.Liveness).Name("health.livez")
+	app.Get("/readyz", healthHandler.Readiness).Name("health.readyz")
+
+	return app
+}
+
+func TestHealthHandlerLiveness_Successful(t *testing.T) {
+	// Given
+	hsm := new(healthServiceMock)
+
+	server := createHealthServer(hsm)
+
+	req := httptest.NewRequest(fiber.MethodGet, "/livez", nil)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	hsm.AssertNotCalled(t, "Readiness", mock.Anything)
+}
+
+func TestHealthHandlerReadiness_Successful(t *testing.T) {
+	// Given
+	expectedReport := health.Report{
+		Ready: true,
+		Components: map[string]health.ComponentStatus{
+			"mysql": {Status: health.StatusUp, Required: true, LatencyMS: 1.5},
+			"redis": {Status: health.StatusUp, Required: true, LatencyMS: 0.5},
+		},
+	}
+
+	hsm := new(healthServiceMock)
+	hsm.On("Readiness", mock.Anything).Return(expectedReport)
+
+	server := createHealthServer(hsm)
+
+	req := httptest.NewRequest(fiber.MethodGet, "/readyz", nil)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var report health.Report
+	err = json.Unmarshal(body, &report)
+	require.NoError(t, err)
+
+	require.Equal(t, expectedReport, report)
+}
+
+func TestHealthHandlerReadiness_FailsDueToComponentDown(t *testing.T) {
+	// Given
+	expectedReport := health.Report{
+		Ready: false,
+		Components: map[string]health.ComponentStatus{
+			"mysql": {Status: health.StatusDown, Required: true, LatencyMS: 2000, Error: "context deadline exceeded"},
+		},
+	}
+
+	hsm := new(healthServiceMock)
+	hsm.On("Readiness", mock.Anything).Return(expectedReport)
+
+	server := createHealthServer(hsm)
+
+	req := httptest.NewRequest(fiber.MethodGet, "/readyz", nil)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var report health.Report
+	err = json.Unmarshal(body, &report)
+	require.NoError(t, err)
+
+	require.Equal(t, expectedReport, report)
+}
@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/logging"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/pubsub"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	// HeaderLastEventID is sent by the clients resuming a stream, with the ID of the last event they
+	// received.
+	HeaderLastEventID = "Last-Event-ID"
+
+	// _eventStreamContentType is the content type of Server-Sent Events.
+	_eventStreamContentType = "text/event-stream"
+
+	// _keepAliveInterval is how often a comment is sent on an idle stream, which keeps proxies from
+	// closing it and detects the clients that left.
+	_keepAliveInterval = 15 * time.Second
+)
+
+type StreamHandler struct {
+	sessionType    string
+	broker         pubsub.Broker
+	sessionService session.Service
+}
+
+func NewStreamHandler(cfg *config.EnvVars, broker pubsub.Broker, sessionService session.Service) *StreamHandler {
+	return &StreamHandler{
+		sessionType:    cfg.AppSessionType,
+		broker:         broker,
+		sessionService: sessionService,
+	}
+}
+
+// resumeStream is the query of the clients resuming a stream that can not send the Last-Event-ID header.
+type resumeStream struct {
+	LastEventID string `query:"last_event_id"`
+}
+
+// lastEventID is where the client resumes the stream from. Browsers send the header when an EventSource
+// reconnects, other clients may use the query parameter.
+func lastEventID(c *fiber.Ctx) string {
+	if id := c.Get(HeaderLastEventID); id != "" {
+		return id
+	}
+
+	return c.Query("last_event_id")
+}
+
+// Events streams the changes of the todos of the authenticated user as Server-Sent Events, named after
+// their type. The stream resumes after the event of the Last-Event-ID header.
+func (h *StreamHandler) Events(c *fiber.Ctx) error {
+	userID, err := getAuthUserID(c, h.sessionService, h.sessionType)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// The stream outlives the handler, so it ends with the subscription instead of the request.
+	ctx, cancel := context.WithCancel(context.WithoutCancel(c.UserContext()))
+
+	events, err := h.broker.Subscribe(ctx, userID, lastEventID(c))
+	if err != nil {
+		cancel()
+
+		status := fiber.StatusInternalServerError
+		if errors.Is(err, domain.ErrInvalidEventID) {
+			status = fiber.StatusBadRequest
+		}
+
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, _eventStreamContentType)
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	logger := logging.FromCtx(c)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		keepAlive := time.NewTicker(_keepAliveInterval)
+		defer keepAlive.Stop()
+
+		// Sending a comment first flushes the headers, so the client knows the stream started.
+		if err := writeComment(w, "connected"); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+
+				if err := writeEvent(w, event); err != nil {
+					logger.Debug("Event stream closed", zap.Error(err))
+					return
+				}
+			case <-keepAlive.C:
+				if err := writeComment(w, "keep-alive"); err != nil {
+					logger.Debug("Event stream closed", zap.Error(err))
+					return
+				}
+			}
+		}
+	})
+
+	return nil
+}
+
+// writeEvent writes the event in the text/event-stream format, flushing it to the client.
+func writeEvent(w *bufio.Writer, event domain.TodoEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// writeComment writes a comment line, which clients ignore, flushing it to the client.
+func writeComment(w *bufio.Writer, comment string) error {
+	if _, err := fmt.Fprintf(w, ": %s\n\n", comment); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// WebSocket upgrades the request to a WebSocket that sends the changes of the todos of the
+// authenticated user as JSON text messages. Like Events, it resumes after the Last-Event-ID header or
+// the last_event_id query parameter.
+func (h *StreamHandler) WebSocket(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return c.Status(fiber.StatusUpgradeRequired).JSON(fiber.Map{
+			"error": "The request must upgrade to a WebSocket",
+		})
+	}
+
+	userID, err := getAuthUserID(c, h.sessionService, h.sessionType)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	fromID := lastEventID(c)
+	baseCtx := context.WithoutCancel(c.UserContext())
+	logger := logging.FromCtx(c)
+
+	// The connection is served after the handler returns, once it is upgraded.
+	return websocket.New(func(conn *websocket.Conn) {
+		ctx, cancel := context.WithCancel(baseCtx)
+		defer cancel()
+
+		events, err := h.broker.Subscribe(ctx, userID, fromID)
+		if err != nil {
+			closeCode := websocket.CloseInternalServerErr
+			if errors.Is(err, domain.ErrInvalidEventID) {
+				closeCode = websocket.ClosePolicyViolation
+			}
+
+			closeWebSocket(conn, closeCode, err.Error())
+
+			return
+		}
+
+		// Clients do not send messages, reading only detects when they close the connection.
+		readDone := make(chan struct{})
+		go func() {
+			defer close(readDone)
+			defer cancel()
+
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		h.sendEvents(ctx, conn, events, logger)
+
+		// The connection is released once the handler returns, so the reads must stop before.
+		_ = conn.Close()
+		<-readDone
+	})(c)
+}
+
+// sendEvents writes the events to the WebSocket until the subscription ends.
+func (h *StreamHandler) sendEvents(
+	ctx context.Context,
+	conn *websocket.Conn,
+	events <-chan domain.TodoEvent,
+	logger *zap.Logger) {
+	for event := range events {
+		if err := conn.WriteJSON(event); err != nil {
+			logger.Debug("WebSocket closed", zap.Error(err))
+			return
+		}
+	}
+
+	// The subscription ended without the client leaving: the server is shutting down or the client
+	// fell behind, both resume from the last event.
+	if ctx.Err() == nil {
+		closeWebSocket(conn, websocket.CloseGoingAway, "")
+	}
+}
+
+// closeWebSocket sends a close message to the client, before the connection is closed.
+func closeWebSocket(conn *websocket.Conn, closeCode int, text string) {
+	_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(closeCode, text),
+		time.Now().Add(time.Second))
+}
@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/fasthttp/websocket"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/middlewares"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/pubsub"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	_streamPath = "/todos/stream"
+	_socketPath = "/todos/ws"
+)
+
+func createStreamServer(cfg *config.EnvVars, broker pubsub.Broker) *fiber.App {
+	app := fiber.New()
+
+	// Sessions are stored by the JWT middleware, like in the application.
+	sessionService := session.NewService(session.NewMemoryRepository())
+
+	streamHandler := NewStreamHandler(cfg, broker, sessionService)
+
+	jwtMiddleware := middlewares.NewJWTMiddleware(
+		context.Background(),
+		cfg.AppSessionType,
+		cfg.AppSecretKey,
+		sessionService,
+	)
+
+	app.Get(_streamPath, jwtMiddleware.GetMiddleware(), streamHandler.Events).Name("todos.stream")
+	app.Get(_socketPath, jwtMiddleware.GetMiddleware(), streamHandler.WebSocket).Name("todos.socket")
+
+	return app
+}
+
+// listenStreamServer serves the streams on a local port, as they are not answered before they end. It
+// returns the address of the server, which stops after closing the broker when the test finishes.
+func listenStreamServer(t *testing.T, broker pubsub.Broker) string {
+	t.Helper()
+
+	server := createStreamServer(_testConfigs, broker)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		_ = server.Listener(listener)
+	}()
+
+	t.Cleanup(func() {
+		require.NoError(t, broker.Close())
+		require.NoError(t, server.Shutdown())
+	})
+
+	return listener.Addr().String()
+}
+
+// authorizationHeader returns the headers of the test user.
+func authorizationHeader(t *testing.T) http.Header {
+	t.Helper()
+
+	token, err := getTestUserSession()
+	require.NoError(t, err)
+
+	return http.Header{"Authorization": {fmt.Sprintf("Bearer %s", token)}}
+}
+
+// publishTodoEvent publishes an event of a todo of the test user.
+func publishTodoEvent(t *testing.T, broker pubsub.Broker, eventType string, id int) domain.TodoEvent {
+	t.Helper()
+
+	event, err := broker.Publish(context.Background(), domain.TodoEvent{
+		Type:   eventType,
+		UserID: 1,
+		Todo:   domain.Todo{ID: id, Title: "Lorem", Description: "Ipsum", UserID: 1, Version: 1},
+		At:     time.Date(2024, time.August, 1, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	return event
+}
+
+// readServerSentEvent reads the fields of the next event of the stream, skipping comments.
+func readServerSentEvent(t *testing.T, reader *bufio.Reader) map[string]string {
+	t.Helper()
+
+	fields := make(map[string]string)
+
+	for {
+		line, err := reader.ReadString('\n')
+		require.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "" && len(fields) > 0:
+			return fields
+		case line == "" || strings.HasPrefix(line, ":"):
+			continue
+		}
+
+		name, value, _ := strings.Cut(line, ": ")
+		fields[name] = value
+	}
+}
+
+// openEventStream requests the stream of the test user, resuming after lastEventID when it is not empty.
+func openEventStream(t *testing.T, address string, lastEventID string) *bufio.Reader {
+	t.Helper()
+
+	req, err := http.NewRequest(fiber.MethodGet, fmt.Sprintf("http://%s%s", address, _streamPath), nil)
+	require.NoError(t, err)
+
+	req.Header = authorizationHeader(t)
+	if lastEventID != "" {
+		req.Header.Set(HeaderLastEventID, lastEventID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, resp.Body.Close())
+	})
+
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, _eventStreamContentType, resp.Header.Get(fiber.HeaderContentType))
+
+	reader := bufio.NewReader(resp.Body)
+
+	// The stream starts once the first comment is received.
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	require.Equal(t, ": connected\n", line)
+
+	return reader
+}
+
+func TestStreamHandlerEvents_Successful(t *testing.T) {
+	// Given
+	broker := pubsub.NewMemoryBroker()
+	address := listenStreamServer(t, broker)
+
+	reader := openEventStream(t, address, "")
+
+	// When
+	event := publishTodoEvent(t, broker, domain.TodoEventCompleted, 1)
+
+	// Then
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]string{
+		"id":    event.ID,
+		"event": domain.TodoEventCompleted,
+		"data":  string(data),
+	}, readServerSentEvent(t, reader))
+}
+
+func TestStreamHandlerEvents_SuccessfulResumingAfterLastEventID(t *testing.T) {
+	// Given
+	broker := pubsub.NewMemoryBroker()
+	address := listenStreamServer(t, broker)
+
+	created := publishTodoEvent(t, broker, domain.TodoEventCreated, 1)
+	deleted := publishTodoEvent(t, broker, domain.TodoEventDeleted, 1)
+
+	// When
+	reader := openEventStream(t, address, created.ID)
+
+	// Then
+	fields := readServerSentEvent(t, reader)
+	require.Equal(t, deleted.ID, fields["id"])
+	require.Equal(t, domain.TodoEventDeleted, fields["event"])
+}
+
+func TestStreamHandlerEvents_FailsDueToInvalidLastEventID(t *testing.T) {
+	// Given
+	server := createStreamServer(_testConfigs, pubsub.NewMemoryBroker())
+
+	req, err := createTodoRequest(fiber.MethodGet, _streamPath, true, "")
+	require.NoError(t, err)
+
+	req.Header.Set(HeaderLastEventID, "invalid")
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+func TestStreamHandlerEvents_FailsDueToUnauthorizedUser(t *testing.T) {
+	// Given
+	server := createStreamServer(_testConfigs, pubsub.NewMemoryBroker())
+
+	req, err := createTodoRequest(fiber.MethodGet, _streamPath, false, "")
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestStreamHandlerWebSocket_Successful(t *testing.T) {
+	// Given
+	broker := pubsub.NewMemoryBroker()
+	address := listenStreamServer(t, broker)
+
+	created := publishTodoEvent(t, broker, domain.TodoEventCreated, 1)
+
+	url := fmt.Sprintf("ws://%s%s?last_event_id=%s", address, _socketPath, created.ID)
+
+	conn, resp, err := websocket.DefaultDialer.Dial(url, authorizationHeader(t))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusSwitchingProtocols, resp.StatusCode)
+
+	t.Cleanup(func() {
+		require.NoError(t, conn.Close())
+	})
+
+	// When
+	updated := publishTodoEvent(t, broker, domain.TodoEventUpdated, 1)
+
+	// Then
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+	var event domain.TodoEvent
+	require.NoError(t, conn.ReadJSON(&event))
+	require.Equal(t, updated, event)
+}
+
+func TestStreamHandlerWebSocket_FailsDueToMissingUpgrade(t *testing.T) {
+	// Given
+	server := createStreamServer(_testConfigs, pubsub.NewMemoryBroker())
+
+	req, err := createTodoRequest(fiber.MethodGet, _socketPath, true, "")
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusUpgradeRequired, resp.StatusCode)
+}
@@ -1,17 +1,37 @@
 package handler
 
 import (
+	"bufio"
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
 	"github.com/ferch5003/go-fiber-tutorial/config"
 	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/data"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/logging"
 	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
 	"github.com/ferch5003/go-fiber-tutorial/internal/platform/validations"
 	"github.com/ferch5003/go-fiber-tutorial/internal/todo"
 	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"mime/multipart"
+	"time"
+)
+
+const (
+	// _todosCacheControl lets clients keep the todos of the user, revalidating them with the ETag on
+	// every use as they change with every write.
+	_todosCacheControl = "private, no-cache"
+
+	// _defaultSearchPageSize is the number of hits of a search page when the client does not choose it.
+	_defaultSearchPageSize = 20
 )
 
 type TodoHandler struct {
 	validator      *validations.XValidator
 	sessionType    string
+	requireIfMatch bool
 	todoService    todo.Service
 	sessionService session.Service
 }
@@ -22,6 +42,7 @@ func NewTodoHandler(cfg *config.EnvVars, todoService todo.Service, sessionServic
 	return &TodoHandler{
 		validator:      myValidator,
 		sessionType:    cfg.AppSessionType,
+		requireIfMatch: cfg.APIRequireIfMatch,
 		todoService:    todoService,
 		sessionService: sessionService,
 	}
@@ -35,13 +56,15 @@ func (h *TodoHandler) GetAll(c *fiber.Ctx) error {
 		})
 	}
 
-	todos, err := h.todoService.GetAll(c.Context(), userID)
+	todos, err := h.todoService.GetAll(c.UserContext(), userID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	c.Set(fiber.HeaderCacheControl, _todosCacheControl)
+
 	return c.Status(fiber.StatusOK).JSON(todos)
 }
 
@@ -53,16 +76,72 @@ func (h *TodoHandler) Get(c *fiber.Ctx) error {
 		})
 	}
 
-	obtainedTodo, err := h.todoService.Get(c.Context(), id)
+	obtainedTodo, err := h.todoService.Get(c.UserContext(), id)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		return c.Status(todoReadStatus(err)).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	c.Set(fiber.HeaderCacheControl, _todosCacheControl)
+
+	if notModified(c, obtainedTodo.Version) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
 	return c.Status(fiber.StatusOK).JSON(obtainedTodo)
 }
 
+type searchTodos struct {
+	Query    string `query:"q" validate:"required,max=255"`
+	Page     int    `query:"page" validate:"omitempty,min=1"`
+	PageSize int    `query:"page_size" validate:"omitempty,min=1,max=100"`
+}
+
+// Search the todos of the authenticated user matching the q query parameter, from the most relevant.
+func (h *TodoHandler) Search(c *fiber.Ctx) error {
+	userID, err := getAuthUserID(c, h.sessionService, h.sessionType)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var searchData searchTodos
+	if err := c.QueryParser(&searchData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	searchValidations := h.validator.GetValidations(searchData)
+	if searchValidations != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": searchValidations,
+		})
+	}
+
+	search := domain.TodoSearch{
+		UserID:   userID,
+		Query:    searchData.Query,
+		Page:     max(searchData.Page, 1),
+		PageSize: searchData.PageSize,
+	}
+
+	if search.PageSize == 0 {
+		search.PageSize = _defaultSearchPageSize
+	}
+
+	page, err := h.todoService.Search(c.UserContext(), search)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(page)
+}
+
 func (h *TodoHandler) Save(c *fiber.Ctx) error {
 	userID, err := getAuthUserID(c, h.sessionService, h.sessionType)
 	if err != nil {
@@ -88,7 +167,7 @@ func (h *TodoHandler) Save(c *fiber.Ctx) error {
 		})
 	}
 
-	savedTodo, err := h.todoService.Save(c.Context(), todoData)
+	savedTodo, err := h.todoService.Save(c.UserContext(), todoData)
 	if err != nil {
 		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
 			"error": err.Error(),
@@ -106,9 +185,9 @@ func (h *TodoHandler) Completed(c *fiber.Ctx) error {
 		})
 	}
 
-	obtainedTodo, err := h.todoService.Get(c.Context(), id)
+	obtainedTodo, err := h.todoService.Get(c.UserContext(), id)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		return c.Status(todoReadStatus(err)).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
@@ -126,17 +205,220 @@ func (h *TodoHandler) Completed(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.todoService.Completed(c.Context(), id); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+	version, err := ifMatchVersion(c, h.requireIfMatch)
+	if err != nil {
+		return c.Status(preconditionStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	completedTodo, err := h.todoService.Completed(c.UserContext(), id, version)
+	if err != nil {
+		return c.Status(todoWriteStatus(err)).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	// Completing a todo deleted since it was read is not an error of the service.
+	if completedTodo.ID == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": domain.ErrNotFound.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderETag, versionETag(completedTodo.Version))
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Updated successfully",
 	})
 }
 
+type updateTodo struct {
+	Title       string             `json:"title" validate:"omitempty,max=255"`
+	Description data.Patch[string] `json:"description"`
+	Completed   data.Patch[bool]   `json:"completed"`
+	DueAt       *time.Time         `json:"due_at"`
+	Recurrence  string             `json:"recurrence" validate:"omitempty,max=255"`
+
+	// Scope selects the occurrences of a series changed, defaulting to this one.
+	Scope domain.TodoScope `json:"scope" validate:"omitempty,oneof=this future"`
+}
+
+// Update changes the title, description, completion, due date or recurrence of a todo of the authenticated
+// user. An empty title, due date or recurrence is kept, while a description or completion sent is written,
+// so the description can be cleared and the todo reopened.
+func (h *TodoHandler) Update(c *fiber.Ctx) error {
+	var todoData updateTodo
+	if err := c.BodyParser(&todoData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	todoValidations := h.validator.GetValidations(todoData)
+	if todoValidations != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": todoValidations,
+		})
+	}
+
+	scope := todoData.Scope
+	if scope == "" {
+		scope = domain.TodoScopeThis
+	}
+
+	return h.writeOwnedTodo(c, func(ctx context.Context, id int, version int) (domain.Todo, error) {
+		changes := domain.TodoChanges{
+			ID:          id,
+			Title:       todoData.Title,
+			Description: todoData.Description,
+			Completed:   todoData.Completed,
+			DueAt:       todoData.DueAt,
+			Recurrence:  todoData.Recurrence,
+			Version:     version,
+		}
+
+		return h.todoService.Update(ctx, changes, scope)
+	})
+}
+
+// Skip moves the pending occurrence of a recurring todo to the date of the next one.
+func (h *TodoHandler) Skip(c *fiber.Ctx) error {
+	return h.writeOwnedTodo(c, h.todoService.Skip)
+}
+
+// EndSeries makes the pending occurrence of a recurring todo the last one.
+func (h *TodoHandler) EndSeries(c *fiber.Ctx) error {
+	return h.writeOwnedTodo(c, h.todoService.EndSeries)
+}
+
+type moveTodo struct {
+	// BeforeID is the todo the moved todo is placed right before.
+	BeforeID int `json:"before_id" validate:"required_without=AfterID,excluded_with=AfterID"`
+
+	// AfterID is the todo the moved todo is placed right after.
+	AfterID int `json:"after_id" validate:"required_without=BeforeID,excluded_with=BeforeID"`
+}
+
+// Move places a todo of the authenticated user right before or after another of its todos.
+func (h *TodoHandler) Move(c *fiber.Ctx) error {
+	var moveData moveTodo
+	if err := c.BodyParser(&moveData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	moveValidations := h.validator.GetValidations(moveData)
+	if moveValidations != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": moveValidations,
+		})
+	}
+
+	userID, err := getAuthUserID(c, h.sessionService, h.sessionType)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return h.writeOwnedTodo(c, func(ctx context.Context, id int, version int) (domain.Todo, error) {
+		move := domain.TodoMove{
+			ID:       id,
+			UserID:   userID,
+			BeforeID: moveData.BeforeID,
+			AfterID:  moveData.AfterID,
+			Version:  version,
+		}
+
+		return h.todoService.Move(ctx, move)
+	})
+}
+
+// writeOwnedTodo applies the write to the todo of the id parameter, when it belongs to the authenticated
+// user, responding with the written todo and its ETag.
+func (h *TodoHandler) writeOwnedTodo(
+	c *fiber.Ctx,
+	write func(ctx context.Context, id int, version int) (domain.Todo, error)) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	obtainedTodo, err := h.todoService.Get(c.UserContext(), id)
+	if err != nil {
+		return c.Status(todoReadStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userID, err := getAuthUserID(c, h.sessionService, h.sessionType)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if obtainedTodo.UserID != userID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "This todo is not from this user",
+		})
+	}
+
+	version, err := ifMatchVersion(c, h.requireIfMatch)
+	if err != nil {
+		return c.Status(preconditionStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	writtenTodo, err := write(c.UserContext(), id, version)
+	if err != nil {
+		return c.Status(todoWriteStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	c.Set(fiber.HeaderETag, versionETag(writtenTodo.Version))
+
+	return c.Status(fiber.StatusOK).JSON(writtenTodo)
+}
+
+// todoReadStatus returns the status of a failed read of a todo.
+func todoReadStatus(err error) int {
+	if errors.Is(err, sql.ErrNoRows) || errors.Is(err, domain.ErrNotFound) {
+		return fiber.StatusNotFound
+	}
+
+	return fiber.StatusInternalServerError
+}
+
+// todoWriteStatus returns the status of a failed write of a todo that may recur or be moved. The todo
+// missing is a 404, while domain.ErrNotFound names another todo, like the one a move is placed next to.
+func todoWriteStatus(err error) int {
+	if status := preconditionStatus(err); status != 0 {
+		return status
+	}
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return fiber.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidRecurrence),
+		errors.Is(err, domain.ErrMissingDueDate),
+		errors.Is(err, domain.ErrNotRecurring),
+		errors.Is(err, domain.ErrSeriesEnded),
+		errors.Is(err, domain.ErrRecurrenceScope),
+		errors.Is(err, domain.ErrInvalidMove),
+		errors.Is(err, domain.ErrNotFound):
+		return fiber.StatusUnprocessableEntity
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
 func (h *TodoHandler) Delete(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
@@ -145,9 +427,9 @@ func (h *TodoHandler) Delete(c *fiber.Ctx) error {
 		})
 	}
 
-	obtainedTodo, err := h.todoService.Get(c.Context(), id)
+	obtainedTodo, err := h.todoService.Get(c.UserContext(), id)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		return c.Status(todoReadStatus(err)).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
@@ -165,13 +447,367 @@ func (h *TodoHandler) Delete(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.todoService.Delete(c.Context(), id); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+	version, err := ifMatchVersion(c, h.requireIfMatch)
+	if err != nil {
+		return c.Status(preconditionStatus(err)).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
+	if err := h.todoService.Delete(c.UserContext(), id, version); err != nil {
+		return c.Status(todoWriteStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// The deletion is a write like any other, so the ETag is the version after the deleted one, which is
+	// the matched one, if any.
+	c.Set(fiber.HeaderETag, versionETag(cmp.Or(version, obtainedTodo.Version)+1))
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Todo deleted successfully",
 	})
 }
+
+// Modes of a batch.
+const (
+	_batchAtomic     = "atomic"
+	_batchBestEffort = "best_effort"
+)
+
+type batchTodos struct {
+	// Mode is atomic, applying every operation or none, or best_effort, applying the operations that
+	// succeed. Defaults to atomic.
+	Mode       string                 `json:"mode" validate:"omitempty,oneof=atomic best_effort"`
+	Operations []domain.TodoOperation `json:"operations" validate:"required,min=1,max=500,dive"`
+}
+
+type batchResult struct {
+	Index   int               `json:"index"`
+	Action  domain.TodoAction `json:"action"`
+	ID      int               `json:"id,omitempty"`
+	Version int               `json:"version,omitempty"`
+	Status  int               `json:"status"`
+	Error   string            `json:"error,omitempty"`
+}
+
+type batchResults struct {
+	Results []batchResult `json:"results"`
+}
+
+// operationStatus is the HTTP status the operation would have had as a single request.
+func operationStatus(result domain.TodoOperationResult) int {
+	switch {
+	case result.Err == nil && result.Action == domain.TodoCreate:
+		return fiber.StatusCreated
+	case result.Err == nil:
+		return fiber.StatusOK
+	case errors.Is(result.Err, domain.ErrVersionMismatch):
+		return fiber.StatusPreconditionFailed
+	case errors.Is(result.Err, domain.ErrNotFound):
+		return fiber.StatusNotFound
+	case errors.Is(result.Err, domain.ErrNotOwned):
+		return fiber.StatusForbidden
+	case errors.Is(result.Err, domain.ErrBatchAborted):
+		return fiber.StatusFailedDependency
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// Batch applies the operations in a single transaction. The response holds the result of every
+// operation, and its status is 200 when all of them were applied, 422 when an atomic batch was rolled
+// back, and 207 when only some operations of a best effort batch were applied.
+func (h *TodoHandler) Batch(c *fiber.Ctx) error {
+	userID, err := getAuthUserID(c, h.sessionService, h.sessionType)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var batchData batchTodos
+	if err := c.BodyParser(&batchData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	batchValidations := h.validator.GetValidations(batchData)
+	if batchValidations != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": batchValidations,
+		})
+	}
+
+	atomic := batchData.Mode != _batchBestEffort
+
+	results, err := h.todoService.Batch(c.UserContext(), userID, batchData.Operations, atomic)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := batchResults{Results: make([]batchResult, 0, len(results))}
+	failed := 0
+
+	for _, result := range results {
+		shownResult := batchResult{
+			Index:   result.Index,
+			Action:  result.Action,
+			ID:      result.ID,
+			Version: result.Version,
+			Status:  operationStatus(result),
+		}
+
+		if result.Err != nil {
+			shownResult.Error = result.Err.Error()
+			failed++
+		}
+
+		response.Results = append(response.Results, shownResult)
+	}
+
+	status := fiber.StatusOK
+	switch {
+	case failed > 0 && atomic:
+		status = fiber.StatusUnprocessableEntity
+	case failed > 0:
+		status = fiber.StatusMultiStatus
+	}
+
+	return c.Status(status).JSON(response)
+}
+
+const (
+	// _maxImportRows is the most todos a file can import.
+	_maxImportRows = 5000
+
+	// _exportPageSize is how many todos an export reads per query.
+	_exportPageSize = 200
+)
+
+type exportTodos struct {
+	// Format is json, csv or ics. Defaults to json.
+	Format string `query:"format"`
+}
+
+// Export downloads the todos of the authenticated user as a JSON, CSV or iCalendar file, which is streamed
+// to the client as its pages are read from the database.
+func (h *TodoHandler) Export(c *fiber.Ctx) error {
+	userID, err := getAuthUserID(c, h.sessionService, h.sessionType)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var exportData exportTodos
+	if err := c.QueryParser(&exportData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	format, err := todo.ParseFormat(cmp.Or(exportData.Format, string(todo.FormatJSON)))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// The context outlives the handler, as the stream writer reads the remaining pages after it returns.
+	ctx := c.UserContext()
+
+	todos, err := h.todoService.GetPage(ctx, userID, domain.Todo{}, _exportPageSize)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	exportedAt := time.Now()
+
+	c.Attachment(format.Filename(exportedAt))
+	c.Set(fiber.HeaderContentType, format.ContentType())
+	c.Set(fiber.HeaderCacheControl, "private, no-store")
+
+	logger := logging.FromCtx(c)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		encoder := todo.NewEncoder(w, format, exportedAt)
+
+		for len(todos) > 0 {
+			for _, exportedTodo := range todos {
+				if err := encoder.Encode(exportedTodo); err != nil {
+					logger.Debug("Todos export closed", zap.Error(err))
+					return
+				}
+			}
+
+			if len(todos) < _exportPageSize {
+				break
+			}
+
+			todos, err = h.todoService.GetPage(ctx, userID, todos[len(todos)-1], _exportPageSize)
+			if err != nil {
+				logger.Warn("Error reading todos export page", zap.Error(err))
+				return
+			}
+		}
+
+		if err := encoder.Close(); err != nil {
+			logger.Debug("Todos export closed", zap.Error(err))
+		}
+	})
+
+	return nil
+}
+
+// importTodos is the multipart form of an import.
+type importTodos struct {
+	// File holds the todos, in the format of its extension unless Format is set.
+	File   *multipart.FileHeader `json:"file" form:"-" validate:"required"`
+	Format string                `json:"format,omitempty" form:"format"`
+	DryRun bool                  `json:"dry_run,omitempty" form:"dry_run"`
+}
+
+type importResult struct {
+	Row        int    `json:"row"`
+	ExternalID string `json:"external_id,omitempty"`
+	ID         int    `json:"id,omitempty"`
+	Status     int    `json:"status"`
+	Error      string `json:"error,omitempty"`
+}
+
+type importResults struct {
+	DryRun    bool           `json:"dry_run"`
+	Succeeded int            `json:"succeeded"`
+	Failed    int            `json:"failed"`
+	Results   []importResult `json:"results"`
+}
+
+// importRowStatus is the HTTP status the row would have had as a single request.
+func importRowStatus(result domain.TodoImportResult, dryRun bool) int {
+	switch {
+	case result.Err == nil && dryRun:
+		return fiber.StatusOK
+	case result.Err == nil:
+		return fiber.StatusCreated
+	case errors.Is(result.Err, domain.ErrDuplicateExternalID):
+		return fiber.StatusConflict
+	default:
+		return fiber.StatusUnprocessableEntity
+	}
+}
+
+// Import creates todos for the authenticated user from a JSON, CSV or iCalendar file, in one transaction.
+// The rows that can not be imported, or were already imported, are skipped and reported, while a dry run
+// only reports the rows. The status is 200 when every row was imported, 207 when only some of them were,
+// and 422 when none was. A concurrent import of the same external IDs results in 409, importing nothing.
+func (h *TodoHandler) Import(c *fiber.Ctx) error {
+	userID, err := getAuthUserID(c, h.sessionService, h.sessionType)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var importData importTodos
+	if err := c.BodyParser(&importData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// The file is not bound by the body parser.
+	importData.File, _ = c.FormFile("file")
+
+	importValidations := h.validator.GetValidations(importData)
+	if importValidations != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": importValidations,
+		})
+	}
+
+	format, err := importFormat(importData)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	file, err := importData.File.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	defer file.Close()
+
+	rows, err := todo.Decode(file, format, _maxImportRows)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	results, err := h.todoService.Import(c.UserContext(), domain.TodoImport{
+		UserID: userID,
+		Rows:   rows,
+		DryRun: importData.DryRun,
+	})
+	if errors.Is(err, domain.ErrDuplicateExternalID) {
+		// A concurrent import saved one of the external IDs after they were checked.
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	response := importResults{DryRun: importData.DryRun, Results: make([]importResult, 0, len(results))}
+
+	for _, result := range results {
+		shownResult := importResult{
+			Row:        result.Row,
+			ExternalID: result.ExternalID,
+			ID:         result.ID,
+			Status:     importRowStatus(result, importData.DryRun),
+		}
+
+		if result.Err != nil {
+			shownResult.Error = result.Err.Error()
+			response.Failed++
+		} else {
+			response.Succeeded++
+		}
+
+		response.Results = append(response.Results, shownResult)
+	}
+
+	status := fiber.StatusOK
+	switch {
+	case response.Failed > 0 && response.Succeeded > 0:
+		status = fiber.StatusMultiStatus
+	case response.Failed > 0:
+		status = fiber.StatusUnprocessableEntity
+	}
+
+	return c.Status(status).JSON(response)
+}
+
+// importFormat returns the format of the form, or else the one of its file.
+func importFormat(importData importTodos) (todo.Format, error) {
+	if importData.Format != "" {
+		return todo.ParseFormat(importData.Format)
+	}
+
+	return todo.DetectFormat(importData.File.Filename, importData.File.Header.Get(fiber.HeaderContentType))
+}
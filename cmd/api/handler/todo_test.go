@@ -3,18 +3,29 @@ package handler
 import (
 	"bytes"
 	"context"
+	stdsql "database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
 	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
 	"github.com/ferch5003/go-fiber-tutorial/internal/middlewares"
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/recurrence"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/sql"
+	"github.com/ferch5003/go-fiber-tutorial/internal/todo"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/etag"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"io"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 )
 
 const _todosPath = "/todos"
@@ -28,6 +39,15 @@ func (tsm *todoServiceMock) GetAll(ctx context.Context, userID int) ([]domain.To
 	return args.Get(0).([]domain.Todo), args.Error(1)
 }
 
+func (tsm *todoServiceMock) GetPage(
+	ctx context.Context,
+	userID int,
+	after domain.Todo,
+	limit int) ([]domain.Todo, error) {
+	args := tsm.Called(ctx, userID, after, limit)
+	return args.Get(0).([]domain.Todo), args.Error(1)
+}
+
 func (tsm *todoServiceMock) Get(ctx context.Context, id int) (domain.Todo, error) {
 	args := tsm.Called(ctx, id)
 	return args.Get(0).(domain.Todo), args.Error(1)
@@ -38,49 +58,118 @@ func (tsm *todoServiceMock) Save(ctx context.Context, todo domain.Todo) (domain.
 	return args.Get(0).(domain.Todo), args.Error(1)
 }
 
-func (tsm *todoServiceMock) Completed(ctx context.Context, id int) error {
-	args := tsm.Called(ctx, id)
-	return args.Error(0)
+func (tsm *todoServiceMock) Completed(ctx context.Context, id int, version int) (domain.Todo, error) {
+	args := tsm.Called(ctx, id, version)
+	return args.Get(0).(domain.Todo), args.Error(1)
 }
 
-func (tsm *todoServiceMock) Delete(ctx context.Context, id int) error {
-	args := tsm.Called(ctx, id)
+func (tsm *todoServiceMock) Delete(ctx context.Context, id int, version int) error {
+	args := tsm.Called(ctx, id, version)
 	return args.Error(0)
 }
 
-func createTodoServer(tsm *todoServiceMock) *fiber.App {
+func (tsm *todoServiceMock) Batch(
+	ctx context.Context,
+	userID int,
+	operations []domain.TodoOperation,
+	atomic bool) ([]domain.TodoOperationResult, error) {
+	args := tsm.Called(ctx, userID, operations, atomic)
+	return args.Get(0).([]domain.TodoOperationResult), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Import(
+	ctx context.Context,
+	todoImport domain.TodoImport) ([]domain.TodoImportResult, error) {
+	args := tsm.Called(ctx, todoImport)
+	return args.Get(0).([]domain.TodoImportResult), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Search(ctx context.Context, search domain.TodoSearch) (domain.TodoSearchPage, error) {
+	args := tsm.Called(ctx, search)
+	return args.Get(0).(domain.TodoSearchPage), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Update(
+	ctx context.Context,
+	changes domain.TodoChanges,
+	scope domain.TodoScope) (domain.Todo, error) {
+	args := tsm.Called(ctx, changes, scope)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Skip(ctx context.Context, id int, version int) (domain.Todo, error) {
+	args := tsm.Called(ctx, id, version)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) EndSeries(ctx context.Context, id int, version int) (domain.Todo, error) {
+	args := tsm.Called(ctx, id, version)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Move(ctx context.Context, move domain.TodoMove) (domain.Todo, error) {
+	args := tsm.Called(ctx, move)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func createTodoServer(todoService todo.Service) *fiber.App {
 	app := fiber.New()
 
-	ssm := new(sessionServiceMock)
-	ssm.On("SetSession", mock.Anything, mock.Anything, mock.Anything).Return(nil)
-	ssm.On("GetSession", mock.Anything, mock.Anything).Return(map[string]string{
-		"iss":  "test",
-		"sub":  "1",
-		"name": "test",
-	}, nil)
+	// Sessions are stored by the JWT middleware, like in the application.
+	sessionService := session.NewService(session.NewMemoryRepository())
 
-	todoHandler := NewTodoHandler(_testConfigs, tsm, ssm)
+	todoHandler := NewTodoHandler(_testConfigs, todoService, sessionService)
 
 	jwtMiddleware := middlewares.NewJWTMiddleware(
 		context.Background(),
 		_testConfigs.AppSessionType,
 		_testConfigs.AppSecretKey,
-		ssm,
+		sessionService,
 	)
 
 	app.Route("/todos", func(api fiber.Router) {
 		// Using JWT Middleware.
 		protectedRoutes := api.Group("", jwtMiddleware.GetMiddleware())
-		protectedRoutes.Get("/", todoHandler.GetAll).Name("get_all")
+		protectedRoutes.Get("/", etag.New(), todoHandler.GetAll).Name("get_all")
+		protectedRoutes.Get("/search", todoHandler.Search).Name("search")
+		protectedRoutes.Get("/export", todoHandler.Export).Name("export")
 		protectedRoutes.Get("/:id", todoHandler.Get).Name("get")
 		protectedRoutes.Post("/", todoHandler.Save).Name("save")
+		protectedRoutes.Post("/batch", todoHandler.Batch).Name("batch")
+		protectedRoutes.Post("/import", todoHandler.Import).Name("import")
+		protectedRoutes.Patch("/:id", todoHandler.Update).Name("update")
 		protectedRoutes.Patch("/:id/complete", todoHandler.Completed).Name("completed")
+		protectedRoutes.Patch("/:id/skip", todoHandler.Skip).Name("skip")
+		protectedRoutes.Patch("/:id/end-series", todoHandler.EndSeries).Name("end_series")
+		protectedRoutes.Post("/:id/move", todoHandler.Move).Name("move")
 		protectedRoutes.Delete("/:id", todoHandler.Delete).Name("delete")
 	}, "todos.")
 
 	return app
 }
 
+// newMemoryTodoService creates a todo.Service backed by an in-memory repository holding todos. The
+// todos must be numbered from 1, in order.
+func newMemoryTodoService(t *testing.T, todos ...domain.Todo) (todo.Service, todo.Repository) {
+	t.Helper()
+
+	repository := todo.NewMemoryRepository(audit.NewMemoryRepository(), outbox.NewMemoryRepository())
+	ctx := context.Background()
+
+	for _, todoData := range todos {
+		id, err := repository.Save(ctx, todoData)
+		require.NoError(t, err)
+		require.Equal(t, todoData.ID, id)
+
+		if todoData.Completed {
+			_, err = repository.Completed(ctx, id, 0)
+			require.NoError(t, err)
+		}
+	}
+
+	return todo.NewService(repository, todo.NewIndexSearcher(repository), sql.NewNopTransactor()), repository
+}
+
 func createTodoRequest(method string, url string, isAuthorized bool, body string) (*http.Request, error) {
 	req := httptest.NewRequest(method, url, bytes.NewBuffer([]byte(body)))
 	req.Header.Add("Content-Type", "application/json")
@@ -97,6 +186,44 @@ func createTodoRequest(method string, url string, isAuthorized bool, body string
 	return req, nil
 }
 
+// createImportRequest creates an authorized multipart request uploading the file with the form fields.
+func createImportRequest(filename string, file string, fields map[string]string) (*http.Request, error) {
+	var body bytes.Buffer
+
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	if filename != "" {
+		part, err := writer.CreateFormFile("file", filename)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := io.WriteString(part, file); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := createTodoRequest(fiber.MethodPost, fmt.Sprintf("%s/import", _todosPath), true, "")
+	if err != nil {
+		return nil, err
+	}
+
+	req.Body = io.NopCloser(&body)
+	req.ContentLength = int64(body.Len())
+	req.Header.Set(fiber.HeaderContentType, writer.FormDataContentType())
+
+	return req, nil
+}
+
 func TestTodoHandlerGetAll_Successful(t *testing.T) {
 	// Given
 	expectedUserID := 1
@@ -107,6 +234,8 @@ func TestTodoHandlerGetAll_Successful(t *testing.T) {
 			Description: "Ipsum",
 			Completed:   false,
 			UserID:      expectedUserID,
+			Version:     1,
+			Position:    1024,
 		},
 		{
 			ID:          2,
@@ -114,13 +243,14 @@ func TestTodoHandlerGetAll_Successful(t *testing.T) {
 			Description: "FLCL",
 			Completed:   true,
 			UserID:      expectedUserID,
+			Version:     2,
+			Position:    2048,
 		},
 	}
 
-	tsm := new(todoServiceMock)
-	tsm.On("GetAll", mock.Anything, expectedUserID).Return(expectedTodos, nil)
+	todoService, _ := newMemoryTodoService(t, expectedTodos...)
 
-	server := createTodoServer(tsm)
+	server := createTodoServer(todoService)
 
 	req, err := createTodoRequest(
 		fiber.MethodGet,
@@ -144,6 +274,8 @@ func TestTodoHandlerGetAll_Successful(t *testing.T) {
 	require.NoError(t, err)
 
 	require.EqualValues(t, expectedTodos, todos)
+	require.Equal(t, _todosCacheControl, resp.Header.Get(fiber.HeaderCacheControl))
+	require.NotEmpty(t, resp.Header.Get(fiber.HeaderETag))
 }
 
 func TestTodoHandlerGetAll_FailsDueToNotAuthenticatedUser(t *testing.T) {
@@ -215,12 +347,13 @@ func TestTodoHandlerGet_Successful(t *testing.T) {
 		Description: "Ipsum",
 		Completed:   false,
 		UserID:      expectedUserID,
+		Version:     1,
+		Position:    1024,
 	}
 
-	tsm := new(todoServiceMock)
-	tsm.On("Get", mock.Anything, expectedTodo.ID).Return(expectedTodo, nil)
+	todoService, _ := newMemoryTodoService(t, expectedTodo)
 
-	server := createTodoServer(tsm)
+	server := createTodoServer(todoService)
 
 	req, err := createTodoRequest(
 		fiber.MethodGet,
@@ -244,6 +377,8 @@ func TestTodoHandlerGet_Successful(t *testing.T) {
 	require.NoError(t, err)
 
 	require.EqualValues(t, expectedTodo, todoData)
+	require.Equal(t, _todosCacheControl, resp.Header.Get(fiber.HeaderCacheControl))
+	require.NotEmpty(t, resp.Header.Get(fiber.HeaderETag))
 }
 
 func TestTodoHandlerGet_FailsDueToInvalidIntParam(t *testing.T) {
@@ -322,11 +457,12 @@ func TestTodoHandlerSave_Successful(t *testing.T) {
 
 	expectedTodo := todoData
 	expectedTodo.ID = 1
+	expectedTodo.Version = 1
+	expectedTodo.Position = 1024
 
-	tsm := new(todoServiceMock)
-	tsm.On("Save", mock.Anything, todoData).Return(expectedTodo, nil)
+	todoService, _ := newMemoryTodoService(t)
 
-	server := createTodoServer(tsm)
+	server := createTodoServer(todoService)
 
 	req, err := createTodoRequest(
 		fiber.MethodPost,
@@ -460,11 +596,9 @@ func TestTodoHandlerCompleted_Successful(t *testing.T) {
 		UserID:      1,
 	}
 
-	tsm := new(todoServiceMock)
-	tsm.On("Get", mock.Anything, todoData.ID).Return(todoData, nil)
-	tsm.On("Completed", mock.Anything, todoData.ID).Return(nil)
+	todoService, repository := newMemoryTodoService(t, todoData)
 
-	server := createTodoServer(tsm)
+	server := createTodoServer(todoService)
 
 	req, err := createTodoRequest(
 		fiber.MethodPatch,
@@ -479,6 +613,7 @@ func TestTodoHandlerCompleted_Successful(t *testing.T) {
 	// Then
 	require.Equal(t, fiber.StatusOK, resp.StatusCode)
 	require.NoError(t, err)
+	require.Equal(t, `"2"`, resp.Header.Get(fiber.HeaderETag))
 
 	body, err := io.ReadAll(resp.Body)
 	require.NoError(t, err)
@@ -488,6 +623,10 @@ func TestTodoHandlerCompleted_Successful(t *testing.T) {
 	require.NoError(t, err)
 
 	require.Contains(t, response.Message, "Updated successfully")
+
+	storedTodo, err := repository.Get(context.Background(), todoData.ID)
+	require.NoError(t, err)
+	require.True(t, storedTodo.Completed)
 }
 
 func TestTodoHandlerCompleted_FailsDueToInvalidIntParam(t *testing.T) {
@@ -565,10 +704,9 @@ func TestTodoHandlerCompleted_FailsDueToUserNotRelatedTodo(t *testing.T) {
 		UserID:      2,
 	}
 
-	tsm := new(todoServiceMock)
-	tsm.On("Get", mock.Anything, todoData.ID).Return(todoData, nil)
+	todoService, repository := newMemoryTodoService(t, todoData)
 
-	server := createTodoServer(tsm)
+	server := createTodoServer(todoService)
 
 	req, err := createTodoRequest(
 		fiber.MethodPatch,
@@ -592,6 +730,10 @@ func TestTodoHandlerCompleted_FailsDueToUserNotRelatedTodo(t *testing.T) {
 	require.NoError(t, err)
 
 	require.Contains(t, response.Error, "This todo is not from this user")
+
+	storedTodo, err := repository.Get(context.Background(), todoData.ID)
+	require.NoError(t, err)
+	require.False(t, storedTodo.Completed)
 }
 
 func TestTodoHandlerCompleted_FailsDueToServiceError(t *testing.T) {
@@ -608,7 +750,7 @@ func TestTodoHandlerCompleted_FailsDueToServiceError(t *testing.T) {
 
 	tsm := new(todoServiceMock)
 	tsm.On("Get", mock.Anything, todoData.ID).Return(todoData, nil)
-	tsm.On("Completed", mock.Anything, todoData.ID).Return(expectedError)
+	tsm.On("Completed", mock.Anything, todoData.ID, 0).Return(domain.Todo{}, expectedError)
 
 	server := createTodoServer(tsm)
 
@@ -646,11 +788,9 @@ func TestTodoHandlerDelete_Successful(t *testing.T) {
 		UserID:      1,
 	}
 
-	tsm := new(todoServiceMock)
-	tsm.On("Get", mock.Anything, todoData.ID).Return(todoData, nil)
-	tsm.On("Delete", mock.Anything, todoData.ID).Return(nil)
+	todoService, repository := newMemoryTodoService(t, todoData)
 
-	server := createTodoServer(tsm)
+	server := createTodoServer(todoService)
 
 	req, err := createTodoRequest(
 		fiber.MethodDelete,
@@ -665,6 +805,7 @@ func TestTodoHandlerDelete_Successful(t *testing.T) {
 	// Then
 	require.Equal(t, fiber.StatusOK, resp.StatusCode)
 	require.NoError(t, err)
+	require.Equal(t, `"2"`, resp.Header.Get(fiber.HeaderETag))
 
 	body, err := io.ReadAll(resp.Body)
 	require.NoError(t, err)
@@ -674,6 +815,9 @@ func TestTodoHandlerDelete_Successful(t *testing.T) {
 	require.NoError(t, err)
 
 	require.Contains(t, response.Message, "Todo deleted successfully")
+
+	_, err = repository.Get(context.Background(), todoData.ID)
+	require.ErrorIs(t, err, stdsql.ErrNoRows)
 }
 
 func TestTodoHandlerDelete_FailsDueToInvalidIntParam(t *testing.T) {
@@ -751,10 +895,9 @@ func TestTodoHandlerDelete_FailsDueToUserNotRelatedTodo(t *testing.T) {
 		UserID:      2,
 	}
 
-	tsm := new(todoServiceMock)
-	tsm.On("Get", mock.Anything, todoData.ID).Return(todoData, nil)
+	todoService, repository := newMemoryTodoService(t, todoData)
 
-	server := createTodoServer(tsm)
+	server := createTodoServer(todoService)
 
 	req, err := createTodoRequest(
 		fiber.MethodDelete,
@@ -778,6 +921,9 @@ func TestTodoHandlerDelete_FailsDueToUserNotRelatedTodo(t *testing.T) {
 	require.NoError(t, err)
 
 	require.Contains(t, response.Error, "This todo is not from this user")
+
+	_, err = repository.Get(context.Background(), todoData.ID)
+	require.NoError(t, err)
 }
 
 func TestTodoHandlerDelete_FailsDueToServiceError(t *testing.T) {
@@ -794,7 +940,7 @@ func TestTodoHandlerDelete_FailsDueToServiceError(t *testing.T) {
 
 	tsm := new(todoServiceMock)
 	tsm.On("Get", mock.Anything, todoData.ID).Return(todoData, nil)
-	tsm.On("Delete", mock.Anything, todoData.ID).Return(expectedError)
+	tsm.On("Delete", mock.Anything, todoData.ID, 0).Return(expectedError)
 
 	server := createTodoServer(tsm)
 
@@ -820,3 +966,1477 @@ func TestTodoHandlerDelete_FailsDueToServiceError(t *testing.T) {
 
 	require.Equal(t, expectedError.Error(), response.Error)
 }
+
+func TestTodoHandlerGet_NotModified(t *testing.T) {
+	// Given
+	todoData := domain.Todo{
+		ID:          1,
+		Title:       "Lorem",
+		Description: "Ipsum",
+		UserID:      1,
+	}
+
+	todoService, _ := newMemoryTodoService(t, todoData)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(
+		fiber.MethodGet,
+		fmt.Sprintf("%s/%d", _todosPath, todoData.ID),
+		true,
+		"")
+	require.NoError(t, err)
+
+	req.Header.Set(fiber.HeaderIfNoneMatch, `"1"`)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusNotModified, resp.StatusCode)
+	require.Equal(t, `"1"`, resp.Header.Get(fiber.HeaderETag))
+}
+
+func TestTodoHandlerCompleted_WithCurrentIfMatch(t *testing.T) {
+	// Given
+	todoData := domain.Todo{
+		ID:          1,
+		Title:       "Lorem",
+		Description: "Ipsum",
+		UserID:      1,
+	}
+
+	todoService, repository := newMemoryTodoService(t, todoData)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(
+		fiber.MethodPatch,
+		fmt.Sprintf("%s/%d/complete", _todosPath, todoData.ID),
+		true,
+		`{}`)
+	require.NoError(t, err)
+
+	req.Header.Set(fiber.HeaderIfMatch, `"1"`)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, `"2"`, resp.Header.Get(fiber.HeaderETag))
+
+	storedTodo, err := repository.Get(context.Background(), todoData.ID)
+	require.NoError(t, err)
+	require.True(t, storedTodo.Completed)
+	require.Equal(t, 2, storedTodo.Version)
+}
+
+func TestTodoHandlerCompleted_FailsDueToMissingTodo(t *testing.T) {
+	// Given
+	todoService, _ := newMemoryTodoService(t)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(fiber.MethodPatch, fmt.Sprintf("%s/%d/complete", _todosPath, 1), true, `{}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestTodoHandlerDelete_FailsDueToMissingTodo(t *testing.T) {
+	// Given
+	todoService, _ := newMemoryTodoService(t)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(fiber.MethodDelete, fmt.Sprintf("%s/%d", _todosPath, 1), true, `{}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestTodoHandlerCompleted_FailsDueToStaleIfMatch(t *testing.T) {
+	// Given
+	todoData := domain.Todo{
+		ID:          1,
+		Title:       "Lorem",
+		Description: "Ipsum",
+		Completed:   true,
+		UserID:      1,
+	}
+
+	todoService, repository := newMemoryTodoService(t, todoData)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(
+		fiber.MethodPatch,
+		fmt.Sprintf("%s/%d/complete", _todosPath, todoData.ID),
+		true,
+		`{}`)
+	require.NoError(t, err)
+
+	req.Header.Set(fiber.HeaderIfMatch, `"1"`)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusPreconditionFailed, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response errorResponse
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Equal(t, domain.ErrVersionMismatch.Error(), response.Error)
+
+	storedTodo, err := repository.Get(context.Background(), todoData.ID)
+	require.NoError(t, err)
+	require.Equal(t, 2, storedTodo.Version)
+}
+
+func TestTodoHandlerDelete_FailsDueToStaleIfMatch(t *testing.T) {
+	// Given
+	todoData := domain.Todo{
+		ID:          1,
+		Title:       "Lorem",
+		Description: "Ipsum",
+		Completed:   true,
+		UserID:      1,
+	}
+
+	todoService, repository := newMemoryTodoService(t, todoData)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(
+		fiber.MethodDelete,
+		fmt.Sprintf("%s/%d", _todosPath, todoData.ID),
+		true,
+		`{}`)
+	require.NoError(t, err)
+
+	req.Header.Set(fiber.HeaderIfMatch, `"1"`)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusPreconditionFailed, resp.StatusCode)
+
+	_, err = repository.Get(context.Background(), todoData.ID)
+	require.NoError(t, err)
+}
+
+func TestTodoHandlerBatch_Successful(t *testing.T) {
+	// Given
+	todoData := domain.Todo{
+		ID:          1,
+		Title:       "Lorem",
+		Description: "Ipsum",
+		UserID:      1,
+	}
+
+	todoService, repository := newMemoryTodoService(t, todoData)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(
+		fiber.MethodPost,
+		fmt.Sprintf("%s/batch", _todosPath),
+		true,
+		`{
+					"operations": [
+						{"action": "create", "title": "Dolor", "description": "Sit"},
+						{"action": "complete", "id": 1, "version": 1}
+					]
+					}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response batchResults
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Equal(t, []batchResult{
+		{Index: 0, Action: domain.TodoCreate, ID: 2, Version: 1, Status: fiber.StatusCreated},
+		{Index: 1, Action: domain.TodoComplete, ID: 1, Version: 2, Status: fiber.StatusOK},
+	}, response.Results)
+
+	storedTodo, err := repository.Get(context.Background(), todoData.ID)
+	require.NoError(t, err)
+	require.True(t, storedTodo.Completed)
+}
+
+func TestTodoHandlerBatch_FailsDueToAtomicBatchRollback(t *testing.T) {
+	// Given
+	todoData := domain.Todo{
+		ID:          1,
+		Title:       "Lorem",
+		Description: "Ipsum",
+		UserID:      2,
+	}
+
+	todoService, repository := newMemoryTodoService(t, todoData)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(
+		fiber.MethodPost,
+		fmt.Sprintf("%s/batch", _todosPath),
+		true,
+		`{
+					"mode": "atomic",
+					"operations": [
+						{"action": "create", "title": "Dolor", "description": "Sit"},
+						{"action": "delete", "id": 1}
+					]
+					}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response batchResults
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Equal(t, []batchResult{
+		{
+			Index:  0,
+			Action: domain.TodoCreate,
+			Status: fiber.StatusFailedDependency,
+			Error:  domain.ErrBatchAborted.Error(),
+		},
+		{
+			Index:  1,
+			Action: domain.TodoDelete,
+			ID:     1,
+			Status: fiber.StatusForbidden,
+			Error:  domain.ErrNotOwned.Error(),
+		},
+	}, response.Results)
+
+	todos, err := repository.GetAll(context.Background(), 1)
+	require.NoError(t, err)
+	require.Empty(t, todos)
+}
+
+func TestTodoHandlerBatch_PartiallyAppliesBestEffortBatch(t *testing.T) {
+	// Given
+	todoService, repository := newMemoryTodoService(t)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(
+		fiber.MethodPost,
+		fmt.Sprintf("%s/batch", _todosPath),
+		true,
+		`{
+					"mode": "best_effort",
+					"operations": [
+						{"action": "create", "title": "Dolor", "description": "Sit"},
+						{"action": "complete", "id": 7}
+					]
+					}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.Equal(t, fiber.StatusMultiStatus, resp.StatusCode)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response batchResults
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Equal(t, []batchResult{
+		{Index: 0, Action: domain.TodoCreate, ID: 1, Version: 1, Status: fiber.StatusCreated},
+		{
+			Index:  1,
+			Action: domain.TodoComplete,
+			ID:     7,
+			Status: fiber.StatusNotFound,
+			Error:  domain.ErrNotFound.Error(),
+		},
+	}, response.Results)
+
+	todos, err := repository.GetAll(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, todos, 1)
+}
+
+func TestTodoHandlerBatch_FailsDueToValidations(t *testing.T) {
+	// Given
+	tsm := new(todoServiceMock)
+
+	server := createTodoServer(tsm)
+
+	req, err := createTodoRequest(
+		fiber.MethodPost,
+		fmt.Sprintf("%s/batch", _todosPath),
+		true,
+		`{
+					"mode": "sometimes",
+					"operations": [{"action": "complete"}]
+					}`)
+	require.NoError(t, err)
+
+	// When
+	resp, _ := server.Test(req)
+
+	// Then
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response errorResponse
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Contains(t, response.Error, "[Mode]: 'sometimes' | Needs to implement 'oneof'")
+	require.Contains(t, response.Error, "[ID]: '0' | Needs to implement 'required_unless'")
+	tsm.AssertNotCalled(t, "Batch")
+}
+
+func TestTodoHandlerBatch_FailsDueToServiceError(t *testing.T) {
+	// Given
+	expectedError := errors.New("Error Code: 1205. Lock wait timeout exceeded")
+
+	tsm := new(todoServiceMock)
+	tsm.On("Batch", mock.Anything, 1, mock.Anything, true).Return([]domain.TodoOperationResult(nil), expectedError)
+
+	server := createTodoServer(tsm)
+
+	req, err := createTodoRequest(
+		fiber.MethodPost,
+		fmt.Sprintf("%s/batch", _todosPath),
+		true,
+		`{"operations": [{"action": "delete", "id": 1}]}`)
+	require.NoError(t, err)
+
+	// When
+	resp, _ := server.Test(req)
+
+	// Then
+	require.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response errorResponse
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Equal(t, expectedError.Error(), response.Error)
+	tsm.AssertExpectations(t)
+}
+
+func TestTodoHandlerSearch_Successful(t *testing.T) {
+	// Given
+	todos := []domain.Todo{
+		{ID: 1, Title: "Buy groceries", Description: "Milk and bread", UserID: 1},
+		{ID: 2, Title: "Walk dog", Description: "Around the park", UserID: 1},
+		{ID: 3, Title: "Buy groceries", Description: "Eggs and butter", UserID: 2},
+	}
+
+	todoService, _ := newMemoryTodoService(t, todos...)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(
+		fiber.MethodGet,
+		fmt.Sprintf("%s/search?q=bread", _todosPath),
+		true,
+		"")
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var page domain.TodoSearchPage
+	err = json.Unmarshal(body, &page)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, page.Total)
+	require.Equal(t, 1, page.Page)
+	require.Equal(t, _defaultSearchPageSize, page.PageSize)
+	require.Len(t, page.Hits, 1)
+	require.Equal(t, 1, page.Hits[0].Todo.ID)
+	require.Equal(t, "Milk and <mark>bread</mark>", page.Hits[0].Snippet)
+}
+
+func TestTodoHandlerSearch_SuccessfulWithPage(t *testing.T) {
+	// Given
+	expectedSearch := domain.TodoSearch{UserID: 1, Query: "lorem ipsum", Page: 3, PageSize: 5}
+	expectedPage := domain.TodoSearchPage{Hits: []domain.TodoSearchHit{}, Total: 10, Page: 3, PageSize: 5}
+
+	tsm := new(todoServiceMock)
+	tsm.On("Search", mock.Anything, expectedSearch).Return(expectedPage, nil)
+
+	server := createTodoServer(tsm)
+
+	req, err := createTodoRequest(
+		fiber.MethodGet,
+		fmt.Sprintf("%s/search?q=lorem+ipsum&page=3&page_size=5", _todosPath),
+		true,
+		"")
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var page domain.TodoSearchPage
+	err = json.Unmarshal(body, &page)
+	require.NoError(t, err)
+
+	require.Equal(t, expectedPage, page)
+	tsm.AssertExpectations(t)
+}
+
+func TestTodoHandlerSearch_FailsDueToValidations(t *testing.T) {
+	// Given
+	tsm := new(todoServiceMock)
+
+	server := createTodoServer(tsm)
+
+	req, err := createTodoRequest(
+		fiber.MethodGet,
+		fmt.Sprintf("%s/search?page_size=500", _todosPath),
+		true,
+		"")
+	require.NoError(t, err)
+
+	// When
+	resp, _ := server.Test(req)
+
+	// Then
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response errorResponse
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Contains(t, response.Error, "[Query]: '' | Needs to implement 'required'")
+	require.Contains(t, response.Error, "[PageSize]: '500' | Needs to implement 'max'")
+	tsm.AssertNotCalled(t, "Search")
+}
+
+func TestTodoHandlerSearch_FailsDueToUnauthorized(t *testing.T) {
+	// Given
+	tsm := new(todoServiceMock)
+
+	server := createTodoServer(tsm)
+
+	req, err := createTodoRequest(
+		fiber.MethodGet,
+		fmt.Sprintf("%s/search?q=lorem", _todosPath),
+		false,
+		"")
+	require.NoError(t, err)
+
+	// When
+	resp, _ := server.Test(req)
+
+	// Then
+	require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+	tsm.AssertNotCalled(t, "Search")
+}
+
+func TestTodoHandlerSearch_FailsDueToServiceError(t *testing.T) {
+	// Given
+	expectedError := errors.New("Error Code: 1191. Can't find FULLTEXT index matching the column list")
+
+	tsm := new(todoServiceMock)
+	tsm.On("Search", mock.Anything, mock.AnythingOfType("domain.TodoSearch")).Return(domain.TodoSearchPage{}, expectedError)
+
+	server := createTodoServer(tsm)
+
+	req, err := createTodoRequest(
+		fiber.MethodGet,
+		fmt.Sprintf("%s/search?q=lorem", _todosPath),
+		true,
+		"")
+	require.NoError(t, err)
+
+	// When
+	resp, _ := server.Test(req)
+
+	// Then
+	require.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response errorResponse
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Equal(t, expectedError.Error(), response.Error)
+}
+
+// newSeriesTodo returns the first occurrence of a daily series of the test user, due at dueAt.
+func newSeriesTodo(t *testing.T, dueAt time.Time) domain.Todo {
+	t.Helper()
+
+	rule, err := recurrence.Normalize("daily", dueAt)
+	require.NoError(t, err)
+
+	return domain.Todo{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: 1, DueAt: &dueAt, Recurrence: rule}
+}
+
+func TestTodoHandlerUpdate_Successful(t *testing.T) {
+	// Given
+	todoData := domain.Todo{
+		ID:          1,
+		Title:       "Lorem",
+		Description: "Ipsum",
+		UserID:      1,
+	}
+
+	todoService, repository := newMemoryTodoService(t, todoData)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(
+		fiber.MethodPatch,
+		fmt.Sprintf("%s/%d", _todosPath, todoData.ID),
+		true,
+		`{"title": "Dolor", "due_at": "2024-01-01T10:00:00+01:00"}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, `"2"`, resp.Header.Get(fiber.HeaderETag))
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response domain.Todo
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Equal(t, "Dolor", response.Title)
+	require.Equal(t, "Ipsum", response.Description)
+
+	storedTodo, err := repository.Get(context.Background(), todoData.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Dolor", storedTodo.Title)
+	require.Equal(t, time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC), *storedTodo.DueAt)
+}
+
+func TestTodoHandlerUpdate_SuccessfulWithoutChanges(t *testing.T) {
+	// Given
+	todoData := domain.Todo{
+		ID:          1,
+		Title:       "Lorem",
+		Description: "Ipsum",
+		UserID:      1,
+	}
+
+	todoService, repository := newMemoryTodoService(t, todoData)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(fiber.MethodPatch, fmt.Sprintf("%s/%d", _todosPath, todoData.ID), true, `{}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, `"1"`, resp.Header.Get(fiber.HeaderETag))
+
+	storedTodo, err := repository.Get(context.Background(), todoData.ID)
+	require.NoError(t, err)
+	require.Equal(t, 1, storedTodo.Version)
+}
+
+func TestTodoHandlerUpdate_FailsDueToMissingTodo(t *testing.T) {
+	// Given
+	todoService, _ := newMemoryTodoService(t)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(fiber.MethodPatch, fmt.Sprintf("%s/%d", _todosPath, 1), true, `{"title": "Dolor"}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestTodoHandlerUpdate_SuccessfulClearsDescriptionAndReopensTodo(t *testing.T) {
+	// Given
+	todoData := domain.Todo{
+		ID:          1,
+		Title:       "Lorem",
+		Description: "Ipsum",
+		Completed:   true,
+		UserID:      1,
+	}
+
+	todoService, repository := newMemoryTodoService(t, todoData)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(
+		fiber.MethodPatch,
+		fmt.Sprintf("%s/%d", _todosPath, todoData.ID),
+		true,
+		`{"description": "", "completed": false}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response domain.Todo
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Equal(t, "Lorem", response.Title)
+	require.Empty(t, response.Description)
+	require.False(t, response.Completed)
+
+	storedTodo, err := repository.Get(context.Background(), todoData.ID)
+	require.NoError(t, err)
+	require.Empty(t, storedTodo.Description)
+	require.False(t, storedTodo.Completed)
+}
+
+func TestTodoHandlerUpdate_SuccessfulWithFutureScope(t *testing.T) {
+	// Given
+	todoData := newSeriesTodo(t, time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC))
+
+	todoService, repository := newMemoryTodoService(t, todoData)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(
+		fiber.MethodPatch,
+		fmt.Sprintf("%s/%d", _todosPath, todoData.ID),
+		true,
+		`{"recurrence": "weekly", "scope": "future"}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	storedTodo, err := repository.Get(context.Background(), todoData.ID)
+	require.NoError(t, err)
+	require.Equal(t, "DTSTART:20240101T090000Z\nRRULE:FREQ=WEEKLY", storedTodo.Recurrence)
+}
+
+func TestTodoHandlerUpdate_FailsDueToValidations(t *testing.T) {
+	// Given
+	todoData := domain.Todo{
+		ID:          1,
+		Title:       "Lorem",
+		Description: "Ipsum",
+		UserID:      1,
+	}
+
+	todoService, repository := newMemoryTodoService(t, todoData)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(
+		fiber.MethodPatch,
+		fmt.Sprintf("%s/%d", _todosPath, todoData.ID),
+		true,
+		`{"title": "Dolor", "scope": "all"}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	storedTodo, err := repository.Get(context.Background(), todoData.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Lorem", storedTodo.Title)
+}
+
+func TestTodoHandlerUpdate_FailsDueToRecurrenceScope(t *testing.T) {
+	// Given
+	todoData := newSeriesTodo(t, time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC))
+
+	todoService, _ := newMemoryTodoService(t, todoData)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(
+		fiber.MethodPatch,
+		fmt.Sprintf("%s/%d", _todosPath, todoData.ID),
+		true,
+		`{"recurrence": "weekly"}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response errorResponse
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Equal(t, domain.ErrRecurrenceScope.Error(), response.Error)
+}
+
+func TestTodoHandlerUpdate_FailsDueToUserNotRelatedTodo(t *testing.T) {
+	// Given
+	todoData := domain.Todo{
+		ID:          1,
+		Title:       "Lorem",
+		Description: "Ipsum",
+		UserID:      2,
+	}
+
+	todoService, repository := newMemoryTodoService(t, todoData)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(
+		fiber.MethodPatch,
+		fmt.Sprintf("%s/%d", _todosPath, todoData.ID),
+		true,
+		`{"title": "Dolor"}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	storedTodo, err := repository.Get(context.Background(), todoData.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Lorem", storedTodo.Title)
+}
+
+func TestTodoHandlerSkip_Successful(t *testing.T) {
+	// Given
+	todoData := newSeriesTodo(t, time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC))
+
+	todoService, repository := newMemoryTodoService(t, todoData)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(
+		fiber.MethodPatch,
+		fmt.Sprintf("%s/%d/skip", _todosPath, todoData.ID),
+		true,
+		`{}`)
+	require.NoError(t, err)
+
+	req.Header.Set(fiber.HeaderIfMatch, `"1"`)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, `"2"`, resp.Header.Get(fiber.HeaderETag))
+
+	storedTodo, err := repository.Get(context.Background(), todoData.ID)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, time.January, 2, 9, 0, 0, 0, time.UTC), *storedTodo.DueAt)
+}
+
+func TestTodoHandlerSkip_FailsDueToTodoNotRecurring(t *testing.T) {
+	// Given
+	todoData := domain.Todo{
+		ID:          1,
+		Title:       "Lorem",
+		Description: "Ipsum",
+		UserID:      1,
+	}
+
+	todoService, _ := newMemoryTodoService(t, todoData)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(
+		fiber.MethodPatch,
+		fmt.Sprintf("%s/%d/skip", _todosPath, todoData.ID),
+		true,
+		`{}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response errorResponse
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Equal(t, domain.ErrNotRecurring.Error(), response.Error)
+}
+
+func TestTodoHandlerEndSeries_Successful(t *testing.T) {
+	// Given
+	todoData := newSeriesTodo(t, time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC))
+
+	todoService, repository := newMemoryTodoService(t, todoData)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(
+		fiber.MethodPatch,
+		fmt.Sprintf("%s/%d/end-series", _todosPath, todoData.ID),
+		true,
+		`{}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	storedTodo, err := repository.Get(context.Background(), todoData.ID)
+	require.NoError(t, err)
+	require.Empty(t, storedTodo.Recurrence)
+	require.Equal(t, todoData.ID, storedTodo.SeriesID)
+}
+
+func TestTodoHandlerEndSeries_FailsDueToStaleIfMatch(t *testing.T) {
+	// Given
+	todoData := newSeriesTodo(t, time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC))
+
+	todoService, repository := newMemoryTodoService(t, todoData)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(
+		fiber.MethodPatch,
+		fmt.Sprintf("%s/%d/end-series", _todosPath, todoData.ID),
+		true,
+		`{}`)
+	require.NoError(t, err)
+
+	req.Header.Set(fiber.HeaderIfMatch, `"2"`)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusPreconditionFailed, resp.StatusCode)
+
+	storedTodo, err := repository.Get(context.Background(), todoData.ID)
+	require.NoError(t, err)
+	require.NotEmpty(t, storedTodo.Recurrence)
+}
+
+func TestTodoHandlerMove_Successful(t *testing.T) {
+	// Given
+	todos := []domain.Todo{
+		{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: 1},
+		{ID: 2, Title: "Dolor", Description: "Sit", UserID: 1},
+		{ID: 3, Title: "Amet", Description: "Consectetur", UserID: 1},
+	}
+
+	todoService, repository := newMemoryTodoService(t, todos...)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(
+		fiber.MethodPost,
+		fmt.Sprintf("%s/%d/move", _todosPath, 3),
+		true,
+		`{"before_id": 1}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, `"2"`, resp.Header.Get(fiber.HeaderETag))
+
+	storedTodos, err := repository.GetAll(context.Background(), 1)
+	require.NoError(t, err)
+	require.Len(t, storedTodos, 3)
+	require.Equal(t, []int{3, 1, 2}, []int{storedTodos[0].ID, storedTodos[1].ID, storedTodos[2].ID})
+}
+
+func TestTodoHandlerMove_FailsDueToBothNeighbours(t *testing.T) {
+	// Given
+	todos := []domain.Todo{
+		{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: 1},
+		{ID: 2, Title: "Dolor", Description: "Sit", UserID: 1},
+	}
+
+	todoService, _ := newMemoryTodoService(t, todos...)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(
+		fiber.MethodPost,
+		fmt.Sprintf("%s/%d/move", _todosPath, 2),
+		true,
+		`{"before_id": 1, "after_id": 1}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+func TestTodoHandlerMove_FailsDueToMissingNeighbour(t *testing.T) {
+	// Given
+	todos := []domain.Todo{
+		{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: 1},
+		{ID: 2, Title: "Dolor", Description: "Sit", UserID: 1},
+	}
+
+	todoService, _ := newMemoryTodoService(t, todos...)
+
+	server := createTodoServer(todoService)
+
+	req, err := createTodoRequest(
+		fiber.MethodPost,
+		fmt.Sprintf("%s/%d/move", _todosPath, 2),
+		true,
+		`{"after_id": 5}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response errorResponse
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Equal(t, domain.ErrNotFound.Error(), response.Error)
+}
+
+func TestTodoHandlerExport_Successful(t *testing.T) {
+	// Given
+	todos := []domain.Todo{
+		{ID: 1, Title: "Buy milk, eggs", UserID: 1},
+		{ID: 2, Title: "Walk dog", UserID: 1},
+		{ID: 3, Title: "Water plants", UserID: 2},
+	}
+
+	todoService, _ := newMemoryTodoService(t, todos...)
+
+	server := createTodoServer(todoService)
+
+	tests := map[string]struct {
+		format              string
+		expectedContentType string
+		expectedExtension   string
+		expectedLines       []string
+	}{
+		"DefaultsToJSON": {
+			expectedContentType: fiber.MIMEApplicationJSON,
+			expectedExtension:   "json",
+			expectedLines: []string{
+				`{"external_id":"todo-1","title":"Buy milk, eggs","description":"","completed":false,"due_at":null},`,
+				`{"external_id":"todo-2","title":"Walk dog","description":"","completed":false,"due_at":null}`,
+			},
+		},
+		"CSV": {
+			format:              "csv",
+			expectedContentType: "text/csv; charset=utf-8",
+			expectedExtension:   "csv",
+			expectedLines: []string{
+				"external_id,title,description,completed,due_at,recurrence",
+				`todo-1,"Buy milk, eggs",,false,,`,
+				"todo-2,Walk dog,,false,,",
+			},
+		},
+		"ICS": {
+			format:              "ics",
+			expectedContentType: "text/calendar; charset=utf-8",
+			expectedExtension:   "ics",
+			expectedLines: []string{
+				"BEGIN:VCALENDAR\r",
+				"UID:todo-1\r",
+				"SUMMARY:Buy milk\\, eggs\r",
+				"UID:todo-2\r",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			req, err := createTodoRequest(
+				fiber.MethodGet,
+				fmt.Sprintf("%s/export?format=%s", _todosPath, test.format),
+				true,
+				"")
+			require.NoError(t, err)
+
+			// When
+			resp, err := server.Test(req)
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, fiber.StatusOK, resp.StatusCode)
+			require.Equal(t, test.expectedContentType, resp.Header.Get(fiber.HeaderContentType))
+			require.Equal(t, "private, no-store", resp.Header.Get(fiber.HeaderCacheControl))
+			require.Regexp(t,
+				fmt.Sprintf(`^attachment; filename="todos-\d{8}\.%s"$`, test.expectedExtension),
+				resp.Header.Get(fiber.HeaderContentDisposition))
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			lines := strings.Split(string(body), "\n")
+			for _, expectedLine := range test.expectedLines {
+				require.Contains(t, lines, expectedLine)
+			}
+
+			require.NotContains(t, string(body), "Water plants")
+		})
+	}
+}
+
+func TestTodoHandlerExport_SuccessfulReadsPages(t *testing.T) {
+	// Given
+	firstPage := make([]domain.Todo, 0, _exportPageSize)
+	for i := range _exportPageSize {
+		firstPage = append(firstPage, domain.Todo{ID: i + 1, Title: "Lorem", UserID: 1, Position: i + 1})
+	}
+
+	lastPage := []domain.Todo{{ID: _exportPageSize + 1, Title: "Ipsum", UserID: 1, Position: _exportPageSize + 1}}
+
+	tsm := new(todoServiceMock)
+	tsm.On("GetPage", mock.Anything, 1, domain.Todo{}, _exportPageSize).Return(firstPage, nil)
+	tsm.On("GetPage", mock.Anything, 1, firstPage[_exportPageSize-1], _exportPageSize).Return(lastPage, nil)
+
+	server := createTodoServer(tsm)
+
+	req, err := createTodoRequest(
+		fiber.MethodGet,
+		fmt.Sprintf("%s/export?format=csv", _todosPath),
+		true,
+		"")
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+	require.Len(t, lines, _exportPageSize+2)
+	require.Equal(t, fmt.Sprintf("todo-%d,Ipsum,,false,,", _exportPageSize+1), lines[len(lines)-1])
+	tsm.AssertExpectations(t)
+	tsm.AssertNotCalled(t, "GetAll")
+}
+
+func TestTodoHandlerExport_FailsDueToUnknownFormat(t *testing.T) {
+	// Given
+	tsm := new(todoServiceMock)
+
+	server := createTodoServer(tsm)
+
+	req, err := createTodoRequest(
+		fiber.MethodGet,
+		fmt.Sprintf("%s/export?format=xml", _todosPath),
+		true,
+		"")
+	require.NoError(t, err)
+
+	// When
+	resp, _ := server.Test(req)
+
+	// Then
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response errorResponse
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Equal(t, `unknown format "xml", use json, csv or ics`, response.Error)
+	tsm.AssertNotCalled(t, "GetPage")
+}
+
+func TestTodoHandlerExport_FailsDueToServiceError(t *testing.T) {
+	// Given
+	expectedError := errors.New("sql: database is closed")
+
+	tsm := new(todoServiceMock)
+	tsm.On("GetPage", mock.Anything, 1, domain.Todo{}, _exportPageSize).Return([]domain.Todo(nil), expectedError)
+
+	server := createTodoServer(tsm)
+
+	req, err := createTodoRequest(
+		fiber.MethodGet,
+		fmt.Sprintf("%s/export?format=csv", _todosPath),
+		true,
+		"")
+	require.NoError(t, err)
+
+	// When
+	resp, _ := server.Test(req)
+
+	// Then
+	require.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response errorResponse
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Equal(t, expectedError.Error(), response.Error)
+	tsm.AssertExpectations(t)
+}
+
+func TestTodoHandlerImport_Successful(t *testing.T) {
+	// Given
+	todoService, repository := newMemoryTodoService(t, domain.Todo{ID: 1, Title: "Walk dog", UserID: 1})
+
+	server := createTodoServer(todoService)
+
+	req, err := createImportRequest(
+		"todos.csv",
+		"external_id,title,completed\ntrello-1,Buy milk,false\ntrello-2,Water plants,true\n",
+		nil)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response importResults
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Equal(t, importResults{
+		Succeeded: 2,
+		Results: []importResult{
+			{Row: 1, ExternalID: "trello-1", ID: 2, Status: fiber.StatusCreated},
+			{Row: 2, ExternalID: "trello-2", ID: 3, Status: fiber.StatusCreated},
+		},
+	}, response)
+
+	imported, err := repository.Get(context.Background(), 3)
+	require.NoError(t, err)
+	require.Equal(t, "Water plants", imported.Title)
+	require.Equal(t, "trello-2", imported.ExternalID)
+	require.True(t, imported.Completed)
+}
+
+func TestTodoHandlerImport_SuccessfulWithDryRun(t *testing.T) {
+	// Given
+	todoService, repository := newMemoryTodoService(t)
+
+	server := createTodoServer(todoService)
+
+	req, err := createImportRequest(
+		"export.txt",
+		`[{"external_id": "a", "title": "Buy milk"}]`,
+		map[string]string{"format": "json", "dry_run": "true"})
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response importResults
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Equal(t, importResults{
+		DryRun:    true,
+		Succeeded: 1,
+		Results:   []importResult{{Row: 1, ExternalID: "a", Status: fiber.StatusOK}},
+	}, response)
+
+	todos, err := repository.GetAll(context.Background(), 1)
+	require.NoError(t, err)
+	require.Empty(t, todos)
+}
+
+func TestTodoHandlerImport_PartiallyImportsFile(t *testing.T) {
+	// Given
+	todoService, _ := newMemoryTodoService(t,
+		domain.Todo{ID: 1, Title: "Walk dog", UserID: 1, ExternalID: "todo-1"})
+
+	server := createTodoServer(todoService)
+
+	req, err := createImportRequest(
+		"todos.ics",
+		"BEGIN:VCALENDAR\n"+
+			"BEGIN:VTODO\nUID:todo-1\nSUMMARY:Walk dog\nEND:VTODO\n"+
+			"BEGIN:VTODO\nUID:todo-2\nSUMMARY:Buy milk\nEND:VTODO\n"+
+			"BEGIN:VTODO\nUID:todo-3\nEND:VTODO\n"+
+			"END:VCALENDAR\n",
+		nil)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusMultiStatus, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response importResults
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, response.Succeeded)
+	require.Equal(t, 2, response.Failed)
+	require.Len(t, response.Results, 3)
+	require.Equal(t, fiber.StatusConflict, response.Results[0].Status)
+	require.Equal(t, domain.ErrDuplicateExternalID.Error(), response.Results[0].Error)
+	require.Equal(t,
+		importResult{Row: 2, ExternalID: "todo-2", ID: 2, Status: fiber.StatusCreated},
+		response.Results[1])
+	require.Equal(t, fiber.StatusUnprocessableEntity, response.Results[2].Status)
+	require.Equal(t, domain.ErrMissingTitle.Error(), response.Results[2].Error)
+}
+
+func TestTodoHandlerImport_FailsDueToInvalidFile(t *testing.T) {
+	tests := map[string]struct {
+		filename      string
+		file          string
+		fields        map[string]string
+		expectedError string
+	}{
+		"MissingFile": {
+			expectedError: "[File]: '<nil>' | Needs to implement 'required'",
+		},
+		"UnknownFormat": {
+			filename:      "todos.xlsx",
+			file:          "title\nBuy milk\n",
+			expectedError: `unknown format of "todos.xlsx", use a .json, .csv or .ics file`,
+		},
+		"UnknownFormatField": {
+			filename:      "todos.csv",
+			file:          "title\nBuy milk\n",
+			fields:        map[string]string{"format": "xml"},
+			expectedError: `unknown format "xml", use json, csv or ics`,
+		},
+		"Unreadable": {
+			filename:      "todos.csv",
+			file:          "name\nBuy milk\n",
+			expectedError: "the imported file is not valid: the header has no title column",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			tsm := new(todoServiceMock)
+
+			server := createTodoServer(tsm)
+
+			req, err := createImportRequest(test.filename, test.file, test.fields)
+			require.NoError(t, err)
+
+			// When
+			resp, _ := server.Test(req)
+
+			// Then
+			require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			var response errorResponse
+			err = json.Unmarshal(body, &response)
+			require.NoError(t, err)
+
+			require.Equal(t, test.expectedError, response.Error)
+			tsm.AssertNotCalled(t, "Import")
+		})
+	}
+}
+
+func TestTodoHandlerImport_FailsDueToConcurrentImport(t *testing.T) {
+	// Given
+	tsm := new(todoServiceMock)
+	tsm.On("Import", mock.Anything, mock.MatchedBy(func(todoImport domain.TodoImport) bool {
+		return todoImport.UserID == 1 && len(todoImport.Rows) == 1
+	})).Return([]domain.TodoImportResult(nil), domain.ErrDuplicateExternalID)
+
+	server := createTodoServer(tsm)
+
+	req, err := createImportRequest("todos.csv", "external_id,title\nmilk,Buy milk\n", nil)
+	require.NoError(t, err)
+
+	// When
+	resp, _ := server.Test(req)
+
+	// Then
+	require.Equal(t, fiber.StatusConflict, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response errorResponse
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Equal(t, domain.ErrDuplicateExternalID.Error(), response.Error)
+	tsm.AssertExpectations(t)
+}
+
+func TestTodoHandlerImport_FailsDueToServiceError(t *testing.T) {
+	// Given
+	expectedError := errors.New("Error Code: 1205. Lock wait timeout exceeded")
+
+	tsm := new(todoServiceMock)
+	tsm.On("Import", mock.Anything, mock.MatchedBy(func(todoImport domain.TodoImport) bool {
+		return todoImport.UserID == 1 && len(todoImport.Rows) == 1 && !todoImport.DryRun
+	})).Return([]domain.TodoImportResult(nil), expectedError)
+
+	server := createTodoServer(tsm)
+
+	req, err := createImportRequest("todos.csv", "title\nBuy milk\n", nil)
+	require.NoError(t, err)
+
+	// When
+	resp, _ := server.Test(req)
+
+	// Then
+	require.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response errorResponse
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Equal(t, expectedError.Error(), response.Error)
+	tsm.AssertExpectations(t)
+}
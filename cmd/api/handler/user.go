@@ -1,15 +1,20 @@
 package handler
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/ferch5003/go-fiber-tutorial/config"
 	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
 	"github.com/ferch5003/go-fiber-tutorial/internal/platform/data"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/jsonpatch"
 	"github.com/ferch5003/go-fiber-tutorial/internal/platform/jwtauth"
 	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
 	"github.com/ferch5003/go-fiber-tutorial/internal/platform/validations"
 	"github.com/ferch5003/go-fiber-tutorial/internal/user"
 	"github.com/gofiber/fiber/v2"
+	"mime"
 	"net/http"
 )
 
@@ -17,6 +22,7 @@ type UserHandler struct {
 	config         *jwtauth.Config
 	validator      *validations.XValidator
 	sessionType    string
+	requireIfMatch bool
 	userService    user.Service
 	sessionService session.Service
 }
@@ -33,6 +39,7 @@ func NewUserHandler(config *config.EnvVars, userService user.Service, sessionSer
 		config:         jwtConfig,
 		validator:      myValidator,
 		sessionType:    config.AppSessionType,
+		requireIfMatch: config.APIRequireIfMatch,
 		userService:    userService,
 		sessionService: sessionService,
 	}
@@ -46,6 +53,8 @@ type showUser struct {
 	Token     *string `json:"token,omitempty"`
 }
 
+var _showUserMapper = data.MustTagged[domain.User, showUser]()
+
 func (h *UserHandler) Get(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
@@ -54,18 +63,18 @@ func (h *UserHandler) Get(c *fiber.Ctx) error {
 		})
 	}
 
-	obtainedUser, err := h.userService.Get(c.Context(), id)
+	obtainedUser, err := h.userService.Get(c.UserContext(), id)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	var userData showUser
-	columns := []string{"ID", "FirstName", "LastName", "Email"}
-	data.OverwriteStruct(&userData, obtainedUser, columns)
+	if notModified(c, obtainedUser.Version) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
 
-	return c.Status(fiber.StatusOK).JSON(userData)
+	return c.Status(fiber.StatusOK).JSON(_showUserMapper.Map(obtainedUser))
 }
 
 type registerUser struct {
@@ -75,6 +84,8 @@ type registerUser struct {
 	Password  string `json:"password" validate:"required,min=8"`
 }
 
+var _registerUserMapper = data.MustTagged[registerUser, domain.User]()
+
 func (h *UserHandler) RegisterUser(c *fiber.Ctx) error {
 	var newUser registerUser
 	if err := c.BodyParser(&newUser); err != nil {
@@ -90,9 +101,7 @@ func (h *UserHandler) RegisterUser(c *fiber.Ctx) error {
 		})
 	}
 
-	var userData domain.User
-	columns := []string{"FirstName", "LastName", "Email", "Password"}
-	data.OverwriteStruct(&userData, newUser, columns)
+	userData := _registerUserMapper.Map(newUser)
 
 	if err := userData.HashPassword(); err != nil {
 		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
@@ -100,7 +109,7 @@ func (h *UserHandler) RegisterUser(c *fiber.Ctx) error {
 		})
 	}
 
-	createdUser, err := h.userService.Save(c.Context(), userData)
+	createdUser, err := h.userService.Save(c.UserContext(), userData)
 	if err != nil {
 		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
 			"error": err.Error(),
@@ -116,17 +125,14 @@ func (h *UserHandler) RegisterUser(c *fiber.Ctx) error {
 	}
 
 	if h.sessionType == "app" {
-		if err := h.sessionService.SetSession(c.Context(), token, claims); err != nil {
+		if err := h.sessionService.SetSession(c.UserContext(), token, claims); err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": err.Error(),
 			})
 		}
 	}
 
-	var showedUser showUser
-	columns = []string{"ID", "FirstName", "LastName", "Email"}
-	data.OverwriteStruct(&showedUser, createdUser, columns)
-
+	showedUser := _showUserMapper.Map(createdUser)
 	showedUser.Token = &token
 
 	return c.Status(fiber.StatusCreated).JSON(showedUser)
@@ -152,23 +158,19 @@ func (h *UserHandler) LoginUser(c *fiber.Ctx) error {
 		})
 	}
 
-	var userData domain.User
-	columns := []string{"Email", "Password"}
-	data.OverwriteStruct(&userData, logUser, columns)
+	obtainedUser, err := h.userService.Login(c.UserContext(), logUser.Email, logUser.Password)
+	if errors.Is(err, domain.ErrInvalidCredentials) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Email or Password are incorrect.",
+		})
+	}
 
-	obtainedUser, err := h.userService.GetByEmail(c.Context(), userData.Email)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	if err := obtainedUser.ValidatePassword(userData.Password); err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Email or Password are incorrect.",
-		})
-	}
-
 	fullName := fmt.Sprintf("%s %s", obtainedUser.FirstName, obtainedUser.LastName)
 	token, claims, err := jwtauth.GenerateToken(obtainedUser.ID, fullName, *h.config)
 	if err != nil {
@@ -178,28 +180,29 @@ func (h *UserHandler) LoginUser(c *fiber.Ctx) error {
 	}
 
 	if h.sessionType == "app" {
-		if err := h.sessionService.SetSession(c.Context(), token, claims); err != nil {
+		if err := h.sessionService.SetSession(c.UserContext(), token, claims); err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": err.Error(),
 			})
 		}
 	}
 
-	var showedUser showUser
-	columns = []string{"ID", "FirstName", "LastName", "Email"}
-	data.OverwriteStruct(&showedUser, obtainedUser, columns)
-
+	showedUser := _showUserMapper.Map(obtainedUser)
 	showedUser.Token = &token
 
 	return c.Status(fiber.StatusOK).JSON(showedUser)
 }
 
+// updateUser only writes the fields sent. Every field of a user is required, so a null or empty one is
+// rejected rather than cleared.
 type updateUser struct {
-	FirstName string `json:"first_name" validate:"omitempty,min=3,max=20"`
-	LastName  string `json:"last_name" validate:"omitempty,min=3,max=20"`
-	Email     string `json:"email" validate:"omitempty,email"`
+	FirstName data.Patch[string] `json:"first_name" validate:"omitnil,required,min=3,max=20"`
+	LastName  data.Patch[string] `json:"last_name" validate:"omitnil,required,min=3,max=20"`
+	Email     data.Patch[string] `json:"email" validate:"omitnil,required,email"`
 }
 
+var _updateUserMapper = data.MustTagged[updateUser, domain.User]()
+
 func (h *UserHandler) Update(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
@@ -221,46 +224,128 @@ func (h *UserHandler) Update(c *fiber.Ctx) error {
 		})
 	}
 
-	obtainedUser, err := h.userService.Get(c.Context(), id)
+	version, err := ifMatchVersion(c, h.requireIfMatch)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		return c.Status(preconditionStatus(err)).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	var userToUpdate updateUser
-	if err := c.BodyParser(&userToUpdate); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+	obtainedUser, err := h.userService.Get(c.UserContext(), id)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	userValidations := h.validator.GetValidations(userToUpdate)
-	if userValidations != "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": userValidations,
-		})
+	// Without If-Match the update is still based on the version just read, so it does not overwrite a
+	// concurrent update.
+	if version != 0 {
+		obtainedUser.Version = version
 	}
 
-	columns := []string{"FirstName", "LastName", "Email"}
-	data.OverwriteStruct(&obtainedUser, userToUpdate, columns)
+	if status, err := h.applyUserUpdate(c, &obtainedUser); err != nil {
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 
-	updatedUser, err := h.userService.Update(c.Context(), obtainedUser)
+	updatedUser, err := h.userService.Update(c.UserContext(), obtainedUser)
 	if err != nil {
+		if status := preconditionStatus(err); status != 0 {
+			return c.Status(status).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
 		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
 			"error": err.Error(),
 		})
 	}
 
-	var showedUser showUser
-	columns = []string{"FirstName", "LastName", "Email"}
-	data.OverwriteStruct(&showedUser, updatedUser, columns)
+	if updatedUser.Version != 0 {
+		c.Set(fiber.HeaderETag, versionETag(updatedUser.Version))
+	}
 
+	showedUser := _showUserMapper.Map(updatedUser)
 	showedUser.ID = id
 
 	return c.Status(fiber.StatusOK).JSON(showedUser)
 }
 
+// patchableUser is the document of a user that JSON patches are applied to, every field is required.
+type patchableUser struct {
+	FirstName string `json:"first_name" validate:"required,min=3,max=20"`
+	LastName  string `json:"last_name" validate:"required,min=3,max=20"`
+	Email     string `json:"email" validate:"required,email"`
+}
+
+var (
+	_patchableUserMapper = data.MustTagged[domain.User, patchableUser]()
+	_patchedUserMapper   = data.MustTagged[patchableUser, domain.User]()
+)
+
+// applyUserUpdate applies the body of an update to the user, by its content type: a JSON Merge Patch, a
+// JSON Patch, or the fields of updateUser. It returns the status of the response when it fails.
+func (h *UserHandler) applyUserUpdate(c *fiber.Ctx, user *domain.User) (int, error) {
+	contentType, _, _ := mime.ParseMediaType(c.Get(fiber.HeaderContentType))
+	if contentType != jsonpatch.MergePatchContentType && contentType != jsonpatch.PatchContentType {
+		var userToUpdate updateUser
+		if err := c.BodyParser(&userToUpdate); err != nil {
+			return fiber.StatusBadRequest, err
+		}
+
+		if userValidations := h.validator.GetValidations(userToUpdate); userValidations != "" {
+			return fiber.StatusBadRequest, errors.New(userValidations)
+		}
+
+		_updateUserMapper.Into(user, userToUpdate)
+
+		return 0, nil
+	}
+
+	document, err := json.Marshal(_patchableUserMapper.Map(*user))
+	if err != nil {
+		return fiber.StatusInternalServerError, err
+	}
+
+	var patchedDocument []byte
+	if contentType == jsonpatch.MergePatchContentType {
+		patchedDocument, err = jsonpatch.MergePatch(document, c.Body())
+	} else {
+		var patch jsonpatch.Patch
+		if patch, err = jsonpatch.DecodePatch(c.Body()); err == nil {
+			patchedDocument, err = patch.Apply(document)
+		}
+	}
+
+	switch {
+	case errors.Is(err, jsonpatch.ErrInvalidPatch):
+		return fiber.StatusBadRequest, err
+	case errors.Is(err, jsonpatch.ErrTestFailed):
+		return fiber.StatusConflict, err
+	case err != nil:
+		return fiber.StatusUnprocessableEntity, err
+	}
+
+	// Members that are not fields of the document, like the password, can not be patched.
+	decoder := json.NewDecoder(bytes.NewReader(patchedDocument))
+	decoder.DisallowUnknownFields()
+
+	var patchedUser patchableUser
+	if err := decoder.Decode(&patchedUser); err != nil {
+		return fiber.StatusUnprocessableEntity, fmt.Errorf("patched user is not valid: %w", err)
+	}
+
+	if userValidations := h.validator.GetValidations(patchedUser); userValidations != "" {
+		return fiber.StatusBadRequest, errors.New(userValidations)
+	}
+
+	_patchedUserMapper.Into(user, patchedUser)
+
+	return 0, nil
+}
+
 func (h *UserHandler) Delete(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
@@ -282,7 +367,20 @@ func (h *UserHandler) Delete(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.userService.Delete(c.Context(), id); err != nil {
+	version, err := ifMatchVersion(c, h.requireIfMatch)
+	if err != nil {
+		return c.Status(preconditionStatus(err)).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err := h.userService.Delete(c.UserContext(), id, version); err != nil {
+		if status := preconditionStatus(err); status != 0 {
+			return c.Status(status).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -3,12 +3,18 @@ package handler
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
 	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
 	"github.com/ferch5003/go-fiber-tutorial/internal/middlewares"
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/jsonpatch"
 	"github.com/ferch5003/go-fiber-tutorial/internal/platform/jwtauth"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
+	"github.com/ferch5003/go-fiber-tutorial/internal/user"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -55,29 +61,29 @@ func (usm *userServiceMock) Update(ctx context.Context, user domain.User) (domai
 	return args.Get(0).(domain.User), args.Error(1)
 }
 
-func (usm *userServiceMock) Delete(ctx context.Context, id int) error {
-	args := usm.Called(ctx, id)
+func (usm *userServiceMock) Delete(ctx context.Context, id int, version int) error {
+	args := usm.Called(ctx, id, version)
 	return args.Error(0)
 }
 
-func createUserServer(usm *userServiceMock) *fiber.App {
+func (usm *userServiceMock) Login(ctx context.Context, email string, password string) (domain.User, error) {
+	args := usm.Called(ctx, email, password)
+	return args.Get(0).(domain.User), args.Error(1)
+}
+
+func createUserServer(userService user.Service) *fiber.App {
 	app := fiber.New()
 
-	ssm := new(sessionServiceMock)
-	ssm.On("SetSession", mock.Anything, mock.Anything, mock.Anything).Return(nil)
-	ssm.On("GetSession", mock.Anything, mock.Anything).Return(map[string]string{
-		"iss":  "test",
-		"sub":  "1",
-		"name": "test",
-	}, nil)
+	// Sessions are stored by the JWT middleware, like in the application.
+	sessionService := session.NewService(session.NewMemoryRepository())
 
-	userHandler := NewUserHandler(_testConfigs, usm, ssm)
+	userHandler := NewUserHandler(_testConfigs, userService, sessionService)
 
 	jwtMiddleware := middlewares.NewJWTMiddleware(
 		context.Background(),
 		_testConfigs.AppSessionType,
 		_testConfigs.AppSecretKey,
-		ssm,
+		sessionService,
 	)
 
 	app.Route("/users", func(api fiber.Router) {
@@ -94,6 +100,22 @@ func createUserServer(usm *userServiceMock) *fiber.App {
 	return app
 }
 
+// newMemoryUserService creates a user.Service backed by an in-memory repository holding users, which
+// get IDs from 1, in order.
+func newMemoryUserService(t *testing.T, users ...domain.User) (user.Service, user.Repository) {
+	t.Helper()
+
+	events := audit.NewMemoryRepository()
+	repository := user.NewMemoryRepository(events, outbox.NewMemoryRepository())
+
+	for _, userData := range users {
+		_, err := repository.Save(context.Background(), userData)
+		require.NoError(t, err)
+	}
+
+	return user.NewService(repository, events), repository
+}
+
 func createUserRequest(method string, url string, userSession *_jwtInfo, body string) (*http.Request, error) {
 	req := httptest.NewRequest(method, url, bytes.NewBuffer([]byte(body)))
 	req.Header.Add("Content-Type", "application/json")
@@ -120,15 +142,15 @@ func TestUserHandlerGet_Successful(t *testing.T) {
 
 	expectedUserID := 1
 	expectedUser := showUser{
+		ID:        expectedUserID,
 		FirstName: "John",
 		LastName:  "Smith",
 		Email:     "john@example.com",
 	}
 
-	usm := new(userServiceMock)
-	usm.On("Get", mock.Anything, expectedUserID).Return(userData, nil)
+	userService, _ := newMemoryUserService(t, userData)
 
-	server := createUserServer(usm)
+	server := createUserServer(userService)
 
 	req, err := createUserRequest(
 		fiber.MethodGet,
@@ -138,7 +160,7 @@ func TestUserHandlerGet_Successful(t *testing.T) {
 	require.NoError(t, err)
 
 	// When
-	resp, err := server.Test(req)
+	resp, err := server.Test(req, -1)
 
 	// Then
 	require.Equal(t, fiber.StatusOK, resp.StatusCode)
@@ -154,6 +176,30 @@ func TestUserHandlerGet_Successful(t *testing.T) {
 	require.EqualValues(t, expectedUser, showedUser)
 }
 
+func TestUserHandlerGet_NotModified(t *testing.T) {
+	// Given
+	userService, _ := newMemoryUserService(t, domain.User{
+		FirstName: "John",
+		LastName:  "Smith",
+		Email:     "john@example.com",
+	})
+
+	server := createUserServer(userService)
+
+	req, err := createUserRequest(fiber.MethodGet, fmt.Sprintf("%s/%d", _usersPath, 1), nil, "")
+	require.NoError(t, err)
+
+	req.Header.Set(fiber.HeaderIfNoneMatch, `W/"1"`)
+
+	// When
+	resp, err := server.Test(req, -1)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusNotModified, resp.StatusCode)
+	require.Equal(t, `"1"`, resp.Header.Get(fiber.HeaderETag))
+}
+
 func TestUserHandlerGet_FailsDueToInvalidIntParam(t *testing.T) {
 	// Given
 	usm := new(userServiceMock)
@@ -212,16 +258,6 @@ func TestUserHandlerGet_FailsDueToServiceError(t *testing.T) {
 
 func TestUserHandlerRegisterUser_Successful(t *testing.T) {
 	// Given
-	userData := domain.User{
-		FirstName: "John",
-		LastName:  "Smith",
-		Email:     "john@example.com",
-		Password:  "12345678",
-	}
-
-	savedUser := userData
-	savedUser.ID = 1
-
 	expectedUser := showUser{
 		ID:        1,
 		FirstName: "John",
@@ -229,10 +265,9 @@ func TestUserHandlerRegisterUser_Successful(t *testing.T) {
 		Email:     "john@example.com",
 	}
 
-	usm := new(userServiceMock)
-	usm.On("Save", mock.Anything, mock.AnythingOfType("domain.User")).Return(savedUser, nil)
+	userService, repository := newMemoryUserService(t)
 
-	server := createUserServer(usm)
+	server := createUserServer(userService)
 
 	req, err := createUserRequest(fiber.MethodPost, _usersPath+"/register", nil, `{
 																	"first_name": "John",
@@ -243,7 +278,7 @@ func TestUserHandlerRegisterUser_Successful(t *testing.T) {
 	require.NoError(t, err)
 
 	// When
-	resp, err := server.Test(req)
+	resp, err := server.Test(req, -1)
 
 	// Then
 	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
@@ -261,6 +296,9 @@ func TestUserHandlerRegisterUser_Successful(t *testing.T) {
 
 	require.EqualValues(t, expectedUser, showedUser)
 	require.NotNil(t, token)
+	storedUser, err := repository.GetByEmail(context.Background(), "john@example.com")
+	require.NoError(t, err)
+	require.NoError(t, storedUser.ValidatePassword("12345678")) // Stored hashed.
 }
 
 func TestUserHandlerRegisterUser_FailsDueToInvalidJSONBodyParse(t *testing.T) {
@@ -314,7 +352,7 @@ func TestUserHandlerRegisterUser_FailsDueToValidations(t *testing.T) {
 	require.Contains(t, response.Error, "[FirstName]: '' | Needs to implement 'required'")
 	require.Contains(t, response.Error, "[LastName]: '' | Needs to implement 'required'")
 	require.Contains(t, response.Error, "[Email]: '' | Needs to implement 'required'")
-	require.Contains(t, response.Error, "[Password]: '' | Needs to implement 'required'")
+	require.Contains(t, response.Error, "[Password]: '[REDACTED]' | Needs to implement 'required'")
 }
 
 func TestUserHandlerRegisterUser_FailsDueToServiceError(t *testing.T) {
@@ -414,10 +452,9 @@ func TestUserHandlerLoginUser_Successful(t *testing.T) {
 		Email:     "john@example.com",
 	}
 
-	usm := new(userServiceMock)
-	usm.On("GetByEmail", mock.Anything, userData.Email).Return(loggedUser, nil)
+	userService, _ := newMemoryUserService(t, loggedUser)
 
-	server := createUserServer(usm)
+	server := createUserServer(userService)
 
 	req, err := createUserRequest(fiber.MethodPost, _usersPath+"/login", nil, `{
 																	"email": "john@example.com",
@@ -426,7 +463,7 @@ func TestUserHandlerLoginUser_Successful(t *testing.T) {
 	require.NoError(t, err)
 
 	// When
-	resp, err := server.Test(req)
+	resp, err := server.Test(req, -1)
 
 	// Then
 	require.Equal(t, fiber.StatusOK, resp.StatusCode)
@@ -495,7 +532,7 @@ func TestUserHandlerLoginUser_FailsDueToValidations(t *testing.T) {
 	require.NoError(t, err)
 
 	require.Contains(t, response.Error, "[Email]: '' | Needs to implement 'required'")
-	require.Contains(t, response.Error, "[Password]: '' | Needs to implement 'required'")
+	require.Contains(t, response.Error, "[Password]: '[REDACTED]' | Needs to implement 'required'")
 }
 
 func TestUserHandlerLoginUser_FailsDueToServiceError(t *testing.T) {
@@ -510,7 +547,7 @@ func TestUserHandlerLoginUser_FailsDueToServiceError(t *testing.T) {
 	expectedError := errors.New("Error Code: 1136. Column count doesn't match value count at row 1")
 
 	usm := new(userServiceMock)
-	usm.On("GetByEmail", mock.Anything, userData.Email).Return(domain.User{}, expectedError)
+	usm.On("Login", mock.Anything, userData.Email, userData.Password).Return(domain.User{}, expectedError)
 
 	server := createUserServer(usm)
 
@@ -551,10 +588,9 @@ func TestUserHandlerLoginUser_FailsDueToInvalidCredentials(t *testing.T) {
 
 	expectedError := errors.New("Email or Password are incorrect.")
 
-	usm := new(userServiceMock)
-	usm.On("GetByEmail", mock.Anything, userData.Email).Return(loggedUser, nil)
+	userService, _ := newMemoryUserService(t, loggedUser)
 
-	server := createUserServer(usm)
+	server := createUserServer(userService)
 
 	req, err := createUserRequest(fiber.MethodPost, _usersPath+"/login", nil, `{
 																	"email": "john@example.com",
@@ -563,7 +599,7 @@ func TestUserHandlerLoginUser_FailsDueToInvalidCredentials(t *testing.T) {
 	require.NoError(t, err)
 
 	// When
-	resp, _ := server.Test(req)
+	resp, _ := server.Test(req, -1)
 
 	// Then
 	require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
@@ -578,6 +614,34 @@ func TestUserHandlerLoginUser_FailsDueToInvalidCredentials(t *testing.T) {
 	require.ErrorContains(t, expectedError, response.Error)
 }
 
+func TestUserHandlerLoginUser_FailsDueToUnknownEmail(t *testing.T) {
+	// Given
+	userService, _ := newMemoryUserService(t)
+
+	server := createUserServer(userService)
+
+	req, err := createUserRequest(fiber.MethodPost, _usersPath+"/login", nil, `{
+																	"email": "john@example.com",
+																	"password": "12345678"
+																}`)
+	require.NoError(t, err)
+
+	// When
+	resp, _ := server.Test(req, -1)
+
+	// Then
+	require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response errorResponse
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Equal(t, "Email or Password are incorrect.", response.Error)
+}
+
 func TestUserHandlerUpdate_Successful(t *testing.T) {
 	// Given
 	userData := domain.User{
@@ -593,9 +657,6 @@ func TestUserHandlerUpdate_Successful(t *testing.T) {
 		Name: fmt.Sprintf("%s %s", userData.FirstName, userData.LastName),
 	}
 
-	updatedUser := userData
-	updatedUser.LastName = "Second"
-
 	expectedUser := showUser{
 		ID:        1,
 		FirstName: "John",
@@ -603,11 +664,9 @@ func TestUserHandlerUpdate_Successful(t *testing.T) {
 		Email:     "john@example.com",
 	}
 
-	usm := new(userServiceMock)
-	usm.On("Get", mock.Anything, userData.ID).Return(userData, nil)
-	usm.On("Update", mock.Anything, updatedUser).Return(updatedUser, nil)
+	userService, repository := newMemoryUserService(t, userData)
 
-	server := createUserServer(usm)
+	server := createUserServer(userService)
 
 	req, err := createUserRequest(fiber.MethodPatch, fmt.Sprintf("%s/%d", _usersPath, userData.ID), authUser, `{
 																	"last_name": "Second"
@@ -615,7 +674,7 @@ func TestUserHandlerUpdate_Successful(t *testing.T) {
 	require.NoError(t, err)
 
 	// When
-	resp, err := server.Test(req)
+	resp, err := server.Test(req, -1)
 
 	// Then
 	require.Equal(t, fiber.StatusOK, resp.StatusCode)
@@ -629,6 +688,60 @@ func TestUserHandlerUpdate_Successful(t *testing.T) {
 	require.NoError(t, err)
 
 	require.EqualValues(t, expectedUser, showedUser)
+	require.Equal(t, `"2"`, resp.Header.Get(fiber.HeaderETag))
+	storedUser, err := repository.Get(context.Background(), userData.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Second", storedUser.LastName)
+}
+
+func TestUserHandlerUpdate_FailsDueToStaleIfMatch(t *testing.T) {
+	// Given
+	userData := domain.User{
+		ID:        1,
+		FirstName: "John",
+		LastName:  "Smith",
+		Email:     "john@example.com",
+		Password:  "12345678",
+	}
+
+	authUser := &_jwtInfo{
+		ID:   userData.ID,
+		Name: fmt.Sprintf("%s %s", userData.FirstName, userData.LastName),
+	}
+
+	userService, repository := newMemoryUserService(t, userData)
+
+	err := repository.Update(context.Background(), domain.User{ID: userData.ID, FirstName: "Johnny"})
+	require.NoError(t, err)
+
+	server := createUserServer(userService)
+
+	req, err := createUserRequest(fiber.MethodPatch, fmt.Sprintf("%s/%d", _usersPath, userData.ID), authUser, `{
+																	"last_name": "Second"
+																}`)
+	require.NoError(t, err)
+
+	req.Header.Set(fiber.HeaderIfMatch, `"1"`)
+
+	// When
+	resp, err := server.Test(req, -1)
+
+	// Then
+	require.Equal(t, fiber.StatusPreconditionFailed, resp.StatusCode)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response errorResponse
+	err = json.Unmarshal(body, &response)
+	require.NoError(t, err)
+
+	require.Equal(t, domain.ErrVersionMismatch.Error(), response.Error)
+
+	storedUser, err := repository.Get(context.Background(), userData.ID)
+	require.NoError(t, err)
+	require.Equal(t, "Smith", storedUser.LastName)
 }
 
 func TestUserHandlerUpdate_FailsDueToInvalidIntParam(t *testing.T) {
@@ -820,6 +933,196 @@ func TestUserHandlerUpdate_FailsDueToIValidations(t *testing.T) {
 	require.Contains(t, response.Error, "'c' | Needs to implement 'email'")            // c is not email format
 }
 
+func TestUserHandlerUpdate_SuccessfulWithPatches(t *testing.T) {
+	tests := map[string]struct {
+		contentType string
+		body        string
+	}{
+		"MergePatch": {
+			contentType: jsonpatch.MergePatchContentType,
+			body:        `{"last_name": "Second", "email": "second@example.com"}`,
+		},
+		"JSONPatch": {
+			contentType: jsonpatch.PatchContentType,
+			body: `[
+				{"op": "test", "path": "/first_name", "value": "John"},
+				{"op": "replace", "path": "/last_name", "value": "Second"},
+				{"op": "replace", "path": "/email", "value": "second@example.com"}
+			]`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			userData := domain.User{
+				ID:        1,
+				FirstName: "John",
+				LastName:  "Smith",
+				Email:     "john@example.com",
+				Password:  "12345678",
+			}
+
+			authUser := &_jwtInfo{
+				ID:   userData.ID,
+				Name: fmt.Sprintf("%s %s", userData.FirstName, userData.LastName),
+			}
+
+			userService, repository := newMemoryUserService(t, userData)
+
+			server := createUserServer(userService)
+
+			req, err := createUserRequest(fiber.MethodPatch, fmt.Sprintf("%s/%d", _usersPath, userData.ID), authUser,
+				test.body)
+			require.NoError(t, err)
+
+			req.Header.Set(fiber.HeaderContentType, test.contentType)
+
+			// When
+			resp, err := server.Test(req, -1)
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			var showedUser showUser
+			err = json.Unmarshal(body, &showedUser)
+			require.NoError(t, err)
+
+			require.Equal(t, showUser{
+				ID:        1,
+				FirstName: "John",
+				LastName:  "Second",
+				Email:     "second@example.com",
+			}, showedUser)
+
+			storedUser, err := repository.Get(context.Background(), userData.ID)
+			require.NoError(t, err)
+			require.Equal(t, "Second", storedUser.LastName)
+			require.Equal(t, "second@example.com", storedUser.Email)
+		})
+	}
+}
+
+func TestUserHandlerUpdate_FailsDueToInvalidPatches(t *testing.T) {
+	tests := map[string]struct {
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedError  string
+	}{
+		"MalformedMergePatch": {
+			contentType:    jsonpatch.MergePatchContentType,
+			body:           `{"last_name": `,
+			expectedStatus: fiber.StatusBadRequest,
+			expectedError:  "invalid patch: unexpected EOF",
+		},
+		"ClearedRequiredField": {
+			contentType:    jsonpatch.MergePatchContentType,
+			body:           `{"last_name": null}`,
+			expectedStatus: fiber.StatusBadRequest,
+			expectedError:  "[LastName]: '' | Needs to implement 'required'",
+		},
+		"NullRequiredField": {
+			contentType:    fiber.MIMEApplicationJSON,
+			body:           `{"first_name": null}`,
+			expectedStatus: fiber.StatusBadRequest,
+			expectedError:  "[FirstName]: '' | Needs to implement 'required'",
+		},
+		"EmptyRequiredField": {
+			contentType:    fiber.MIMEApplicationJSON,
+			body:           `{"first_name": ""}`,
+			expectedStatus: fiber.StatusBadRequest,
+			expectedError:  "[FirstName]: '' | Needs to implement 'required'",
+		},
+		"UnknownMember": {
+			contentType:    jsonpatch.MergePatchContentType,
+			body:           `{"password": "87654321"}`,
+			expectedStatus: fiber.StatusUnprocessableEntity,
+			expectedError:  `patched user is not valid: json: unknown field "password"`,
+		},
+		"UnknownOperation": {
+			contentType:    jsonpatch.PatchContentType,
+			body:           `[{"op": "increment", "path": "/first_name"}]`,
+			expectedStatus: fiber.StatusBadRequest,
+			expectedError:  `operation 0: invalid patch: unknown op "increment"`,
+		},
+		"MissingPath": {
+			contentType:    jsonpatch.PatchContentType,
+			body:           `[{"op": "remove", "path": "/password"}]`,
+			expectedStatus: fiber.StatusUnprocessableEntity,
+			expectedError:  `operation 0 (remove /password): invalid path: member "password" does not exist`,
+		},
+		"AddedUnknownMember": {
+			contentType:    jsonpatch.PatchContentType,
+			body:           `[{"op": "add", "path": "/id", "value": 2}]`,
+			expectedStatus: fiber.StatusUnprocessableEntity,
+			expectedError:  `patched user is not valid: json: unknown field "id"`,
+		},
+		"WrongType": {
+			contentType:    jsonpatch.PatchContentType,
+			body:           `[{"op": "replace", "path": "/first_name", "value": 5}]`,
+			expectedStatus: fiber.StatusUnprocessableEntity,
+			expectedError:  "patched user is not valid: json: cannot unmarshal number",
+		},
+		"FailedTest": {
+			contentType:    jsonpatch.PatchContentType,
+			body:           `[{"op": "test", "path": "/first_name", "value": "Johnny"}]`,
+			expectedStatus: fiber.StatusConflict,
+			expectedError:  "operation 0 (test /first_name): test failed",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			userData := domain.User{
+				ID:        1,
+				FirstName: "John",
+				LastName:  "Smith",
+				Email:     "john@example.com",
+				Password:  "12345678",
+			}
+
+			usm := new(userServiceMock)
+			usm.On("Get", mock.Anything, userData.ID).Return(userData, nil)
+
+			server := createUserServer(usm)
+
+			authUser := &_jwtInfo{
+				ID:   1,
+				Name: "John Smith",
+			}
+
+			req, err := createUserRequest(fiber.MethodPatch, fmt.Sprintf("%s/%d", _usersPath, userData.ID), authUser,
+				test.body)
+			require.NoError(t, err)
+
+			req.Header.Set(fiber.HeaderContentType, test.contentType)
+
+			// When
+			resp, err := server.Test(req)
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, test.expectedStatus, resp.StatusCode)
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			var response errorResponse
+			err = json.Unmarshal(body, &response)
+			require.NoError(t, err)
+
+			require.Contains(t, response.Error, test.expectedError)
+			usm.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		})
+	}
+}
+
 func TestUserHandlerUpdate_FailsDueToServiceError(t *testing.T) {
 	// Given
 	userData := domain.User{
@@ -872,10 +1175,14 @@ func TestUserHandlerDelete_Successful(t *testing.T) {
 	// Given
 	expectedUserID := 1
 
-	usm := new(userServiceMock)
-	usm.On("Delete", mock.Anything, expectedUserID).Return(nil)
+	userService, repository := newMemoryUserService(t, domain.User{
+		FirstName: "John",
+		LastName:  "Smith",
+		Email:     "john@example.com",
+		Password:  "12345678",
+	})
 
-	server := createUserServer(usm)
+	server := createUserServer(userService)
 
 	authUser := &_jwtInfo{
 		ID:   1,
@@ -886,11 +1193,46 @@ func TestUserHandlerDelete_Successful(t *testing.T) {
 	require.NoError(t, err)
 
 	// When
-	resp, err := server.Test(req)
+	resp, err := server.Test(req, -1)
 
 	// Then
 	require.Equal(t, fiber.StatusNoContent, resp.StatusCode)
 	require.NoError(t, err)
+	_, err = repository.Get(context.Background(), expectedUserID)
+	require.ErrorIs(t, err, sql.ErrNoRows)
+}
+
+func TestUserHandlerDelete_FailsDueToStaleIfMatch(t *testing.T) {
+	// Given
+	expectedUserID := 1
+
+	userService, repository := newMemoryUserService(t, domain.User{
+		FirstName: "John",
+		LastName:  "Smith",
+		Email:     "john@example.com",
+		Password:  "12345678",
+	})
+
+	server := createUserServer(userService)
+
+	authUser := &_jwtInfo{
+		ID:   1,
+		Name: "John Smith",
+	}
+
+	req, err := createUserRequest(fiber.MethodDelete, fmt.Sprintf("%s/%d", _usersPath, expectedUserID), authUser, "")
+	require.NoError(t, err)
+
+	req.Header.Set(fiber.HeaderIfMatch, `"2"`)
+
+	// When
+	resp, err := server.Test(req, -1)
+
+	// Then
+	require.Equal(t, fiber.StatusPreconditionFailed, resp.StatusCode)
+	require.NoError(t, err)
+	_, err = repository.Get(context.Background(), expectedUserID)
+	require.NoError(t, err)
 }
 
 func TestUserHandlerDelete_FailsDueToInvalidIntParam(t *testing.T) {
@@ -931,7 +1273,7 @@ func TestUserHandlerDelete_FailsDueToUnauthorizedUser(t *testing.T) {
 	expectedErr := errors.New("Updating not user resource")
 
 	usm := new(userServiceMock)
-	usm.On("Delete", mock.Anything, expectedUserID).Return(expectedErr)
+	usm.On("Delete", mock.Anything, expectedUserID, 0).Return(expectedErr)
 
 	server := createUserServer(usm)
 
@@ -965,7 +1307,7 @@ func TestUserHandlerDelete_FailsDueToServiceError(t *testing.T) {
 	expectedErr := errors.New("no rows affected")
 
 	usm := new(userServiceMock)
-	usm.On("Delete", mock.Anything, expectedUserID).Return(expectedErr)
+	usm.On("Delete", mock.Anything, expectedUserID, 0).Return(expectedErr)
 
 	server := createUserServer(usm)
 
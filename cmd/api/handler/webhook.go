@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"errors"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/validations"
+	"github.com/ferch5003/go-fiber-tutorial/internal/webhook"
+	"github.com/gofiber/fiber/v2"
+)
+
+// _defaultDeliveriesLimit is the number of deliveries listed when the client does not choose it.
+const _defaultDeliveriesLimit = 20
+
+type WebhookHandler struct {
+	validator      *validations.XValidator
+	sessionType    string
+	webhookService webhook.Service
+	sessionService session.Service
+}
+
+func NewWebhookHandler(
+	cfg *config.EnvVars,
+	webhookService webhook.Service,
+	sessionService session.Service) *WebhookHandler {
+	myValidator := validations.NewValidator()
+
+	return &WebhookHandler{
+		validator:      myValidator,
+		sessionType:    cfg.AppSessionType,
+		webhookService: webhookService,
+		sessionService: sessionService,
+	}
+}
+
+type createWebhook struct {
+	URL string `json:"url" validate:"required,http_url,max=2048"`
+
+	// Events are some of domain.WebhookEvents.
+	Events []string `json:"events" validate:"required,min=1,dive,oneof=todo.created todo.updated todo.completed todo.deleted user.updated"`
+}
+
+// Create registers a webhook of the authenticated user. The response is the only one with the secret
+// that signs its deliveries. URLs pointing to loopback, private or link-local addresses are rejected.
+func (h *WebhookHandler) Create(c *fiber.Ctx) error {
+	userID, err := getAuthUserID(c, h.sessionService, h.sessionType)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var webhookData createWebhook
+	if err := c.BodyParser(&webhookData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	webhookValidations := h.validator.GetValidations(webhookData)
+	if webhookValidations != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": webhookValidations,
+		})
+	}
+
+	createdWebhook, err := h.webhookService.Create(c.UserContext(), domain.Webhook{
+		UserID: userID,
+		URL:    webhookData.URL,
+		Events: webhookData.Events,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(createdWebhook)
+}
+
+// GetAll lists the webhooks of the authenticated user, from the oldest.
+func (h *WebhookHandler) GetAll(c *fiber.Ctx) error {
+	userID, err := getAuthUserID(c, h.sessionService, h.sessionType)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	webhooks, err := h.webhookService.GetAll(c.UserContext(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(webhooks)
+}
+
+type updateWebhook struct {
+	URL    string `json:"url" validate:"omitempty,http_url,max=2048"`
+	Active *bool  `json:"active"`
+
+	// Events are some of domain.WebhookEvents.
+	Events []string `json:"events" validate:"omitempty,min=1,dive,oneof=todo.created todo.updated todo.completed todo.deleted user.updated"`
+}
+
+// Update changes the URL, events or state of a webhook of the authenticated user. Fields left empty are
+// kept, and enabling a disabled webhook forgets its failures.
+func (h *WebhookHandler) Update(c *fiber.Ctx) error {
+	var webhookData updateWebhook
+	if err := c.BodyParser(&webhookData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	webhookValidations := h.validator.GetValidations(webhookData)
+	if webhookValidations != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": webhookValidations,
+		})
+	}
+
+	return h.withOwnedWebhook(c, func(obtainedWebhook domain.Webhook) error {
+		if webhookData.URL != "" {
+			obtainedWebhook.URL = webhookData.URL
+		}
+
+		if len(webhookData.Events) > 0 {
+			obtainedWebhook.Events = webhookData.Events
+		}
+
+		if webhookData.Active != nil {
+			obtainedWebhook.Active = *webhookData.Active
+		}
+
+		updatedWebhook, err := h.webhookService.Update(c.UserContext(), obtainedWebhook)
+		if err != nil {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(updatedWebhook)
+	})
+}
+
+// Delete removes a webhook of the authenticated user, along with its deliveries.
+func (h *WebhookHandler) Delete(c *fiber.Ctx) error {
+	return h.withOwnedWebhook(c, func(obtainedWebhook domain.Webhook) error {
+		if err := h.webhookService.Delete(c.UserContext(), obtainedWebhook.ID); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"message": "Webhook deleted successfully",
+		})
+	})
+}
+
+type listDeliveries struct {
+	Limit int `query:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+// Deliveries lists the last deliveries of a webhook of the authenticated user, from the newest.
+func (h *WebhookHandler) Deliveries(c *fiber.Ctx) error {
+	var listData listDeliveries
+	if err := c.QueryParser(&listData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	listValidations := h.validator.GetValidations(listData)
+	if listValidations != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": listValidations,
+		})
+	}
+
+	if listData.Limit == 0 {
+		listData.Limit = _defaultDeliveriesLimit
+	}
+
+	return h.withOwnedWebhook(c, func(obtainedWebhook domain.Webhook) error {
+		deliveries, err := h.webhookService.Deliveries(c.UserContext(), obtainedWebhook.ID, listData.Limit)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusOK).JSON(deliveries)
+	})
+}
+
+// Redeliver sends the event of a delivery of a webhook of the authenticated user again, as a new
+// delivery that is attempted in the background.
+func (h *WebhookHandler) Redeliver(c *fiber.Ctx) error {
+	deliveryID, err := c.ParamsInt("deliveryID")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return h.withOwnedWebhook(c, func(obtainedWebhook domain.Webhook) error {
+		delivery, err := h.webhookService.GetDelivery(c.UserContext(), deliveryID)
+		if err == nil && delivery.WebhookID != obtainedWebhook.ID {
+			// Deliveries are only found through their webhook.
+			err = domain.ErrNotFound
+		}
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		redelivery, err := h.webhookService.Redeliver(c.UserContext(), delivery.ID)
+		if errors.Is(err, domain.ErrWebhookDisabled) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		return c.Status(fiber.StatusAccepted).JSON(redelivery)
+	})
+}
+
+// withOwnedWebhook responds with handle when the webhook of the id parameter belongs to the authenticated
+// user.
+func (h *WebhookHandler) withOwnedWebhook(c *fiber.Ctx, handle func(obtainedWebhook domain.Webhook) error) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	userID, err := getAuthUserID(c, h.sessionService, h.sessionType)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	obtainedWebhook, err := h.webhookService.Get(c.UserContext(), id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	if obtainedWebhook.UserID != userID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "This webhook is not from this user",
+		})
+	}
+
+	return handle(obtainedWebhook)
+}
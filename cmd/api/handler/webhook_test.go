@@ -0,0 +1,384 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/middlewares"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/jobs"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
+	"github.com/ferch5003/go-fiber-tutorial/internal/webhook"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const _webhooksPath = "/webhooks"
+
+func createWebhookServer(cfg *config.EnvVars, webhookService webhook.Service) *fiber.App {
+	app := fiber.New()
+
+	// Sessions are stored by the JWT middleware, like in the application.
+	sessionService := session.NewService(session.NewMemoryRepository())
+
+	webhookHandler := NewWebhookHandler(cfg, webhookService, sessionService)
+
+	jwtMiddleware := middlewares.NewJWTMiddleware(
+		context.Background(),
+		cfg.AppSessionType,
+		cfg.AppSecretKey,
+		sessionService,
+	)
+
+	webhooks := app.Group(_webhooksPath, jwtMiddleware.GetMiddleware())
+	webhooks.Get("/", webhookHandler.GetAll).Name("webhooks.get_all")
+	webhooks.Post("/", webhookHandler.Create).Name("webhooks.create")
+	webhooks.Patch("/:id<int>", webhookHandler.Update).Name("webhooks.update")
+	webhooks.Delete("/:id<int>", webhookHandler.Delete).Name("webhooks.delete")
+	webhooks.Get("/:id<int>/deliveries", webhookHandler.Deliveries).Name("webhooks.deliveries")
+	webhooks.Post("/:id<int>/deliveries/:deliveryID<int>/redeliver", webhookHandler.Redeliver).
+		Name("webhooks.redeliver")
+
+	return app
+}
+
+// newWebhookService creates a webhook.Service backed by an in-memory repository, whose dispatcher is
+// stopped when the test finishes. Deliveries to local addresses are allowed, for the receivers.
+func newWebhookService(t *testing.T) (webhook.Service, webhook.Repository) {
+	t.Helper()
+
+	repository := webhook.NewMemoryRepository()
+	dispatcher := webhook.NewDispatcher(repository, jobs.NewMemoryQueue(), webhook.DispatcherConfig{
+		MaxAttempts: 1,
+		AllowLocal:  true,
+	})
+
+	require.NoError(t, dispatcher.Start(context.Background()))
+
+	t.Cleanup(func() {
+		require.NoError(t, dispatcher.Stop(context.Background()))
+	})
+
+	return webhook.NewService(repository, dispatcher), repository
+}
+
+// saveWebhook saves a webhook of the user subscribed to the created todos.
+func saveWebhook(t *testing.T, repository webhook.Repository, userID int, url string) domain.Webhook {
+	t.Helper()
+
+	saved, err := repository.Save(context.Background(), domain.Webhook{
+		UserID: userID,
+		URL:    url,
+		Events: domain.WebhookFilter{domain.TodoEventCreated},
+		Secret: "whsec_secret",
+		Active: true,
+	})
+	require.NoError(t, err)
+
+	return saved
+}
+
+func TestWebhookHandlerCreate_Successful(t *testing.T) {
+	// Given
+	webhookService, _ := newWebhookService(t)
+	server := createWebhookServer(_testConfigs, webhookService)
+
+	req, err := createTodoRequest(fiber.MethodPost, _webhooksPath, true,
+		`{"url": "https://example.com/webhook", "events": ["todo.created", "user.updated"]}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var created domain.Webhook
+	require.NoError(t, json.Unmarshal(body, &created))
+	require.Positive(t, created.ID)
+	require.Equal(t, 1, created.UserID)
+	require.Equal(t, domain.WebhookFilter{domain.TodoEventCreated, domain.WebhookUserUpdated}, created.Events)
+	require.True(t, created.Active)
+	require.NotEmpty(t, created.Secret)
+}
+
+func TestWebhookHandlerCreate_FailsDueToInvalidWebhook(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "MissingURL", body: `{"events": ["todo.created"]}`},
+		{name: "NotHTTPURL", body: `{"url": "ftp://example.com", "events": ["todo.created"]}`},
+		{name: "MissingEvents", body: `{"url": "https://example.com/webhook"}`},
+		{name: "UnknownEvent", body: `{"url": "https://example.com/webhook", "events": ["user.deleted"]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			webhookService, _ := newWebhookService(t)
+			server := createWebhookServer(_testConfigs, webhookService)
+
+			req, err := createTodoRequest(fiber.MethodPost, _webhooksPath, true, tt.body)
+			require.NoError(t, err)
+
+			// When
+			resp, err := server.Test(req)
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
+
+func TestWebhookHandlerCreate_FailsDueToLocalURL(t *testing.T) {
+	// Given
+	repository := webhook.NewMemoryRepository()
+	dispatcher := webhook.NewDispatcher(repository, jobs.NewMemoryQueue(), webhook.DispatcherConfig{})
+	webhookService := webhook.NewService(repository, dispatcher)
+	server := createWebhookServer(_testConfigs, webhookService)
+
+	req, err := createTodoRequest(fiber.MethodPost, _webhooksPath, true,
+		`{"url": "http://169.254.169.254/latest/meta-data", "events": ["todo.created"]}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response errorResponse
+	require.NoError(t, json.Unmarshal(body, &response))
+	require.Equal(t, domain.ErrWebhookForbiddenURL.Error(), response.Error)
+}
+
+func TestWebhookHandlerGetAll_SuccessfulWithoutSecrets(t *testing.T) {
+	// Given
+	webhookService, repository := newWebhookService(t)
+	server := createWebhookServer(_testConfigs, webhookService)
+
+	saved := saveWebhook(t, repository, 1, "https://example.com/webhook")
+	saveWebhook(t, repository, 2, "https://example.com/other")
+
+	req, err := createTodoRequest(fiber.MethodGet, _webhooksPath, true, "")
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var webhooks []domain.Webhook
+	require.NoError(t, json.Unmarshal(body, &webhooks))
+	require.Len(t, webhooks, 1)
+	require.Equal(t, saved.ID, webhooks[0].ID)
+	require.Empty(t, webhooks[0].Secret)
+}
+
+func TestWebhookHandlerUpdate_Successful(t *testing.T) {
+	// Given
+	webhookService, repository := newWebhookService(t)
+	server := createWebhookServer(_testConfigs, webhookService)
+
+	saved := saveWebhook(t, repository, 1, "https://example.com/webhook")
+
+	req, err := createTodoRequest(fiber.MethodPatch, fmt.Sprintf("%s/%d", _webhooksPath, saved.ID), true,
+		`{"events": ["todo.deleted"], "active": false}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	updated, err := repository.Get(context.Background(), saved.ID)
+	require.NoError(t, err)
+	require.Equal(t, saved.URL, updated.URL)
+	require.Equal(t, domain.WebhookFilter{domain.TodoEventDeleted}, updated.Events)
+	require.False(t, updated.Active)
+}
+
+func TestWebhookHandlerUpdate_FailsDueToWebhookOfOtherUser(t *testing.T) {
+	// Given
+	webhookService, repository := newWebhookService(t)
+	server := createWebhookServer(_testConfigs, webhookService)
+
+	saved := saveWebhook(t, repository, 2, "https://example.com/webhook")
+
+	req, err := createTodoRequest(fiber.MethodPatch, fmt.Sprintf("%s/%d", _webhooksPath, saved.ID), true,
+		`{"active": false}`)
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+}
+
+func TestWebhookHandlerDelete_Successful(t *testing.T) {
+	// Given
+	webhookService, repository := newWebhookService(t)
+	server := createWebhookServer(_testConfigs, webhookService)
+
+	saved := saveWebhook(t, repository, 1, "https://example.com/webhook")
+
+	req, err := createTodoRequest(fiber.MethodDelete, fmt.Sprintf("%s/%d", _webhooksPath, saved.ID), true, "")
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	_, err = repository.Get(context.Background(), saved.ID)
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestWebhookHandlerDelete_FailsDueToNotFound(t *testing.T) {
+	// Given
+	webhookService, _ := newWebhookService(t)
+	server := createWebhookServer(_testConfigs, webhookService)
+
+	req, err := createTodoRequest(fiber.MethodDelete, _webhooksPath+"/1", true, "")
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
+
+func TestWebhookHandlerRedeliver_Successful(t *testing.T) {
+	// Given
+	received := make(chan string, 2)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		received <- req.Header.Get(webhook.HeaderDelivery)
+	}))
+	t.Cleanup(receiver.Close)
+
+	webhookService, repository := newWebhookService(t)
+	server := createWebhookServer(_testConfigs, webhookService)
+
+	saved := saveWebhook(t, repository, 1, receiver.URL)
+
+	webhookService.Notify(context.Background(), 1, domain.TodoEventCreated, domain.Todo{ID: 1, UserID: 1})
+	<-received
+
+	deliveries, err := webhookService.Deliveries(context.Background(), saved.ID, 1)
+	require.NoError(t, err)
+
+	url := fmt.Sprintf("%s/%d/deliveries/%d/redeliver", _webhooksPath, saved.ID, deliveries[0].ID)
+
+	req, err := createTodoRequest(fiber.MethodPost, url, true, "")
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusAccepted, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var redelivery domain.WebhookDelivery
+	require.NoError(t, json.Unmarshal(body, &redelivery))
+	require.Equal(t, domain.WebhookDeliveryPending, redelivery.Status)
+
+	select {
+	case deliveryID := <-received:
+		require.Equal(t, fmt.Sprint(redelivery.ID), deliveryID)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "the redelivery was not received")
+	}
+}
+
+func TestWebhookHandlerRedeliver_FailsDueToDisabledWebhook(t *testing.T) {
+	// Given
+	webhookService, repository := newWebhookService(t)
+	server := createWebhookServer(_testConfigs, webhookService)
+
+	saved := saveWebhook(t, repository, 1, "https://example.com/webhook")
+
+	delivery, err := repository.SaveDelivery(context.Background(), domain.WebhookDelivery{
+		WebhookID: saved.ID,
+		Event:     domain.TodoEventCreated,
+		Payload:   domain.WebhookPayload(`{}`),
+		Status:    domain.WebhookDeliveryFailed,
+	})
+	require.NoError(t, err)
+
+	saved.Active = false
+	require.NoError(t, repository.Update(context.Background(), saved))
+
+	url := fmt.Sprintf("%s/%d/deliveries/%d/redeliver", _webhooksPath, saved.ID, delivery.ID)
+
+	req, err := createTodoRequest(fiber.MethodPost, url, true, "")
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusConflict, resp.StatusCode)
+}
+
+func TestWebhookHandlerRedeliver_FailsDueToDeliveryOfOtherWebhook(t *testing.T) {
+	// Given
+	webhookService, repository := newWebhookService(t)
+	server := createWebhookServer(_testConfigs, webhookService)
+
+	saved := saveWebhook(t, repository, 1, "https://example.com/webhook")
+	other := saveWebhook(t, repository, 2, "https://example.com/other")
+
+	delivery, err := repository.SaveDelivery(context.Background(), domain.WebhookDelivery{
+		WebhookID: other.ID,
+		Event:     domain.TodoEventCreated,
+		Payload:   domain.WebhookPayload(`{}`),
+		Status:    domain.WebhookDeliveryFailed,
+	})
+	require.NoError(t, err)
+
+	url := fmt.Sprintf("%s/%d/deliveries/%d/redeliver", _webhooksPath, saved.ID, delivery.ID)
+
+	req, err := createTodoRequest(fiber.MethodPost, url, true, "")
+	require.NoError(t, err)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+}
@@ -7,11 +7,16 @@ import (
 	"github.com/ferch5003/go-fiber-tutorial/config"
 	"github.com/ferch5003/go-fiber-tutorial/db/seeds"
 	"github.com/ferch5003/go-fiber-tutorial/internal/platform/console"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/logging"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/metrics"
 	"github.com/ferch5003/go-fiber-tutorial/internal/platform/mysql"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/postgres"
 	"github.com/ferch5003/go-fiber-tutorial/internal/platform/redis"
 	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/tracing"
 	"go.uber.org/fx"
 	"go.uber.org/zap"
+	"os"
 	"sync"
 
 	_ "github.com/go-sql-driver/mysql"
@@ -24,25 +29,45 @@ func main() {
 		Mutex:   &sync.Mutex{},
 	}
 
+	command, err := bootstrap.ParseCommand(os.Args[1:])
+	if err != nil {
+		panic(err)
+	}
+
 	configurations, err := config.NewConfigurations()
 	if err != nil {
 		panic(err)
 	}
 
+	if command.Storage != "" {
+		configurations.Storage = command.Storage
+	}
+
 	ctx := context.Background()
 
 	mysqlCtx := context.Background()
 	mySQLContainer := mysql.NewMySQLContainer(mysqlCtx)
 
+	postgresCtx := context.Background()
+	postgresContainer := postgres.NewPostgresContainer(postgresCtx)
+
 	redisCtx := context.Background()
 	redisContainer := redis.NewRedisContainer(redisCtx)
 
+	storage, err := newStorage(configurations, mySQLContainer, postgresContainer, redisContainer)
+	if err != nil {
+		panic(err)
+	}
+
 	cmd := console.NewConsole()
-	logger, err := zap.NewProduction()
+	logger, err := zap.NewProduction(zap.WrapCore(logging.NewRedactingCore))
 	if err != nil {
 		panic(err)
 	}
 
+	// Fallback for code that logs without a per-request logger.
+	zap.ReplaceGlobals(logger)
+
 	app := fx.New(
 		// Clear terminal/console
 		fx.Invoke(cmd.Clear),
@@ -53,13 +78,20 @@ func main() {
 		fx.Supply(server),
 		// creates: *zap.Logger
 		fx.Supply(logger),
+		// creates: *metrics.Metrics
+		fx.Provide(metrics.NewMetrics),
+		// creates: trace.TracerProvider
+		fx.Provide(tracing.NewTracerProvider),
 		// creates: *fiber.Router
 		fx.Provide(
 			fx.Annotate(
 				router.NewRouter,
-				fx.ParamTags( // Equivalent to *fiber.App, config.Envars, []Router `group:"routers"` in constructor
+				// Equivalent to *fiber.App, config.Envars, []VersionedRouter `group:"versioned_routers"`,
+				// []Router `group:"routers"` in constructor
+				fx.ParamTags(
 					``,
 					``,
+					`group:"versioned_routers"`,
 					`group:"routers"`),
 			),
 		),
@@ -68,45 +100,39 @@ func main() {
 		// creates: context.Context
 		fx.Supply(ctx),
 
-		// Create MysSQL Container
-		fx.Invoke(mySQLContainer.CreateOrUseContainer),
-
-		// creates: *sqlx.DB
-		fx.Provide(mysql.NewConnection),
+		// creates: session.Repository, user.Repository, todo.Repository
+		storage,
 
-		// Create Redis Container
-
-		fx.Invoke(func() error {
-			if configurations.AppSessionType != "app" {
-				return nil
-			}
-
-			return redisContainer.CreateOrUseContainer(configurations)
-		}),
-
-		// creates: *redis.Client
-		fx.Provide(redis.NewConnection),
-
-		// creates: *session.Repository
-		fx.Provide(session.NewRepository),
+		// Trace sessions operations.
+		fx.Decorate(tracing.NewSessionRepository),
 		// creates: *session.Service
 		fx.Provide(session.NewService),
 
 		// Provide modules
+		router.NewHealthModule,
+		router.NewMetricsModule,
+		router.NewDocsModule,
 		router.NewUserModule,
 		router.NewTodoModule,
+		router.NewAuditModule,
+		router.NewStreamModule,
+		router.NewWebhookModule,
+		router.NewJobsModule,
+		router.NewOutboxModule,
 
 		// Provide seeders
 		fx.Provide(seeds.NewSeed),
 
-		fx.Invoke(seeds.Execute),
-
-		// Start web server.
-		fx.Invoke(bootstrap.Start),
+		// Run the web server or the worker.
+		newCommand(command, configurations),
 	)
 
 	defer func() {
-		defer server.Wg.Done()
+		if command.Name == bootstrap.ServeCommand {
+			// Only the web server adds to the wait group.
+			defer server.Wg.Done()
+		}
+
 		server.Mutex.Lock()
 		select {
 		case _, ok := <-(server.ErrChan):
@@ -134,6 +160,10 @@ func main() {
 			logger.DPanic("Error cleaning MySQL container: ", zap.Error(err))
 		}
 
+		if err := postgresContainer.CleanContainer(); err != nil {
+			logger.DPanic("Error cleaning PostgreSQL container: ", zap.Error(err))
+		}
+
 		if err := redisContainer.CleanContainer(); err != nil {
 			logger.DPanic("Error cleaning Redis container: ", zap.Error(err))
 		}
@@ -148,6 +178,10 @@ func main() {
 			logger.DPanic("Error cleaning MySQL container: ", zap.Error(err))
 		}
 
+		if err := postgresContainer.CleanContainer(); err != nil {
+			logger.DPanic("Error cleaning PostgreSQL container: ", zap.Error(err))
+		}
+
 		if err := redisContainer.CleanContainer(); err != nil {
 			logger.DPanic("Error cleaning Redis container: ", zap.Error(err))
 		}
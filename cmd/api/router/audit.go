@@ -0,0 +1,66 @@
+package router
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/cmd/api/handler"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
+	"github.com/ferch5003/go-fiber-tutorial/internal/middlewares"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/fx"
+)
+
+var NewAuditModule = fx.Module("audit",
+	// Register Service, the Repository is provided by the configured storage
+	fx.Provide(audit.NewService),
+
+	// Register Handler
+	fx.Provide(handler.NewAuditHandler),
+
+	// Register Router
+	fx.Provide(
+		fx.Annotate(
+			NewAuditRouter,
+			fx.ResultTags(`group:"versioned_routers"`),
+		),
+	),
+)
+
+type auditRouter struct {
+	config         *config.EnvVars
+	sessionService session.Service
+	Handler        *handler.AuditHandler
+}
+
+func NewAuditRouter(
+	config *config.EnvVars,
+	sessionService session.Service,
+	auditHandler *handler.AuditHandler) VersionedRouter {
+	return &auditRouter{
+		config:         config,
+		sessionService: sessionService,
+		Handler:        auditHandler,
+	}
+}
+
+func (a auditRouter) Versions() []string {
+	return []string{"v1"}
+}
+
+func (a auditRouter) RegisterVersion(api fiber.Router, _ string, handlers ...fiber.Handler) {
+	jwtMiddleware := middlewares.NewJWTMiddleware(
+		context.Background(),
+		a.config.AppSessionType,
+		a.config.AppSecretKey,
+		a.sessionService,
+	)
+
+	protected := chain(handlers, jwtMiddleware.GetMiddleware())
+
+	todos := api.Group("/todos").Name("todos.")
+	todos.Get("/:id<int>/history", chain(protected, a.Handler.TodoHistory)...).Name("history")
+
+	admin := api.Group("/admin", handlers...).Name("admin.")
+	admin.Get("/audit", jwtMiddleware.GetMiddleware(), a.Handler.Feed).Name("audit")
+}
@@ -0,0 +1,37 @@
+package router
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/cmd/api/handler"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/fx"
+)
+
+var NewDocsModule = fx.Module("docs",
+	// Register Handler
+	fx.Provide(handler.NewDocsHandler),
+
+	// Register Router
+	fx.Provide(
+		fx.Annotate(
+			NewDocsRouter,
+			fx.ResultTags(`group:"routers"`),
+		),
+	),
+)
+
+type docsRouter struct {
+	App     fiber.Router
+	Handler *handler.DocsHandler
+}
+
+func NewDocsRouter(app *fiber.App, docsHandler *handler.DocsHandler) Router {
+	return &docsRouter{
+		App:     app,
+		Handler: docsHandler,
+	}
+}
+
+func (d docsRouter) Register() {
+	d.App.Get("/openapi.json", d.Handler.OpenAPI).Name("docs.openapi")
+	d.App.Get("/docs", d.Handler.SwaggerUI).Name("docs.ui")
+}
@@ -0,0 +1,46 @@
+package router
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/cmd/api/handler"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/health"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/fx"
+)
+
+var NewHealthModule = fx.Module("health",
+	// Register Service
+	fx.Provide(
+		fx.Annotate(
+			health.NewService,
+			fx.ParamTags(`group:"health_checkers"`),
+		),
+	),
+
+	// Register Handler
+	fx.Provide(handler.NewHealthHandler),
+
+	// Register Router
+	fx.Provide(
+		fx.Annotate(
+			NewHealthRouter,
+			fx.ResultTags(`group:"routers"`),
+		),
+	),
+)
+
+type healthRouter struct {
+	App     fiber.Router
+	Handler *handler.HealthHandler
+}
+
+func NewHealthRouter(app *fiber.App, healthHandler *handler.HealthHandler) Router {
+	return &healthRouter{
+		App:     app,
+		Handler: healthHandler,
+	}
+}
+
+func (h healthRouter) Register() {
+	h.App.Get("/livez", h.Handler.Liveness).Name("health.livez")
+	h.App.Get("/readyz", h.Handler.Readiness).Name("health.readyz")
+}
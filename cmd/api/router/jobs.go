@@ -0,0 +1,53 @@
+package router
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/jobs"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/fx"
+)
+
+var NewJobsModule = fx.Module("jobs",
+	// Register Queue
+	fx.Provide(
+		fx.Annotate(
+			newQueue,
+			fx.ParamTags(`optional:"true"`),
+		),
+	),
+
+	// Register Worker, the handlers and schedules are provided by the other modules
+	fx.Provide(
+		fx.Annotate(
+			newWorker,
+			fx.ParamTags(``, ``, `group:"job_handlers"`, `group:"job_schedules"`),
+		),
+	),
+)
+
+// newQueue keeps the jobs in Redis when it is available, so the workers of every instance share them.
+// Otherwise, they are run by this instance only, and lost on restarts.
+func newQueue(redisClient *redis.Client) jobs.Queue {
+	if redisClient != nil {
+		return jobs.NewRedisQueue(redisClient)
+	}
+
+	return jobs.NewMemoryQueue()
+}
+
+func newWorker(
+	config *config.EnvVars,
+	queue jobs.Queue,
+	handlers []jobs.Handler,
+	schedules []jobs.Schedule) (*jobs.Worker, error) {
+	return jobs.NewWorker(queue, jobs.WorkerConfig{Concurrency: config.JobsConcurrency}, handlers, schedules)
+}
+
+// RunJobs runs the jobs along with the application. When it is invoked before the web server is
+// started, the worker stops after it, running the jobs queued by the last requests.
+func RunJobs(lc fx.Lifecycle, worker *jobs.Worker) {
+	lc.Append(fx.Hook{
+		OnStart: worker.Start,
+		OnStop:  worker.Stop,
+	})
+}
@@ -0,0 +1,57 @@
+package router
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/metrics"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/basicauth"
+	"go.uber.org/fx"
+)
+
+const _defaultMetricsPath = "/metrics"
+
+var NewMetricsModule = fx.Module("metrics",
+	// Register Router
+	fx.Provide(
+		fx.Annotate(
+			NewMetricsRouter,
+			fx.ResultTags(`group:"routers"`),
+		),
+	),
+)
+
+type metricsRouter struct {
+	App     fiber.Router
+	config  *config.EnvVars
+	metrics *metrics.Metrics
+}
+
+func NewMetricsRouter(app *fiber.App, config *config.EnvVars, metrics *metrics.Metrics) Router {
+	return &metricsRouter{
+		App:     app,
+		config:  config,
+		metrics: metrics,
+	}
+}
+
+func (m metricsRouter) Register() {
+	path := _defaultMetricsPath
+	if m.config.MetricsPath != "" {
+		path = m.config.MetricsPath
+	}
+
+	handlers := make([]fiber.Handler, 0)
+
+	// Protect the endpoint only when credentials are configured.
+	if m.config.MetricsUsername != "" {
+		handlers = append(handlers, basicauth.New(basicauth.Config{
+			Users: map[string]string{
+				m.config.MetricsUsername: m.config.MetricsPassword,
+			},
+		}))
+	}
+
+	handlers = append(handlers, m.metrics.Handler())
+
+	m.App.Get(path, handlers...).Name("metrics")
+}
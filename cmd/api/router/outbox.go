@@ -0,0 +1,85 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const (
+	// _outboxStream is the Redis stream the domain events are appended to.
+	_outboxStream = "outbox:events"
+
+	// _outboxLockKey is the Redis key of the lock held by the relay publishing the domain events.
+	_outboxLockKey = "outbox:relay"
+)
+
+var errRedisSinkUnavailable = errors.New("the redis outbox sink needs a storage with Redis")
+
+var NewOutboxModule = fx.Module("outbox",
+	// Register Relay, the Repository is provided by the configured storage
+	fx.Provide(
+		fx.Annotate(
+			newRelay,
+			fx.ParamTags(``, ``, `optional:"true"`, ``),
+		),
+	),
+
+	// Register Jobs
+	fx.Provide(
+		fx.Annotate(
+			outbox.NewPurgePublishedHandler,
+			fx.ResultTags(`group:"job_handlers"`),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			outbox.NewPurgePublishedSchedule,
+			fx.ResultTags(`group:"job_schedules"`),
+		),
+	),
+)
+
+// newRelay publishes the domain events to the configured sinks. When Redis is available, a lock in it
+// lets a single instance publish at a time, keeping the events of an aggregate in order.
+func newRelay(
+	configurations *config.EnvVars,
+	repository outbox.Repository,
+	redisClient *redis.Client,
+	logger *zap.Logger) (*outbox.Relay, error) {
+	sinks := make([]outbox.Sink, 0, len(configurations.OutboxSinks))
+
+	for _, name := range configurations.OutboxSinks {
+		switch name {
+		case config.LogSink:
+			sinks = append(sinks, outbox.NewLogSink(logger))
+		case config.RedisSink:
+			if redisClient == nil {
+				return nil, errRedisSinkUnavailable
+			}
+
+			sinks = append(sinks, outbox.NewRedisStreamSink(redisClient, _outboxStream))
+		default:
+			return nil, fmt.Errorf("unknown outbox sink: %s", name)
+		}
+	}
+
+	lock := outbox.NewLocalLock()
+	if redisClient != nil {
+		lock = outbox.NewRedisLock(redisClient, _outboxLockKey)
+	}
+
+	return outbox.NewRelay(repository, sinks, lock, outbox.RelayConfig{}), nil
+}
+
+// RunOutboxRelay publishes the domain events along with the application.
+func RunOutboxRelay(lc fx.Lifecycle, relay *outbox.Relay) {
+	lc.Append(fx.Hook{
+		OnStart: relay.Start,
+		OnStop:  relay.Stop,
+	})
+}
@@ -2,24 +2,72 @@ package router
 
 import (
 	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/middlewares"
 	"github.com/gofiber/fiber/v2"
+	"slices"
+	"time"
 )
 
+const (
+	// _apiPrefix is the prefix of every versioned API path, like "/api/v1/todos".
+	_apiPrefix = "/api"
+
+	// _legacyVersion is the version served by the unversioned paths.
+	_legacyVersion = "v1"
+)
+
+// APIVersion is a version of the API, mounted under /api/<Name>.
+type APIVersion struct {
+	Name       string
+	Deprecated bool
+	Sunset     time.Time // Announced removal date of a deprecated version, zero when unknown.
+}
+
+// APIVersions lists the versions of the API from oldest to newest.
+var APIVersions = []APIVersion{
+	{Name: "v1"},
+}
+
 type Router interface {
 	Register()
 }
 
+// VersionedRouter registers API resources once per API version it serves, so handlers of different
+// versions can coexist.
+type VersionedRouter interface {
+	// Versions served by the router, like "v1".
+	Versions() []string
+
+	// RegisterVersion registers the routes of the version in api, applying handlers before them.
+	RegisterVersion(api fiber.Router, version string, handlers ...fiber.Handler)
+}
+
+// chain returns the middlewares followed by the handlers of a route. Several routers register routes
+// under /todos, so their middlewares are chained per route: applied to a /todos group, they would also
+// run for the routes of the other routers.
+func chain(middlewares []fiber.Handler, handlers ...fiber.Handler) []fiber.Handler {
+	return slices.Concat(middlewares, handlers)
+}
+
 type GeneralRouter struct {
-	App     fiber.Router
-	config  *config.EnvVars
-	routers []Router
+	App              fiber.Router
+	Versions         []APIVersion
+	config           *config.EnvVars
+	routers          []Router
+	versionedRouters []VersionedRouter
 }
 
-func NewRouter(fiber *fiber.App, config *config.EnvVars, routers ...Router) *GeneralRouter {
+func NewRouter(
+	fiber *fiber.App,
+	config *config.EnvVars,
+	versionedRouters []VersionedRouter,
+	routers ...Router) *GeneralRouter {
 	return &GeneralRouter{
-		App:     fiber,
-		config:  config,
-		routers: routers,
+		App:              fiber,
+		Versions:         APIVersions,
+		config:           config,
+		routers:          routers,
+		versionedRouters: versionedRouters,
 	}
 }
 
@@ -27,9 +75,55 @@ func NewRouter(fiber *fiber.App, config *config.EnvVars, routers ...Router) *Gen
 func (r *GeneralRouter) Register() {
 	r.App.Get("/health", func(c *fiber.Ctx) error {
 		return c.SendString("Application is working correctly! 👋")
-	})
+	}).Name("health")
 
 	for _, router := range r.routers {
 		router.Register()
 	}
+
+	for _, version := range r.Versions {
+		api := r.App.Group(versionPrefix(version.Name)).Name(version.Name + ".")
+
+		handlers := make([]fiber.Handler, 0)
+		if version.Deprecated {
+			handlers = append(handlers, middlewares.DeprecationMiddleware(version.Sunset, r.successorPrefix()))
+		}
+
+		for _, router := range r.versionedRouters {
+			if slices.Contains(router.Versions(), version.Name) {
+				router.RegisterVersion(api, version.Name, handlers...)
+			}
+		}
+	}
+
+	if r.config != nil && r.config.APILegacyRoutes {
+		r.registerLegacy()
+	}
+}
+
+// registerLegacy mounts the legacy version at the root, as it was served before versioning, flagging
+// it as deprecated.
+func (r *GeneralRouter) registerLegacy() {
+	deprecation := middlewares.DeprecationMiddleware(r.config.APILegacySunset, r.successorPrefix())
+
+	for _, router := range r.versionedRouters {
+		if slices.Contains(router.Versions(), _legacyVersion) {
+			router.RegisterVersion(r.App, _legacyVersion, deprecation)
+		}
+	}
+}
+
+// successorPrefix returns the prefix of the newest version that is not deprecated.
+func (r *GeneralRouter) successorPrefix() string {
+	for i := len(r.Versions) - 1; i >= 0; i-- {
+		if !r.Versions[i].Deprecated {
+			return versionPrefix(r.Versions[i].Name)
+		}
+	}
+
+	return ""
+}
+
+func versionPrefix(version string) string {
+	return _apiPrefix + "/" + version
 }
@@ -1,12 +1,18 @@
 package router
 
 import (
+	"github.com/ferch5003/go-fiber-tutorial/cmd/api/handler"
 	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/middlewares"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/health"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/metrics"
 	"github.com/gofiber/fiber/v2"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"io"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 var _testConfigs = &config.EnvVars{
@@ -32,7 +38,7 @@ func TestRegister_Successful(t *testing.T) {
 	mtr := new(mockRouter)
 	mtr.On("Register")
 
-	router := NewRouter(app, _testConfigs, mur, mtr) // Always have the /health endpoint.
+	router := NewRouter(app, _testConfigs, nil, mur, mtr) // Always have the /health endpoint.
 	expectedRoute := "/health"
 	expectedStatusCode := fiber.StatusOK
 
@@ -46,3 +52,253 @@ func TestRegister_Successful(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, expectedStatusCode, resp.StatusCode)
 }
+
+func TestMetricsRouterRegister_Successful(t *testing.T) {
+	// Given
+	app := fiber.New()
+
+	router := NewMetricsRouter(app, &config.EnvVars{MetricsPath: "/internal/metrics"}, metrics.NewMetrics())
+
+	// When
+	router.Register()
+
+	req := httptest.NewRequest("GET", "/internal/metrics", nil)
+	resp, err := app.Test(req, -1)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestMetricsRouterRegister_FailsDueToMissingCredentials(t *testing.T) {
+	// Given
+	app := fiber.New()
+
+	configs := &config.EnvVars{
+		MetricsUsername: "prometheus",
+		MetricsPassword: "secret",
+	}
+
+	router := NewMetricsRouter(app, configs, metrics.NewMetrics())
+
+	// When
+	router.Register()
+
+	unauthorizedReq := httptest.NewRequest("GET", _defaultMetricsPath, nil)
+	unauthorizedResp, err := app.Test(unauthorizedReq, -1)
+	require.NoError(t, err)
+
+	authorizedReq := httptest.NewRequest("GET", _defaultMetricsPath, nil)
+	authorizedReq.SetBasicAuth(configs.MetricsUsername, configs.MetricsPassword)
+	authorizedResp, err := app.Test(authorizedReq, -1)
+	require.NoError(t, err)
+
+	// Then
+	require.Equal(t, fiber.StatusUnauthorized, unauthorizedResp.StatusCode)
+	require.Equal(t, fiber.StatusOK, authorizedResp.StatusCode)
+}
+
+func TestDocsRouterRegister_DocumentsEveryRoute(t *testing.T) {
+	// Given
+	app := fiber.New()
+
+	docsHandler := handler.NewDocsHandler(app, _testConfigs)
+
+	configs := &config.EnvVars{
+		AppName:         "test",
+		AppSecretKey:    "test",
+		APILegacyRoutes: true,
+	}
+
+	versionedRouters := []VersionedRouter{
+		NewUserRouter(configs, nil, nil, handler.NewUserHandler(configs, nil, nil)),
+		NewTodoRouter(configs, nil, nil, handler.NewTodoHandler(configs, nil, nil)),
+		NewAuditRouter(configs, nil, handler.NewAuditHandler(configs, nil, nil, nil)),
+		NewStreamRouter(configs, nil, handler.NewStreamHandler(configs, nil, nil)),
+		NewWebhookRouter(configs, nil, handler.NewWebhookHandler(configs, nil, nil)),
+	}
+
+	router := NewRouter(app, configs, versionedRouters,
+		NewHealthRouter(app, handler.NewHealthHandler(health.NewService())),
+		NewMetricsRouter(app, configs, metrics.NewMetrics()),
+		NewDocsRouter(app, docsHandler),
+	)
+
+	// When
+	router.Register()
+
+	document := docsHandler.Document()
+
+	// Then
+	for _, route := range app.GetRoutes(true) {
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+
+		require.Truef(t, document.HasOperation(route.Method, route.Path),
+			"route %s %s (%q) is not documented", route.Method, route.Path, route.Name)
+	}
+}
+
+type mockVersionedRouter struct {
+	mock.Mock
+}
+
+func (m *mockVersionedRouter) Versions() []string {
+	args := m.Called()
+	return args.Get(0).([]string)
+}
+
+func (m *mockVersionedRouter) RegisterVersion(api fiber.Router, version string, handlers ...fiber.Handler) {
+	m.Called(api, version, handlers)
+
+	api.Group("/todos", handlers...).Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(version)
+	})
+}
+
+func TestRegister_MountsVersionedRouters(t *testing.T) {
+	// Given
+	app := fiber.New()
+
+	mvr := new(mockVersionedRouter)
+	mvr.On("Versions").Return([]string{"v1", "v2"})
+	mvr.On("RegisterVersion", mock.Anything, mock.Anything, mock.Anything)
+
+	router := NewRouter(app, _testConfigs, []VersionedRouter{mvr})
+	router.Versions = []APIVersion{{Name: "v1"}, {Name: "v2"}, {Name: "v3"}}
+
+	// When
+	router.Register()
+
+	// Then
+	for _, version := range []string{"v1", "v2"} {
+		req := httptest.NewRequest(fiber.MethodGet, "/api/"+version+"/todos", nil)
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		require.Empty(t, resp.Header.Get(middlewares.HeaderDeprecation))
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, version, string(body))
+	}
+
+	// Versions not served by the router and legacy paths are not mounted.
+	for _, path := range []string{"/api/v3/todos", "/todos"} {
+		req := httptest.NewRequest(fiber.MethodGet, path, nil)
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	}
+
+	mvr.AssertNumberOfCalls(t, "RegisterVersion", 2)
+}
+
+func TestRegister_DeprecatedVersionEmitsHeaders(t *testing.T) {
+	// Given
+	app := fiber.New()
+
+	mvr := new(mockVersionedRouter)
+	mvr.On("Versions").Return([]string{"v1", "v2"})
+	mvr.On("RegisterVersion", mock.Anything, mock.Anything, mock.Anything)
+
+	sunset := time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	router := NewRouter(app, _testConfigs, []VersionedRouter{mvr})
+	router.Versions = []APIVersion{{Name: "v1", Deprecated: true, Sunset: sunset}, {Name: "v2"}}
+
+	// When
+	router.Register()
+
+	deprecatedResp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/api/v1/todos", nil), -1)
+	require.NoError(t, err)
+
+	currentResp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/api/v2/todos", nil), -1)
+	require.NoError(t, err)
+
+	// Then
+	require.Equal(t, fiber.StatusOK, deprecatedResp.StatusCode)
+	require.Equal(t, "true", deprecatedResp.Header.Get(middlewares.HeaderDeprecation))
+	require.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", deprecatedResp.Header.Get(middlewares.HeaderSunset))
+	require.Equal(t, `</api/v2>; rel="successor-version"`, deprecatedResp.Header.Get(fiber.HeaderLink))
+
+	require.Equal(t, fiber.StatusOK, currentResp.StatusCode)
+	require.Empty(t, currentResp.Header.Get(middlewares.HeaderDeprecation))
+}
+
+func TestRegister_MountsLegacyRoutesWhenEnabled(t *testing.T) {
+	// Given
+	app := fiber.New()
+
+	mvr := new(mockVersionedRouter)
+	mvr.On("Versions").Return([]string{"v1"})
+	mvr.On("RegisterVersion", mock.Anything, mock.Anything, mock.Anything)
+
+	configs := &config.EnvVars{
+		APILegacyRoutes: true,
+		APILegacySunset: time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	router := NewRouter(app, configs, []VersionedRouter{mvr})
+
+	// When
+	router.Register()
+
+	legacyResp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/todos", nil), -1)
+	require.NoError(t, err)
+
+	versionedResp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/api/v1/todos", nil), -1)
+	require.NoError(t, err)
+
+	healthResp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/health", nil), -1)
+	require.NoError(t, err)
+
+	// Then
+	require.Equal(t, fiber.StatusOK, legacyResp.StatusCode)
+	require.Equal(t, "true", legacyResp.Header.Get(middlewares.HeaderDeprecation))
+	require.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", legacyResp.Header.Get(middlewares.HeaderSunset))
+	require.Equal(t, `</api/v1>; rel="successor-version"`, legacyResp.Header.Get(fiber.HeaderLink))
+
+	require.Equal(t, fiber.StatusOK, versionedResp.StatusCode)
+	require.Empty(t, versionedResp.Header.Get(middlewares.HeaderDeprecation))
+
+	// The deprecation only applies to the legacy resources.
+	require.Equal(t, fiber.StatusOK, healthResp.StatusCode)
+	require.Empty(t, healthResp.Header.Get(middlewares.HeaderDeprecation))
+}
+
+func TestRegisterVersion_TodosRoutersRunMiddlewaresOnce(t *testing.T) {
+	// Given
+	app := fiber.New()
+
+	calls := 0
+	counter := func(c *fiber.Ctx) error {
+		calls++
+
+		return c.Next()
+	}
+
+	// The todo router is registered last, so its routes would be matched after the other ones.
+	versionedRouters := []VersionedRouter{
+		NewAuditRouter(_testConfigs, nil, handler.NewAuditHandler(_testConfigs, nil, nil, nil)),
+		NewStreamRouter(_testConfigs, nil, handler.NewStreamHandler(_testConfigs, nil, nil)),
+		NewTodoRouter(_testConfigs, nil, nil, handler.NewTodoHandler(_testConfigs, nil, nil)),
+	}
+
+	for _, versionedRouter := range versionedRouters {
+		versionedRouter.RegisterVersion(app, "v1", counter)
+	}
+
+	// When
+	for _, path := range []string{"/todos/1/history", "/todos/stream", "/todos/1"} {
+		calls = 0
+
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, path, nil), -1)
+
+		// Then
+		require.NoError(t, err)
+		require.NotEqual(t, fiber.StatusNotFound, resp.StatusCode, path)
+		require.Equal(t, 1, calls, path)
+	}
+}
@@ -0,0 +1,91 @@
+package router
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/cmd/api/handler"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/middlewares"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/pubsub"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
+	"github.com/gofiber/fiber/v2"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/fx"
+)
+
+var NewStreamModule = fx.Module("stream",
+	// Register Broker
+	fx.Provide(
+		fx.Annotate(
+			newBroker,
+			fx.ParamTags(`optional:"true"`),
+		),
+	),
+
+	// Register Handler
+	fx.Provide(handler.NewStreamHandler),
+
+	// Register Router
+	fx.Provide(
+		fx.Annotate(
+			NewStreamRouter,
+			fx.ResultTags(`group:"versioned_routers"`),
+		),
+	),
+)
+
+// newBroker fans the events out through Redis when it is available, so every instance receives them.
+// Otherwise, they only reach the clients of this instance.
+func newBroker(redisClient *redis.Client) pubsub.Broker {
+	if redisClient != nil {
+		return pubsub.NewRedisBroker(redisClient)
+	}
+
+	return pubsub.NewMemoryBroker()
+}
+
+// CloseStreamsOnStop ends the streams of the clients when the application stops. The web server waits
+// for its open requests while shutting down, so this must be invoked after it is started: the stop
+// hooks run in reverse order, closing the streams first.
+func CloseStreamsOnStop(lc fx.Lifecycle, broker pubsub.Broker) {
+	lc.Append(fx.Hook{
+		OnStop: func(context.Context) error {
+			return broker.Close()
+		},
+	})
+}
+
+type streamRouter struct {
+	config         *config.EnvVars
+	sessionService session.Service
+	Handler        *handler.StreamHandler
+}
+
+func NewStreamRouter(
+	config *config.EnvVars,
+	sessionService session.Service,
+	streamHandler *handler.StreamHandler) VersionedRouter {
+	return &streamRouter{
+		config:         config,
+		sessionService: sessionService,
+		Handler:        streamHandler,
+	}
+}
+
+func (s streamRouter) Versions() []string {
+	return []string{"v1"}
+}
+
+func (s streamRouter) RegisterVersion(api fiber.Router, _ string, handlers ...fiber.Handler) {
+	jwtMiddleware := middlewares.NewJWTMiddleware(
+		context.Background(),
+		s.config.AppSessionType,
+		s.config.AppSecretKey,
+		s.sessionService,
+	)
+
+	protected := chain(handlers, jwtMiddleware.GetMiddleware())
+
+	todos := api.Group("/todos").Name("todos.")
+	todos.Get("/stream", chain(protected, s.Handler.Events)...).Name("stream")
+	todos.Get("/ws", chain(protected, s.Handler.WebSocket)...).Name("socket")
+}
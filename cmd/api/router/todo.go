@@ -5,17 +5,34 @@ import (
 	"github.com/ferch5003/go-fiber-tutorial/cmd/api/handler"
 	"github.com/ferch5003/go-fiber-tutorial/config"
 	"github.com/ferch5003/go-fiber-tutorial/internal/middlewares"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/cache"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/idempotency"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/metrics"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/pubsub"
 	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/tracing"
 	"github.com/ferch5003/go-fiber-tutorial/internal/todo"
+	"github.com/ferch5003/go-fiber-tutorial/internal/webhook"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/etag"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/fx"
 )
 
 var NewTodoModule = fx.Module("todo",
-	// Register Repository & Service
-	fx.Provide(todo.NewRepository),
+	// Register Service, the Repository is provided by the configured storage
 	fx.Provide(todo.NewService),
 
+	// Decorate Repository & Service with observability, caching and events
+	fx.Decorate(tracing.NewTodoRepository),
+	fx.Decorate(
+		fx.Annotate(
+			decorateTodoService,
+			fx.ParamTags(``, ``, ``, ``, ``, ``, `optional:"true"`),
+		),
+	),
+
 	// Register Handler
 	fx.Provide(handler.NewTodoHandler),
 
@@ -23,32 +40,56 @@ var NewTodoModule = fx.Module("todo",
 	fx.Provide(
 		fx.Annotate(
 			NewTodoRouter,
-			fx.ResultTags(`group:"routers"`),
+			fx.ResultTags(`group:"versioned_routers"`),
 		),
 	),
 )
 
+// decorateTodoService wraps the todo.Service counting business events inside a traced span, and
+// publishing the written todos to the broker and the webhooks. Reads are cached in Redis when it is available and a TTL
+// is configured.
+func decorateTodoService(
+	service todo.Service,
+	config *config.EnvVars,
+	m *metrics.Metrics,
+	tp trace.TracerProvider,
+	broker pubsub.Broker,
+	webhooks webhook.Service,
+	redisClient *redis.Client) todo.Service {
+	service = pubsub.NewTodoService(service, broker, webhook.NewTodoPublisher(webhooks))
+
+	if redisClient != nil && config.CacheTTL > 0 {
+		service = cache.NewTodoService(service, redisClient, config.CacheTTL, m)
+	}
+
+	return tracing.NewTodoService(metrics.NewTodoService(service, m), tp)
+}
+
 type todoRouter struct {
-	App            fiber.Router
-	config         *config.EnvVars
-	sessionService session.Service
-	Handler        *handler.TodoHandler
+	config                *config.EnvVars
+	sessionService        session.Service
+	idempotencyRepository idempotency.Repository
+	Handler               *handler.TodoHandler
 }
 
 func NewTodoRouter(
-	app *fiber.App,
 	config *config.EnvVars,
 	sessionService session.Service,
-	todoHandler *handler.TodoHandler) Router {
+	idempotencyRepository idempotency.Repository,
+	todoHandler *handler.TodoHandler) VersionedRouter {
 	return &todoRouter{
-		App:            app,
-		config:         config,
-		sessionService: sessionService,
-		Handler:        todoHandler,
+		config:                config,
+		sessionService:        sessionService,
+		idempotencyRepository: idempotencyRepository,
+		Handler:               todoHandler,
 	}
 }
 
-func (t todoRouter) Register() {
+func (t todoRouter) Versions() []string {
+	return []string{"v1"}
+}
+
+func (t todoRouter) RegisterVersion(api fiber.Router, _ string, handlers ...fiber.Handler) {
 	jwtMiddleware := middlewares.NewJWTMiddleware(
 		context.Background(),
 		t.config.AppSessionType,
@@ -56,13 +97,23 @@ func (t todoRouter) Register() {
 		t.sessionService,
 	)
 
-	t.App.Route("/todos", func(api fiber.Router) {
-		// Using JWT Middleware.
-		protectedRoutes := api.Group("", jwtMiddleware.GetMiddleware())
-		protectedRoutes.Get("/", t.Handler.GetAll).Name("get_all")
-		protectedRoutes.Get("/:id<int>", t.Handler.Get).Name("get")
-		protectedRoutes.Post("/", t.Handler.Save).Name("save")
-		protectedRoutes.Patch("/:id<int>/complete", t.Handler.Completed).Name("completed")
-		protectedRoutes.Delete("/:id<int>", t.Handler.Delete).Name("delete")
-	}, "todos.")
+	idempotencyMiddleware := middlewares.IdempotencyMiddleware(t.idempotencyRepository, t.config.IdempotencyTTL)
+
+	todos := api.Group("/todos").Name("todos.")
+
+	// Using JWT Middleware.
+	protected := chain(handlers, jwtMiddleware.GetMiddleware())
+	todos.Get("/", chain(protected, etag.New(), t.Handler.GetAll)...).Name("get_all")
+	todos.Get("/search", chain(protected, t.Handler.Search)...).Name("search")
+	todos.Get("/export", chain(protected, t.Handler.Export)...).Name("export")
+	todos.Get("/:id<int>", chain(protected, t.Handler.Get)...).Name("get")
+	todos.Post("/", chain(protected, idempotencyMiddleware, t.Handler.Save)...).Name("save")
+	todos.Post("/batch", chain(protected, idempotencyMiddleware, t.Handler.Batch)...).Name("batch")
+	todos.Post("/import", chain(protected, idempotencyMiddleware, t.Handler.Import)...).Name("import")
+	todos.Patch("/:id<int>", chain(protected, t.Handler.Update)...).Name("update")
+	todos.Patch("/:id<int>/complete", chain(protected, t.Handler.Completed)...).Name("completed")
+	todos.Patch("/:id<int>/skip", chain(protected, t.Handler.Skip)...).Name("skip")
+	todos.Patch("/:id<int>/end-series", chain(protected, t.Handler.EndSeries)...).Name("end_series")
+	todos.Post("/:id<int>/move", chain(protected, t.Handler.Move)...).Name("move")
+	todos.Delete("/:id<int>", chain(protected, t.Handler.Delete)...).Name("delete")
 }
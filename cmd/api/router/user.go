@@ -5,17 +5,25 @@ import (
 	"github.com/ferch5003/go-fiber-tutorial/cmd/api/handler"
 	"github.com/ferch5003/go-fiber-tutorial/config"
 	"github.com/ferch5003/go-fiber-tutorial/internal/middlewares"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/idempotency"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/metrics"
 	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/tracing"
 	"github.com/ferch5003/go-fiber-tutorial/internal/user"
+	"github.com/ferch5003/go-fiber-tutorial/internal/webhook"
 	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/fx"
 )
 
 var NewUserModule = fx.Module("user",
-	// Register Repository & Service
-	fx.Provide(user.NewRepository),
+	// Register Service, the Repository is provided by the configured storage
 	fx.Provide(user.NewService),
 
+	// Decorate Repository & Service with observability
+	fx.Decorate(tracing.NewUserRepository),
+	fx.Decorate(decorateUserService),
+
 	// Register Handler
 	fx.Provide(handler.NewUserHandler),
 
@@ -23,31 +31,48 @@ var NewUserModule = fx.Module("user",
 	fx.Provide(
 		fx.Annotate(
 			NewUserRouter,
-			fx.ResultTags(`group:"routers"`),
+			fx.ResultTags(`group:"versioned_routers"`),
 		),
 	),
 )
 
+// decorateUserService wraps the user.Service counting business events inside a traced span, and
+// notifying the webhooks of the updated users.
+func decorateUserService(
+	service user.Service,
+	m *metrics.Metrics,
+	tp trace.TracerProvider,
+	webhooks webhook.Service) user.Service {
+	service = webhook.NewUserService(service, webhooks)
+
+	return tracing.NewUserService(metrics.NewUserService(service, m), tp)
+}
+
 type userRouter struct {
-	App            fiber.Router
-	config         *config.EnvVars
-	sessionService session.Service
-	Handler        *handler.UserHandler
+	config                *config.EnvVars
+	sessionService        session.Service
+	idempotencyRepository idempotency.Repository
+	Handler               *handler.UserHandler
 }
 
-func NewUserRouter(app *fiber.App,
+func NewUserRouter(
 	config *config.EnvVars,
 	sessionService session.Service,
-	userHandler *handler.UserHandler) Router {
+	idempotencyRepository idempotency.Repository,
+	userHandler *handler.UserHandler) VersionedRouter {
 	return &userRouter{
-		App:            app,
-		config:         config,
-		sessionService: sessionService,
-		Handler:        userHandler,
+		config:                config,
+		sessionService:        sessionService,
+		idempotencyRepository: idempotencyRepository,
+		Handler:               userHandler,
 	}
 }
 
-func (u userRouter) Register() {
+func (u userRouter) Versions() []string {
+	return []string{"v1"}
+}
+
+func (u userRouter) RegisterVersion(api fiber.Router, _ string, handlers ...fiber.Handler) {
 	jwtMiddleware := middlewares.NewJWTMiddleware(
 		context.Background(),
 		u.config.AppSessionType,
@@ -55,14 +80,15 @@ func (u userRouter) Register() {
 		u.sessionService,
 	)
 
-	u.App.Route("/users", func(api fiber.Router) {
-		api.Get("/:id<int>", u.Handler.Get).Name("get")
-		api.Post("/register", u.Handler.RegisterUser).Name("register")
-		api.Post("/login", u.Handler.LoginUser).Name("login")
+	idempotencyMiddleware := middlewares.IdempotencyMiddleware(u.idempotencyRepository, u.config.IdempotencyTTL)
+
+	users := api.Group("/users", handlers...).Name("users.")
+	users.Get("/:id<int>", u.Handler.Get).Name("get")
+	users.Post("/register", idempotencyMiddleware, u.Handler.RegisterUser).Name("register")
+	users.Post("/login", u.Handler.LoginUser).Name("login")
 
-		// Using JWT Middleware.
-		protectedRoutes := api.Group("", jwtMiddleware.GetMiddleware())
-		protectedRoutes.Patch("/:id<int>", u.Handler.Update).Name("update")
-		protectedRoutes.Delete("/:id<int>", u.Handler.Delete).Name("delete")
-	}, "users.")
+	// Using JWT Middleware. Naming the group keeps the name prefix of the parent route.
+	protectedRoutes := users.Group("", jwtMiddleware.GetMiddleware()).Name("")
+	protectedRoutes.Patch("/:id<int>", u.Handler.Update).Name("update")
+	protectedRoutes.Delete("/:id<int>", u.Handler.Delete).Name("delete")
 }
@@ -0,0 +1,123 @@
+package router
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/cmd/api/handler"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/middlewares"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/jobs"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
+	"github.com/ferch5003/go-fiber-tutorial/internal/webhook"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/fx"
+)
+
+var NewWebhookModule = fx.Module("webhook",
+	// Register Dispatcher & Service, the Repository is provided by the configured storage
+	fx.Provide(newDispatcher),
+	fx.Provide(webhook.NewService),
+
+	// Register Jobs, the retries of the deliveries are attempted by the worker of any command
+	fx.Provide(
+		fx.Annotate(
+			webhook.NewDeliverHandler,
+			fx.ResultTags(`group:"job_handlers"`),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			webhook.NewRequeuePendingHandler,
+			fx.ResultTags(`group:"job_handlers"`),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			webhook.NewRequeuePendingSchedule,
+			fx.ResultTags(`group:"job_schedules"`),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			webhook.NewPurgeDeliveriesHandler,
+			fx.ResultTags(`group:"job_handlers"`),
+		),
+	),
+	fx.Provide(
+		fx.Annotate(
+			webhook.NewPurgeDeliveriesSchedule,
+			fx.ResultTags(`group:"job_schedules"`),
+		),
+	),
+
+	// Register Handler
+	fx.Provide(handler.NewWebhookHandler),
+
+	// Register Router
+	fx.Provide(
+		fx.Annotate(
+			NewWebhookRouter,
+			fx.ResultTags(`group:"versioned_routers"`),
+		),
+	),
+)
+
+// newDispatcher creates the webhook.Dispatcher, running its workers along with the application. It is
+// created before the web server and the jobs worker are started, so it stops after them, delivering the
+// events of the last requests and jobs.
+func newDispatcher(
+	lc fx.Lifecycle,
+	cfg *config.EnvVars,
+	repository webhook.Repository,
+	queue jobs.Queue) *webhook.Dispatcher {
+	dispatcher := webhook.NewDispatcher(repository, queue, webhook.DispatcherConfig{
+		AllowLocal: cfg.WebhookAllowLocal,
+	})
+
+	lc.Append(fx.Hook{
+		OnStart: dispatcher.Start,
+		OnStop:  dispatcher.Stop,
+	})
+
+	return dispatcher
+}
+
+type webhookRouter struct {
+	config         *config.EnvVars
+	sessionService session.Service
+	Handler        *handler.WebhookHandler
+}
+
+func NewWebhookRouter(
+	config *config.EnvVars,
+	sessionService session.Service,
+	webhookHandler *handler.WebhookHandler) VersionedRouter {
+	return &webhookRouter{
+		config:         config,
+		sessionService: sessionService,
+		Handler:        webhookHandler,
+	}
+}
+
+func (w webhookRouter) Versions() []string {
+	return []string{"v1"}
+}
+
+func (w webhookRouter) RegisterVersion(api fiber.Router, _ string, handlers ...fiber.Handler) {
+	jwtMiddleware := middlewares.NewJWTMiddleware(
+		context.Background(),
+		w.config.AppSessionType,
+		w.config.AppSecretKey,
+		w.sessionService,
+	)
+
+	webhooks := api.Group("/webhooks", handlers...).Name("webhooks.")
+
+	// Using JWT Middleware. Naming the group keeps the name prefix of the parent route.
+	protectedRoutes := webhooks.Group("", jwtMiddleware.GetMiddleware()).Name("")
+	protectedRoutes.Get("/", w.Handler.GetAll).Name("get_all")
+	protectedRoutes.Post("/", w.Handler.Create).Name("create")
+	protectedRoutes.Patch("/:id<int>", w.Handler.Update).Name("update")
+	protectedRoutes.Delete("/:id<int>", w.Handler.Delete).Name("delete")
+	protectedRoutes.Get("/:id<int>/deliveries", w.Handler.Deliveries).Name("deliveries")
+	protectedRoutes.Post("/:id<int>/deliveries/:deliveryID<int>/redeliver", w.Handler.Redeliver).Name("redeliver")
+}
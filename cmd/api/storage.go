@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/idempotency"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/metrics"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/mysql"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/postgres"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/redis"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/sql"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/sqlite"
+	"github.com/ferch5003/go-fiber-tutorial/internal/todo"
+	"github.com/ferch5003/go-fiber-tutorial/internal/user"
+	"github.com/ferch5003/go-fiber-tutorial/internal/webhook"
+	"go.uber.org/fx"
+)
+
+// newStorage provides the repositories of the configured storage, along with the connections and
+// health checkers they need.
+func newStorage(
+	configurations *config.EnvVars,
+	mySQLContainer platform.Container,
+	postgresContainer platform.Container,
+	redisContainer platform.Container) (fx.Option, error) {
+	switch configurations.Storage {
+	case "", config.MySQLStorage:
+		return newSQLStorage(configurations, redisContainer,
+			// Create MySQL Container
+			fx.Invoke(mySQLContainer.CreateOrUseContainer),
+
+			// creates: *sqlx.DB
+			fx.Provide(mysql.NewConnection),
+		), nil
+	case config.PostgresStorage:
+		return newSQLStorage(configurations, redisContainer,
+			// Create PostgreSQL Container
+			fx.Invoke(postgresContainer.CreateOrUseContainer),
+
+			// creates: *sqlx.DB
+			fx.Provide(postgres.NewConnection),
+		), nil
+	case config.SQLiteStorage:
+		return newSQLStorage(configurations, redisContainer,
+			// creates: *sqlx.DB
+			fx.Provide(sqlite.NewConnection),
+		), nil
+	case config.MemoryStorage:
+		return newMemoryStorage(), nil
+	default:
+		return nil, fmt.Errorf("unknown storage: %s", configurations.Storage)
+	}
+}
+
+// newSQLStorage provides the SQL repositories on top of the *sqlx.DB provided by connection options.
+func newSQLStorage(
+	configurations *config.EnvVars,
+	redisContainer platform.Container,
+	connection ...fx.Option) fx.Option {
+	return fx.Options(
+		fx.Options(connection...),
+
+		// Create Redis Container
+		fx.Invoke(func() error {
+			if configurations.AppSessionType != "app" {
+				return nil
+			}
+
+			return redisContainer.CreateOrUseContainer(configurations)
+		}),
+
+		// creates: *redis.Client, which the memory storage does not provide, so the routers take it as optional
+		fx.Provide(redis.NewConnection),
+
+		// creates: audit.Repository, outbox.Repository, session.Repository, idempotency.Repository,
+		// user.Repository, todo.Repository, webhook.Repository
+		fx.Provide(audit.NewRepository),
+		fx.Provide(outbox.NewRepository),
+		fx.Provide(session.NewRepository),
+		fx.Provide(idempotency.NewRepository),
+		fx.Provide(user.NewRepository),
+		fx.Provide(todo.NewRepository),
+		fx.Provide(webhook.NewRepository),
+
+		// creates: todo.Searcher
+		fx.Provide(todo.NewSearcher),
+
+		// creates: sql.Transactor
+		fx.Provide(sql.NewTransactor),
+
+		// creates: []health.Checker `group:"health_checkers"`
+		fx.Provide(
+			fx.Annotate(
+				sql.NewHealthChecker,
+				fx.ResultTags(`group:"health_checkers"`),
+			),
+			fx.Annotate(
+				redis.NewHealthChecker,
+				fx.ResultTags(`group:"health_checkers"`),
+			),
+		),
+
+		// Expose connection pools stats.
+		fx.Invoke((*metrics.Metrics).RegisterDB),
+		fx.Invoke((*metrics.Metrics).RegisterRedis),
+	)
+}
+
+func newMemoryStorage() fx.Option {
+	return fx.Options(
+		// creates: audit.Repository, outbox.Repository, session.Repository, idempotency.Repository,
+		// user.Repository, todo.Repository, webhook.Repository
+		fx.Provide(audit.NewMemoryRepository),
+		fx.Provide(outbox.NewMemoryRepository),
+		fx.Provide(session.NewMemoryRepository),
+		fx.Provide(idempotency.NewMemoryRepository),
+		fx.Provide(user.NewMemoryRepository),
+		fx.Provide(todo.NewMemoryRepository),
+		fx.Provide(webhook.NewMemoryRepository),
+
+		// creates: todo.Searcher
+		fx.Provide(todo.NewIndexSearcher),
+
+		// creates: sql.Transactor
+		fx.Provide(sql.NewNopTransactor),
+	)
+}
@@ -4,15 +4,49 @@ import (
 	"github.com/ferch5003/go-fiber-tutorial/internal/platform/files"
 	"github.com/joho/godotenv"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MySQLStorage keeps data in MySQL and sessions in Redis.
+	MySQLStorage = "mysql"
+
+	// PostgresStorage keeps data in PostgreSQL and sessions in Redis.
+	PostgresStorage = "postgres"
+
+	// SQLiteStorage keeps data in a SQLite file and sessions in Redis.
+	SQLiteStorage = "sqlite"
+
+	// MemoryStorage keeps everything in memory, so the application runs without Docker.
+	MemoryStorage = "memory"
+)
+
+// _defaultIdempotencyTTL keeps the responses of Idempotency-Key requests for a day.
+const _defaultIdempotencyTTL = 24 * time.Hour
+
+// _defaultJobsConcurrency is the number of jobs run at the same time by every instance.
+const _defaultJobsConcurrency = 4
+
+// LogSink and RedisSink are the sinks the outbox relay publishes the domain events to.
+const (
+	// LogSink logs the events.
+	LogSink = "log"
+
+	// RedisSink appends the events to a Redis stream.
+	RedisSink = "redis"
 )
 
 type EnvVars struct {
 	// App Data.
-	AppName        string
-	AppSecretKey   string
-	AppSessionType string // Fiber JWT or Manual JWT ("fiber" or "app").
-	Host           string
-	Port           string
+	AppName         string
+	AppSecretKey    string
+	AppSessionType  string // Fiber JWT or Manual JWT ("fiber" or "app").
+	AppAdminUserIDs []int  // Users allowed to read the audit feed.
+	Storage         string // Storage of the repositories ("mysql", "postgres", "sqlite" or "memory"), defaults to "mysql".
+	Host            string
+	Port            string
 
 	// MySQL Data.
 	MySQLDSN      string
@@ -20,11 +54,50 @@ type EnvVars struct {
 	MySQLPassword string
 	MySQLDB       string
 
+	// PostgreSQL Data.
+	PostgresDSN      string
+	PostgresUsername string
+	PostgresPassword string
+	PostgresDB       string
+
+	// SQLite Data.
+	SQLiteDSN string // Path of the database file, or ":memory:".
+
 	// Redis Data.
 	RedisConnection string
 	RedisUsername   string
 	RedisPassword   string
 	RedisDB         string
+
+	// Cache Data.
+	CacheTTL time.Duration // Lifetime of the cached todos in Redis, the cache is disabled when zero.
+
+	// Idempotency Data.
+	IdempotencyTTL time.Duration // Lifetime of the stored responses of Idempotency-Key requests, defaults to 24h.
+
+	// Jobs Data.
+	JobsConcurrency int  // Jobs run at the same time by every instance, defaults to 4.
+	JobsInline      bool // Also run the jobs and the outbox relay in the serve command, not only in the worker.
+
+	// Outbox Data.
+	OutboxSinks []string // Sinks of the domain events ("log" or "redis"), defaults to "log".
+
+	// Webhook Data.
+	WebhookAllowLocal bool // Deliver webhooks to loopback, private and link-local addresses, only for tests.
+
+	// Metrics Data.
+	MetricsPath     string // Defaults to "/metrics".
+	MetricsUsername string // Basic auth is disabled when empty.
+	MetricsPassword string
+
+	// Tracing Data.
+	TracingExporter string // Tracing is disabled when empty ("otlp" or "stdout").
+	TracingEndpoint string // OTLP HTTP collector endpoint (host:port).
+
+	// API Data.
+	APILegacyRoutes   bool      // Also serve the unversioned paths (/users, /todos) during migration.
+	APILegacySunset   time.Time // Announced removal date of the unversioned paths, zero when unknown.
+	APIRequireIfMatch bool      // Reject writes without If-Match with 428, instead of applying them unconditionally.
 }
 
 func NewConfigurations() (*EnvVars, error) {
@@ -40,6 +113,20 @@ func NewConfigurations() (*EnvVars, error) {
 	appName := os.Getenv("APP_NAME")
 	appSecretKey := os.Getenv("APP_SECRET_KEY")
 	appSessionType := os.Getenv("APP_SESSION_TYPE")
+
+	appAdminUserIDs := make([]int, 0)
+	if value := os.Getenv("APP_ADMIN_USER_IDS"); value != "" {
+		for _, field := range strings.Split(value, ",") {
+			userID, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil {
+				return nil, err
+			}
+
+			appAdminUserIDs = append(appAdminUserIDs, userID)
+		}
+	}
+
+	storage := os.Getenv("STORAGE")
 	host := os.Getenv("HOST")
 	port := os.Getenv("PORT")
 
@@ -48,27 +135,145 @@ func NewConfigurations() (*EnvVars, error) {
 	mySQLPassword := os.Getenv("MYSQL_PASSWORD")
 	mySQLDB := os.Getenv("MYSQL_DB")
 
+	postgresDSN := os.Getenv("POSTGRES_DSN")
+	postgresUsername := os.Getenv("POSTGRES_USERNAME")
+	postgresPassword := os.Getenv("POSTGRES_PASSWORD")
+	postgresDB := os.Getenv("POSTGRES_DB")
+
+	sqliteDSN := os.Getenv("SQLITE_DSN")
+
 	redisConnection := os.Getenv("REDIS_CONNECTION")
 	redisUsername := os.Getenv("REDIS_USERNAME")
 	redisPassword := os.Getenv("REDIS_PASSWORD")
 	redisLDB := os.Getenv("REDIS")
 
+	cacheTTL := time.Duration(0)
+	if value := os.Getenv("CACHE_TTL"); value != "" {
+		cacheTTL, err = time.ParseDuration(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	idempotencyTTL := _defaultIdempotencyTTL
+	if value := os.Getenv("IDEMPOTENCY_TTL"); value != "" {
+		idempotencyTTL, err = time.ParseDuration(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	jobsConcurrency := _defaultJobsConcurrency
+	if value := os.Getenv("JOBS_CONCURRENCY"); value != "" {
+		jobsConcurrency, err = strconv.Atoi(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	jobsInline := true
+	if value := os.Getenv("JOBS_INLINE"); value != "" {
+		jobsInline, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	outboxSinks := []string{LogSink}
+	if value := os.Getenv("OUTBOX_SINKS"); value != "" {
+		outboxSinks = make([]string, 0)
+		for _, field := range strings.Split(value, ",") {
+			outboxSinks = append(outboxSinks, strings.TrimSpace(field))
+		}
+	}
+
+	webhookAllowLocal := false
+	if value := os.Getenv("WEBHOOK_ALLOW_LOCAL"); value != "" {
+		webhookAllowLocal, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	metricsPath := os.Getenv("METRICS_PATH")
+	metricsUsername := os.Getenv("METRICS_USERNAME")
+	metricsPassword := os.Getenv("METRICS_PASSWORD")
+
+	tracingExporter := os.Getenv("TRACING_EXPORTER")
+	tracingEndpoint := os.Getenv("TRACING_ENDPOINT")
+
+	apiLegacyRoutes := false
+	if value := os.Getenv("API_LEGACY_ROUTES"); value != "" {
+		apiLegacyRoutes, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	apiLegacySunset := time.Time{}
+	if value := os.Getenv("API_LEGACY_SUNSET"); value != "" {
+		apiLegacySunset, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	apiRequireIfMatch := false
+	if value := os.Getenv("API_REQUIRE_IF_MATCH"); value != "" {
+		apiRequireIfMatch, err = strconv.ParseBool(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	environment := &EnvVars{
-		AppName:        appName,
-		AppSecretKey:   appSecretKey,
-		AppSessionType: appSessionType,
-		Host:           host,
-		Port:           port,
+		AppName:         appName,
+		AppSecretKey:    appSecretKey,
+		AppSessionType:  appSessionType,
+		AppAdminUserIDs: appAdminUserIDs,
+		Storage:         storage,
+		Host:            host,
+		Port:            port,
 
 		MySQLDSN:      mySQLDSN,
 		MySQLUsername: mySQLUsername,
 		MySQLPassword: mySQLPassword,
 		MySQLDB:       mySQLDB,
 
+		PostgresDSN:      postgresDSN,
+		PostgresUsername: postgresUsername,
+		PostgresPassword: postgresPassword,
+		PostgresDB:       postgresDB,
+
+		SQLiteDSN: sqliteDSN,
+
 		RedisConnection: redisConnection,
 		RedisUsername:   redisUsername,
 		RedisPassword:   redisPassword,
 		RedisDB:         redisLDB,
+
+		CacheTTL: cacheTTL,
+
+		IdempotencyTTL: idempotencyTTL,
+
+		JobsConcurrency: jobsConcurrency,
+		JobsInline:      jobsInline,
+
+		OutboxSinks: outboxSinks,
+
+		WebhookAllowLocal: webhookAllowLocal,
+
+		MetricsPath:     metricsPath,
+		MetricsUsername: metricsUsername,
+		MetricsPassword: metricsPassword,
+
+		TracingExporter: tracingExporter,
+		TracingEndpoint: tracingEndpoint,
+
+		APILegacyRoutes: apiLegacyRoutes,
+		APILegacySunset: apiLegacySunset,
+
+		APIRequireIfMatch: apiRequireIfMatch,
 	}
 
 	return environment, nil
@@ -3,3 +3,11 @@ package apierrors
 import "errors"
 
 var ErrAuthUserNotFound = errors.New("user not found. Unauthorized")
+
+var ErrIfMatchRequired = errors.New("the If-Match header is required. Send the ETag of the resource")
+
+var ErrIdempotencyKeyTooLong = errors.New("the Idempotency-Key header must have at most 255 characters")
+
+var ErrIdempotencyKeyReused = errors.New("the Idempotency-Key was already used with another request")
+
+var ErrIdempotencyKeyInFlight = errors.New("a request with the same Idempotency-Key is being processed. Retry later")
@@ -0,0 +1,204 @@
+// Package audittest provides a conformance suite that every audit.Repository implementation must pass.
+package audittest
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// _createdAt is when the first event of the suite happened, the next ones happen a minute apart.
+var _createdAt = time.Date(2024, time.August, 1, 9, 0, 0, 0, time.UTC)
+
+// RepositoryFactory creates an empty repository for every test.
+type RepositoryFactory func(t *testing.T) audit.Repository
+
+// RunRepositoryTests runs the conformance suite against the repositories created by newRepository.
+func RunRepositoryTests(t *testing.T, newRepository RepositoryFactory) {
+	t.Run("AppendAndList", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		event := domain.AuditEvent{
+			ActorID:  1,
+			Action:   domain.AuditTodoUpdated,
+			Entity:   domain.AuditTodo,
+			EntityID: 2,
+			Changes: domain.AuditChanges{
+				"title": {Before: "Lorem", After: "Ipsum"},
+			},
+			RequestID: "request",
+			IP:        "192.0.2.1",
+			CreatedAt: _createdAt,
+		}
+
+		// When
+		err := repository.Append(ctx, event)
+		require.NoError(t, err)
+
+		events, err := repository.List(ctx, domain.AuditFilter{})
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+
+		event.ID = events[0].ID
+		require.Positive(t, event.ID)
+		require.True(t, event.CreatedAt.Equal(events[0].CreatedAt))
+
+		event.CreatedAt = events[0].CreatedAt
+		require.Equal(t, event, events[0])
+	})
+
+	t.Run("AppendWithoutChanges", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		// When
+		err := repository.Append(ctx, newEvent(domain.AuditUserLoginFailed, domain.AuditUser, 0, 0))
+		require.NoError(t, err)
+
+		events, err := repository.List(ctx, domain.AuditFilter{})
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Empty(t, events[0].Changes)
+	})
+
+	t.Run("ListFromNewest", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		appendEvents(t, repository)
+
+		// When
+		events, err := repository.List(ctx, domain.AuditFilter{})
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, []string{
+			domain.AuditUserLoggedIn,
+			domain.AuditTodoDeleted,
+			domain.AuditTodoCompleted,
+			domain.AuditTodoCreated,
+		}, actions(events))
+	})
+
+	t.Run("ListFiltered", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		appendEvents(t, repository)
+
+		filters := map[string]struct {
+			filter   domain.AuditFilter
+			expected []string
+		}{
+			"Actor": {
+				filter:   domain.AuditFilter{ActorID: 2},
+				expected: []string{domain.AuditTodoDeleted},
+			},
+			"Action": {
+				filter:   domain.AuditFilter{Action: domain.AuditTodoCompleted},
+				expected: []string{domain.AuditTodoCompleted},
+			},
+			"Entity": {
+				filter:   domain.AuditFilter{Entity: domain.AuditTodo, EntityID: 1},
+				expected: []string{domain.AuditTodoCompleted, domain.AuditTodoCreated},
+			},
+			"Period": {
+				filter:   domain.AuditFilter{Since: _createdAt.Add(time.Minute), Until: _createdAt.Add(3 * time.Minute)},
+				expected: []string{domain.AuditTodoDeleted, domain.AuditTodoCompleted},
+			},
+		}
+
+		for name, test := range filters {
+			t.Run(name, func(t *testing.T) {
+				// When
+				events, err := repository.List(ctx, test.filter)
+
+				// Then
+				require.NoError(t, err)
+				require.Equal(t, test.expected, actions(events))
+			})
+		}
+	})
+
+	t.Run("ListPages", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		appendEvents(t, repository)
+
+		// When
+		firstPage, err := repository.List(ctx, domain.AuditFilter{Limit: 3})
+		require.NoError(t, err)
+
+		secondPage, err := repository.List(ctx, domain.AuditFilter{BeforeID: firstPage[2].ID, Limit: 3})
+		require.NoError(t, err)
+
+		// Then
+		require.Len(t, firstPage, 3)
+		require.Equal(t, []string{domain.AuditTodoCreated}, actions(secondPage))
+	})
+
+	t.Run("ListReturnsEmptyListWithoutEvents", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+
+		// When
+		events, err := repository.List(context.Background(), domain.AuditFilter{})
+
+		// Then
+		require.NoError(t, err)
+		require.Empty(t, events)
+		require.NotNil(t, events)
+	})
+}
+
+// appendEvents appends, a minute apart, the creation and completion of the todo 1 by the user 1, the
+// deletion of the todo 2 by the user 2, and a login of the user 1.
+func appendEvents(t *testing.T, repository audit.Repository) {
+	t.Helper()
+
+	events := []domain.AuditEvent{
+		newEvent(domain.AuditTodoCreated, domain.AuditTodo, 1, 1),
+		newEvent(domain.AuditTodoCompleted, domain.AuditTodo, 1, 1),
+		newEvent(domain.AuditTodoDeleted, domain.AuditTodo, 2, 2),
+		newEvent(domain.AuditUserLoggedIn, domain.AuditUser, 1, 1),
+	}
+
+	for i := range events {
+		events[i].CreatedAt = _createdAt.Add(time.Duration(i) * time.Minute)
+	}
+
+	require.NoError(t, repository.Append(context.Background(), events...))
+}
+
+func newEvent(action string, entity string, entityID int, actorID int) domain.AuditEvent {
+	return domain.AuditEvent{
+		ActorID:   actorID,
+		Action:    action,
+		Entity:    entity,
+		EntityID:  entityID,
+		CreatedAt: _createdAt,
+	}
+}
+
+func actions(events []domain.AuditEvent) []string {
+	obtainedActions := make([]string, 0, len(events))
+	for _, event := range events {
+		obtainedActions = append(obtainedActions, event.Action)
+	}
+
+	return obtainedActions
+}
@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"reflect"
+	"time"
+)
+
+// _redacted replaces the values of the secret fields, so the events show they changed without leaking
+// them.
+const _redacted = "[REDACTED]"
+
+var (
+	// _secretFields are never stored in the events.
+	_secretFields = map[string]struct{}{"password": {}}
+
+	// _ignoredFields change on every write, so they would only add noise to the events.
+	_ignoredFields = map[string]struct{}{"version": {}}
+)
+
+// NewEvent returns the event of the action on the entity done by the Source of ctx, with the fields
+// that changed from before to after. before is nil for a created entity, and after for a deleted one.
+func NewEvent(
+	ctx context.Context,
+	action string,
+	entity string,
+	entityID int,
+	before any,
+	after any) (domain.AuditEvent, error) {
+	changes, err := Diff(before, after)
+	if err != nil {
+		return domain.AuditEvent{}, err
+	}
+
+	source := SourceFrom(ctx)
+
+	return domain.AuditEvent{
+		ActorID:   source.ActorID,
+		Action:    action,
+		Entity:    entity,
+		EntityID:  entityID,
+		Changes:   changes,
+		RequestID: source.RequestID,
+		IP:        source.IP,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}, nil
+}
+
+// Diff returns the fields of the JSON representations of before and after that are different. A nil
+// before or after has no fields.
+func Diff(before any, after any) (domain.AuditChanges, error) {
+	beforeFields, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+
+	afterFields, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(domain.AuditChanges)
+
+	for name, value := range afterFields {
+		previous, ok := beforeFields[name]
+		if !ok && isEmpty(value) {
+			continue
+		}
+
+		if !reflect.DeepEqual(previous, value) {
+			changes[name] = domain.AuditChange{Before: previous, After: value}
+		}
+	}
+
+	for name, value := range beforeFields {
+		if _, ok := afterFields[name]; !ok && !isEmpty(value) {
+			changes[name] = domain.AuditChange{Before: value}
+		}
+	}
+
+	for name, change := range changes {
+		if _, ok := _ignoredFields[name]; ok {
+			delete(changes, name)
+
+			continue
+		}
+
+		if _, ok := _secretFields[name]; ok {
+			changes[name] = redact(change)
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	return changes, nil
+}
+
+// jsonFields returns the fields of the JSON object of value, none when value is nil.
+func jsonFields(value any) (map[string]any, error) {
+	var fields map[string]any
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	return fields, nil
+}
+
+// isEmpty reports if the JSON value is zero, which is not worth recording for a field that is created
+// or deleted.
+func isEmpty(value any) bool {
+	return value == nil || value == "" || value == false || value == float64(0)
+}
+
+func redact(change domain.AuditChange) domain.AuditChange {
+	if change.Before != nil {
+		change.Before = _redacted
+	}
+
+	if change.After != nil {
+		change.After = _redacted
+	}
+
+	return change
+}
@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type diffedRecord struct {
+	Title    string `json:"title"`
+	Done     bool   `json:"done"`
+	Password string `json:"password,omitempty"`
+	Version  int    `json:"version"`
+}
+
+func TestDiff_SuccessfulWithChangedFields(t *testing.T) {
+	// Given
+	before := diffedRecord{Title: "Lorem", Version: 1}
+	after := diffedRecord{Title: "Ipsum", Done: true, Version: 2}
+
+	// When
+	changes, err := Diff(before, after)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, domain.AuditChanges{
+		"title": {Before: "Lorem", After: "Ipsum"},
+		"done":  {Before: false, After: true},
+	}, changes)
+}
+
+func TestDiff_SuccessfulWithCreatedRecord(t *testing.T) {
+	// Given
+	after := &diffedRecord{Title: "Lorem", Version: 1}
+
+	// When
+	changes, err := Diff(nil, after)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, domain.AuditChanges{"title": {After: "Lorem"}}, changes)
+}
+
+func TestDiff_SuccessfulWithDeletedRecord(t *testing.T) {
+	// Given
+	before := &diffedRecord{Title: "Lorem", Done: true, Version: 3}
+
+	// When
+	changes, err := Diff(before, nil)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, domain.AuditChanges{
+		"title": {Before: "Lorem"},
+		"done":  {Before: true},
+	}, changes)
+}
+
+func TestDiff_SuccessfulRedactsSecrets(t *testing.T) {
+	// Given
+	before := diffedRecord{Title: "Lorem", Password: "12345678"}
+	after := diffedRecord{Title: "Lorem", Password: "87654321"}
+
+	// When
+	changes, err := Diff(before, after)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, domain.AuditChanges{"password": {Before: _redacted, After: _redacted}}, changes)
+}
+
+func TestDiff_SuccessfulWithoutChanges(t *testing.T) {
+	// Given
+	before := diffedRecord{Title: "Lorem", Version: 1}
+	after := diffedRecord{Title: "Lorem", Version: 2}
+
+	// When
+	changes, err := Diff(before, after)
+
+	// Then
+	require.NoError(t, err)
+	require.Nil(t, changes)
+}
+
+func TestDiff_FailsDueToUnmarshalableValue(t *testing.T) {
+	// Given
+	after := map[string]any{"callback": func() {}}
+
+	// When
+	changes, err := Diff(nil, after)
+
+	// Then
+	require.ErrorContains(t, err, "unsupported type")
+	require.Nil(t, changes)
+}
+
+func TestNewEvent_SuccessfulWithSource(t *testing.T) {
+	// Given
+	source := Source{ActorID: 1, RequestID: "request", IP: "192.0.2.1"}
+	ctx := WithActor(WithSource(context.Background(), source), 2)
+
+	// When
+	event, err := NewEvent(ctx, domain.AuditTodoCreated, domain.AuditTodo, 3, nil, diffedRecord{Title: "Lorem"})
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, 2, event.ActorID)
+	require.Equal(t, source.RequestID, event.RequestID)
+	require.Equal(t, source.IP, event.IP)
+	require.Equal(t, domain.AuditTodoCreated, event.Action)
+	require.Equal(t, domain.AuditTodo, event.Entity)
+	require.Equal(t, 3, event.EntityID)
+	require.Equal(t, domain.AuditChanges{"title": {After: "Lorem"}}, event.Changes)
+	require.False(t, event.CreatedAt.IsZero())
+}
@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"sync"
+)
+
+type memoryRepository struct {
+	mutex  *sync.RWMutex
+	events []domain.AuditEvent
+}
+
+// NewMemoryRepository creates a thread-safe Repository that keeps events in memory. It behaves like the
+// SQL Repository, so it can replace it in development and tests.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{
+		mutex:  &sync.RWMutex{},
+		events: make([]domain.AuditEvent, 0),
+	}
+}
+
+func (r *memoryRepository) Append(_ context.Context, events ...domain.AuditEvent) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, event := range events {
+		event.ID = len(r.events) + 1
+		r.events = append(r.events, event)
+	}
+
+	return nil
+}
+
+func (r *memoryRepository) List(_ context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	events := make([]domain.AuditEvent, 0)
+
+	for i := len(r.events) - 1; i >= 0; i-- {
+		if filter.Limit > 0 && len(events) == filter.Limit {
+			break
+		}
+
+		if event := r.events[i]; matches(event, filter) {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+// matches reports if the event is selected by the filter, like the WHERE clause of the SQL Repository.
+func matches(event domain.AuditEvent, filter domain.AuditFilter) bool {
+	switch {
+	case filter.ActorID != 0 && event.ActorID != filter.ActorID,
+		filter.Action != "" && event.Action != filter.Action,
+		filter.Entity != "" && event.Entity != filter.Entity,
+		filter.EntityID != 0 && event.EntityID != filter.EntityID,
+		!filter.Since.IsZero() && event.CreatedAt.Before(filter.Since),
+		!filter.Until.IsZero() && !event.CreatedAt.Before(filter.Until),
+		filter.BeforeID != 0 && event.ID >= filter.BeforeID:
+		return false
+	default:
+		return true
+	}
+}
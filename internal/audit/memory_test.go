@@ -0,0 +1,13 @@
+package audit_test
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit/audittest"
+	"testing"
+)
+
+func TestMemoryRepository_Conformance(t *testing.T) {
+	audittest.RunRepositoryTests(t, func(t *testing.T) audit.Repository {
+		return audit.NewMemoryRepository()
+	})
+}
@@ -0,0 +1,120 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/sql"
+	"github.com/jmoiron/sqlx"
+	"strings"
+)
+
+const (
+	_insertEventStmt = `INSERT INTO audit_events (actor_id, action, entity, entity_id, changes, request_id, ip, created_at)
+							VALUES (?, ?, ?, ?, ?, ?, ?, ?);`
+	_listEventsStmt = `SELECT id, actor_id, action, entity, entity_id, changes, request_id, ip, created_at
+						FROM audit_events
+						%s
+						ORDER BY id DESC%s;`
+)
+
+type Repository interface {
+	// Append the events, in order.
+	Append(ctx context.Context, events ...domain.AuditEvent) error
+
+	// List the events selected by the filter, from the newest one. A zero limit lists all of them.
+	List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error)
+}
+
+type repository struct {
+	conn    *sqlx.DB
+	dialect sql.Dialect
+}
+
+func NewRepository(conn *sqlx.DB) Repository {
+	return &repository{
+		conn:    conn,
+		dialect: sql.NewDialect(conn.DriverName()),
+	}
+}
+
+func (r repository) Append(ctx context.Context, events ...domain.AuditEvent) error {
+	return insert(ctx, sql.ConnFrom(ctx, r.conn), r.dialect, events)
+}
+
+// Insert appends the events inside tx, so they are only recorded when the changes they describe are
+// committed.
+func Insert(ctx context.Context, tx *sql.Tx, events ...domain.AuditEvent) error {
+	return insert(ctx, tx, sql.NewDialect(tx.DriverName()), events)
+}
+
+func insert(ctx context.Context, conn sqlx.ExecerContext, dialect sql.Dialect, events []domain.AuditEvent) error {
+	for _, event := range events {
+		_, err := conn.ExecContext(ctx, dialect.Rebind(_insertEventStmt),
+			event.ActorID, event.Action, event.Entity, event.EntityID, event.Changes, event.RequestID, event.IP,
+			event.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r repository) List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEvent, error) {
+	conditions := make([]string, 0)
+	args := make([]any, 0)
+
+	where := func(condition string, arg any) {
+		conditions = append(conditions, condition)
+		args = append(args, arg)
+	}
+
+	if filter.ActorID != 0 {
+		where("actor_id = ?", filter.ActorID)
+	}
+
+	if filter.Action != "" {
+		where("action = ?", filter.Action)
+	}
+
+	if filter.Entity != "" {
+		where("entity = ?", filter.Entity)
+	}
+
+	if filter.EntityID != 0 {
+		where("entity_id = ?", filter.EntityID)
+	}
+
+	if !filter.Since.IsZero() {
+		where("created_at >= ?", filter.Since.UTC())
+	}
+
+	if !filter.Until.IsZero() {
+		where("created_at < ?", filter.Until.UTC())
+	}
+
+	if filter.BeforeID != 0 {
+		where("id < ?", filter.BeforeID)
+	}
+
+	whereClause := ""
+	if len(conditions) > 0 {
+		whereClause = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	limitClause := ""
+	if filter.Limit > 0 {
+		limitClause = " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	events := make([]domain.AuditEvent, 0)
+
+	query := r.dialect.Rebind(fmt.Sprintf(_listEventsStmt, whereClause, limitClause))
+	if err := sql.ConnFrom(ctx, r.conn).SelectContext(ctx, &events, query, args...); err != nil {
+		return make([]domain.AuditEvent, 0), err
+	}
+
+	return events, nil
+}
@@ -0,0 +1,35 @@
+package audit_test
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit/audittest"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/mysql/mysqltest"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/postgres/postgrestest"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/sqlite/sqlitetest"
+	"github.com/jmoiron/sqlx"
+	"testing"
+)
+
+func TestRepository_Conformance(t *testing.T) {
+	backends := []struct {
+		name     string
+		connect  func(t *testing.T) *sqlx.DB
+		truncate func(t *testing.T, conn *sqlx.DB)
+	}{
+		{name: "MySQL", connect: mysqltest.NewConnection, truncate: mysqltest.Truncate},
+		{name: "PostgreSQL", connect: postgrestest.NewConnection, truncate: postgrestest.Truncate},
+		{name: "SQLite", connect: sqlitetest.NewConnection, truncate: sqlitetest.Truncate},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			conn := backend.connect(t)
+
+			audittest.RunRepositoryTests(t, func(t *testing.T) audit.Repository {
+				backend.truncate(t, conn)
+
+				return audit.NewRepository(conn)
+			})
+		})
+	}
+}
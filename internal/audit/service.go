@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"slices"
+)
+
+type Service interface {
+	// History returns every event of the entity, from the oldest one.
+	History(ctx context.Context, entity string, entityID int) ([]domain.AuditEvent, error)
+
+	// Feed returns the page of the events selected by the filter, from the newest one.
+	Feed(ctx context.Context, filter domain.AuditFilter) (domain.AuditPage, error)
+}
+
+type service struct {
+	repository Repository
+}
+
+func NewService(repository Repository) Service {
+	return &service{
+		repository: repository,
+	}
+}
+
+func (s service) History(ctx context.Context, entity string, entityID int) ([]domain.AuditEvent, error) {
+	events, err := s.repository.List(ctx, domain.AuditFilter{Entity: entity, EntityID: entityID})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(events)
+
+	return events, nil
+}
+
+func (s service) Feed(ctx context.Context, filter domain.AuditFilter) (domain.AuditPage, error) {
+	limit := filter.Limit
+
+	// One more event tells if there is a next page. Without limit, every event is in the page.
+	if limit > 0 {
+		filter.Limit++
+	}
+
+	events, err := s.repository.List(ctx, filter)
+	if err != nil {
+		return domain.AuditPage{}, err
+	}
+
+	page := domain.AuditPage{Events: events}
+
+	if limit > 0 && len(events) > limit {
+		page.Events = events[:limit]
+		page.NextBeforeID = page.Events[limit-1].ID
+	}
+
+	return page, nil
+}
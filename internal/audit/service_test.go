@@ -0,0 +1,86 @@
+package audit
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// appendEvents appends count events of the todo of entityID to repository, in order.
+func appendEvents(t *testing.T, repository Repository, entityID int, count int) {
+	t.Helper()
+
+	for range count {
+		err := repository.Append(context.Background(), domain.AuditEvent{
+			Action:   domain.AuditTodoUpdated,
+			Entity:   domain.AuditTodo,
+			EntityID: entityID,
+		})
+		require.NoError(t, err)
+	}
+}
+
+func TestServiceHistory_SuccessfulFromOldest(t *testing.T) {
+	// Given
+	repository := NewMemoryRepository()
+	appendEvents(t, repository, 1, 2)
+	appendEvents(t, repository, 2, 1)
+	appendEvents(t, repository, 1, 1)
+
+	service := NewService(repository)
+
+	// When
+	events, err := service.History(context.Background(), domain.AuditTodo, 1)
+
+	// Then
+	require.NoError(t, err)
+	require.Len(t, events, 3)
+
+	for i, event := range events {
+		require.Equal(t, 1, event.EntityID)
+
+		if i > 0 {
+			require.Greater(t, event.ID, events[i-1].ID)
+		}
+	}
+}
+
+func TestServiceFeed_SuccessfulWithNextPage(t *testing.T) {
+	// Given
+	repository := NewMemoryRepository()
+	appendEvents(t, repository, 1, 5)
+
+	service := NewService(repository)
+
+	// When
+	firstPage, err := service.Feed(context.Background(), domain.AuditFilter{Limit: 2})
+	require.NoError(t, err)
+
+	lastPage, err := service.Feed(context.Background(), domain.AuditFilter{Limit: 3, BeforeID: firstPage.NextBeforeID})
+	require.NoError(t, err)
+
+	// Then
+	require.Len(t, firstPage.Events, 2)
+	require.Equal(t, firstPage.Events[1].ID, firstPage.NextBeforeID)
+
+	require.Len(t, lastPage.Events, 3)
+	require.Zero(t, lastPage.NextBeforeID)
+	require.Less(t, lastPage.Events[0].ID, firstPage.NextBeforeID)
+}
+
+func TestServiceFeed_SuccessfulWithoutLimit(t *testing.T) {
+	// Given
+	repository := NewMemoryRepository()
+	appendEvents(t, repository, 1, 3)
+
+	service := NewService(repository)
+
+	// When
+	page, err := service.Feed(context.Background(), domain.AuditFilter{})
+
+	// Then
+	require.NoError(t, err)
+	require.Len(t, page.Events, 3)
+	require.Zero(t, page.NextBeforeID)
+}
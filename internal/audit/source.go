@@ -0,0 +1,37 @@
+// Package audit records an append-only trail of who changed what, from where. Repositories append the
+// events of their writes in the same transaction, so an event exists if and only if its change does.
+package audit
+
+import (
+	"context"
+)
+
+type sourceKey struct{}
+
+// Source identifies who does the changes of a request, and from where.
+type Source struct {
+	ActorID   int // Zero when nobody is authenticated.
+	RequestID string
+	IP        string
+}
+
+// WithSource returns a copy of ctx whose changes are done by source.
+func WithSource(ctx context.Context, source Source) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+// WithActor returns a copy of ctx whose changes are done by the user of actorID, keeping the rest of
+// its Source.
+func WithActor(ctx context.Context, actorID int) context.Context {
+	source := SourceFrom(ctx)
+	source.ActorID = actorID
+
+	return WithSource(ctx, source)
+}
+
+// SourceFrom returns the Source of the changes done with ctx, empty when unknown.
+func SourceFrom(ctx context.Context) Source {
+	source, _ := ctx.Value(sourceKey{}).(Source)
+
+	return source
+}
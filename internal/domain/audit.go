@@ -0,0 +1,117 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Entities of the audit events.
+const (
+	AuditTodo = "todo"
+	AuditUser = "user"
+)
+
+// Actions of the audit events.
+const (
+	AuditTodoCreated     = "todo.created"
+	AuditTodoUpdated     = "todo.updated"
+	AuditTodoCompleted   = "todo.completed"
+	AuditTodoSkipped     = "todo.skipped"
+	AuditTodoSeriesEnded = "todo.series_ended"
+	AuditTodoMoved       = "todo.moved"
+	AuditTodoDeleted     = "todo.deleted"
+	AuditUserCreated     = "user.created"
+	AuditUserUpdated     = "user.updated"
+	AuditUserDeleted     = "user.deleted"
+	AuditUserLoggedIn    = "user.logged_in"
+	AuditUserLoginFailed = "user.login_failed"
+)
+
+// AuditEvent records who did an action on an entity, from where, and what it changed. Events are only
+// appended, never changed.
+type AuditEvent struct {
+	ID        int          `json:"id" db:"id"`
+	ActorID   int          `json:"actor_id,omitempty" db:"actor_id"` // Zero when nobody was authenticated.
+	Action    string       `json:"action" db:"action"`
+	Entity    string       `json:"entity" db:"entity"`
+	EntityID  int          `json:"entity_id,omitempty" db:"entity_id"` // Zero when the entity is unknown.
+	Changes   AuditChanges `json:"changes,omitempty" db:"changes"`
+	RequestID string       `json:"request_id,omitempty" db:"request_id"`
+	IP        string       `json:"ip,omitempty" db:"ip"`
+	CreatedAt time.Time    `json:"created_at" db:"created_at"`
+}
+
+// AuditChange is the value of a field before and after an action, nil when the field did not exist.
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// AuditChanges are the changed fields, by their JSON name. They are stored as a JSON object.
+type AuditChanges map[string]AuditChange
+
+// Value implements driver.Valuer.
+func (c AuditChanges) Value() (driver.Value, error) {
+	if len(c) == 0 {
+		return "{}", nil
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner.
+func (c *AuditChanges) Scan(src any) error {
+	var data []byte
+
+	switch value := src.(type) {
+	case nil:
+		*c = nil
+
+		return nil
+	case string:
+		data = []byte(value)
+	case []byte:
+		data = value
+	default:
+		return fmt.Errorf("can not scan %T into AuditChanges", src)
+	}
+
+	changes := make(AuditChanges)
+	if err := json.Unmarshal(data, &changes); err != nil {
+		return err
+	}
+
+	if len(changes) == 0 {
+		changes = nil
+	}
+
+	*c = changes
+
+	return nil
+}
+
+// AuditFilter selects the audit events of a feed, from the newest one. Zero fields do not filter.
+type AuditFilter struct {
+	ActorID  int
+	Action   string
+	Entity   string
+	EntityID int
+	Since    time.Time
+	Until    time.Time
+	BeforeID int // Only events older than this one, to get the next page.
+	Limit    int
+}
+
+// AuditPage is a page of an audit feed. NextBeforeID is the BeforeID of the next page, zero on the last
+// page.
+type AuditPage struct {
+	Events       []AuditEvent `json:"events"`
+	NextBeforeID int          `json:"next_before_id,omitempty"`
+}
@@ -0,0 +1,62 @@
+package domain
+
+import "errors"
+
+// ErrVersionMismatch is returned by writes that expected another version of the resource, because it
+// was modified in the meantime.
+var ErrVersionMismatch = errors.New("the resource was modified by another request")
+
+// ErrNotFound is returned when the resource does not exist.
+var ErrNotFound = errors.New("the resource was not found")
+
+// ErrNotOwned is returned when the resource belongs to another user.
+var ErrNotOwned = errors.New("the resource belongs to another user")
+
+// ErrBatchAborted is the result of the operations of an all-or-nothing batch that were not applied,
+// because another operation of the batch failed.
+var ErrBatchAborted = errors.New("the operation was not applied because another operation of the batch failed")
+
+// ErrInvalidRecurrence is returned when the recurrence rule of a todo can not be parsed.
+var ErrInvalidRecurrence = errors.New("invalid recurrence rule")
+
+// ErrMissingDueDate is returned when a recurring todo has no due date, which is its first occurrence.
+var ErrMissingDueDate = errors.New("a recurring todo needs a due date")
+
+// ErrNotRecurring is returned by the series operations on a todo that does not recur.
+var ErrNotRecurring = errors.New("the todo does not recur")
+
+// ErrSeriesEnded is returned when skipping the last occurrence of a series.
+var ErrSeriesEnded = errors.New("the series has no more occurrences")
+
+// ErrRecurrenceScope is returned when changing the recurrence of a single occurrence of a series, as it
+// only applies to the occurrence and the ones after it.
+var ErrRecurrenceScope = errors.New("the recurrence of a series can only be changed for the future occurrences")
+
+// ErrInvalidMove is returned when a todo is moved next to itself.
+var ErrInvalidMove = errors.New("a todo can not be moved next to itself")
+
+// ErrInvalidCredentials is returned when a login does not match any user.
+var ErrInvalidCredentials = errors.New("the email or the password are incorrect")
+
+// ErrInvalidEventID is returned when resuming a stream of events from an ID that was not sent by it.
+var ErrInvalidEventID = errors.New("invalid event ID")
+
+// ErrWebhookDisabled is returned when redelivering an event to a disabled webhook.
+var ErrWebhookDisabled = errors.New("the webhook is disabled")
+
+// ErrWebhookForbiddenURL is returned when the URL of a webhook points to a loopback, private or link-local
+// address, which would let users reach the internal network of the server.
+var ErrWebhookForbiddenURL = errors.New("the webhook URL must not point to a loopback, private or link-local address")
+
+// ErrMissingTitle is returned when an imported todo has no title.
+var ErrMissingTitle = errors.New("a todo needs a title")
+
+// ErrDuplicateExternalID is returned when importing a todo whose external ID was already imported.
+var ErrDuplicateExternalID = errors.New("a todo with the external ID was already imported")
+
+// ErrUnknownFormat is returned when exporting or importing todos in a format that is not supported.
+var ErrUnknownFormat = errors.New("unknown format")
+
+// ErrInvalidImport is returned when an imported file can not be read at all, unlike the rows of it that
+// can not be read, which are reported one by one.
+var ErrInvalidImport = errors.New("the imported file is not valid")
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"slices"
+	"time"
+)
+
+// Aggregates of the outbox messages.
+const (
+	OutboxTodo = "todo"
+	OutboxUser = "user"
+)
+
+// OutboxMessage is a domain event, written in the transaction of the change it describes and published
+// afterward. Its ID orders the messages, so consumers receive the ones of an aggregate in the order they
+// happened.
+type OutboxMessage struct {
+	ID            int           `json:"id" db:"id"`
+	AggregateType string        `json:"aggregate_type" db:"aggregate_type"`
+	AggregateID   int           `json:"aggregate_id" db:"aggregate_id"`
+	EventType     string        `json:"event_type" db:"event_type"` // One of the audit actions, like "todo.created".
+	Payload       OutboxPayload `json:"payload" db:"payload"`
+	CreatedAt     time.Time     `json:"created_at" db:"created_at"`
+	PublishedAt   *time.Time    `json:"published_at,omitempty" db:"published_at"` // Nil until it is published.
+
+	// Attempts that failed to publish it. After too many of them it is dead-lettered, it is no longer
+	// published.
+	Attempts       int        `json:"attempts" db:"attempts"`
+	DeadLetteredAt *time.Time `json:"dead_lettered_at,omitempty" db:"dead_lettered_at"`
+}
+
+// OutboxPayload is the JSON subject of an outbox message. It is stored as text.
+type OutboxPayload []byte
+
+// MarshalJSON implements json.Marshaler.
+func (p OutboxPayload) MarshalJSON() ([]byte, error) {
+	if len(p) == 0 {
+		return []byte("null"), nil
+	}
+
+	return p, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *OutboxPayload) UnmarshalJSON(data []byte) error {
+	*p = slices.Clone(data)
+
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (p OutboxPayload) Value() (driver.Value, error) {
+	return string(p), nil
+}
+
+// Scan implements sql.Scanner.
+func (p *OutboxPayload) Scan(src any) error {
+	data, err := scanText(src, "OutboxPayload")
+	if err != nil {
+		return err
+	}
+
+	*p = slices.Clone(data)
+
+	return nil
+}
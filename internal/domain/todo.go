@@ -1,9 +1,171 @@
 package domain
 
+import (
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/data"
+	"time"
+)
+
 type Todo struct {
 	ID          int    `json:"id" db:"id"`
 	Title       string `json:"title" db:"title" fake:"{word}" validate:"required"`
 	Description string `json:"description" db:"description" fake:"{loremipsumsentence:10}" validate:"required"`
 	Completed   bool   `json:"completed" db:"completed" fake:"{bool}"`
 	UserID      int    `json:"user_id" db:"user_id"`
+	Version     int    `json:"version" db:"version" fake:"skip"` // Incremented on every write, starting at 1.
+
+	// DueAt is the due date, in UTC. It is required by recurring todos, as it is the date of the
+	// occurrence.
+	DueAt *time.Time `json:"due_at,omitempty" db:"due_at" fake:"skip" validate:"required_with=Recurrence"`
+
+	// Recurrence is the RRULE of the series of a recurring todo, with its DTSTART in the time zone of the
+	// due date it started at, and empty for the todos that do not recur. Only the pending occurrence of a
+	// series recurs: completing it creates the next.
+	Recurrence string `json:"recurrence,omitempty" db:"recurrence" fake:"skip"`
+
+	// SeriesID is the ID of the first occurrence of the series of the todo, and zero for the todos that
+	// never recurred.
+	SeriesID int `json:"series_id,omitempty" db:"series_id" fake:"skip"`
+
+	// Position orders the todos of the user, from the lowest. Positions leave gaps between todos, so
+	// moving one usually only writes the moved todo.
+	Position int `json:"position,omitempty" db:"position" fake:"skip"`
+
+	// ExternalID identifies the todo in the tool it was imported from, and is empty for the todos created
+	// here. Imports skip the todos whose external ID was already imported for the user.
+	ExternalID string `json:"external_id,omitempty" db:"external_id" fake:"skip"`
+
+	// NextID is the ID of the occurrence created by the write that returned the todo, when it completed
+	// the pending occurrence of a series. It is neither stored nor sent.
+	NextID int `json:"-" db:"-" fake:"skip"`
+}
+
+// Recurs reports whether the todo is the pending occurrence of a series, which creates the next one.
+func (t Todo) Recurs() bool {
+	return t.Recurrence != "" && !t.Completed
+}
+
+// TodoScope selects the occurrences of a series changed by an edit.
+type TodoScope string
+
+const (
+	// TodoScopeThis changes only the occurrence, which leaves the series.
+	TodoScopeThis TodoScope = "this"
+
+	// TodoScopeFuture changes the occurrence and the ones after it.
+	TodoScopeFuture TodoScope = "future"
+)
+
+// TodoChanges are the fields written by an update of the Todo with the ID. An empty Title, a nil DueAt
+// and an empty Recurrence are kept, while the Description and Completed are written whenever they are
+// present, so the description can be cleared and a completed todo reopened. A non-zero Version must
+// match the version of the Todo.
+type TodoChanges struct {
+	ID          int
+	Version     int
+	Title       string
+	Description data.Patch[string]
+	Completed   data.Patch[bool]
+	DueAt       *time.Time
+	Recurrence  string
+}
+
+// IsEmpty reports whether the changes write no field, so applying them leaves the Todo as it is.
+func (c TodoChanges) IsEmpty() bool {
+	return c.Title == "" && !c.Description.Present() && !c.Completed.Present() && c.DueAt == nil &&
+		c.Recurrence == ""
+}
+
+// TodoAction is the write applied by a TodoOperation.
+type TodoAction string
+
+const (
+	TodoCreate   TodoAction = "create"
+	TodoUpdate   TodoAction = "update"
+	TodoComplete TodoAction = "complete"
+	TodoDelete   TodoAction = "delete"
+)
+
+// TodoOperation is one write of a batch. Create uses the Title and Description, update changes the
+// Todo with the ID like TodoChanges do, and complete and delete only use the ID. A non-zero Version
+// must match the version of the Todo.
+type TodoOperation struct {
+	Action      TodoAction         `json:"action" validate:"required,oneof=create update complete delete"`
+	ID          int                `json:"id" validate:"required_unless=Action create"`
+	Version     int                `json:"version"`
+	Title       string             `json:"title" validate:"required_if=Action create"`
+	Description data.Patch[string] `json:"description" validate:"required_if=Action create"`
+	Completed   data.Patch[bool]   `json:"completed"`
+}
+
+// TodoOperationResult is the outcome of the TodoOperation at Index of a batch.
+type TodoOperationResult struct {
+	Index   int
+	Action  TodoAction
+	ID      int
+	Version int // Version of the Todo after the operation, zero when it was deleted or not applied.
+	NextID  int // ID of the occurrence created by the operation, zero when it created none.
+	Err     error
+}
+
+// TodoSearch is a full-text search on the todos of a user, returning one page of the hits.
+type TodoSearch struct {
+	UserID   int
+	Query    string
+	Page     int // Starting at 1.
+	PageSize int
+}
+
+// Offset is the number of hits before the page.
+func (s TodoSearch) Offset() int {
+	return (s.Page - 1) * s.PageSize
+}
+
+// TodoSearchHit is a Todo matching a search, with its relevance and a snippet of the matching text.
+type TodoSearchHit struct {
+	Todo  Todo    `json:"todo"`
+	Score float64 `json:"score"`
+
+	// Snippet is HTML-escaped text of the Todo with the matching terms inside <mark> tags.
+	Snippet string `json:"snippet"`
+}
+
+// TodoSearchPage is a page of the hits of a search, from the most relevant one.
+type TodoSearchPage struct {
+	Hits     []TodoSearchHit `json:"hits"`
+	Total    int             `json:"total"`
+	Page     int             `json:"page"`
+	PageSize int             `json:"page_size"`
+}
+
+// TodoMove places the Todo of ID, of the user, right before the Todo of BeforeID or right after the Todo
+// of AfterID, only one of them being set. A non-zero Version must match the version of the moved Todo.
+type TodoMove struct {
+	ID       int
+	UserID   int
+	BeforeID int
+	AfterID  int
+	Version  int
+}
+
+// TodoImportRow is a todo read from an imported file. Err is set when the row can not be read.
+type TodoImportRow struct {
+	Row  int // Starting at 1, the record of a CSV file or the element of a JSON or iCalendar file.
+	Todo Todo
+	Err  error
+}
+
+// TodoImport saves the todos of the rows of a file for the user, after its other todos. A dry run only
+// validates the rows.
+type TodoImport struct {
+	UserID int
+	Rows   []TodoImportRow
+	DryRun bool
+}
+
+// TodoImportResult is the outcome of the TodoImportRow of Row.
+type TodoImportResult struct {
+	Row        int
+	ExternalID string
+	ID         int // ID of the saved Todo, zero when it was not saved.
+	Err        error
 }
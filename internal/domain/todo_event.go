@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// Types of the changes pushed to the clients following the todos of a user.
+const (
+	TodoEventCreated   = "todo.created"
+	TodoEventUpdated   = "todo.updated"
+	TodoEventCompleted = "todo.completed"
+	TodoEventDeleted   = "todo.deleted"
+)
+
+// TodoEvent is a change of a todo, pushed to the clients of its user. The ID orders the events of a
+// user, and resumes a stream after it. A deleted todo only carries its ID and its user.
+type TodoEvent struct {
+	ID     string    `json:"id"`
+	Type   string    `json:"type"`
+	UserID int       `json:"user_id"`
+	Todo   Todo      `json:"todo"`
+	At     time.Time `json:"at"`
+}
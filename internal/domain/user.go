@@ -10,6 +10,7 @@ type User struct {
 	LastName  string `json:"last_name" db:"last_name" fake:"{lastname}"`
 	Email     string `json:"email" db:"email" fake:"{email}"`
 	Password  string `json:"password" db:"password"`
+	Version   int    `json:"version" db:"version" fake:"skip"` // Incremented on every write, starting at 1.
 }
 
 func (u *User) HashPassword() error {
@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// WebhookUserUpdated is the event of a user updating their account. Deleting the account deletes its
+// webhooks too, so it has no event.
+const WebhookUserUpdated = "user.updated"
+
+// WebhookEvents are the events webhooks can subscribe to.
+var WebhookEvents = []string{
+	TodoEventCreated,
+	TodoEventUpdated,
+	TodoEventCompleted,
+	TodoEventDeleted,
+	WebhookUserUpdated,
+}
+
+// Statuses of a webhook delivery.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook is an endpoint of a user that receives the events it subscribed to. It is disabled after
+// failing too many deliveries in a row.
+type Webhook struct {
+	ID       int           `json:"id" db:"id"`
+	UserID   int           `json:"user_id" db:"user_id"`
+	URL      string        `json:"url" db:"url"`
+	Events   WebhookFilter `json:"events" db:"events"`
+	Secret   string        `json:"secret,omitempty" db:"secret"` // Only shown when the webhook is created.
+	Active   bool          `json:"active" db:"active"`
+	Failures int           `json:"failures" db:"failures"` // Failed deliveries since the last successful one.
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Receives reports whether the webhook subscribed to the event.
+func (w Webhook) Receives(event string) bool {
+	return slices.Contains(w.Events, event)
+}
+
+// WebhookFilter are the events a webhook subscribed to. They are stored as a JSON array.
+type WebhookFilter []string
+
+// Value implements driver.Valuer.
+func (f WebhookFilter) Value() (driver.Value, error) {
+	if len(f) == 0 {
+		return "[]", nil
+	}
+
+	data, err := json.Marshal([]string(f))
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner.
+func (f *WebhookFilter) Scan(src any) error {
+	data, err := scanText(src, "WebhookFilter")
+	if err != nil || data == nil {
+		*f = nil
+
+		return err
+	}
+
+	return json.Unmarshal(data, (*[]string)(f))
+}
+
+// WebhookDelivery is the delivery of an event to a webhook, retried until it succeeds or runs out of
+// attempts. Redelivering an event creates another delivery.
+type WebhookDelivery struct {
+	ID         int            `json:"id" db:"id"`
+	WebhookID  int            `json:"webhook_id" db:"webhook_id"`
+	Event      string         `json:"event" db:"event"`
+	Payload    WebhookPayload `json:"payload" db:"payload"`
+	Status     string         `json:"status" db:"status"`
+	Attempts   int            `json:"attempts" db:"attempts"`
+	StatusCode int            `json:"status_code,omitempty" db:"status_code"` // Of the last attempt.
+	Error      string         `json:"error,omitempty" db:"error"`             // Of the last attempt.
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// WebhookPayload is the JSON body sent to the webhook. It is stored as text.
+type WebhookPayload []byte
+
+// MarshalJSON implements json.Marshaler.
+func (p WebhookPayload) MarshalJSON() ([]byte, error) {
+	if len(p) == 0 {
+		return []byte("null"), nil
+	}
+
+	return p, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (p *WebhookPayload) UnmarshalJSON(data []byte) error {
+	*p = slices.Clone(data)
+
+	return nil
+}
+
+// Value implements driver.Valuer.
+func (p WebhookPayload) Value() (driver.Value, error) {
+	return string(p), nil
+}
+
+// Scan implements sql.Scanner.
+func (p *WebhookPayload) Scan(src any) error {
+	data, err := scanText(src, "WebhookPayload")
+	if err != nil {
+		return err
+	}
+
+	*p = slices.Clone(data)
+
+	return nil
+}
+
+// scanText returns the text of a column scanned into the type, nil when it is NULL.
+func scanText(src any, into string) ([]byte, error) {
+	switch value := src.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(value), nil
+	case []byte:
+		return value, nil
+	default:
+		return nil, fmt.Errorf("can not scan %T into %s", src, into)
+	}
+}
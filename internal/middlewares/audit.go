@@ -0,0 +1,22 @@
+package middlewares
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
+	"github.com/gofiber/fiber/v2"
+)
+
+// AuditMiddleware stores the request ID and the IP of the client in the user context, so the audit
+// events of the changes done by the request record where they came from. The actor is added once the
+// user is authenticated.
+func AuditMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		source := audit.Source{
+			RequestID: GetRequestID(c),
+			IP:        c.IP(),
+		}
+
+		c.SetUserContext(audit.WithSource(c.UserContext(), source))
+
+		return c.Next()
+	}
+}
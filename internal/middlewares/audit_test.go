@@ -0,0 +1,34 @@
+package middlewares
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAuditMiddleware_SuccessfulStoresSource(t *testing.T) {
+	// Given
+	var source audit.Source
+
+	app := fiber.New()
+	app.Use(RequestIDMiddleware())
+	app.Use(AuditMiddleware())
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		source = audit.SourceFrom(c.UserContext())
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "request-id")
+
+	// When
+	resp, err := app.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, audit.Source{RequestID: "request-id", IP: "0.0.0.0"}, source)
+}
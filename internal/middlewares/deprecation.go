@@ -0,0 +1,34 @@
+package middlewares
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"time"
+)
+
+const (
+	// HeaderDeprecation tells clients the requested API is deprecated.
+	HeaderDeprecation = "Deprecation"
+
+	// HeaderSunset is the HTTP date after which the requested API may stop answering.
+	HeaderSunset = "Sunset"
+)
+
+// DeprecationMiddleware marks responses of a deprecated API with the Deprecation header, the Sunset
+// header when a removal date is announced, and a link to the successor API when there is one.
+func DeprecationMiddleware(sunset time.Time, successor string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Set(HeaderDeprecation, "true")
+
+		if !sunset.IsZero() {
+			c.Set(HeaderSunset, sunset.UTC().Format(http.TimeFormat))
+		}
+
+		if successor != "" {
+			c.Set(fiber.HeaderLink, fmt.Sprintf(`<%s>; rel="successor-version"`, successor))
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDeprecationMiddleware_Successful(t *testing.T) {
+	// Given
+	app := fiber.New()
+
+	sunset := time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)
+	app.Use(DeprecationMiddleware(sunset, "/api/v2"))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+
+	// When
+	resp, err := app.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, "true", resp.Header.Get(HeaderDeprecation))
+	require.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", resp.Header.Get(HeaderSunset))
+	require.Equal(t, `</api/v2>; rel="successor-version"`, resp.Header.Get(fiber.HeaderLink))
+}
+
+func TestDeprecationMiddleware_WithoutSunsetNorSuccessor(t *testing.T) {
+	// Given
+	app := fiber.New()
+
+	app.Use(DeprecationMiddleware(time.Time{}, ""))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+
+	// When
+	resp, err := app.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "true", resp.Header.Get(HeaderDeprecation))
+	require.Empty(t, resp.Header.Get(HeaderSunset))
+	require.Empty(t, resp.Header.Get(fiber.HeaderLink))
+}
@@ -0,0 +1,191 @@
+package middlewares
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/ferch5003/go-fiber-tutorial/internal/apierrors"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/idempotency"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/logging"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+	"io"
+	"mime/multipart"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// HeaderIdempotencyKey is the header clients send to retry a request without repeating it.
+	HeaderIdempotencyKey = "Idempotency-Key"
+
+	// HeaderIdempotentReplayed tells clients the response is the stored one of a previous request.
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	// _idempotencyLockTTL limits how long a crashed request keeps its key in flight.
+	_idempotencyLockTTL = time.Minute
+
+	_maxIdempotencyKeyLength = 255
+)
+
+// IdempotencyMiddleware answers requests repeating the Idempotency-Key of a previous request with its
+// stored response, for ttl. Keys are scoped to the route and to the user authenticated by the JWT
+// middleware, so they survive a new token; on public routes, like the registration, the key alone is
+// used. Reusing a key with another body is rejected with 422, and with 409 while the first request is in
+// flight. Failed requests, answered with 5xx, are not stored, so they can be retried. Multipart bodies
+// are compared by their fields and files, since their boundary changes on every retry.
+func IdempotencyMiddleware(repository idempotency.Repository, ttl time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := c.Get(HeaderIdempotencyKey)
+		if key == "" {
+			return c.Next()
+		}
+
+		if len(key) > _maxIdempotencyKeyLength {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": apierrors.ErrIdempotencyKeyTooLong.Error(),
+			})
+		}
+
+		ctx := c.UserContext()
+		userID, _ := c.Locals(UserIDKey).(int)
+		scopedKey := hashParts(c.Method(), c.Route().Path, strconv.Itoa(userID), key)
+		requestHash, err := hashRequest(c)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		record, reserved, err := repository.Reserve(ctx, scopedKey, requestHash, _idempotencyLockTTL)
+		if err != nil {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		if !reserved {
+			switch {
+			case record.RequestHash != requestHash:
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+					"error": apierrors.ErrIdempotencyKeyReused.Error(),
+				})
+			case record.InFlight:
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": apierrors.ErrIdempotencyKeyInFlight.Error(),
+				})
+			}
+
+			c.Set(HeaderIdempotentReplayed, "true")
+			c.Set(fiber.HeaderContentType, record.ContentType)
+
+			return c.Status(record.StatusCode).Send(record.Body)
+		}
+
+		if err := c.Next(); err != nil {
+			releaseIdempotencyKey(c, repository, scopedKey)
+
+			return err
+		}
+
+		statusCode := c.Response().StatusCode()
+		if statusCode >= fiber.StatusInternalServerError {
+			releaseIdempotencyKey(c, repository, scopedKey)
+
+			return nil
+		}
+
+		err = repository.Save(ctx, scopedKey, idempotency.Record{
+			RequestHash: requestHash,
+			StatusCode:  statusCode,
+			ContentType: string(c.Response().Header.ContentType()),
+			Body:        c.Response().Body(),
+		}, ttl)
+		if err != nil {
+			// The request already succeeded, a retry will wait for the lock to expire.
+			logging.FromContext(ctx).Error("failed to save idempotent response", zap.Error(err))
+		}
+
+		return nil
+	}
+}
+
+func releaseIdempotencyKey(c *fiber.Ctx, repository idempotency.Repository, key string) {
+	if err := repository.Release(c.UserContext(), key); err != nil {
+		logging.FromContext(c.UserContext()).Error("failed to release idempotency key", zap.Error(err))
+	}
+}
+
+// hashParts returns the hex SHA-256 of the parts, separated so they can not be shifted between them.
+func hashParts(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// hashRequest returns the hash of the body of the request. A multipart body is hashed by the names and
+// values of its fields, and the names, filenames and contents of its files, in order of name.
+func hashRequest(c *fiber.Ctx) (string, error) {
+	if !strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		return hashParts(string(c.Body())), nil
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return "", err
+	}
+
+	parts := make([]string, 0)
+
+	for _, name := range sortedKeys(form.Value) {
+		for _, value := range form.Value[name] {
+			parts = append(parts, "value", name, value)
+		}
+	}
+
+	for _, name := range sortedKeys(form.File) {
+		for _, file := range form.File[name] {
+			contentHash, err := hashFile(file)
+			if err != nil {
+				return "", err
+			}
+
+			parts = append(parts, "file", name, file.Filename, contentHash)
+		}
+	}
+
+	return hashParts(parts...), nil
+}
+
+// hashFile returns the hex SHA-256 of the content of the uploaded file.
+func hashFile(header *multipart.FileHeader) (string, error) {
+	file, err := header.Open()
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
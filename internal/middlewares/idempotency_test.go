@@ -0,0 +1,328 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ferch5003/go-fiber-tutorial/internal/apierrors"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/idempotency"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type failingIdempotencyRepository struct {
+	idempotency.Repository
+}
+
+func (failingIdempotencyRepository) Reserve(
+	context.Context,
+	string,
+	string,
+	time.Duration) (idempotency.Record, bool, error) {
+	return idempotency.Record{}, false, errors.New("connection refused")
+}
+
+// _testUserIDHeader authenticates the requests of the idempotent server as the user it holds.
+const _testUserIDHeader = "X-User-ID"
+
+// createIdempotentServer serves POST / counting the requests that reach the handler, which answers
+// with the given status.
+func createIdempotentServer(repository idempotency.Repository, status int, calls *atomic.Int32) *fiber.App {
+	app := fiber.New()
+
+	authenticate := func(c *fiber.Ctx) error {
+		if userID, err := strconv.Atoi(c.Get(_testUserIDHeader)); err == nil {
+			c.Locals(UserIDKey, userID)
+		}
+
+		return c.Next()
+	}
+
+	app.Post("/", authenticate, IdempotencyMiddleware(repository, time.Hour), func(c *fiber.Ctx) error {
+		calls.Add(1)
+
+		return c.Status(status).JSON(fiber.Map{"call": calls.Load()})
+	})
+
+	return app
+}
+
+func createIdempotentRequest(key string, body string) *http.Request {
+	req := httptest.NewRequest(fiber.MethodPost, "/", strings.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+
+	if key != "" {
+		req.Header.Set(HeaderIdempotencyKey, key)
+	}
+
+	return req
+}
+
+// createIdempotentUpload creates a multipart request uploading the file, with a random boundary like the
+// one of every client.
+func createIdempotentUpload(t *testing.T, key string, file string) *http.Request {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	require.NoError(t, writer.WriteField("format", "csv"))
+
+	part, err := writer.CreateFormFile("file", "todos.csv")
+	require.NoError(t, err)
+
+	_, err = part.Write([]byte(file))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(fiber.MethodPost, "/", body)
+	req.Header.Set(fiber.HeaderContentType, writer.FormDataContentType())
+	req.Header.Set(HeaderIdempotencyKey, key)
+
+	return req
+}
+
+func readError(t *testing.T, resp *http.Response) string {
+	t.Helper()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var response struct {
+		Error string `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(body, &response))
+
+	return response.Error
+}
+
+func TestIdempotencyMiddleware_ReplaysStoredResponse(t *testing.T) {
+	// Given
+	calls := &atomic.Int32{}
+	app := createIdempotentServer(idempotency.NewMemoryRepository(), fiber.StatusCreated, calls)
+
+	first, err := app.Test(createIdempotentRequest("key", `{"title":"Lorem"}`))
+	require.NoError(t, err)
+
+	firstBody, err := io.ReadAll(first.Body)
+	require.NoError(t, err)
+
+	// When
+	resp, err := app.Test(createIdempotentRequest("key", `{"title":"Lorem"}`))
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	require.Equal(t, "true", resp.Header.Get(HeaderIdempotentReplayed))
+	require.Equal(t, fiber.MIMEApplicationJSON, resp.Header.Get(fiber.HeaderContentType))
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, firstBody, body)
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestIdempotencyMiddleware_ReplaysStoredResponseOfUpload(t *testing.T) {
+	// Given
+	calls := &atomic.Int32{}
+	app := createIdempotentServer(idempotency.NewMemoryRepository(), fiber.StatusCreated, calls)
+
+	_, err := app.Test(createIdempotentUpload(t, "key", "title\nLorem\n"))
+	require.NoError(t, err)
+
+	// When
+	resp, err := app.Test(createIdempotentUpload(t, "key", "title\nLorem\n"))
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	require.Equal(t, "true", resp.Header.Get(HeaderIdempotentReplayed))
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestIdempotencyMiddleware_WithoutKey(t *testing.T) {
+	// Given
+	calls := &atomic.Int32{}
+	app := createIdempotentServer(idempotency.NewMemoryRepository(), fiber.StatusCreated, calls)
+
+	// When
+	for range 2 {
+		resp, err := app.Test(createIdempotentRequest("", `{"title":"Lorem"}`))
+		require.NoError(t, err)
+		require.Empty(t, resp.Header.Get(HeaderIdempotentReplayed))
+	}
+
+	// Then
+	require.Equal(t, int32(2), calls.Load())
+}
+
+func TestIdempotencyMiddleware_KeysAreScopedByUser(t *testing.T) {
+	// Given
+	calls := &atomic.Int32{}
+	app := createIdempotentServer(idempotency.NewMemoryRepository(), fiber.StatusCreated, calls)
+
+	// When
+	for _, userID := range []string{"1", "2"} {
+		req := createIdempotentRequest("key", `{"title":"Lorem"}`)
+		req.Header.Set(_testUserIDHeader, userID)
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Empty(t, resp.Header.Get(HeaderIdempotentReplayed))
+	}
+
+	// Then
+	require.Equal(t, int32(2), calls.Load())
+}
+
+func TestIdempotencyMiddleware_ReplaysStoredResponseWithNewToken(t *testing.T) {
+	// Given
+	calls := &atomic.Int32{}
+	app := createIdempotentServer(idempotency.NewMemoryRepository(), fiber.StatusCreated, calls)
+
+	// When
+	var replayed string
+	for _, token := range []string{"Bearer first", "Bearer renewed"} {
+		req := createIdempotentRequest("key", `{"title":"Lorem"}`)
+		req.Header.Set(fiber.HeaderAuthorization, token)
+		req.Header.Set(_testUserIDHeader, "1")
+
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+
+		replayed = resp.Header.Get(HeaderIdempotentReplayed)
+	}
+
+	// Then
+	require.Equal(t, "true", replayed)
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestIdempotencyMiddleware_RetriesFailedRequests(t *testing.T) {
+	// Given
+	calls := &atomic.Int32{}
+	app := createIdempotentServer(idempotency.NewMemoryRepository(), fiber.StatusInternalServerError, calls)
+
+	// When
+	for range 2 {
+		resp, err := app.Test(createIdempotentRequest("key", `{"title":"Lorem"}`))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+	}
+
+	// Then
+	require.Equal(t, int32(2), calls.Load())
+}
+
+func TestIdempotencyMiddleware_FailsDueToDifferentPayload(t *testing.T) {
+	// Given
+	calls := &atomic.Int32{}
+	app := createIdempotentServer(idempotency.NewMemoryRepository(), fiber.StatusCreated, calls)
+
+	_, err := app.Test(createIdempotentRequest("key", `{"title":"Lorem"}`))
+	require.NoError(t, err)
+
+	// When
+	resp, err := app.Test(createIdempotentRequest("key", `{"title":"Ipsum"}`))
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+	require.Equal(t, apierrors.ErrIdempotencyKeyReused.Error(), readError(t, resp))
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestIdempotencyMiddleware_FailsDueToDifferentUpload(t *testing.T) {
+	// Given
+	calls := &atomic.Int32{}
+	app := createIdempotentServer(idempotency.NewMemoryRepository(), fiber.StatusCreated, calls)
+
+	_, err := app.Test(createIdempotentUpload(t, "key", "title\nLorem\n"))
+	require.NoError(t, err)
+
+	// When
+	resp, err := app.Test(createIdempotentUpload(t, "key", "title\nIpsum\n"))
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+	require.Equal(t, apierrors.ErrIdempotencyKeyReused.Error(), readError(t, resp))
+	require.Equal(t, int32(1), calls.Load())
+}
+
+func TestIdempotencyMiddleware_FailsDueToRequestInFlight(t *testing.T) {
+	// Given
+	repository := idempotency.NewMemoryRepository()
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+
+	app := fiber.New()
+	app.Post("/", IdempotencyMiddleware(repository, time.Hour), func(c *fiber.Ctx) error {
+		close(started)
+		<-finish
+
+		return c.SendStatus(fiber.StatusCreated)
+	})
+
+	firstDone := make(chan *http.Response)
+	go func() {
+		resp, _ := app.Test(createIdempotentRequest("key", `{"title":"Lorem"}`), -1)
+		firstDone <- resp
+	}()
+
+	<-started
+
+	// When
+	resp, err := app.Test(createIdempotentRequest("key", `{"title":"Lorem"}`))
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusConflict, resp.StatusCode)
+	require.Equal(t, apierrors.ErrIdempotencyKeyInFlight.Error(), readError(t, resp))
+
+	close(finish)
+
+	first := <-firstDone
+	require.NotNil(t, first)
+	require.Equal(t, fiber.StatusCreated, first.StatusCode)
+}
+
+func TestIdempotencyMiddleware_FailsDueToLongKey(t *testing.T) {
+	// Given
+	calls := &atomic.Int32{}
+	app := createIdempotentServer(idempotency.NewMemoryRepository(), fiber.StatusCreated, calls)
+
+	// When
+	resp, err := app.Test(createIdempotentRequest(strings.Repeat("k", 256), `{}`))
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	require.Equal(t, apierrors.ErrIdempotencyKeyTooLong.Error(), readError(t, resp))
+	require.Zero(t, calls.Load())
+}
+
+func TestIdempotencyMiddleware_FailsDueToRepositoryError(t *testing.T) {
+	// Given
+	calls := &atomic.Int32{}
+	app := createIdempotentServer(failingIdempotencyRepository{}, fiber.StatusCreated, calls)
+
+	// When
+	resp, err := app.Test(createIdempotentRequest("key", `{}`))
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+	require.Zero(t, calls.Load())
+}
@@ -50,6 +50,15 @@ func (j *JWTMiddleware) GetMiddleware() fiber.Handler {
 func (j *JWTMiddleware) FiberJWTMiddleware(secret string) fiber.Handler {
 	return jwtware.New(jwtware.Config{
 		SigningKey: jwtware.SigningKey{Key: []byte(secret)},
+		SuccessHandler: func(c *fiber.Ctx) error {
+			if token, ok := c.Locals("user").(*jwt.Token); ok {
+				if claims, ok := token.Claims.(jwt.MapClaims); ok {
+					storeUserID(c, claims)
+				}
+			}
+
+			return c.Next()
+		},
 	})
 }
 
@@ -77,11 +86,22 @@ func (j *JWTMiddleware) ApplicationJWTMiddleware(secret string) fiber.Handler {
 			return c.Status(fiber.StatusUnauthorized).SendString("Invalid or expired JWT")
 		}
 
-		err = j.sessionService.SetSession(j.ctx, headerToken, claims)
+		err = j.sessionService.SetSession(c.UserContext(), headerToken, claims)
 		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).SendString("Invalid or expired JWT")
 		}
 
+		storeUserID(c, claims)
+
 		return c.Next()
 	}
 }
+
+// storeUserID stores the subject of the verified claims in the locals, so the middlewares running before
+// the handler, like the idempotency one, know the authenticated user.
+func storeUserID(c *fiber.Ctx, claims jwt.MapClaims) {
+	// Claims are decoded from JSON, so the numeric subject is a float64.
+	if userID, ok := claims["sub"].(float64); ok {
+		c.Locals(UserIDKey, int(userID))
+	}
+}
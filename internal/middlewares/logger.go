@@ -0,0 +1,58 @@
+package middlewares
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/logging"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// UserIDKey is the key of the authenticated user ID stored in the Fiber locals.
+const UserIDKey = "user_id"
+
+// LoggerMiddleware stores a per-request logger, tagged with the request and trace IDs, in the locals
+// and in the user context of the request.
+func LoggerMiddleware(logger *zap.Logger) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		fields := []zap.Field{
+			zap.String("request_id", GetRequestID(c)),
+		}
+		fields = append(fields, TraceFields(c)...)
+
+		logging.SetRequestLogger(c, logger.With(fields...))
+
+		return c.Next()
+	}
+}
+
+// AccessLogFields returns the fields of the access log of the request. Headers are always redacted
+// and the body is only logged, redacted, for failed requests.
+func AccessLogFields(c *fiber.Ctx) []zap.Field {
+	fields := []zap.Field{
+		zap.String("request_id", GetRequestID(c)),
+		zap.String("route", c.Route().Name),
+	}
+
+	if userID, ok := c.Locals(UserIDKey).(int); ok {
+		fields = append(fields, zap.Int("user_id", userID))
+	}
+
+	fields = append(fields, TraceFields(c)...)
+
+	headers := make(map[string]string)
+	c.Request().Header.VisitAll(func(key, value []byte) {
+		if logging.IsSensitive(string(key)) {
+			headers[string(key)] = logging.Redacted
+			return
+		}
+
+		headers[string(key)] = string(value)
+	})
+
+	fields = append(fields, zap.Any("headers", headers))
+
+	if c.Response().StatusCode() >= fiber.StatusBadRequest {
+		fields = append(fields, zap.ByteString("body", logging.RedactJSON(c.Body())))
+	}
+
+	return fields
+}
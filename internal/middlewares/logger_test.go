@@ -0,0 +1,106 @@
+package middlewares
+
+import (
+	"bytes"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/logging"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoggerMiddleware_SuccessfulStoresRequestLogger(t *testing.T) {
+	// Given
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	app := fiber.New()
+	app.Use(RequestIDMiddleware())
+	app.Use(LoggerMiddleware(zap.New(core)))
+	app.Get("/", func(c *fiber.Ctx) error {
+		logging.FromContext(c.UserContext()).Info("from service")
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "abc")
+
+	// When
+	resp, err := app.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, 1, logs.Len())
+	require.Equal(t, map[string]any{"request_id": "abc"}, logs.All()[0].ContextMap())
+}
+
+func TestAccessLogFields_SuccessfulRedactsHeadersAndBody(t *testing.T) {
+	// Given
+	var fields []zap.Field
+
+	app := fiber.New()
+	app.Use(RequestIDMiddleware())
+	app.Use(func(c *fiber.Ctx) error {
+		err := c.Next()
+		fields = AccessLogFields(c)
+
+		return err
+	})
+	app.Post("/users/register", func(c *fiber.Ctx) error {
+		c.Locals(UserIDKey, 1)
+		return c.SendStatus(fiber.StatusUnprocessableEntity)
+	}).Name("users.register")
+
+	body := `{"email":"john@example.com","password":"12345678"}`
+	req := httptest.NewRequest(fiber.MethodPost, "/users/register", bytes.NewBufferString(body))
+	req.Header.Set(fiber.HeaderXRequestID, "abc")
+	req.Header.Set(fiber.HeaderAuthorization, "Bearer secret-token")
+
+	// When
+	_, err := app.Test(req)
+
+	// Then
+	require.NoError(t, err)
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	zap.New(core).Info("access", fields...)
+	logged := logs.All()[0].ContextMap()
+
+	require.Equal(t, "abc", logged["request_id"])
+	require.Equal(t, "users.register", logged["route"])
+	require.Equal(t, int64(1), logged["user_id"])
+	require.Equal(t, logging.Redacted, logged["headers"].(map[string]string)["Authorization"])
+	require.JSONEq(t, `{"email":"john@example.com","password":"[REDACTED]"}`, logged["body"].(string))
+}
+
+func TestAccessLogFields_SuccessfulSkipsBodyOfSuccessfulRequests(t *testing.T) {
+	// Given
+	var fields []zap.Field
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		err := c.Next()
+		fields = AccessLogFields(c)
+
+		return err
+	})
+	app.Post("/todos", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusCreated)
+	}).Name("todos.save")
+
+	req := httptest.NewRequest(fiber.MethodPost, "/todos", bytes.NewBufferString(`{"title":"Lorem"}`))
+
+	// When
+	_, err := app.Test(req)
+
+	// Then
+	require.NoError(t, err)
+
+	for _, field := range fields {
+		require.NotEqual(t, "body", field.Key)
+		require.NotEqual(t, "user_id", field.Key)
+	}
+}
@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"regexp"
+)
+
+// RequestIDKey is the key of the request ID stored in the Fiber locals.
+const RequestIDKey = "request_id"
+
+// _requestIDRegex only accepts short IDs that are safe to write in logs and headers.
+var _requestIDRegex = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestIDMiddleware accepts the X-Request-ID header of the client, or generates a new one when it
+// is missing or invalid, and echoes it back in the response.
+func RequestIDMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID := c.Get(fiber.HeaderXRequestID)
+		if !_requestIDRegex.MatchString(requestID) {
+			requestID = utils.UUIDv4()
+		}
+
+		c.Locals(RequestIDKey, requestID)
+		c.Set(fiber.HeaderXRequestID, requestID)
+
+		return c.Next()
+	}
+}
+
+// GetRequestID returns the ID of the request set by RequestIDMiddleware.
+func GetRequestID(c *fiber.Ctx) string {
+	requestID, _ := c.Locals(RequestIDKey).(string)
+
+	return requestID
+}
@@ -0,0 +1,75 @@
+package middlewares
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func createRequestIDServer(requestID *string) *fiber.App {
+	app := fiber.New()
+
+	app.Use(RequestIDMiddleware())
+
+	app.Get("/", func(c *fiber.Ctx) error {
+		*requestID = GetRequestID(c)
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	return app
+}
+
+func TestRequestIDMiddleware_SuccessfulAcceptsClientID(t *testing.T) {
+	// Given
+	var requestID string
+	server := createRequestIDServer(&requestID)
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "client-id_1.2:3")
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "client-id_1.2:3", resp.Header.Get(fiber.HeaderXRequestID))
+	require.Equal(t, "client-id_1.2:3", requestID)
+}
+
+func TestRequestIDMiddleware_SuccessfulGeneratesMissingID(t *testing.T) {
+	// Given
+	var requestID string
+	server := createRequestIDServer(&requestID)
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Len(t, requestID, 36) // UUID.
+	require.Equal(t, requestID, resp.Header.Get(fiber.HeaderXRequestID))
+}
+
+func TestRequestIDMiddleware_SuccessfulReplacesInvalidID(t *testing.T) {
+	for _, invalidID := range []string{"id with spaces", "id\"injection", strings.Repeat("a", 129)} {
+		// Given
+		var requestID string
+		server := createRequestIDServer(&requestID)
+
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		req.Header.Set(fiber.HeaderXRequestID, invalidID)
+
+		// When
+		resp, err := server.Test(req)
+
+		// Then
+		require.NoError(t, err)
+		require.NotEqual(t, invalidID, requestID)
+		require.Len(t, requestID, 36)
+		require.Equal(t, requestID, resp.Header.Get(fiber.HeaderXRequestID))
+	}
+}
@@ -0,0 +1,93 @@
+package middlewares
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"strings"
+)
+
+const _tracingInstrumentationName = "github.com/ferch5003/go-fiber-tutorial/internal/middlewares"
+
+type TracingMiddleware struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+func NewTracingMiddleware(tp trace.TracerProvider) *TracingMiddleware {
+	return &TracingMiddleware{
+		tracer:     tp.Tracer(_tracingInstrumentationName),
+		propagator: propagation.TraceContext{},
+	}
+}
+
+// GetMiddleware creates a server span per request, honoring the W3C traceparent header, and stores it
+// on the user context so handlers can propagate it with c.UserContext().
+func (t *TracingMiddleware) GetMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		carrier := propagation.MapCarrier{}
+		c.Request().Header.VisitAll(func(key, value []byte) {
+			carrier.Set(strings.ToLower(string(key)), string(value))
+		})
+
+		ctx := t.propagator.Extract(c.UserContext(), carrier)
+		ctx, span := t.tracer.Start(ctx, fmt.Sprintf("%s %s", c.Method(), c.Path()),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String(string(semconv.HTTPRequestMethodKey), c.Method()),
+				semconv.URLPath(c.Path()),
+			),
+		)
+		defer span.End()
+
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			span.RecordError(err)
+
+			if fiberErr, ok := err.(*fiber.Error); ok {
+				status = fiberErr.Code
+			} else {
+				status = fiber.StatusInternalServerError
+			}
+		}
+
+		if route := c.Route(); route != nil {
+			span.SetAttributes(semconv.HTTPRoute(route.Path))
+
+			if route.Name != "" {
+				span.SetName(route.Name)
+			} else {
+				span.SetName(fmt.Sprintf("%s %s", c.Method(), route.Path))
+			}
+		}
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, fmt.Sprintf("status code %d", status))
+		}
+
+		return err
+	}
+}
+
+// TraceFields returns the trace and span IDs of the request so they can be attached to its logs.
+func TraceFields(c *fiber.Ctx) []zap.Field {
+	spanContext := trace.SpanContextFromContext(c.UserContext())
+	if !spanContext.IsValid() {
+		return nil
+	}
+
+	return []zap.Field{
+		zap.String("trace_id", spanContext.TraceID().String()),
+		zap.String("span_id", spanContext.SpanID().String()),
+	}
+}
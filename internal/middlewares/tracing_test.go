@@ -0,0 +1,143 @@
+package middlewares
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"net/http/httptest"
+	"testing"
+)
+
+const (
+	_testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	_testParentID    = "00f067aa0ba902b7"
+	_testTraceParent = "00-" + _testTraceID + "-" + _testParentID + "-01"
+)
+
+func createTracingServer(tp trace.TracerProvider, fields *[]zap.Field) *fiber.App {
+	app := fiber.New()
+
+	app.Use(func(c *fiber.Ctx) error {
+		err := c.Next()
+		*fields = TraceFields(c)
+
+		return err
+	})
+
+	app.Use(NewTracingMiddleware(tp).GetMiddleware())
+
+	app.Route("/todos", func(api fiber.Router) {
+		api.Get("/:id<int>", func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusOK)
+		}).Name("get")
+		api.Delete("/:id<int>", func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusInternalServerError)
+		}).Name("delete")
+	}, "todos.")
+
+	return app
+}
+
+func TestTracingMiddleware_SuccessfulCreatesServerSpan(t *testing.T) {
+	// Given
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	fields := make([]zap.Field, 0)
+	server := createTracingServer(tp, &fields)
+
+	req := httptest.NewRequest(fiber.MethodGet, "/todos/1", nil)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "todos.get", spans[0].Name())
+	require.Equal(t, trace.SpanKindServer, spans[0].SpanKind())
+	require.Contains(t, spans[0].Attributes(), semconv.HTTPRoute("/todos/:id<int>"))
+	require.Contains(t, spans[0].Attributes(), semconv.HTTPResponseStatusCode(fiber.StatusOK))
+	require.False(t, spans[0].Parent().IsValid())
+
+	require.Len(t, fields, 2)
+	require.Equal(t, spans[0].SpanContext().TraceID().String(), fields[0].String)
+}
+
+func TestTracingMiddleware_SuccessfulHonorsTraceParent(t *testing.T) {
+	// Given
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	fields := make([]zap.Field, 0)
+	server := createTracingServer(tp, &fields)
+
+	req := httptest.NewRequest(fiber.MethodGet, "/todos/1", nil)
+	req.Header.Set("traceparent", _testTraceParent)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, _testTraceID, spans[0].SpanContext().TraceID().String())
+	require.Equal(t, _testParentID, spans[0].Parent().SpanID().String())
+	require.True(t, spans[0].Parent().IsRemote())
+
+	require.Equal(t, zap.String("trace_id", _testTraceID), fields[0])
+}
+
+func TestTracingMiddleware_FailsMarkingServerErrors(t *testing.T) {
+	// Given
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	fields := make([]zap.Field, 0)
+	server := createTracingServer(tp, &fields)
+
+	req := httptest.NewRequest(fiber.MethodDelete, "/todos/1", nil)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "todos.delete", spans[0].Name())
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+}
+
+func TestTraceFields_SuccessfulEmptyWithoutSpan(t *testing.T) {
+	// Given
+	app := fiber.New()
+
+	var fields []zap.Field
+	app.Get("/", func(c *fiber.Ctx) error {
+		fields = TraceFields(c)
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+
+	// When
+	_, err := app.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Empty(t, fields)
+}
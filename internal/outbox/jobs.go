@@ -0,0 +1,48 @@
+package outbox
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/jobs"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/logging"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	// _purgePublishedSpec purges the published messages every day at 04:00 UTC.
+	_purgePublishedSpec = "0 4 * * *"
+
+	// _publishedRetentionDays is how long the published messages are kept, to investigate their consumers.
+	_publishedRetentionDays = 7
+)
+
+// PurgePublished deletes the messages published before the retention.
+type PurgePublished struct {
+	RetentionDays int `json:"retention_days"`
+}
+
+var PurgePublishedJob = jobs.Type[PurgePublished]("outbox.purge_published")
+
+// NewPurgePublishedHandler creates the handler of the PurgePublishedJob.
+func NewPurgePublishedHandler(repository Repository) jobs.Handler {
+	return PurgePublishedJob.Handler(func(ctx context.Context, payload PurgePublished) error {
+		before := time.Now().UTC().AddDate(0, 0, -payload.RetentionDays)
+
+		deleted, err := repository.DeletePublishedBefore(ctx, before)
+		if err != nil {
+			return err
+		}
+
+		logging.FromContext(ctx).Info("Purged outbox messages", zap.Int("deleted", deleted))
+
+		return nil
+	})
+}
+
+// NewPurgePublishedSchedule creates the Schedule purging the messages published more than 7 days ago
+// every day.
+func NewPurgePublishedSchedule() (jobs.Schedule, error) {
+	return PurgePublishedJob.Schedule(_purgePublishedSpec, PurgePublished{
+		RetentionDays: _publishedRetentionDays,
+	})
+}
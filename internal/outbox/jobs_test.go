@@ -0,0 +1,79 @@
+package outbox_test
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/jobs"
+	"github.com/stretchr/testify/require"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// purgeRecorder records how many messages its repository purged.
+type purgeRecorder struct {
+	outbox.Repository
+	deleted atomic.Int64
+}
+
+func (r *purgeRecorder) DeletePublishedBefore(ctx context.Context, before time.Time) (int, error) {
+	deleted, err := r.Repository.DeletePublishedBefore(ctx, before)
+	r.deleted.Add(int64(deleted))
+
+	return deleted, err
+}
+
+func TestPurgePublishedHandler_SuccessfulDeletesOldMessages(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	repository := outbox.NewMemoryRepository()
+	appendMessages(t, repository, 1, 2, 3)
+
+	messages, err := repository.Pending(ctx, 10)
+	require.NoError(t, err)
+
+	now := time.Now().UTC()
+	require.NoError(t, repository.MarkPublished(ctx, []int{messages[0].ID}, now.AddDate(0, 0, -8)))
+	require.NoError(t, repository.MarkPublished(ctx, []int{messages[1].ID}, now.Add(-time.Hour)))
+
+	recorder := &purgeRecorder{Repository: repository}
+	queue := jobs.NewMemoryQueue()
+
+	worker, err := jobs.NewWorker(queue, jobs.WorkerConfig{}, []jobs.Handler{
+		outbox.NewPurgePublishedHandler(recorder),
+	}, nil)
+	require.NoError(t, err)
+	require.NoError(t, worker.Start(ctx))
+
+	t.Cleanup(func() {
+		_ = worker.Stop(context.Background())
+	})
+
+	// When
+	err = outbox.PurgePublishedJob.Enqueue(ctx, queue, outbox.PurgePublished{RetentionDays: 7})
+
+	// Then
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return recorder.deleted.Load() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The recently published message and the pending one are kept.
+	pending, err := repository.Pending(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+
+	deleted, err := repository.DeletePublishedBefore(ctx, now)
+	require.NoError(t, err)
+	require.Equal(t, 1, deleted)
+}
+
+func TestNewPurgePublishedSchedule_Successful(t *testing.T) {
+	// When
+	schedule, err := outbox.NewPurgePublishedSchedule()
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "outbox.purge_published 0 4 * * *", schedule.Name)
+}
@@ -0,0 +1,62 @@
+package outbox
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// _holdScript acquires the lock when it is free, or extends it when it is held with the token.
+var _holdScript = redis.NewScript(`
+local holder = redis.call('GET', KEYS[1])
+if holder == false then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+	return 1
+end
+
+if holder == ARGV[1] then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+	return 1
+end
+
+return 0
+`)
+
+// Lock keeps a single Relay publishing at a time, so two instances never publish the messages of an
+// aggregate out of order.
+type Lock interface {
+	// Hold acquires the lock, or extends it when this Lock already holds it, for the ttl. It reports
+	// whether it is held.
+	Hold(ctx context.Context, ttl time.Duration) (bool, error)
+}
+
+type localLock struct{}
+
+// NewLocalLock creates a Lock that is always held, for a single instance.
+func NewLocalLock() Lock {
+	return localLock{}
+}
+
+func (localLock) Hold(context.Context, time.Duration) (bool, error) {
+	return true, nil
+}
+
+type redisLock struct {
+	client *redis.Client
+	key    string
+	token  string
+}
+
+// NewRedisLock creates a Lock shared by the instances through the Redis key.
+func NewRedisLock(client *redis.Client, key string) Lock {
+	return &redisLock{
+		client: client,
+		key:    key,
+		token:  utils.UUIDv4(),
+	}
+}
+
+func (l *redisLock) Hold(ctx context.Context, ttl time.Duration) (bool, error) {
+	return _holdScript.Run(ctx, l.client, []string{l.key}, l.token, ttl.Milliseconds()).Bool()
+}
@@ -0,0 +1,112 @@
+package outbox
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"slices"
+	"sync"
+	"time"
+)
+
+type memoryRepository struct {
+	mutex    *sync.RWMutex
+	lastID   int
+	messages []domain.OutboxMessage // Sorted by ID.
+}
+
+// NewMemoryRepository creates a thread-safe Repository that keeps messages in memory. It behaves like the
+// SQL Repository, so it can replace it in development and tests.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{
+		mutex:    &sync.RWMutex{},
+		messages: make([]domain.OutboxMessage, 0),
+	}
+}
+
+func (r *memoryRepository) Append(_ context.Context, messages ...domain.OutboxMessage) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for _, message := range messages {
+		r.lastID++
+		message.ID = r.lastID
+		message.Payload = slices.Clone(message.Payload)
+
+		r.messages = append(r.messages, message)
+	}
+
+	return nil
+}
+
+func (r *memoryRepository) Pending(_ context.Context, limit int) ([]domain.OutboxMessage, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	messages := make([]domain.OutboxMessage, 0)
+
+	for _, message := range r.messages {
+		if len(messages) == limit {
+			break
+		}
+
+		if message.PublishedAt == nil && message.DeadLetteredAt == nil {
+			messages = append(messages, message)
+		}
+	}
+
+	return messages, nil
+}
+
+func (r *memoryRepository) MarkPublished(_ context.Context, ids []int, at time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	at = at.UTC()
+
+	for i, message := range r.messages {
+		if slices.Contains(ids, message.ID) {
+			r.messages[i].PublishedAt = &at
+		}
+	}
+
+	return nil
+}
+
+func (r *memoryRepository) MarkFailed(_ context.Context, id int, deadLetter bool, at time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	at = at.UTC()
+
+	for i, message := range r.messages {
+		if message.ID != id {
+			continue
+		}
+
+		r.messages[i].Attempts++
+
+		if deadLetter {
+			r.messages[i].DeadLetteredAt = &at
+		}
+	}
+
+	return nil
+}
+
+func (r *memoryRepository) DeletePublishedBefore(_ context.Context, before time.Time) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	kept := r.messages[:0]
+
+	for _, message := range r.messages {
+		if message.PublishedAt == nil || !message.PublishedAt.Before(before) {
+			kept = append(kept, message)
+		}
+	}
+
+	deleted := len(r.messages) - len(kept)
+	r.messages = kept
+
+	return deleted, nil
+}
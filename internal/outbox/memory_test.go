@@ -0,0 +1,13 @@
+package outbox_test
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox"
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox/outboxtest"
+	"testing"
+)
+
+func TestMemoryRepository_Conformance(t *testing.T) {
+	outboxtest.RunRepositoryTests(t, func(t *testing.T) outbox.Repository {
+		return outbox.NewMemoryRepository()
+	})
+}
@@ -0,0 +1,30 @@
+// Package outbox publishes the domain events reliably. Repositories write the event of every change in
+// its own transaction, so an event exists if and only if its change does, and a Relay publishes the
+// written events to the sinks afterward: at least once, and in order for every aggregate.
+package outbox
+
+import (
+	"encoding/json"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"time"
+)
+
+// NewMessage returns the message of the event on the aggregate, whose payload is the JSON of subject.
+func NewMessage(
+	aggregateType string,
+	aggregateID int,
+	eventType string,
+	subject any) (domain.OutboxMessage, error) {
+	payload, err := json.Marshal(subject)
+	if err != nil {
+		return domain.OutboxMessage{}, err
+	}
+
+	return domain.OutboxMessage{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       payload,
+		CreatedAt:     time.Now().UTC().Truncate(time.Second),
+	}, nil
+}
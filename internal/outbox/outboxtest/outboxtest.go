@@ -0,0 +1,210 @@
+// Package outboxtest provides a conformance suite that every outbox.Repository implementation must pass.
+package outboxtest
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// _createdAt is when the first message of the suite was created, the next ones are created a minute apart.
+var _createdAt = time.Date(2024, time.October, 1, 9, 0, 0, 0, time.UTC)
+
+// RepositoryFactory creates an empty repository for every test.
+type RepositoryFactory func(t *testing.T) outbox.Repository
+
+// RunRepositoryTests runs the conformance suite against the repositories created by newRepository.
+func RunRepositoryTests(t *testing.T, newRepository RepositoryFactory) {
+	t.Run("AppendAndPending", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		message := newMessage(domain.OutboxTodo, 1, domain.AuditTodoCreated, 0)
+
+		// When
+		err := repository.Append(ctx, message)
+		require.NoError(t, err)
+
+		messages, err := repository.Pending(ctx, 10)
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+
+		message.ID = messages[0].ID
+		require.Positive(t, message.ID)
+		require.True(t, message.CreatedAt.Equal(messages[0].CreatedAt))
+
+		message.CreatedAt = messages[0].CreatedAt
+		require.Equal(t, message, messages[0])
+	})
+
+	t.Run("PendingInOrderUpToTheLimit", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		appendMessages(t, repository, 3)
+
+		// When
+		messages, err := repository.Pending(ctx, 2)
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, messages, 2)
+		require.Equal(t, 1, messages[0].AggregateID)
+		require.Equal(t, 2, messages[1].AggregateID)
+		require.Less(t, messages[0].ID, messages[1].ID)
+	})
+
+	t.Run("MarkPublishedExcludesThemFromPending", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		appendMessages(t, repository, 3)
+
+		messages, err := repository.Pending(ctx, 10)
+		require.NoError(t, err)
+
+		// When
+		err = repository.MarkPublished(ctx, []int{messages[0].ID, messages[2].ID}, _createdAt.Add(time.Hour))
+		require.NoError(t, err)
+
+		pending, err := repository.Pending(ctx, 10)
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		require.Equal(t, messages[1].ID, pending[0].ID)
+	})
+
+	t.Run("MarkPublishedWithoutIDs", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		appendMessages(t, repository, 1)
+
+		// When
+		err := repository.MarkPublished(ctx, nil, _createdAt)
+		require.NoError(t, err)
+
+		pending, err := repository.Pending(ctx, 10)
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+	})
+
+	t.Run("MarkFailedCountsAttempts", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		appendMessages(t, repository, 2)
+
+		messages, err := repository.Pending(ctx, 10)
+		require.NoError(t, err)
+
+		// When
+		for range 2 {
+			err = repository.MarkFailed(ctx, messages[0].ID, false, _createdAt)
+			require.NoError(t, err)
+		}
+
+		pending, err := repository.Pending(ctx, 10)
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, pending, 2)
+		require.Equal(t, 2, pending[0].Attempts)
+		require.Nil(t, pending[0].DeadLetteredAt)
+		require.Zero(t, pending[1].Attempts)
+	})
+
+	t.Run("MarkFailedDeadLettersExcludesThemFromPending", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		appendMessages(t, repository, 2)
+
+		messages, err := repository.Pending(ctx, 10)
+		require.NoError(t, err)
+
+		// When
+		err = repository.MarkFailed(ctx, messages[0].ID, true, _createdAt)
+		require.NoError(t, err)
+
+		pending, err := repository.Pending(ctx, 10)
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		require.Equal(t, messages[1].ID, pending[0].ID)
+
+		// Dead-lettered messages are not published, so they are not purged either.
+		deleted, err := repository.DeletePublishedBefore(ctx, _createdAt.Add(time.Hour))
+		require.NoError(t, err)
+		require.Zero(t, deleted)
+	})
+
+	t.Run("DeletePublishedBefore", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		appendMessages(t, repository, 3)
+
+		messages, err := repository.Pending(ctx, 10)
+		require.NoError(t, err)
+
+		err = repository.MarkPublished(ctx, []int{messages[0].ID}, _createdAt)
+		require.NoError(t, err)
+
+		err = repository.MarkPublished(ctx, []int{messages[1].ID}, _createdAt.Add(2*time.Hour))
+		require.NoError(t, err)
+
+		// When
+		deleted, err := repository.DeletePublishedBefore(ctx, _createdAt.Add(time.Hour))
+		require.NoError(t, err)
+
+		pending, err := repository.Pending(ctx, 10)
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, 1, deleted)
+		require.Len(t, pending, 1)
+		require.Equal(t, messages[2].ID, pending[0].ID)
+
+		deleted, err = repository.DeletePublishedBefore(ctx, _createdAt.Add(3*time.Hour))
+		require.NoError(t, err)
+		require.Equal(t, 1, deleted)
+	})
+}
+
+// appendMessages appends count messages of the todos 1 to count.
+func appendMessages(t *testing.T, repository outbox.Repository, count int) {
+	t.Helper()
+
+	for i := range count {
+		message := newMessage(domain.OutboxTodo, i+1, domain.AuditTodoCreated, i)
+		require.NoError(t, repository.Append(context.Background(), message))
+	}
+}
+
+// newMessage returns the message of the event on the aggregate, created minutes after _createdAt.
+func newMessage(aggregateType string, aggregateID int, eventType string, minutes int) domain.OutboxMessage {
+	return domain.OutboxMessage{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		EventType:     eventType,
+		Payload:       domain.OutboxPayload(`{"id":1}`),
+		CreatedAt:     _createdAt.Add(time.Duration(minutes) * time.Minute),
+	}
+}
@@ -0,0 +1,227 @@
+package outbox
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/logging"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+const (
+	_defaultInterval  = time.Second
+	_defaultBatchSize = 100
+	_defaultTimeout   = 10 * time.Second
+	_defaultLockTTL   = 30 * time.Second
+
+	_defaultMaxAttempts = 30
+)
+
+// RelayConfig tunes the Relay, zero fields take their default.
+type RelayConfig struct {
+	Interval  time.Duration // Between the polls of the pending messages, defaults to 1s.
+	BatchSize int           // Messages published by every poll, defaults to 100.
+	Timeout   time.Duration // Of publishing a message to a sink, defaults to 10s.
+	LockTTL   time.Duration // Of the Lock, held while publishing every batch, defaults to 30s.
+
+	// MaxAttempts of publishing a message before it is dead-lettered, once per poll, defaults to 30.
+	MaxAttempts int
+}
+
+// aggregate identifies the aggregate of a message.
+type aggregate struct {
+	aggregateType string
+	aggregateID   int
+}
+
+// Relay publishes the pending messages to every sink, in order, and marks them as published. A message
+// that fails is published again by the next poll, holding the next messages of its aggregate until it
+// succeeds: sinks receive every message at least once, and the ones of an aggregate in order. A message
+// failing MaxAttempts times is dead-lettered instead, releasing its aggregate, so a message that can never
+// be published does not hold it, nor fill the batches, forever.
+type Relay struct {
+	repository Repository
+	sinks      []Sink
+	lock       Lock
+	config     RelayConfig
+
+	mutex  sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewRelay(repository Repository, sinks []Sink, lock Lock, config RelayConfig) *Relay {
+	config.Interval = cmp.Or(config.Interval, _defaultInterval)
+	config.BatchSize = cmp.Or(config.BatchSize, _defaultBatchSize)
+	config.Timeout = cmp.Or(config.Timeout, _defaultTimeout)
+	config.LockTTL = cmp.Or(config.LockTTL, _defaultLockTTL)
+	config.MaxAttempts = cmp.Or(config.MaxAttempts, _defaultMaxAttempts)
+
+	return &Relay{
+		repository: repository,
+		sinks:      sinks,
+		lock:       lock,
+		config:     config,
+	}
+}
+
+// Start polling the pending messages in the background.
+func (r *Relay) Start(_ context.Context) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.cancel != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	r.done = make(chan struct{})
+
+	go r.run(ctx, r.done)
+
+	return nil
+}
+
+// Stop polling and wait until the running poll ends, or ctx is done. Messages whose publishing is
+// interrupted stay pending, they are published by the next relay.
+func (r *Relay) Stop(ctx context.Context) error {
+	r.mutex.Lock()
+	cancel, done := r.cancel, r.done
+	r.mutex.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+
+	cancel()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run polls the pending messages until ctx is done, right away again while full batches are published.
+func (r *Relay) run(ctx context.Context, done chan<- struct{}) {
+	defer close(done)
+
+	for {
+		published, err := r.PublishPending(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			logging.FromContext(ctx).Warn("Error publishing outbox messages", zap.Error(err))
+		}
+
+		if err == nil && published == r.config.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.config.Interval):
+		}
+	}
+}
+
+// PublishPending publishes a batch of the pending messages when the Lock is held, returning how many
+// were published.
+func (r *Relay) PublishPending(ctx context.Context) (int, error) {
+	held, err := r.lock.Hold(ctx, r.config.LockTTL)
+	if err != nil || !held {
+		return 0, err
+	}
+
+	// Stopping halfway through the lock leaves time to mark the published messages before it is lost.
+	deadline := time.Now().Add(r.config.LockTTL / 2)
+
+	messages, err := r.repository.Pending(ctx, r.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	published := make([]int, 0, len(messages))
+	failed := make(map[aggregate]struct{})
+
+	var errs error
+
+	for _, message := range messages {
+		if ctx.Err() != nil || time.Now().After(deadline) {
+			break
+		}
+
+		key := aggregate{aggregateType: message.AggregateType, aggregateID: message.AggregateID}
+		if _, ok := failed[key]; ok {
+			continue
+		}
+
+		if err := r.publish(ctx, message); err != nil {
+			failed[key] = struct{}{}
+			errs = errors.Join(errs, fmt.Errorf("publishing outbox message %d: %w", message.ID, err))
+
+			// An interrupted attempt does not count.
+			if ctx.Err() != nil {
+				break
+			}
+
+			if err := r.markFailed(ctx, message); err != nil {
+				errs = errors.Join(errs, err)
+			}
+
+			continue
+		}
+
+		published = append(published, message.ID)
+	}
+
+	// Marking them even when ctx is done keeps them from being published again.
+	if err := r.repository.MarkPublished(context.WithoutCancel(ctx), published, time.Now()); err != nil {
+		return 0, err
+	}
+
+	return len(published), errs
+}
+
+// markFailed records the failed attempt of the message, dead-lettering it after MaxAttempts of them.
+func (r *Relay) markFailed(ctx context.Context, message domain.OutboxMessage) error {
+	deadLetter := message.Attempts+1 >= r.config.MaxAttempts
+
+	if err := r.repository.MarkFailed(ctx, message.ID, deadLetter, time.Now()); err != nil {
+		return fmt.Errorf("marking outbox message %d as failed: %w", message.ID, err)
+	}
+
+	if deadLetter {
+		logging.FromContext(ctx).Error("Dead-lettered outbox message",
+			zap.Int("id", message.ID),
+			zap.String("aggregate_type", message.AggregateType),
+			zap.Int("aggregate_id", message.AggregateID),
+			zap.Int("attempts", message.Attempts+1))
+	}
+
+	return nil
+}
+
+// publish the message to every sink.
+func (r *Relay) publish(ctx context.Context, message domain.OutboxMessage) error {
+	for _, sink := range r.sinks {
+		publishCtx, cancel := context.WithTimeout(ctx, r.config.Timeout)
+		err := sink.Publish(publishCtx, message)
+		cancel()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,249 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+var errUnavailable = errors.New("unavailable")
+
+// recordingSink records the published messages, failing the ones of its failing aggregates.
+type recordingSink struct {
+	mutex     sync.Mutex
+	published []domain.OutboxMessage
+	failing   map[int]bool
+}
+
+func (s *recordingSink) Publish(_ context.Context, message domain.OutboxMessage) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.failing[message.AggregateID] {
+		return errUnavailable
+	}
+
+	s.published = append(s.published, message)
+
+	return nil
+}
+
+func (s *recordingSink) aggregateIDs() []int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ids := make([]int, 0, len(s.published))
+	for _, message := range s.published {
+		ids = append(ids, message.AggregateID)
+	}
+
+	return ids
+}
+
+func (s *recordingSink) fail(aggregateID int, failing bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.failing[aggregateID] = failing
+}
+
+// heldLock is a Lock that is held when held is true.
+type heldLock bool
+
+func (l heldLock) Hold(_ context.Context, _ time.Duration) (bool, error) {
+	return bool(l), nil
+}
+
+// appendMessages appends a message of every aggregate ID, in order.
+func appendMessages(t *testing.T, repository outbox.Repository, aggregateIDs ...int) {
+	t.Helper()
+
+	for _, aggregateID := range aggregateIDs {
+		message, err := outbox.NewMessage(domain.OutboxTodo, aggregateID, domain.AuditTodoUpdated, map[string]int{
+			"id": aggregateID,
+		})
+		require.NoError(t, err)
+		require.NoError(t, repository.Append(context.Background(), message))
+	}
+}
+
+func TestRelay_PublishPending_SuccessfulPublishesAndMarksMessages(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	repository := outbox.NewMemoryRepository()
+	appendMessages(t, repository, 1, 2, 1)
+
+	sink := &recordingSink{failing: map[int]bool{}}
+	relay := outbox.NewRelay(repository, []outbox.Sink{sink}, outbox.NewLocalLock(), outbox.RelayConfig{})
+
+	// When
+	published, err := relay.PublishPending(ctx)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, 3, published)
+	require.Equal(t, []int{1, 2, 1}, sink.aggregateIDs())
+
+	pending, err := repository.Pending(ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
+
+func TestRelay_PublishPending_SuccessfulPublishesInBatches(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	repository := outbox.NewMemoryRepository()
+	appendMessages(t, repository, 1, 2, 3)
+
+	sink := &recordingSink{failing: map[int]bool{}}
+	relay := outbox.NewRelay(repository, []outbox.Sink{sink}, outbox.NewLocalLock(), outbox.RelayConfig{
+		BatchSize: 2,
+	})
+
+	// When
+	published, err := relay.PublishPending(ctx)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, 2, published)
+	require.Equal(t, []int{1, 2}, sink.aggregateIDs())
+}
+
+func TestRelay_PublishPending_FailsDueToSinkHoldingTheAggregate(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	repository := outbox.NewMemoryRepository()
+	appendMessages(t, repository, 1, 2, 1, 2)
+
+	sink := &recordingSink{failing: map[int]bool{1: true}}
+	relay := outbox.NewRelay(repository, []outbox.Sink{sink}, outbox.NewLocalLock(), outbox.RelayConfig{})
+
+	// When
+	published, err := relay.PublishPending(ctx)
+
+	// Then
+	require.ErrorIs(t, err, errUnavailable)
+	require.Equal(t, 2, published)
+	require.Equal(t, []int{2, 2}, sink.aggregateIDs())
+
+	pending, err := repository.Pending(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+
+	// The messages of the aggregate are published in order once the sink recovers.
+	sink.fail(1, false)
+
+	published, err = relay.PublishPending(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, published)
+	require.Equal(t, []int{2, 2, 1, 1}, sink.aggregateIDs())
+}
+
+func TestRelay_PublishPending_SuccessfulDeadLettersPoisonMessage(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	repository := outbox.NewMemoryRepository()
+	appendMessages(t, repository, 1, 2, 3, 4, 5, 1)
+
+	sink := &recordingSink{failing: map[int]bool{1: true}}
+	relay := outbox.NewRelay(repository, []outbox.Sink{sink}, outbox.NewLocalLock(), outbox.RelayConfig{
+		BatchSize:   3,
+		MaxAttempts: 2,
+	})
+
+	// When
+	_, err := relay.PublishPending(ctx)
+	require.ErrorIs(t, err, errUnavailable)
+
+	_, err = relay.PublishPending(ctx)
+	require.ErrorIs(t, err, errUnavailable)
+
+	// The poison message is dead-lettered, the other messages of its aggregate are published again.
+	sink.fail(1, false)
+
+	_, err = relay.PublishPending(ctx)
+	require.NoError(t, err)
+
+	// Then
+	require.Equal(t, []int{2, 3, 4, 5, 1}, sink.aggregateIDs())
+
+	pending, err := repository.Pending(ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
+
+func TestRelay_PublishPending_SuccessfulWithBatchesOfPoisonMessages(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	repository := outbox.NewMemoryRepository()
+	appendMessages(t, repository, 1, 1, 1, 2, 3, 4)
+
+	sink := &recordingSink{failing: map[int]bool{1: true}}
+	relay := outbox.NewRelay(repository, []outbox.Sink{sink}, outbox.NewLocalLock(), outbox.RelayConfig{
+		BatchSize:   3,
+		MaxAttempts: 2,
+	})
+
+	// When
+	// Every message of the aggregate fills a batch for two polls, until it is dead-lettered.
+	for range 6 {
+		_, err := relay.PublishPending(ctx)
+		require.ErrorIs(t, err, errUnavailable)
+	}
+
+	// Then
+	require.Equal(t, []int{2, 3, 4}, sink.aggregateIDs())
+
+	pending, err := repository.Pending(ctx, 10)
+	require.NoError(t, err)
+	require.Empty(t, pending)
+}
+
+func TestRelay_PublishPending_SuccessfulWithoutTheLock(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	repository := outbox.NewMemoryRepository()
+	appendMessages(t, repository, 1)
+
+	sink := &recordingSink{failing: map[int]bool{}}
+	relay := outbox.NewRelay(repository, []outbox.Sink{sink}, heldLock(false), outbox.RelayConfig{})
+
+	// When
+	published, err := relay.PublishPending(ctx)
+
+	// Then
+	require.NoError(t, err)
+	require.Zero(t, published)
+	require.Empty(t, sink.aggregateIDs())
+}
+
+func TestRelay_Start_SuccessfulPublishesInTheBackground(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	repository := outbox.NewMemoryRepository()
+
+	sink := &recordingSink{failing: map[int]bool{}}
+	relay := outbox.NewRelay(repository, []outbox.Sink{sink}, heldLock(true), outbox.RelayConfig{
+		Interval: 10 * time.Millisecond,
+	})
+
+	// When
+	err := relay.Start(ctx)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, relay.Stop(context.Background()))
+	})
+
+	appendMessages(t, repository, 1, 2)
+
+	// Then
+	require.Eventually(t, func() bool {
+		return len(sink.aggregateIDs()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,129 @@
+package outbox
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/sql"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+const (
+	_insertMessageStmt = `INSERT INTO outbox (aggregate_type, aggregate_id, event_type, payload, created_at)
+							VALUES (?, ?, ?, ?, ?);`
+	_pendingMessagesStmt = `SELECT id, aggregate_type, aggregate_id, event_type, payload, created_at, published_at,
+								attempts, dead_lettered_at
+							FROM outbox
+							WHERE published_at IS NULL AND dead_lettered_at IS NULL
+							ORDER BY id
+							LIMIT ?;`
+	_deletePublishedBeforeStmt = `DELETE FROM outbox WHERE published_at < ?;`
+)
+
+type Repository interface {
+	// Append the messages, in order.
+	Append(ctx context.Context, messages ...domain.OutboxMessage) error
+
+	// Pending obtain the first messages that are neither published nor dead-lettered, in order.
+	Pending(ctx context.Context, limit int) ([]domain.OutboxMessage, error)
+
+	// MarkPublished records the messages as published at the time.
+	MarkPublished(ctx context.Context, ids []int, at time.Time) error
+
+	// MarkFailed records a failed attempt to publish the message, dead-lettering it at the time when
+	// deadLetter is true. Dead-lettered messages are kept, but no longer pending.
+	MarkFailed(ctx context.Context, id int, deadLetter bool, at time.Time) error
+
+	// DeletePublishedBefore deletes the messages published before the time, returning how many.
+	DeletePublishedBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+type repository struct {
+	conn    *sqlx.DB
+	dialect sql.Dialect
+}
+
+func NewRepository(conn *sqlx.DB) Repository {
+	return &repository{
+		conn:    conn,
+		dialect: sql.NewDialect(conn.DriverName()),
+	}
+}
+
+func (r repository) Append(ctx context.Context, messages ...domain.OutboxMessage) error {
+	return insert(ctx, sql.ConnFrom(ctx, r.conn), r.dialect, messages)
+}
+
+// Insert appends the messages inside tx, so they are only published when the changes they describe are
+// committed.
+func Insert(ctx context.Context, tx *sql.Tx, messages ...domain.OutboxMessage) error {
+	return insert(ctx, tx, sql.NewDialect(tx.DriverName()), messages)
+}
+
+func insert(ctx context.Context, conn sqlx.ExecerContext, dialect sql.Dialect, messages []domain.OutboxMessage) error {
+	for _, message := range messages {
+		_, err := conn.ExecContext(ctx, dialect.Rebind(_insertMessageStmt),
+			message.AggregateType, message.AggregateID, message.EventType, message.Payload, message.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r repository) Pending(ctx context.Context, limit int) ([]domain.OutboxMessage, error) {
+	messages := make([]domain.OutboxMessage, 0)
+
+	query := r.dialect.Rebind(_pendingMessagesStmt)
+	if err := sql.ConnFrom(ctx, r.conn).SelectContext(ctx, &messages, query, limit); err != nil {
+		return make([]domain.OutboxMessage, 0), err
+	}
+
+	return messages, nil
+}
+
+func (r repository) MarkPublished(ctx context.Context, ids []int, at time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query, args, err := sql.Update("outbox").Set("published_at", at.UTC()).Where(sql.In("id", ids)).Build(r.dialect)
+	if err != nil {
+		return err
+	}
+
+	_, err = sql.ConnFrom(ctx, r.conn).ExecContext(ctx, query, args...)
+
+	return err
+}
+
+func (r repository) MarkFailed(ctx context.Context, id int, deadLetter bool, at time.Time) error {
+	update := sql.Update("outbox").SetExpr("attempts", "attempts + 1")
+	if deadLetter {
+		update = update.Set("dead_lettered_at", at.UTC())
+	}
+
+	query, args, err := update.Where(sql.Eq("id", id)).Build(r.dialect)
+	if err != nil {
+		return err
+	}
+
+	_, err = sql.ConnFrom(ctx, r.conn).ExecContext(ctx, query, args...)
+
+	return err
+}
+
+func (r repository) DeletePublishedBefore(ctx context.Context, before time.Time) (int, error) {
+	result, err := sql.ConnFrom(ctx, r.conn).ExecContext(ctx, r.dialect.Rebind(_deletePublishedBeforeStmt), before.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(deleted), nil
+}
@@ -0,0 +1,73 @@
+package outbox
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"strconv"
+	"time"
+)
+
+// _streamMaxLen is the approximate number of messages kept in a Redis stream.
+const _streamMaxLen = 100_000
+
+// Sink publishes the messages to their consumers. A message may be published more than once, so
+// consumers must ignore the IDs they already processed. Brokers like NATS or Kafka are added by
+// implementing Sink, keying the messages by their aggregate to keep their order.
+type Sink interface {
+	// Publish the message, returning once the sink accepted it.
+	Publish(ctx context.Context, message domain.OutboxMessage) error
+}
+
+type logSink struct {
+	logger *zap.Logger
+}
+
+// NewLogSink creates a Sink that logs the messages, to follow the events in development.
+func NewLogSink(logger *zap.Logger) Sink {
+	return &logSink{
+		logger: logger,
+	}
+}
+
+func (s *logSink) Publish(_ context.Context, message domain.OutboxMessage) error {
+	s.logger.Info("Published domain event",
+		zap.Int("id", message.ID),
+		zap.String("aggregate_type", message.AggregateType),
+		zap.Int("aggregate_id", message.AggregateID),
+		zap.String("event_type", message.EventType),
+		zap.ByteString("payload", message.Payload))
+
+	return nil
+}
+
+type redisStreamSink struct {
+	client *redis.Client
+	stream string
+}
+
+// NewRedisStreamSink creates a Sink that appends the messages to a Redis stream, keeping the last ones.
+// Consumer groups read them in order.
+func NewRedisStreamSink(client *redis.Client, stream string) Sink {
+	return &redisStreamSink{
+		client: client,
+		stream: stream,
+	}
+}
+
+func (s *redisStreamSink) Publish(ctx context.Context, message domain.OutboxMessage) error {
+	return s.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: s.stream,
+		MaxLen: _streamMaxLen,
+		Approx: true,
+		Values: map[string]any{
+			"id":             strconv.Itoa(message.ID),
+			"aggregate_type": message.AggregateType,
+			"aggregate_id":   strconv.Itoa(message.AggregateID),
+			"event_type":     message.EventType,
+			"payload":        string(message.Payload),
+			"created_at":     message.CreatedAt.UTC().Format(time.RFC3339),
+		},
+	}).Err()
+}
@@ -0,0 +1,329 @@
+// Package cache decorates services with a read-through cache in Redis.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/logging"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/metrics"
+	"github.com/ferch5003/go-fiber-tutorial/internal/todo"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	// _todosCache labels the hits and misses of the todos cache.
+	_todosCache = "todos"
+
+	// _operationTimeout bounds every Redis operation, so an unreachable Redis slows requests down by
+	// this much at most before they fall back to the service.
+	_operationTimeout = 250 * time.Millisecond
+)
+
+// Results of a cache lookup.
+const (
+	Hit   = "hit"
+	Miss  = "miss"
+	Error = "error"
+)
+
+type todoService struct {
+	next    todo.Service
+	client  *redis.Client
+	ttl     time.Duration
+	metrics *metrics.Metrics
+}
+
+// NewTodoService decorates todo.Service caching the todos of every user for ttl. Writes invalidate the
+// cached entries of the user, and Redis errors fall back to the decorated service.
+//
+// The keys of a user are stamped with its generation, which writes increment. A read that raced with a
+// write may cache what it read before the write, but under the previous generation, which is no longer
+// read.
+func NewTodoService(next todo.Service, client *redis.Client, ttl time.Duration, m *metrics.Metrics) todo.Service {
+	return &todoService{
+		next:    next,
+		client:  client,
+		ttl:     ttl,
+		metrics: m,
+	}
+}
+
+// generationKey is the key of the generation of the cached entries of a user. It does not expire, so a
+// generation is never reused while its entries live.
+func generationKey(userID int) string {
+	return fmt.Sprintf("todos:user:%d:generation", userID)
+}
+
+// userTodosKey is the key of the todos list of a user.
+func userTodosKey(userID int, generation int64) string {
+	return fmt.Sprintf("todos:user:%d:%d", userID, generation)
+}
+
+// todoKey is the key of a single todo of a user.
+func todoKey(userID int, generation int64, id int) string {
+	return fmt.Sprintf("todos:user:%d:%d:todo:%d", userID, generation, id)
+}
+
+func (s todoService) GetAll(ctx context.Context, userID int) ([]domain.Todo, error) {
+	generation, ok := s.generation(ctx, userID)
+	if !ok {
+		return s.next.GetAll(ctx, userID)
+	}
+
+	key := userTodosKey(userID, generation)
+
+	var todos []domain.Todo
+	if s.get(ctx, key, &todos) {
+		return todos, nil
+	}
+
+	todos, err := s.next.GetAll(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.set(ctx, key, todos)
+
+	return todos, nil
+}
+
+// Get caches the todos of the authenticated user only, under its keys, so a todo is never served from
+// the cache to another user. Todos obtained without one are not cached.
+func (s todoService) Get(ctx context.Context, id int) (domain.Todo, error) {
+	userID := audit.SourceFrom(ctx).ActorID
+	if userID == 0 {
+		return s.next.Get(ctx, id)
+	}
+
+	generation, ok := s.generation(ctx, userID)
+	if !ok {
+		return s.next.Get(ctx, id)
+	}
+
+	key := todoKey(userID, generation, id)
+
+	var obtainedTodo domain.Todo
+	if s.get(ctx, key, &obtainedTodo) {
+		return obtainedTodo, nil
+	}
+
+	obtainedTodo, err := s.next.Get(ctx, id)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	if obtainedTodo.UserID == userID {
+		s.set(ctx, key, obtainedTodo)
+	}
+
+	return obtainedTodo, nil
+}
+
+func (s todoService) Save(ctx context.Context, todo domain.Todo) (domain.Todo, error) {
+	savedTodo, err := s.next.Save(ctx, todo)
+
+	s.invalidate(ctx, todo.UserID)
+
+	return savedTodo, err
+}
+
+func (s todoService) Completed(ctx context.Context, id int, version int) (domain.Todo, error) {
+	userID := s.ownerOf(ctx, id)
+
+	completedTodo, err := s.next.Completed(ctx, id, version)
+
+	s.invalidate(ctx, userID)
+
+	return completedTodo, err
+}
+
+func (s todoService) Delete(ctx context.Context, id int, version int) error {
+	userID := s.ownerOf(ctx, id)
+
+	err := s.next.Delete(ctx, id, version)
+
+	s.invalidate(ctx, userID)
+
+	return err
+}
+
+// Batch invalidates the todos of the user, as batches only write todos of the user.
+func (s todoService) Batch(
+	ctx context.Context,
+	userID int,
+	operations []domain.TodoOperation,
+	atomic bool) ([]domain.TodoOperationResult, error) {
+	results, err := s.next.Batch(ctx, userID, operations, atomic)
+
+	s.invalidate(ctx, userID)
+
+	return results, err
+}
+
+// Import invalidates the todos of the user, as imports only create todos.
+func (s todoService) Import(ctx context.Context, todoImport domain.TodoImport) ([]domain.TodoImportResult, error) {
+	results, err := s.next.Import(ctx, todoImport)
+
+	if !todoImport.DryRun {
+		s.invalidate(ctx, todoImport.UserID)
+	}
+
+	return results, err
+}
+
+// Update invalidates the todos of the user, which include the occurrences of the series changed with
+// the todo.
+func (s todoService) Update(
+	ctx context.Context,
+	changes domain.TodoChanges,
+	scope domain.TodoScope) (domain.Todo, error) {
+	userID := s.ownerOf(ctx, changes.ID)
+
+	updatedTodo, err := s.next.Update(ctx, changes, scope)
+
+	s.invalidate(ctx, userID)
+
+	return updatedTodo, err
+}
+
+func (s todoService) Skip(ctx context.Context, id int, version int) (domain.Todo, error) {
+	userID := s.ownerOf(ctx, id)
+
+	skippedTodo, err := s.next.Skip(ctx, id, version)
+
+	s.invalidate(ctx, userID)
+
+	return skippedTodo, err
+}
+
+func (s todoService) EndSeries(ctx context.Context, id int, version int) (domain.Todo, error) {
+	userID := s.ownerOf(ctx, id)
+
+	endedTodo, err := s.next.EndSeries(ctx, id, version)
+
+	s.invalidate(ctx, userID)
+
+	return endedTodo, err
+}
+
+// Move invalidates the todos of the user, as rebalancing their positions may have written all of them.
+func (s todoService) Move(ctx context.Context, move domain.TodoMove) (domain.Todo, error) {
+	movedTodo, err := s.next.Move(ctx, move)
+
+	s.invalidate(ctx, move.UserID)
+
+	return movedTodo, err
+}
+
+// Search is not cached, as every query would be a different key to invalidate on writes.
+func (s todoService) Search(ctx context.Context, search domain.TodoSearch) (domain.TodoSearchPage, error) {
+	return s.next.Search(ctx, search)
+}
+
+// GetPage is not cached, as it is read once by a streamed export that would only fill the cache.
+func (s todoService) GetPage(ctx context.Context, userID int, after domain.Todo, limit int) ([]domain.Todo, error) {
+	return s.next.GetPage(ctx, userID, after, limit)
+}
+
+// ownerOf returns the user of the todo, or zero when it can not be obtained, to invalidate its entries.
+func (s todoService) ownerOf(ctx context.Context, id int) int {
+	obtainedTodo, err := s.Get(ctx, id)
+	if err != nil {
+		return 0
+	}
+
+	return obtainedTodo.UserID
+}
+
+// generation returns the generation of the cached entries of the user, reporting false when Redis can
+// not be read.
+func (s todoService) generation(ctx context.Context, userID int) (int64, bool) {
+	ctx, cancel := context.WithTimeout(ctx, _operationTimeout)
+	defer cancel()
+
+	key := generationKey(userID)
+
+	generation, err := s.client.Get(ctx, key).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, true
+	}
+
+	if err != nil {
+		s.record(Error)
+		logging.FromContext(ctx).Warn("cache read failed", zap.String("key", key), zap.Error(err))
+
+		return 0, false
+	}
+
+	return generation, true
+}
+
+// get reads the key into value, reporting whether it was a hit.
+func (s todoService) get(ctx context.Context, key string, value any) bool {
+	ctx, cancel := context.WithTimeout(ctx, _operationTimeout)
+	defer cancel()
+
+	data, err := s.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		s.record(Miss)
+
+		return false
+	}
+
+	if err == nil {
+		err = json.Unmarshal(data, value)
+	}
+
+	if err != nil {
+		s.record(Error)
+		logging.FromContext(ctx).Warn("cache read failed", zap.String("key", key), zap.Error(err))
+
+		return false
+	}
+
+	s.record(Hit)
+
+	return true
+}
+
+func (s todoService) set(ctx context.Context, key string, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, _operationTimeout)
+	defer cancel()
+
+	if err := s.client.Set(ctx, key, data, s.ttl).Err(); err != nil {
+		logging.FromContext(ctx).Warn("cache write failed", zap.String("key", key), zap.Error(err))
+	}
+}
+
+// invalidate moves the user to its next generation, so its cached entries are no longer read. It runs
+// even when the write failed, as the write may have been applied anyway. When it fails, the entries
+// expire after the TTL.
+func (s todoService) invalidate(ctx context.Context, userID int) {
+	if userID == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, _operationTimeout)
+	defer cancel()
+
+	key := generationKey(userID)
+
+	if err := s.client.Incr(ctx, key).Err(); err != nil {
+		logging.FromContext(ctx).Warn("cache invalidation failed", zap.String("key", key), zap.Error(err))
+	}
+}
+
+func (s todoService) record(result string) {
+	s.metrics.CacheRequests.WithLabelValues(_todosCache, result).Inc()
+}
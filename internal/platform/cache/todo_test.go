@@ -0,0 +1,530 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/data"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/metrics"
+	"github.com/go-redis/redismock/v9"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const _ttl = time.Minute
+
+type todoServiceMock struct {
+	mock.Mock
+}
+
+func (tsm *todoServiceMock) GetAll(ctx context.Context, userID int) ([]domain.Todo, error) {
+	args := tsm.Called(ctx, userID)
+	return args.Get(0).([]domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) GetPage(
+	ctx context.Context,
+	userID int,
+	after domain.Todo,
+	limit int) ([]domain.Todo, error) {
+	args := tsm.Called(ctx, userID, after, limit)
+	return args.Get(0).([]domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Get(ctx context.Context, id int) (domain.Todo, error) {
+	args := tsm.Called(ctx, id)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Save(ctx context.Context, todo domain.Todo) (domain.Todo, error) {
+	args := tsm.Called(ctx, todo)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Completed(ctx context.Context, id int, version int) (domain.Todo, error) {
+	args := tsm.Called(ctx, id, version)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Delete(ctx context.Context, id int, version int) error {
+	args := tsm.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+func (tsm *todoServiceMock) Batch(
+	ctx context.Context,
+	userID int,
+	operations []domain.TodoOperation,
+	atomic bool) ([]domain.TodoOperationResult, error) {
+	args := tsm.Called(ctx, userID, operations, atomic)
+	return args.Get(0).([]domain.TodoOperationResult), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Import(
+	ctx context.Context,
+	todoImport domain.TodoImport) ([]domain.TodoImportResult, error) {
+	args := tsm.Called(ctx, todoImport)
+	return args.Get(0).([]domain.TodoImportResult), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Search(ctx context.Context, search domain.TodoSearch) (domain.TodoSearchPage, error) {
+	args := tsm.Called(ctx, search)
+	return args.Get(0).(domain.TodoSearchPage), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Update(
+	ctx context.Context,
+	changes domain.TodoChanges,
+	scope domain.TodoScope) (domain.Todo, error) {
+	args := tsm.Called(ctx, changes, scope)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Skip(ctx context.Context, id int, version int) (domain.Todo, error) {
+	args := tsm.Called(ctx, id, version)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) EndSeries(ctx context.Context, id int, version int) (domain.Todo, error) {
+	args := tsm.Called(ctx, id, version)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Move(ctx context.Context, move domain.TodoMove) (domain.Todo, error) {
+	args := tsm.Called(ctx, move)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func cacheRequests(m *metrics.Metrics, result string) float64 {
+	return testutil.ToFloat64(m.CacheRequests.WithLabelValues(_todosCache, result))
+}
+
+func TestTodoServiceGetAll_SuccessfulWithHit(t *testing.T) {
+	// Given
+	userID := 1
+	expectedTodos := []domain.Todo{{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: userID}}
+
+	data, err := json.Marshal(expectedTodos)
+	require.NoError(t, err)
+
+	db, redisMock := redismock.NewClientMock()
+	redisMock.ExpectGet("todos:user:1:generation").SetVal("3")
+	redisMock.ExpectGet("todos:user:1:3").SetVal(string(data))
+
+	tsm := new(todoServiceMock)
+	m := metrics.NewMetrics()
+
+	service := NewTodoService(tsm, db, _ttl, m)
+
+	// When
+	todos, err := service.GetAll(context.Background(), userID)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, expectedTodos, todos)
+	require.Equal(t, float64(1), cacheRequests(m, Hit))
+	require.NoError(t, redisMock.ExpectationsWereMet())
+	tsm.AssertNotCalled(t, "GetAll", mock.Anything, mock.Anything)
+}
+
+func TestTodoServiceGetAll_SuccessfulWithMiss(t *testing.T) {
+	// Given
+	userID := 1
+	expectedTodos := []domain.Todo{{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: userID}}
+
+	data, err := json.Marshal(expectedTodos)
+	require.NoError(t, err)
+
+	db, redisMock := redismock.NewClientMock()
+	redisMock.ExpectGet("todos:user:1:generation").RedisNil()
+	redisMock.ExpectGet("todos:user:1:0").RedisNil()
+	redisMock.ExpectSet("todos:user:1:0", data, _ttl).SetVal("OK")
+
+	tsm := new(todoServiceMock)
+	tsm.On("GetAll", mock.Anything, userID).Return(expectedTodos, nil)
+
+	m := metrics.NewMetrics()
+
+	service := NewTodoService(tsm, db, _ttl, m)
+
+	// When
+	todos, err := service.GetAll(context.Background(), userID)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, expectedTodos, todos)
+	require.Equal(t, float64(1), cacheRequests(m, Miss))
+	require.NoError(t, redisMock.ExpectationsWereMet())
+	tsm.AssertExpectations(t)
+}
+
+func TestTodoServiceGetAll_SuccessfulWithUnavailableRedis(t *testing.T) {
+	// Given
+	userID := 1
+	expectedTodos := []domain.Todo{{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: userID}}
+	redisErr := errors.New("dial tcp: connection refused")
+
+	db, redisMock := redismock.NewClientMock()
+	redisMock.ExpectGet("todos:user:1:generation").SetErr(redisErr)
+
+	tsm := new(todoServiceMock)
+	tsm.On("GetAll", mock.Anything, userID).Return(expectedTodos, nil)
+
+	m := metrics.NewMetrics()
+
+	service := NewTodoService(tsm, db, _ttl, m)
+
+	// When
+	todos, err := service.GetAll(context.Background(), userID)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, expectedTodos, todos)
+	require.Equal(t, float64(1), cacheRequests(m, Error))
+	require.NoError(t, redisMock.ExpectationsWereMet())
+	tsm.AssertExpectations(t)
+}
+
+func TestTodoServiceGetAll_FailsDueToServiceError(t *testing.T) {
+	// Given
+	userID := 1
+	expectedErr := errors.New("sql: connection is already closed")
+
+	db, redisMock := redismock.NewClientMock()
+	redisMock.ExpectGet("todos:user:1:generation").RedisNil()
+	redisMock.ExpectGet("todos:user:1:0").RedisNil()
+
+	tsm := new(todoServiceMock)
+	tsm.On("GetAll", mock.Anything, userID).Return([]domain.Todo{}, expectedErr)
+
+	service := NewTodoService(tsm, db, _ttl, metrics.NewMetrics())
+
+	// When
+	todos, err := service.GetAll(context.Background(), userID)
+
+	// Then
+	require.ErrorIs(t, err, expectedErr)
+	require.Nil(t, todos)
+	require.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestTodoServiceGet_SuccessfulWithHit(t *testing.T) {
+	// Given
+	expectedTodo := domain.Todo{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: 1}
+
+	data, err := json.Marshal(expectedTodo)
+	require.NoError(t, err)
+
+	db, redisMock := redismock.NewClientMock()
+	redisMock.ExpectGet("todos:user:1:generation").SetVal("2")
+	redisMock.ExpectGet("todos:user:1:2:todo:1").SetVal(string(data))
+
+	tsm := new(todoServiceMock)
+	m := metrics.NewMetrics()
+
+	service := NewTodoService(tsm, db, _ttl, m)
+
+	// When
+	obtainedTodo, err := service.Get(audit.WithActor(context.Background(), 1), expectedTodo.ID)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, expectedTodo, obtainedTodo)
+	require.Equal(t, float64(1), cacheRequests(m, Hit))
+	tsm.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+}
+
+func TestTodoServiceGet_SuccessfulWithMiss(t *testing.T) {
+	// Given
+	expectedTodo := domain.Todo{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: 1}
+
+	data, err := json.Marshal(expectedTodo)
+	require.NoError(t, err)
+
+	db, redisMock := redismock.NewClientMock()
+	redisMock.ExpectGet("todos:user:1:generation").RedisNil()
+	redisMock.ExpectGet("todos:user:1:0:todo:1").RedisNil()
+	redisMock.ExpectSet("todos:user:1:0:todo:1", data, _ttl).SetVal("OK")
+
+	tsm := new(todoServiceMock)
+	tsm.On("Get", mock.Anything, expectedTodo.ID).Return(expectedTodo, nil)
+
+	m := metrics.NewMetrics()
+
+	service := NewTodoService(tsm, db, _ttl, m)
+
+	// When
+	obtainedTodo, err := service.Get(audit.WithActor(context.Background(), 1), expectedTodo.ID)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, expectedTodo, obtainedTodo)
+	require.Equal(t, float64(1), cacheRequests(m, Miss))
+	require.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestTodoServiceGet_SuccessfulDoesNotCacheTodoOfAnotherUser(t *testing.T) {
+	// Given
+	expectedTodo := domain.Todo{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: 1}
+
+	db, redisMock := redismock.NewClientMock()
+	redisMock.ExpectGet("todos:user:2:generation").RedisNil()
+	redisMock.ExpectGet("todos:user:2:0:todo:1").RedisNil()
+
+	tsm := new(todoServiceMock)
+	tsm.On("Get", mock.Anything, expectedTodo.ID).Return(expectedTodo, nil)
+
+	service := NewTodoService(tsm, db, _ttl, metrics.NewMetrics())
+
+	// When
+	obtainedTodo, err := service.Get(audit.WithActor(context.Background(), 2), expectedTodo.ID)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, expectedTodo, obtainedTodo)
+	require.NoError(t, redisMock.ExpectationsWereMet())
+	tsm.AssertExpectations(t)
+}
+
+func TestTodoServiceGet_SuccessfulWithoutActorSkipsCache(t *testing.T) {
+	// Given
+	expectedTodo := domain.Todo{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: 1}
+
+	db, redisMock := redismock.NewClientMock()
+
+	tsm := new(todoServiceMock)
+	tsm.On("Get", mock.Anything, expectedTodo.ID).Return(expectedTodo, nil)
+
+	m := metrics.NewMetrics()
+
+	service := NewTodoService(tsm, db, _ttl, m)
+
+	// When
+	obtainedTodo, err := service.Get(context.Background(), expectedTodo.ID)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, expectedTodo, obtainedTodo)
+	require.Equal(t, float64(0), cacheRequests(m, Miss))
+	require.NoError(t, redisMock.ExpectationsWereMet())
+	tsm.AssertExpectations(t)
+}
+
+func TestTodoServiceSave_SuccessfulInvalidatesUserTodos(t *testing.T) {
+	// Given
+	newTodo := domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: 1}
+	expectedTodo := newTodo
+	expectedTodo.ID = 1
+
+	db, redisMock := redismock.NewClientMock()
+	redisMock.ExpectIncr("todos:user:1:generation").SetVal(1)
+
+	tsm := new(todoServiceMock)
+	tsm.On("Save", mock.Anything, newTodo).Return(expectedTodo, nil)
+
+	service := NewTodoService(tsm, db, _ttl, metrics.NewMetrics())
+
+	// When
+	savedTodo, err := service.Save(context.Background(), newTodo)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, expectedTodo, savedTodo)
+	require.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestTodoServiceCompleted_SuccessfulInvalidatesUserTodos(t *testing.T) {
+	// Given
+	cachedTodo := domain.Todo{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: 2}
+
+	data, err := json.Marshal(cachedTodo)
+	require.NoError(t, err)
+
+	db, redisMock := redismock.NewClientMock()
+	redisMock.ExpectGet("todos:user:2:generation").SetVal("4")
+	redisMock.ExpectGet("todos:user:2:4:todo:1").SetVal(string(data))
+	redisMock.ExpectIncr("todos:user:2:generation").SetVal(5)
+
+	tsm := new(todoServiceMock)
+	tsm.On("Completed", mock.Anything, cachedTodo.ID, 0).Return(domain.Todo{}, nil)
+
+	service := NewTodoService(tsm, db, _ttl, metrics.NewMetrics())
+
+	// When
+	_, err = service.Completed(audit.WithActor(context.Background(), 2), cachedTodo.ID, 0)
+
+	// Then
+	require.NoError(t, err)
+	require.NoError(t, redisMock.ExpectationsWereMet())
+	tsm.AssertExpectations(t)
+}
+
+func TestTodoServiceUpdate_SuccessfulInvalidatesFutureOccurrences(t *testing.T) {
+	// Given
+	cachedTodo := domain.Todo{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: 2, SeriesID: 1}
+	changes := domain.TodoChanges{ID: 1, Title: "Dolor"}
+	updatedTodo := domain.Todo{ID: 1, Title: "Dolor", Description: "Ipsum", UserID: 2, SeriesID: 1, Version: 2}
+
+	data, err := json.Marshal(cachedTodo)
+	require.NoError(t, err)
+
+	db, redisMock := redismock.NewClientMock()
+	redisMock.ExpectGet("todos:user:2:generation").SetVal("4")
+	redisMock.ExpectGet("todos:user:2:4:todo:1").SetVal(string(data))
+	redisMock.ExpectIncr("todos:user:2:generation").SetVal(5)
+
+	tsm := new(todoServiceMock)
+	tsm.On("Update", mock.Anything, changes, domain.TodoScopeFuture).Return(updatedTodo, nil)
+
+	service := NewTodoService(tsm, db, _ttl, metrics.NewMetrics())
+
+	// When
+	obtainedTodo, err := service.Update(audit.WithActor(context.Background(), 2), changes, domain.TodoScopeFuture)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, updatedTodo, obtainedTodo)
+	require.NoError(t, redisMock.ExpectationsWereMet())
+	tsm.AssertExpectations(t)
+	tsm.AssertNotCalled(t, "GetAll", mock.Anything, mock.Anything)
+}
+
+func TestTodoServiceSkip_SuccessfulInvalidatesUserTodos(t *testing.T) {
+	// Given
+	cachedTodo := domain.Todo{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: 2}
+
+	data, err := json.Marshal(cachedTodo)
+	require.NoError(t, err)
+
+	db, redisMock := redismock.NewClientMock()
+	redisMock.ExpectGet("todos:user:2:generation").SetVal("4")
+	redisMock.ExpectGet("todos:user:2:4:todo:1").SetVal(string(data))
+	redisMock.ExpectIncr("todos:user:2:generation").SetVal(5)
+
+	tsm := new(todoServiceMock)
+	tsm.On("Skip", mock.Anything, cachedTodo.ID, 0).Return(cachedTodo, nil)
+
+	service := NewTodoService(tsm, db, _ttl, metrics.NewMetrics())
+
+	// When
+	_, err = service.Skip(audit.WithActor(context.Background(), 2), cachedTodo.ID, 0)
+
+	// Then
+	require.NoError(t, err)
+	require.NoError(t, redisMock.ExpectationsWereMet())
+	tsm.AssertExpectations(t)
+}
+
+func TestTodoServiceMove_SuccessfulInvalidatesEveryUserTodo(t *testing.T) {
+	// Given
+	move := domain.TodoMove{ID: 2, UserID: 2, BeforeID: 1}
+	movedTodo := domain.Todo{ID: 2, Title: "Dolor", UserID: 2, Version: 2, Position: 512}
+
+	db, redisMock := redismock.NewClientMock()
+	redisMock.ExpectIncr("todos:user:2:generation").SetVal(1)
+
+	tsm := new(todoServiceMock)
+	tsm.On("Move", mock.Anything, move).Return(movedTodo, nil)
+
+	service := NewTodoService(tsm, db, _ttl, metrics.NewMetrics())
+
+	// When
+	obtainedTodo, err := service.Move(context.Background(), move)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, movedTodo, obtainedTodo)
+	require.NoError(t, redisMock.ExpectationsWereMet())
+	tsm.AssertExpectations(t)
+	tsm.AssertNotCalled(t, "GetAll", mock.Anything, mock.Anything)
+}
+
+func TestTodoServiceDelete_SuccessfulInvalidatesUserTodos(t *testing.T) {
+	// Given
+	existingTodo := domain.Todo{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: 2}
+
+	data, err := json.Marshal(existingTodo)
+	require.NoError(t, err)
+
+	db, redisMock := redismock.NewClientMock()
+	redisMock.ExpectGet("todos:user:2:generation").RedisNil()
+	redisMock.ExpectGet("todos:user:2:0:todo:1").RedisNil()
+	redisMock.ExpectSet("todos:user:2:0:todo:1", data, _ttl).SetVal("OK")
+	redisMock.ExpectIncr("todos:user:2:generation").SetVal(1)
+
+	tsm := new(todoServiceMock)
+	tsm.On("Get", mock.Anything, existingTodo.ID).Return(existingTodo, nil)
+	tsm.On("Delete", mock.Anything, existingTodo.ID, 0).Return(nil)
+
+	service := NewTodoService(tsm, db, _ttl, metrics.NewMetrics())
+
+	// When
+	err = service.Delete(audit.WithActor(context.Background(), 2), existingTodo.ID, 0)
+
+	// Then
+	require.NoError(t, err)
+	require.NoError(t, redisMock.ExpectationsWereMet())
+	tsm.AssertExpectations(t)
+}
+
+func TestTodoServiceDelete_FailsDueToServiceErrorStillInvalidates(t *testing.T) {
+	// Given
+	expectedErr := errors.New("no rows affected")
+	cachedTodo := domain.Todo{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: 2}
+
+	data, err := json.Marshal(cachedTodo)
+	require.NoError(t, err)
+
+	db, redisMock := redismock.NewClientMock()
+	redisMock.ExpectGet("todos:user:2:generation").SetVal("4")
+	redisMock.ExpectGet("todos:user:2:4:todo:1").SetVal(string(data))
+	redisMock.ExpectIncr("todos:user:2:generation").SetVal(5)
+
+	tsm := new(todoServiceMock)
+	tsm.On("Delete", mock.Anything, 1, 0).Return(expectedErr)
+
+	service := NewTodoService(tsm, db, _ttl, metrics.NewMetrics())
+
+	// When
+	err = service.Delete(audit.WithActor(context.Background(), 2), 1, 0)
+
+	// Then
+	require.ErrorIs(t, err, expectedErr)
+	require.NoError(t, redisMock.ExpectationsWereMet())
+}
+
+func TestTodoServiceBatch_SuccessfulInvalidatesUserTodos(t *testing.T) {
+	// Given
+	operations := []domain.TodoOperation{
+		{Action: domain.TodoCreate, Title: "Lorem", Description: data.Some("Ipsum")},
+		{Action: domain.TodoComplete, ID: 1},
+		{Action: domain.TodoDelete, ID: 2},
+	}
+	expectedResults := []domain.TodoOperationResult{
+		{Index: 0, Action: domain.TodoCreate, ID: 3, Version: 1},
+		{Index: 1, Action: domain.TodoComplete, ID: 1, Version: 2},
+		{Index: 2, Action: domain.TodoDelete, ID: 2},
+	}
+
+	db, redisMock := redismock.NewClientMock()
+	redisMock.ExpectIncr("todos:user:5:generation").SetVal(1)
+
+	tsm := new(todoServiceMock)
+	tsm.On("Batch", mock.Anything, 5, operations, true).Return(expectedResults, nil)
+
+	service := NewTodoService(tsm, db, _ttl, metrics.NewMetrics())
+
+	// When
+	results, err := service.Batch(context.Background(), 5, operations, true)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, expectedResults, results)
+	require.NoError(t, redisMock.ExpectationsWereMet())
+	tsm.AssertExpectations(t)
+}
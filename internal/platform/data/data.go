@@ -4,6 +4,10 @@ import (
 	"reflect"
 )
 
+// OverwriteStruct copies the columns of dtoInstance to structInstance, skipping zero values and fields of
+// another kind.
+//
+// Deprecated: use a Mapper, which checks its fields once and tells absent, null and zero values apart.
 func OverwriteStruct(structInstance, dtoInstance any, columns []string) {
 	structReflectValue := reflect.ValueOf(structInstance).Elem()
 	dtoReflectValue := reflect.ValueOf(dtoInstance)
@@ -0,0 +1,194 @@
+package data
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// _mapTag names the source field of a tagged mapping, "-" skips the field.
+const _mapTag = "map"
+
+// Mapping copies fields of a source to a destination.
+type Mapping[S, D any] func(src *S, dst *D)
+
+// Mapper copies the fields of a source struct to a destination struct. Values are assigned, so slices,
+// maps and pointers are shared with the source.
+type Mapper[S, D any] struct {
+	mappings []Mapping[S, D]
+}
+
+// NewMapper creates a Mapper of the mappings, applied in order.
+func NewMapper[S, D any](mappings ...Mapping[S, D]) Mapper[S, D] {
+	return Mapper[S, D]{mappings: mappings}
+}
+
+// With returns a Mapper applying the mappings after the ones of m.
+func (m Mapper[S, D]) With(mappings ...Mapping[S, D]) Mapper[S, D] {
+	return Mapper[S, D]{mappings: append(m.mappings[:len(m.mappings):len(m.mappings)], mappings...)}
+}
+
+// Map returns a new destination with the fields of src.
+func (m Mapper[S, D]) Map(src S) D {
+	var dst D
+	m.Into(&dst, src)
+
+	return dst
+}
+
+// Into overwrites the fields of dst with the ones of src, leaving the fields that are not mapped, and
+// the absent patches, as they are.
+func (m Mapper[S, D]) Into(dst *D, src S) {
+	for _, mapping := range m.mappings {
+		mapping(&src, dst)
+	}
+}
+
+// Field copies a field, zero values included. The types of both fields are checked when compiling.
+func Field[S, D, T any](from func(src *S) T, to func(dst *D) *T) Mapping[S, D] {
+	return func(src *S, dst *D) {
+		*to(dst) = from(src)
+	}
+}
+
+// Convert copies a field of another type, converting it.
+func Convert[S, D, T, U any](from func(src *S) T, to func(dst *D) *U, convert func(T) U) Mapping[S, D] {
+	return func(src *S, dst *D) {
+		*to(dst) = convert(from(src))
+	}
+}
+
+// PatchField applies a patch to a field, see Patch.Apply.
+func PatchField[S, D, T any](from func(src *S) Patch[T], to func(dst *D) *T) Mapping[S, D] {
+	return func(src *S, dst *D) {
+		from(src).Apply(to(dst))
+	}
+}
+
+// PatchPointer applies a patch to a nullable field, see Patch.ApplyPointer.
+func PatchPointer[S, D, T any](from func(src *S) Patch[T], to func(dst *D) **T) Mapping[S, D] {
+	return func(src *S, dst *D) {
+		from(src).ApplyPointer(to(dst))
+	}
+}
+
+// Tagged creates a Mapper copying every exported field of D from the field of S with the same name, or
+// with the name of its "map" tag. Fields of embedded structs are mapped as their own. A Patch field is
+// applied to a field of its type or of a pointer to it. The mapping is checked once, here: a field of
+// another type is an error instead of being skipped, while a field of D without a source is left as it is.
+func Tagged[S, D any]() (Mapper[S, D], error) {
+	srcType, dstType := reflect.TypeFor[S](), reflect.TypeFor[D]()
+	if srcType.Kind() != reflect.Struct || dstType.Kind() != reflect.Struct {
+		return Mapper[S, D]{}, fmt.Errorf("data: cannot map %s to %s, both must be structs", srcType, dstType)
+	}
+
+	sources := make(map[string]reflect.StructField)
+	for _, field := range mappedFields(srcType) {
+		sources[fieldKey(field)] = field
+	}
+
+	fields := make([]fieldMapping, 0, len(sources))
+
+	for _, dstField := range mappedFields(dstType) {
+		srcField, ok := sources[fieldKey(dstField)]
+		if !ok {
+			continue
+		}
+
+		mapping, err := newFieldMapping(srcField, dstField)
+		if err != nil {
+			return Mapper[S, D]{}, fmt.Errorf("data: cannot map %s to %s: %w", srcType, dstType, err)
+		}
+
+		fields = append(fields, mapping)
+	}
+
+	return NewMapper(func(src *S, dst *D) {
+		srcValue, dstValue := reflect.ValueOf(src).Elem(), reflect.ValueOf(dst).Elem()
+
+		for _, field := range fields {
+			field.copy(srcValue.FieldByIndex(field.src), dstValue.FieldByIndex(field.dst))
+		}
+	}), nil
+}
+
+// MustTagged is like Tagged but panics when the mapping is not valid, for the mappers of package variables.
+func MustTagged[S, D any]() Mapper[S, D] {
+	mapper, err := Tagged[S, D]()
+	if err != nil {
+		panic(err)
+	}
+
+	return mapper
+}
+
+// fieldMapping copies the field at the src index to the field at the dst index.
+type fieldMapping struct {
+	src  []int
+	dst  []int
+	copy func(src, dst reflect.Value)
+}
+
+func newFieldMapping(src, dst reflect.StructField) (fieldMapping, error) {
+	mapping := fieldMapping{src: src.Index, dst: dst.Index}
+
+	if src.Type.Implements(reflect.TypeFor[patch]()) {
+		valueType := reflect.Zero(src.Type).Interface().(patch).ValueType()
+		if dst.Type != valueType && dst.Type != reflect.PointerTo(valueType) {
+			return fieldMapping{}, fmt.Errorf("field %s is a %s, which cannot be applied to the %s %s",
+				src.Name, src.Type, dst.Type, dst.Name)
+		}
+
+		mapping.copy = func(src, dst reflect.Value) {
+			src.Interface().(patch).applyTo(dst)
+		}
+
+		return mapping, nil
+	}
+
+	if !src.Type.AssignableTo(dst.Type) {
+		return fieldMapping{}, fmt.Errorf("field %s is a %s, which cannot be assigned to the %s %s",
+			src.Name, src.Type, dst.Type, dst.Name)
+	}
+
+	mapping.copy = func(src, dst reflect.Value) {
+		dst.Set(src)
+	}
+
+	return mapping, nil
+}
+
+// mappedFields returns the exported fields of the struct type, including the ones of its embedded
+// structs but not the ones of embedded pointers, which may be nil.
+func mappedFields(structType reflect.Type) []reflect.StructField {
+	fields := make([]reflect.StructField, 0, structType.NumField())
+
+	for _, field := range reflect.VisibleFields(structType) {
+		if !field.IsExported() || field.Anonymous || fieldKey(field) == "-" || throughPointer(structType, field) {
+			continue
+		}
+
+		fields = append(fields, field)
+	}
+
+	return fields
+}
+
+// throughPointer reports whether the field is promoted from an embedded pointer.
+func throughPointer(structType reflect.Type, field reflect.StructField) bool {
+	for _, i := range field.Index[:len(field.Index)-1] {
+		structType = structType.Field(i).Type
+		if structType.Kind() == reflect.Pointer {
+			return true
+		}
+	}
+
+	return false
+}
+
+func fieldKey(field reflect.StructField) string {
+	if name := field.Tag.Get(_mapTag); name != "" {
+		return name
+	}
+
+	return field.Name
+}
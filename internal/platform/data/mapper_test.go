@@ -0,0 +1,255 @@
+package data
+
+import (
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type audit struct {
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+}
+
+type address struct {
+	City string
+}
+
+type source struct {
+	audit
+	ID       int
+	Name     string
+	Nickname string `map:"Alias"`
+	Tags     []string
+	Address  address
+	Secret   string `map:"-"`
+	internal string
+}
+
+type destination struct {
+	audit
+	ID      int
+	Name    string
+	Alias   string
+	Tags    []string
+	Address address
+	Secret  string
+	Token   *string
+}
+
+func TestTagged_Successful(t *testing.T) {
+	// Given
+	createdAt := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	src := source{
+		audit:    audit{CreatedAt: createdAt},
+		ID:       1,
+		Nickname: "Johnny",
+		Tags:     []string{"admin"},
+		Address:  address{City: "Bogotá"},
+		Secret:   "12345678",
+		internal: "internal",
+	}
+
+	token := "token"
+	dst := destination{Name: "John", Secret: "secret", Token: &token}
+
+	mapper, err := Tagged[source, destination]()
+	require.NoError(t, err)
+
+	// When
+	mapper.Into(&dst, src)
+
+	// Then
+	require.Equal(t, destination{
+		audit:   audit{CreatedAt: createdAt},
+		ID:      1,
+		Alias:   "Johnny",
+		Tags:    []string{"admin"},
+		Address: address{City: "Bogotá"},
+		Secret:  "secret",
+		Token:   &token,
+	}, dst)
+}
+
+func TestTagged_AppliesPatches(t *testing.T) {
+	// Given
+	type patchSource struct {
+		FirstName   Patch[string]
+		LastName    Patch[string]
+		Email       Patch[string]
+		Completed   Patch[bool]
+		DueAt       Patch[time.Time]
+		CompletedAt Patch[time.Time]
+	}
+
+	type patchDestination struct {
+		FirstName   string
+		LastName    string
+		Email       string
+		Completed   bool
+		DueAt       *time.Time
+		CompletedAt *time.Time
+	}
+
+	dueAt := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	dst := patchDestination{FirstName: "John", LastName: "Smith", Email: "john@example.com", Completed: true,
+		CompletedAt: &dueAt}
+
+	mapper, err := Tagged[patchSource, patchDestination]()
+	require.NoError(t, err)
+
+	// When
+	mapper.Into(&dst, patchSource{
+		LastName:    Null[string](),
+		Email:       Some("johnny@example.com"),
+		Completed:   Some(false),
+		DueAt:       Some(dueAt),
+		CompletedAt: Null[time.Time](),
+	})
+
+	// Then
+	require.Equal(t, patchDestination{
+		FirstName: "John",
+		Email:     "johnny@example.com",
+		DueAt:     &dueAt,
+	}, dst)
+}
+
+func TestTagged_FailsDueToMismatchedTypes(t *testing.T) {
+	tests := map[string]func() error{
+		"Kind": func() error {
+			_, err := Tagged[struct{ Value string }, struct{ Value int }]()
+			return err
+		},
+		"Patch": func() error {
+			_, err := Tagged[struct{ Value Patch[string] }, struct{ Value int }]()
+			return err
+		},
+		"NotStruct": func() error {
+			_, err := Tagged[string, struct{ Value int }]()
+			return err
+		},
+	}
+
+	for name, tagged := range tests {
+		t.Run(name, func(t *testing.T) {
+			// When
+			err := tagged()
+
+			// Then
+			require.ErrorContains(t, err, "data: cannot map")
+		})
+	}
+}
+
+func TestMustTagged_PanicsDueToMismatchedTypes(t *testing.T) {
+	// When
+	mustTagged := func() {
+		MustTagged[struct{ Value string }, struct{ Value int }]()
+	}
+
+	// Then
+	require.PanicsWithError(t,
+		"data: cannot map struct { Value string } to struct { Value int }: "+
+			"field Value is a string, which cannot be assigned to the int Value", mustTagged)
+}
+
+func TestNewMapper_Successful(t *testing.T) {
+	// Given
+	type request struct {
+		Title       string
+		Description Patch[string]
+		Priority    string
+		DueAt       Patch[time.Time]
+	}
+
+	type todo struct {
+		Title       string
+		Description string
+		Priority    int
+		DueAt       *time.Time
+	}
+
+	mapper := NewMapper(
+		Field(func(r *request) string { return r.Title }, func(t *todo) *string { return &t.Title }),
+		PatchField(func(r *request) Patch[string] { return r.Description },
+			func(t *todo) *string { return &t.Description }),
+	).With(
+		Convert(func(r *request) string { return r.Priority }, func(t *todo) *int { return &t.Priority },
+			func(priority string) int {
+				value, _ := strconv.Atoi(priority)
+				return value
+			}),
+		PatchPointer(func(r *request) Patch[time.Time] { return r.DueAt },
+			func(t *todo) **time.Time { return &t.DueAt }),
+	)
+
+	dueAt := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	// When
+	mapped := mapper.Map(request{Priority: "2", DueAt: Some(dueAt)})
+
+	// Then
+	require.Equal(t, todo{Priority: 2, DueAt: &dueAt}, mapped)
+}
+
+type benchmarkUser struct {
+	ID        int
+	FirstName string
+	LastName  string
+	Email     string
+	Password  string
+	Version   int
+}
+
+type benchmarkShowUser struct {
+	ID        int
+	FirstName string
+	LastName  string
+	Email     string
+	Token     *string
+}
+
+var _benchmarkUser = benchmarkUser{
+	ID:        1,
+	FirstName: "John",
+	LastName:  "Smith",
+	Email:     "john@example.com",
+	Password:  "12345678",
+	Version:   1,
+}
+
+func BenchmarkOverwriteStruct(b *testing.B) {
+	columns := []string{"ID", "FirstName", "LastName", "Email"}
+
+	for range b.N {
+		var showUser benchmarkShowUser
+		OverwriteStruct(&showUser, _benchmarkUser, columns)
+	}
+}
+
+func BenchmarkTagged(b *testing.B) {
+	mapper := MustTagged[benchmarkUser, benchmarkShowUser]()
+
+	for range b.N {
+		_ = mapper.Map(_benchmarkUser)
+	}
+}
+
+func BenchmarkNewMapper(b *testing.B) {
+	mapper := NewMapper(
+		Field(func(u *benchmarkUser) int { return u.ID }, func(u *benchmarkShowUser) *int { return &u.ID }),
+		Field(func(u *benchmarkUser) string { return u.FirstName },
+			func(u *benchmarkShowUser) *string { return &u.FirstName }),
+		Field(func(u *benchmarkUser) string { return u.LastName },
+			func(u *benchmarkShowUser) *string { return &u.LastName }),
+		Field(func(u *benchmarkUser) string { return u.Email },
+			func(u *benchmarkShowUser) *string { return &u.Email }),
+	)
+
+	for range b.N {
+		_ = mapper.Map(_benchmarkUser)
+	}
+}
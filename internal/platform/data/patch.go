@@ -0,0 +1,132 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+// Patch is a field of a partial update, which tells a field missing from the request, which leaves the
+// destination as it is, from an explicit null, which clears it, and from a value, zero values included.
+// The zero Patch is absent, so a field missing from a JSON document is absent.
+type Patch[T any] struct {
+	value   T
+	present bool
+	null    bool
+}
+
+// Some returns a present value, applied even when it is the zero value.
+func Some[T any](value T) Patch[T] {
+	return Patch[T]{value: value, present: true}
+}
+
+// Null returns a present null.
+func Null[T any]() Patch[T] {
+	return Patch[T]{present: true, null: true}
+}
+
+// Get returns the value and whether it is present and not null.
+func (p Patch[T]) Get() (T, bool) {
+	return p.value, p.present && !p.null
+}
+
+// Present reports whether the field was sent, null included.
+func (p Patch[T]) Present() bool {
+	return p.present
+}
+
+// IsNull reports whether the field was sent as null.
+func (p Patch[T]) IsNull() bool {
+	return p.null
+}
+
+// Value returns the value, or nil when it is absent or null, for code that does not know T, like the
+// validator.
+func (p Patch[T]) Value() any {
+	if value, ok := p.Get(); ok {
+		return value
+	}
+
+	return nil
+}
+
+// Apply writes the patch to dst: an absent patch leaves it as it is and null writes the zero value.
+func (p Patch[T]) Apply(dst *T) {
+	if !p.present {
+		return
+	}
+
+	var zero T
+	if p.null {
+		*dst = zero
+
+		return
+	}
+
+	*dst = p.value
+}
+
+// ApplyPointer writes the patch to a nullable dst: null writes nil, and a value a pointer to a copy of it.
+func (p Patch[T]) ApplyPointer(dst **T) {
+	if !p.present {
+		return
+	}
+
+	if p.null {
+		*dst = nil
+
+		return
+	}
+
+	value := p.value
+	*dst = &value
+}
+
+func (p *Patch[T]) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(bytes.TrimSpace(b), []byte("null")) {
+		*p = Null[T]()
+
+		return nil
+	}
+
+	var value T
+	if err := json.Unmarshal(b, &value); err != nil {
+		return err
+	}
+
+	*p = Some(value)
+
+	return nil
+}
+
+// MarshalJSON writes null for an absent or null patch, as JSON cannot omit a struct by its value.
+func (p Patch[T]) MarshalJSON() ([]byte, error) {
+	value, ok := p.Get()
+	if !ok {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(value)
+}
+
+// patch is implemented by every Patch, so the tagged mappings tell them from plain values.
+type patch interface {
+	ValueType() reflect.Type
+	applyTo(dst reflect.Value)
+}
+
+// ValueType returns T, so code documenting or mapping patches by reflection knows what they hold.
+func (p Patch[T]) ValueType() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+// applyTo writes the patch to dst, which is a T or a *T.
+func (p Patch[T]) applyTo(dst reflect.Value) {
+	if dst.Kind() == reflect.Pointer && dst.Type().Elem() == p.ValueType() {
+		p.ApplyPointer(dst.Addr().Interface().(**T))
+
+		return
+	}
+
+	p.Apply(dst.Addr().Interface().(*T))
+}
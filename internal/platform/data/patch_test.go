@@ -0,0 +1,84 @@
+package data
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestPatchUnmarshalJSON_Successful(t *testing.T) {
+	// Given
+	var request struct {
+		FirstName Patch[string] `json:"first_name"`
+		LastName  Patch[string] `json:"last_name"`
+		Email     Patch[string] `json:"email"`
+		Age       Patch[int]    `json:"age"`
+	}
+
+	// When
+	err := json.Unmarshal([]byte(`{"last_name": null, "email": "", "age": 0}`), &request)
+
+	// Then
+	require.NoError(t, err)
+	require.False(t, request.FirstName.Present())
+	require.True(t, request.LastName.Present())
+	require.True(t, request.LastName.IsNull())
+	require.Equal(t, Some(""), request.Email)
+	require.Equal(t, Some(0), request.Age)
+}
+
+func TestPatchUnmarshalJSON_FailsDueToMismatchedType(t *testing.T) {
+	// Given
+	var request struct {
+		Age Patch[int] `json:"age"`
+	}
+
+	// When
+	err := json.Unmarshal([]byte(`{"age": "zero"}`), &request)
+
+	// Then
+	require.Error(t, err)
+}
+
+func TestPatchMarshalJSON_Successful(t *testing.T) {
+	// Given
+	response := struct {
+		FirstName Patch[string] `json:"first_name"`
+		LastName  Patch[string] `json:"last_name"`
+		Email     Patch[string] `json:"email"`
+	}{
+		LastName: Null[string](),
+		Email:    Some("john@example.com"),
+	}
+
+	// When
+	body, err := json.Marshal(response)
+
+	// Then
+	require.NoError(t, err)
+	require.JSONEq(t, `{"first_name": null, "last_name": null, "email": "john@example.com"}`, string(body))
+}
+
+func TestPatchApply_Successful(t *testing.T) {
+	// Given
+	name := "John"
+	nickname := &name
+
+	// When
+	Patch[string]{}.Apply(&name)
+	Patch[string]{}.ApplyPointer(&nickname)
+
+	// Then
+	require.Equal(t, "John", name)
+	require.Equal(t, &name, nickname)
+
+	// When
+	Some("").Apply(&name)
+	Null[string]().ApplyPointer(&nickname)
+
+	// Then
+	require.Empty(t, name)
+	require.Nil(t, nickname)
+	require.Nil(t, Null[string]().Value())
+	require.Equal(t, "Johnny", Some("Johnny").Value())
+}
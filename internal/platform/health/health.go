@@ -0,0 +1,112 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// StatusUp is reported when a component answered its check.
+	StatusUp = "up"
+
+	// StatusDown is reported when a component failed its check.
+	StatusDown = "down"
+
+	// _defaultCheckTimeout bounds how long a single component check can take.
+	_defaultCheckTimeout = 2 * time.Second
+)
+
+type Checker interface {
+	// Name of the component shown in the readiness report.
+	Name() string
+
+	// Required indicates if the application is not ready when the check fails.
+	Required() bool
+
+	// Check pings the component.
+	Check(ctx context.Context) error
+}
+
+type ComponentStatus struct {
+	Status    string  `json:"status"`
+	Required  bool    `json:"required"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Ready      bool                       `json:"ready"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+type Service interface {
+	// Readiness runs every registered check and reports per-component status.
+	Readiness(ctx context.Context) Report
+}
+
+type service struct {
+	timeout  time.Duration
+	checkers []Checker
+}
+
+func NewService(checkers ...Checker) Service {
+	return &service{
+		timeout:  _defaultCheckTimeout,
+		checkers: checkers,
+	}
+}
+
+func (s service) Readiness(ctx context.Context) Report {
+	report := Report{
+		Ready:      true,
+		Components: make(map[string]ComponentStatus, len(s.checkers)),
+	}
+
+	mutex := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+
+	for _, checker := range s.checkers {
+		wg.Add(1)
+
+		go func(checker Checker) {
+			defer wg.Done()
+
+			status := s.check(ctx, checker)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			report.Components[checker.Name()] = status
+			if status.Status == StatusDown && status.Required {
+				report.Ready = false
+			}
+		}(checker)
+	}
+
+	wg.Wait()
+
+	return report
+}
+
+func (s service) check(ctx context.Context, checker Checker) ComponentStatus {
+	checkCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := checker.Check(checkCtx)
+	latency := time.Since(start)
+
+	status := ComponentStatus{
+		Status:    StatusUp,
+		Required:  checker.Required(),
+		LatencyMS: float64(latency.Microseconds()) / 1000,
+	}
+
+	if err != nil {
+		status.Status = StatusDown
+		status.Error = err.Error()
+	}
+
+	return status
+}
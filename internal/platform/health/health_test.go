@@ -0,0 +1,135 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+type mockChecker struct {
+	mock.Mock
+}
+
+func (mc *mockChecker) Name() string {
+	args := mc.Called()
+	return args.String(0)
+}
+
+func (mc *mockChecker) Required() bool {
+	args := mc.Called()
+	return args.Bool(0)
+}
+
+func (mc *mockChecker) Check(ctx context.Context) error {
+	args := mc.Called(ctx)
+	return args.Error(0)
+}
+
+func newMockChecker(name string, required bool, err error) *mockChecker {
+	mc := new(mockChecker)
+	mc.On("Name").Return(name)
+	mc.On("Required").Return(required)
+	mc.On("Check", mock.Anything).Return(err)
+
+	return mc
+}
+
+func TestServiceReadiness_Successful(t *testing.T) {
+	// Given
+	mysqlChecker := newMockChecker("mysql", true, nil)
+	redisChecker := newMockChecker("redis", true, nil)
+
+	service := NewService(mysqlChecker, redisChecker)
+
+	// When
+	report := service.Readiness(context.Background())
+
+	// Then
+	require.True(t, report.Ready)
+	require.Len(t, report.Components, 2)
+	require.Equal(t, StatusUp, report.Components["mysql"].Status)
+	require.Equal(t, StatusUp, report.Components["redis"].Status)
+	require.Empty(t, report.Components["mysql"].Error)
+}
+
+func TestServiceReadiness_SuccessfulWithoutCheckers(t *testing.T) {
+	// Given
+	service := NewService()
+
+	// When
+	report := service.Readiness(context.Background())
+
+	// Then
+	require.True(t, report.Ready)
+	require.Empty(t, report.Components)
+}
+
+func TestServiceReadiness_SuccessfulWithOptionalComponentDown(t *testing.T) {
+	// Given
+	expectedError := errors.New("connection refused")
+	mysqlChecker := newMockChecker("mysql", true, nil)
+	redisChecker := newMockChecker("redis", false, expectedError)
+
+	service := NewService(mysqlChecker, redisChecker)
+
+	// When
+	report := service.Readiness(context.Background())
+
+	// Then
+	require.True(t, report.Ready)
+	require.Equal(t, StatusDown, report.Components["redis"].Status)
+	require.Equal(t, expectedError.Error(), report.Components["redis"].Error)
+	require.False(t, report.Components["redis"].Required)
+}
+
+func TestServiceReadiness_FailsDueToRequiredComponentDown(t *testing.T) {
+	// Given
+	expectedError := errors.New("connection refused")
+	mysqlChecker := newMockChecker("mysql", true, expectedError)
+	redisChecker := newMockChecker("redis", true, nil)
+
+	service := NewService(mysqlChecker, redisChecker)
+
+	// When
+	report := service.Readiness(context.Background())
+
+	// Then
+	require.False(t, report.Ready)
+	require.Equal(t, StatusDown, report.Components["mysql"].Status)
+	require.Equal(t, expectedError.Error(), report.Components["mysql"].Error)
+	require.Equal(t, StatusUp, report.Components["redis"].Status)
+}
+
+type blockingChecker struct{}
+
+func (bc blockingChecker) Name() string {
+	return "mysql"
+}
+
+func (bc blockingChecker) Required() bool {
+	return true
+}
+
+func (bc blockingChecker) Check(ctx context.Context) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestServiceReadiness_FailsDueToTimeout(t *testing.T) {
+	// Given
+	service := &service{
+		timeout:  10 * time.Millisecond,
+		checkers: []Checker{blockingChecker{}},
+	}
+
+	// When
+	report := service.Readiness(context.Background())
+
+	// Then
+	require.False(t, report.Ready)
+	require.Equal(t, StatusDown, report.Components["mysql"].Status)
+	require.Equal(t, context.DeadlineExceeded.Error(), report.Components["mysql"].Error)
+}
@@ -0,0 +1,291 @@
+// Package ical reads and writes the content lines of iCalendar (RFC 5545) files.
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ContentType is the media type of iCalendar files.
+const ContentType = "text/calendar"
+
+// _maxLineLength is the length in octets after which content lines are folded, without the CRLF.
+const _maxLineLength = 75
+
+// ErrInvalidLine is returned when a content line has no name or no value.
+var ErrInvalidLine = errors.New("invalid content line")
+
+// Property is a content line, like "DUE;VALUE=DATE:20240101". Names and params are upper case and params
+// keep their value unquoted. Value is as written, TEXT values must be unescaped with Unescape.
+type Property struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// Writer writes folded content lines ended by CRLF.
+type Writer struct {
+	w *bufio.Writer
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// WriteProperty writes the content line of the property, folding it when it is too long. Params are
+// written sorted by name.
+func (w *Writer) WriteProperty(property Property) error {
+	var line strings.Builder
+
+	line.WriteString(property.Name)
+
+	names := make([]string, 0, len(property.Params))
+	for name := range property.Params {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := property.Params[name]
+		if strings.ContainsAny(value, `;:,`) {
+			value = `"` + value + `"`
+		}
+
+		fmt.Fprintf(&line, ";%s=%s", name, value)
+	}
+
+	line.WriteString(":")
+	line.WriteString(property.Value)
+
+	return w.writeLine(line.String())
+}
+
+// WriteText writes a property of a TEXT value, escaping it.
+func (w *Writer) WriteText(name, text string) error {
+	return w.WriteProperty(Property{Name: name, Value: Escape(text)})
+}
+
+// Flush writes the buffered lines to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// writeLine folds the line every _maxLineLength octets, without splitting a character. Continuation lines
+// start with a space, which counts towards their length.
+func (w *Writer) writeLine(line string) error {
+	limit := _maxLineLength
+
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		if _, err := w.w.WriteString(line[:cut] + "\r\n "); err != nil {
+			return err
+		}
+
+		line = line[cut:]
+		limit = _maxLineLength - 1
+	}
+
+	_, err := w.w.WriteString(line + "\r\n")
+
+	return err
+}
+
+// Reader reads unfolded content lines, accepting lines ended by LF as well as CRLF.
+type Reader struct {
+	scanner *bufio.Scanner
+	scanned int // Physical lines scanned, the peeked one included.
+	line    int
+	next    string
+	peeked  bool
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{scanner: bufio.NewScanner(r)}
+}
+
+// Line returns the number of the physical line the last property started at.
+func (r *Reader) Line() int {
+	return r.line
+}
+
+// ReadProperty returns the next property, skipping blank lines, and io.EOF after the last one. A malformed
+// line returns ErrInvalidLine, and reading can go on with the next one.
+func (r *Reader) ReadProperty() (Property, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return Property{}, err
+	}
+
+	property, err := parseProperty(line)
+	if err != nil {
+		return Property{}, fmt.Errorf("line %d: %w", r.line, err)
+	}
+
+	return property, nil
+}
+
+// readLine returns the next logical line, joining the physical lines folded into it.
+func (r *Reader) readLine() (string, error) {
+	var line strings.Builder
+
+	for {
+		physical, ok, err := r.peek()
+		if err != nil {
+			return "", err
+		}
+
+		if !ok {
+			if line.Len() == 0 {
+				return "", io.EOF
+			}
+
+			return line.String(), nil
+		}
+
+		folded := strings.HasPrefix(physical, " ") || strings.HasPrefix(physical, "\t")
+
+		switch {
+		case line.Len() == 0 && strings.TrimSpace(physical) == "":
+			r.peeked = false
+		case line.Len() == 0:
+			r.peeked = false
+			r.line = r.scanned
+			line.WriteString(strings.TrimLeft(physical, " \t"))
+		case folded:
+			r.peeked = false
+			line.WriteString(physical[1:])
+		default:
+			return line.String(), nil
+		}
+	}
+}
+
+// peek returns the next physical line without consuming it, reporting false after the last one.
+func (r *Reader) peek() (string, bool, error) {
+	if r.peeked {
+		return r.next, true, nil
+	}
+
+	if !r.scanner.Scan() {
+		return "", false, r.scanner.Err()
+	}
+
+	r.next = strings.TrimSuffix(r.scanner.Text(), "\r")
+	r.peeked = true
+	r.scanned++
+
+	return r.next, true, nil
+}
+
+// parseProperty parses a content line: a name, params separated by semicolons, and the value after the
+// first colon that is not inside a quoted param value.
+func parseProperty(line string) (Property, error) {
+	inQuotes := false
+	colon := -1
+
+	for i, char := range line {
+		if char == '"' {
+			inQuotes = !inQuotes
+		}
+
+		if char == ':' && !inQuotes {
+			colon = i
+
+			break
+		}
+	}
+
+	if colon < 0 {
+		return Property{}, fmt.Errorf("%w: %q has no value", ErrInvalidLine, line)
+	}
+
+	parts := splitParams(line[:colon])
+
+	property := Property{Name: strings.ToUpper(parts[0]), Value: line[colon+1:]}
+	if property.Name == "" {
+		return Property{}, fmt.Errorf("%w: %q has no name", ErrInvalidLine, line)
+	}
+
+	for _, param := range parts[1:] {
+		name, value, ok := strings.Cut(param, "=")
+		if !ok || name == "" {
+			return Property{}, fmt.Errorf("%w: %q has an invalid param %q", ErrInvalidLine, line, param)
+		}
+
+		if property.Params == nil {
+			property.Params = make(map[string]string)
+		}
+
+		property.Params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+	}
+
+	return property, nil
+}
+
+// splitParams splits the name and the params of a content line at the semicolons outside quotes.
+func splitParams(s string) []string {
+	parts := make([]string, 0, 1)
+	inQuotes := false
+	start := 0
+
+	for i, char := range s {
+		switch {
+		case char == '"':
+			inQuotes = !inQuotes
+		case char == ';' && !inQuotes:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+// _escaper escapes TEXT values. Carriage returns are dropped, as new lines are written as \n.
+var _escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", "")
+
+// Escape escapes a TEXT value.
+func Escape(text string) string {
+	return _escaper.Replace(text)
+}
+
+// Unescape reverts Escape, keeping a backslash that does not escape anything.
+func Unescape(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+
+	var text strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i+1 == len(value) {
+			text.WriteByte(value[i])
+
+			continue
+		}
+
+		switch next := value[i+1]; next {
+		case 'n', 'N':
+			text.WriteByte('\n')
+		case '\\', ';', ',':
+			text.WriteByte(next)
+		default:
+			text.WriteByte('\\')
+			text.WriteByte(next)
+		}
+
+		i++
+	}
+
+	return text.String()
+}
@@ -0,0 +1,162 @@
+package ical
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriter_Successful(t *testing.T) {
+	// Given
+	var buffer bytes.Buffer
+
+	writer := NewWriter(&buffer)
+
+	// When
+	require.NoError(t, writer.WriteProperty(Property{Name: "BEGIN", Value: "VTODO"}))
+	require.NoError(t, writer.WriteProperty(Property{
+		Name:   "DUE",
+		Params: map[string]string{"VALUE": "DATE", "X-NOTE": "a;b"},
+		Value:  "20240101",
+	}))
+	require.NoError(t, writer.WriteText("SUMMARY", "Buy milk, eggs; bread\nand a \\ slash"))
+	require.NoError(t, writer.Flush())
+
+	// Then
+	require.Equal(t, "BEGIN:VTODO\r\n"+
+		"DUE;VALUE=DATE;X-NOTE=\"a;b\":20240101\r\n"+
+		"SUMMARY:Buy milk\\, eggs\\; bread\\nand a \\\\ slash\r\n", buffer.String())
+}
+
+func TestWriter_SuccessfulFoldsLongLines(t *testing.T) {
+	// Given
+	var buffer bytes.Buffer
+
+	writer := NewWriter(&buffer)
+	description := strings.Repeat("ñ", 100)
+
+	// When
+	require.NoError(t, writer.WriteText("DESCRIPTION", description))
+	require.NoError(t, writer.Flush())
+
+	// Then
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\r\n"), "\r\n")
+	require.Len(t, lines, 3)
+
+	for i, line := range lines {
+		require.LessOrEqual(t, len(line), _maxLineLength)
+		require.True(t, strings.ToValidUTF8(line, "") == line, "line %d splits a character", i)
+
+		if i > 0 {
+			require.True(t, strings.HasPrefix(line, " "))
+		}
+	}
+
+	property, err := NewReader(&buffer).ReadProperty()
+	require.NoError(t, err)
+	require.Equal(t, description, Unescape(property.Value))
+}
+
+func TestReader_Successful(t *testing.T) {
+	// Given
+	reader := NewReader(strings.NewReader("BEGIN:VCALENDAR\r\n" +
+		"\r\n" +
+		"summary:Lorem\r\n" +
+		"  ipsum\n" +
+		"DUE;VALUE=DATE;TZID=\"America/Bogota\":20240101\n" +
+		"URL:https://example.com\r\n"))
+
+	// When
+	properties := make([]Property, 0)
+	lines := make([]int, 0)
+
+	for {
+		property, err := reader.ReadProperty()
+		if err == io.EOF {
+			break
+		}
+
+		require.NoError(t, err)
+
+		properties = append(properties, property)
+		lines = append(lines, reader.Line())
+	}
+
+	// Then
+	require.Equal(t, []Property{
+		{Name: "BEGIN", Value: "VCALENDAR"},
+		{Name: "SUMMARY", Value: "Lorem ipsum"},
+		{Name: "DUE", Params: map[string]string{"VALUE": "DATE", "TZID": "America/Bogota"}, Value: "20240101"},
+		{Name: "URL", Value: "https://example.com"},
+	}, properties)
+	require.Equal(t, []int{1, 3, 5, 6}, lines)
+}
+
+func TestReader_FailsDueToInvalidLine(t *testing.T) {
+	tests := map[string]struct {
+		line          string
+		expectedError string
+	}{
+		"WithoutValue": {
+			line:          "SUMMARY",
+			expectedError: `line 2: invalid content line: "SUMMARY" has no value`,
+		},
+		"WithoutName": {
+			line:          ":Lorem",
+			expectedError: `line 2: invalid content line: ":Lorem" has no name`,
+		},
+		"WithInvalidParam": {
+			line:          "DUE;VALUE:20240101",
+			expectedError: `line 2: invalid content line: "DUE;VALUE:20240101" has an invalid param "VALUE"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			reader := NewReader(strings.NewReader("BEGIN:VTODO\n" + test.line + "\nEND:VTODO\n"))
+
+			_, err := reader.ReadProperty()
+			require.NoError(t, err)
+
+			// When
+			_, err = reader.ReadProperty()
+
+			// Then
+			require.ErrorIs(t, err, ErrInvalidLine)
+			require.EqualError(t, err, test.expectedError)
+
+			property, err := reader.ReadProperty()
+			require.NoError(t, err)
+			require.Equal(t, Property{Name: "END", Value: "VTODO"}, property)
+		})
+	}
+}
+
+func TestUnescape_Successful(t *testing.T) {
+	tests := map[string]struct {
+		value        string
+		expectedText string
+	}{
+		"Plain":         {value: "Lorem ipsum", expectedText: "Lorem ipsum"},
+		"Escaped":       {value: `a\, b\; c\\d\ne\Nf`, expectedText: "a, b; c\\d\ne\nf"},
+		"UnknownEscape": {value: `a\tb`, expectedText: `a\tb`},
+		"TrailingSlash": {value: `a\`, expectedText: `a\`},
+		"EscapeRoundTrip": {
+			value:        Escape("Lorem, ipsum;\r\ndolor \\ sit"),
+			expectedText: "Lorem, ipsum;\ndolor \\ sit",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// When
+			text := Unescape(test.value)
+
+			// Then
+			require.Equal(t, test.expectedText, text)
+		})
+	}
+}
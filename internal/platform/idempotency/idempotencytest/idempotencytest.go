@@ -0,0 +1,155 @@
+// Package idempotencytest provides a conformance suite that every idempotency.Repository
+// implementation must pass.
+package idempotencytest
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/idempotency"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+	"time"
+)
+
+// RepositoryFactory creates an empty repository for every test.
+type RepositoryFactory func(t *testing.T) idempotency.Repository
+
+// RunRepositoryTests runs the conformance suite against the repositories created by newRepository.
+func RunRepositoryTests(t *testing.T, newRepository RepositoryFactory) {
+	t.Run("ReserveUnknownKey", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+
+		// When
+		record, reserved, err := repository.Reserve(context.Background(), "key", "hash", time.Minute)
+
+		// Then
+		require.NoError(t, err)
+		require.True(t, reserved)
+		require.Equal(t, idempotency.Record{RequestHash: "hash", InFlight: true}, record)
+	})
+
+	t.Run("ReserveInFlightKey", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		_, _, err := repository.Reserve(ctx, "key", "hash", time.Minute)
+		require.NoError(t, err)
+
+		// When
+		record, reserved, err := repository.Reserve(ctx, "key", "other_hash", time.Minute)
+
+		// Then
+		require.NoError(t, err)
+		require.False(t, reserved)
+		require.Equal(t, idempotency.Record{RequestHash: "hash", InFlight: true}, record)
+	})
+
+	t.Run("ReserveSavedKey", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		savedRecord := idempotency.Record{
+			RequestHash: "hash",
+			StatusCode:  201,
+			ContentType: "application/json",
+			Body:        []byte(`{"id":1}`),
+		}
+
+		_, _, err := repository.Reserve(ctx, "key", "hash", time.Minute)
+		require.NoError(t, err)
+
+		err = repository.Save(ctx, "key", savedRecord, time.Hour)
+		require.NoError(t, err)
+
+		// When
+		record, reserved, err := repository.Reserve(ctx, "key", "hash", time.Minute)
+
+		// Then
+		require.NoError(t, err)
+		require.False(t, reserved)
+		require.Equal(t, savedRecord, record)
+	})
+
+	t.Run("ReserveReleasedKey", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		_, _, err := repository.Reserve(ctx, "key", "hash", time.Minute)
+		require.NoError(t, err)
+
+		err = repository.Release(ctx, "key")
+		require.NoError(t, err)
+
+		// When
+		_, reserved, err := repository.Reserve(ctx, "key", "other_hash", time.Minute)
+
+		// Then
+		require.NoError(t, err)
+		require.True(t, reserved)
+	})
+
+	t.Run("ReserveExpiredKey", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		_, _, err := repository.Reserve(ctx, "key", "hash", 50*time.Millisecond)
+		require.NoError(t, err)
+
+		time.Sleep(100 * time.Millisecond)
+
+		// When
+		_, reserved, err := repository.Reserve(ctx, "key", "hash", time.Minute)
+
+		// Then
+		require.NoError(t, err)
+		require.True(t, reserved)
+	})
+
+	t.Run("ConcurrentReservesOfSameKey", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		const reserves = 10
+
+		wg := &sync.WaitGroup{}
+		results := make(chan bool, reserves)
+		errs := make(chan error, reserves)
+
+		// When
+		for range reserves {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				_, reserved, err := repository.Reserve(ctx, "key", "hash", time.Minute)
+				errs <- err
+				results <- reserved
+			}()
+		}
+
+		wg.Wait()
+		close(results)
+		close(errs)
+
+		// Then
+		for err := range errs {
+			require.NoError(t, err)
+		}
+
+		succeeded := 0
+		for reserved := range results {
+			if reserved {
+				succeeded++
+			}
+		}
+
+		require.Equal(t, 1, succeeded)
+	})
+}
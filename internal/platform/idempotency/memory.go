@@ -0,0 +1,77 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryRecord struct {
+	record    Record
+	expiresAt time.Time
+}
+
+type memoryRepository struct {
+	mutex   *sync.Mutex
+	records map[string]memoryRecord
+}
+
+// NewMemoryRepository creates a thread-safe Repository that keeps records in memory. It behaves like
+// the Redis Repository, so it can replace it in development and tests.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{
+		mutex:   &sync.Mutex{},
+		records: make(map[string]memoryRecord),
+	}
+}
+
+func (r *memoryRepository) Reserve(
+	_ context.Context,
+	key string,
+	requestHash string,
+	ttl time.Duration) (Record, bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+
+	if stored, ok := r.records[key]; ok && now.Before(stored.expiresAt) {
+		return stored.record, false, nil
+	}
+
+	// Expired records are dropped when a new key is reserved, memory storage only serves development.
+	r.removeExpired(now)
+
+	inFlight := Record{RequestHash: requestHash, InFlight: true}
+	r.records[key] = memoryRecord{record: inFlight, expiresAt: now.Add(ttl)}
+
+	return inFlight, true, nil
+}
+
+func (r *memoryRepository) Save(_ context.Context, key string, record Record, ttl time.Duration) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	record.Body = append([]byte(nil), record.Body...)
+	r.records[key] = memoryRecord{record: record, expiresAt: time.Now().Add(ttl)}
+
+	return nil
+}
+
+func (r *memoryRepository) Release(_ context.Context, key string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.records, key)
+
+	return nil
+}
+
+// removeExpired must be called holding the mutex.
+func (r *memoryRepository) removeExpired(now time.Time) {
+	for key, stored := range r.records {
+		if !now.Before(stored.expiresAt) {
+			delete(r.records, key)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+package idempotency_test
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/idempotency"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/idempotency/idempotencytest"
+	"testing"
+)
+
+func TestMemoryRepository_Conformance(t *testing.T) {
+	idempotencytest.RunRepositoryTests(t, func(t *testing.T) idempotency.Repository {
+		return idempotency.NewMemoryRepository()
+	})
+}
@@ -0,0 +1,98 @@
+// Package idempotency stores the responses of requests sent with an Idempotency-Key, so retries of a
+// request are answered without running it again.
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// Record is what is known about a key: the hash of the request that first used it and, once that
+// request finished, its response.
+type Record struct {
+	RequestHash string `json:"request_hash"`
+	InFlight    bool   `json:"in_flight"`
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+type Repository interface {
+	// Reserve stores an in-flight Record of the key for ttl when the key is unknown, and reports if it
+	// did. Otherwise, the existing Record is returned.
+	Reserve(ctx context.Context, key string, requestHash string, ttl time.Duration) (Record, bool, error)
+
+	// Save the finished Record of the key, replacing the in-flight one, for ttl.
+	Save(ctx context.Context, key string, record Record, ttl time.Duration) error
+
+	// Release forgets the key, so the request can be retried.
+	Release(ctx context.Context, key string) error
+}
+
+type repository struct {
+	conn *redis.Client
+}
+
+func NewRepository(conn *redis.Client) Repository {
+	return &repository{conn: conn}
+}
+
+func (r repository) Reserve(
+	ctx context.Context,
+	key string,
+	requestHash string,
+	ttl time.Duration) (Record, bool, error) {
+	inFlight := Record{RequestHash: requestHash, InFlight: true}
+
+	value, err := json.Marshal(inFlight)
+	if err != nil {
+		return Record{}, false, err
+	}
+
+	reserved, err := r.conn.SetNX(ctx, redisKey(key), value, ttl).Result()
+	if err != nil {
+		return Record{}, false, err
+	}
+
+	if reserved {
+		return inFlight, true, nil
+	}
+
+	stored, err := r.conn.Get(ctx, redisKey(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// The key expired after SETNX, the caller retries it as if it was in flight.
+		return inFlight, false, nil
+	}
+
+	if err != nil {
+		return Record{}, false, err
+	}
+
+	var record Record
+	if err := json.Unmarshal(stored, &record); err != nil {
+		return Record{}, false, err
+	}
+
+	return record, false, nil
+}
+
+func (r repository) Save(ctx context.Context, key string, record Record, ttl time.Duration) error {
+	value, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	return r.conn.Set(ctx, redisKey(key), value, ttl).Err()
+}
+
+func (r repository) Release(ctx context.Context, key string) error {
+	return r.conn.Del(ctx, redisKey(key)).Err()
+}
+
+func redisKey(key string) string {
+	return fmt.Sprintf("idempotency:%s", key)
+}
@@ -0,0 +1,20 @@
+package idempotency_test
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/idempotency"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/idempotency/idempotencytest"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/redis/redistest"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRepository_Conformance(t *testing.T) {
+	client := redistest.NewConnection(t)
+
+	idempotencytest.RunRepositoryTests(t, func(t *testing.T) idempotency.Repository {
+		require.NoError(t, client.FlushDB(context.Background()).Err())
+
+		return idempotency.NewRepository(client)
+	})
+}
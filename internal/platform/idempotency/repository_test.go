@@ -0,0 +1,102 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestRepositoryReserve_Successful(t *testing.T) {
+	// Given
+	db, mock := redismock.NewClientMock()
+
+	mock.ExpectSetNX("idempotency:key", []byte(`{"request_hash":"hash","in_flight":true}`), time.Minute).
+		SetVal(true)
+
+	repository := NewRepository(db)
+
+	// When
+	record, reserved, err := repository.Reserve(context.Background(), "key", "hash", time.Minute)
+
+	// Then
+	require.NoError(t, err)
+	require.True(t, reserved)
+	require.Equal(t, Record{RequestHash: "hash", InFlight: true}, record)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepositoryReserve_ReturnsExistingRecord(t *testing.T) {
+	// Given
+	db, mock := redismock.NewClientMock()
+
+	mock.ExpectSetNX("idempotency:key", []byte(`{"request_hash":"hash","in_flight":true}`), time.Minute).
+		SetVal(false)
+	mock.ExpectGet("idempotency:key").
+		SetVal(`{"request_hash":"hash","in_flight":false,"status_code":201,"body":"eyJpZCI6MX0="}`)
+
+	repository := NewRepository(db)
+
+	// When
+	record, reserved, err := repository.Reserve(context.Background(), "key", "hash", time.Minute)
+
+	// Then
+	require.NoError(t, err)
+	require.False(t, reserved)
+	require.Equal(t, Record{RequestHash: "hash", StatusCode: 201, Body: []byte(`{"id":1}`)}, record)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepositoryReserve_FailsDueToRedisError(t *testing.T) {
+	// Given
+	db, mock := redismock.NewClientMock()
+
+	expectedErr := errors.New("connection refused")
+
+	mock.ExpectSetNX("idempotency:key", []byte(`{"request_hash":"hash","in_flight":true}`), time.Minute).
+		SetErr(expectedErr)
+
+	repository := NewRepository(db)
+
+	// When
+	_, reserved, err := repository.Reserve(context.Background(), "key", "hash", time.Minute)
+
+	// Then
+	require.ErrorIs(t, err, expectedErr)
+	require.False(t, reserved)
+}
+
+func TestRepositorySave_Successful(t *testing.T) {
+	// Given
+	db, mock := redismock.NewClientMock()
+
+	mock.ExpectSet("idempotency:key", []byte(`{"request_hash":"hash","in_flight":false,"status_code":201}`), time.Hour).
+		SetVal("OK")
+
+	repository := NewRepository(db)
+
+	// When
+	err := repository.Save(context.Background(), "key", Record{RequestHash: "hash", StatusCode: 201}, time.Hour)
+
+	// Then
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepositoryRelease_Successful(t *testing.T) {
+	// Given
+	db, mock := redismock.NewClientMock()
+
+	mock.ExpectDel("idempotency:key").SetVal(1)
+
+	repository := NewRepository(db)
+
+	// When
+	err := repository.Release(context.Background(), "key")
+
+	// Then
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,173 @@
+// Package jobs runs work out of the request path. Jobs are queued in Redis, or in memory when it is not
+// available, and run by a Worker that retries the failed ones with backoff, keeps the ones out of
+// attempts as dead, and queues the scheduled ones on the times of their cron spec.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/robfig/cron/v3"
+	"time"
+)
+
+// ErrInvalidSchedule is returned when the cron spec of a Schedule can not be parsed.
+var ErrInvalidSchedule = errors.New("invalid schedule")
+
+// Job is a unit of work of a type, whose payload is its JSON encoded input.
+type Job struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`     // Failed attempts so far.
+	MaxAttempts int             `json:"max_attempts"` // Zero takes the default of the Worker.
+	LastError   string          `json:"last_error,omitempty"`
+	RunAt       time.Time       `json:"run_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+
+	// lease identifies the job in its Queue from when it is dequeued until it is acknowledged.
+	lease string
+}
+
+// Option customizes an enqueued job.
+type Option func(job *Job)
+
+// Delay runs the job after the delay, instead of right away.
+func Delay(delay time.Duration) Option {
+	return func(job *Job) {
+		job.RunAt = job.RunAt.Add(delay)
+	}
+}
+
+// MaxAttempts overrides the attempts of the job before it is dead.
+func MaxAttempts(attempts int) Option {
+	return func(job *Job) {
+		job.MaxAttempts = attempts
+	}
+}
+
+// Type is a type of job whose payload is P. Declaring a type once keeps the enqueued payloads and their
+// handler in agreement:
+//
+//	var SendEmail = jobs.Type[Email]("email.send")
+type Type[P any] string
+
+// Enqueue a job of the type with the payload.
+func (t Type[P]) Enqueue(ctx context.Context, queue Queue, payload P, options ...Option) error {
+	job, err := t.newJob(payload, options...)
+	if err != nil {
+		return err
+	}
+
+	return queue.Enqueue(ctx, job)
+}
+
+// Handler returns the Handler running handle with the decoded payload of the jobs of the type. Payloads
+// that can not be decoded fail without retries.
+func (t Type[P]) Handler(handle func(ctx context.Context, payload P) error) Handler {
+	return Handler{
+		jobType: string(t),
+		handle: func(ctx context.Context, data json.RawMessage) error {
+			var payload P
+			if err := json.Unmarshal(data, &payload); err != nil {
+				return Permanent(err)
+			}
+
+			return handle(ctx, payload)
+		},
+	}
+}
+
+// Schedule returns the Schedule queueing a job of the type with the payload on every time of the spec.
+// The spec has the five fields "minute hour day-of-month month day-of-week", or is a descriptor like
+// "@daily" or "@every 5m", in UTC.
+func (t Type[P]) Schedule(spec string, payload P) (Schedule, error) {
+	parsed, err := cron.ParseStandard(spec)
+	if err != nil {
+		return Schedule{}, fmt.Errorf("%w %q: %s", ErrInvalidSchedule, spec, err)
+	}
+
+	return Schedule{
+		Name:     string(t) + " " + spec,
+		schedule: parsed,
+		newJob: func() (Job, error) {
+			return t.newJob(payload)
+		},
+	}, nil
+}
+
+func (t Type[P]) newJob(payload P, options ...Option) (Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Job{}, err
+	}
+
+	now := time.Now().UTC()
+
+	job := Job{
+		ID:        utils.UUIDv4(),
+		Type:      string(t),
+		Payload:   data,
+		RunAt:     now,
+		CreatedAt: now,
+	}
+
+	for _, option := range options {
+		option(&job)
+	}
+
+	return job, nil
+}
+
+// Handler runs the jobs of a type, it is created by Type.Handler.
+type Handler struct {
+	jobType string
+	handle  func(ctx context.Context, payload json.RawMessage) error
+}
+
+// Schedule queues a job on every time of a cron spec, it is created by Type.Schedule.
+type Schedule struct {
+	Name string // The type and spec, identifying the times of the schedule between workers.
+
+	schedule cron.Schedule
+	newJob   func() (Job, error)
+}
+
+// next returns the first time of the schedule after the time, zero when there is none. Intervals are
+// aligned to the zero time, so every worker agrees on the times.
+func (s Schedule) next(after time.Time) time.Time {
+	after = after.UTC()
+
+	if every, ok := s.schedule.(cron.ConstantDelaySchedule); ok {
+		return after.Truncate(every.Delay).Add(every.Delay)
+	}
+
+	return s.schedule.Next(after)
+}
+
+// permanentError is a failure that retrying does not fix.
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e permanentError) Unwrap() error {
+	return e.err
+}
+
+// Permanent marks err as a failure that retrying does not fix, so its job is dead right away.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked by Permanent.
+func IsPermanent(err error) bool {
+	var permanent permanentError
+
+	return errors.As(err, &permanent)
+}
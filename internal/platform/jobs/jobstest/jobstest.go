@@ -0,0 +1,263 @@
+// Package jobstest provides a conformance suite that every jobs.Queue implementation must pass.
+package jobstest
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/jobs"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const (
+	// _lease is long enough for the jobs not to be dequeued again during a test.
+	_lease = time.Minute
+
+	// _timeout bounds waiting for a job.
+	_timeout = 5 * time.Second
+)
+
+// QueueFactory creates an empty queue for every test.
+type QueueFactory func(t *testing.T) jobs.Queue
+
+// RunQueueTests runs the conformance suite against the queues created by newQueue.
+func RunQueueTests(t *testing.T, newQueue QueueFactory) {
+	t.Run("DequeuesEnqueuedJob", func(t *testing.T) {
+		// Given
+		queue := newQueue(t)
+		ctx := context.Background()
+
+		job := newJob("1", time.Now())
+		require.NoError(t, queue.Enqueue(ctx, job))
+
+		// When
+		dequeued := dequeue(t, queue, _lease)
+
+		// Then
+		require.Equal(t, job.ID, dequeued.ID)
+		require.Equal(t, job.Type, dequeued.Type)
+		require.JSONEq(t, string(job.Payload), string(dequeued.Payload))
+		require.Equal(t, job.MaxAttempts, dequeued.MaxAttempts)
+		require.WithinDuration(t, job.RunAt, dequeued.RunAt, time.Millisecond)
+	})
+
+	t.Run("DequeuesEarliestJobFirst", func(t *testing.T) {
+		// Given
+		queue := newQueue(t)
+		ctx := context.Background()
+		now := time.Now()
+
+		require.NoError(t, queue.Enqueue(ctx, newJob("later", now.Add(-time.Second))))
+		require.NoError(t, queue.Enqueue(ctx, newJob("earlier", now.Add(-time.Minute))))
+
+		// When
+		first := dequeue(t, queue, _lease)
+		second := dequeue(t, queue, _lease)
+
+		// Then
+		require.Equal(t, "earlier", first.ID)
+		require.Equal(t, "later", second.ID)
+	})
+
+	t.Run("DelayedJobIsNotDequeuedBeforeItsTime", func(t *testing.T) {
+		// Given
+		queue := newQueue(t)
+		ctx := context.Background()
+
+		require.NoError(t, queue.Enqueue(ctx, newJob("1", time.Now().Add(time.Hour))))
+
+		dequeueCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+		defer cancel()
+
+		// When
+		_, err := queue.Dequeue(dequeueCtx, _lease)
+
+		// Then
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("DequeueWaitsForEnqueuedJob", func(t *testing.T) {
+		// Given
+		queue := newQueue(t)
+		ctx := context.Background()
+
+		go func() {
+			time.Sleep(300 * time.Millisecond)
+			_ = queue.Enqueue(ctx, newJob("1", time.Now()))
+		}()
+
+		// When
+		dequeued := dequeue(t, queue, _lease)
+
+		// Then
+		require.Equal(t, "1", dequeued.ID)
+	})
+
+	t.Run("LeasedJobIsNotDequeuedTwice", func(t *testing.T) {
+		// Given
+		queue := newQueue(t)
+		ctx := context.Background()
+
+		require.NoError(t, queue.Enqueue(ctx, newJob("1", time.Now())))
+		dequeue(t, queue, _lease)
+
+		dequeueCtx, cancel := context.WithTimeout(ctx, 500*time.Millisecond)
+		defer cancel()
+
+		// When
+		_, err := queue.Dequeue(dequeueCtx, _lease)
+
+		// Then
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("JobIsDequeuedAgainWhenItsLeaseEnds", func(t *testing.T) {
+		// Given
+		queue := newQueue(t)
+		ctx := context.Background()
+
+		require.NoError(t, queue.Enqueue(ctx, newJob("1", time.Now())))
+		expired := dequeue(t, queue, 100*time.Millisecond)
+
+		// When
+		dequeued := dequeue(t, queue, _lease)
+
+		// Then
+		require.Equal(t, expired.ID, dequeued.ID)
+
+		// The acknowledgment of the expired lease does not release the new one.
+		require.NoError(t, queue.Ack(ctx, expired))
+		requireEmpty(t, queue, 300*time.Millisecond)
+	})
+
+	t.Run("AcknowledgedJobIsNotDequeuedAgain", func(t *testing.T) {
+		// Given
+		queue := newQueue(t)
+		ctx := context.Background()
+
+		require.NoError(t, queue.Enqueue(ctx, newJob("1", time.Now())))
+		dequeued := dequeue(t, queue, 100*time.Millisecond)
+
+		// When
+		err := queue.Ack(ctx, dequeued)
+
+		// Then
+		require.NoError(t, err)
+		requireEmpty(t, queue, 500*time.Millisecond)
+	})
+
+	t.Run("BuriedJobsAreDeadFromTheNewest", func(t *testing.T) {
+		// Given
+		queue := newQueue(t)
+		ctx := context.Background()
+
+		for _, id := range []string{"1", "2", "3"} {
+			job := newJob(id, time.Now())
+			job.Attempts = 5
+			job.LastError = "failed " + id
+
+			require.NoError(t, queue.Bury(ctx, job))
+		}
+
+		// When
+		dead, err := queue.Dead(ctx, 2)
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, dead, 2)
+		require.Equal(t, "3", dead[0].ID)
+		require.Equal(t, "failed 3", dead[0].LastError)
+		require.Equal(t, 5, dead[0].Attempts)
+		require.Equal(t, "2", dead[1].ID)
+
+		requireEmpty(t, queue, 300*time.Millisecond)
+	})
+
+	t.Run("DeadIsEmptyWithoutBuriedJobs", func(t *testing.T) {
+		// Given
+		queue := newQueue(t)
+
+		// When
+		dead, err := queue.Dead(context.Background(), 10)
+
+		// Then
+		require.NoError(t, err)
+		require.Empty(t, dead)
+	})
+
+	t.Run("KeyIsClaimedOnce", func(t *testing.T) {
+		// Given
+		queue := newQueue(t)
+		ctx := context.Background()
+
+		// When
+		first, err := queue.Claim(ctx, "schedule@1", time.Minute)
+		require.NoError(t, err)
+
+		second, err := queue.Claim(ctx, "schedule@1", time.Minute)
+		require.NoError(t, err)
+
+		other, err := queue.Claim(ctx, "schedule@2", time.Minute)
+		require.NoError(t, err)
+
+		// Then
+		require.True(t, first)
+		require.False(t, second)
+		require.True(t, other)
+	})
+
+	t.Run("KeyIsClaimedAgainAfterItsTTL", func(t *testing.T) {
+		// Given
+		queue := newQueue(t)
+		ctx := context.Background()
+
+		claimed, err := queue.Claim(ctx, "schedule@1", 100*time.Millisecond)
+		require.NoError(t, err)
+		require.True(t, claimed)
+
+		time.Sleep(200 * time.Millisecond)
+
+		// When
+		claimed, err = queue.Claim(ctx, "schedule@1", time.Minute)
+
+		// Then
+		require.NoError(t, err)
+		require.True(t, claimed)
+	})
+}
+
+func newJob(id string, runAt time.Time) jobs.Job {
+	return jobs.Job{
+		ID:          id,
+		Type:        "test.job",
+		Payload:     json.RawMessage(`{"id":"` + id + `"}`),
+		MaxAttempts: 3,
+		RunAt:       runAt.UTC(),
+		CreatedAt:   runAt.UTC(),
+	}
+}
+
+// dequeue the next job of the queue, failing when there is none in time.
+func dequeue(t *testing.T, queue jobs.Queue, lease time.Duration) jobs.Job {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), _timeout)
+	defer cancel()
+
+	job, err := queue.Dequeue(ctx, lease)
+	require.NoError(t, err)
+
+	return job
+}
+
+// requireEmpty fails when a job is dequeued in the wait.
+func requireEmpty(t *testing.T, queue jobs.Queue, wait time.Duration) {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), wait)
+	defer cancel()
+
+	job, err := queue.Dequeue(ctx, _lease)
+	require.ErrorIs(t, err, context.DeadlineExceeded, "dequeued job %q", job.ID)
+}
@@ -0,0 +1,160 @@
+package jobs
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2/utils"
+	"sort"
+	"sync"
+	"time"
+)
+
+type leasedJob struct {
+	job      Job
+	deadline time.Time
+}
+
+type memoryQueue struct {
+	mutex   *sync.Mutex
+	wake    chan struct{}
+	pending []Job // Sorted by RunAt, from the earliest.
+	leased  map[string]leasedJob
+	dead    []Job // From the newest.
+	claims  map[string]time.Time
+}
+
+// NewMemoryQueue creates a Queue that keeps the jobs in this instance only, so they are lost on
+// restarts. It replaces the Redis Queue when there is a single instance.
+func NewMemoryQueue() Queue {
+	return &memoryQueue{
+		mutex:  &sync.Mutex{},
+		wake:   make(chan struct{}, 1),
+		leased: make(map[string]leasedJob),
+		claims: make(map[string]time.Time),
+	}
+}
+
+func (q *memoryQueue) Enqueue(_ context.Context, job Job) error {
+	q.mutex.Lock()
+	q.push(job)
+	q.mutex.Unlock()
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+func (q *memoryQueue) Dequeue(ctx context.Context, lease time.Duration) (Job, error) {
+	for {
+		if job, ok := q.pop(lease); ok {
+			return job, nil
+		}
+
+		timer := time.NewTimer(_pollInterval)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return Job{}, ctx.Err()
+		case <-q.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+func (q *memoryQueue) Ack(_ context.Context, job Job) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	delete(q.leased, job.lease)
+
+	return nil
+}
+
+func (q *memoryQueue) Bury(_ context.Context, job Job) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	job.lease = ""
+
+	q.dead = append([]Job{job}, q.dead...)
+	if len(q.dead) > _deadSize {
+		q.dead = q.dead[:_deadSize]
+	}
+
+	return nil
+}
+
+func (q *memoryQueue) Dead(_ context.Context, limit int) ([]Job, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	dead := make([]Job, 0, min(limit, len(q.dead)))
+	dead = append(dead, q.dead[:min(limit, len(q.dead))]...)
+
+	return dead, nil
+}
+
+func (q *memoryQueue) Claim(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	now := time.Now()
+
+	for claimed, expiration := range q.claims {
+		if !now.Before(expiration) {
+			delete(q.claims, claimed)
+		}
+	}
+
+	if _, ok := q.claims[key]; ok {
+		return false, nil
+	}
+
+	q.claims[key] = now.Add(ttl)
+
+	return true, nil
+}
+
+// pop leases the first due job, after queueing again the jobs whose lease ended.
+func (q *memoryQueue) pop(lease time.Duration) (Job, bool) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	now := time.Now()
+
+	for token, leased := range q.leased {
+		if !now.Before(leased.deadline) {
+			delete(q.leased, token)
+			q.push(leased.job)
+		}
+	}
+
+	if len(q.pending) == 0 || q.pending[0].RunAt.After(now) {
+		return Job{}, false
+	}
+
+	job := q.pending[0]
+	q.pending = q.pending[1:]
+
+	job.lease = utils.UUIDv4()
+	q.leased[job.lease] = leasedJob{job: job, deadline: now.Add(lease)}
+
+	return job, true
+}
+
+// push inserts the job after the pending jobs that run at or before it, holding the mutex.
+func (q *memoryQueue) push(job Job) {
+	job.lease = ""
+
+	i := sort.Search(len(q.pending), func(i int) bool {
+		return q.pending[i].RunAt.After(job.RunAt)
+	})
+
+	q.pending = append(q.pending, Job{})
+	copy(q.pending[i+1:], q.pending[i:])
+	q.pending[i] = job
+}
@@ -0,0 +1,13 @@
+package jobs_test
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/jobs"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/jobs/jobstest"
+	"testing"
+)
+
+func TestMemoryQueue_Conformance(t *testing.T) {
+	jobstest.RunQueueTests(t, func(t *testing.T) jobs.Queue {
+		return jobs.NewMemoryQueue()
+	})
+}
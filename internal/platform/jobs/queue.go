@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// _pollInterval is how often an empty queue is checked for jobs that became due.
+	_pollInterval = 200 * time.Millisecond
+
+	// _deadSize is the number of dead jobs kept.
+	_deadSize = 1000
+)
+
+// Queue keeps the jobs until a Worker runs them.
+type Queue interface {
+	// Enqueue the job to run at its RunAt.
+	Enqueue(ctx context.Context, job Job) error
+
+	// Dequeue the due job that runs first, waiting until there is one or ctx is done. The job is leased:
+	// unless it is acknowledged before the lease ends, it is dequeued again, so it runs at least once.
+	Dequeue(ctx context.Context, lease time.Duration) (Job, error)
+
+	// Ack removes the dequeued job, once it ran or was queued again.
+	Ack(ctx context.Context, job Job) error
+
+	// Bury keeps the job as dead, for inspection. Only the last dead jobs are kept.
+	Bury(ctx context.Context, job Job) error
+
+	// Dead returns the last dead jobs, from the newest.
+	Dead(ctx context.Context, limit int) ([]Job, error)
+
+	// Claim reports whether the key was claimed by this call, and not by another one in the last ttl.
+	// Workers claim the times of their schedules, so every time is queued once.
+	Claim(ctx context.Context, key string, ttl time.Duration) (bool, error)
+}
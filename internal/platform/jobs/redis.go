@@ -0,0 +1,156 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+const (
+	// _queueKey is the sorted set of the pending jobs, scored by the Unix milliseconds of their RunAt.
+	_queueKey = "jobs:queue"
+
+	// _leasedKey is the sorted set of the leased jobs, scored by the Unix milliseconds their lease ends.
+	// Their members are the lease token, a colon and the job.
+	_leasedKey = "jobs:leased"
+
+	// _deadKey is the list of the dead jobs, from the newest.
+	_deadKey = "jobs:dead"
+
+	// _claimsPrefix prefixes the keys of the claims.
+	_claimsPrefix = "jobs:claims:"
+)
+
+// _dequeueScript queues again the jobs whose lease ended, then leases the first due job. Running it as a
+// script keeps two workers from leasing the same job.
+var _dequeueScript = redis.NewScript(`
+local expired = redis.call('ZRANGEBYSCORE', KEYS[2], '-inf', ARGV[1])
+for _, member in ipairs(expired) do
+	redis.call('ZREM', KEYS[2], member)
+	redis.call('ZADD', KEYS[1], ARGV[1], string.sub(member, string.find(member, ':', 1, true) + 1))
+end
+
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, 1)
+if #due == 0 then
+	return false
+end
+
+redis.call('ZREM', KEYS[1], due[1])
+redis.call('ZADD', KEYS[2], ARGV[2], ARGV[3] .. ':' .. due[1])
+
+return due[1]
+`)
+
+type redisQueue struct {
+	client *redis.Client
+}
+
+// NewRedisQueue creates a Queue that keeps the jobs in Redis, so they are shared by the workers of every
+// instance and survive restarts.
+func NewRedisQueue(client *redis.Client) Queue {
+	return &redisQueue{
+		client: client,
+	}
+}
+
+func (q *redisQueue) Enqueue(ctx context.Context, job Job) error {
+	member, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	return q.client.ZAdd(ctx, _queueKey, redis.Z{
+		Score:  float64(job.RunAt.UnixMilli()),
+		Member: member,
+	}).Err()
+}
+
+func (q *redisQueue) Dequeue(ctx context.Context, lease time.Duration) (Job, error) {
+	for {
+		job, err := q.pop(ctx, lease)
+		if err == nil {
+			return job, nil
+		}
+
+		if !errors.Is(err, redis.Nil) {
+			return Job{}, err
+		}
+
+		timer := time.NewTimer(_pollInterval)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return Job{}, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (q *redisQueue) Ack(ctx context.Context, job Job) error {
+	return q.client.ZRem(ctx, _leasedKey, job.lease).Err()
+}
+
+func (q *redisQueue) Bury(ctx context.Context, job Job) error {
+	value, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.LPush(ctx, _deadKey, value)
+		pipe.LTrim(ctx, _deadKey, 0, _deadSize-1)
+
+		return nil
+	})
+
+	return err
+}
+
+func (q *redisQueue) Dead(ctx context.Context, limit int) ([]Job, error) {
+	values, err := q.client.LRange(ctx, _deadKey, 0, int64(limit)-1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	dead := make([]Job, 0, len(values))
+
+	for _, value := range values {
+		var job Job
+		if err := json.Unmarshal([]byte(value), &job); err != nil {
+			return nil, err
+		}
+
+		dead = append(dead, job)
+	}
+
+	return dead, nil
+}
+
+func (q *redisQueue) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return q.client.SetNX(ctx, _claimsPrefix+key, 1, ttl).Result()
+}
+
+// pop leases the first due job, failing with redis.Nil when there is none.
+func (q *redisQueue) pop(ctx context.Context, lease time.Duration) (Job, error) {
+	now := time.Now()
+	token := utils.UUIDv4()
+
+	member, err := _dequeueScript.Run(ctx, q.client, []string{_queueKey, _leasedKey},
+		now.UnixMilli(), now.Add(lease).UnixMilli(), token).Text()
+	if err != nil {
+		return Job{}, err
+	}
+
+	var job Job
+	if err := json.Unmarshal([]byte(member), &job); err != nil {
+		return Job{}, err
+	}
+
+	job.lease = token + ":" + member
+
+	return job, nil
+}
@@ -0,0 +1,20 @@
+package jobs_test
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/jobs"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/jobs/jobstest"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/redis/redistest"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRedisQueue_Conformance(t *testing.T) {
+	client := redistest.NewConnection(t)
+
+	jobstest.RunQueueTests(t, func(t *testing.T) jobs.Queue {
+		require.NoError(t, client.FlushDB(context.Background()).Err())
+
+		return jobs.NewRedisQueue(client)
+	})
+}
@@ -0,0 +1,311 @@
+package jobs
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/logging"
+	"go.uber.org/zap"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	_defaultConcurrency = 4
+	_defaultMaxAttempts = 5
+	_defaultBackoff     = time.Second
+	_defaultMaxBackoff  = time.Hour
+	_defaultTimeout     = time.Minute
+
+	// _retryDequeue is the wait after the queue fails, before dequeuing again.
+	_retryDequeue = time.Second
+
+	// _claimTTL is how long the claim of a time of a schedule is kept, longer than the clocks of the
+	// workers may disagree.
+	_claimTTL = time.Hour
+)
+
+var (
+	// ErrDuplicateHandler is returned when there are two handlers for the same type of jobs.
+	ErrDuplicateHandler = errors.New("duplicate handler for jobs of type")
+
+	// ErrNoHandler is the failure of the jobs of a type without handler, they are dead right away.
+	ErrNoHandler = errors.New("no handler for jobs of type")
+)
+
+// WorkerConfig tunes the Worker, zero fields take their default.
+type WorkerConfig struct {
+	Concurrency int           // Jobs run at the same time, defaults to 4.
+	MaxAttempts int           // Attempts of a job before it is dead, unless it sets its own, defaults to 5.
+	Backoff     time.Duration // Wait before the first retry, doubled on every other one, defaults to 1s.
+	MaxBackoff  time.Duration // Longest wait before a retry, defaults to 1h.
+	Timeout     time.Duration // Of every attempt, defaults to 1m.
+}
+
+// Worker runs the jobs of a Queue with their handlers, and queues the jobs of the schedules on their
+// times. Workers of many instances can share a Redis Queue: every job runs in one of them, and every
+// time of a schedule is queued by one of them.
+type Worker struct {
+	queue     Queue
+	config    WorkerConfig
+	handlers  map[string]Handler
+	schedules []Schedule
+
+	mutex   sync.Mutex
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+}
+
+func NewWorker(queue Queue, config WorkerConfig, handlers []Handler, schedules []Schedule) (*Worker, error) {
+	config.Concurrency = cmp.Or(config.Concurrency, _defaultConcurrency)
+	config.MaxAttempts = cmp.Or(config.MaxAttempts, _defaultMaxAttempts)
+	config.Backoff = cmp.Or(config.Backoff, _defaultBackoff)
+	config.MaxBackoff = cmp.Or(config.MaxBackoff, _defaultMaxBackoff)
+	config.Timeout = cmp.Or(config.Timeout, _defaultTimeout)
+
+	byType := make(map[string]Handler, len(handlers))
+
+	for _, handler := range handlers {
+		if _, ok := byType[handler.jobType]; ok {
+			return nil, fmt.Errorf("%w %q", ErrDuplicateHandler, handler.jobType)
+		}
+
+		byType[handler.jobType] = handler
+	}
+
+	return &Worker{
+		queue:     queue,
+		config:    config,
+		handlers:  byType,
+		schedules: schedules,
+	}, nil
+}
+
+// Start running the jobs and queueing the ones of the schedules, in the background.
+func (w *Worker) Start(_ context.Context) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.cancel != nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	for range w.config.Concurrency {
+		w.running.Add(1)
+
+		go func() {
+			defer w.running.Done()
+
+			w.work(ctx)
+		}()
+	}
+
+	if len(w.schedules) > 0 {
+		w.running.Add(1)
+
+		go func() {
+			defer w.running.Done()
+
+			w.schedule(ctx)
+		}()
+	}
+
+	return nil
+}
+
+// Stop taking jobs and wait until the running ones end, or ctx is done. Jobs that do not end in time are
+// run again once their lease ends.
+func (w *Worker) Stop(ctx context.Context) error {
+	w.mutex.Lock()
+	if w.cancel != nil {
+		w.cancel()
+	}
+	w.mutex.Unlock()
+
+	drained := make(chan struct{})
+
+	go func() {
+		w.running.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// work runs the jobs of the queue one after the other, until ctx is done.
+func (w *Worker) work(ctx context.Context) {
+	// Jobs that are not acknowledged while they run are run again, by this or another worker.
+	lease := 2 * w.config.Timeout
+
+	for {
+		job, err := w.queue.Dequeue(ctx, lease)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			logging.FromContext(ctx).Warn("Error dequeuing job", zap.Error(err))
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(_retryDequeue):
+			}
+
+			continue
+		}
+
+		w.run(job)
+	}
+}
+
+// run the job, then acknowledge it once it succeeded, was queued again to be retried, or is dead.
+// Running jobs are not canceled by Stop, they are given until their timeout.
+func (w *Worker) run(job Job) {
+	ctx := context.Background()
+	logger := logging.FromContext(ctx).With(zap.String("job_id", job.ID), zap.String("job_type", job.Type))
+
+	err := w.handle(logging.WithLogger(ctx, logger), job)
+	if err != nil {
+		job.Attempts++
+		job.LastError = err.Error()
+
+		if IsPermanent(err) || job.Attempts >= cmp.Or(job.MaxAttempts, w.config.MaxAttempts) {
+			logger.Warn("Job is dead", zap.Int("attempts", job.Attempts), zap.Error(err))
+
+			err = w.queue.Bury(ctx, job)
+		} else {
+			job.RunAt = time.Now().UTC().Add(w.backoff(job.Attempts))
+
+			err = w.queue.Enqueue(ctx, job)
+		}
+
+		if err != nil {
+			// The job runs again once its lease ends.
+			logger.Warn("Error queueing failed job", zap.Error(err))
+
+			return
+		}
+	}
+
+	if err := w.queue.Ack(ctx, job); err != nil {
+		logger.Warn("Error acknowledging job", zap.Error(err))
+	}
+}
+
+// handle runs the handler of the job with its timeout, turning its panics into errors.
+func (w *Worker) handle(ctx context.Context, job Job) (err error) {
+	handler, ok := w.handlers[job.Type]
+	if !ok {
+		return Permanent(fmt.Errorf("%w %q", ErrNoHandler, job.Type))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.config.Timeout)
+	defer cancel()
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("job panicked: %v", recovered)
+		}
+	}()
+
+	return handler.handle(ctx, job.Payload)
+}
+
+// backoff is the wait before retrying a job after its failed attempts.
+func (w *Worker) backoff(attempts int) time.Duration {
+	backoff := w.config.Backoff
+
+	for range attempts - 1 {
+		if backoff >= w.config.MaxBackoff {
+			break
+		}
+
+		backoff *= 2
+	}
+
+	return min(backoff, w.config.MaxBackoff)
+}
+
+// schedule queues the jobs of the schedules on their times, until ctx is done.
+func (w *Worker) schedule(ctx context.Context) {
+	now := time.Now()
+	next := make([]time.Time, len(w.schedules))
+
+	for i, schedule := range w.schedules {
+		next[i] = schedule.next(now)
+	}
+
+	for {
+		var earliest time.Time
+
+		for _, due := range next {
+			if !due.IsZero() && (earliest.IsZero() || due.Before(earliest)) {
+				earliest = due
+			}
+		}
+
+		if earliest.IsZero() {
+			return
+		}
+
+		timer := time.NewTimer(time.Until(earliest))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		now := time.Now()
+
+		for i, schedule := range w.schedules {
+			if next[i].IsZero() || next[i].After(now) {
+				continue
+			}
+
+			w.enqueueScheduled(ctx, schedule, next[i])
+			next[i] = schedule.next(next[i])
+		}
+	}
+}
+
+// enqueueScheduled queues the job of the schedule for the due time, unless another worker claimed it.
+func (w *Worker) enqueueScheduled(ctx context.Context, schedule Schedule, due time.Time) {
+	logger := logging.FromContext(ctx).With(zap.String("schedule", schedule.Name))
+
+	claimed, err := w.queue.Claim(ctx, schedule.Name+"@"+strconv.FormatInt(due.UnixMilli(), 10), _claimTTL)
+	if err != nil {
+		logger.Warn("Error claiming scheduled job", zap.Error(err))
+
+		return
+	}
+
+	if !claimed {
+		return
+	}
+
+	job, err := schedule.newJob()
+	if err != nil {
+		logger.Warn("Error creating scheduled job", zap.Error(err))
+
+		return
+	}
+
+	job.RunAt = due
+
+	if err := w.queue.Enqueue(ctx, job); err != nil {
+		logger.Warn("Error queueing scheduled job", zap.Error(err))
+	}
+}
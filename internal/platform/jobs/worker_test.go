@@ -0,0 +1,409 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/jobs"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type greeting struct {
+	Name string `json:"name"`
+}
+
+var _greetJob = jobs.Type[greeting]("test.greet")
+
+// _config retries right away, so the tests do not wait for the backoff.
+var _config = jobs.WorkerConfig{
+	Concurrency: 2,
+	MaxAttempts: 3,
+	Backoff:     time.Millisecond,
+	Timeout:     time.Second,
+}
+
+func startWorker(
+	t *testing.T,
+	queue jobs.Queue,
+	config jobs.WorkerConfig,
+	handlers []jobs.Handler,
+	schedules ...jobs.Schedule) *jobs.Worker {
+	t.Helper()
+
+	worker, err := jobs.NewWorker(queue, config, handlers, schedules)
+	require.NoError(t, err)
+	require.NoError(t, worker.Start(context.Background()))
+
+	t.Cleanup(func() {
+		_ = worker.Stop(context.Background())
+	})
+
+	return worker
+}
+
+func requireDead(t *testing.T, queue jobs.Queue) jobs.Job {
+	t.Helper()
+
+	var dead []jobs.Job
+
+	require.Eventually(t, func() bool {
+		var err error
+		dead, err = queue.Dead(context.Background(), 10)
+		require.NoError(t, err)
+
+		return len(dead) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Len(t, dead, 1)
+
+	return dead[0]
+}
+
+func TestWorker_SuccessfulRunsJobWithPayload(t *testing.T) {
+	// Given
+	queue := jobs.NewMemoryQueue()
+	greeted := make(chan string, 1)
+
+	startWorker(t, queue, _config, []jobs.Handler{
+		_greetJob.Handler(func(_ context.Context, payload greeting) error {
+			greeted <- payload.Name
+			return nil
+		}),
+	})
+
+	// When
+	err := _greetJob.Enqueue(context.Background(), queue, greeting{Name: "Fernando"})
+
+	// Then
+	require.NoError(t, err)
+
+	select {
+	case name := <-greeted:
+		require.Equal(t, "Fernando", name)
+	case <-time.After(5 * time.Second):
+		t.Fatal("job did not run")
+	}
+}
+
+func TestWorker_SuccessfulRetriesFailedJob(t *testing.T) {
+	// Given
+	queue := jobs.NewMemoryQueue()
+	var attempts atomic.Int32
+	done := make(chan struct{})
+
+	startWorker(t, queue, _config, []jobs.Handler{
+		_greetJob.Handler(func(context.Context, greeting) error {
+			if attempts.Add(1) < 3 {
+				return errors.New("temporary failure")
+			}
+
+			close(done)
+
+			return nil
+		}),
+	})
+
+	// When
+	err := _greetJob.Enqueue(context.Background(), queue, greeting{Name: "Fernando"})
+
+	// Then
+	require.NoError(t, err)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("job was not retried")
+	}
+
+	require.EqualValues(t, 3, attempts.Load())
+
+	dead, err := queue.Dead(context.Background(), 10)
+	require.NoError(t, err)
+	require.Empty(t, dead)
+}
+
+func TestWorker_SuccessfulBuriesJobOutOfAttempts(t *testing.T) {
+	// Given
+	queue := jobs.NewMemoryQueue()
+	var attempts atomic.Int32
+
+	startWorker(t, queue, _config, []jobs.Handler{
+		_greetJob.Handler(func(context.Context, greeting) error {
+			attempts.Add(1)
+			return errors.New("always failing")
+		}),
+	})
+
+	// When
+	err := _greetJob.Enqueue(context.Background(), queue, greeting{Name: "Fernando"}, jobs.MaxAttempts(2))
+
+	// Then
+	require.NoError(t, err)
+
+	dead := requireDead(t, queue)
+	require.Equal(t, "test.greet", dead.Type)
+	require.Equal(t, 2, dead.Attempts)
+	require.Equal(t, "always failing", dead.LastError)
+	require.EqualValues(t, 2, attempts.Load())
+}
+
+func TestWorker_SuccessfulBuriesJobWithPermanentError(t *testing.T) {
+	// Given
+	queue := jobs.NewMemoryQueue()
+	var attempts atomic.Int32
+
+	startWorker(t, queue, _config, []jobs.Handler{
+		_greetJob.Handler(func(context.Context, greeting) error {
+			attempts.Add(1)
+			return jobs.Permanent(errors.New("unknown name"))
+		}),
+	})
+
+	// When
+	err := _greetJob.Enqueue(context.Background(), queue, greeting{Name: "Fernando"})
+
+	// Then
+	require.NoError(t, err)
+
+	dead := requireDead(t, queue)
+	require.Equal(t, 1, dead.Attempts)
+	require.Equal(t, "unknown name", dead.LastError)
+	require.EqualValues(t, 1, attempts.Load())
+}
+
+func TestWorker_SuccessfulBuriesJobWithUndecodablePayload(t *testing.T) {
+	// Given
+	queue := jobs.NewMemoryQueue()
+
+	startWorker(t, queue, _config, []jobs.Handler{
+		_greetJob.Handler(func(context.Context, greeting) error {
+			return nil
+		}),
+	})
+
+	// When
+	err := queue.Enqueue(context.Background(), jobs.Job{
+		ID:      "1",
+		Type:    "test.greet",
+		Payload: []byte(`"not a greeting"`),
+		RunAt:   time.Now(),
+	})
+
+	// Then
+	require.NoError(t, err)
+
+	dead := requireDead(t, queue)
+	require.Equal(t, 1, dead.Attempts)
+}
+
+func TestWorker_SuccessfulBuriesJobWithoutHandler(t *testing.T) {
+	// Given
+	queue := jobs.NewMemoryQueue()
+
+	startWorker(t, queue, _config, nil)
+
+	// When
+	err := _greetJob.Enqueue(context.Background(), queue, greeting{Name: "Fernando"})
+
+	// Then
+	require.NoError(t, err)
+
+	dead := requireDead(t, queue)
+	require.Contains(t, dead.LastError, jobs.ErrNoHandler.Error())
+}
+
+func TestWorker_SuccessfulRetriesPanickedJob(t *testing.T) {
+	// Given
+	queue := jobs.NewMemoryQueue()
+
+	startWorker(t, queue, _config, []jobs.Handler{
+		_greetJob.Handler(func(context.Context, greeting) error {
+			panic("unexpected")
+		}),
+	})
+
+	// When
+	err := _greetJob.Enqueue(context.Background(), queue, greeting{Name: "Fernando"})
+
+	// Then
+	require.NoError(t, err)
+
+	dead := requireDead(t, queue)
+	require.Equal(t, 3, dead.Attempts)
+	require.Contains(t, dead.LastError, "unexpected")
+}
+
+func TestWorker_SuccessfulLimitsConcurrency(t *testing.T) {
+	// Given
+	queue := jobs.NewMemoryQueue()
+	var running, maxRunning atomic.Int32
+	var wg sync.WaitGroup
+
+	startWorker(t, queue, _config, []jobs.Handler{
+		_greetJob.Handler(func(context.Context, greeting) error {
+			defer wg.Done()
+
+			current := running.Add(1)
+			defer running.Add(-1)
+
+			for {
+				observed := maxRunning.Load()
+				if current <= observed || maxRunning.CompareAndSwap(observed, current) {
+					break
+				}
+			}
+
+			time.Sleep(50 * time.Millisecond)
+
+			return nil
+		}),
+	})
+
+	// When
+	for range 6 {
+		wg.Add(1)
+		require.NoError(t, _greetJob.Enqueue(context.Background(), queue, greeting{Name: "Fernando"}))
+	}
+
+	// Then
+	wg.Wait()
+	require.EqualValues(t, _config.Concurrency, maxRunning.Load())
+}
+
+func TestWorker_SuccessfulStopWaitsForRunningJobs(t *testing.T) {
+	// Given
+	queue := jobs.NewMemoryQueue()
+	started := make(chan struct{})
+	var finished atomic.Bool
+
+	worker, err := jobs.NewWorker(queue, _config, []jobs.Handler{
+		_greetJob.Handler(func(context.Context, greeting) error {
+			close(started)
+			time.Sleep(200 * time.Millisecond)
+			finished.Store(true)
+
+			return nil
+		}),
+	}, nil)
+	require.NoError(t, err)
+	require.NoError(t, worker.Start(context.Background()))
+
+	require.NoError(t, _greetJob.Enqueue(context.Background(), queue, greeting{Name: "Fernando"}))
+	<-started
+
+	// When
+	err = worker.Stop(context.Background())
+
+	// Then
+	require.NoError(t, err)
+	require.True(t, finished.Load())
+}
+
+func TestWorker_FailsStopDueToContextDone(t *testing.T) {
+	// Given
+	queue := jobs.NewMemoryQueue()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+
+	worker, err := jobs.NewWorker(queue, _config, []jobs.Handler{
+		_greetJob.Handler(func(context.Context, greeting) error {
+			close(started)
+			<-release
+
+			return nil
+		}),
+	}, nil)
+	require.NoError(t, err)
+	require.NoError(t, worker.Start(context.Background()))
+
+	require.NoError(t, _greetJob.Enqueue(context.Background(), queue, greeting{Name: "Fernando"}))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// When
+	err = worker.Stop(ctx)
+
+	// Then
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestWorker_SuccessfulQueuesScheduledJobsOnce(t *testing.T) {
+	// Given
+	queue := jobs.NewMemoryQueue()
+	var runs atomic.Int32
+
+	schedule, err := _greetJob.Schedule("@every 1s", greeting{Name: "Fernando"})
+	require.NoError(t, err)
+
+	handlers := []jobs.Handler{
+		_greetJob.Handler(func(context.Context, greeting) error {
+			runs.Add(1)
+			return nil
+		}),
+	}
+
+	// Starting past a whole second, the times of the schedule, makes the runs in the wait predictable.
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second + 100*time.Millisecond)))
+
+	// Two workers sharing the queue, like two instances.
+	startWorker(t, queue, _config, handlers, schedule)
+	startWorker(t, queue, _config, handlers, schedule)
+
+	// When
+	time.Sleep(2500 * time.Millisecond)
+
+	// Then
+	require.EqualValues(t, 2, runs.Load())
+}
+
+func TestNewWorker_FailsDueToDuplicateHandler(t *testing.T) {
+	// Given
+	handler := _greetJob.Handler(func(context.Context, greeting) error {
+		return nil
+	})
+
+	// When
+	worker, err := jobs.NewWorker(jobs.NewMemoryQueue(), _config, []jobs.Handler{handler, handler}, nil)
+
+	// Then
+	require.ErrorIs(t, err, jobs.ErrDuplicateHandler)
+	require.Nil(t, worker)
+}
+
+func TestType_FailsScheduleDueToInvalidSpec(t *testing.T) {
+	// When
+	_, err := _greetJob.Schedule("every now and then", greeting{})
+
+	// Then
+	require.ErrorIs(t, err, jobs.ErrInvalidSchedule)
+}
+
+func TestType_SuccessfulEnqueueWithOptions(t *testing.T) {
+	// Given
+	queue := jobs.NewMemoryQueue()
+	before := time.Now()
+
+	// When
+	err := _greetJob.Enqueue(context.Background(), queue, greeting{Name: "Fernando"},
+		jobs.Delay(-time.Minute), jobs.MaxAttempts(7))
+
+	// Then
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	job, err := queue.Dequeue(ctx, time.Minute)
+	require.NoError(t, err)
+	require.NotEmpty(t, job.ID)
+	require.Equal(t, "test.greet", job.Type)
+	require.JSONEq(t, `{"name":"Fernando"}`, string(job.Payload))
+	require.Equal(t, 7, job.MaxAttempts)
+	require.WithinDuration(t, before.Add(-time.Minute), job.RunAt, time.Second)
+}
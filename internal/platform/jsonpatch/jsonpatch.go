@@ -0,0 +1,310 @@
+// Package jsonpatch applies JSON Patch (RFC 6902) and JSON Merge Patch (RFC 7396) documents.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+const (
+	// PatchContentType is the media type of a JSON Patch.
+	PatchContentType = "application/json-patch+json"
+
+	// MergePatchContentType is the media type of a JSON Merge Patch.
+	MergePatchContentType = "application/merge-patch+json"
+)
+
+var (
+	// ErrInvalidPatch is returned when a patch is malformed, like an unknown operation or a missing value.
+	ErrInvalidPatch = errors.New("invalid patch")
+
+	// ErrInvalidPath is returned when an operation refers to a location the document does not have.
+	ErrInvalidPath = errors.New("invalid path")
+
+	// ErrTestFailed is returned when the value of a test operation is not the one of the document.
+	ErrTestFailed = errors.New("test failed")
+)
+
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+	OpMove    = "move"
+	OpCopy    = "copy"
+	OpTest    = "test"
+)
+
+// Operation is an operation of a JSON Patch.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Patch is a JSON Patch, whose operations are applied in order.
+type Patch []Operation
+
+// DecodePatch decodes a JSON Patch, checking that every operation has the members it needs.
+func DecodePatch(b []byte) (Patch, error) {
+	var patch Patch
+	if err := json.Unmarshal(b, &patch); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+	}
+
+	for i, operation := range patch {
+		if err := operation.check(); err != nil {
+			return nil, fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+
+	return patch, nil
+}
+
+func (o Operation) check() error {
+	switch o.Op {
+	case OpAdd, OpReplace, OpTest:
+		if len(o.Value) == 0 {
+			return fmt.Errorf("%w: %s needs a value", ErrInvalidPatch, o.Op)
+		}
+	case OpMove, OpCopy:
+		if _, err := parsePointer(o.From); err != nil {
+			return err
+		}
+	case OpRemove:
+	case "":
+		return fmt.Errorf("%w: missing op", ErrInvalidPatch)
+	default:
+		return fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, o.Op)
+	}
+
+	_, err := parsePointer(o.Path)
+
+	return err
+}
+
+// Apply applies the operations to the JSON document, returning the patched document. The patch is
+// atomic: the document is only returned when every operation is applied.
+func (p Patch) Apply(document []byte) ([]byte, error) {
+	doc, err := decode(document)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, operation := range p {
+		if doc, err = operation.apply(doc); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, operation.Op, operation.Path, err)
+		}
+	}
+
+	return json.Marshal(doc)
+}
+
+func (o Operation) apply(doc any) (any, error) {
+	if err := o.check(); err != nil {
+		return nil, err
+	}
+
+	path, _ := parsePointer(o.Path)
+	from, _ := parsePointer(o.From)
+
+	switch o.Op {
+	case OpAdd:
+		value, err := decode(o.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+		}
+
+		return add(doc, path, value)
+	case OpRemove:
+		return remove(doc, path)
+	case OpReplace:
+		value, err := decode(o.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+		}
+
+		if len(path) == 0 {
+			return value, nil
+		}
+
+		if doc, err = remove(doc, path); err != nil {
+			return nil, err
+		}
+
+		return add(doc, path, value)
+	case OpMove:
+		if len(from) < len(path) && slices.Equal(from, path[:len(from)]) {
+			return nil, fmt.Errorf("%w: %q can not be moved into itself", ErrInvalidPath, o.From)
+		}
+
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+
+		if doc, err = remove(doc, from); err != nil {
+			return nil, err
+		}
+
+		return add(doc, path, value)
+	case OpCopy:
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+
+		return add(doc, path, deepCopy(value))
+	default:
+		expected, err := decode(o.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidPatch, err)
+		}
+
+		value, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !equal(value, expected) {
+			return nil, ErrTestFailed
+		}
+
+		return doc, nil
+	}
+}
+
+// add sets the member of an object, or inserts the element of an array, at the path.
+func add(doc any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return update(doc, path, func(parent any, token string) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			container[token] = value
+
+			return container, nil
+		case []any:
+			index, err := arrayIndex(token, len(container), true)
+			if err != nil {
+				return nil, err
+			}
+
+			return slices.Insert(container, index, value), nil
+		default:
+			return nil, fmt.Errorf("%w: %q is not in an object or an array", ErrInvalidPath, token)
+		}
+	})
+}
+
+// remove deletes the member of an object, or the element of an array, at the path, which must exist.
+func remove(doc any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: the whole document can not be removed", ErrInvalidPath)
+	}
+
+	return update(doc, path, func(parent any, token string) (any, error) {
+		switch container := parent.(type) {
+		case map[string]any:
+			if _, ok := container[token]; !ok {
+				return nil, fmt.Errorf("%w: member %q does not exist", ErrInvalidPath, token)
+			}
+
+			delete(container, token)
+
+			return container, nil
+		case []any:
+			index, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+
+			return slices.Delete(container, index, index+1), nil
+		default:
+			return nil, fmt.Errorf("%w: %q is not in an object or an array", ErrInvalidPath, token)
+		}
+	})
+}
+
+// decode decodes a JSON value, keeping numbers as they are written.
+func decode(b []byte) (any, error) {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	if decoder.More() {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+
+	return value, nil
+}
+
+func deepCopy(value any) any {
+	switch container := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(container))
+		for key, element := range container {
+			copied[key] = deepCopy(element)
+		}
+
+		return copied
+	case []any:
+		copied := make([]any, len(container))
+		for i, element := range container {
+			copied[i] = deepCopy(element)
+		}
+
+		return copied
+	default:
+		return value
+	}
+}
+
+// equal compares JSON values as RFC 6902 tests them, with numbers compared by their value.
+func equal(a, b any) bool {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+
+		for key, value := range a {
+			other, ok := b[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+
+		return true
+	case []any:
+		b, ok := b.([]any)
+
+		return ok && slices.EqualFunc(a, b, equal)
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+
+		aFloat, aErr := a.Float64()
+		bFloat, bErr := b.Float64()
+		if aErr != nil || bErr != nil {
+			return strings.EqualFold(a.String(), b.String())
+		}
+
+		return aFloat == bFloat
+	default:
+		return a == b
+	}
+}
@@ -0,0 +1,199 @@
+package jsonpatch
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestPatchApply_Successful(t *testing.T) {
+	tests := map[string]struct {
+		document         string
+		patch            string
+		expectedDocument string
+	}{
+		"AddMember": {
+			document:         `{"foo": "bar"}`,
+			patch:            `[{"op": "add", "path": "/baz", "value": "qux"}]`,
+			expectedDocument: `{"baz": "qux", "foo": "bar"}`,
+		},
+		"AddArrayElement": {
+			document:         `{"foo": ["bar", "baz"]}`,
+			patch:            `[{"op": "add", "path": "/foo/1", "value": "qux"}]`,
+			expectedDocument: `{"foo": ["bar", "qux", "baz"]}`,
+		},
+		"AppendArrayElement": {
+			document:         `{"foo": ["bar"]}`,
+			patch:            `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`,
+			expectedDocument: `{"foo": ["bar", ["abc", "def"]]}`,
+		},
+		"AddNull": {
+			document:         `{"foo": "bar"}`,
+			patch:            `[{"op": "add", "path": "/baz", "value": null}]`,
+			expectedDocument: `{"baz": null, "foo": "bar"}`,
+		},
+		"RemoveMember": {
+			document:         `{"baz": "qux", "foo": "bar"}`,
+			patch:            `[{"op": "remove", "path": "/baz"}]`,
+			expectedDocument: `{"foo": "bar"}`,
+		},
+		"RemoveArrayElement": {
+			document:         `{"foo": ["bar", "qux", "baz"]}`,
+			patch:            `[{"op": "remove", "path": "/foo/1"}]`,
+			expectedDocument: `{"foo": ["bar", "baz"]}`,
+		},
+		"Replace": {
+			document:         `{"baz": "qux", "foo": "bar"}`,
+			patch:            `[{"op": "replace", "path": "/baz", "value": "boo"}]`,
+			expectedDocument: `{"baz": "boo", "foo": "bar"}`,
+		},
+		"ReplaceDocument": {
+			document:         `{"foo": "bar"}`,
+			patch:            `[{"op": "replace", "path": "", "value": {"baz": "qux"}}]`,
+			expectedDocument: `{"baz": "qux"}`,
+		},
+		"Move": {
+			document:         `{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+			patch:            `[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+			expectedDocument: `{"foo": {"bar": "baz"}, "qux": {"corge": "grault", "thud": "fred"}}`,
+		},
+		"MoveArrayElement": {
+			document:         `{"foo": ["all", "grass", "cows", "eat"]}`,
+			patch:            `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+			expectedDocument: `{"foo": ["all", "cows", "eat", "grass"]}`,
+		},
+		"Copy": {
+			document: `{"foo": {"bar": 1}}`,
+			patch: `[{"op": "copy", "from": "/foo", "path": "/baz"},
+						{"op": "replace", "path": "/baz/bar", "value": 2}]`,
+			expectedDocument: `{"foo": {"bar": 1}, "baz": {"bar": 2}}`,
+		},
+		"Test": {
+			document: `{"baz": "qux", "foo": ["a", 2, "c"], "n": 1}`,
+			patch: `[{"op": "test", "path": "/baz", "value": "qux"},
+						{"op": "test", "path": "/foo/1", "value": 2},
+						{"op": "test", "path": "/n", "value": 1.0}]`,
+			expectedDocument: `{"baz": "qux", "foo": ["a", 2, "c"], "n": 1}`,
+		},
+		"EscapedPointer": {
+			document: `{"a/b": 1, "m~n": 2}`,
+			patch: `[{"op": "replace", "path": "/a~1b", "value": 3},
+						{"op": "remove", "path": "/m~0n"}]`,
+			expectedDocument: `{"a/b": 3}`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			patch, err := DecodePatch([]byte(test.patch))
+			require.NoError(t, err)
+
+			// When
+			document, err := patch.Apply([]byte(test.document))
+
+			// Then
+			require.NoError(t, err)
+			require.JSONEq(t, test.expectedDocument, string(document))
+		})
+	}
+}
+
+func TestDecodePatch_FailsDueToInvalidPatch(t *testing.T) {
+	tests := map[string]struct {
+		patch         string
+		expectedError string
+	}{
+		"NotJSON": {
+			patch:         `{"op": "add"`,
+			expectedError: "invalid patch: unexpected end of JSON input",
+		},
+		"NotArray": {
+			patch:         `{"op": "add", "path": "/foo", "value": 1}`,
+			expectedError: "invalid patch: json: cannot unmarshal object into Go value of type jsonpatch.Patch",
+		},
+		"MissingOp": {
+			patch:         `[{"path": "/foo", "value": 1}]`,
+			expectedError: "operation 0: invalid patch: missing op",
+		},
+		"UnknownOp": {
+			patch:         `[{"op": "add", "path": "/foo", "value": 1}, {"op": "increment", "path": "/foo"}]`,
+			expectedError: `operation 1: invalid patch: unknown op "increment"`,
+		},
+		"MissingValue": {
+			patch:         `[{"op": "replace", "path": "/foo"}]`,
+			expectedError: "operation 0: invalid patch: replace needs a value",
+		},
+		"InvalidPath": {
+			patch:         `[{"op": "remove", "path": "foo"}]`,
+			expectedError: `operation 0: invalid patch: "foo" does not start with /`,
+		},
+		"InvalidFrom": {
+			patch:         `[{"op": "move", "from": "foo", "path": "/bar"}]`,
+			expectedError: `operation 0: invalid patch: "foo" does not start with /`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// When
+			patch, err := DecodePatch([]byte(test.patch))
+
+			// Then
+			require.ErrorIs(t, err, ErrInvalidPatch)
+			require.EqualError(t, err, test.expectedError)
+			require.Nil(t, patch)
+		})
+	}
+}
+
+func TestPatchApply_FailsDueToInvalidPath(t *testing.T) {
+	tests := map[string]struct {
+		patch         string
+		expectedError error
+	}{
+		"AddToMissingParent": {
+			patch:         `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`,
+			expectedError: ErrInvalidPath,
+		},
+		"RemoveMissingMember": {
+			patch:         `[{"op": "remove", "path": "/baz"}]`,
+			expectedError: ErrInvalidPath,
+		},
+		"ReplaceMissingMember": {
+			patch:         `[{"op": "replace", "path": "/baz", "value": 1}]`,
+			expectedError: ErrInvalidPath,
+		},
+		"AddOutOfBounds": {
+			patch:         `[{"op": "add", "path": "/list/3", "value": 1}]`,
+			expectedError: ErrInvalidPath,
+		},
+		"IndexWithLeadingZero": {
+			patch:         `[{"op": "remove", "path": "/list/01"}]`,
+			expectedError: ErrInvalidPath,
+		},
+		"MoveIntoItself": {
+			patch:         `[{"op": "move", "from": "/list", "path": "/list/0"}]`,
+			expectedError: ErrInvalidPath,
+		},
+		"FailedTest": {
+			patch: `[{"op": "replace", "path": "/foo", "value": "baz"},
+						{"op": "test", "path": "/foo", "value": "bar"}]`,
+			expectedError: ErrTestFailed,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			patch, err := DecodePatch([]byte(test.patch))
+			require.NoError(t, err)
+
+			// When
+			document, err := patch.Apply([]byte(`{"foo": "bar", "list": [1, 2]}`))
+
+			// Then
+			require.ErrorIs(t, err, test.expectedError)
+			require.Nil(t, document)
+		})
+	}
+}
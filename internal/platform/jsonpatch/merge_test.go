@@ -0,0 +1,55 @@
+package jsonpatch
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestMergePatch_Successful(t *testing.T) {
+	// The examples of RFC 7396, appendix A.
+	tests := []struct {
+		document         string
+		patch            string
+		expectedDocument string
+	}{
+		{document: `{"a": "b"}`, patch: `{"a": "c"}`, expectedDocument: `{"a": "c"}`},
+		{document: `{"a": "b"}`, patch: `{"b": "c"}`, expectedDocument: `{"a": "b", "b": "c"}`},
+		{document: `{"a": "b"}`, patch: `{"a": null}`, expectedDocument: `{}`},
+		{document: `{"a": "b", "b": "c"}`, patch: `{"a": null}`, expectedDocument: `{"b": "c"}`},
+		{document: `{"a": ["b"]}`, patch: `{"a": "c"}`, expectedDocument: `{"a": "c"}`},
+		{document: `{"a": "c"}`, patch: `{"a": ["b"]}`, expectedDocument: `{"a": ["b"]}`},
+		{
+			document:         `{"a": {"b": "c"}}`,
+			patch:            `{"a": {"b": "d", "c": null}}`,
+			expectedDocument: `{"a": {"b": "d"}}`,
+		},
+		{document: `{"a": [{"b": "c"}]}`, patch: `{"a": [1]}`, expectedDocument: `{"a": [1]}`},
+		{document: `["a", "b"]`, patch: `["c", "d"]`, expectedDocument: `["c", "d"]`},
+		{document: `{"a": "b"}`, patch: `["c"]`, expectedDocument: `["c"]`},
+		{document: `{"a": "foo"}`, patch: `null`, expectedDocument: `null`},
+		{document: `{"a": "foo"}`, patch: `"bar"`, expectedDocument: `"bar"`},
+		{document: `{"e": null}`, patch: `{"a": 1}`, expectedDocument: `{"e": null, "a": 1}`},
+		{document: `[1, 2]`, patch: `{"a": "b", "c": null}`, expectedDocument: `{"a": "b"}`},
+		{document: `{}`, patch: `{"a": {"bb": {"ccc": null}}}`, expectedDocument: `{"a": {"bb": {}}}`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.patch, func(t *testing.T) {
+			// When
+			document, err := MergePatch([]byte(test.document), []byte(test.patch))
+
+			// Then
+			require.NoError(t, err)
+			require.JSONEq(t, test.expectedDocument, string(document))
+		})
+	}
+}
+
+func TestMergePatch_FailsDueToInvalidPatch(t *testing.T) {
+	// When
+	document, err := MergePatch([]byte(`{"a": "b"}`), []byte(`{"a": `))
+
+	// Then
+	require.ErrorIs(t, err, ErrInvalidPatch)
+	require.Nil(t, document)
+}
@@ -0,0 +1,125 @@
+package jsonpatch
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// _appendToken is the index past the last element of an array, where add appends.
+const _appendToken = "-"
+
+// parsePointer returns the reference tokens of a JSON pointer (RFC 6901), which is empty for the whole
+// document.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: %q does not start with /", ErrInvalidPatch, pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// arrayIndex returns the index of the token in an array of length elements. The index may be length
+// when appending.
+func arrayIndex(token string, length int, appending bool) (int, error) {
+	if appending && token == _appendToken {
+		return length, nil
+	}
+
+	// Leading zeros and signs are not indexes.
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.ContainsAny(token, "+-") {
+		return 0, fmt.Errorf("%w: %q is not an array index", ErrInvalidPath, token)
+	}
+
+	index, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is not an array index", ErrInvalidPath, token)
+	}
+
+	limit := length - 1
+	if appending {
+		limit = length
+	}
+
+	if index > limit {
+		return 0, fmt.Errorf("%w: index %d is out of bounds", ErrInvalidPath, index)
+	}
+
+	return index, nil
+}
+
+// get returns the value at the tokens.
+func get(doc any, tokens []string) (any, error) {
+	for _, token := range tokens {
+		switch container := doc.(type) {
+		case map[string]any:
+			value, ok := container[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: member %q does not exist", ErrInvalidPath, token)
+			}
+
+			doc = value
+		case []any:
+			index, err := arrayIndex(token, len(container), false)
+			if err != nil {
+				return nil, err
+			}
+
+			doc = container[index]
+		default:
+			return nil, fmt.Errorf("%w: %q is not in an object or an array", ErrInvalidPath, token)
+		}
+	}
+
+	return doc, nil
+}
+
+// update replaces the parent of the last token with the result of fn, which receives the parent and
+// the last token. Arrays change their length, so every container on the way is written back.
+func update(doc any, tokens []string, fn func(parent any, token string) (any, error)) (any, error) {
+	if len(tokens) == 1 {
+		return fn(doc, tokens[0])
+	}
+
+	switch container := doc.(type) {
+	case map[string]any:
+		child, ok := container[tokens[0]]
+		if !ok {
+			return nil, fmt.Errorf("%w: member %q does not exist", ErrInvalidPath, tokens[0])
+		}
+
+		value, err := update(child, tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+
+		container[tokens[0]] = value
+
+		return container, nil
+	case []any:
+		index, err := arrayIndex(tokens[0], len(container), false)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := update(container[index], tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+
+		container[index] = value
+
+		return container, nil
+	default:
+		return nil, fmt.Errorf("%w: %q is not in an object or an array", ErrInvalidPath, tokens[0])
+	}
+}
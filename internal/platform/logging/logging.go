@@ -0,0 +1,46 @@
+package logging
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"go.uber.org/zap"
+)
+
+// LoggerKey is the key of the per-request *zap.Logger stored in the Fiber locals.
+const LoggerKey = "logger"
+
+type loggerContextKey struct{}
+
+// WithLogger stores the logger in the context so services can retrieve it.
+func WithLogger(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, logger)
+}
+
+// FromContext returns the logger stored in the context or the global logger when there is none.
+func FromContext(ctx context.Context) *zap.Logger {
+	if logger, ok := ctx.Value(loggerContextKey{}).(*zap.Logger); ok {
+		return logger
+	}
+
+	return zap.L()
+}
+
+// FromCtx returns the per-request logger stored in the Fiber locals.
+func FromCtx(c *fiber.Ctx) *zap.Logger {
+	if logger, ok := c.Locals(LoggerKey).(*zap.Logger); ok {
+		return logger
+	}
+
+	return FromContext(c.UserContext())
+}
+
+// SetRequestLogger stores the logger in both the Fiber locals and the user context of the request.
+func SetRequestLogger(c *fiber.Ctx, logger *zap.Logger) {
+	c.Locals(LoggerKey, logger)
+	c.SetUserContext(WithLogger(c.UserContext(), logger))
+}
+
+// Enrich adds fields to the per-request logger.
+func Enrich(c *fiber.Ctx, fields ...zap.Field) {
+	SetRequestLogger(c, FromCtx(c).With(fields...))
+}
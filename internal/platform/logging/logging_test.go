@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"context"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFromContext_SuccessfulWithStoredLogger(t *testing.T) {
+	// Given
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core)
+
+	ctx := WithLogger(context.Background(), logger)
+
+	// When
+	FromContext(ctx).Info("from service")
+
+	// Then
+	require.Equal(t, 1, logs.Len())
+	require.Equal(t, "from service", logs.All()[0].Message)
+}
+
+func TestFromContext_SuccessfulFallsBackToGlobalLogger(t *testing.T) {
+	// When
+	logger := FromContext(context.Background())
+
+	// Then
+	require.Equal(t, zap.L(), logger)
+}
+
+func TestEnrich_SuccessfulAddsFieldsToLocalsAndContext(t *testing.T) {
+	// Given
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(core)
+
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		SetRequestLogger(c, logger.With(zap.String("request_id", "abc")))
+		Enrich(c, zap.Int("user_id", 1))
+
+		FromCtx(c).Info("from handler")
+		FromContext(c.UserContext()).Info("from service")
+
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+
+	// When
+	resp, err := app.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, 2, logs.Len())
+
+	for _, entry := range logs.All() {
+		require.Equal(t, map[string]any{"request_id": "abc", "user_id": int64(1)}, entry.ContextMap())
+	}
+}
@@ -0,0 +1,118 @@
+package logging
+
+import (
+	"encoding/json"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"strings"
+)
+
+// Redacted replaces the value of sensitive data.
+const Redacted = "[REDACTED]"
+
+// _sensitiveKeys are matched case-insensitively as a substring of header, field and JSON keys.
+var _sensitiveKeys = []string{
+	"authorization",
+	"password",
+	"token",
+	"secret",
+	"cookie",
+}
+
+// IsSensitive reports whether the value of the key must never be logged.
+func IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+
+	for _, sensitiveKey := range _sensitiveKeys {
+		if strings.Contains(key, sensitiveKey) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RedactJSON masks the values of sensitive keys at any depth of a JSON document. Bodies that are not
+// valid JSON are fully redacted because they can not be inspected.
+func RedactJSON(body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+
+	var document any
+	if err := json.Unmarshal(body, &document); err != nil {
+		return []byte(Redacted)
+	}
+
+	redacted, err := json.Marshal(redactValue(document))
+	if err != nil {
+		return []byte(Redacted)
+	}
+
+	return redacted
+}
+
+func redactValue(value any) any {
+	switch typedValue := value.(type) {
+	case map[string]any:
+		for key, nestedValue := range typedValue {
+			if IsSensitive(key) {
+				typedValue[key] = Redacted
+				continue
+			}
+
+			typedValue[key] = redactValue(nestedValue)
+		}
+
+		return typedValue
+	case []any:
+		for i, nestedValue := range typedValue {
+			typedValue[i] = redactValue(nestedValue)
+		}
+
+		return typedValue
+	default:
+		return value
+	}
+}
+
+type redactingCore struct {
+	zapcore.Core
+}
+
+// NewRedactingCore wraps a core masking every field with a sensitive key, so tokens or passwords are
+// never written even when a caller logs them by mistake. Use it with zap.WrapCore.
+func NewRedactingCore(core zapcore.Core) zapcore.Core {
+	return &redactingCore{Core: core}
+}
+
+func (r *redactingCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactingCore{Core: r.Core.With(redactFields(fields))}
+}
+
+func (r *redactingCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if r.Enabled(entry.Level) {
+		return checked.AddCore(entry, r)
+	}
+
+	return checked
+}
+
+func (r *redactingCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return r.Core.Write(entry, redactFields(fields))
+}
+
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+
+	for i, field := range fields {
+		if IsSensitive(field.Key) {
+			redacted[i] = zap.String(field.Key, Redacted)
+			continue
+		}
+
+		redacted[i] = field
+	}
+
+	return redacted
+}
@@ -0,0 +1,74 @@
+package logging
+
+import (
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"testing"
+)
+
+func TestIsSensitive_Successful(t *testing.T) {
+	for key, expected := range map[string]bool{
+		"Authorization":   true,
+		"password":        true,
+		"new_password":    true,
+		"token":           true,
+		"X-Refresh-Token": true,
+		"Cookie":          true,
+		"email":           false,
+		"first_name":      false,
+	} {
+		require.Equal(t, expected, IsSensitive(key), key)
+	}
+}
+
+func TestRedactJSON_Successful(t *testing.T) {
+	// Given
+	body := []byte(`{"email":"john@example.com","password":"12345678","nested":{"token":"abc"},"items":[{"secret":"x"}]}`)
+
+	// When
+	redacted := RedactJSON(body)
+
+	// Then
+	require.JSONEq(t, `{
+		"email":"john@example.com",
+		"password":"[REDACTED]",
+		"nested":{"token":"[REDACTED]"},
+		"items":[{"secret":"[REDACTED]"}]
+	}`, string(redacted))
+}
+
+func TestRedactJSON_SuccessfulWithEmptyBody(t *testing.T) {
+	// When
+	redacted := RedactJSON([]byte{})
+
+	// Then
+	require.Empty(t, redacted)
+}
+
+func TestRedactJSON_SuccessfulRedactsInvalidJSON(t *testing.T) {
+	// When
+	redacted := RedactJSON([]byte("email=john@example.com&password=12345678"))
+
+	// Then
+	require.Equal(t, Redacted, string(redacted))
+}
+
+func TestNewRedactingCore_Successful(t *testing.T) {
+	// Given
+	core, logs := observer.New(zapcore.InfoLevel)
+	logger := zap.New(NewRedactingCore(core)).With(zap.String("authorization", "Bearer abc"))
+
+	// When
+	logger.Info("login", zap.String("email", "john@example.com"), zap.String("password", "12345678"))
+	logger.Debug("not enabled", zap.String("token", "abc"))
+
+	// Then
+	require.Equal(t, 1, logs.Len())
+	require.Equal(t, map[string]any{
+		"authorization": Redacted,
+		"email":         "john@example.com",
+		"password":      Redacted,
+	}, logs.All()[0].ContextMap())
+}
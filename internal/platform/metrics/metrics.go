@@ -0,0 +1,128 @@
+package metrics
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+	"strconv"
+	"time"
+)
+
+// _unmatchedRoute labels requests that did not match any named route, keeping label cardinality bounded.
+const _unmatchedRoute = "unmatched"
+
+type Metrics struct {
+	registry        *prometheus.Registry
+	requestsTotal   *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+
+	UsersRegistered prometheus.Counter
+	TodosCreated    prometheus.Counter
+	TodosCompleted  prometheus.Counter
+	CacheRequests   *prometheus.CounterVec
+}
+
+func NewMetrics() *Metrics {
+	registry := prometheus.NewRegistry()
+
+	m := &Metrics{
+		registry: registry,
+		requestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total number of HTTP requests by route name, method and status.",
+		}, []string{"route", "method", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by route name, method and status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		UsersRegistered: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "users_registered_total",
+			Help: "Total number of registered users.",
+		}),
+		TodosCreated: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "todos_created_total",
+			Help: "Total number of created todos.",
+		}),
+		TodosCompleted: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "todos_completed_total",
+			Help: "Total number of completed todos.",
+		}),
+		CacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "cache_requests_total",
+			Help: "Total number of cache lookups by cache and result (hit, miss or error).",
+		}, []string{"cache", "result"}),
+	}
+
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requestsTotal,
+		m.requestDuration,
+		m.UsersRegistered,
+		m.TodosCreated,
+		m.TodosCompleted,
+		m.CacheRequests,
+	)
+
+	return m
+}
+
+// Registry exposes the underlying registry so other collectors can be attached.
+func (m *Metrics) Registry() *prometheus.Registry {
+	return m.registry
+}
+
+// Middleware records the count and latency of every request labelled by the Fiber route name.
+func (m *Metrics) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			// The error handler has not written the response yet.
+			if fiberErr, ok := err.(*fiber.Error); ok {
+				status = fiberErr.Code
+			} else {
+				status = fiber.StatusInternalServerError
+			}
+		}
+
+		route := c.Route().Name
+		if route == "" {
+			route = _unmatchedRoute
+		}
+
+		labels := prometheus.Labels{
+			"route":  route,
+			"method": c.Method(),
+			"status": strconv.Itoa(status),
+		}
+
+		m.requestsTotal.With(labels).Inc()
+		m.requestDuration.With(labels).Observe(time.Since(start).Seconds())
+
+		return err
+	}
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func (m *Metrics) Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
+
+// RegisterDB exposes the connection pool stats of the database.
+func (m *Metrics) RegisterDB(conn *sqlx.DB) error {
+	return m.registry.Register(collectors.NewDBStatsCollector(conn.DB, conn.DriverName()))
+}
+
+// RegisterRedis exposes the connection pool stats of the Redis client.
+func (m *Metrics) RegisterRedis(conn *redis.Client) error {
+	return m.registry.Register(newRedisPoolCollector(conn))
+}
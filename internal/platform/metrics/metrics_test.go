@@ -0,0 +1,177 @@
+package metrics
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-redis/redismock/v9"
+	"github.com/gofiber/fiber/v2"
+	"github.com/jmoiron/sqlx"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func createMetricsServer(m *Metrics) *fiber.App {
+	app := fiber.New()
+
+	app.Use(m.Middleware())
+
+	app.Route("/todos", func(api fiber.Router) {
+		api.Get("/:id<int>", func(c *fiber.Ctx) error {
+			return c.SendStatus(fiber.StatusOK)
+		}).Name("get")
+		api.Post("/", func(c *fiber.Ctx) error {
+			return fiber.ErrUnprocessableEntity
+		}).Name("save")
+	}, "todos.")
+
+	app.Get("/metrics", m.Handler()).Name("metrics")
+
+	return app
+}
+
+func TestMetricsMiddleware_SuccessfulLabelsByRouteName(t *testing.T) {
+	// Given
+	m := NewMetrics()
+	server := createMetricsServer(m)
+
+	req := httptest.NewRequest(fiber.MethodGet, "/todos/1", nil)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Equal(t, float64(1), testutil.ToFloat64(m.requestsTotal.WithLabelValues("todos.get", "GET", "200")))
+	require.Equal(t, 1, testutil.CollectAndCount(m.requestDuration))
+}
+
+func TestMetricsMiddleware_SuccessfulWithHandlerError(t *testing.T) {
+	// Given
+	m := NewMetrics()
+	server := createMetricsServer(m)
+
+	req := httptest.NewRequest(fiber.MethodPost, "/todos", nil)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+	require.Equal(t, float64(1), testutil.ToFloat64(m.requestsTotal.WithLabelValues("todos.save", "POST", "422")))
+}
+
+func TestMetricsMiddleware_SuccessfulWithUnmatchedRoute(t *testing.T) {
+	// Given
+	m := NewMetrics()
+	server := createMetricsServer(m)
+
+	req := httptest.NewRequest(fiber.MethodGet, "/not-found", nil)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	require.Equal(t, float64(1), testutil.ToFloat64(m.requestsTotal.WithLabelValues(_unmatchedRoute, "GET", "404")))
+}
+
+func TestMetricsHandler_Successful(t *testing.T) {
+	// Given
+	m := NewMetrics()
+	m.TodosCreated.Inc()
+
+	server := createMetricsServer(m)
+
+	req := httptest.NewRequest(fiber.MethodGet, "/metrics", nil)
+
+	// When
+	resp, err := server.Test(req)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	require.Contains(t, string(body), "todos_created_total 1")
+	require.Contains(t, string(body), "users_registered_total 0")
+	require.Contains(t, string(body), "go_goroutines")
+}
+
+func TestMetricsRegisterDB_Successful(t *testing.T) {
+	// Given
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+
+	m := NewMetrics()
+
+	// When
+	err = m.RegisterDB(dbx)
+
+	// Then
+	require.NoError(t, err)
+
+	families, err := m.Registry().Gather()
+	require.NoError(t, err)
+	require.True(t, hasMetricFamily(families, "go_sql_open_connections"))
+}
+
+func TestMetricsRegisterDB_FailsDueToDuplicatedRegistration(t *testing.T) {
+	// Given
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+
+	m := NewMetrics()
+	err = m.RegisterDB(dbx)
+	require.NoError(t, err)
+
+	// When
+	err = m.RegisterDB(dbx)
+
+	// Then
+	require.ErrorContains(t, err, "duplicate metrics collector registration attempted")
+}
+
+func TestMetricsRegisterRedis_Successful(t *testing.T) {
+	// Given
+	client, _ := redismock.NewClientMock()
+
+	m := NewMetrics()
+
+	// When
+	err := m.RegisterRedis(client)
+
+	// Then
+	require.NoError(t, err)
+
+	families, err := m.Registry().Gather()
+	require.NoError(t, err)
+	require.True(t, hasMetricFamily(families, "redis_pool_connections"))
+	require.True(t, hasMetricFamily(families, "redis_pool_hits_total"))
+}
+
+func hasMetricFamily(families []*dto.MetricFamily, name string) bool {
+	for _, family := range families {
+		if strings.EqualFold(family.GetName(), name) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+type redisPoolCollector struct {
+	conn *redis.Client
+
+	hits       *prometheus.Desc
+	misses     *prometheus.Desc
+	timeouts   *prometheus.Desc
+	totalConns *prometheus.Desc
+	idleConns  *prometheus.Desc
+	staleConns *prometheus.Desc
+}
+
+func newRedisPoolCollector(conn *redis.Client) prometheus.Collector {
+	return &redisPoolCollector{
+		conn: conn,
+		hits: prometheus.NewDesc("redis_pool_hits_total",
+			"Number of times a free connection was found in the pool.", nil, nil),
+		misses: prometheus.NewDesc("redis_pool_misses_total",
+			"Number of times a free connection was not found in the pool.", nil, nil),
+		timeouts: prometheus.NewDesc("redis_pool_timeouts_total",
+			"Number of times a wait timeout occurred.", nil, nil),
+		totalConns: prometheus.NewDesc("redis_pool_connections",
+			"Number of total connections in the pool.", nil, nil),
+		idleConns: prometheus.NewDesc("redis_pool_idle_connections",
+			"Number of idle connections in the pool.", nil, nil),
+		staleConns: prometheus.NewDesc("redis_pool_stale_connections_total",
+			"Number of stale connections removed from the pool.", nil, nil),
+	}
+}
+
+func (r *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- r.hits
+	ch <- r.misses
+	ch <- r.timeouts
+	ch <- r.totalConns
+	ch <- r.idleConns
+	ch <- r.staleConns
+}
+
+func (r *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := r.conn.PoolStats()
+
+	ch <- prometheus.MustNewConstMetric(r.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(r.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(r.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(r.totalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(r.idleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(r.staleConns, prometheus.CounterValue, float64(stats.StaleConns))
+}
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/todo"
+	"github.com/ferch5003/go-fiber-tutorial/internal/user"
+)
+
+type todoService struct {
+	todo.Service
+	metrics *Metrics
+}
+
+// NewTodoService decorates todo.Service counting created and completed todos.
+func NewTodoService(next todo.Service, metrics *Metrics) todo.Service {
+	return &todoService{
+		Service: next,
+		metrics: metrics,
+	}
+}
+
+func (s todoService) Save(ctx context.Context, todo domain.Todo) (domain.Todo, error) {
+	savedTodo, err := s.Service.Save(ctx, todo)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	s.metrics.TodosCreated.Inc()
+
+	return savedTodo, nil
+}
+
+func (s todoService) Completed(ctx context.Context, id int, version int) (domain.Todo, error) {
+	completedTodo, err := s.Service.Completed(ctx, id, version)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	s.metrics.TodosCompleted.Inc()
+
+	return completedTodo, nil
+}
+
+func (s todoService) Batch(
+	ctx context.Context,
+	userID int,
+	operations []domain.TodoOperation,
+	atomic bool) ([]domain.TodoOperationResult, error) {
+	results, err := s.Service.Batch(ctx, userID, operations, atomic)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+
+		switch result.Action {
+		case domain.TodoCreate:
+			s.metrics.TodosCreated.Inc()
+		case domain.TodoComplete:
+			s.metrics.TodosCompleted.Inc()
+		}
+	}
+
+	return results, nil
+}
+
+// Import counts the saved todos as created, and the ones imported completed as completed too.
+func (s todoService) Import(ctx context.Context, todoImport domain.TodoImport) ([]domain.TodoImportResult, error) {
+	results, err := s.Service.Import(ctx, todoImport)
+	if err != nil {
+		return nil, err
+	}
+
+	completedRows := make(map[int]bool, len(todoImport.Rows))
+	for _, row := range todoImport.Rows {
+		completedRows[row.Row] = row.Todo.Completed
+	}
+
+	for _, result := range results {
+		if result.ID == 0 {
+			continue
+		}
+
+		s.metrics.TodosCreated.Inc()
+
+		if completedRows[result.Row] {
+			s.metrics.TodosCompleted.Inc()
+		}
+	}
+
+	return results, nil
+}
+
+// Update counts the todo as completed when the changes complete a pending todo, reading it first only
+// when they complete it.
+func (s todoService) Update(
+	ctx context.Context,
+	changes domain.TodoChanges,
+	scope domain.TodoScope) (domain.Todo, error) {
+	completesTodo := false
+	if completed, ok := changes.Completed.Get(); ok && completed {
+		currentTodo, err := s.Service.Get(ctx, changes.ID)
+		completesTodo = err == nil && !currentTodo.Completed
+	}
+
+	updatedTodo, err := s.Service.Update(ctx, changes, scope)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	if completesTodo {
+		s.metrics.TodosCompleted.Inc()
+	}
+
+	return updatedTodo, nil
+}
+
+type userService struct {
+	user.Service
+	metrics *Metrics
+}
+
+// NewUserService decorates user.Service counting registered users.
+func NewUserService(next user.Service, metrics *Metrics) user.Service {
+	return &userService{
+		Service: next,
+		metrics: metrics,
+	}
+}
+
+func (s userService) Save(ctx context.Context, user domain.User) (domain.User, error) {
+	savedUser, err := s.Service.Save(ctx, user)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	s.metrics.UsersRegistered.Inc()
+
+	return savedUser, nil
+}
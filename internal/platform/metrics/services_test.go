@@ -0,0 +1,380 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/data"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type todoServiceMock struct {
+	mock.Mock
+}
+
+func (tsm *todoServiceMock) GetAll(ctx context.Context, userID int) ([]domain.Todo, error) {
+	args := tsm.Called(ctx, userID)
+	return args.Get(0).([]domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) GetPage(
+	ctx context.Context,
+	userID int,
+	after domain.Todo,
+	limit int) ([]domain.Todo, error) {
+	args := tsm.Called(ctx, userID, after, limit)
+	return args.Get(0).([]domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Get(ctx context.Context, id int) (domain.Todo, error) {
+	args := tsm.Called(ctx, id)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Save(ctx context.Context, todo domain.Todo) (domain.Todo, error) {
+	args := tsm.Called(ctx, todo)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Completed(ctx context.Context, id int, version int) (domain.Todo, error) {
+	args := tsm.Called(ctx, id, version)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Delete(ctx context.Context, id int, version int) error {
+	args := tsm.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+func (tsm *todoServiceMock) Batch(
+	ctx context.Context,
+	userID int,
+	operations []domain.TodoOperation,
+	atomic bool) ([]domain.TodoOperationResult, error) {
+	args := tsm.Called(ctx, userID, operations, atomic)
+	return args.Get(0).([]domain.TodoOperationResult), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Import(
+	ctx context.Context,
+	todoImport domain.TodoImport) ([]domain.TodoImportResult, error) {
+	args := tsm.Called(ctx, todoImport)
+	return args.Get(0).([]domain.TodoImportResult), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Search(ctx context.Context, search domain.TodoSearch) (domain.TodoSearchPage, error) {
+	args := tsm.Called(ctx, search)
+	return args.Get(0).(domain.TodoSearchPage), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Update(
+	ctx context.Context,
+	changes domain.TodoChanges,
+	scope domain.TodoScope) (domain.Todo, error) {
+	args := tsm.Called(ctx, changes, scope)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Skip(ctx context.Context, id int, version int) (domain.Todo, error) {
+	args := tsm.Called(ctx, id, version)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) EndSeries(ctx context.Context, id int, version int) (domain.Todo, error) {
+	args := tsm.Called(ctx, id, version)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Move(ctx context.Context, move domain.TodoMove) (domain.Todo, error) {
+	args := tsm.Called(ctx, move)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+type userServiceMock struct {
+	mock.Mock
+}
+
+func (usm *userServiceMock) GetAll(ctx context.Context) ([]domain.User, error) {
+	args := usm.Called(ctx)
+	return args.Get(0).([]domain.User), args.Error(1)
+}
+
+func (usm *userServiceMock) Get(ctx context.Context, id int) (domain.User, error) {
+	args := usm.Called(ctx, id)
+	return args.Get(0).(domain.User), args.Error(1)
+}
+
+func (usm *userServiceMock) GetByEmail(ctx context.Context, email string) (domain.User, error) {
+	args := usm.Called(ctx, email)
+	return args.Get(0).(domain.User), args.Error(1)
+}
+
+func (usm *userServiceMock) Save(ctx context.Context, user domain.User) (domain.User, error) {
+	args := usm.Called(ctx, user)
+	return args.Get(0).(domain.User), args.Error(1)
+}
+
+func (usm *userServiceMock) Update(ctx context.Context, user domain.User) (domain.User, error) {
+	args := usm.Called(ctx, user)
+	return args.Get(0).(domain.User), args.Error(1)
+}
+
+func (usm *userServiceMock) Delete(ctx context.Context, id int, version int) error {
+	args := usm.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+func (usm *userServiceMock) Login(ctx context.Context, email string, password string) (domain.User, error) {
+	args := usm.Called(ctx, email, password)
+	return args.Get(0).(domain.User), args.Error(1)
+}
+
+func TestTodoServiceSave_SuccessfulCountsCreatedTodo(t *testing.T) {
+	// Given
+	todo := domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: 1}
+	expectedTodo := todo
+	expectedTodo.ID = 1
+
+	tsm := new(todoServiceMock)
+	tsm.On("Save", mock.Anything, todo).Return(expectedTodo, nil)
+
+	m := NewMetrics()
+	service := NewTodoService(tsm, m)
+
+	// When
+	savedTodo, err := service.Save(context.Background(), todo)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, expectedTodo, savedTodo)
+	require.Equal(t, float64(1), testutil.ToFloat64(m.TodosCreated))
+}
+
+func TestTodoServiceSave_FailsDueToServiceError(t *testing.T) {
+	// Given
+	expectedError := errors.New("error saving todo")
+
+	tsm := new(todoServiceMock)
+	tsm.On("Save", mock.Anything, domain.Todo{}).Return(domain.Todo{}, expectedError)
+
+	m := NewMetrics()
+	service := NewTodoService(tsm, m)
+
+	// When
+	_, err := service.Save(context.Background(), domain.Todo{})
+
+	// Then
+	require.ErrorIs(t, err, expectedError)
+	require.Equal(t, float64(0), testutil.ToFloat64(m.TodosCreated))
+}
+
+func TestTodoServiceCompleted_SuccessfulCountsCompletedTodo(t *testing.T) {
+	// Given
+	tsm := new(todoServiceMock)
+	tsm.On("Completed", mock.Anything, 1, 0).Return(domain.Todo{}, nil)
+
+	m := NewMetrics()
+	service := NewTodoService(tsm, m)
+
+	// When
+	_, err := service.Completed(context.Background(), 1, 0)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, float64(1), testutil.ToFloat64(m.TodosCompleted))
+}
+
+func TestTodoServiceCompleted_FailsDueToServiceError(t *testing.T) {
+	// Given
+	expectedError := errors.New("error completing todo")
+
+	tsm := new(todoServiceMock)
+	tsm.On("Completed", mock.Anything, 1, 0).Return(domain.Todo{}, expectedError)
+
+	m := NewMetrics()
+	service := NewTodoService(tsm, m)
+
+	// When
+	_, err := service.Completed(context.Background(), 1, 0)
+
+	// Then
+	require.ErrorIs(t, err, expectedError)
+	require.Equal(t, float64(0), testutil.ToFloat64(m.TodosCompleted))
+}
+
+func TestTodoServiceUpdate_SuccessfulCountsCompletedTodo(t *testing.T) {
+	// Given
+	changes := domain.TodoChanges{ID: 1, Completed: data.Some(true)}
+
+	tsm := new(todoServiceMock)
+	tsm.On("Get", mock.Anything, 1).Return(domain.Todo{ID: 1, Title: "Lorem", UserID: 1}, nil)
+	tsm.On("Update", mock.Anything, changes, domain.TodoScopeThis).
+		Return(domain.Todo{ID: 1, Title: "Lorem", Completed: true, UserID: 1}, nil)
+
+	m := NewMetrics()
+	service := NewTodoService(tsm, m)
+
+	// When
+	_, err := service.Update(context.Background(), changes, domain.TodoScopeThis)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, float64(1), testutil.ToFloat64(m.TodosCompleted))
+}
+
+func TestTodoServiceUpdate_SuccessfulDoesNotCountCompletedTodoAgain(t *testing.T) {
+	// Given
+	changes := domain.TodoChanges{ID: 1, Completed: data.Some(true)}
+	completedTodo := domain.Todo{ID: 1, Title: "Lorem", Completed: true, UserID: 1}
+
+	tsm := new(todoServiceMock)
+	tsm.On("Get", mock.Anything, 1).Return(completedTodo, nil)
+	tsm.On("Update", mock.Anything, changes, domain.TodoScopeThis).Return(completedTodo, nil)
+
+	m := NewMetrics()
+	service := NewTodoService(tsm, m)
+
+	// When
+	_, err := service.Update(context.Background(), changes, domain.TodoScopeThis)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, float64(0), testutil.ToFloat64(m.TodosCompleted))
+}
+
+func TestTodoServiceUpdate_SuccessfulWithoutCompletingTodo(t *testing.T) {
+	// Given
+	changes := domain.TodoChanges{ID: 1, Title: "Dolor"}
+
+	tsm := new(todoServiceMock)
+	tsm.On("Update", mock.Anything, changes, domain.TodoScopeThis).
+		Return(domain.Todo{ID: 1, Title: "Dolor", UserID: 1}, nil)
+
+	m := NewMetrics()
+	service := NewTodoService(tsm, m)
+
+	// When
+	_, err := service.Update(context.Background(), changes, domain.TodoScopeThis)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, float64(0), testutil.ToFloat64(m.TodosCompleted))
+	tsm.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+}
+
+func TestTodoServiceImport_SuccessfulCountsCreatedAndCompletedTodos(t *testing.T) {
+	// Given
+	todoImport := domain.TodoImport{
+		UserID: 1,
+		Rows: []domain.TodoImportRow{
+			{Row: 1, Todo: domain.Todo{Title: "Lorem", UserID: 1}},
+			{Row: 2, Todo: domain.Todo{Title: "Dolor", Completed: true, UserID: 1}},
+			{Row: 3, Todo: domain.Todo{Title: "Sit", Completed: true, UserID: 1}},
+		},
+	}
+
+	tsm := new(todoServiceMock)
+	tsm.On("Import", mock.Anything, todoImport).Return([]domain.TodoImportResult{
+		{Row: 1, ID: 1},
+		{Row: 2, ID: 2},
+		{Row: 3, Err: domain.ErrDuplicateExternalID},
+	}, nil)
+
+	m := NewMetrics()
+	service := NewTodoService(tsm, m)
+
+	// When
+	_, err := service.Import(context.Background(), todoImport)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, float64(2), testutil.ToFloat64(m.TodosCreated))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.TodosCompleted))
+}
+
+func TestTodoServiceGet_SuccessfulDelegates(t *testing.T) {
+	// Given
+	expectedTodo := domain.Todo{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: 1}
+
+	tsm := new(todoServiceMock)
+	tsm.On("Get", mock.Anything, 1).Return(expectedTodo, nil)
+
+	service := NewTodoService(tsm, NewMetrics())
+
+	// When
+	todo, err := service.Get(context.Background(), 1)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, expectedTodo, todo)
+}
+
+func TestUserServiceSave_SuccessfulCountsRegisteredUser(t *testing.T) {
+	// Given
+	user := domain.User{FirstName: "John", LastName: "Doe", Email: "john@example.com"}
+	expectedUser := user
+	expectedUser.ID = 1
+
+	usm := new(userServiceMock)
+	usm.On("Save", mock.Anything, user).Return(expectedUser, nil)
+
+	m := NewMetrics()
+	service := NewUserService(usm, m)
+
+	// When
+	savedUser, err := service.Save(context.Background(), user)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, expectedUser, savedUser)
+	require.Equal(t, float64(1), testutil.ToFloat64(m.UsersRegistered))
+}
+
+func TestUserServiceSave_FailsDueToServiceError(t *testing.T) {
+	// Given
+	expectedError := errors.New("duplicated email")
+
+	usm := new(userServiceMock)
+	usm.On("Save", mock.Anything, domain.User{}).Return(domain.User{}, expectedError)
+
+	m := NewMetrics()
+	service := NewUserService(usm, m)
+
+	// When
+	_, err := service.Save(context.Background(), domain.User{})
+
+	// Then
+	require.ErrorIs(t, err, expectedError)
+	require.Equal(t, float64(0), testutil.ToFloat64(m.UsersRegistered))
+}
+
+func TestTodoServiceBatch_SuccessfulCountsAppliedOperations(t *testing.T) {
+	// Given
+	operations := []domain.TodoOperation{
+		{Action: domain.TodoCreate, Title: "Lorem", Description: data.Some("Ipsum")},
+		{Action: domain.TodoCreate, Title: "Dolor", Description: data.Some("Sit")},
+		{Action: domain.TodoComplete, ID: 1},
+	}
+
+	tsm := new(todoServiceMock)
+	tsm.On("Batch", mock.Anything, 1, operations, false).Return([]domain.TodoOperationResult{
+		{Index: 0, Action: domain.TodoCreate, ID: 2, Version: 1},
+		{Index: 1, Action: domain.TodoCreate, Err: errors.New("Data too long for column 'title'")},
+		{Index: 2, Action: domain.TodoComplete, ID: 1, Version: 2},
+	}, nil)
+
+	m := NewMetrics()
+	service := NewTodoService(tsm, m)
+
+	// When
+	results, err := service.Batch(context.Background(), 1, operations, false)
+
+	// Then
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	require.Equal(t, float64(1), testutil.ToFloat64(m.TodosCreated))
+	require.Equal(t, float64(1), testutil.ToFloat64(m.TodosCompleted))
+}
@@ -5,17 +5,11 @@ import (
 	"fmt"
 	"github.com/ferch5003/go-fiber-tutorial/config"
 	"github.com/ferch5003/go-fiber-tutorial/internal/platform"
-	"github.com/ferch5003/go-fiber-tutorial/internal/platform/files"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/sql"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/mysql"
-	"io/fs"
-	"path/filepath"
-	"regexp"
 )
 
-// _sqlFilesRegex only detects *.sql files on given filepath.
-const _sqlFilesRegex = `[\w.-]+\.sql$`
-
 type mysSQLContainer struct {
 	ctx       context.Context
 	container *mysql.MySQLContainer
@@ -28,12 +22,7 @@ func NewMySQLContainer(ctx context.Context) platform.Container {
 }
 
 func (c *mysSQLContainer) CreateOrUseContainer(config *config.EnvVars) error {
-	migrationsDir, err := files.GetDir("migrations")
-	if err != nil {
-		return err
-	}
-
-	migrationFiles, err := getSQLFiles(migrationsDir)
+	migrationFiles, err := sql.MigrationFiles(sql.MySQL)
 	if err != nil {
 		return err
 	}
@@ -62,32 +51,10 @@ func (c *mysSQLContainer) CreateOrUseContainer(config *config.EnvVars) error {
 }
 
 func (c *mysSQLContainer) CleanContainer() error {
-	return c.container.Terminate(c.ctx)
-}
-
-func getSQLFiles(dir string) ([]string, error) {
-	// slice with only *.sql files.
-	sqlFiles := make([]string, 0)
-
-	// This regex only accepts *.sql files.
-	re := regexp.MustCompile(_sqlFilesRegex)
-
-	walk := func(path string, info fs.FileInfo, err error) error {
-		if !re.MatchString(path) {
-			return nil
-		}
-
-		if !info.IsDir() {
-			sqlFiles = append(sqlFiles, path)
-		}
-
+	// Nothing to clean when the container was never created.
+	if c.container == nil {
 		return nil
 	}
 
-	err := filepath.Walk(dir, walk)
-	if err != nil {
-		return []string{}, err
-	}
-
-	return sqlFiles, nil
+	return c.container.Terminate(c.ctx)
 }
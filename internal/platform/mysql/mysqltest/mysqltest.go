@@ -0,0 +1,58 @@
+// Package mysqltest starts disposable MySQL databases, with the migrations applied, for tests that need
+// a real server. Tests are skipped when Docker is not available.
+package mysqltest
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/mysql"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"testing"
+)
+
+// NewConnection starts a MySQL container that is terminated when the test finishes.
+func NewConnection(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	configs := &config.EnvVars{
+		MySQLUsername: "root",
+		MySQLPassword: "root",
+		MySQLDB:       "fiber_example",
+	}
+
+	container := mysql.NewMySQLContainer(context.Background())
+
+	err := container.CreateOrUseContainer(configs)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, container.CleanContainer())
+	})
+
+	conn, err := mysql.NewConnection(configs)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, conn.Close())
+	})
+
+	return conn
+}
+
+// Truncate empties the tables and restarts their IDs.
+func Truncate(t *testing.T, conn *sqlx.DB) {
+	t.Helper()
+
+	// Children first because of the foreign keys.
+	for _, table := range []string{"outbox", "webhook_deliveries", "webhooks", "audit_events", "todos", "users"} {
+		_, err := conn.Exec("DELETE FROM " + table)
+		require.NoError(t, err)
+
+		_, err = conn.Exec("ALTER TABLE " + table + " AUTO_INCREMENT = 1")
+		require.NoError(t, err)
+	}
+}
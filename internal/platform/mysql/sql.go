@@ -3,13 +3,20 @@ package mysql
 import (
 	"fmt"
 	"github.com/ferch5003/go-fiber-tutorial/config"
+	mysqldriver "github.com/go-sql-driver/mysql"
 	"github.com/jmoiron/sqlx"
-
-	_ "github.com/go-sql-driver/mysql"
 )
 
 func NewConnection(config *config.EnvVars) (*sqlx.DB, error) {
-	db := sqlx.MustConnect("mysql", config.MySQLDSN)
+	dsnConfig, err := mysqldriver.ParseDSN(config.MySQLDSN)
+	if err != nil {
+		return nil, err
+	}
+
+	// Due dates are scanned into time.Time.
+	dsnConfig.ParseTime = true
+
+	db := sqlx.MustConnect("mysql", dsnConfig.FormatDSN())
 
 	if err := db.Ping(); err != nil {
 		return nil, err
@@ -0,0 +1,319 @@
+package openapi
+
+import (
+	"fmt"
+	"github.com/gofiber/fiber/v2"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Version of the OpenAPI specification of the generated documents.
+	Version = "3.1.0"
+
+	// BearerAuth is the name of the JWT security scheme.
+	BearerAuth = "bearerAuth"
+
+	_jsonContentType = fiber.MIMEApplicationJSON
+)
+
+var (
+	// _pathParamRegex matches Fiber params like ":id" or ":id<int>".
+	_pathParamRegex = regexp.MustCompile(`:(\w+)(<(\w+)>)?\??`)
+
+	// _versionNameRegex matches the API version prefix of route names, like "v1." in "v1.todos.get".
+	_versionNameRegex = regexp.MustCompile(`^v\d+\.`)
+)
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+	Tags       []map[string]string `json:"tags,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// PathItem maps lower-cased HTTP methods to their operations.
+type PathItem map[string]*OperationObject
+
+type OperationObject struct {
+	OperationID string                    `json:"operationId"`
+	Summary     string                    `json:"summary,omitempty"`
+	Tags        []string                  `json:"tags,omitempty"`
+	Parameters  []Parameter               `json:"parameters,omitempty"`
+	RequestBody *RequestBody              `json:"requestBody,omitempty"`
+	Responses   map[string]ResponseObject `json:"responses"`
+	Security    []map[string][]string     `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type ResponseObject struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Operation documents a named route. Request and Response bodies are Go values whose types are
+// converted to JSON schemas.
+type Operation struct {
+	Summary   string
+	Tags      []string
+	Secured   bool
+	Headers   []string // Optional request headers.
+	Query     any      // Struct whose fields tagged with "query" are the query parameters.
+	Request   any
+	Requests  map[string]any // Request bodies of other content types than application/json.
+	Responses map[int]Response
+}
+
+type Response struct {
+	Description string
+	ContentType string // Defaults to application/json when Body is set.
+	Body        any
+}
+
+// NewDocument builds the document from the route table of the application. Every route is documented
+// with the Operation registered under its name, without the API version prefix, so one Operation
+// documents the route in every version. Routes without an Operation are left out.
+func NewDocument(info Info, routes []fiber.Route, operations map[string]Operation) *Document {
+	generator := newSchemaGenerator()
+
+	document := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas: generator.schemas,
+			SecuritySchemes: map[string]SecurityScheme{
+				BearerAuth: {
+					Type:         "http",
+					Scheme:       "bearer",
+					BearerFormat: "JWT",
+				},
+			},
+		},
+	}
+
+	tags := make(map[string]struct{})
+
+	for _, route := range routes {
+		// HEAD routes are registered automatically for every GET route.
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+
+		operation, ok := operations[route.Name]
+		if !ok {
+			operation, ok = operations[_versionNameRegex.ReplaceAllString(route.Name, "")]
+		}
+
+		if !ok {
+			continue
+		}
+
+		path, parameters := convertPath(route.Path, generator)
+
+		pathItem, ok := document.Paths[path]
+		if !ok {
+			pathItem = make(PathItem)
+			document.Paths[path] = pathItem
+		}
+
+		pathItem[strings.ToLower(route.Method)] = newOperationObject(route.Name, operation, parameters, generator)
+
+		for _, tag := range operation.Tags {
+			tags[tag] = struct{}{}
+		}
+	}
+
+	for tag := range tags {
+		document.Tags = append(document.Tags, map[string]string{"name": tag})
+	}
+
+	sort.Slice(document.Tags, func(i, j int) bool {
+		return document.Tags[i]["name"] < document.Tags[j]["name"]
+	})
+
+	return document
+}
+
+// HasOperation reports whether the document describes the given Fiber method and path.
+func (d *Document) HasOperation(method, fiberPath string) bool {
+	path, _ := convertPath(fiberPath, newSchemaGenerator())
+
+	pathItem, ok := d.Paths[path]
+	if !ok {
+		return false
+	}
+
+	_, ok = pathItem[strings.ToLower(method)]
+
+	return ok
+}
+
+func newOperationObject(
+	name string,
+	operation Operation,
+	parameters []Parameter,
+	generator *schemaGenerator) *OperationObject {
+	operationObject := &OperationObject{
+		OperationID: name,
+		Summary:     operation.Summary,
+		Tags:        operation.Tags,
+		Parameters:  parameters,
+		Responses:   make(map[string]ResponseObject),
+	}
+
+	for _, header := range operation.Headers {
+		operationObject.Parameters = append(operationObject.Parameters, Parameter{
+			Name:   header,
+			In:     "header",
+			Schema: &Schema{Type: "string"},
+		})
+	}
+
+	if operation.Query != nil {
+		operationObject.Parameters = append(operationObject.Parameters,
+			queryParameters(reflect.TypeOf(operation.Query), generator)...)
+	}
+
+	if operation.Secured {
+		operationObject.Security = []map[string][]string{{BearerAuth: {}}}
+	}
+
+	if operation.Request != nil || len(operation.Requests) > 0 {
+		operationObject.RequestBody = &RequestBody{
+			Required: true,
+			Content:  make(map[string]MediaType),
+		}
+
+		if operation.Request != nil {
+			operationObject.RequestBody.Content[_jsonContentType] = MediaType{
+				Schema: generator.schemaOf(operation.Request),
+			}
+		}
+
+		for contentType, request := range operation.Requests {
+			operationObject.RequestBody.Content[contentType] = MediaType{Schema: generator.schemaOf(request)}
+		}
+	}
+
+	for status, response := range operation.Responses {
+		description := response.Description
+		if description == "" {
+			description = http.StatusText(status)
+		}
+
+		responseObject := ResponseObject{Description: description}
+
+		if response.Body != nil || response.ContentType != "" {
+			contentType := response.ContentType
+			if contentType == "" {
+				contentType = _jsonContentType
+			}
+
+			schema := &Schema{Type: "string"}
+			if response.Body != nil {
+				schema = generator.schemaOf(response.Body)
+			}
+
+			responseObject.Content = map[string]MediaType{
+				contentType: {Schema: schema},
+			}
+		}
+
+		operationObject.Responses[strconv.Itoa(status)] = responseObject
+	}
+
+	return operationObject
+}
+
+// queryParameters returns the query parameters of the struct fields tagged with "query". Fields are
+// required when validated as required.
+func queryParameters(t reflect.Type, generator *schemaGenerator) []Parameter {
+	parameters := make([]Parameter, 0, t.NumField())
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		name := field.Tag.Get("query")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		schema := generator.schemaOfType(field.Type)
+
+		parameters = append(parameters, Parameter{
+			Name:     name,
+			In:       "query",
+			Required: applyValidations(schema, field.Tag.Get("validate")),
+			Schema:   schema,
+		})
+	}
+
+	return parameters
+}
+
+// convertPath converts a Fiber path into an OpenAPI path and its path parameters.
+func convertPath(fiberPath string, generator *schemaGenerator) (string, []Parameter) {
+	parameters := make([]Parameter, 0)
+
+	for _, match := range _pathParamRegex.FindAllStringSubmatch(fiberPath, -1) {
+		schema := &Schema{Type: "string"}
+		if match[3] == "int" {
+			schema = generator.schemaOf(0)
+		}
+
+		parameters = append(parameters, Parameter{
+			Name:     match[1],
+			In:       "path",
+			Required: true,
+			Schema:   schema,
+		})
+	}
+
+	path := _pathParamRegex.ReplaceAllStringFunc(fiberPath, func(param string) string {
+		return fmt.Sprintf("{%s}", _pathParamRegex.FindStringSubmatch(param)[1])
+	})
+
+	// Fiber is not strict with trailing slashes, so "/todos/" is served as "/todos".
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+
+	return path, parameters
+}
@@ -0,0 +1,254 @@
+package openapi
+
+import (
+	"encoding/json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/require"
+	"mime/multipart"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testUser struct {
+	ID       int     `json:"id"`
+	Email    string  `json:"email" validate:"required,email"`
+	Password string  `json:"password" validate:"required,min=8,max=64"`
+	Nickname *string `json:"nickname"`
+	Ignored  string  `json:"-"`
+	internal string
+}
+
+func TestNewDocument_Successful(t *testing.T) {
+	// Given
+	app := fiber.New()
+	app.Get("/users/:id<int>", func(c *fiber.Ctx) error { return nil }).Name("users.get")
+	app.Post("/users/", func(c *fiber.Ctx) error { return nil }).Name("users.create")
+	app.Get("/undocumented", func(c *fiber.Ctx) error { return nil }).Name("undocumented")
+
+	operations := map[string]Operation{
+		"users.get": {
+			Summary: "Get a user",
+			Tags:    []string{"users"},
+			Query: struct {
+				Fields string `query:"fields" validate:"required,max=64"`
+				Limit  int    `query:"limit"`
+				Ignore string
+			}{},
+			Responses: map[int]Response{
+				fiber.StatusOK: {Body: testUser{}},
+			},
+		},
+		"users.create": {
+			Summary: "Create a user",
+			Tags:    []string{"users", "admin"},
+			Secured: true,
+			Headers: []string{"Idempotency-Key"},
+			Request: testUser{},
+			Responses: map[int]Response{
+				fiber.StatusNoContent: {},
+			},
+		},
+	}
+
+	// When
+	document := NewDocument(Info{Title: "test", Version: "1.0.0"}, app.GetRoutes(true), operations)
+
+	// Then
+	require.Equal(t, Version, document.OpenAPI)
+	require.Len(t, document.Paths, 2)
+	require.NotContains(t, document.Paths, "/undocumented")
+	require.Equal(t, []map[string]string{{"name": "admin"}, {"name": "users"}}, document.Tags)
+
+	get := document.Paths["/users/{id}"]["get"]
+	require.NotNil(t, get)
+	require.Nil(t, document.Paths["/users/{id}"]["head"])
+	require.Equal(t, "users.get", get.OperationID)
+	require.Empty(t, get.Security)
+	maxLength := 64
+	require.Equal(t, []Parameter{
+		{
+			Name:     "id",
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "integer", Format: "int64"},
+		},
+		{
+			Name:     "fields",
+			In:       "query",
+			Required: true,
+			Schema:   &Schema{Type: "string", MaxLength: &maxLength},
+		},
+		{
+			Name:   "limit",
+			In:     "query",
+			Schema: &Schema{Type: "integer", Format: "int64"},
+		},
+	}, get.Parameters)
+	require.Equal(t, "#/components/schemas/TestUser", get.Responses["200"].Content[fiber.MIMEApplicationJSON].Schema.Ref)
+	require.Equal(t, "OK", get.Responses["200"].Description)
+
+	create := document.Paths["/users"]["post"]
+	require.NotNil(t, create)
+	require.Equal(t, []map[string][]string{{BearerAuth: {}}}, create.Security)
+	require.Equal(t, []Parameter{{
+		Name:   "Idempotency-Key",
+		In:     "header",
+		Schema: &Schema{Type: "string"},
+	}}, create.Parameters)
+	require.True(t, create.RequestBody.Required)
+	require.Nil(t, create.Responses["204"].Content)
+
+	require.True(t, document.HasOperation(fiber.MethodGet, "/users/:id<int>"))
+	require.True(t, document.HasOperation(fiber.MethodPost, "/users/"))
+	require.False(t, document.HasOperation(fiber.MethodDelete, "/users/:id<int>"))
+	require.False(t, document.HasOperation(fiber.MethodGet, "/undocumented"))
+}
+
+func TestSchemaOf_Successful(t *testing.T) {
+	// Given
+	generator := newSchemaGenerator()
+
+	// When
+	schema := generator.schemaOf([]testUser{})
+
+	// Then
+	require.Equal(t, "array", schema.Type)
+	require.Equal(t, "#/components/schemas/TestUser", schema.Items.Ref)
+
+	component := generator.schemas["TestUser"]
+	require.NotNil(t, component)
+	require.Equal(t, []string{"email", "password"}, component.Required)
+	require.Len(t, component.Properties, 4)
+	require.NotContains(t, component.Properties, "Ignored")
+	require.NotContains(t, component.Properties, "internal")
+	require.Equal(t, "email", component.Properties["email"].Format)
+	require.Equal(t, 8, *component.Properties["password"].MinLength)
+	require.Equal(t, 64, *component.Properties["password"].MaxLength)
+	require.Equal(t, []string{"string", "null"}, component.Properties["nickname"].Type)
+}
+
+func TestSchemaOf_InlinesAnonymousStructs(t *testing.T) {
+	// Given
+	generator := newSchemaGenerator()
+
+	// When
+	schema := generator.schemaOf(struct {
+		Message string `json:"message"`
+	}{})
+
+	// Then
+	require.Empty(t, schema.Ref)
+	require.Equal(t, "object", schema.Type)
+	require.Equal(t, "string", schema.Properties["message"].Type)
+	require.Empty(t, generator.schemas)
+}
+
+func TestSchemaOf_SuccessfulWithTimes(t *testing.T) {
+	// Given
+	generator := newSchemaGenerator()
+
+	// When
+	schema := generator.schemaOf(struct {
+		CreatedAt time.Time  `json:"created_at"`
+		DeletedAt *time.Time `json:"deleted_at"`
+	}{})
+
+	// Then
+	require.Equal(t, &Schema{Type: "string", Format: "date-time"}, schema.Properties["created_at"])
+	require.Equal(t, []string{"string", "null"}, schema.Properties["deleted_at"].Type)
+	require.Equal(t, "date-time", schema.Properties["deleted_at"].Format)
+	require.Empty(t, generator.schemas)
+}
+
+func TestNewDocument_DocumentsEveryVersion(t *testing.T) {
+	// Given
+	app := fiber.New()
+	app.Get("/users", func(c *fiber.Ctx) error { return nil }).Name("users.get_all")
+	app.Get("/api/v1/users", func(c *fiber.Ctx) error { return nil }).Name("v1.users.get_all")
+	app.Get("/api/v2/users", func(c *fiber.Ctx) error { return nil }).Name("v2.users.get_all")
+
+	operations := map[string]Operation{
+		"users.get_all": {Summary: "List users"},
+	}
+
+	// When
+	document := NewDocument(Info{Title: "test", Version: "1.0.0"}, app.GetRoutes(true), operations)
+
+	// Then
+	require.Len(t, document.Paths, 3)
+	require.Equal(t, "users.get_all", document.Paths["/users"]["get"].OperationID)
+	require.Equal(t, "v1.users.get_all", document.Paths["/api/v1/users"]["get"].OperationID)
+	require.Equal(t, "v2.users.get_all", document.Paths["/api/v2/users"]["get"].OperationID)
+}
+
+type testNullable[T any] struct {
+	value T
+}
+
+func (n testNullable[T]) ValueType() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+func TestSchemaOf_SuccessfulWithNullables(t *testing.T) {
+	// Given
+	generator := newSchemaGenerator()
+
+	// When
+	schema := generator.schemaOf(struct {
+		Name  testNullable[string]    `json:"name" validate:"omitempty,min=3"`
+		Email testNullable[string]    `json:"email" validate:"omitnil,required,email"`
+		DueAt testNullable[time.Time] `json:"due_at"`
+	}{})
+
+	// Then
+	require.Equal(t, []string{"string", "null"}, schema.Properties["name"].Type)
+	require.Equal(t, 3, *schema.Properties["name"].MinLength)
+	require.Equal(t, "string", schema.Properties["email"].Type)
+	require.Equal(t, "email", schema.Properties["email"].Format)
+	require.Equal(t, []string{"string", "null"}, schema.Properties["due_at"].Type)
+	require.Equal(t, "date-time", schema.Properties["due_at"].Format)
+	require.Empty(t, schema.Required)
+	require.Empty(t, generator.schemas)
+}
+
+func TestNewDocument_DocumentsRequestContentTypes(t *testing.T) {
+	// Given
+	app := fiber.New()
+	app.Patch("/users/:id<int>", func(c *fiber.Ctx) error { return nil }).Name("users.update")
+
+	operations := map[string]Operation{
+		"users.update": {
+			Request: testUser{},
+			Requests: map[string]any{
+				"application/merge-patch+json": testUser{},
+				"application/json-patch+json":  []map[string]any{},
+			},
+		},
+	}
+
+	// When
+	document := NewDocument(Info{Title: "test", Version: "1.0.0"}, app.GetRoutes(true), operations)
+
+	// Then
+	content := document.Paths["/users/{id}"]["patch"].RequestBody.Content
+	require.Len(t, content, 3)
+	require.Equal(t, "#/components/schemas/TestUser", content[fiber.MIMEApplicationJSON].Schema.Ref)
+	require.Equal(t, "#/components/schemas/TestUser", content["application/merge-patch+json"].Schema.Ref)
+	require.Equal(t, "array", content["application/json-patch+json"].Schema.Type)
+	require.Equal(t, &Schema{}, newSchemaGenerator().schemaOf(json.RawMessage(`{}`)))
+}
+
+func TestSchemaOf_SuccessfulWithFile(t *testing.T) {
+	// Given
+	upload := struct {
+		File *multipart.FileHeader `json:"file" validate:"required"`
+	}{}
+
+	// When
+	schema := newSchemaGenerator().schemaOf(upload)
+
+	// Then
+	require.Equal(t, &Schema{Type: "string", Format: "binary"}, schema.Properties["file"])
+	require.Equal(t, []string{"file"}, schema.Required)
+}
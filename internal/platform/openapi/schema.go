@@ -0,0 +1,217 @@
+package openapi
+
+import (
+	"encoding/json"
+	"mime/multipart"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// _timeType is encoded by encoding/json as an RFC 3339 string, not as a struct.
+var _timeType = reflect.TypeOf(time.Time{})
+
+// _rawMessageType is any JSON value.
+var _rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+// _fileType is a file uploaded in a multipart form.
+var _fileType = reflect.TypeOf(multipart.FileHeader{})
+
+// _nullableType is implemented by wrappers encoded as their value or null, like data.Patch.
+var _nullableType = reflect.TypeOf((*nullable)(nil)).Elem()
+
+type nullable interface {
+	ValueType() reflect.Type
+}
+
+type Schema struct {
+	Ref        string             `json:"$ref,omitempty"`
+	Type       any                `json:"type,omitempty"` // A type name or a list of them to allow "null".
+	Format     string             `json:"format,omitempty"`
+	Properties map[string]*Schema `json:"properties,omitempty"`
+	Required   []string           `json:"required,omitempty"`
+	Items      *Schema            `json:"items,omitempty"`
+	MinLength  *int               `json:"minLength,omitempty"`
+	MaxLength  *int               `json:"maxLength,omitempty"`
+}
+
+// schemaGenerator converts Go types into JSON schemas, registering structs as named components.
+type schemaGenerator struct {
+	schemas map[string]*Schema
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		schemas: make(map[string]*Schema),
+	}
+}
+
+func (g *schemaGenerator) schemaOf(value any) *Schema {
+	return g.schemaOfType(reflect.TypeOf(value))
+}
+
+func (g *schemaGenerator) schemaOfType(t reflect.Type) *Schema {
+	if t == _timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	if t == _rawMessageType {
+		return &Schema{}
+	}
+
+	if t == _fileType || t == reflect.PointerTo(_fileType) {
+		return &Schema{Type: "string", Format: "binary"}
+	}
+
+	if t.Kind() != reflect.Pointer && t.Implements(_nullableType) {
+		return g.schemaOfType(reflect.PointerTo(reflect.Zero(t).Interface().(nullable).ValueType()))
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := g.schemaOfType(t.Elem())
+		if typeName, ok := schema.Type.(string); ok {
+			schema.Type = []string{typeName, "null"}
+		}
+
+		return schema
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schemaOfType(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object"}
+	case reflect.Struct:
+		return g.structSchema(t)
+	default:
+		return &Schema{}
+	}
+}
+
+// structSchema registers the struct as a component and returns a reference to it. Anonymous structs
+// are inlined.
+func (g *schemaGenerator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{
+		Type:       "object",
+		Properties: make(map[string]*Schema),
+	}
+
+	if t.Name() == "" {
+		g.fillStructSchema(schema, t)
+
+		return schema
+	}
+
+	name := componentName(t)
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+
+	if _, ok := g.schemas[name]; ok {
+		return ref
+	}
+
+	// Register before walking the fields to support recursive types.
+	g.schemas[name] = schema
+	g.fillStructSchema(schema, t)
+
+	return ref
+}
+
+func (g *schemaGenerator) fillStructSchema(schema *Schema, t reflect.Type) {
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		jsonName := jsonFieldName(field)
+		if jsonName == "-" {
+			continue
+		}
+
+		fieldSchema := g.schemaOfType(field.Type)
+		if required := applyValidations(fieldSchema, field.Tag.Get("validate")); required {
+			schema.Required = append(schema.Required, jsonName)
+		}
+
+		schema.Properties[jsonName] = fieldSchema
+	}
+}
+
+func componentName(t reflect.Type) string {
+	runes := []rune(t.Name())
+	runes[0] = unicode.ToUpper(runes[0])
+
+	return string(runes)
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+
+	return name
+}
+
+// applyValidations maps go-playground/validator rules to the schema and reports if the field is required.
+func applyValidations(schema *Schema, validate string) bool {
+	omittable, required := false, false
+
+	for _, rule := range strings.Split(validate, ",") {
+		name, value, _ := strings.Cut(rule, "=")
+
+		switch name {
+		case "omitnil":
+			omittable = true
+		case "required":
+			required = true
+		case "email":
+			schema.Format = "email"
+		case "min":
+			if length, err := strconv.Atoi(value); err == nil && isString(schema) {
+				schema.MinLength = &length
+			}
+		case "max":
+			if length, err := strconv.Atoi(value); err == nil && isString(schema) {
+				schema.MaxLength = &length
+			}
+		}
+	}
+
+	// A field that may be omitted but is required when sent, like the patch of a required field, can not
+	// be null.
+	if omittable && required {
+		if schemaType, ok := schema.Type.([]string); ok {
+			schema.Type = schemaType[0]
+		}
+
+		return false
+	}
+
+	return required
+}
+
+// isString reports whether the schema is of strings, which may be null.
+func isString(schema *Schema) bool {
+	switch schemaType := schema.Type.(type) {
+	case string:
+		return schemaType == "string"
+	case []string:
+		return slices.Contains(schemaType, "string")
+	default:
+		return false
+	}
+}
@@ -0,0 +1,71 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/sql"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/modules/postgres"
+	"github.com/testcontainers/testcontainers-go/wait"
+	"time"
+)
+
+// _startupTimeout is how long to wait for PostgreSQL to accept connections.
+const _startupTimeout = time.Minute
+
+type postgresContainer struct {
+	ctx       context.Context
+	container *postgres.PostgresContainer
+}
+
+func NewPostgresContainer(ctx context.Context) platform.Container {
+	return &postgresContainer{
+		ctx: ctx,
+	}
+}
+
+func (c *postgresContainer) CreateOrUseContainer(config *config.EnvVars) error {
+	migrationFiles, err := sql.MigrationFiles(sql.PostgreSQL)
+	if err != nil {
+		return err
+	}
+
+	container, err := postgres.RunContainer(c.ctx,
+		testcontainers.WithImage("docker.io/postgres:16-alpine"),
+		postgres.WithDatabase(config.PostgresDB),
+		postgres.WithUsername(config.PostgresUsername),
+		postgres.WithPassword(config.PostgresPassword),
+		postgres.WithInitScripts(migrationFiles...),
+		// The server restarts once after running the init scripts.
+		testcontainers.WithWaitStrategy(
+			wait.ForLog("database system is ready to accept connections").
+				WithOccurrence(2).
+				WithStartupTimeout(_startupTimeout),
+		),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to start container: %s", err)
+	}
+
+	c.container = container
+
+	connectionString, err := c.container.ConnectionString(c.ctx, "sslmode=disable")
+	if err != nil {
+		return fmt.Errorf("failed to obtain connection string: %s", err)
+	}
+
+	config.PostgresDSN = connectionString
+
+	return nil
+}
+
+func (c *postgresContainer) CleanContainer() error {
+	// Nothing to clean when the container was never created.
+	if c.container == nil {
+		return nil
+	}
+
+	return c.container.Terminate(c.ctx)
+}
@@ -0,0 +1,53 @@
+// Package postgrestest starts disposable PostgreSQL databases, with the migrations applied, for tests that
+// need a real server. Tests are skipped when Docker is not available.
+package postgrestest
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/postgres"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"testing"
+)
+
+// NewConnection starts a PostgreSQL container that is terminated when the test finishes.
+func NewConnection(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	configs := &config.EnvVars{
+		PostgresUsername: "postgres",
+		PostgresPassword: "postgres",
+		PostgresDB:       "fiber_example",
+	}
+
+	container := postgres.NewPostgresContainer(context.Background())
+
+	err := container.CreateOrUseContainer(configs)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, container.CleanContainer())
+	})
+
+	conn, err := postgres.NewConnection(configs)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, conn.Close())
+	})
+
+	return conn
+}
+
+// Truncate empties the tables and restarts their IDs.
+func Truncate(t *testing.T, conn *sqlx.DB) {
+	t.Helper()
+
+	_, err := conn.Exec("TRUNCATE TABLE outbox, webhook_deliveries, webhooks, audit_events, todos, users " +
+		"RESTART IDENTITY CASCADE")
+	require.NoError(t, err)
+}
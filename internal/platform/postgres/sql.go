@@ -0,0 +1,17 @@
+package postgres
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/jmoiron/sqlx"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+func NewConnection(config *config.EnvVars) (*sqlx.DB, error) {
+	db, err := sqlx.Connect("pgx", config.PostgresDSN)
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
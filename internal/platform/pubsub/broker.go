@@ -0,0 +1,86 @@
+// Package pubsub pushes the changes of the todos to the clients following them, on every instance of
+// the API.
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"strconv"
+	"strings"
+)
+
+const (
+	// _historySize is the number of events of every user kept to resume streams.
+	_historySize = 100
+
+	// _subscriberBuffer is the number of live events a subscriber can fall behind before it is dropped.
+	_subscriberBuffer = 16
+)
+
+// ErrClosed is returned when subscribing to a closed Broker.
+var ErrClosed = errors.New("the broker is closed")
+
+// Publisher receives the events of the written todos.
+type Publisher interface {
+	// Publish the event, returning it with the ID it was given.
+	Publish(ctx context.Context, event domain.TodoEvent) (domain.TodoEvent, error)
+}
+
+type Broker interface {
+	// Publisher publishes the events to the subscribers of their user.
+	Publisher
+
+	// Subscribe to the events of the user. When lastEventID is not empty, the kept events published
+	// after it are sent before the live ones; an ID that was not sent by the Broker returns
+	// domain.ErrInvalidEventID. The channel is closed when ctx is done, when the subscriber falls
+	// behind, which resumes subscribing again from its last event, or when the Broker is closed.
+	Subscribe(ctx context.Context, userID int, lastEventID string) (<-chan domain.TodoEvent, error)
+
+	// Close the channels of every subscriber.
+	Close() error
+}
+
+// eventID is an ID given by a Broker, like the "<milliseconds>-<sequence>" IDs of Redis streams. The
+// sequence of the in-memory IDs, which are a single number, is zero.
+type eventID struct {
+	first  uint64
+	second uint64
+}
+
+func parseEventID(id string) (eventID, error) {
+	first, second, hasSecond := strings.Cut(id, "-")
+
+	var parsed eventID
+	var err error
+
+	if parsed.first, err = strconv.ParseUint(first, 10, 64); err != nil {
+		return eventID{}, domain.ErrInvalidEventID
+	}
+
+	if !hasSecond {
+		return parsed, nil
+	}
+
+	if parsed.second, err = strconv.ParseUint(second, 10, 64); err != nil {
+		return eventID{}, domain.ErrInvalidEventID
+	}
+
+	return parsed, nil
+}
+
+// after reports if the ID orders after other.
+func (i eventID) after(other eventID) bool {
+	if i.first != other.first {
+		return i.first > other.first
+	}
+
+	return i.second > other.second
+}
+
+// publishedAfter reports if the event was published after the ID, which is valid.
+func publishedAfter(event domain.TodoEvent, id eventID) bool {
+	parsed, err := parseEventID(event.ID)
+
+	return err == nil && parsed.after(id)
+}
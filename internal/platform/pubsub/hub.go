@@ -0,0 +1,150 @@
+package pubsub
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"sync"
+)
+
+// subscriber receives the events of a user. Until it is activated, the live events are kept in its
+// backlog, so none is lost while the kept events are read.
+type subscriber struct {
+	userID  int
+	events  chan domain.TodoEvent
+	backlog []domain.TodoEvent
+	stop    func() bool
+}
+
+// hub delivers the events published on an instance to its subscribers.
+type hub struct {
+	mutex       sync.Mutex
+	closed      bool
+	subscribers map[int]map[*subscriber]struct{}
+}
+
+func newHub() *hub {
+	return &hub{
+		subscribers: make(map[int]map[*subscriber]struct{}),
+	}
+}
+
+// register a subscriber of the user, which is removed when ctx is done.
+func (h *hub) register(ctx context.Context, userID int) (*subscriber, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if h.closed {
+		return nil, ErrClosed
+	}
+
+	sub := &subscriber{userID: userID}
+
+	if h.subscribers[userID] == nil {
+		h.subscribers[userID] = make(map[*subscriber]struct{})
+	}
+
+	h.subscribers[userID][sub] = struct{}{}
+
+	sub.stop = context.AfterFunc(ctx, func() {
+		h.unregister(sub)
+	})
+
+	return sub, nil
+}
+
+// activate sends the replayed events to the subscriber, followed by the ones of its backlog published
+// after them, and returns its channel.
+func (h *hub) activate(sub *subscriber, replayed []domain.TodoEvent) <-chan domain.TodoEvent {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if _, ok := h.subscribers[sub.userID][sub]; !ok {
+		// The subscriber was removed while the events were read.
+		events := make(chan domain.TodoEvent)
+		close(events)
+
+		return events
+	}
+
+	events := replayed
+	if len(replayed) == 0 {
+		events = sub.backlog
+	} else {
+		last, _ := parseEventID(replayed[len(replayed)-1].ID)
+
+		for _, event := range sub.backlog {
+			if publishedAfter(event, last) {
+				events = append(events, event)
+			}
+		}
+	}
+
+	sub.events = make(chan domain.TodoEvent, len(events)+_subscriberBuffer)
+	for _, event := range events {
+		sub.events <- event
+	}
+
+	sub.backlog = nil
+
+	return sub.events
+}
+
+// deliver the event to the subscribers of its user. A subscriber whose channel is full is removed, so a
+// slow client does not hold the others back.
+func (h *hub) deliver(event domain.TodoEvent) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for sub := range h.subscribers[event.UserID] {
+		if sub.events == nil {
+			sub.backlog = append(sub.backlog, event)
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+			h.remove(sub)
+		}
+	}
+}
+
+// unregister removes the subscriber, closing its channel.
+func (h *hub) unregister(sub *subscriber) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.remove(sub)
+}
+
+// remove must be called holding the mutex. It closes the channel of the subscriber.
+func (h *hub) remove(sub *subscriber) {
+	if _, ok := h.subscribers[sub.userID][sub]; !ok {
+		return
+	}
+
+	delete(h.subscribers[sub.userID], sub)
+	if len(h.subscribers[sub.userID]) == 0 {
+		delete(h.subscribers, sub.userID)
+	}
+
+	sub.stop()
+
+	if sub.events != nil {
+		close(sub.events)
+	}
+}
+
+// close removes every subscriber, and refuses new ones.
+func (h *hub) close() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.closed = true
+
+	for _, subscribers := range h.subscribers {
+		for sub := range subscribers {
+			h.remove(sub)
+		}
+	}
+}
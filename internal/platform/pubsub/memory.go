@@ -0,0 +1,84 @@
+package pubsub
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"strconv"
+	"sync"
+)
+
+type memoryBroker struct {
+	hub     *hub
+	mutex   *sync.Mutex
+	lastID  int
+	history map[int][]domain.TodoEvent
+}
+
+// NewMemoryBroker creates a Broker that delivers the events to the subscribers of this instance only,
+// keeping the last events of every user to resume their streams. It replaces the Redis Broker when
+// there is a single instance.
+func NewMemoryBroker() Broker {
+	return &memoryBroker{
+		hub:     newHub(),
+		mutex:   &sync.Mutex{},
+		history: make(map[int][]domain.TodoEvent),
+	}
+}
+
+func (b *memoryBroker) Publish(_ context.Context, event domain.TodoEvent) (domain.TodoEvent, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.lastID++
+	event.ID = strconv.Itoa(b.lastID)
+
+	history := append(b.history[event.UserID], event)
+	if len(history) > _historySize {
+		history = history[len(history)-_historySize:]
+	}
+
+	b.history[event.UserID] = history
+
+	// Delivering holding the mutex keeps the events of every subscriber in the order of their IDs.
+	b.hub.deliver(event)
+
+	return event, nil
+}
+
+func (b *memoryBroker) Subscribe(
+	ctx context.Context,
+	userID int,
+	lastEventID string) (<-chan domain.TodoEvent, error) {
+	var last eventID
+	if lastEventID != "" {
+		var err error
+		if last, err = parseEventID(lastEventID); err != nil {
+			return nil, err
+		}
+	}
+
+	sub, err := b.hub.register(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var replayed []domain.TodoEvent
+
+	if lastEventID != "" {
+		b.mutex.Lock()
+		for _, event := range b.history[userID] {
+			if publishedAfter(event, last) {
+				replayed = append(replayed, event)
+			}
+		}
+		b.mutex.Unlock()
+	}
+
+	return b.hub.activate(sub, replayed), nil
+}
+
+func (b *memoryBroker) Close() error {
+	b.hub.close()
+
+	return nil
+}
@@ -0,0 +1,13 @@
+package pubsub_test
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/pubsub"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/pubsub/pubsubtest"
+	"testing"
+)
+
+func TestMemoryBroker_Conformance(t *testing.T) {
+	pubsubtest.RunBrokerTests(t, func(t *testing.T) pubsub.Broker {
+		return pubsub.NewMemoryBroker()
+	})
+}
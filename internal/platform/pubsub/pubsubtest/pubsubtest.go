@@ -0,0 +1,211 @@
+// Package pubsubtest provides a conformance suite that every pubsub.Broker implementation must pass.
+package pubsubtest
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/pubsub"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const (
+	// _userID is the user of the published events.
+	_userID = 1
+
+	// _timeout bounds waiting for an event.
+	_timeout = 5 * time.Second
+)
+
+// BrokerFactory creates a broker without kept events for every test.
+type BrokerFactory func(t *testing.T) pubsub.Broker
+
+// RunBrokerTests runs the conformance suite against the brokers created by newBroker.
+func RunBrokerTests(t *testing.T, newBroker BrokerFactory) {
+	t.Run("SubscriberReceivesPublishedEvents", func(t *testing.T) {
+		// Given
+		broker := newBroker(t)
+		ctx := context.Background()
+
+		events, err := broker.Subscribe(ctx, _userID, "")
+		require.NoError(t, err)
+
+		// When
+		created := publish(t, broker, domain.TodoEventCreated, 1)
+		completed := publish(t, broker, domain.TodoEventCompleted, 1)
+
+		// Then
+		require.NotEmpty(t, created.ID)
+		require.NotEqual(t, created.ID, completed.ID)
+
+		requireEvents(t, events, created, completed)
+	})
+
+	t.Run("SubscriberOnlyReceivesEventsOfItsUser", func(t *testing.T) {
+		// Given
+		broker := newBroker(t)
+		ctx := context.Background()
+
+		events, err := broker.Subscribe(ctx, _userID, "")
+		require.NoError(t, err)
+
+		// When
+		_, err = broker.Publish(ctx, domain.TodoEvent{
+			Type:   domain.TodoEventCreated,
+			UserID: _userID + 1,
+			Todo:   domain.Todo{ID: 1, UserID: _userID + 1},
+		})
+		require.NoError(t, err)
+
+		created := publish(t, broker, domain.TodoEventCreated, 2)
+
+		// Then
+		requireEvents(t, events, created)
+	})
+
+	t.Run("EverySubscriberReceivesTheEvents", func(t *testing.T) {
+		// Given
+		broker := newBroker(t)
+		ctx := context.Background()
+
+		first, err := broker.Subscribe(ctx, _userID, "")
+		require.NoError(t, err)
+
+		second, err := broker.Subscribe(ctx, _userID, "")
+		require.NoError(t, err)
+
+		// When
+		created := publish(t, broker, domain.TodoEventCreated, 1)
+
+		// Then
+		requireEvents(t, first, created)
+		requireEvents(t, second, created)
+	})
+
+	t.Run("SubscribeResumesAfterLastEvent", func(t *testing.T) {
+		// Given
+		broker := newBroker(t)
+		ctx := context.Background()
+
+		created := publish(t, broker, domain.TodoEventCreated, 1)
+		updated := publish(t, broker, domain.TodoEventUpdated, 1)
+		deleted := publish(t, broker, domain.TodoEventDeleted, 1)
+
+		// When
+		events, err := broker.Subscribe(ctx, _userID, created.ID)
+		require.NoError(t, err)
+
+		later := publish(t, broker, domain.TodoEventCreated, 2)
+
+		// Then
+		requireEvents(t, events, updated, deleted, later)
+	})
+
+	t.Run("SubscribeFailsDueToInvalidLastEventID", func(t *testing.T) {
+		// Given
+		broker := newBroker(t)
+
+		// When
+		_, err := broker.Subscribe(context.Background(), _userID, "invalid")
+
+		// Then
+		require.ErrorIs(t, err, domain.ErrInvalidEventID)
+	})
+
+	t.Run("SubscriptionEndsWithContext", func(t *testing.T) {
+		// Given
+		broker := newBroker(t)
+		ctx, cancel := context.WithCancel(context.Background())
+
+		events, err := broker.Subscribe(ctx, _userID, "")
+		require.NoError(t, err)
+
+		// When
+		cancel()
+
+		// Then
+		requireClosed(t, events)
+	})
+
+	t.Run("SlowSubscriberIsDropped", func(t *testing.T) {
+		// Given
+		broker := newBroker(t)
+
+		events, err := broker.Subscribe(context.Background(), _userID, "")
+		require.NoError(t, err)
+
+		// When
+		for todoID := range 200 {
+			publish(t, broker, domain.TodoEventCreated, todoID)
+		}
+
+		// Then
+		received := 0
+		for range events {
+			received++
+		}
+
+		require.Less(t, received, 200)
+	})
+
+	t.Run("CloseEndsSubscriptions", func(t *testing.T) {
+		// Given
+		broker := newBroker(t)
+
+		events, err := broker.Subscribe(context.Background(), _userID, "")
+		require.NoError(t, err)
+
+		// When
+		err = broker.Close()
+		require.NoError(t, err)
+
+		// Then
+		requireClosed(t, events)
+
+		_, err = broker.Subscribe(context.Background(), _userID, "")
+		require.ErrorIs(t, err, pubsub.ErrClosed)
+	})
+}
+
+// publish an event of the todo of the user, returning it with its ID.
+func publish(t *testing.T, broker pubsub.Broker, eventType string, todoID int) domain.TodoEvent {
+	t.Helper()
+
+	event, err := broker.Publish(context.Background(), domain.TodoEvent{
+		Type:   eventType,
+		UserID: _userID,
+		Todo:   domain.Todo{ID: todoID, Title: "Lorem", UserID: _userID},
+		At:     time.Date(2024, time.August, 1, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	return event
+}
+
+// requireEvents asserts the next events received are the expected ones.
+func requireEvents(t *testing.T, events <-chan domain.TodoEvent, expected ...domain.TodoEvent) {
+	t.Helper()
+
+	for _, expectedEvent := range expected {
+		select {
+		case event, ok := <-events:
+			require.True(t, ok, "the subscription ended")
+			require.Equal(t, expectedEvent, event)
+		case <-time.After(_timeout):
+			require.Failf(t, "event not received", "expected %s", expectedEvent.ID)
+		}
+	}
+}
+
+// requireClosed asserts the subscription ends.
+func requireClosed(t *testing.T, events <-chan domain.TodoEvent) {
+	t.Helper()
+
+	select {
+	case _, ok := <-events:
+		require.False(t, ok)
+	case <-time.After(_timeout):
+		require.Fail(t, "the subscription did not end")
+	}
+}
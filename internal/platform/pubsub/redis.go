@@ -0,0 +1,230 @@
+package pubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/logging"
+	"github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+const (
+	// _eventsChannel is where every instance publishes the events, for the subscribers of all of them.
+	_eventsChannel = "todos:events"
+
+	// _eventField is the field of a stream entry holding the event.
+	_eventField = "event"
+
+	// _historyTTL is how long the kept events of a user outlive the last one.
+	_historyTTL = 24 * time.Hour
+)
+
+type redisBroker struct {
+	client *redis.Client
+	hub    *hub
+
+	// mutex guards the subscription to the events channel, started by the first subscriber.
+	mutex  *sync.Mutex
+	closed bool
+	pubSub *redis.PubSub
+	done   chan struct{}
+}
+
+// NewRedisBroker creates a Broker that delivers the events to the subscribers of every instance through
+// Redis pub/sub. The last events of every user are kept in a Redis stream, whose entry IDs are the IDs
+// of the events, to resume their streams.
+func NewRedisBroker(client *redis.Client) Broker {
+	return &redisBroker{
+		client: client,
+		hub:    newHub(),
+		mutex:  &sync.Mutex{},
+	}
+}
+
+// historyKey is the key of the stream of the kept events of a user.
+func historyKey(userID int) string {
+	return fmt.Sprintf("todos:events:user:%d", userID)
+}
+
+func (b *redisBroker) Publish(ctx context.Context, event domain.TodoEvent) (domain.TodoEvent, error) {
+	event.ID = ""
+
+	value, err := json.Marshal(event)
+	if err != nil {
+		return domain.TodoEvent{}, err
+	}
+
+	key := historyKey(event.UserID)
+
+	var added *redis.StringCmd
+
+	_, err = b.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		added = pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: key,
+			MaxLen: _historySize,
+			Approx: true,
+			Values: map[string]any{_eventField: value},
+		})
+		pipe.Expire(ctx, key, _historyTTL)
+
+		return nil
+	})
+	if err != nil {
+		return domain.TodoEvent{}, err
+	}
+
+	event.ID = added.Val()
+
+	message, err := json.Marshal(event)
+	if err != nil {
+		return domain.TodoEvent{}, err
+	}
+
+	if err := b.client.Publish(ctx, _eventsChannel, message).Err(); err != nil {
+		return domain.TodoEvent{}, err
+	}
+
+	return event, nil
+}
+
+func (b *redisBroker) Subscribe(
+	ctx context.Context,
+	userID int,
+	lastEventID string) (<-chan domain.TodoEvent, error) {
+	var last eventID
+	if lastEventID != "" {
+		var err error
+		if last, err = parseEventID(lastEventID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := b.listen(ctx); err != nil {
+		return nil, err
+	}
+
+	// Registering before reading the stream keeps the events published in the meantime in the backlog.
+	sub, err := b.hub.register(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var replayed []domain.TodoEvent
+
+	if lastEventID != "" {
+		replayed, err = b.history(ctx, userID, lastEventID, last)
+		if err != nil {
+			b.hub.unregister(sub)
+
+			return nil, err
+		}
+	}
+
+	return b.hub.activate(sub, replayed), nil
+}
+
+// history reads the kept events of the user published after the last event.
+func (b *redisBroker) history(
+	ctx context.Context,
+	userID int,
+	lastEventID string,
+	last eventID) ([]domain.TodoEvent, error) {
+	messages, err := b.client.XRange(ctx, historyKey(userID), lastEventID, "+").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]domain.TodoEvent, 0, len(messages))
+
+	for _, message := range messages {
+		value, ok := message.Values[_eventField].(string)
+		if !ok {
+			continue
+		}
+
+		var event domain.TodoEvent
+		if err := json.Unmarshal([]byte(value), &event); err != nil {
+			return nil, err
+		}
+
+		event.ID = message.ID
+
+		// The range includes the last event.
+		if publishedAfter(event, last) {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+// listen subscribes to the events channel, unless it is already subscribed, forwarding its messages to
+// the subscribers of this instance.
+func (b *redisBroker) listen(ctx context.Context) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return ErrClosed
+	}
+
+	if b.pubSub != nil {
+		return nil
+	}
+
+	pubSub := b.client.Subscribe(ctx, _eventsChannel)
+
+	// Waiting for the confirmation ensures no event is published between reading the stream and
+	// receiving the messages.
+	if _, err := pubSub.Receive(ctx); err != nil {
+		return fmt.Errorf("subscribing to %s: %w", _eventsChannel, errors.Join(err, pubSub.Close()))
+	}
+
+	b.pubSub = pubSub
+	b.done = make(chan struct{})
+
+	go b.forward(pubSub.Channel(), b.done)
+
+	return nil
+}
+
+// forward delivers the messages of the events channel until it is closed.
+func (b *redisBroker) forward(messages <-chan *redis.Message, done chan<- struct{}) {
+	defer close(done)
+
+	for message := range messages {
+		var event domain.TodoEvent
+		if err := json.Unmarshal([]byte(message.Payload), &event); err != nil {
+			logging.FromContext(context.Background()).Warn("Error decoding todo event", zap.Error(err))
+			continue
+		}
+
+		b.hub.deliver(event)
+	}
+}
+
+func (b *redisBroker) Close() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return nil
+	}
+
+	b.closed = true
+	b.hub.close()
+
+	if b.pubSub == nil {
+		return nil
+	}
+
+	err := b.pubSub.Close()
+	<-b.done
+
+	return err
+}
@@ -0,0 +1,26 @@
+package pubsub_test
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/pubsub"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/pubsub/pubsubtest"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/redis/redistest"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRedisBroker_Conformance(t *testing.T) {
+	client := redistest.NewConnection(t)
+
+	pubsubtest.RunBrokerTests(t, func(t *testing.T) pubsub.Broker {
+		require.NoError(t, client.FlushDB(context.Background()).Err())
+
+		broker := pubsub.NewRedisBroker(client)
+
+		t.Cleanup(func() {
+			require.NoError(t, broker.Close())
+		})
+
+		return broker
+	})
+}
@@ -0,0 +1,228 @@
+package pubsub
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/logging"
+	"github.com/ferch5003/go-fiber-tutorial/internal/todo"
+	"go.uber.org/zap"
+	"time"
+)
+
+// _publishTimeout bounds publishing an event, which outlives the request that wrote the todo.
+const _publishTimeout = time.Second
+
+type todoService struct {
+	next       todo.Service
+	publishers []Publisher
+}
+
+// NewTodoService decorates todo.Service publishing an event for every written todo to the publishers.
+// Publishing errors are logged, as the write was already applied.
+func NewTodoService(next todo.Service, publishers ...Publisher) todo.Service {
+	return &todoService{
+		next:       next,
+		publishers: publishers,
+	}
+}
+
+func (s todoService) GetAll(ctx context.Context, userID int) ([]domain.Todo, error) {
+	return s.next.GetAll(ctx, userID)
+}
+
+func (s todoService) Get(ctx context.Context, id int) (domain.Todo, error) {
+	return s.next.Get(ctx, id)
+}
+
+func (s todoService) Search(ctx context.Context, search domain.TodoSearch) (domain.TodoSearchPage, error) {
+	return s.next.Search(ctx, search)
+}
+
+func (s todoService) GetPage(ctx context.Context, userID int, after domain.Todo, limit int) ([]domain.Todo, error) {
+	return s.next.GetPage(ctx, userID, after, limit)
+}
+
+func (s todoService) Save(ctx context.Context, todo domain.Todo) (domain.Todo, error) {
+	savedTodo, err := s.next.Save(ctx, todo)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	s.publish(ctx, domain.TodoEventCreated, savedTodo)
+
+	return savedTodo, nil
+}
+
+// Completed publishes the completed todo, and the next occurrence of its series it created.
+func (s todoService) Completed(ctx context.Context, id int, version int) (domain.Todo, error) {
+	completedTodo, err := s.next.Completed(ctx, id, version)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	s.publishWritten(ctx, domain.TodoEventCompleted, id)
+	s.publishNext(ctx, completedTodo.NextID)
+
+	return completedTodo, nil
+}
+
+func (s todoService) Delete(ctx context.Context, id int, version int) error {
+	// The todo is read before deleting it to know its user.
+	obtainedTodo, getErr := s.next.Get(ctx, id)
+
+	if err := s.next.Delete(ctx, id, version); err != nil {
+		return err
+	}
+
+	if getErr != nil {
+		logging.FromContext(ctx).Warn("Error reading deleted todo", zap.Int("todo_id", id), zap.Error(getErr))
+
+		return nil
+	}
+
+	s.publish(ctx, domain.TodoEventDeleted, domain.Todo{ID: id, UserID: obtainedTodo.UserID})
+
+	return nil
+}
+
+// Batch publishes an event for every applied operation, and for the occurrences they created.
+func (s todoService) Batch(
+	ctx context.Context,
+	userID int,
+	operations []domain.TodoOperation,
+	atomic bool) ([]domain.TodoOperationResult, error) {
+	results, err := s.next.Batch(ctx, userID, operations, atomic)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		if result.Err != nil {
+			continue
+		}
+
+		switch result.Action {
+		case domain.TodoCreate:
+			s.publishWritten(ctx, domain.TodoEventCreated, result.ID)
+		case domain.TodoUpdate:
+			s.publishWritten(ctx, domain.TodoEventUpdated, result.ID)
+		case domain.TodoComplete:
+			s.publishWritten(ctx, domain.TodoEventCompleted, result.ID)
+		case domain.TodoDelete:
+			s.publish(ctx, domain.TodoEventDeleted, domain.Todo{ID: result.ID, UserID: userID})
+		}
+
+		s.publishNext(ctx, result.NextID)
+	}
+
+	return results, nil
+}
+
+// Import publishes an event for every saved todo.
+func (s todoService) Import(ctx context.Context, todoImport domain.TodoImport) ([]domain.TodoImportResult, error) {
+	results, err := s.next.Import(ctx, todoImport)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, result := range results {
+		if result.ID != 0 {
+			s.publishWritten(ctx, domain.TodoEventCreated, result.ID)
+		}
+	}
+
+	return results, nil
+}
+
+func (s todoService) Update(
+	ctx context.Context,
+	changes domain.TodoChanges,
+	scope domain.TodoScope) (domain.Todo, error) {
+	updatedTodo, err := s.next.Update(ctx, changes, scope)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	// Empty changes are not written, so there is no change to publish.
+	if changes.IsEmpty() {
+		return updatedTodo, nil
+	}
+
+	s.publish(ctx, domain.TodoEventUpdated, updatedTodo)
+	s.publishNext(ctx, updatedTodo.NextID)
+
+	return updatedTodo, nil
+}
+
+func (s todoService) Skip(ctx context.Context, id int, version int) (domain.Todo, error) {
+	skippedTodo, err := s.next.Skip(ctx, id, version)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	s.publish(ctx, domain.TodoEventUpdated, skippedTodo)
+
+	return skippedTodo, nil
+}
+
+func (s todoService) EndSeries(ctx context.Context, id int, version int) (domain.Todo, error) {
+	endedTodo, err := s.next.EndSeries(ctx, id, version)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	s.publish(ctx, domain.TodoEventUpdated, endedTodo)
+
+	return endedTodo, nil
+}
+
+func (s todoService) Move(ctx context.Context, move domain.TodoMove) (domain.Todo, error) {
+	movedTodo, err := s.next.Move(ctx, move)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	s.publish(ctx, domain.TodoEventUpdated, movedTodo)
+
+	return movedTodo, nil
+}
+
+// publishWritten publishes the todo read after writing it, as the write does not return it.
+func (s todoService) publishWritten(ctx context.Context, eventType string, id int) {
+	writtenTodo, err := s.next.Get(ctx, id)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Error reading written todo", zap.Int("todo_id", id), zap.Error(err))
+
+		return
+	}
+
+	s.publish(ctx, eventType, writtenTodo)
+}
+
+// publishNext publishes the creation of the next occurrence of a series, if a write created one.
+func (s todoService) publishNext(ctx context.Context, nextID int) {
+	if nextID != 0 {
+		s.publishWritten(ctx, domain.TodoEventCreated, nextID)
+	}
+}
+
+func (s todoService) publish(ctx context.Context, eventType string, todo domain.Todo) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), _publishTimeout)
+	defer cancel()
+
+	event := domain.TodoEvent{
+		Type:   eventType,
+		UserID: todo.UserID,
+		Todo:   todo,
+		At:     time.Now().UTC(),
+	}
+
+	for _, publisher := range s.publishers {
+		if _, err := publisher.Publish(ctx, event); err != nil {
+			logging.FromContext(ctx).Warn("Error publishing todo event",
+				zap.String("type", eventType),
+				zap.Int("todo_id", todo.ID),
+				zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package pubsub
+
+import (
+	"context"
+	"errors"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/data"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+type todoServiceMock struct {
+	mock.Mock
+}
+
+func (tsm *todoServiceMock) GetAll(ctx context.Context, userID int) ([]domain.Todo, error) {
+	args := tsm.Called(ctx, userID)
+	return args.Get(0).([]domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) GetPage(
+	ctx context.Context,
+	userID int,
+	after domain.Todo,
+	limit int) ([]domain.Todo, error) {
+	args := tsm.Called(ctx, userID, after, limit)
+	return args.Get(0).([]domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Get(ctx context.Context, id int) (domain.Todo, error) {
+	args := tsm.Called(ctx, id)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Save(ctx context.Context, todo domain.Todo) (domain.Todo, error) {
+	args := tsm.Called(ctx, todo)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Completed(ctx context.Context, id int, version int) (domain.Todo, error) {
+	args := tsm.Called(ctx, id, version)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Delete(ctx context.Context, id int, version int) error {
+	args := tsm.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+func (tsm *todoServiceMock) Batch(
+	ctx context.Context,
+	userID int,
+	operations []domain.TodoOperation,
+	atomic bool) ([]domain.TodoOperationResult, error) {
+	args := tsm.Called(ctx, userID, operations, atomic)
+	return args.Get(0).([]domain.TodoOperationResult), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Import(
+	ctx context.Context,
+	todoImport domain.TodoImport) ([]domain.TodoImportResult, error) {
+	args := tsm.Called(ctx, todoImport)
+	return args.Get(0).([]domain.TodoImportResult), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Search(ctx context.Context, search domain.TodoSearch) (domain.TodoSearchPage, error) {
+	args := tsm.Called(ctx, search)
+	return args.Get(0).(domain.TodoSearchPage), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Update(
+	ctx context.Context,
+	changes domain.TodoChanges,
+	scope domain.TodoScope) (domain.Todo, error) {
+	args := tsm.Called(ctx, changes, scope)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Skip(ctx context.Context, id int, version int) (domain.Todo, error) {
+	args := tsm.Called(ctx, id, version)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) EndSeries(ctx context.Context, id int, version int) (domain.Todo, error) {
+	args := tsm.Called(ctx, id, version)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Move(ctx context.Context, move domain.TodoMove) (domain.Todo, error) {
+	args := tsm.Called(ctx, move)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+// subscribe returns a memory broker and the events it receives for the user.
+func subscribe(t *testing.T, userID int) (Broker, <-chan domain.TodoEvent) {
+	t.Helper()
+
+	broker := NewMemoryBroker()
+
+	events, err := broker.Subscribe(context.Background(), userID, "")
+	require.NoError(t, err)
+
+	return broker, events
+}
+
+func TestTodoServiceSave_PublishesCreated(t *testing.T) {
+	// Given
+	savedTodo := domain.Todo{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: 1, Version: 1}
+
+	tsm := new(todoServiceMock)
+	tsm.On("Save", mock.Anything, mock.Anything).Return(savedTodo, nil)
+
+	broker, events := subscribe(t, savedTodo.UserID)
+
+	service := NewTodoService(tsm, broker)
+
+	// When
+	obtainedTodo, err := service.Save(context.Background(), domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: 1})
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, savedTodo, obtainedTodo)
+
+	event := <-events
+	require.Equal(t, domain.TodoEventCreated, event.Type)
+	require.Equal(t, savedTodo.UserID, event.UserID)
+	require.Equal(t, savedTodo, event.Todo)
+	require.NotEmpty(t, event.ID)
+	require.False(t, event.At.IsZero())
+	tsm.AssertExpectations(t)
+}
+
+func TestTodoServiceSave_FailsWithoutPublishing(t *testing.T) {
+	// Given
+	expectedErr := errors.New("error")
+
+	tsm := new(todoServiceMock)
+	tsm.On("Save", mock.Anything, mock.Anything).Return(domain.Todo{}, expectedErr)
+
+	broker, events := subscribe(t, 1)
+
+	service := NewTodoService(tsm, broker)
+
+	// When
+	_, err := service.Save(context.Background(), domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: 1})
+
+	// Then
+	require.ErrorIs(t, err, expectedErr)
+	require.Empty(t, events)
+}
+
+func TestTodoServiceCompleted_PublishesCompletedTodo(t *testing.T) {
+	// Given
+	completedTodo := domain.Todo{ID: 1, Title: "Lorem", Completed: true, UserID: 1, Version: 2}
+
+	tsm := new(todoServiceMock)
+	tsm.On("Completed", mock.Anything, completedTodo.ID, 1).Return(domain.Todo{}, nil)
+	tsm.On("Get", mock.Anything, completedTodo.ID).Return(completedTodo, nil)
+
+	broker, events := subscribe(t, completedTodo.UserID)
+
+	service := NewTodoService(tsm, broker)
+
+	// When
+	_, err := service.Completed(context.Background(), completedTodo.ID, 1)
+
+	// Then
+	require.NoError(t, err)
+
+	event := <-events
+	require.Equal(t, domain.TodoEventCompleted, event.Type)
+	require.Equal(t, completedTodo, event.Todo)
+	tsm.AssertExpectations(t)
+}
+
+func TestTodoServiceCompleted_PublishesNextOccurrence(t *testing.T) {
+	// Given
+	completedTodo := domain.Todo{ID: 1, Title: "Lorem", Completed: true, UserID: 1, Version: 2, SeriesID: 1, NextID: 2}
+	nextTodo := domain.Todo{ID: 2, Title: "Lorem", UserID: 1, Version: 1, SeriesID: 1}
+
+	tsm := new(todoServiceMock)
+	tsm.On("Completed", mock.Anything, completedTodo.ID, 1).Return(completedTodo, nil)
+	tsm.On("Get", mock.Anything, completedTodo.ID).Return(completedTodo, nil)
+	tsm.On("Get", mock.Anything, nextTodo.ID).Return(nextTodo, nil)
+
+	broker, events := subscribe(t, completedTodo.UserID)
+
+	service := NewTodoService(tsm, broker)
+
+	// When
+	obtainedTodo, err := service.Completed(context.Background(), completedTodo.ID, 1)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, completedTodo, obtainedTodo)
+
+	completed := <-events
+	require.Equal(t, domain.TodoEventCompleted, completed.Type)
+
+	created := <-events
+	require.Equal(t, domain.TodoEventCreated, created.Type)
+	require.Equal(t, nextTodo, created.Todo)
+	tsm.AssertExpectations(t)
+}
+
+func TestTodoServiceDelete_PublishesDeleted(t *testing.T) {
+	// Given
+	deletedTodo := domain.Todo{ID: 1, Title: "Lorem", UserID: 1, Version: 1}
+
+	tsm := new(todoServiceMock)
+	tsm.On("Get", mock.Anything, deletedTodo.ID).Return(deletedTodo, nil)
+	tsm.On("Delete", mock.Anything, deletedTodo.ID, 0).Return(nil)
+
+	broker, events := subscribe(t, deletedTodo.UserID)
+
+	service := NewTodoService(tsm, broker)
+
+	// When
+	err := service.Delete(context.Background(), deletedTodo.ID, 0)
+
+	// Then
+	require.NoError(t, err)
+
+	event := <-events
+	require.Equal(t, domain.TodoEventDeleted, event.Type)
+	require.Equal(t, domain.Todo{ID: deletedTodo.ID, UserID: deletedTodo.UserID}, event.Todo)
+	tsm.AssertExpectations(t)
+}
+
+func TestTodoServiceUpdate_PublishesUpdated(t *testing.T) {
+	// Given
+	changes := domain.TodoChanges{ID: 1, Title: "Dolor"}
+	updatedTodo := domain.Todo{ID: 1, Title: "Dolor", UserID: 1, Version: 2}
+
+	tsm := new(todoServiceMock)
+	tsm.On("Update", mock.Anything, changes, domain.TodoScopeThis).Return(updatedTodo, nil)
+
+	broker, events := subscribe(t, updatedTodo.UserID)
+
+	service := NewTodoService(tsm, broker)
+
+	// When
+	obtainedTodo, err := service.Update(context.Background(), changes, domain.TodoScopeThis)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, updatedTodo, obtainedTodo)
+
+	event := <-events
+	require.Equal(t, domain.TodoEventUpdated, event.Type)
+	require.Equal(t, updatedTodo, event.Todo)
+}
+
+func TestTodoServiceUpdate_PublishesNextOccurrence(t *testing.T) {
+	// Given
+	changes := domain.TodoChanges{ID: 1, Completed: data.Some(true)}
+	updatedTodo := domain.Todo{ID: 1, Title: "Lorem", Completed: true, UserID: 1, Version: 2, SeriesID: 1, NextID: 2}
+	nextTodo := domain.Todo{ID: 2, Title: "Lorem", UserID: 1, Version: 1, SeriesID: 1}
+
+	tsm := new(todoServiceMock)
+	tsm.On("Update", mock.Anything, changes, domain.TodoScopeFuture).Return(updatedTodo, nil)
+	tsm.On("Get", mock.Anything, nextTodo.ID).Return(nextTodo, nil)
+
+	broker, events := subscribe(t, updatedTodo.UserID)
+
+	service := NewTodoService(tsm, broker)
+
+	// When
+	_, err := service.Update(context.Background(), changes, domain.TodoScopeFuture)
+
+	// Then
+	require.NoError(t, err)
+
+	updated := <-events
+	require.Equal(t, domain.TodoEventUpdated, updated.Type)
+
+	created := <-events
+	require.Equal(t, domain.TodoEventCreated, created.Type)
+	require.Equal(t, nextTodo, created.Todo)
+	tsm.AssertExpectations(t)
+}
+
+func TestTodoServiceUpdate_DoesNotPublishEmptyChanges(t *testing.T) {
+	// Given
+	changes := domain.TodoChanges{ID: 1}
+	currentTodo := domain.Todo{ID: 1, Title: "Lorem", UserID: 1, Version: 1}
+
+	tsm := new(todoServiceMock)
+	tsm.On("Update", mock.Anything, changes, domain.TodoScopeThis).Return(currentTodo, nil)
+
+	broker, events := subscribe(t, currentTodo.UserID)
+
+	service := NewTodoService(tsm, broker)
+
+	// When
+	_, err := service.Update(context.Background(), changes, domain.TodoScopeThis)
+
+	// Then
+	require.NoError(t, err)
+	require.Empty(t, events)
+	tsm.AssertExpectations(t)
+}
+
+func TestTodoServiceBatch_PublishesAppliedOperations(t *testing.T) {
+	// Given
+	userID := 1
+	createdTodo := domain.Todo{ID: 3, Title: "Lorem", UserID: userID, Version: 1}
+
+	operations := []domain.TodoOperation{
+		{Action: domain.TodoCreate, Title: "Lorem", Description: data.Some("Ipsum")},
+		{Action: domain.TodoComplete, ID: 1},
+		{Action: domain.TodoDelete, ID: 2},
+	}
+
+	results := []domain.TodoOperationResult{
+		{Index: 0, Action: domain.TodoCreate, ID: createdTodo.ID, Version: 1},
+		{Index: 1, Action: domain.TodoComplete, ID: 1, Err: domain.ErrNotOwned},
+		{Index: 2, Action: domain.TodoDelete, ID: 2},
+	}
+
+	tsm := new(todoServiceMock)
+	tsm.On("Batch", mock.Anything, userID, operations, false).Return(results, nil)
+	tsm.On("Get", mock.Anything, createdTodo.ID).Return(createdTodo, nil)
+
+	broker, events := subscribe(t, userID)
+
+	service := NewTodoService(tsm, broker)
+
+	// When
+	obtainedResults, err := service.Batch(context.Background(), userID, operations, false)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, results, obtainedResults)
+
+	created := <-events
+	require.Equal(t, domain.TodoEventCreated, created.Type)
+	require.Equal(t, createdTodo, created.Todo)
+
+	deleted := <-events
+	require.Equal(t, domain.TodoEventDeleted, deleted.Type)
+	require.Equal(t, domain.Todo{ID: 2, UserID: userID}, deleted.Todo)
+
+	require.Empty(t, events)
+	tsm.AssertExpectations(t)
+}
+
+func TestTodoServiceBatch_PublishesNextOccurrences(t *testing.T) {
+	// Given
+	userID := 1
+	completedTodo := domain.Todo{ID: 1, Title: "Lorem", Completed: true, UserID: userID, Version: 2, SeriesID: 1}
+	nextTodo := domain.Todo{ID: 2, Title: "Lorem", UserID: userID, Version: 1, SeriesID: 1}
+
+	operations := []domain.TodoOperation{{Action: domain.TodoComplete, ID: completedTodo.ID}}
+	results := []domain.TodoOperationResult{
+		{Index: 0, Action: domain.TodoComplete, ID: completedTodo.ID, Version: 2, NextID: nextTodo.ID},
+	}
+
+	tsm := new(todoServiceMock)
+	tsm.On("Batch", mock.Anything, userID, operations, false).Return(results, nil)
+	tsm.On("Get", mock.Anything, completedTodo.ID).Return(completedTodo, nil)
+	tsm.On("Get", mock.Anything, nextTodo.ID).Return(nextTodo, nil)
+
+	broker, events := subscribe(t, userID)
+
+	service := NewTodoService(tsm, broker)
+
+	// When
+	_, err := service.Batch(context.Background(), userID, operations, false)
+
+	// Then
+	require.NoError(t, err)
+
+	completed := <-events
+	require.Equal(t, domain.TodoEventCompleted, completed.Type)
+	require.Equal(t, completedTodo, completed.Todo)
+
+	created := <-events
+	require.Equal(t, domain.TodoEventCreated, created.Type)
+	require.Equal(t, nextTodo, created.Todo)
+
+	require.Empty(t, events)
+	tsm.AssertExpectations(t)
+}
@@ -0,0 +1,198 @@
+// Package recurrence computes the occurrences of recurring todos from iCalendar (RFC 5545) recurrence
+// rules.
+package recurrence
+
+import (
+	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/teambition/rrule-go"
+	"strings"
+	"time"
+)
+
+// _localTimeFormat is the format of a DTSTART with a TZID, in the local time of the zone.
+const _localTimeFormat = "20060102T150405"
+
+// _offsetZonePrefix starts the TZID of the zones that are only an offset, like "UTC+02:00", since due
+// dates in JSON carry an offset but no zone name.
+const _offsetZonePrefix = "UTC"
+
+// _shorthands are the rules of the common recurrences, repeating on the weekday, day of the month or
+// day of the year of the first occurrence.
+var _shorthands = map[string]string{
+	"daily":   "FREQ=DAILY",
+	"weekly":  "FREQ=WEEKLY",
+	"monthly": "FREQ=MONTHLY",
+	"yearly":  "FREQ=YEARLY",
+}
+
+// Normalize parses the rule, an RRULE like "FREQ=WEEKLY;BYDAY=MO,WE" or one of the shorthands daily,
+// weekly, monthly and yearly, and returns it with the series starting at start. The DTSTART of the rule
+// is replaced, so COUNT counts the occurrences from start. It keeps the time zone of start, so BYDAY and
+// BYMONTHDAY are the days where the todo is due, not the ones in UTC. Rules repeating more often than
+// daily are rejected, every occurrence is a todo.
+func Normalize(rule string, start time.Time) (string, error) {
+	if shorthand, ok := _shorthands[strings.ToLower(strings.TrimSpace(rule))]; ok {
+		rule = shorthand
+	}
+
+	option, _, err := parse(rule)
+	if err != nil {
+		return "", err
+	}
+
+	if option.Freq > rrule.DAILY {
+		return "", fmt.Errorf("%w: it can not repeat more often than daily", domain.ErrInvalidRecurrence)
+	}
+
+	option.Dtstart = start
+
+	if _, err := rrule.NewRRule(*option); err != nil {
+		return "", fmt.Errorf("%w: %s", domain.ErrInvalidRecurrence, err)
+	}
+
+	zone := zoneName(start)
+	if zone == "UTC" {
+		option.Dtstart = start.UTC()
+
+		return option.String(), nil
+	}
+
+	return fmt.Sprintf("DTSTART;TZID=%s:%s\nRRULE:%s", zone, start.Format(_localTimeFormat),
+		option.RRuleString()), nil
+}
+
+// Next returns the first occurrence of a rule returned by Normalize after the time, reporting false
+// when the series ends before.
+func Next(rule string, after time.Time) (time.Time, bool, error) {
+	option, start, err := parse(rule)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	option.Dtstart = start
+
+	r, err := rrule.NewRRule(*option)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%w: %s", domain.ErrInvalidRecurrence, err)
+	}
+
+	next := r.After(after, false)
+	if next.IsZero() {
+		return time.Time{}, false, nil
+	}
+
+	return next.UTC(), true, nil
+}
+
+// Location returns the time zone of the series of a rule returned by Normalize, UTC when it has none.
+func Location(rule string) *time.Location {
+	_, start, err := parse(rule)
+	if err != nil || start.IsZero() {
+		return time.UTC
+	}
+
+	return start.Location()
+}
+
+// Rule returns the RRULE of a rule returned by Normalize, like "FREQ=WEEKLY", without the start of the
+// series, which is the due date of its first occurrence.
+func Rule(rule string) string {
+	for _, line := range strings.Split(rule, "\n") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(line), "RRULE:"); ok {
+			return value
+		}
+	}
+
+	return rule
+}
+
+// parse returns the options of the RRULE of the rule and the start of its DTSTART, which is zero when
+// it has none. DTSTART is parsed here, as its TZID may be an offset zone the rrule package can not load.
+func parse(rule string) (*rrule.ROption, time.Time, error) {
+	var start time.Time
+
+	lines := make([]string, 0, 1)
+
+	for _, line := range strings.Split(strings.TrimSpace(rule), "\n") {
+		value, ok := strings.CutPrefix(strings.TrimSpace(line), "DTSTART")
+		if !ok {
+			lines = append(lines, line)
+
+			continue
+		}
+
+		var err error
+		if start, err = parseStart(value); err != nil {
+			return nil, time.Time{}, fmt.Errorf("%w: %s", domain.ErrInvalidRecurrence, err)
+		}
+	}
+
+	option, err := rrule.StrToROption(strings.Join(lines, "\n"))
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("%w: %s", domain.ErrInvalidRecurrence, err)
+	}
+
+	return option, start, nil
+}
+
+// parseStart parses the value of a DTSTART after its name, like ":20240101T140000Z" or
+// ";TZID=UTC+02:00:20240101T090000".
+func parseStart(value string) (time.Time, error) {
+	if utc, ok := strings.CutPrefix(value, ":"); ok {
+		return time.Parse("20060102T150405Z", utc)
+	}
+
+	tzid, ok := strings.CutPrefix(value, ";TZID=")
+	separator := strings.LastIndex(tzid, ":")
+
+	if !ok || separator < 0 {
+		return time.Time{}, fmt.Errorf("invalid DTSTART: %s", value)
+	}
+
+	location, err := loadZone(tzid[:separator])
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.ParseInLocation(_localTimeFormat, tzid[separator+1:], location)
+}
+
+// zoneName returns the TZID of the time zone of the time: its name when it can be loaded, otherwise its
+// offset, like "UTC+02:00".
+func zoneName(t time.Time) string {
+	if name := t.Location().String(); name != "" && name != "Local" {
+		if _, err := time.LoadLocation(name); err == nil {
+			return name
+		}
+	}
+
+	_, offset := t.Zone()
+	if offset == 0 {
+		return "UTC"
+	}
+
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+
+	return fmt.Sprintf("%s%s%02d:%02d", _offsetZonePrefix, sign, offset/3600, offset%3600/60)
+}
+
+// loadZone loads the time zone of a TZID returned by zoneName.
+func loadZone(tzid string) (*time.Location, error) {
+	offset, ok := strings.CutPrefix(tzid, _offsetZonePrefix)
+	if !ok || offset == "" {
+		return time.LoadLocation(tzid)
+	}
+
+	parsed, err := time.Parse("-07:00", offset)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TZID: %s", tzid)
+	}
+
+	_, seconds := parsed.Zone()
+
+	return time.FixedZone(tzid, seconds), nil
+}
@@ -0,0 +1,150 @@
+package recurrence
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestNormalize_Successful(t *testing.T) {
+	start := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.FixedZone("UTC-5", -5*60*60))
+
+	tests := []struct {
+		name     string
+		rule     string
+		expected string
+	}{
+		{name: "Shorthand", rule: " Weekly ", expected: "DTSTART;TZID=UTC-05:00:20240101T090000\nRRULE:FREQ=WEEKLY"},
+		{
+			name:     "Rule",
+			rule:     "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=4",
+			expected: "DTSTART;TZID=UTC-05:00:20240101T090000\nRRULE:FREQ=WEEKLY;COUNT=4;BYDAY=MO,WE",
+		},
+		{
+			name:     "ReplacesStart",
+			rule:     "DTSTART:20200101T000000Z\nRRULE:FREQ=DAILY",
+			expected: "DTSTART;TZID=UTC-05:00:20240101T090000\nRRULE:FREQ=DAILY",
+		},
+		{
+			name:     "ReplacesStartWithZone",
+			rule:     "DTSTART;TZID=UTC+02:00:20200101T000000\nRRULE:FREQ=DAILY",
+			expected: "DTSTART;TZID=UTC-05:00:20240101T090000\nRRULE:FREQ=DAILY",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			rule, err := Normalize(tt.rule, start)
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, rule)
+		})
+	}
+}
+
+func TestNormalize_SuccessfulInUTC(t *testing.T) {
+	// Given
+	start := time.Date(2024, time.January, 1, 14, 0, 0, 0, time.UTC)
+
+	// When
+	rule, err := Normalize("FREQ=WEEKLY", start)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "DTSTART:20240101T140000Z\nRRULE:FREQ=WEEKLY", rule)
+	require.Equal(t, time.UTC, Location(rule))
+}
+
+func TestNormalize_FailsDueToInvalidRule(t *testing.T) {
+	rules := []string{"FREQ=SOMETIMES", "FREQ=HOURLY", "FREQ=MINUTELY;COUNT=10", "FREQ=SECONDLY"}
+
+	for _, rule := range rules {
+		t.Run(rule, func(t *testing.T) {
+			// When
+			_, err := Normalize(rule, time.Now())
+
+			// Then
+			require.ErrorIs(t, err, domain.ErrInvalidRecurrence)
+		})
+	}
+}
+
+func TestNext_Successful(t *testing.T) {
+	// Given
+	start := time.Date(2024, time.January, 31, 9, 0, 0, 0, time.UTC)
+
+	rule, err := Normalize("FREQ=MONTHLY;BYMONTHDAY=-1;COUNT=2", start)
+	require.NoError(t, err)
+
+	// When
+	next, ok, err := Next(rule, start)
+
+	// Then
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, time.Date(2024, time.February, 29, 9, 0, 0, 0, time.UTC), next)
+}
+
+func TestNext_SuccessfulOnTheWeekdayOfTheTimeZone(t *testing.T) {
+	// Given
+	// Tuesday at 01:00 in UTC+02:00 is still Monday in UTC.
+	start := time.Date(2026, time.October, 20, 1, 0, 0, 0, time.FixedZone("", 2*60*60))
+
+	rule, err := Normalize("FREQ=WEEKLY;BYDAY=TU", start)
+	require.NoError(t, err)
+
+	// When
+	next, ok, err := Next(rule, start)
+
+	// Then
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, time.Date(2026, time.October, 26, 23, 0, 0, 0, time.UTC), next)
+	require.Equal(t, time.Tuesday, next.In(Location(rule)).Weekday())
+}
+
+func TestNext_SuccessfulWithSeriesEnded(t *testing.T) {
+	// Given
+	start := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+	rule, err := Normalize("FREQ=DAILY;COUNT=2", start)
+	require.NoError(t, err)
+
+	// When
+	_, ok, err := Next(rule, start.AddDate(0, 0, 1))
+
+	// Then
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestNext_FailsDueToInvalidRule(t *testing.T) {
+	// When
+	_, _, err := Next("RRULE:FREQ=SOMETIMES", time.Now())
+
+	// Then
+	require.ErrorIs(t, err, domain.ErrInvalidRecurrence)
+}
+
+func TestRule_Successful(t *testing.T) {
+	tests := map[string]struct {
+		rule     string
+		expected string
+	}{
+		"Normalized":   {rule: "DTSTART:20240101T140000Z\nRRULE:FREQ=WEEKLY;COUNT=4", expected: "FREQ=WEEKLY;COUNT=4"},
+		"WithoutStart": {rule: "FREQ=DAILY", expected: "FREQ=DAILY"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// When
+			rule := Rule(test.rule)
+
+			// Then
+			require.Equal(t, test.expected, rule)
+		})
+	}
+}
@@ -43,5 +43,10 @@ func (r *redisContainer) CreateOrUseContainer(config *config.EnvVars) (err error
 }
 
 func (r *redisContainer) CleanContainer() error {
+	// Nothing to clean when the container was never created.
+	if r.container == nil {
+		return nil
+	}
+
 	return r.container.Terminate(r.ctx)
 }
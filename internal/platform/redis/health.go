@@ -0,0 +1,33 @@
+package redis
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/health"
+	"github.com/redis/go-redis/v9"
+)
+
+type healthChecker struct {
+	sessionType string
+	conn        *redis.Client
+}
+
+func NewHealthChecker(config *config.EnvVars, conn *redis.Client) health.Checker {
+	return &healthChecker{
+		sessionType: config.AppSessionType,
+		conn:        conn,
+	}
+}
+
+func (h healthChecker) Name() string {
+	return "redis"
+}
+
+// Required only when sessions are stored in Redis ("app" session type).
+func (h healthChecker) Required() bool {
+	return h.sessionType == "app"
+}
+
+func (h healthChecker) Check(ctx context.Context) error {
+	return h.conn.Ping(ctx).Err()
+}
@@ -0,0 +1,56 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/go-redis/redismock/v9"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestHealthCheckerCheck_Successful(t *testing.T) {
+	// Given
+	db, mock := redismock.NewClientMock()
+	mock.ExpectPing().SetVal("PONG")
+
+	checker := NewHealthChecker(&config.EnvVars{AppSessionType: "app"}, db)
+
+	// When
+	err := checker.Check(context.Background())
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "redis", checker.Name())
+	require.True(t, checker.Required())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHealthCheckerRequired_FalseWithFiberSessions(t *testing.T) {
+	// Given
+	db, _ := redismock.NewClientMock()
+
+	checker := NewHealthChecker(&config.EnvVars{AppSessionType: "fiber"}, db)
+
+	// When
+	required := checker.Required()
+
+	// Then
+	require.False(t, required)
+}
+
+func TestHealthCheckerCheck_FailsDueToPingError(t *testing.T) {
+	// Given
+	db, mock := redismock.NewClientMock()
+
+	expectedError := errors.New("connection refused")
+	mock.ExpectPing().SetErr(expectedError)
+
+	checker := NewHealthChecker(&config.EnvVars{AppSessionType: "app"}, db)
+
+	// When
+	err := checker.Check(context.Background())
+
+	// Then
+	require.Equal(t, expectedError, err)
+}
@@ -0,0 +1,40 @@
+// Package redistest starts disposable Redis servers for tests that need a real server. Tests are
+// skipped when Docker is not available.
+package redistest
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/redis"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	"testing"
+)
+
+// NewConnection starts a Redis container that is terminated when the test finishes.
+func NewConnection(t *testing.T) *goredis.Client {
+	t.Helper()
+
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+
+	configs := &config.EnvVars{}
+
+	container := redis.NewRedisContainer(context.Background())
+
+	err := container.CreateOrUseContainer(configs)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, container.CleanContainer())
+	})
+
+	client, err := redis.NewConnection(configs)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, client.Close())
+	})
+
+	return client
+}
@@ -0,0 +1,79 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type memoryRepository struct {
+	mutex    *sync.RWMutex
+	sessions map[string]map[string]string
+}
+
+// NewMemoryRepository creates a thread-safe Repository that keeps sessions in memory. It behaves like
+// the Redis Repository, so it can replace it in development and tests.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{
+		mutex:    &sync.RWMutex{},
+		sessions: make(map[string]map[string]string),
+	}
+}
+
+func (r *memoryRepository) SetSession(_ context.Context, token string, claims map[string]any) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.sessions[token]; ok {
+		return nil
+	}
+
+	session := make(map[string]string, len(claims))
+	for key, value := range claims {
+		session[key] = formatClaim(value)
+	}
+
+	r.sessions[token] = session
+
+	return nil
+}
+
+func (r *memoryRepository) GetSession(_ context.Context, token string) (map[string]string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	session := make(map[string]string, len(r.sessions[token]))
+	for key, value := range r.sessions[token] {
+		session[key] = value
+	}
+
+	return session, nil
+}
+
+// formatClaim stores the claim as Redis does when writing a hash field.
+func formatClaim(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		if v {
+			return "1"
+		}
+
+		return "0"
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,13 @@
+package session_test
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session/sessiontest"
+	"testing"
+)
+
+func TestMemoryRepository_Conformance(t *testing.T) {
+	sessiontest.RunRepositoryTests(t, func(t *testing.T) session.Repository {
+		return session.NewMemoryRepository()
+	})
+}
@@ -0,0 +1,20 @@
+package session_test
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/redis/redistest"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session/sessiontest"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRepository_Conformance(t *testing.T) {
+	client := redistest.NewConnection(t)
+
+	sessiontest.RunRepositoryTests(t, func(t *testing.T) session.Repository {
+		require.NoError(t, client.FlushDB(context.Background()).Err())
+
+		return session.NewRepository(client)
+	})
+}
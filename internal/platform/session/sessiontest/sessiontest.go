@@ -0,0 +1,98 @@
+// Package sessiontest provides a conformance suite that every session.Repository implementation must
+// pass.
+package sessiontest
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// RepositoryFactory creates an empty repository for every test.
+type RepositoryFactory func(t *testing.T) session.Repository
+
+// RunRepositoryTests runs the conformance suite against the repositories created by newRepository.
+func RunRepositoryTests(t *testing.T, newRepository RepositoryFactory) {
+	t.Run("SetAndGetSession", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		claims := map[string]any{
+			"iss":  "test",
+			"sub":  1,
+			"exp":  float64(1708065314),
+			"name": "John Doe",
+		}
+
+		// When
+		err := repository.SetSession(ctx, "token", claims)
+		require.NoError(t, err)
+
+		obtainedSession, err := repository.GetSession(ctx, "token")
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{
+			"iss":  "test",
+			"sub":  "1",
+			"exp":  "1708065314",
+			"name": "John Doe",
+		}, obtainedSession)
+	})
+
+	t.Run("SetSessionKeepsExistingSession", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		err := repository.SetSession(ctx, "token", map[string]any{"sub": "1"})
+		require.NoError(t, err)
+
+		// When
+		err = repository.SetSession(ctx, "token", map[string]any{"sub": "2"})
+		require.NoError(t, err)
+
+		obtainedSession, err := repository.GetSession(ctx, "token")
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"sub": "1"}, obtainedSession)
+	})
+
+	t.Run("GetSessionReturnsEmptySessionForUnknownToken", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+
+		// When
+		obtainedSession, err := repository.GetSession(context.Background(), "unknown")
+
+		// Then
+		require.NoError(t, err)
+		require.Empty(t, obtainedSession)
+	})
+
+	t.Run("SessionsAreIsolatedByToken", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		err := repository.SetSession(ctx, "first", map[string]any{"sub": "1"})
+		require.NoError(t, err)
+
+		err = repository.SetSession(ctx, "second", map[string]any{"sub": "2"})
+		require.NoError(t, err)
+
+		// When
+		first, err := repository.GetSession(ctx, "first")
+		require.NoError(t, err)
+
+		second, err := repository.GetSession(ctx, "second")
+		require.NoError(t, err)
+
+		// Then
+		require.Equal(t, map[string]string{"sub": "1"}, first)
+		require.Equal(t, map[string]string{"sub": "2"}, second)
+	})
+}
@@ -0,0 +1,178 @@
+package sql
+
+import (
+	"strings"
+)
+
+// Cond is a condition of a WHERE clause, written with "?" placeholders.
+type Cond interface {
+	// write appends the condition to query, returning args with its arguments.
+	write(query *strings.Builder, args []any) []any
+}
+
+type expr struct {
+	sql  string
+	args []any
+}
+
+// Expr is a raw condition, like "version = ? OR ? = 0", with an argument for every placeholder.
+func Expr(sql string, args ...any) Cond {
+	return expr{sql: sql, args: args}
+}
+
+func (e expr) write(query *strings.Builder, args []any) []any {
+	query.WriteString(e.sql)
+
+	return append(args, e.args...)
+}
+
+type comparison struct {
+	column   string
+	operator string
+	value    any
+}
+
+// Eq is column = value, or column IS NULL when value is nil.
+func Eq(column string, value any) Cond {
+	if value == nil {
+		return IsNull(column)
+	}
+
+	return comparison{column: column, operator: "=", value: value}
+}
+
+// NotEq is column <> value.
+func NotEq(column string, value any) Cond {
+	return comparison{column: column, operator: "<>", value: value}
+}
+
+// Lt is column < value.
+func Lt(column string, value any) Cond {
+	return comparison{column: column, operator: "<", value: value}
+}
+
+// Lte is column <= value.
+func Lte(column string, value any) Cond {
+	return comparison{column: column, operator: "<=", value: value}
+}
+
+// Gt is column > value.
+func Gt(column string, value any) Cond {
+	return comparison{column: column, operator: ">", value: value}
+}
+
+// Gte is column >= value.
+func Gte(column string, value any) Cond {
+	return comparison{column: column, operator: ">=", value: value}
+}
+
+// Like is column LIKE pattern.
+func Like(column string, pattern string) Cond {
+	return comparison{column: column, operator: "LIKE", value: pattern}
+}
+
+func (c comparison) write(query *strings.Builder, args []any) []any {
+	query.WriteString(c.column + " " + c.operator + " ?")
+
+	return append(args, c.value)
+}
+
+// IsNull is column IS NULL.
+func IsNull(column string) Cond {
+	return Expr(column + " IS NULL")
+}
+
+// In is column IN (values...), which is false without values.
+func In[T any](column string, values []T) Cond {
+	if len(values) == 0 {
+		return Expr("1 = 0")
+	}
+
+	args := make([]any, 0, len(values))
+	for _, value := range values {
+		args = append(args, value)
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(values)), ", ")
+
+	return Expr(column+" IN ("+placeholders+")", args...)
+}
+
+type junction struct {
+	operator string
+	empty    string
+	conds    []Cond
+}
+
+// And joins the conditions with AND, it is true without conditions.
+func And(conds ...Cond) Cond {
+	return junction{operator: " AND ", empty: "1 = 1", conds: conds}
+}
+
+// Or joins the conditions with OR, it is false without conditions.
+func Or(conds ...Cond) Cond {
+	return junction{operator: " OR ", empty: "1 = 0", conds: conds}
+}
+
+func (j junction) write(query *strings.Builder, args []any) []any {
+	if len(j.conds) == 0 {
+		query.WriteString(j.empty)
+
+		return args
+	}
+
+	if len(j.conds) == 1 {
+		return j.conds[0].write(query, args)
+	}
+
+	query.WriteString("(")
+
+	for i, cond := range j.conds {
+		if i > 0 {
+			query.WriteString(j.operator)
+		}
+
+		// A raw condition may have operators of a lower precedence than the junction.
+		if _, ok := cond.(expr); ok {
+			query.WriteString("(")
+			args = cond.write(query, args)
+			query.WriteString(")")
+
+			continue
+		}
+
+		args = cond.write(query, args)
+	}
+
+	query.WriteString(")")
+
+	return args
+}
+
+// After selects the rows after a keyset, the values of the columns of the last row of the previous page,
+// in the ascending order of the columns.
+func After(columns []string, values ...any) Cond {
+	return keyset(columns, values, ">")
+}
+
+// Before selects the rows before a keyset, in the descending order of the columns.
+func Before(columns []string, values ...any) Cond {
+	return keyset(columns, values, "<")
+}
+
+// keyset expands (a, b) > (x, y) to a > x OR (a = x AND b > y), which every database supports.
+func keyset(columns []string, values []any, operator string) Cond {
+	conds := make([]Cond, 0, len(columns))
+
+	for i := range min(len(columns), len(values)) {
+		equal := make([]Cond, 0, i+1)
+		for j := range i {
+			equal = append(equal, Eq(columns[j], values[j]))
+		}
+
+		equal = append(equal, comparison{column: columns[i], operator: operator, value: values[i]})
+		conds = append(conds, And(equal...))
+	}
+
+	return Or(conds...)
+}
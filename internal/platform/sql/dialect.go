@@ -0,0 +1,206 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+	"strings"
+)
+
+const (
+	// MySQL is the name of the MySQL dialect, also used for unknown drivers.
+	MySQL = "mysql"
+
+	// PostgreSQL is the name of the PostgreSQL dialect.
+	PostgreSQL = "postgres"
+
+	// SQLite is the name of the SQLite dialect.
+	SQLite = "sqlite"
+)
+
+// Dialect hides the syntax differences between the supported databases. Queries are written with "?"
+// placeholders and rebound for the database.
+type Dialect interface {
+	// Name of the dialect, like "mysql".
+	Name() string
+
+	// Rebind converts the "?" placeholders of query into the placeholders of the database.
+	Rebind(query string) string
+
+	// InsertQuery prepares an INSERT statement to report the ID of the new row, with RETURNING when the
+	// database can not report the last inserted ID.
+	InsertQuery(query string) string
+
+	// InsertedID executes a statement prepared from InsertQuery and returns the ID of the new row.
+	InsertedID(ctx context.Context, stmt *sqlx.Stmt, args ...any) (int, error)
+
+	// Upsert returns the clause that updates the columns when an INSERT conflicts with an existing row
+	// on the conflict columns.
+	Upsert(conflictColumns []string, columns []string) string
+
+	// ForUpdate returns the clause that locks the selected rows until the end of the transaction.
+	ForUpdate() string
+
+	// IsUniqueViolation reports whether err is the violation of a unique index or primary key.
+	IsUniqueViolation(err error) bool
+}
+
+// NewDialect returns the Dialect of a database/sql driver name.
+func NewDialect(driverName string) Dialect {
+	switch driverName {
+	case "pgx", "postgres":
+		return postgresDialect{}
+	case "sqlite", "sqlite3":
+		return sqliteDialect{}
+	default:
+		return mysqlDialect{}
+	}
+}
+
+type mysqlDialect struct{}
+
+func (mysqlDialect) Name() string {
+	return MySQL
+}
+
+func (mysqlDialect) Rebind(query string) string {
+	return query
+}
+
+func (mysqlDialect) InsertQuery(query string) string {
+	return query
+}
+
+func (mysqlDialect) InsertedID(ctx context.Context, stmt *sqlx.Stmt, args ...any) (int, error) {
+	return lastInsertID(ctx, stmt, args...)
+}
+
+func (mysqlDialect) Upsert(_ []string, columns []string) string {
+	assignments := make([]string, 0, len(columns))
+	for _, column := range columns {
+		assignments = append(assignments, fmt.Sprintf("%s = VALUES(%s)", column, column))
+	}
+
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", ")
+}
+
+func (mysqlDialect) ForUpdate() string {
+	return " FOR UPDATE"
+}
+
+// IsUniqueViolation checks for ER_DUP_ENTRY.
+func (mysqlDialect) IsUniqueViolation(err error) bool {
+	var mysqlErr *mysqldriver.MySQLError
+
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == 1062
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string {
+	return PostgreSQL
+}
+
+func (postgresDialect) Rebind(query string) string {
+	return sqlx.Rebind(sqlx.DOLLAR, query)
+}
+
+// InsertQuery adds RETURNING because PostgreSQL drivers do not support LastInsertId.
+func (postgresDialect) InsertQuery(query string) string {
+	return strings.TrimSuffix(strings.TrimSpace(query), ";") + " RETURNING id;"
+}
+
+func (postgresDialect) InsertedID(ctx context.Context, stmt *sqlx.Stmt, args ...any) (int, error) {
+	var id int
+	if err := stmt.QueryRowxContext(ctx, args...).Scan(&id); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+func (postgresDialect) Upsert(conflictColumns []string, columns []string) string {
+	return onConflictUpdate(conflictColumns, columns)
+}
+
+func (postgresDialect) ForUpdate() string {
+	return " FOR UPDATE"
+}
+
+// IsUniqueViolation checks for the unique_violation SQLSTATE.
+func (postgresDialect) IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+
+	return errors.As(err, &pgErr) && pgErr.SQLState() == "23505"
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string {
+	return SQLite
+}
+
+func (sqliteDialect) Rebind(query string) string {
+	return query
+}
+
+func (sqliteDialect) InsertQuery(query string) string {
+	return query
+}
+
+func (sqliteDialect) InsertedID(ctx context.Context, stmt *sqlx.Stmt, args ...any) (int, error) {
+	return lastInsertID(ctx, stmt, args...)
+}
+
+func (sqliteDialect) Upsert(conflictColumns []string, columns []string) string {
+	return onConflictUpdate(conflictColumns, columns)
+}
+
+// ForUpdate is empty because SQLite has no row locks. Transactions begin immediately, locking the
+// whole database for writes instead.
+func (sqliteDialect) ForUpdate() string {
+	return ""
+}
+
+// IsUniqueViolation checks for the extended result codes of unique and primary key constraints.
+func (sqliteDialect) IsUniqueViolation(err error) bool {
+	var sqliteErr *sqlite.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+
+	code := sqliteErr.Code()
+
+	return code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+func lastInsertID(ctx context.Context, stmt *sqlx.Stmt, args ...any) (int, error) {
+	res, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(id), nil
+}
+
+// onConflictUpdate is the upsert clause shared by PostgreSQL and SQLite.
+func onConflictUpdate(conflictColumns []string, columns []string) string {
+	assignments := make([]string, 0, len(columns))
+	for _, column := range columns {
+		assignments = append(assignments, fmt.Sprintf("%s = excluded.%s", column, column))
+	}
+
+	return fmt.Sprintf("ON CONFLICT (%s) DO UPDATE SET %s",
+		strings.Join(conflictColumns, ", "),
+		strings.Join(assignments, ", "))
+}
@@ -0,0 +1,195 @@
+package sql
+
+import (
+	"context"
+	stdsql "database/sql"
+	"errors"
+	"fmt"
+	"github.com/DATA-DOG/go-sqlmock"
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNewDialect_Successful(t *testing.T) {
+	tests := map[string]string{
+		"mysql":    MySQL,
+		"sqlmock":  MySQL,
+		"pgx":      PostgreSQL,
+		"postgres": PostgreSQL,
+		"sqlite":   SQLite,
+		"sqlite3":  SQLite,
+	}
+
+	for driverName, expectedName := range tests {
+		t.Run(driverName, func(t *testing.T) {
+			// When
+			dialect := NewDialect(driverName)
+
+			// Then
+			require.Equal(t, expectedName, dialect.Name())
+		})
+	}
+}
+
+func TestDialectRebind_Successful(t *testing.T) {
+	// Given
+	query := `SELECT id FROM todos WHERE id = ? AND user_id = ?;`
+
+	// When
+	mysqlQuery := NewDialect("mysql").Rebind(query)
+	postgresQuery := NewDialect("pgx").Rebind(query)
+	sqliteQuery := NewDialect("sqlite").Rebind(query)
+
+	// Then
+	require.Equal(t, query, mysqlQuery)
+	require.Equal(t, `SELECT id FROM todos WHERE id = $1 AND user_id = $2;`, postgresQuery)
+	require.Equal(t, query, sqliteQuery)
+}
+
+func TestDialectInsertQuery_Successful(t *testing.T) {
+	// Given
+	query := `INSERT INTO todos (title) VALUES (?);`
+
+	// When
+	mysqlQuery := NewDialect("mysql").InsertQuery(query)
+	postgresQuery := NewDialect("pgx").InsertQuery(query)
+	sqliteQuery := NewDialect("sqlite").InsertQuery(query)
+
+	// Then
+	require.Equal(t, query, mysqlQuery)
+	require.Equal(t, `INSERT INTO todos (title) VALUES (?) RETURNING id;`, postgresQuery)
+	require.Equal(t, query, sqliteQuery)
+}
+
+func TestDialectUpsert_Successful(t *testing.T) {
+	// Given
+	conflictColumns := []string{"user_id", "key"}
+	columns := []string{"status", "response"}
+
+	// When
+	mysqlUpsert := NewDialect("mysql").Upsert(conflictColumns, columns)
+	postgresUpsert := NewDialect("pgx").Upsert(conflictColumns, columns)
+	sqliteUpsert := NewDialect("sqlite").Upsert(conflictColumns, columns)
+
+	// Then
+	require.Equal(t,
+		`ON DUPLICATE KEY UPDATE status = VALUES(status), response = VALUES(response)`, mysqlUpsert)
+	require.Equal(t,
+		`ON CONFLICT (user_id, key) DO UPDATE SET status = excluded.status, response = excluded.response`,
+		postgresUpsert)
+	require.Equal(t, postgresUpsert, sqliteUpsert)
+}
+
+func TestDialectForUpdate_Successful(t *testing.T) {
+	require.Equal(t, " FOR UPDATE", NewDialect("mysql").ForUpdate())
+	require.Equal(t, " FOR UPDATE", NewDialect("pgx").ForUpdate())
+	require.Empty(t, NewDialect("sqlite").ForUpdate())
+}
+
+func TestDialectInsertedID_SuccessfulWithLastInsertID(t *testing.T) {
+	// Given
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+	dialect := NewDialect(dbx.DriverName())
+
+	mock.ExpectPrepare(`INSERT INTO todos`).
+		ExpectExec().
+		WithArgs("Lorem").
+		WillReturnResult(sqlmock.NewResult(7, 1))
+
+	stmt, err := dbx.Preparex(dialect.InsertQuery(`INSERT INTO todos (title) VALUES (?);`))
+	require.NoError(t, err)
+
+	// When
+	id, err := dialect.InsertedID(context.Background(), stmt, "Lorem")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, 7, id)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDialectInsertedID_SuccessfulWithReturning(t *testing.T) {
+	// Given
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+	dialect := NewDialect("pgx")
+
+	mock.ExpectPrepare(`INSERT INTO todos \(title\) VALUES \(\$1\) RETURNING id;`).
+		ExpectQuery().
+		WithArgs("Lorem").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+
+	stmt, err := dbx.Preparex(dialect.Rebind(dialect.InsertQuery(`INSERT INTO todos (title) VALUES (?);`)))
+	require.NoError(t, err)
+
+	// When
+	id, err := dialect.InsertedID(context.Background(), stmt, "Lorem")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, 7, id)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDialectIsUniqueViolation_Successful(t *testing.T) {
+	tests := map[string]struct {
+		driverName string
+		err        error
+	}{
+		"MySQL": {
+			driverName: "mysql",
+			err:        fmt.Errorf("insert: %w", &mysqldriver.MySQLError{Number: 1062, Message: "Duplicate entry"}),
+		},
+		"PostgreSQL": {
+			driverName: "pgx",
+			err:        fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23505"}),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// When
+			dialect := NewDialect(test.driverName)
+
+			// Then
+			require.True(t, dialect.IsUniqueViolation(test.err))
+			require.False(t, dialect.IsUniqueViolation(errors.New("other error")))
+		})
+	}
+}
+
+func TestDialectIsUniqueViolation_SuccessfulWithSQLite(t *testing.T) {
+	// Given
+	db, err := stdsql.Open("sqlite", ":memory:")
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dialect := NewDialect("sqlite")
+
+	_, err = db.Exec(`CREATE TABLE todos (id INTEGER PRIMARY KEY, title TEXT NOT NULL UNIQUE);`)
+	require.NoError(t, err)
+
+	_, err = db.Exec(`INSERT INTO todos (title) VALUES ('Lorem');`)
+	require.NoError(t, err)
+
+	// When
+	_, err = db.Exec(`INSERT INTO todos (title) VALUES ('Lorem');`)
+	_, nullErr := db.Exec(`INSERT INTO todos (title) VALUES (NULL);`)
+
+	// Then
+	require.True(t, dialect.IsUniqueViolation(err))
+	require.False(t, dialect.IsUniqueViolation(nullErr))
+}
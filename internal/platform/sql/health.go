@@ -0,0 +1,32 @@
+package sql
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/health"
+	"github.com/jmoiron/sqlx"
+)
+
+type healthChecker struct {
+	conn    *sqlx.DB
+	dialect Dialect
+}
+
+// NewHealthChecker checks the database is reachable. The component is named after its dialect.
+func NewHealthChecker(conn *sqlx.DB) health.Checker {
+	return &healthChecker{
+		conn:    conn,
+		dialect: NewDialect(conn.DriverName()),
+	}
+}
+
+func (h healthChecker) Name() string {
+	return h.dialect.Name()
+}
+
+func (h healthChecker) Required() bool {
+	return true
+}
+
+func (h healthChecker) Check(ctx context.Context) error {
+	return h.conn.PingContext(ctx)
+}
@@ -0,0 +1,84 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestHealthCheckerCheck_Successful(t *testing.T) {
+	// Given
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+
+	mock.ExpectPing()
+
+	checker := NewHealthChecker(dbx)
+
+	// When
+	err = checker.Check(context.Background())
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "mysql", checker.Name())
+	require.True(t, checker.Required())
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHealthCheckerCheck_FailsDueToPingError(t *testing.T) {
+	// Given
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+
+	expectedError := errors.New("connection refused")
+	mock.ExpectPing().WillReturnError(expectedError)
+
+	checker := NewHealthChecker(dbx)
+
+	// When
+	err = checker.Check(context.Background())
+
+	// Then
+	require.ErrorIs(t, err, expectedError)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestHealthCheckerName_Successful(t *testing.T) {
+	tests := []struct {
+		driverName   string
+		expectedName string
+	}{
+		{driverName: "mysql", expectedName: MySQL},
+		{driverName: "pgx", expectedName: PostgreSQL},
+		{driverName: "sqlite", expectedName: SQLite},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.driverName, func(t *testing.T) {
+			// Given
+			db, _, err := sqlmock.New()
+			require.NoError(t, err)
+
+			defer db.Close()
+
+			checker := NewHealthChecker(sqlx.NewDb(db, tt.driverName))
+
+			// When
+			name := checker.Name()
+
+			// Then
+			require.Equal(t, tt.expectedName, name)
+		})
+	}
+}
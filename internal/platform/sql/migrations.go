@@ -0,0 +1,50 @@
+package sql
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/files"
+	"io/fs"
+	"path/filepath"
+	"regexp"
+)
+
+// _sqlFilesRegex only detects *.sql files on given filepath.
+const _sqlFilesRegex = `[\w.-]+\.sql$`
+
+// MigrationFiles returns the *.sql migration scripts of the dialect, found in "migrations/<dialect>", in
+// the order they must run.
+func MigrationFiles(dialect string) ([]string, error) {
+	migrationsDir, err := files.GetDir(filepath.Join("migrations", dialect))
+	if err != nil {
+		return nil, err
+	}
+
+	return getSQLFiles(migrationsDir)
+}
+
+func getSQLFiles(dir string) ([]string, error) {
+	// slice with only *.sql files.
+	sqlFiles := make([]string, 0)
+
+	// This regex only accepts *.sql files.
+	re := regexp.MustCompile(_sqlFilesRegex)
+
+	// Walk visits files in lexical order, which is the order of the timestamped migrations.
+	walk := func(path string, info fs.FileInfo, err error) error {
+		if !re.MatchString(path) {
+			return nil
+		}
+
+		if !info.IsDir() {
+			sqlFiles = append(sqlFiles, path)
+		}
+
+		return nil
+	}
+
+	err := filepath.Walk(dir, walk)
+	if err != nil {
+		return []string{}, err
+	}
+
+	return sqlFiles, nil
+}
@@ -0,0 +1,33 @@
+package sql
+
+import (
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrationFiles_Successful(t *testing.T) {
+	for _, dialect := range []string{MySQL, PostgreSQL, SQLite} {
+		t.Run(dialect, func(t *testing.T) {
+			// When
+			migrationFiles, err := MigrationFiles(dialect)
+
+			// Then
+			require.NoError(t, err)
+			require.NotEmpty(t, migrationFiles)
+
+			for _, migrationFile := range migrationFiles {
+				require.Equal(t, ".sql", filepath.Ext(migrationFile))
+				require.Equal(t, dialect, filepath.Base(filepath.Dir(migrationFile)))
+			}
+		})
+	}
+}
+
+func TestMigrationFiles_FailsDueToUnknownDialect(t *testing.T) {
+	// When
+	_, err := MigrationFiles("oracle")
+
+	// Then
+	require.Error(t, err)
+}
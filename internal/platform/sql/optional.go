@@ -0,0 +1,58 @@
+package sql
+
+// Optional is a column value of an UPDATE that may be absent, leaving the column as it is. Present zero
+// values and NULL are written, so columns can be cleared. The zero Optional is absent.
+type Optional[T any] struct {
+	value   T
+	present bool
+	null    bool
+}
+
+// Some returns a present value, written even when it is the zero value.
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{value: value, present: true}
+}
+
+// Null returns a present NULL.
+func Null[T any]() Optional[T] {
+	return Optional[T]{present: true, null: true}
+}
+
+// NonZero returns a present value, or an absent one when value is the zero value.
+func NonZero[T comparable](value T) Optional[T] {
+	var zero T
+	if value == zero {
+		return Optional[T]{}
+	}
+
+	return Some(value)
+}
+
+// Get returns the value and whether it is present and not NULL.
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.present && !o.null
+}
+
+// Present reports whether the value is present, NULL included.
+func (o Optional[T]) Present() bool {
+	return o.present
+}
+
+// IsNull reports whether the value is a present NULL.
+func (o Optional[T]) IsNull() bool {
+	return o.null
+}
+
+// arg returns the argument of the value, nil for NULL, and whether it is present.
+func (o Optional[T]) arg() (any, bool) {
+	if !o.present || o.null {
+		return nil, o.present
+	}
+
+	return o.value, true
+}
+
+// optional is implemented by every Optional, so the builder tells them from plain values.
+type optional interface {
+	arg() (any, bool)
+}
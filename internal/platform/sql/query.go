@@ -0,0 +1,298 @@
+package sql
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrEmptyUpdate is returned when an UPDATE has no column to set.
+	ErrEmptyUpdate = errors.New("no column is going to be updated")
+
+	// ErrMissingWhere is returned when an UPDATE or a DELETE has no WHERE clause, so it would write every
+	// row.
+	ErrMissingWhere = errors.New("missing where clause")
+
+	// ErrUnknownSortField is returned when a query is sorted by a field that is not allowed.
+	ErrUnknownSortField = errors.New("unknown sort field")
+)
+
+// Order orders the rows by a column.
+type Order struct {
+	Column string
+	Desc   bool
+}
+
+// Asc orders the rows by the column, ascending.
+func Asc(column string) Order {
+	return Order{Column: column}
+}
+
+// Desc orders the rows by the column, descending.
+func Desc(column string) Order {
+	return Order{Column: column, Desc: true}
+}
+
+// Sort orders the rows by a field requested by a client, which only sorts by the fields of a whitelist.
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+// SortColumns is the whitelist of the fields a client sorts by, with their columns.
+type SortColumns map[string]string
+
+// SelectQuery builds a SELECT statement.
+type SelectQuery struct {
+	columns   []string
+	table     string
+	joins     []string
+	where     []Cond
+	orders    []Order
+	err       error
+	limit     int
+	offset    int
+	forUpdate bool
+}
+
+// Select starts a SELECT of the columns.
+func Select(columns ...string) *SelectQuery {
+	return &SelectQuery{columns: columns}
+}
+
+// From sets the table.
+func (q *SelectQuery) From(table string) *SelectQuery {
+	q.table = table
+
+	return q
+}
+
+// Join adds a join, like "INNER JOIN users ON users.id = todos.user_id".
+func (q *SelectQuery) Join(join string) *SelectQuery {
+	q.joins = append(q.joins, join)
+
+	return q
+}
+
+// Where adds conditions, every condition of the query must be true.
+func (q *SelectQuery) Where(conds ...Cond) *SelectQuery {
+	q.where = append(q.where, conds...)
+
+	return q
+}
+
+// OrderBy adds the orders chosen by the code.
+func (q *SelectQuery) OrderBy(orders ...Order) *SelectQuery {
+	q.orders = append(q.orders, orders...)
+
+	return q
+}
+
+// SortBy adds the orders requested by a client, whose fields must be in columns.
+func (q *SelectQuery) SortBy(columns SortColumns, sorts ...Sort) *SelectQuery {
+	for _, sort := range sorts {
+		column, ok := columns[sort.Field]
+		if !ok {
+			q.err = errors.Join(q.err, fmt.Errorf("%w: %q", ErrUnknownSortField, sort.Field))
+
+			continue
+		}
+
+		q.orders = append(q.orders, Order{Column: column, Desc: sort.Desc})
+	}
+
+	return q
+}
+
+// Limit the number of rows, no limit is set when it is zero.
+func (q *SelectQuery) Limit(limit int) *SelectQuery {
+	q.limit = limit
+
+	return q
+}
+
+// Offset skips the first rows.
+func (q *SelectQuery) Offset(offset int) *SelectQuery {
+	q.offset = offset
+
+	return q
+}
+
+// ForUpdate locks the selected rows until the end of the transaction, on the databases that lock rows.
+func (q *SelectQuery) ForUpdate() *SelectQuery {
+	q.forUpdate = true
+
+	return q
+}
+
+// Build returns the statement for the dialect and its arguments.
+func (q *SelectQuery) Build(dialect Dialect) (string, []any, error) {
+	if q.err != nil {
+		return "", nil, q.err
+	}
+
+	var query strings.Builder
+
+	query.WriteString("SELECT " + strings.Join(q.columns, ", ") + " FROM " + q.table)
+
+	for _, join := range q.joins {
+		query.WriteString(" " + join)
+	}
+
+	args := writeWhere(&query, q.where, nil)
+
+	for i, order := range q.orders {
+		if i == 0 {
+			query.WriteString(" ORDER BY ")
+		} else {
+			query.WriteString(", ")
+		}
+
+		query.WriteString(order.Column)
+
+		if order.Desc {
+			query.WriteString(" DESC")
+		}
+	}
+
+	if q.limit > 0 {
+		query.WriteString(" LIMIT " + strconv.Itoa(q.limit))
+	}
+
+	if q.offset > 0 {
+		query.WriteString(" OFFSET " + strconv.Itoa(q.offset))
+	}
+
+	if q.forUpdate {
+		query.WriteString(dialect.ForUpdate())
+	}
+
+	return dialect.Rebind(query.String() + ";"), args, nil
+}
+
+// assignment is a "column = value" of an UPDATE.
+type assignment struct {
+	column string
+	expr   string
+	args   []any
+}
+
+// UpdateQuery builds an UPDATE statement.
+type UpdateQuery struct {
+	table       string
+	assignments []assignment
+	where       []Cond
+}
+
+// Update starts an UPDATE of the table.
+func Update(table string) *UpdateQuery {
+	return &UpdateQuery{table: table}
+}
+
+// Set writes the value to the column. The value may be an Optional: an absent one leaves the column as it
+// is, and NULL clears it.
+func (q *UpdateQuery) Set(column string, value any) *UpdateQuery {
+	if value, ok := value.(optional); ok {
+		arg, present := value.arg()
+		if !present {
+			return q
+		}
+
+		return q.SetExpr(column, "?", arg)
+	}
+
+	return q.SetExpr(column, "?", value)
+}
+
+// SetExpr writes the result of an expression to the column, like "version + 1".
+func (q *UpdateQuery) SetExpr(column string, expr string, args ...any) *UpdateQuery {
+	q.assignments = append(q.assignments, assignment{column: column, expr: expr, args: args})
+
+	return q
+}
+
+// Empty reports whether no column is set.
+func (q *UpdateQuery) Empty() bool {
+	return len(q.assignments) == 0
+}
+
+// Where adds conditions, every condition of the query must be true.
+func (q *UpdateQuery) Where(conds ...Cond) *UpdateQuery {
+	q.where = append(q.where, conds...)
+
+	return q
+}
+
+// Build returns the statement for the dialect and its arguments.
+func (q *UpdateQuery) Build(dialect Dialect) (string, []any, error) {
+	if q.Empty() {
+		return "", nil, ErrEmptyUpdate
+	}
+
+	if len(q.where) == 0 {
+		return "", nil, ErrMissingWhere
+	}
+
+	var query strings.Builder
+
+	query.WriteString("UPDATE " + q.table + " SET ")
+
+	args := make([]any, 0, len(q.assignments))
+	for i, assignment := range q.assignments {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+
+		query.WriteString(assignment.column + " = " + assignment.expr)
+		args = append(args, assignment.args...)
+	}
+
+	args = writeWhere(&query, q.where, args)
+
+	return dialect.Rebind(query.String() + ";"), args, nil
+}
+
+// DeleteQuery builds a DELETE statement.
+type DeleteQuery struct {
+	table string
+	where []Cond
+}
+
+// Delete starts a DELETE from the table.
+func Delete(table string) *DeleteQuery {
+	return &DeleteQuery{table: table}
+}
+
+// Where adds conditions, every condition of the query must be true.
+func (q *DeleteQuery) Where(conds ...Cond) *DeleteQuery {
+	q.where = append(q.where, conds...)
+
+	return q
+}
+
+// Build returns the statement for the dialect and its arguments.
+func (q *DeleteQuery) Build(dialect Dialect) (string, []any, error) {
+	if len(q.where) == 0 {
+		return "", nil, ErrMissingWhere
+	}
+
+	var query strings.Builder
+
+	query.WriteString("DELETE FROM " + q.table)
+	args := writeWhere(&query, q.where, nil)
+
+	return dialect.Rebind(query.String() + ";"), args, nil
+}
+
+// writeWhere appends the WHERE clause of the conditions to query, returning args with their arguments.
+func writeWhere(query *strings.Builder, where []Cond, args []any) []any {
+	if len(where) == 0 {
+		return args
+	}
+
+	query.WriteString(" WHERE ")
+
+	return And(where...).write(query, args)
+}
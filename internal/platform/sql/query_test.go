@@ -0,0 +1,225 @@
+package sql
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+func TestSelectQueryBuild_Successful(t *testing.T) {
+	// Given
+	query := Select("todos.id", "todos.title").
+		From("todos").
+		Join("INNER JOIN users ON users.id = todos.user_id").
+		Where(Eq("todos.user_id", 1), Like("todos.title", "%Lorem%")).
+		OrderBy(Asc("todos.position"), Desc("todos.id")).
+		Limit(10).
+		Offset(20).
+		ForUpdate()
+
+	// When
+	mysqlQuery, mysqlArgs, mysqlErr := query.Build(NewDialect("mysql"))
+	postgresQuery, postgresArgs, postgresErr := query.Build(NewDialect("pgx"))
+	sqliteQuery, _, sqliteErr := query.Build(NewDialect("sqlite"))
+
+	// Then
+	require.NoError(t, mysqlErr)
+	require.NoError(t, postgresErr)
+	require.NoError(t, sqliteErr)
+	require.Equal(t, "SELECT todos.id, todos.title FROM todos INNER JOIN users ON users.id = todos.user_id "+
+		"WHERE (todos.user_id = ? AND todos.title LIKE ?) ORDER BY todos.position, todos.id DESC LIMIT 10 OFFSET 20 "+
+		"FOR UPDATE;", mysqlQuery)
+	require.Equal(t, "SELECT todos.id, todos.title FROM todos INNER JOIN users ON users.id = todos.user_id "+
+		"WHERE (todos.user_id = $1 AND todos.title LIKE $2) ORDER BY todos.position, todos.id DESC LIMIT 10 "+
+		"OFFSET 20 FOR UPDATE;", postgresQuery)
+	require.Equal(t, "SELECT todos.id, todos.title FROM todos INNER JOIN users ON users.id = todos.user_id "+
+		"WHERE (todos.user_id = ? AND todos.title LIKE ?) ORDER BY todos.position, todos.id DESC LIMIT 10 "+
+		"OFFSET 20;", sqliteQuery)
+	require.Equal(t, []any{1, "%Lorem%"}, mysqlArgs)
+	require.Equal(t, mysqlArgs, postgresArgs)
+}
+
+func TestSelectQueryBuild_SortsByWhitelistedFields(t *testing.T) {
+	// Given
+	columns := SortColumns{"title": "todos.title", "due": "todos.due_at"}
+
+	// When
+	query, _, err := Select("id").
+		From("todos").
+		SortBy(columns, Sort{Field: "due", Desc: true}, Sort{Field: "title"}).
+		Build(NewDialect("mysql"))
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "SELECT id FROM todos ORDER BY todos.due_at DESC, todos.title;", query)
+}
+
+func TestSelectQueryBuild_FailsDueToUnknownSortField(t *testing.T) {
+	// Given
+	columns := SortColumns{"title": "todos.title"}
+
+	// When
+	query, args, err := Select("id").
+		From("todos").
+		SortBy(columns, Sort{Field: "password"}).
+		Build(NewDialect("mysql"))
+
+	// Then
+	require.ErrorIs(t, err, ErrUnknownSortField)
+	require.ErrorContains(t, err, `"password"`)
+	require.Empty(t, query)
+	require.Nil(t, args)
+}
+
+func TestSelectQueryBuild_WritesConditions(t *testing.T) {
+	tests := map[string]struct {
+		cond          Cond
+		expectedWhere string
+		expectedArgs  []any
+	}{
+		"In": {
+			cond:          In("id", []int{1, 2, 3}),
+			expectedWhere: "id IN (?, ?, ?)",
+			expectedArgs:  []any{1, 2, 3},
+		},
+		"EmptyIn": {
+			cond:          In("id", []int{}),
+			expectedWhere: "1 = 0",
+		},
+		"NilEq": {
+			cond:          Eq("due_at", nil),
+			expectedWhere: "due_at IS NULL",
+		},
+		"OrInsideAnd": {
+			cond:          And(Eq("user_id", 1), Or(Eq("completed", false), Gte("version", 2))),
+			expectedWhere: "(user_id = ? AND (completed = ? OR version >= ?))",
+			expectedArgs:  []any{1, false, 2},
+		},
+		"ExprInsideAnd": {
+			cond:          And(Eq("id", 1), Expr("version = ? OR ? = 0", 2, 2)),
+			expectedWhere: "(id = ? AND (version = ? OR ? = 0))",
+			expectedArgs:  []any{1, 2, 2},
+		},
+		"EmptyAnd": {
+			cond:          And(),
+			expectedWhere: "1 = 1",
+		},
+		"EmptyOr": {
+			cond:          Or(),
+			expectedWhere: "1 = 0",
+		},
+		"After": {
+			cond:          After([]string{"position", "id"}, 1024, 7),
+			expectedWhere: "(position > ? OR (position = ? AND id > ?))",
+			expectedArgs:  []any{1024, 1024, 7},
+		},
+		"Before": {
+			cond:          Before([]string{"position", "id"}, 1024, 7),
+			expectedWhere: "(position < ? OR (position = ? AND id < ?))",
+			expectedArgs:  []any{1024, 1024, 7},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// When
+			query, args, err := Select("id").From("todos").Where(test.cond).Build(NewDialect("mysql"))
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, "SELECT id FROM todos WHERE "+test.expectedWhere+";", query)
+			require.Equal(t, test.expectedArgs, args)
+		})
+	}
+}
+
+func TestUpdateQueryBuild_Successful(t *testing.T) {
+	// Given
+	dueAt := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	// When
+	query, args, err := Update("todos").
+		Set("title", Some("")).
+		Set("description", Optional[string]{}).
+		Set("completed", NonZero(false)).
+		Set("due_at", Null[time.Time]()).
+		Set("position", 1024).
+		Set("recurrence", Some("daily")).
+		SetExpr("version", "version + 1").
+		Where(Eq("id", 1), Lt("due_at", dueAt)).
+		Build(NewDialect("pgx"))
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "UPDATE todos SET title = $1, due_at = $2, position = $3, recurrence = $4, "+
+		"version = version + 1 WHERE (id = $5 AND due_at < $6);", query)
+	require.Equal(t, []any{"", nil, 1024, "daily", 1, dueAt}, args)
+}
+
+func TestUpdateQueryBuild_FailsDueToEmptyUpdate(t *testing.T) {
+	// Given
+	update := Update("users").Set("first_name", NonZero("")).Set("email", Optional[string]{})
+
+	// When
+	query, args, err := update.Where(Eq("id", 1)).Build(NewDialect("mysql"))
+
+	// Then
+	require.True(t, update.Empty())
+	require.ErrorIs(t, err, ErrEmptyUpdate)
+	require.Empty(t, query)
+	require.Nil(t, args)
+}
+
+func TestUpdateQueryBuild_FailsDueToMissingWhere(t *testing.T) {
+	// When
+	query, args, err := Update("users").Set("first_name", "John").Build(NewDialect("mysql"))
+
+	// Then
+	require.ErrorIs(t, err, ErrMissingWhere)
+	require.Empty(t, query)
+	require.Nil(t, args)
+}
+
+func TestDeleteQueryBuild_Successful(t *testing.T) {
+	// When
+	query, args, err := Delete("todos").
+		Where(Eq("id", 1), Expr("version = ? OR ? = 0", 2, 2)).
+		Build(NewDialect("pgx"))
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "DELETE FROM todos WHERE (id = $1 AND (version = $2 OR $3 = 0));", query)
+	require.Equal(t, []any{1, 2, 2}, args)
+}
+
+func TestDeleteQueryBuild_FailsDueToMissingWhere(t *testing.T) {
+	// When
+	query, args, err := Delete("todos").Build(NewDialect("mysql"))
+
+	// Then
+	require.ErrorIs(t, err, ErrMissingWhere)
+	require.Empty(t, query)
+	require.Nil(t, args)
+}
+
+func TestOptional_Successful(t *testing.T) {
+	// Given
+	absent := NonZero("")
+	zero := Some("")
+	null := Null[string]()
+
+	// When
+	_, absentOk := absent.Get()
+	zeroValue, zeroOk := zero.Get()
+	_, nullOk := null.Get()
+
+	// Then
+	require.False(t, absent.Present())
+	require.False(t, absentOk)
+	require.True(t, zero.Present())
+	require.True(t, zeroOk)
+	require.Empty(t, zeroValue)
+	require.True(t, null.Present())
+	require.True(t, null.IsNull())
+	require.False(t, nullOk)
+}
@@ -0,0 +1,151 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"github.com/jmoiron/sqlx"
+	"sync/atomic"
+)
+
+// ErrRollbackOnly is returned by WithinTx when its function succeeded although a write that joined the
+// transaction failed, so the transaction was rolled back instead of committing part of the work.
+var ErrRollbackOnly = errors.New("transaction rolled back after a failed write")
+
+// txKey is the context key of the transaction started by WithinTx.
+type txKey struct{}
+
+// transaction is the transaction of a context, shared by the repositories that join it.
+type transaction struct {
+	tx           *sqlx.Tx
+	rollbackOnly atomic.Bool // Set when a write that joined the transaction failed.
+}
+
+// Conn runs queries, it is implemented by *sqlx.DB and *sqlx.Tx.
+type Conn interface {
+	sqlx.ExtContext
+
+	GetContext(ctx context.Context, dest any, query string, args ...any) error
+	SelectContext(ctx context.Context, dest any, query string, args ...any) error
+	PreparexContext(ctx context.Context, query string) (*sqlx.Stmt, error)
+}
+
+// ConnFrom returns the transaction started by WithinTx in ctx, or conn when there is none, so reads see
+// the writes of the transaction.
+func ConnFrom(ctx context.Context, conn *sqlx.DB) Conn {
+	if current, ok := ctx.Value(txKey{}).(*transaction); ok {
+		return current.tx
+	}
+
+	return conn
+}
+
+// Tx is a transaction begun by Begin. When it joined the transaction started by WithinTx, committing it
+// is left to WithinTx, and rolling it back makes WithinTx roll back.
+type Tx struct {
+	*sqlx.Tx
+
+	joined *transaction
+}
+
+// Begin joins the transaction started by WithinTx in ctx, or begins a transaction bound to ctx when there
+// is none.
+func Begin(ctx context.Context, conn *sqlx.DB) (*Tx, error) {
+	if current, ok := ctx.Value(txKey{}).(*transaction); ok {
+		return &Tx{Tx: current.tx, joined: current}, nil
+	}
+
+	tx, err := conn.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tx{Tx: tx}, nil
+}
+
+// Commit the transaction, unless it was joined.
+func (t *Tx) Commit() error {
+	if t.joined != nil {
+		return nil
+	}
+
+	return t.Tx.Commit()
+}
+
+// Rollback the transaction, or mark the joined one to be rolled back.
+func (t *Tx) Rollback() error {
+	if t.joined != nil {
+		t.joined.rollbackOnly.Store(true)
+
+		return nil
+	}
+
+	return t.Tx.Rollback()
+}
+
+// Transactor runs the work of several repositories as a unit.
+type Transactor interface {
+	// WithinTx runs fn inside a transaction, committed when fn returns nil and rolled back otherwise. The
+	// repositories called with the ctx given to fn join the transaction; a nested WithinTx joins it too.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type transactor struct {
+	conn *sqlx.DB
+}
+
+func NewTransactor(conn *sqlx.DB) Transactor {
+	return &transactor{
+		conn: conn,
+	}
+}
+
+func (t transactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	tx, err := Begin(ctx, t.conn)
+	if err != nil {
+		return err
+	}
+
+	current := tx.joined
+	if current == nil {
+		current = &transaction{tx: tx.Tx}
+	}
+
+	defer func() {
+		// A panic leaves nothing to commit; the transaction is rolled back before it goes on.
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, current)); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return rollbackErr
+		}
+
+		return err
+	}
+
+	if current.rollbackOnly.Load() && tx.joined == nil {
+		if err := tx.Rollback(); err != nil {
+			return err
+		}
+
+		return ErrRollbackOnly
+	}
+
+	return tx.Commit()
+}
+
+type nopTransactor struct{}
+
+// NewNopTransactor creates a Transactor that runs the functions without a transaction, for repositories
+// that keep data in memory. Their writes are not undone when a function fails.
+func NewNopTransactor() Transactor {
+	return nopTransactor{}
+}
+
+func (nopTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,231 @@
+package sql
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+var errWrite = errors.New("write failed")
+
+func newMock(t *testing.T) (*sqlx.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	return sqlx.NewDb(db, "sqlmock"), mock
+}
+
+// write runs a write of a repository, which begins its own transaction or joins the one of ctx. A
+// failing write is rolled back after inserting.
+func write(ctx context.Context, conn *sqlx.DB, fail bool) error {
+	tx, err := Begin(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO todos (title) VALUES (?);", "Lorem"); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return rollbackErr
+		}
+
+		return err
+	}
+
+	if fail {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return rollbackErr
+		}
+
+		return errWrite
+	}
+
+	return tx.Commit()
+}
+
+func TestTransactorWithinTx_SuccessfulCommitsTheJoinedWrites(t *testing.T) {
+	// Given
+	conn, mock := newMock(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO todos`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO todos`).WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectCommit()
+
+	transactor := NewTransactor(conn)
+
+	// When
+	err := transactor.WithinTx(context.Background(), func(ctx context.Context) error {
+		if err := write(ctx, conn, false); err != nil {
+			return err
+		}
+
+		return write(ctx, conn, false)
+	})
+
+	// Then
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransactorWithinTx_SuccessfulJoinsTheOuterTransaction(t *testing.T) {
+	// Given
+	conn, mock := newMock(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO todos`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	transactor := NewTransactor(conn)
+
+	// When
+	err := transactor.WithinTx(context.Background(), func(ctx context.Context) error {
+		return transactor.WithinTx(ctx, func(ctx context.Context) error {
+			return write(ctx, conn, false)
+		})
+	})
+
+	// Then
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransactorWithinTx_FailsDueToFailingFunction(t *testing.T) {
+	// Given
+	conn, mock := newMock(t)
+
+	expectedError := errors.New("seeding failed")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO todos`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectRollback()
+
+	transactor := NewTransactor(conn)
+
+	// When
+	err := transactor.WithinTx(context.Background(), func(ctx context.Context) error {
+		if err := write(ctx, conn, false); err != nil {
+			return err
+		}
+
+		return expectedError
+	})
+
+	// Then
+	require.ErrorIs(t, err, expectedError)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransactorWithinTx_FailsDueToIgnoredFailingWrite(t *testing.T) {
+	// Given
+	conn, mock := newMock(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO todos`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO todos`).WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectRollback()
+
+	transactor := NewTransactor(conn)
+
+	// When
+	err := transactor.WithinTx(context.Background(), func(ctx context.Context) error {
+		if err := write(ctx, conn, false); err != nil {
+			return err
+		}
+
+		_ = write(ctx, conn, true)
+
+		return nil
+	})
+
+	// Then
+	require.ErrorIs(t, err, ErrRollbackOnly)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTransactorWithinTx_FailsDueToInvalidBeginTransaction(t *testing.T) {
+	// Given
+	conn, mock := newMock(t)
+
+	expectedError := errors.New("begin failed")
+
+	mock.ExpectBegin().WillReturnError(expectedError)
+
+	transactor := NewTransactor(conn)
+
+	// When
+	err := transactor.WithinTx(context.Background(), func(ctx context.Context) error {
+		require.Fail(t, "the function must not run")
+
+		return nil
+	})
+
+	// Then
+	require.ErrorIs(t, err, expectedError)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestBegin_SuccessfulWithoutTransaction(t *testing.T) {
+	// Given
+	conn, mock := newMock(t)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO todos`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// When
+	err := write(context.Background(), conn, false)
+
+	// Then
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConnFrom_Successful(t *testing.T) {
+	// Given
+	conn, mock := newMock(t)
+
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	transactor := NewTransactor(conn)
+
+	// When
+	withoutTx := ConnFrom(context.Background(), conn)
+
+	var withinTx Conn
+	err := transactor.WithinTx(context.Background(), func(ctx context.Context) error {
+		withinTx = ConnFrom(ctx, conn)
+
+		return nil
+	})
+
+	// Then
+	require.NoError(t, err)
+	require.Same(t, conn, withoutTx)
+	require.IsType(t, &sqlx.Tx{}, withinTx)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNopTransactorWithinTx_Successful(t *testing.T) {
+	// Given
+	expectedError := errors.New("seeding failed")
+
+	transactor := NewNopTransactor()
+
+	// When
+	err := transactor.WithinTx(context.Background(), func(ctx context.Context) error {
+		return expectedError
+	})
+
+	// Then
+	require.ErrorIs(t, err, expectedError)
+}
@@ -0,0 +1,119 @@
+package sqlite
+
+import (
+	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/sql"
+	"github.com/jmoiron/sqlx"
+	"os"
+	"path/filepath"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+const (
+	// _memoryDSN keeps the database in memory, only while the connection is open.
+	_memoryDSN = ":memory:"
+
+	// _pragmas enforce the foreign keys, which SQLite ignores by default, and let concurrent writers wait
+	// for the lock instead of failing with "database is locked".
+	_pragmas = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate"
+
+	_createMigrationsTableStmt = `CREATE TABLE IF NOT EXISTS schema_migrations (version TEXT PRIMARY KEY);`
+	_countMigrationStmt        = `SELECT COUNT(*) FROM schema_migrations WHERE version = ?;`
+	_saveMigrationStmt         = `INSERT INTO schema_migrations (version) VALUES (?);`
+)
+
+// NewConnection opens the SQLite database of the configured file, creating it and applying the
+// migrations when needed.
+func NewConnection(config *config.EnvVars) (*sqlx.DB, error) {
+	db, err := sqlx.Connect("sqlite", withPragmas(config.SQLiteDSN))
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows a single writer, and every connection to ":memory:" opens a different database.
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(db); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func withPragmas(dsn string) string {
+	if dsn == "" {
+		dsn = _memoryDSN
+	}
+
+	separator := "?"
+	if strings.Contains(dsn, "?") {
+		separator = "&"
+	}
+
+	return dsn + separator + _pragmas
+}
+
+// migrate runs the migrations that were not applied yet, as SQLite databases are not created by a
+// container. Applied migrations are recorded in the schema_migrations table.
+func migrate(db *sqlx.DB) error {
+	if _, err := db.Exec(_createMigrationsTableStmt); err != nil {
+		return err
+	}
+
+	migrationFiles, err := sql.MigrationFiles(sql.SQLite)
+	if err != nil {
+		return err
+	}
+
+	for _, migrationFile := range migrationFiles {
+		if err := applyMigration(db, migrationFile); err != nil {
+			return fmt.Errorf("failed to run migration %s: %w", migrationFile, err)
+		}
+	}
+
+	return nil
+}
+
+func applyMigration(db *sqlx.DB, migrationFile string) error {
+	version := filepath.Base(migrationFile)
+
+	var applied int
+	if err := db.Get(&applied, _countMigrationStmt, version); err != nil {
+		return err
+	}
+
+	if applied > 0 {
+		return nil
+	}
+
+	script, err := os.ReadFile(migrationFile)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(string(script)); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return rollbackErr
+		}
+
+		return err
+	}
+
+	if _, err := tx.Exec(_saveMigrationStmt, version); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return rollbackErr
+		}
+
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewConnection_Successful(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	configs := &config.EnvVars{
+		SQLiteDSN: filepath.Join(t.TempDir(), "fiber_example.db"),
+	}
+
+	// When
+	conn, err := NewConnection(configs)
+
+	// Then
+	require.NoError(t, err)
+	require.NotEmpty(t, conn)
+
+	var tables []string
+	err = conn.Select(&tables, "SELECT name FROM sqlite_master WHERE type = 'table' AND name IN ('users', 'todos') ORDER BY name")
+	require.NoError(t, err)
+	require.Equal(t, []string{"todos", "users"}, tables)
+
+	var foreignKeys bool
+	err = conn.Get(&foreignKeys, "PRAGMA foreign_keys")
+	require.NoError(t, err)
+	require.True(t, foreignKeys)
+
+	require.NoError(t, conn.Close())
+}
+
+func TestNewConnection_SuccessfulWithExistingDatabase(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	configs := &config.EnvVars{
+		SQLiteDSN: filepath.Join(t.TempDir(), "fiber_example.db"),
+	}
+
+	conn, err := NewConnection(configs)
+	require.NoError(t, err)
+
+	_, err = conn.Exec("INSERT INTO users (first_name, last_name, email, password) VALUES ('John', 'Doe', 'john@doe.com', '12345678')")
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	// When
+	conn, err = NewConnection(configs)
+
+	// Then
+	require.NoError(t, err)
+
+	var count int
+	err = conn.Get(&count, "SELECT COUNT(*) FROM users")
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+
+	var version int
+	err = conn.Get(&version, "SELECT version FROM users")
+	require.NoError(t, err)
+	require.Equal(t, 1, version)
+
+	require.NoError(t, conn.Close())
+}
+
+func TestNewConnection_SuccessfulInMemory(t *testing.T) {
+	t.Parallel()
+
+	// Given
+	configs := &config.EnvVars{}
+
+	// When
+	conn, err := NewConnection(configs)
+
+	// Then
+	require.NoError(t, err)
+
+	var count int
+	err = conn.Get(&count, "SELECT COUNT(*) FROM todos")
+	require.NoError(t, err)
+	require.Zero(t, count)
+
+	require.NoError(t, conn.Close())
+}
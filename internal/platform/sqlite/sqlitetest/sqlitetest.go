@@ -0,0 +1,44 @@
+// Package sqlitetest opens disposable SQLite databases, with the migrations applied, for tests that need
+// a real database.
+package sqlitetest
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/sqlite"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"path/filepath"
+	"testing"
+)
+
+// NewConnection opens a database in a temporary file that is removed when the test finishes.
+func NewConnection(t *testing.T) *sqlx.DB {
+	t.Helper()
+
+	configs := &config.EnvVars{
+		SQLiteDSN: filepath.Join(t.TempDir(), "fiber_example.db"),
+	}
+
+	conn, err := sqlite.NewConnection(configs)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		require.NoError(t, conn.Close())
+	})
+
+	return conn
+}
+
+// Truncate empties the tables and restarts their IDs.
+func Truncate(t *testing.T, conn *sqlx.DB) {
+	t.Helper()
+
+	// Children first because of the foreign keys.
+	for _, table := range []string{"outbox", "webhook_deliveries", "webhooks", "audit_events", "todos", "users"} {
+		_, err := conn.Exec("DELETE FROM " + table)
+		require.NoError(t, err)
+
+		_, err = conn.Exec("DELETE FROM sqlite_sequence WHERE name = ?", table)
+		require.NoError(t, err)
+	}
+}
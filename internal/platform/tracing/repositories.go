@@ -0,0 +1,250 @@
+package tracing
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/session"
+	"github.com/ferch5003/go-fiber-tutorial/internal/todo"
+	"github.com/ferch5003/go-fiber-tutorial/internal/user"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// sqlAttributes describes a query against a MySQL table.
+func sqlAttributes(operation, table string) trace.SpanStartOption {
+	return trace.WithAttributes(
+		semconv.DBSystemMySQL,
+		semconv.DBOperation(operation),
+		semconv.DBSQLTable(table),
+	)
+}
+
+// redisAttributes describes a command against Redis.
+func redisAttributes(operation string) trace.SpanStartOption {
+	return trace.WithAttributes(
+		semconv.DBSystemRedis,
+		semconv.DBOperation(operation),
+	)
+}
+
+type todoRepository struct {
+	next   todo.Repository
+	tracer trace.Tracer
+}
+
+// NewTodoRepository decorates todo.Repository creating a client span per query.
+func NewTodoRepository(next todo.Repository, tp trace.TracerProvider) todo.Repository {
+	return &todoRepository{
+		next:   next,
+		tracer: tp.Tracer(_instrumentationName),
+	}
+}
+
+func (r todoRepository) GetAll(ctx context.Context, userID int) (todos []domain.Todo, err error) {
+	ctx, span := startSpan(ctx, r.tracer, "todo.Repository.GetAll", trace.SpanKindClient,
+		sqlAttributes("SELECT", "todos"))
+	defer func() { endSpan(span, err) }()
+
+	return r.next.GetAll(ctx, userID)
+}
+
+func (r todoRepository) GetPage(
+	ctx context.Context,
+	userID int,
+	after domain.Todo,
+	limit int) (todos []domain.Todo, err error) {
+	ctx, span := startSpan(ctx, r.tracer, "todo.Repository.GetPage", trace.SpanKindClient,
+		sqlAttributes("SELECT", "todos"))
+	defer func() { endSpan(span, err) }()
+
+	return r.next.GetPage(ctx, userID, after, limit)
+}
+
+func (r todoRepository) Get(ctx context.Context, id int) (todo domain.Todo, err error) {
+	ctx, span := startSpan(ctx, r.tracer, "todo.Repository.Get", trace.SpanKindClient,
+		sqlAttributes("SELECT", "todos"))
+	defer func() { endSpan(span, err) }()
+
+	return r.next.Get(ctx, id)
+}
+
+func (r todoRepository) Save(ctx context.Context, todo domain.Todo) (id int, err error) {
+	ctx, span := startSpan(ctx, r.tracer, "todo.Repository.Save", trace.SpanKindClient,
+		sqlAttributes("INSERT", "todos"))
+	defer func() { endSpan(span, err) }()
+
+	return r.next.Save(ctx, todo)
+}
+
+func (r todoRepository) SaveAll(ctx context.Context, userID int, todos []domain.Todo) (ids []int, err error) {
+	ctx, span := startSpan(ctx, r.tracer, "todo.Repository.SaveAll", trace.SpanKindClient,
+		sqlAttributes("INSERT", "todos"))
+	defer func() { endSpan(span, err) }()
+
+	return r.next.SaveAll(ctx, userID, todos)
+}
+
+func (r todoRepository) ExternalIDs(
+	ctx context.Context,
+	userID int,
+	externalIDs []string) (existing []string, err error) {
+	ctx, span := startSpan(ctx, r.tracer, "todo.Repository.ExternalIDs", trace.SpanKindClient,
+		sqlAttributes("SELECT", "todos"))
+	defer func() { endSpan(span, err) }()
+
+	return r.next.ExternalIDs(ctx, userID, externalIDs)
+}
+
+func (r todoRepository) Completed(ctx context.Context, id int, version int) (todo domain.Todo, err error) {
+	ctx, span := startSpan(ctx, r.tracer, "todo.Repository.Completed", trace.SpanKindClient,
+		sqlAttributes("UPDATE", "todos"))
+	defer func() { endSpan(span, err) }()
+
+	return r.next.Completed(ctx, id, version)
+}
+
+func (r todoRepository) Delete(ctx context.Context, id int, version int) (err error) {
+	ctx, span := startSpan(ctx, r.tracer, "todo.Repository.Delete", trace.SpanKindClient,
+		sqlAttributes("DELETE", "todos"))
+	defer func() { endSpan(span, err) }()
+
+	return r.next.Delete(ctx, id, version)
+}
+
+func (r todoRepository) Batch(
+	ctx context.Context,
+	userID int,
+	operations []domain.TodoOperation,
+	atomic bool) (results []domain.TodoOperationResult, err error) {
+	ctx, span := startSpan(ctx, r.tracer, "todo.Repository.Batch", trace.SpanKindClient,
+		sqlAttributes("BATCH", "todos"))
+	defer func() { endSpan(span, err) }()
+
+	return r.next.Batch(ctx, userID, operations, atomic)
+}
+
+func (r todoRepository) Update(
+	ctx context.Context,
+	todo domain.Todo,
+	scope domain.TodoScope) (updatedTodo domain.Todo, err error) {
+	ctx, span := startSpan(ctx, r.tracer, "todo.Repository.Update", trace.SpanKindClient,
+		sqlAttributes("UPDATE", "todos"))
+	defer func() { endSpan(span, err) }()
+
+	return r.next.Update(ctx, todo, scope)
+}
+
+func (r todoRepository) Skip(ctx context.Context, id int, version int) (todo domain.Todo, err error) {
+	ctx, span := startSpan(ctx, r.tracer, "todo.Repository.Skip", trace.SpanKindClient,
+		sqlAttributes("UPDATE", "todos"))
+	defer func() { endSpan(span, err) }()
+
+	return r.next.Skip(ctx, id, version)
+}
+
+func (r todoRepository) EndSeries(ctx context.Context, id int, version int) (todo domain.Todo, err error) {
+	ctx, span := startSpan(ctx, r.tracer, "todo.Repository.EndSeries", trace.SpanKindClient,
+		sqlAttributes("UPDATE", "todos"))
+	defer func() { endSpan(span, err) }()
+
+	return r.next.EndSeries(ctx, id, version)
+}
+
+func (r todoRepository) Move(ctx context.Context, move domain.TodoMove) (todo domain.Todo, err error) {
+	ctx, span := startSpan(ctx, r.tracer, "todo.Repository.Move", trace.SpanKindClient,
+		sqlAttributes("UPDATE", "todos"))
+	defer func() { endSpan(span, err) }()
+
+	return r.next.Move(ctx, move)
+}
+
+type userRepository struct {
+	next   user.Repository
+	tracer trace.Tracer
+}
+
+// NewUserRepository decorates user.Repository creating a client span per query.
+func NewUserRepository(next user.Repository, tp trace.TracerProvider) user.Repository {
+	return &userRepository{
+		next:   next,
+		tracer: tp.Tracer(_instrumentationName),
+	}
+}
+
+func (r userRepository) GetAll(ctx context.Context) (users []domain.User, err error) {
+	ctx, span := startSpan(ctx, r.tracer, "user.Repository.GetAll", trace.SpanKindClient,
+		sqlAttributes("SELECT", "users"))
+	defer func() { endSpan(span, err) }()
+
+	return r.next.GetAll(ctx)
+}
+
+func (r userRepository) Get(ctx context.Context, id int) (user domain.User, err error) {
+	ctx, span := startSpan(ctx, r.tracer, "user.Repository.Get", trace.SpanKindClient,
+		sqlAttributes("SELECT", "users"))
+	defer func() { endSpan(span, err) }()
+
+	return r.next.Get(ctx, id)
+}
+
+func (r userRepository) GetByEmail(ctx context.Context, email string) (user domain.User, err error) {
+	ctx, span := startSpan(ctx, r.tracer, "user.Repository.GetByEmail", trace.SpanKindClient,
+		sqlAttributes("SELECT", "users"))
+	defer func() { endSpan(span, err) }()
+
+	return r.next.GetByEmail(ctx, email)
+}
+
+func (r userRepository) Save(ctx context.Context, user domain.User) (id int, err error) {
+	ctx, span := startSpan(ctx, r.tracer, "user.Repository.Save", trace.SpanKindClient,
+		sqlAttributes("INSERT", "users"))
+	defer func() { endSpan(span, err) }()
+
+	return r.next.Save(ctx, user)
+}
+
+func (r userRepository) Update(ctx context.Context, user domain.User) (err error) {
+	ctx, span := startSpan(ctx, r.tracer, "user.Repository.Update", trace.SpanKindClient,
+		sqlAttributes("UPDATE", "users"))
+	defer func() { endSpan(span, err) }()
+
+	return r.next.Update(ctx, user)
+}
+
+func (r userRepository) Delete(ctx context.Context, id int, version int) (err error) {
+	ctx, span := startSpan(ctx, r.tracer, "user.Repository.Delete", trace.SpanKindClient,
+		sqlAttributes("DELETE", "users"))
+	defer func() { endSpan(span, err) }()
+
+	return r.next.Delete(ctx, id, version)
+}
+
+type sessionRepository struct {
+	next   session.Repository
+	tracer trace.Tracer
+}
+
+// NewSessionRepository decorates session.Repository creating a client span per Redis call.
+func NewSessionRepository(next session.Repository, tp trace.TracerProvider) session.Repository {
+	return &sessionRepository{
+		next:   next,
+		tracer: tp.Tracer(_instrumentationName),
+	}
+}
+
+func (r sessionRepository) SetSession(ctx context.Context, token string, claims map[string]any) (err error) {
+	ctx, span := startSpan(ctx, r.tracer, "session.Repository.SetSession", trace.SpanKindClient,
+		redisAttributes("HSET"), trace.WithAttributes(attribute.Int("session.claims", len(claims))))
+	defer func() { endSpan(span, err) }()
+
+	return r.next.SetSession(ctx, token, claims)
+}
+
+func (r sessionRepository) GetSession(ctx context.Context, token string) (data map[string]string, err error) {
+	ctx, span := startSpan(ctx, r.tracer, "session.Repository.GetSession", trace.SpanKindClient,
+		redisAttributes("HGETALL"))
+	defer func() { endSpan(span, err) }()
+
+	return r.next.GetSession(ctx, token)
+}
@@ -0,0 +1,290 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+type todoRepositoryMock struct {
+	mock.Mock
+}
+
+func (trm *todoRepositoryMock) GetAll(ctx context.Context, userID int) ([]domain.Todo, error) {
+	args := trm.Called(ctx, userID)
+	return args.Get(0).([]domain.Todo), args.Error(1)
+}
+
+func (trm *todoRepositoryMock) GetPage(
+	ctx context.Context,
+	userID int,
+	after domain.Todo,
+	limit int) ([]domain.Todo, error) {
+	args := trm.Called(ctx, userID, after, limit)
+	return args.Get(0).([]domain.Todo), args.Error(1)
+}
+
+func (trm *todoRepositoryMock) Get(ctx context.Context, id int) (domain.Todo, error) {
+	args := trm.Called(ctx, id)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (trm *todoRepositoryMock) Save(ctx context.Context, todo domain.Todo) (int, error) {
+	args := trm.Called(ctx, todo)
+	return args.Int(0), args.Error(1)
+}
+
+func (trm *todoRepositoryMock) SaveAll(ctx context.Context, userID int, todos []domain.Todo) ([]int, error) {
+	args := trm.Called(ctx, userID, todos)
+	return args.Get(0).([]int), args.Error(1)
+}
+
+func (trm *todoRepositoryMock) ExternalIDs(ctx context.Context, userID int, externalIDs []string) ([]string, error) {
+	args := trm.Called(ctx, userID, externalIDs)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (trm *todoRepositoryMock) Completed(ctx context.Context, id int, version int) (domain.Todo, error) {
+	args := trm.Called(ctx, id, version)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (trm *todoRepositoryMock) Delete(ctx context.Context, id int, version int) error {
+	args := trm.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+func (trm *todoRepositoryMock) Batch(
+	ctx context.Context,
+	userID int,
+	operations []domain.TodoOperation,
+	atomic bool) ([]domain.TodoOperationResult, error) {
+	args := trm.Called(ctx, userID, operations, atomic)
+	return args.Get(0).([]domain.TodoOperationResult), args.Error(1)
+}
+
+func (trm *todoRepositoryMock) Update(ctx context.Context, todo domain.Todo, scope domain.TodoScope) (domain.Todo, error) {
+	args := trm.Called(ctx, todo, scope)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (trm *todoRepositoryMock) Skip(ctx context.Context, id int, version int) (domain.Todo, error) {
+	args := trm.Called(ctx, id, version)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (trm *todoRepositoryMock) EndSeries(ctx context.Context, id int, version int) (domain.Todo, error) {
+	args := trm.Called(ctx, id, version)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (trm *todoRepositoryMock) Move(ctx context.Context, move domain.TodoMove) (domain.Todo, error) {
+	args := trm.Called(ctx, move)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+type userRepositoryMock struct {
+	mock.Mock
+}
+
+func (urm *userRepositoryMock) GetAll(ctx context.Context) ([]domain.User, error) {
+	args := urm.Called(ctx)
+	return args.Get(0).([]domain.User), args.Error(1)
+}
+
+func (urm *userRepositoryMock) Get(ctx context.Context, id int) (domain.User, error) {
+	args := urm.Called(ctx, id)
+	return args.Get(0).(domain.User), args.Error(1)
+}
+
+func (urm *userRepositoryMock) GetByEmail(ctx context.Context, email string) (domain.User, error) {
+	args := urm.Called(ctx, email)
+	return args.Get(0).(domain.User), args.Error(1)
+}
+
+func (urm *userRepositoryMock) Save(ctx context.Context, user domain.User) (int, error) {
+	args := urm.Called(ctx, user)
+	return args.Int(0), args.Error(1)
+}
+
+func (urm *userRepositoryMock) Update(ctx context.Context, user domain.User) error {
+	args := urm.Called(ctx, user)
+	return args.Error(0)
+}
+
+func (urm *userRepositoryMock) Delete(ctx context.Context, id int, version int) error {
+	args := urm.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+type sessionRepositoryMock struct {
+	mock.Mock
+}
+
+func (srm *sessionRepositoryMock) SetSession(ctx context.Context, token string, claims map[string]any) error {
+	args := srm.Called(ctx, token, claims)
+	return args.Error(0)
+}
+
+func (srm *sessionRepositoryMock) GetSession(ctx context.Context, token string) (map[string]string, error) {
+	args := srm.Called(ctx, token)
+	return args.Get(0).(map[string]string), args.Error(1)
+}
+
+func TestTodoRepository_SuccessfulCreatesChildSpans(t *testing.T) {
+	// Given
+	todo := domain.Todo{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: 1}
+
+	trm := new(todoRepositoryMock)
+	trm.On("GetAll", mock.Anything, 1).Return([]domain.Todo{todo}, nil)
+	trm.On("GetPage", mock.Anything, 1, todo, 2).Return([]domain.Todo{todo}, nil)
+	trm.On("Get", mock.Anything, 1).Return(todo, nil)
+	trm.On("Save", mock.Anything, todo).Return(1, nil)
+	trm.On("Completed", mock.Anything, 1, 0).Return(domain.Todo{}, nil)
+	trm.On("Delete", mock.Anything, 1, 0).Return(nil)
+
+	tp, recorder := newTestTracerProvider()
+	repository := NewTodoRepository(trm, tp)
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "todo.Service")
+
+	// When
+	_, _ = repository.GetAll(ctx, 1)
+	_, _ = repository.GetPage(ctx, 1, todo, 2)
+	_, _ = repository.Get(ctx, 1)
+	_, _ = repository.Save(ctx, todo)
+	_, _ = repository.Completed(ctx, 1, 0)
+	err := repository.Delete(ctx, 1, 0)
+
+	parent.End()
+
+	// Then
+	require.NoError(t, err)
+
+	expectedSpans := []struct {
+		name      string
+		operation string
+	}{
+		{"todo.Repository.GetAll", "SELECT"},
+		{"todo.Repository.GetPage", "SELECT"},
+		{"todo.Repository.Get", "SELECT"},
+		{"todo.Repository.Save", "INSERT"},
+		{"todo.Repository.Completed", "UPDATE"},
+		{"todo.Repository.Delete", "DELETE"},
+	}
+
+	spans := recorder.Ended()
+	require.Len(t, spans, len(expectedSpans)+1)
+
+	for i, expected := range expectedSpans {
+		require.Equal(t, expected.name, spans[i].Name())
+		require.Equal(t, trace.SpanKindClient, spans[i].SpanKind())
+		require.Equal(t, parent.SpanContext().SpanID(), spans[i].Parent().SpanID())
+		require.Contains(t, spans[i].Attributes(), semconv.DBSystemMySQL)
+		require.Contains(t, spans[i].Attributes(), semconv.DBOperation(expected.operation))
+		require.Contains(t, spans[i].Attributes(), semconv.DBSQLTable("todos"))
+	}
+}
+
+func TestTodoRepositoryGet_FailsRecordingError(t *testing.T) {
+	// Given
+	expectedError := errors.New("sql: no rows in result set")
+
+	trm := new(todoRepositoryMock)
+	trm.On("Get", mock.Anything, 1).Return(domain.Todo{}, expectedError)
+
+	tp, recorder := newTestTracerProvider()
+	repository := NewTodoRepository(trm, tp)
+
+	// When
+	_, err := repository.Get(context.Background(), 1)
+
+	// Then
+	require.ErrorIs(t, err, expectedError)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+}
+
+func TestUserRepository_SuccessfulCreatesChildSpans(t *testing.T) {
+	// Given
+	user := domain.User{ID: 1, FirstName: "John", LastName: "Doe", Email: "john@example.com"}
+
+	urm := new(userRepositoryMock)
+	urm.On("GetAll", mock.Anything).Return([]domain.User{user}, nil)
+	urm.On("Get", mock.Anything, 1).Return(user, nil)
+	urm.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	urm.On("Save", mock.Anything, user).Return(1, nil)
+	urm.On("Update", mock.Anything, user).Return(nil)
+	urm.On("Delete", mock.Anything, 1, 0).Return(nil)
+
+	tp, recorder := newTestTracerProvider()
+	repository := NewUserRepository(urm, tp)
+	ctx := context.Background()
+
+	// When
+	_, _ = repository.GetAll(ctx)
+	_, _ = repository.Get(ctx, 1)
+	_, _ = repository.GetByEmail(ctx, user.Email)
+	_, _ = repository.Save(ctx, user)
+	_ = repository.Update(ctx, user)
+	err := repository.Delete(ctx, 1, 0)
+
+	// Then
+	require.NoError(t, err)
+
+	expectedNames := []string{
+		"user.Repository.GetAll",
+		"user.Repository.Get",
+		"user.Repository.GetByEmail",
+		"user.Repository.Save",
+		"user.Repository.Update",
+		"user.Repository.Delete",
+	}
+
+	spans := recorder.Ended()
+	require.Len(t, spans, len(expectedNames))
+
+	for i, span := range spans {
+		require.Equal(t, expectedNames[i], span.Name())
+		require.Contains(t, span.Attributes(), semconv.DBSQLTable("users"))
+	}
+}
+
+func TestSessionRepository_SuccessfulCreatesRedisSpans(t *testing.T) {
+	// Given
+	claims := map[string]any{"sub": 1}
+
+	srm := new(sessionRepositoryMock)
+	srm.On("SetSession", mock.Anything, "token", claims).Return(nil)
+	srm.On("GetSession", mock.Anything, "token").Return(map[string]string{"sub": "1"}, nil)
+
+	tp, recorder := newTestTracerProvider()
+	repository := NewSessionRepository(srm, tp)
+	ctx := context.Background()
+
+	// When
+	setErr := repository.SetSession(ctx, "token", claims)
+	data, getErr := repository.GetSession(ctx, "token")
+
+	// Then
+	require.NoError(t, setErr)
+	require.NoError(t, getErr)
+	require.Equal(t, map[string]string{"sub": "1"}, data)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	require.Equal(t, "session.Repository.SetSession", spans[0].Name())
+	require.Contains(t, spans[0].Attributes(), semconv.DBOperation("HSET"))
+	require.Contains(t, spans[0].Attributes(), attribute.Int("session.claims", 1))
+	require.Equal(t, "session.Repository.GetSession", spans[1].Name())
+	require.Contains(t, spans[1].Attributes(), semconv.DBSystemRedis)
+}
@@ -0,0 +1,227 @@
+package tracing
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/todo"
+	"github.com/ferch5003/go-fiber-tutorial/internal/user"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type todoService struct {
+	next   todo.Service
+	tracer trace.Tracer
+}
+
+// NewTodoService decorates todo.Service creating an internal span per call.
+func NewTodoService(next todo.Service, tp trace.TracerProvider) todo.Service {
+	return &todoService{
+		next:   next,
+		tracer: tp.Tracer(_instrumentationName),
+	}
+}
+
+func (s todoService) GetAll(ctx context.Context, userID int) (todos []domain.Todo, err error) {
+	ctx, span := startSpan(ctx, s.tracer, "todo.Service.GetAll", trace.SpanKindInternal,
+		trace.WithAttributes(attribute.Int("user.id", userID)))
+	defer func() { endSpan(span, err) }()
+
+	return s.next.GetAll(ctx, userID)
+}
+
+func (s todoService) GetPage(
+	ctx context.Context,
+	userID int,
+	after domain.Todo,
+	limit int) (todos []domain.Todo, err error) {
+	ctx, span := startSpan(ctx, s.tracer, "todo.Service.GetPage", trace.SpanKindInternal,
+		trace.WithAttributes(
+			attribute.Int("user.id", userID),
+			attribute.Int("todo.page.after_id", after.ID),
+			attribute.Int("todo.page.limit", limit),
+		))
+	defer func() { endSpan(span, err) }()
+
+	return s.next.GetPage(ctx, userID, after, limit)
+}
+
+func (s todoService) Get(ctx context.Context, id int) (todo domain.Todo, err error) {
+	ctx, span := startSpan(ctx, s.tracer, "todo.Service.Get", trace.SpanKindInternal,
+		trace.WithAttributes(attribute.Int("todo.id", id)))
+	defer func() { endSpan(span, err) }()
+
+	return s.next.Get(ctx, id)
+}
+
+func (s todoService) Save(ctx context.Context, todo domain.Todo) (savedTodo domain.Todo, err error) {
+	ctx, span := startSpan(ctx, s.tracer, "todo.Service.Save", trace.SpanKindInternal,
+		trace.WithAttributes(attribute.Int("user.id", todo.UserID)))
+	defer func() { endSpan(span, err) }()
+
+	return s.next.Save(ctx, todo)
+}
+
+func (s todoService) Completed(ctx context.Context, id int, version int) (todo domain.Todo, err error) {
+	ctx, span := startSpan(ctx, s.tracer, "todo.Service.Completed", trace.SpanKindInternal,
+		trace.WithAttributes(attribute.Int("todo.id", id)))
+	defer func() { endSpan(span, err) }()
+
+	return s.next.Completed(ctx, id, version)
+}
+
+func (s todoService) Delete(ctx context.Context, id int, version int) (err error) {
+	ctx, span := startSpan(ctx, s.tracer, "todo.Service.Delete", trace.SpanKindInternal,
+		trace.WithAttributes(attribute.Int("todo.id", id)))
+	defer func() { endSpan(span, err) }()
+
+	return s.next.Delete(ctx, id, version)
+}
+
+func (s todoService) Batch(
+	ctx context.Context,
+	userID int,
+	operations []domain.TodoOperation,
+	atomic bool) (results []domain.TodoOperationResult, err error) {
+	ctx, span := startSpan(ctx, s.tracer, "todo.Service.Batch", trace.SpanKindInternal,
+		trace.WithAttributes(
+			attribute.Int("user.id", userID),
+			attribute.Int("todo.batch.size", len(operations)),
+			attribute.Bool("todo.batch.atomic", atomic),
+		))
+	defer func() { endSpan(span, err) }()
+
+	return s.next.Batch(ctx, userID, operations, atomic)
+}
+
+func (s todoService) Import(
+	ctx context.Context,
+	todoImport domain.TodoImport) (results []domain.TodoImportResult, err error) {
+	ctx, span := startSpan(ctx, s.tracer, "todo.Service.Import", trace.SpanKindInternal,
+		trace.WithAttributes(
+			attribute.Int("user.id", todoImport.UserID),
+			attribute.Int("todo.import.rows", len(todoImport.Rows)),
+			attribute.Bool("todo.import.dry_run", todoImport.DryRun),
+		))
+	defer func() { endSpan(span, err) }()
+
+	return s.next.Import(ctx, todoImport)
+}
+
+func (s todoService) Search(ctx context.Context, search domain.TodoSearch) (page domain.TodoSearchPage, err error) {
+	ctx, span := startSpan(ctx, s.tracer, "todo.Service.Search", trace.SpanKindInternal,
+		trace.WithAttributes(
+			attribute.Int("user.id", search.UserID),
+			attribute.Int("todo.search.page", search.Page),
+			attribute.Int("todo.search.page_size", search.PageSize),
+		))
+	defer func() { endSpan(span, err) }()
+
+	return s.next.Search(ctx, search)
+}
+
+func (s todoService) Update(
+	ctx context.Context,
+	changes domain.TodoChanges,
+	scope domain.TodoScope) (updatedTodo domain.Todo, err error) {
+	ctx, span := startSpan(ctx, s.tracer, "todo.Service.Update", trace.SpanKindInternal,
+		trace.WithAttributes(
+			attribute.Int("todo.id", changes.ID),
+			attribute.String("todo.scope", string(scope)),
+		))
+	defer func() { endSpan(span, err) }()
+
+	return s.next.Update(ctx, changes, scope)
+}
+
+func (s todoService) Skip(ctx context.Context, id int, version int) (todo domain.Todo, err error) {
+	ctx, span := startSpan(ctx, s.tracer, "todo.Service.Skip", trace.SpanKindInternal,
+		trace.WithAttributes(attribute.Int("todo.id", id)))
+	defer func() { endSpan(span, err) }()
+
+	return s.next.Skip(ctx, id, version)
+}
+
+func (s todoService) EndSeries(ctx context.Context, id int, version int) (todo domain.Todo, err error) {
+	ctx, span := startSpan(ctx, s.tracer, "todo.Service.EndSeries", trace.SpanKindInternal,
+		trace.WithAttributes(attribute.Int("todo.id", id)))
+	defer func() { endSpan(span, err) }()
+
+	return s.next.EndSeries(ctx, id, version)
+}
+
+func (s todoService) Move(ctx context.Context, move domain.TodoMove) (todo domain.Todo, err error) {
+	ctx, span := startSpan(ctx, s.tracer, "todo.Service.Move", trace.SpanKindInternal,
+		trace.WithAttributes(
+			attribute.Int("todo.id", move.ID),
+			attribute.Int("user.id", move.UserID),
+		))
+	defer func() { endSpan(span, err) }()
+
+	return s.next.Move(ctx, move)
+}
+
+type userService struct {
+	next   user.Service
+	tracer trace.Tracer
+}
+
+// NewUserService decorates user.Service creating an internal span per call.
+func NewUserService(next user.Service, tp trace.TracerProvider) user.Service {
+	return &userService{
+		next:   next,
+		tracer: tp.Tracer(_instrumentationName),
+	}
+}
+
+func (s userService) GetAll(ctx context.Context) (users []domain.User, err error) {
+	ctx, span := startSpan(ctx, s.tracer, "user.Service.GetAll", trace.SpanKindInternal)
+	defer func() { endSpan(span, err) }()
+
+	return s.next.GetAll(ctx)
+}
+
+func (s userService) Get(ctx context.Context, id int) (user domain.User, err error) {
+	ctx, span := startSpan(ctx, s.tracer, "user.Service.Get", trace.SpanKindInternal,
+		trace.WithAttributes(attribute.Int("user.id", id)))
+	defer func() { endSpan(span, err) }()
+
+	return s.next.Get(ctx, id)
+}
+
+func (s userService) GetByEmail(ctx context.Context, email string) (user domain.User, err error) {
+	ctx, span := startSpan(ctx, s.tracer, "user.Service.GetByEmail", trace.SpanKindInternal)
+	defer func() { endSpan(span, err) }()
+
+	return s.next.GetByEmail(ctx, email)
+}
+
+func (s userService) Save(ctx context.Context, user domain.User) (savedUser domain.User, err error) {
+	ctx, span := startSpan(ctx, s.tracer, "user.Service.Save", trace.SpanKindInternal)
+	defer func() { endSpan(span, err) }()
+
+	return s.next.Save(ctx, user)
+}
+
+func (s userService) Update(ctx context.Context, user domain.User) (updatedUser domain.User, err error) {
+	ctx, span := startSpan(ctx, s.tracer, "user.Service.Update", trace.SpanKindInternal,
+		trace.WithAttributes(attribute.Int("user.id", user.ID)))
+	defer func() { endSpan(span, err) }()
+
+	return s.next.Update(ctx, user)
+}
+
+func (s userService) Delete(ctx context.Context, id int, version int) (err error) {
+	ctx, span := startSpan(ctx, s.tracer, "user.Service.Delete", trace.SpanKindInternal,
+		trace.WithAttributes(attribute.Int("user.id", id)))
+	defer func() { endSpan(span, err) }()
+
+	return s.next.Delete(ctx, id, version)
+}
+
+func (s userService) Login(ctx context.Context, email string, password string) (user domain.User, err error) {
+	ctx, span := startSpan(ctx, s.tracer, "user.Service.Login", trace.SpanKindInternal)
+	defer func() { endSpan(span, err) }()
+
+	return s.next.Login(ctx, email, password)
+}
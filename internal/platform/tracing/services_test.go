@@ -0,0 +1,265 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"testing"
+)
+
+type todoServiceMock struct {
+	mock.Mock
+}
+
+func (tsm *todoServiceMock) GetAll(ctx context.Context, userID int) ([]domain.Todo, error) {
+	args := tsm.Called(ctx, userID)
+	return args.Get(0).([]domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) GetPage(
+	ctx context.Context,
+	userID int,
+	after domain.Todo,
+	limit int) ([]domain.Todo, error) {
+	args := tsm.Called(ctx, userID, after, limit)
+	return args.Get(0).([]domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Get(ctx context.Context, id int) (domain.Todo, error) {
+	args := tsm.Called(ctx, id)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Save(ctx context.Context, todo domain.Todo) (domain.Todo, error) {
+	args := tsm.Called(ctx, todo)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Completed(ctx context.Context, id int, version int) (domain.Todo, error) {
+	args := tsm.Called(ctx, id, version)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Delete(ctx context.Context, id int, version int) error {
+	args := tsm.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+func (tsm *todoServiceMock) Batch(
+	ctx context.Context,
+	userID int,
+	operations []domain.TodoOperation,
+	atomic bool) ([]domain.TodoOperationResult, error) {
+	args := tsm.Called(ctx, userID, operations, atomic)
+	return args.Get(0).([]domain.TodoOperationResult), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Import(
+	ctx context.Context,
+	todoImport domain.TodoImport) ([]domain.TodoImportResult, error) {
+	args := tsm.Called(ctx, todoImport)
+	return args.Get(0).([]domain.TodoImportResult), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Search(ctx context.Context, search domain.TodoSearch) (domain.TodoSearchPage, error) {
+	args := tsm.Called(ctx, search)
+	return args.Get(0).(domain.TodoSearchPage), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Update(
+	ctx context.Context,
+	changes domain.TodoChanges,
+	scope domain.TodoScope) (domain.Todo, error) {
+	args := tsm.Called(ctx, changes, scope)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Skip(ctx context.Context, id int, version int) (domain.Todo, error) {
+	args := tsm.Called(ctx, id, version)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) EndSeries(ctx context.Context, id int, version int) (domain.Todo, error) {
+	args := tsm.Called(ctx, id, version)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (tsm *todoServiceMock) Move(ctx context.Context, move domain.TodoMove) (domain.Todo, error) {
+	args := tsm.Called(ctx, move)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+type userServiceMock struct {
+	mock.Mock
+}
+
+func (usm *userServiceMock) GetAll(ctx context.Context) ([]domain.User, error) {
+	args := usm.Called(ctx)
+	return args.Get(0).([]domain.User), args.Error(1)
+}
+
+func (usm *userServiceMock) Get(ctx context.Context, id int) (domain.User, error) {
+	args := usm.Called(ctx, id)
+	return args.Get(0).(domain.User), args.Error(1)
+}
+
+func (usm *userServiceMock) GetByEmail(ctx context.Context, email string) (domain.User, error) {
+	args := usm.Called(ctx, email)
+	return args.Get(0).(domain.User), args.Error(1)
+}
+
+func (usm *userServiceMock) Save(ctx context.Context, user domain.User) (domain.User, error) {
+	args := usm.Called(ctx, user)
+	return args.Get(0).(domain.User), args.Error(1)
+}
+
+func (usm *userServiceMock) Update(ctx context.Context, user domain.User) (domain.User, error) {
+	args := usm.Called(ctx, user)
+	return args.Get(0).(domain.User), args.Error(1)
+}
+
+func (usm *userServiceMock) Delete(ctx context.Context, id int, version int) error {
+	args := usm.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+func (usm *userServiceMock) Login(ctx context.Context, email string, password string) (domain.User, error) {
+	args := usm.Called(ctx, email, password)
+	return args.Get(0).(domain.User), args.Error(1)
+}
+
+func TestTodoServiceGetAll_SuccessfulCreatesSpan(t *testing.T) {
+	// Given
+	expectedTodos := []domain.Todo{{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: 1}}
+
+	var spanContext trace.SpanContext
+
+	tsm := new(todoServiceMock)
+	tsm.On("GetAll", mock.Anything, 1).Return(expectedTodos, nil).Run(func(args mock.Arguments) {
+		spanContext = trace.SpanContextFromContext(args.Get(0).(context.Context))
+	})
+
+	tp, recorder := newTestTracerProvider()
+	service := NewTodoService(tsm, tp)
+
+	// When
+	todos, err := service.GetAll(context.Background(), 1)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, expectedTodos, todos)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "todo.Service.GetAll", spans[0].Name())
+	require.Equal(t, trace.SpanKindInternal, spans[0].SpanKind())
+	require.Equal(t, spans[0].SpanContext().SpanID(), spanContext.SpanID()) // Context is propagated.
+}
+
+func TestTodoServiceCompleted_FailsRecordingError(t *testing.T) {
+	// Given
+	expectedError := errors.New("error completing todo")
+
+	tsm := new(todoServiceMock)
+	tsm.On("Completed", mock.Anything, 1, 0).Return(domain.Todo{}, expectedError)
+
+	tp, recorder := newTestTracerProvider()
+	service := NewTodoService(tsm, tp)
+
+	// When
+	_, err := service.Completed(context.Background(), 1, 0)
+
+	// Then
+	require.ErrorIs(t, err, expectedError)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "todo.Service.Completed", spans[0].Name())
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+	require.Equal(t, expectedError.Error(), spans[0].Status().Description)
+	require.Len(t, spans[0].Events(), 1) // Recorded exception.
+}
+
+func TestTodoServiceSaveGetDelete_SuccessfulCreatesSpans(t *testing.T) {
+	// Given
+	todo := domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: 1}
+	savedTodo := todo
+	savedTodo.ID = 1
+
+	tsm := new(todoServiceMock)
+	tsm.On("Save", mock.Anything, todo).Return(savedTodo, nil)
+	tsm.On("Get", mock.Anything, 1).Return(savedTodo, nil)
+	tsm.On("Delete", mock.Anything, 1, 0).Return(nil)
+
+	tp, recorder := newTestTracerProvider()
+	service := NewTodoService(tsm, tp)
+	ctx := context.Background()
+
+	// When
+	_, saveErr := service.Save(ctx, todo)
+	_, getErr := service.Get(ctx, 1)
+	deleteErr := service.Delete(ctx, 1, 0)
+
+	// Then
+	require.NoError(t, saveErr)
+	require.NoError(t, getErr)
+	require.NoError(t, deleteErr)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	require.Equal(t, "todo.Service.Save", spans[0].Name())
+	require.Equal(t, "todo.Service.Get", spans[1].Name())
+	require.Equal(t, "todo.Service.Delete", spans[2].Name())
+}
+
+func TestUserService_SuccessfulCreatesSpans(t *testing.T) {
+	// Given
+	user := domain.User{ID: 1, FirstName: "John", LastName: "Doe", Email: "john@example.com"}
+
+	usm := new(userServiceMock)
+	usm.On("GetAll", mock.Anything).Return([]domain.User{user}, nil)
+	usm.On("Get", mock.Anything, 1).Return(user, nil)
+	usm.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+	usm.On("Save", mock.Anything, user).Return(user, nil)
+	usm.On("Update", mock.Anything, user).Return(user, nil)
+	usm.On("Delete", mock.Anything, 1, 0).Return(nil)
+	usm.On("Login", mock.Anything, user.Email, "12345678").Return(user, nil)
+
+	tp, recorder := newTestTracerProvider()
+	service := NewUserService(usm, tp)
+	ctx := context.Background()
+
+	// When
+	_, _ = service.GetAll(ctx)
+	_, _ = service.Get(ctx, 1)
+	_, _ = service.GetByEmail(ctx, user.Email)
+	_, _ = service.Save(ctx, user)
+	_, _ = service.Update(ctx, user)
+	_, _ = service.Login(ctx, user.Email, "12345678")
+	err := service.Delete(ctx, 1, 0)
+
+	// Then
+	require.NoError(t, err)
+
+	expectedNames := []string{
+		"user.Service.GetAll",
+		"user.Service.Get",
+		"user.Service.GetByEmail",
+		"user.Service.Save",
+		"user.Service.Update",
+		"user.Service.Login",
+		"user.Service.Delete",
+	}
+
+	spans := recorder.Ended()
+	require.Len(t, spans, len(expectedNames))
+
+	for i, span := range spans {
+		require.Equal(t, expectedNames[i], span.Name())
+		require.Equal(t, codes.Unset, span.Status().Code)
+	}
+}
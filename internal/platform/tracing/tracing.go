@@ -0,0 +1,106 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/fx"
+)
+
+const (
+	// otlpExporter sends spans to an OTLP HTTP collector.
+	otlpExporter = "otlp"
+
+	// stdoutExporter prints spans to the standard output, useful for local debugging.
+	stdoutExporter = "stdout"
+
+	_instrumentationName = "github.com/ferch5003/go-fiber-tutorial"
+)
+
+// NewTracerProvider creates the tracer provider of the application. Tracing is opt-in, so a no-op
+// provider is returned when no exporter is configured.
+func NewTracerProvider(lc fx.Lifecycle, config *config.EnvVars) (trace.TracerProvider, error) {
+	if config.TracingExporter == "" {
+		return noop.NewTracerProvider(), nil
+	}
+
+	exporter, err := newExporter(config)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(config.AppName),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	lc.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			return tp.Shutdown(ctx)
+		},
+	})
+
+	return tp, nil
+}
+
+func newExporter(config *config.EnvVars) (sdktrace.SpanExporter, error) {
+	switch config.TracingExporter {
+	case otlpExporter:
+		options := make([]otlptracehttp.Option, 0)
+		if config.TracingEndpoint != "" {
+			options = append(options, otlptracehttp.WithEndpoint(config.TracingEndpoint), otlptracehttp.WithInsecure())
+		}
+
+		return otlptracehttp.New(context.Background(), options...)
+	case stdoutExporter:
+		return stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter: %s", config.TracingExporter)
+	}
+}
+
+// startSpan starts a child span of the span stored in ctx.
+func startSpan(
+	ctx context.Context,
+	tracer trace.Tracer,
+	name string,
+	kind trace.SpanKind,
+	opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	opts = append(opts, trace.WithSpanKind(kind))
+
+	return tracer.Start(ctx, name, opts...)
+}
+
+// endSpan records err, if any, and ends the span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
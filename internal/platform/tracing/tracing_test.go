@@ -0,0 +1,94 @@
+package tracing
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/config"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+	"go.uber.org/fx/fxtest"
+	"testing"
+)
+
+// newTestTracerProvider records every ended span in memory.
+func newTestTracerProvider() (*sdktrace.TracerProvider, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	return tp, recorder
+}
+
+func TestNewTracerProvider_SuccessfulDisabledByDefault(t *testing.T) {
+	// Given
+	lc := fxtest.NewLifecycle(t)
+
+	// When
+	tp, err := NewTracerProvider(lc, &config.EnvVars{AppName: "test"})
+
+	// Then
+	require.NoError(t, err)
+	require.IsType(t, noop.TracerProvider{}, tp)
+}
+
+func TestNewTracerProvider_SuccessfulWithStdoutExporter(t *testing.T) {
+	// Given
+	lc := fxtest.NewLifecycle(t)
+
+	// When
+	tp, err := NewTracerProvider(lc, &config.EnvVars{AppName: "test", TracingExporter: stdoutExporter})
+
+	// Then
+	require.NoError(t, err)
+	require.IsType(t, &sdktrace.TracerProvider{}, tp)
+
+	lc.RequireStart()
+	lc.RequireStop()
+}
+
+func TestNewTracerProvider_SuccessfulWithOTLPExporter(t *testing.T) {
+	// Given
+	lc := fxtest.NewLifecycle(t)
+
+	// When
+	tp, err := NewTracerProvider(lc, &config.EnvVars{
+		AppName:         "test",
+		TracingExporter: otlpExporter,
+		TracingEndpoint: "localhost:4318",
+	})
+
+	// Then
+	require.NoError(t, err)
+	require.IsType(t, &sdktrace.TracerProvider{}, tp)
+}
+
+func TestNewTracerProvider_FailsDueToUnknownExporter(t *testing.T) {
+	// Given
+	lc := fxtest.NewLifecycle(t)
+
+	// When
+	tp, err := NewTracerProvider(lc, &config.EnvVars{AppName: "test", TracingExporter: "zipkin"})
+
+	// Then
+	require.ErrorContains(t, err, "unknown tracing exporter: zipkin")
+	require.Nil(t, tp)
+}
+
+func TestStartSpan_SuccessfulAsChildOfContextSpan(t *testing.T) {
+	// Given
+	tp, recorder := newTestTracerProvider()
+	tracer := tp.Tracer("test")
+
+	ctx, parent := tracer.Start(context.Background(), "parent")
+
+	// When
+	_, child := startSpan(ctx, tracer, "child", 0)
+	endSpan(child, nil)
+	parent.End()
+
+	// Then
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	require.Equal(t, "child", spans[0].Name())
+	require.Equal(t, parent.SpanContext().SpanID(), spans[0].Parent().SpanID())
+}
@@ -2,8 +2,12 @@ package validations
 
 import (
 	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/data"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/logging"
 	"github.com/go-playground/validator/v10"
+	"reflect"
 	"strings"
+	"time"
 )
 
 type (
@@ -20,9 +24,40 @@ type (
 )
 
 func NewValidator() *XValidator {
+	myValidator := validator.New()
+
+	// A patch is validated by its value: an absent one is a nil pointer, which omitnil skips, while a null
+	// one is the zero value, which required rejects.
+	myValidator.RegisterCustomTypeFunc(patchValue,
+		data.Patch[string]{}, data.Patch[int]{}, data.Patch[bool]{}, data.Patch[time.Time]{})
+
 	return &XValidator{
-		validator: validator.New(),
+		validator: myValidator,
+	}
+}
+
+// patchValue returns the value of a patch, the zero value when it is null, and a nil pointer when it is
+// absent rather than nil, as the tags that check empty fields, like required_if, only run on nil values
+// of a type.
+func patchValue(field reflect.Value) any {
+	patch, ok := field.Interface().(interface {
+		Present() bool
+		Value() any
+		ValueType() reflect.Type
+	})
+	if !ok {
+		return nil
 	}
+
+	if !patch.Present() {
+		return reflect.Zero(reflect.PointerTo(patch.ValueType())).Interface()
+	}
+
+	if value := patch.Value(); value != nil {
+		return value
+	}
+
+	return reflect.Zero(patch.ValueType()).Interface()
 }
 
 func (v XValidator) validate(data any) []ErrorResponse {
@@ -36,6 +71,9 @@ func (v XValidator) validate(data any) []ErrorResponse {
 			elem.FailedField = err.Field() // Export struct field name
 			elem.Tag = err.Tag()           // Export struct tag
 			elem.Value = err.Value()       // Export field value
+			if logging.IsSensitive(err.Field()) {
+				elem.Value = logging.Redacted // Never echo secrets back in responses or logs.
+			}
 			elem.Error = true
 
 			validationErrors = append(validationErrors, elem)
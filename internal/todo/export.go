@@ -0,0 +1,224 @@
+package todo
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/ical"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/recurrence"
+	"io"
+	"strconv"
+	"time"
+)
+
+// _icalTimeLayout is the layout of the UTC date-times of iCalendar.
+const _icalTimeLayout = "20060102T150405Z"
+
+// Encoder writes todos to a file one at a time, so exports are streamed instead of built in memory.
+type Encoder interface {
+	// Encode writes the todo.
+	Encode(todo domain.Todo) error
+
+	// Close writes the end of the file and flushes it. It must be called once every todo is written.
+	Close() error
+}
+
+// NewEncoder returns an Encoder writing the format to w. exportedAt is the time iCalendar files are
+// stamped with.
+func NewEncoder(w io.Writer, format Format, exportedAt time.Time) Encoder {
+	switch format {
+	case FormatCSV:
+		return &csvEncoder{w: csv.NewWriter(w)}
+	case FormatICS:
+		return &icsEncoder{w: ical.NewWriter(w), stamp: exportedAt.UTC().Format(_icalTimeLayout)}
+	default:
+		return &jsonEncoder{w: bufio.NewWriter(w)}
+	}
+}
+
+// newExportedTodo returns the todo as it is exported, due in UTC and with its rule without the start of the
+// series, which is its due date.
+func newExportedTodo(todo domain.Todo) ExportedTodo {
+	exported := ExportedTodo{
+		ExternalID:  exportID(todo),
+		Title:       todo.Title,
+		Description: todo.Description,
+		Completed:   todo.Completed,
+		Recurrence:  recurrence.Rule(todo.Recurrence),
+	}
+
+	if todo.DueAt != nil {
+		dueAt := todo.DueAt.UTC()
+		exported.DueAt = &dueAt
+	}
+
+	return exported
+}
+
+// jsonEncoder writes an array, one todo per line.
+type jsonEncoder struct {
+	w       *bufio.Writer
+	started bool
+}
+
+func (e *jsonEncoder) Encode(todo domain.Todo) error {
+	b, err := json.Marshal(newExportedTodo(todo))
+	if err != nil {
+		return err
+	}
+
+	separator := ",\n"
+	if !e.started {
+		separator = "[\n"
+		e.started = true
+	}
+
+	if _, err := e.w.WriteString(separator); err != nil {
+		return err
+	}
+
+	_, err = e.w.Write(b)
+
+	return err
+}
+
+func (e *jsonEncoder) Close() error {
+	end := "\n]\n"
+	if !e.started {
+		end = "[]\n"
+	}
+
+	if _, err := e.w.WriteString(end); err != nil {
+		return err
+	}
+
+	return e.w.Flush()
+}
+
+// csvEncoder writes the header before the first todo.
+type csvEncoder struct {
+	w       *csv.Writer
+	started bool
+}
+
+func (e *csvEncoder) Encode(todo domain.Todo) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	exported := newExportedTodo(todo)
+
+	dueAt := ""
+	if exported.DueAt != nil {
+		dueAt = exported.DueAt.Format(time.RFC3339)
+	}
+
+	return e.w.Write([]string{
+		exported.ExternalID,
+		exported.Title,
+		exported.Description,
+		strconv.FormatBool(exported.Completed),
+		dueAt,
+		exported.Recurrence,
+	})
+}
+
+func (e *csvEncoder) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	e.w.Flush()
+
+	return e.w.Error()
+}
+
+func (e *csvEncoder) writeHeader() error {
+	if e.started {
+		return nil
+	}
+
+	e.started = true
+
+	return e.w.Write(_csvHeader)
+}
+
+// icsEncoder writes a VTODO per todo inside a VCALENDAR.
+type icsEncoder struct {
+	w       *ical.Writer
+	stamp   string
+	started bool
+}
+
+func (e *icsEncoder) Encode(todo domain.Todo) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	status := "NEEDS-ACTION"
+	if todo.Completed {
+		status = "COMPLETED"
+	}
+
+	properties := []ical.Property{
+		{Name: "BEGIN", Value: "VTODO"},
+		{Name: "UID", Value: ical.Escape(exportID(todo))},
+		{Name: "DTSTAMP", Value: e.stamp},
+		{Name: "SUMMARY", Value: ical.Escape(todo.Title)},
+	}
+
+	if todo.Description != "" {
+		properties = append(properties, ical.Property{Name: "DESCRIPTION", Value: ical.Escape(todo.Description)})
+	}
+
+	properties = append(properties, ical.Property{Name: "STATUS", Value: status})
+
+	if todo.DueAt != nil {
+		properties = append(properties, ical.Property{Name: "DUE", Value: todo.DueAt.UTC().Format(_icalTimeLayout)})
+	}
+
+	if todo.Recurrence != "" {
+		properties = append(properties, ical.Property{Name: "RRULE", Value: recurrence.Rule(todo.Recurrence)})
+	}
+
+	properties = append(properties, ical.Property{Name: "END", Value: "VTODO"})
+
+	return e.writeProperties(properties...)
+}
+
+func (e *icsEncoder) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	if err := e.writeProperties(ical.Property{Name: "END", Value: "VCALENDAR"}); err != nil {
+		return err
+	}
+
+	return e.w.Flush()
+}
+
+func (e *icsEncoder) writeHeader() error {
+	if e.started {
+		return nil
+	}
+
+	e.started = true
+
+	return e.writeProperties(
+		ical.Property{Name: "BEGIN", Value: "VCALENDAR"},
+		ical.Property{Name: "VERSION", Value: "2.0"},
+		ical.Property{Name: "PRODID", Value: "-//go-fiber-tutorial//todos//EN"},
+	)
+}
+
+func (e *icsEncoder) writeProperties(properties ...ical.Property) error {
+	for _, property := range properties {
+		if err := e.w.WriteProperty(property); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package todo
+
+import (
+	"bytes"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+var _exportedAt = time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+func exportedTodos() []domain.Todo {
+	dueAt := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.FixedZone("UTC-5", -5*60*60))
+
+	return []domain.Todo{
+		{ID: 1, Title: "Buy milk, eggs", Description: "Two \"liters\"\nand a dozen", UserID: 1, Version: 1},
+		{
+			ID:         2,
+			Title:      "Water plants",
+			Completed:  true,
+			UserID:     1,
+			Version:    3,
+			DueAt:      &dueAt,
+			Recurrence: "DTSTART:20240101T140000Z\nRRULE:FREQ=WEEKLY",
+			SeriesID:   2,
+			ExternalID: "trello-42",
+		},
+	}
+}
+
+func TestEncoder_Successful(t *testing.T) {
+	tests := map[Format]string{
+		FormatJSON: "[\n" +
+			`{"external_id":"todo-1","title":"Buy milk, eggs","description":"Two \"liters\"\nand a dozen",` +
+			`"completed":false,"due_at":null},` + "\n" +
+			`{"external_id":"trello-42","title":"Water plants","description":"","completed":true,` +
+			`"due_at":"2024-01-01T14:00:00Z","recurrence":"FREQ=WEEKLY"}` + "\n" +
+			"]\n",
+		FormatCSV: "external_id,title,description,completed,due_at,recurrence\n" +
+			"todo-1,\"Buy milk, eggs\",\"Two \"\"liters\"\"\nand a dozen\",false,,\n" +
+			"trello-42,Water plants,,true,2024-01-01T14:00:00Z,FREQ=WEEKLY\n",
+		FormatICS: "BEGIN:VCALENDAR\r\n" +
+			"VERSION:2.0\r\n" +
+			"PRODID:-//go-fiber-tutorial//todos//EN\r\n" +
+			"BEGIN:VTODO\r\n" +
+			"UID:todo-1\r\n" +
+			"DTSTAMP:20240301T120000Z\r\n" +
+			"SUMMARY:Buy milk\\, eggs\r\n" +
+			"DESCRIPTION:Two \"liters\"\\nand a dozen\r\n" +
+			"STATUS:NEEDS-ACTION\r\n" +
+			"END:VTODO\r\n" +
+			"BEGIN:VTODO\r\n" +
+			"UID:trello-42\r\n" +
+			"DTSTAMP:20240301T120000Z\r\n" +
+			"SUMMARY:Water plants\r\n" +
+			"STATUS:COMPLETED\r\n" +
+			"DUE:20240101T140000Z\r\n" +
+			"RRULE:FREQ=WEEKLY\r\n" +
+			"END:VTODO\r\n" +
+			"END:VCALENDAR\r\n",
+	}
+
+	for format, expectedFile := range tests {
+		t.Run(string(format), func(t *testing.T) {
+			// Given
+			var buffer bytes.Buffer
+
+			encoder := NewEncoder(&buffer, format, _exportedAt)
+
+			// When
+			for _, todo := range exportedTodos() {
+				require.NoError(t, encoder.Encode(todo))
+			}
+
+			err := encoder.Close()
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, expectedFile, buffer.String())
+		})
+	}
+}
+
+func TestEncoder_SuccessfulWithZeroTodos(t *testing.T) {
+	tests := map[Format]string{
+		FormatJSON: "[]\n",
+		FormatCSV:  "external_id,title,description,completed,due_at,recurrence\n",
+		FormatICS:  "BEGIN:VCALENDAR\r\nVERSION:2.0\r\nPRODID:-//go-fiber-tutorial//todos//EN\r\nEND:VCALENDAR\r\n",
+	}
+
+	for format, expectedFile := range tests {
+		t.Run(string(format), func(t *testing.T) {
+			// Given
+			var buffer bytes.Buffer
+
+			// When
+			err := NewEncoder(&buffer, format, _exportedAt).Close()
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, expectedFile, buffer.String())
+		})
+	}
+}
+
+func TestEncoder_SuccessfulRoundTrip(t *testing.T) {
+	for _, format := range []Format{FormatJSON, FormatCSV, FormatICS} {
+		t.Run(string(format), func(t *testing.T) {
+			// Given
+			var buffer bytes.Buffer
+
+			encoder := NewEncoder(&buffer, format, _exportedAt)
+			for _, todo := range exportedTodos() {
+				require.NoError(t, encoder.Encode(todo))
+			}
+
+			require.NoError(t, encoder.Close())
+
+			// When
+			rows, err := Decode(&buffer, format, 10)
+
+			// Then
+			require.NoError(t, err)
+			require.Len(t, rows, 2)
+
+			for i, todo := range exportedTodos() {
+				row := rows[i]
+				require.NoError(t, row.Err)
+				require.Equal(t, i+1, row.Row)
+				require.Equal(t, exportID(todo), row.Todo.ExternalID)
+				require.Equal(t, todo.Title, row.Todo.Title)
+				require.Equal(t, todo.Description, row.Todo.Description)
+				require.Equal(t, todo.Completed, row.Todo.Completed)
+
+				if todo.DueAt == nil {
+					require.Nil(t, row.Todo.DueAt)
+				} else {
+					require.True(t, todo.DueAt.Equal(*row.Todo.DueAt))
+				}
+
+				// The rule of the series is normalized again from the due date.
+				normalized, err := normalizeSchedule(row.Todo)
+				require.NoError(t, err)
+				require.Equal(t, todo.Recurrence, normalized.Recurrence)
+			}
+		})
+	}
+}
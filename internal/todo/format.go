@@ -0,0 +1,96 @@
+package todo
+
+import (
+	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/ical"
+	"mime"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Format is a file format todos are exported to and imported from.
+type Format string
+
+const (
+	// FormatJSON is an array of todos.
+	FormatJSON Format = "json"
+
+	// FormatCSV has a header row naming the columns of the todos.
+	FormatCSV Format = "csv"
+
+	// FormatICS is an iCalendar file with a VTODO per todo.
+	FormatICS Format = "ics"
+)
+
+// _contentTypes are the media types of the formats, also accepted to detect the format of an import.
+var _contentTypes = map[Format]string{
+	FormatJSON: "application/json",
+	FormatCSV:  "text/csv",
+	FormatICS:  ical.ContentType,
+}
+
+// ParseFormat returns the format of the name, which is case-insensitive.
+func ParseFormat(name string) (Format, error) {
+	format := Format(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := _contentTypes[format]; !ok {
+		return "", fmt.Errorf("%w %q, use json, csv or ics", domain.ErrUnknownFormat, name)
+	}
+
+	return format, nil
+}
+
+// DetectFormat returns the format of an imported file, from the extension of its name or else its
+// content type.
+func DetectFormat(filename, contentType string) (Format, error) {
+	if format, err := ParseFormat(strings.TrimPrefix(filepath.Ext(filename), ".")); err == nil {
+		return format, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	for format, formatType := range _contentTypes {
+		if mediaType == formatType {
+			return format, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w of %q, use a .json, .csv or .ics file", domain.ErrUnknownFormat, filename)
+}
+
+// ContentType returns the media type of the format.
+func (f Format) ContentType() string {
+	if f == FormatJSON {
+		return _contentTypes[f]
+	}
+
+	return _contentTypes[f] + "; charset=utf-8"
+}
+
+// Filename returns the name of an export in the format, dated at the time.
+func (f Format) Filename(at time.Time) string {
+	return fmt.Sprintf("todos-%s.%s", at.UTC().Format("20060102"), f)
+}
+
+// ExportedTodo is a todo as the JSON and CSV formats write it and read it back.
+type ExportedTodo struct {
+	ExternalID  string     `json:"external_id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Completed   bool       `json:"completed"`
+	DueAt       *time.Time `json:"due_at"`
+	Recurrence  string     `json:"recurrence,omitempty"`
+}
+
+// _csvHeader names the columns of the CSV format, in the order they are exported.
+var _csvHeader = []string{"external_id", "title", "description", "completed", "due_at", "recurrence"}
+
+// exportID identifies the todo in an export, so importing the export twice skips the todos imported the
+// first time: the ID of the tool it was imported from, or else its own.
+func exportID(todo domain.Todo) string {
+	if todo.ExternalID != "" {
+		return todo.ExternalID
+	}
+
+	return fmt.Sprintf("todo-%d", todo.ID)
+}
@@ -0,0 +1,305 @@
+package todo
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/ical"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// _icalDateLayout and _icalLocalTimeLayout are the layouts of the iCalendar dates and of the date-times
+// of a time zone, or floating ones.
+const (
+	_icalDateLayout      = "20060102"
+	_icalLocalTimeLayout = "20060102T150405"
+)
+
+// Decode reads the todos of a file in the format, at most maxRows of them. A row that can not be read is
+// returned with its error, so the rest of the file is still imported, while a file that can not be read at
+// all returns domain.ErrInvalidImport.
+func Decode(r io.Reader, format Format, maxRows int) ([]domain.TodoImportRow, error) {
+	var decode func(r io.Reader, maxRows int) ([]domain.TodoImportRow, error)
+
+	switch format {
+	case FormatCSV:
+		decode = decodeCSV
+	case FormatICS:
+		decode = decodeICS
+	default:
+		decode = decodeJSON
+	}
+
+	rows, err := decode(r, maxRows)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", domain.ErrInvalidImport, err)
+	}
+
+	return rows, nil
+}
+
+// importedTodo returns the todo of a row read from a JSON or CSV file.
+func (t ExportedTodo) importedTodo() domain.Todo {
+	return domain.Todo{
+		Title:       t.Title,
+		Description: t.Description,
+		Completed:   t.Completed,
+		DueAt:       t.DueAt,
+		Recurrence:  t.Recurrence,
+		ExternalID:  strings.TrimSpace(t.ExternalID),
+	}
+}
+
+// tooManyRows is the error of a file with more rows than maxRows.
+func tooManyRows(maxRows int) error {
+	return fmt.Errorf("the file has more than %d todos", maxRows)
+}
+
+// decodeJSON reads an array of todos. An element that is not a todo, like a title that is not a string,
+// is a row error; invalid JSON ends the import.
+func decodeJSON(r io.Reader, maxRows int) ([]domain.TodoImportRow, error) {
+	decoder := json.NewDecoder(r)
+
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return nil, errors.New("the file must be a JSON array of todos")
+	}
+
+	rows := make([]domain.TodoImportRow, 0)
+
+	for decoder.More() {
+		if len(rows) == maxRows {
+			return nil, tooManyRows(maxRows)
+		}
+
+		row := domain.TodoImportRow{Row: len(rows) + 1}
+
+		var exported ExportedTodo
+		if err := decoder.Decode(&exported); err != nil {
+			var typeErr *json.UnmarshalTypeError
+			if !errors.As(err, &typeErr) {
+				return nil, err
+			}
+
+			row.Err = err
+		} else {
+			row.Todo = exported.importedTodo()
+		}
+
+		rows = append(rows, row)
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// decodeCSV reads the records after a header naming their columns, which must include the title. Other
+// columns are optional, and unknown ones are ignored.
+func decodeCSV(r io.Reader, maxRows int) ([]domain.TodoImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading the header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		if i == 0 {
+			// Spreadsheets may start the file with a byte order mark.
+			name = strings.TrimPrefix(name, "\uFEFF")
+		}
+
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	if _, ok := columns["title"]; !ok {
+		return nil, errors.New("the header has no title column")
+	}
+
+	rows := make([]domain.TodoImportRow, 0)
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+
+		if len(rows) == maxRows {
+			return nil, tooManyRows(maxRows)
+		}
+
+		row := domain.TodoImportRow{Row: len(rows) + 1}
+
+		var parseErr *csv.ParseError
+		switch {
+		case errors.As(err, &parseErr):
+			row.Err = err
+		case err != nil:
+			return nil, err
+		default:
+			row.Todo, row.Err = csvTodo(columns, record)
+		}
+
+		rows = append(rows, row)
+	}
+}
+
+func csvTodo(columns map[string]int, record []string) (domain.Todo, error) {
+	field := func(name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+
+		return strings.TrimSpace(record[i])
+	}
+
+	exported := ExportedTodo{
+		ExternalID:  field("external_id"),
+		Title:       field("title"),
+		Description: field("description"),
+		Recurrence:  field("recurrence"),
+	}
+
+	if completed := field("completed"); completed != "" {
+		var err error
+		if exported.Completed, err = strconv.ParseBool(completed); err != nil {
+			return domain.Todo{}, fmt.Errorf("completed must be true or false, not %q", completed)
+		}
+	}
+
+	if dueAt := field("due_at"); dueAt != "" {
+		parsed, err := time.Parse(time.RFC3339, dueAt)
+		if err != nil {
+			return domain.Todo{}, fmt.Errorf("due_at must be an RFC 3339 date-time, not %q", dueAt)
+		}
+
+		exported.DueAt = &parsed
+	}
+
+	return exported.importedTodo(), nil
+}
+
+// decodeICS reads the VTODO components of a VCALENDAR, ignoring its other components and the ones nested
+// in a VTODO, like alarms. A VTODO with a malformed line or an invalid value is a row error; a malformed
+// line outside of them ends the import.
+func decodeICS(r io.Reader, maxRows int) ([]domain.TodoImportRow, error) {
+	reader := ical.NewReader(r)
+
+	property, err := reader.ReadProperty()
+	if err != nil || property.Name != "BEGIN" || !strings.EqualFold(property.Value, "VCALENDAR") {
+		return nil, errors.New("the file must start with BEGIN:VCALENDAR")
+	}
+
+	rows := make([]domain.TodoImportRow, 0)
+
+	var row *domain.TodoImportRow // The VTODO being read.
+	depth := 0                    // Of the components nested in the VTODO.
+
+	for {
+		property, err := reader.ReadProperty()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("the file ends before END:VCALENDAR")
+		}
+
+		if err != nil {
+			if row == nil || !errors.Is(err, ical.ErrInvalidLine) {
+				return nil, err
+			}
+
+			if row.Err == nil {
+				row.Err = err
+			}
+
+			continue
+		}
+
+		component := strings.ToUpper(property.Value)
+
+		switch {
+		case row == nil && property.Name == "END" && component == "VCALENDAR":
+			return rows, nil
+		case row == nil && property.Name == "BEGIN" && component == "VTODO":
+			if len(rows) == maxRows {
+				return nil, tooManyRows(maxRows)
+			}
+
+			row = &domain.TodoImportRow{Row: len(rows) + 1}
+		case row == nil:
+		case property.Name == "BEGIN":
+			depth++
+		case property.Name == "END" && depth > 0:
+			depth--
+		case property.Name == "END":
+			rows = append(rows, *row)
+			row = nil
+		case depth == 0 && row.Err == nil:
+			row.Err = setICSProperty(&row.Todo, property)
+		}
+	}
+}
+
+// setICSProperty sets the field of the todo the property of its VTODO is read into.
+func setICSProperty(todo *domain.Todo, property ical.Property) error {
+	switch property.Name {
+	case "UID":
+		todo.ExternalID = strings.TrimSpace(ical.Unescape(property.Value))
+	case "SUMMARY":
+		todo.Title = ical.Unescape(property.Value)
+	case "DESCRIPTION":
+		todo.Description = ical.Unescape(property.Value)
+	case "STATUS":
+		todo.Completed = strings.EqualFold(property.Value, "COMPLETED")
+	case "COMPLETED":
+		todo.Completed = true
+	case "RRULE":
+		todo.Recurrence = property.Value
+	case "DUE":
+		dueAt, err := parseICSTime(property)
+		if err != nil {
+			return err
+		}
+
+		todo.DueAt = &dueAt
+	}
+
+	return nil
+}
+
+// parseICSTime parses a date, a UTC date-time, or a date-time of the TZID param. Dates and floating
+// date-times are read as UTC.
+func parseICSTime(property ical.Property) (time.Time, error) {
+	value := strings.TrimSpace(property.Value)
+
+	location := time.UTC
+	if tzid := property.Params["TZID"]; tzid != "" {
+		var err error
+		if location, err = time.LoadLocation(tzid); err != nil {
+			return time.Time{}, fmt.Errorf("%s has an unknown time zone %q", property.Name, tzid)
+		}
+	}
+
+	layout := _icalLocalTimeLayout
+	switch {
+	case property.Params["VALUE"] == "DATE" || len(value) == len(_icalDateLayout):
+		layout = _icalDateLayout
+	case strings.HasSuffix(value, "Z"):
+		layout, location = _icalTimeLayout, time.UTC
+	}
+
+	parsed, err := time.ParseInLocation(layout, value, location)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an iCalendar date or date-time, not %q", property.Name, value)
+	}
+
+	return parsed, nil
+}
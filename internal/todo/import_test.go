@@ -0,0 +1,246 @@
+package todo
+
+import (
+	"errors"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecode_Successful(t *testing.T) {
+	dueAt := time.Date(2024, time.January, 1, 14, 0, 0, 0, time.UTC)
+	bogota, err := time.LoadLocation("America/Bogota")
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		format       Format
+		file         string
+		expectedRows []domain.TodoImportRow
+	}{
+		"JSON": {
+			format: FormatJSON,
+			file: `[{"title": "Lorem", "completed": true, "due_at": "2024-01-01T14:00:00Z", "id": 7},
+					{"title": 1},
+					{"external_id": " a ", "title": "Ipsum", "recurrence": "FREQ=DAILY"}]`,
+			expectedRows: []domain.TodoImportRow{
+				{Row: 1, Todo: domain.Todo{Title: "Lorem", Completed: true, DueAt: &dueAt}},
+				{
+					Row: 2,
+					Err: errors.New(
+						"json: cannot unmarshal number into Go struct field ExportedTodo.title of type string"),
+				},
+				{Row: 3, Todo: domain.Todo{Title: "Ipsum", Recurrence: "FREQ=DAILY", ExternalID: "a"}},
+			},
+		},
+		"CSV": {
+			format: FormatCSV,
+			file: "\uFEFFTitle,Completed,Due_At,notes\n" +
+				"Lorem,TRUE,2024-01-01T09:00:00-05:00,ignored\n" +
+				"Ipsum,maybe,,\n" +
+				"Dolor,,2024-01-01,\n" +
+				"Sit\n" +
+				"Bare \"quote\",,,\n",
+			expectedRows: []domain.TodoImportRow{
+				{Row: 1, Todo: domain.Todo{Title: "Lorem", Completed: true, DueAt: &dueAt}},
+				{Row: 2, Err: errors.New(`completed must be true or false, not "maybe"`)},
+				{Row: 3, Err: errors.New(`due_at must be an RFC 3339 date-time, not "2024-01-01"`)},
+				{Row: 4, Todo: domain.Todo{Title: "Sit"}},
+				{Row: 5, Err: errors.New(`parse error on line 6, column 6: bare " in non-quoted-field`)},
+			},
+		},
+		"ICS": {
+			format: FormatICS,
+			file: "BEGIN:VCALENDAR\n" +
+				"BEGIN:VEVENT\nSUMMARY:Ignored\nEND:VEVENT\n" +
+				"BEGIN:VTODO\nUID:a\nSUMMARY:Lorem\\, ipsum\nSTATUS:COMPLETED\nDUE:20240101T140000Z\n" +
+				"BEGIN:VALARM\nDESCRIPTION:Ignored\nEND:VALARM\nEND:VTODO\n" +
+				"BEGIN:VTODO\nSUMMARY:Dolor\nDUE;TZID=America/Bogota:20240101T090000\nRRULE:FREQ=DAILY\nEND:VTODO\n" +
+				"BEGIN:VTODO\nSUMMARY:Sit\nDUE;VALUE=DATE:20240101\nCOMPLETED:20240102T000000Z\nEND:VTODO\n" +
+				"BEGIN:VTODO\nSUMMARY:Amet\nDUE:tomorrow\nEND:VTODO\n" +
+				"BEGIN:VTODO\nSUMMARY\nEND:VTODO\n" +
+				"END:VCALENDAR\n",
+			expectedRows: []domain.TodoImportRow{
+				{Row: 1, Todo: domain.Todo{Title: "Lorem, ipsum", Completed: true, DueAt: &dueAt, ExternalID: "a"}},
+				{Row: 2, Todo: domain.Todo{Title: "Dolor", DueAt: januaryFirst(9, bogota), Recurrence: "FREQ=DAILY"}},
+				{Row: 3, Todo: domain.Todo{Title: "Sit", Completed: true, DueAt: januaryFirst(0, time.UTC)}},
+				{Row: 4, Err: errors.New(`DUE must be an iCalendar date or date-time, not "tomorrow"`)},
+				{Row: 5, Err: errors.New(`line 29: invalid content line: "SUMMARY" has no value`)},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// When
+			rows, err := Decode(strings.NewReader(test.file), test.format, 10)
+
+			// Then
+			require.NoError(t, err)
+			require.Len(t, rows, len(test.expectedRows))
+
+			for i, expectedRow := range test.expectedRows {
+				row := rows[i]
+				require.Equal(t, expectedRow.Row, row.Row)
+
+				if expectedRow.Err != nil {
+					require.EqualError(t, row.Err, expectedRow.Err.Error())
+
+					continue
+				}
+
+				require.NoError(t, row.Err)
+				requireImportedTodo(t, expectedRow.Todo, row.Todo)
+			}
+		})
+	}
+}
+
+func TestDecode_FailsDueToInvalidFile(t *testing.T) {
+	tests := map[string]struct {
+		format        Format
+		file          string
+		expectedError string
+	}{
+		"JSONNotArray": {
+			format:        FormatJSON,
+			file:          `{"title": "Lorem"}`,
+			expectedError: "the imported file is not valid: the file must be a JSON array of todos",
+		},
+		"JSONSyntax": {
+			format:        FormatJSON,
+			file:          `[{"title": "Lorem"`,
+			expectedError: "the imported file is not valid: unexpected EOF",
+		},
+		"JSONTooManyRows": {
+			format:        FormatJSON,
+			file:          `[{"title": "Lorem"}, {"title": "Ipsum"}, {"title": "Dolor"}]`,
+			expectedError: "the imported file is not valid: the file has more than 2 todos",
+		},
+		"CSVEmpty": {
+			format:        FormatCSV,
+			file:          "",
+			expectedError: "the imported file is not valid: reading the header: EOF",
+		},
+		"CSVWithoutTitle": {
+			format:        FormatCSV,
+			file:          "name,description\nLorem,Ipsum\n",
+			expectedError: "the imported file is not valid: the header has no title column",
+		},
+		"CSVTooManyRows": {
+			format:        FormatCSV,
+			file:          "title\nLorem\nIpsum\nDolor\n",
+			expectedError: "the imported file is not valid: the file has more than 2 todos",
+		},
+		"ICSNotCalendar": {
+			format:        FormatICS,
+			file:          "BEGIN:VTODO\nSUMMARY:Lorem\nEND:VTODO\n",
+			expectedError: "the imported file is not valid: the file must start with BEGIN:VCALENDAR",
+		},
+		"ICSUnterminated": {
+			format:        FormatICS,
+			file:          "BEGIN:VCALENDAR\nBEGIN:VTODO\nSUMMARY:Lorem\n",
+			expectedError: "the imported file is not valid: the file ends before END:VCALENDAR",
+		},
+		"ICSInvalidLine": {
+			format:        FormatICS,
+			file:          "BEGIN:VCALENDAR\nVERSION\nEND:VCALENDAR\n",
+			expectedError: `the imported file is not valid: line 2: invalid content line: "VERSION" has no value`,
+		},
+		"ICSTooManyRows": {
+			format: FormatICS,
+			file: "BEGIN:VCALENDAR\n" + strings.Repeat("BEGIN:VTODO\nSUMMARY:Lorem\nEND:VTODO\n", 3) +
+				"END:VCALENDAR\n",
+			expectedError: "the imported file is not valid: the file has more than 2 todos",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// When
+			rows, err := Decode(strings.NewReader(test.file), test.format, 2)
+
+			// Then
+			require.ErrorIs(t, err, domain.ErrInvalidImport)
+			require.EqualError(t, err, test.expectedError)
+			require.Nil(t, rows)
+		})
+	}
+}
+
+func TestDetectFormat_Successful(t *testing.T) {
+	tests := map[string]struct {
+		filename       string
+		contentType    string
+		expectedFormat Format
+	}{
+		"Extension": {
+			filename:       "todos.CSV",
+			contentType:    "application/octet-stream",
+			expectedFormat: FormatCSV,
+		},
+		"ContentType": {
+			filename:       "todos",
+			contentType:    "text/calendar; charset=utf-8",
+			expectedFormat: FormatICS,
+		},
+		"ExtensionOverContentType": {
+			filename:       "todos.json",
+			contentType:    "text/plain",
+			expectedFormat: FormatJSON,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// When
+			format, err := DetectFormat(test.filename, test.contentType)
+
+			// Then
+			require.NoError(t, err)
+			require.Equal(t, test.expectedFormat, format)
+		})
+	}
+}
+
+func TestDetectFormat_FailsDueToUnknownFormat(t *testing.T) {
+	// When
+	format, err := DetectFormat("todos.xlsx", "application/octet-stream")
+
+	// Then
+	require.ErrorIs(t, err, domain.ErrUnknownFormat)
+	require.Empty(t, format)
+}
+
+func TestParseFormat_FailsDueToUnknownFormat(t *testing.T) {
+	// When
+	format, err := ParseFormat("xml")
+
+	// Then
+	require.ErrorIs(t, err, domain.ErrUnknownFormat)
+	require.EqualError(t, err, `unknown format "xml", use json, csv or ics`)
+	require.Empty(t, format)
+}
+
+// januaryFirst returns the first of January of 2024 at the hour in the location.
+func januaryFirst(hour int, location *time.Location) *time.Time {
+	at := time.Date(2024, time.January, 1, hour, 0, 0, 0, location)
+
+	return &at
+}
+
+// requireImportedTodo asserts the todos are equal, with their due dates compared as instants.
+func requireImportedTodo(t *testing.T, expected, actual domain.Todo) {
+	t.Helper()
+
+	if expected.DueAt == nil {
+		require.Nil(t, actual.DueAt)
+	} else {
+		require.NotNil(t, actual.DueAt)
+		require.True(t, expected.DueAt.Equal(*actual.DueAt), "expected due at %s, got %s", expected.DueAt, actual.DueAt)
+	}
+
+	expected.DueAt, actual.DueAt = nil, nil
+	require.Equal(t, expected, actual)
+}
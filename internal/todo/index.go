@@ -0,0 +1,138 @@
+package todo
+
+import (
+	"cmp"
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"math"
+	"slices"
+)
+
+const (
+	// BM25 parameters, with their usual values: _bm25K1 saturates the frequency of a term in a todo,
+	// and _bm25B normalizes it by the length of the todo.
+	_bm25K1 = 1.2
+	_bm25B  = 0.75
+
+	// _titleBoost is the number of times the words of the title are counted, ranking the todos that
+	// match in their title first.
+	_titleBoost = 2
+)
+
+type indexSearcher struct {
+	repository Repository
+}
+
+// NewIndexSearcher creates a Searcher ranking the todos of the user with BM25 in an in-process index.
+// The index is built from the repository on every search, so it never misses a write; this is cheap
+// because a user has few todos.
+func NewIndexSearcher(repository Repository) Searcher {
+	return &indexSearcher{
+		repository: repository,
+	}
+}
+
+func (s indexSearcher) Search(ctx context.Context, search domain.TodoSearch) (domain.TodoSearchPage, error) {
+	page := newSearchPage(search)
+
+	terms := queryTerms(search.Query)
+	if len(terms) == 0 {
+		return page, nil
+	}
+
+	todos, err := s.repository.GetAll(ctx, search.UserID)
+	if err != nil {
+		return domain.TodoSearchPage{}, err
+	}
+
+	hits := newIndex(todos).search(terms)
+	page.Total = len(hits)
+
+	start := min(search.Offset(), len(hits))
+	end := min(start+search.PageSize, len(hits))
+
+	for _, hit := range hits[start:end] {
+		hit.Snippet = snippet(hit.Todo, terms)
+		page.Hits = append(page.Hits, hit)
+	}
+
+	return page, nil
+}
+
+// index is an inverted index of todos.
+type index struct {
+	todos         []domain.Todo
+	lengths       []int                  // Number of terms of every todo.
+	averageLength float64                // Average number of terms of the todos.
+	postings      map[string]map[int]int // Frequency of the term in the todos, by position in todos.
+}
+
+func newIndex(todos []domain.Todo) *index {
+	idx := &index{
+		todos:    todos,
+		lengths:  make([]int, len(todos)),
+		postings: make(map[string]map[int]int),
+	}
+
+	totalLength := 0
+
+	for position, todo := range todos {
+		terms := tokenize(todo.Description)
+		for range _titleBoost {
+			terms = append(terms, tokenize(todo.Title)...)
+		}
+
+		for _, term := range terms {
+			if idx.postings[term] == nil {
+				idx.postings[term] = make(map[int]int)
+			}
+
+			idx.postings[term][position]++
+		}
+
+		idx.lengths[position] = len(terms)
+		totalLength += len(terms)
+	}
+
+	if len(todos) > 0 {
+		idx.averageLength = float64(totalLength) / float64(len(todos))
+	}
+
+	return idx
+}
+
+// search returns the todos containing any of the terms, from the highest BM25 score. Ties are ordered by
+// ID.
+func (idx *index) search(terms []string) []domain.TodoSearchHit {
+	scores := make(map[int]float64)
+	total := float64(len(idx.todos))
+
+	for _, term := range terms {
+		postings := idx.postings[term]
+		matching := float64(len(postings))
+
+		idf := math.Log(1 + (total-matching+0.5)/(matching+0.5))
+
+		for position, frequency := range postings {
+			tf := float64(frequency)
+			norm := _bm25K1 * (1 - _bm25B + _bm25B*float64(idx.lengths[position])/idx.averageLength)
+
+			scores[position] += idf * tf * (_bm25K1 + 1) / (tf + norm)
+		}
+	}
+
+	hits := make([]domain.TodoSearchHit, 0, len(scores))
+	for position, score := range scores {
+		hits = append(hits, domain.TodoSearchHit{Todo: idx.todos[position], Score: score})
+	}
+
+	slices.SortFunc(hits, func(a, b domain.TodoSearchHit) int {
+		if byScore := cmp.Compare(b.Score, a.Score); byScore != 0 {
+			return byScore
+		}
+
+		return cmp.Compare(a.Todo.ID, b.Todo.ID)
+	})
+
+	return hits
+}
@@ -0,0 +1,538 @@
+package todo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// eventAppender is the part of audit.Repository the writes need.
+type eventAppender interface {
+	Append(ctx context.Context, events ...domain.AuditEvent) error
+}
+
+// pendingEvents keeps the events of a batch, to append them once it is applied.
+type pendingEvents struct {
+	events []domain.AuditEvent
+}
+
+func (p *pendingEvents) Append(_ context.Context, events ...domain.AuditEvent) error {
+	p.events = append(p.events, events...)
+
+	return nil
+}
+
+// messageAppender is the part of outbox.Repository the writes need.
+type messageAppender interface {
+	Append(ctx context.Context, messages ...domain.OutboxMessage) error
+}
+
+// pendingMessages keeps the outbox messages of a batch, to append them once it is applied.
+type pendingMessages struct {
+	messages []domain.OutboxMessage
+}
+
+func (p *pendingMessages) Append(_ context.Context, messages ...domain.OutboxMessage) error {
+	p.messages = append(p.messages, messages...)
+
+	return nil
+}
+
+type memoryRepository struct {
+	mutex    *sync.RWMutex
+	lastID   int
+	todos    map[int]domain.Todo
+	events   eventAppender
+	messages messageAppender
+}
+
+// NewMemoryRepository creates a thread-safe Repository that keeps todos in memory, appending the audit
+// events of its writes to events and their outbox messages to messages. It behaves like the MySQL
+// Repository, so it can replace it in development and tests.
+func NewMemoryRepository(events audit.Repository, messages outbox.Repository) Repository {
+	return &memoryRepository{
+		mutex:    &sync.RWMutex{},
+		todos:    make(map[int]domain.Todo),
+		events:   events,
+		messages: messages,
+	}
+}
+
+func (r *memoryRepository) GetAll(_ context.Context, userID int) ([]domain.Todo, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	todos := make([]domain.Todo, 0)
+
+	for _, todo := range r.todos {
+		if todo.UserID == userID {
+			todos = append(todos, todo)
+		}
+	}
+
+	slices.SortFunc(todos, func(a, b domain.Todo) int {
+		if a.Position != b.Position {
+			return a.Position - b.Position
+		}
+
+		return a.ID - b.ID
+	})
+
+	return todos, nil
+}
+
+func (r *memoryRepository) GetPage(
+	ctx context.Context,
+	userID int,
+	after domain.Todo,
+	limit int) ([]domain.Todo, error) {
+	todos, err := r.GetAll(ctx, userID)
+	if err != nil {
+		return make([]domain.Todo, 0), err
+	}
+
+	if after.ID != 0 {
+		todos = slices.DeleteFunc(todos, func(todo domain.Todo) bool {
+			return todo.Position < after.Position || todo.Position == after.Position && todo.ID <= after.ID
+		})
+	}
+
+	return todos[:min(limit, len(todos))], nil
+}
+
+func (r *memoryRepository) Get(_ context.Context, id int) (domain.Todo, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	todo, ok := r.todos[id]
+	if !ok {
+		return domain.Todo{}, sql.ErrNoRows
+	}
+
+	return todo, nil
+}
+
+func (r *memoryRepository) Save(ctx context.Context, todo domain.Todo) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	todo.Completed = false // New todos are pending, only imported ones keep their state.
+
+	return r.insert(ctx, todo)
+}
+
+func (r *memoryRepository) SaveAll(ctx context.Context, userID int, todos []domain.Todo) ([]int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Like in Batch, restoring these undoes the saved todos when one of them fails.
+	lastID := r.lastID
+	saved := maps.Clone(r.todos)
+	events, messages := r.events, r.messages
+	pending, pendingMessages := &pendingEvents{}, &pendingMessages{}
+
+	r.events, r.messages = pending, pendingMessages
+	defer func() {
+		r.events, r.messages = events, messages
+	}()
+
+	rollback := func(err error) ([]int, error) {
+		r.lastID = lastID
+		r.todos = saved
+
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(todos))
+
+	for _, todo := range todos {
+		todo.UserID = userID
+
+		id, err := r.insert(ctx, todo)
+		if err != nil {
+			return rollback(err)
+		}
+
+		ids = append(ids, id)
+	}
+
+	if err := events.Append(ctx, pending.events...); err != nil {
+		return rollback(err)
+	}
+
+	if err := messages.Append(ctx, pendingMessages.messages...); err != nil {
+		return rollback(err)
+	}
+
+	return ids, nil
+}
+
+func (r *memoryRepository) ExternalIDs(_ context.Context, userID int, externalIDs []string) ([]string, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	existing := make([]string, 0)
+
+	for _, todo := range r.todos {
+		if todo.UserID == userID && todo.ExternalID != "" && slices.Contains(externalIDs, todo.ExternalID) {
+			existing = append(existing, todo.ExternalID)
+		}
+	}
+
+	return existing, nil
+}
+
+// insert must be called holding the mutex. It saves the todo after the other todos of the user, starting
+// its series when it recurs and is not an occurrence of one. Like the unique index of the database, a
+// non-empty external ID can only be used once per user.
+func (r *memoryRepository) insert(ctx context.Context, todo domain.Todo) (int, error) {
+	lastPosition := 0
+	for _, userTodo := range r.todos {
+		if userTodo.UserID != todo.UserID {
+			continue
+		}
+
+		if todo.ExternalID != "" && userTodo.ExternalID == todo.ExternalID {
+			return 0, domain.ErrDuplicateExternalID
+		}
+
+		lastPosition = max(lastPosition, userTodo.Position)
+	}
+
+	todo.ID = r.lastID + 1
+	todo.Version = 1
+	todo.Position = nextPosition(lastPosition)
+
+	if todo.Recurrence != "" && todo.SeriesID == 0 {
+		todo.SeriesID = todo.ID
+	}
+
+	if err := r.record(ctx, domain.AuditTodoCreated, todo.ID, nil, &todo); err != nil {
+		return 0, err
+	}
+
+	r.lastID = todo.ID
+	r.todos[todo.ID] = todo
+
+	return todo.ID, nil
+}
+
+// record appends the audit event and the outbox message of the action on the todo, before the todo is
+// written like in the transaction of the MySQL Repository. before is nil for a created todo, and after for
+// a deleted one.
+func (r *memoryRepository) record(
+	ctx context.Context,
+	action string,
+	id int,
+	before *domain.Todo,
+	after *domain.Todo) error {
+	event, err := audit.NewEvent(ctx, action, domain.AuditTodo, id, before, after)
+	if err != nil {
+		return err
+	}
+
+	message, err := newMessage(action, id, before, after)
+	if err != nil {
+		return err
+	}
+
+	if err := r.events.Append(ctx, event); err != nil {
+		return err
+	}
+
+	return r.messages.Append(ctx, message)
+}
+
+func (r *memoryRepository) Completed(ctx context.Context, id int, version int) (domain.Todo, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	completedTodo, err := r.writeSeries(ctx, id, version, domain.AuditTodoCompleted, completeWrite)
+
+	// Like the UPDATE statement, completing a missing todo is not an error.
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.Todo{}, nil
+	}
+
+	return completedTodo, err
+}
+
+func (r *memoryRepository) Update(ctx context.Context, todo domain.Todo, scope domain.TodoScope) (domain.Todo, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	plan := func(current domain.Todo) (seriesWrite, error) {
+		return updateWrite(current, todo, scope)
+	}
+
+	return r.writeSeries(ctx, todo.ID, todo.Version, domain.AuditTodoUpdated, plan)
+}
+
+func (r *memoryRepository) Skip(ctx context.Context, id int, version int) (domain.Todo, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.writeSeries(ctx, id, version, domain.AuditTodoSkipped, skipWrite)
+}
+
+func (r *memoryRepository) EndSeries(ctx context.Context, id int, version int) (domain.Todo, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.writeSeries(ctx, id, version, domain.AuditTodoSeriesEnded, endSeriesWrite)
+}
+
+func (r *memoryRepository) Move(ctx context.Context, move domain.TodoMove) (domain.Todo, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	todos := make([]todoPosition, 0)
+	for _, todo := range r.todos {
+		if todo.UserID == move.UserID {
+			todos = append(todos, todoPosition{ID: todo.ID, Position: todo.Position, Version: todo.Version})
+		}
+	}
+
+	slices.SortFunc(todos, func(a, b todoPosition) int {
+		if a.Position != b.Position {
+			return a.Position - b.Position
+		}
+
+		return a.ID - b.ID
+	})
+
+	positions, err := planMove(todos, move)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	// Only the position of the moved todo is recorded, the rebalanced ones keep their order.
+	before := r.todos[move.ID]
+	after := before
+	after.Position = positions[move.ID]
+	after.Version++
+
+	if err := r.record(ctx, domain.AuditTodoMoved, move.ID, &before, &after); err != nil {
+		return domain.Todo{}, err
+	}
+
+	for id, position := range positions {
+		todo := r.todos[id]
+		todo.Position = position
+
+		if id == move.ID {
+			todo.Version++
+		}
+
+		r.todos[id] = todo
+	}
+
+	return r.todos[move.ID], nil
+}
+
+// writeSeries must be called holding the mutex. It applies the write planned from the current todo,
+// recording it as the action, and returns the written todo.
+func (r *memoryRepository) writeSeries(
+	ctx context.Context,
+	id int,
+	version int,
+	action string,
+	plan func(current domain.Todo) (seriesWrite, error)) (domain.Todo, error) {
+	current, ok := r.todos[id]
+	if !ok {
+		return domain.Todo{}, sql.ErrNoRows
+	}
+
+	if version != 0 && version != current.Version {
+		return domain.Todo{}, domain.ErrVersionMismatch
+	}
+
+	write, err := plan(current)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	written := write.todo
+	written.Version = current.Version + 1
+
+	if err := r.record(ctx, action, id, &current, &written); err != nil {
+		return domain.Todo{}, err
+	}
+
+	if following := write.following; following != nil {
+		for _, todo := range r.todos {
+			if todo.SeriesID != current.SeriesID || todo.ID == id || todo.Completed ||
+				todo.DueAt == nil || !todo.DueAt.After(*current.DueAt) {
+				continue
+			}
+
+			todo.Title = following.Title
+			todo.Description = following.Description
+			if todo.Recurrence != "" && following.Recurrence != "" {
+				todo.Recurrence = following.Recurrence
+			}
+
+			todo.Version++
+			r.todos[todo.ID] = todo
+		}
+	}
+
+	nextID := 0
+
+	if write.next != nil {
+		if nextID, err = r.insert(ctx, *write.next); err != nil {
+			return domain.Todo{}, err
+		}
+	}
+
+	r.todos[id] = written
+	written.NextID = nextID
+
+	return written, nil
+}
+
+func (r *memoryRepository) Delete(ctx context.Context, id int, version int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	todo, ok := r.todos[id]
+	if !ok {
+		return errors.New("no rows affected")
+	}
+
+	if version != 0 && todo.Version != version {
+		return domain.ErrVersionMismatch
+	}
+
+	if err := r.record(ctx, domain.AuditTodoDeleted, id, &todo, nil); err != nil {
+		return err
+	}
+
+	delete(r.todos, id)
+
+	return nil
+}
+
+func (r *memoryRepository) Batch(
+	ctx context.Context,
+	userID int,
+	operations []domain.TodoOperation,
+	atomic bool) ([]domain.TodoOperationResult, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Like the transaction, restoring these undoes the batch, whose events and messages are only appended
+	// when it is applied.
+	lastID := r.lastID
+	todos := maps.Clone(r.todos)
+	events, messages := r.events, r.messages
+	pending, pendingMessages := &pendingEvents{}, &pendingMessages{}
+
+	r.events, r.messages = pending, pendingMessages
+	defer func() {
+		r.events, r.messages = events, messages
+	}()
+
+	results := make([]domain.TodoOperationResult, len(operations))
+
+	for i, operation := range operations {
+		written, err := r.applyOperation(ctx, userID, operation)
+		results[i] = domain.TodoOperationResult{
+			Index:   i,
+			Action:  operation.Action,
+			ID:      written.ID,
+			Version: written.Version,
+			NextID:  written.NextID,
+			Err:     err,
+		}
+
+		if err != nil && atomic {
+			r.lastID = lastID
+			r.todos = todos
+
+			return abortBatch(results, i, operations), nil
+		}
+	}
+
+	if err := events.Append(ctx, pending.events...); err != nil {
+		r.lastID = lastID
+		r.todos = todos
+
+		return nil, err
+	}
+
+	if err := messages.Append(ctx, pendingMessages.messages...); err != nil {
+		r.lastID = lastID
+		r.todos = todos
+
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// applyOperation must be called holding the mutex. It returns the written todo, with its ID and new
+// version. Only its ID is returned when the operation fails, and no version when the todo was deleted.
+func (r *memoryRepository) applyOperation(
+	ctx context.Context,
+	userID int,
+	operation domain.TodoOperation) (domain.Todo, error) {
+	if operation.Action == domain.TodoCreate {
+		description, _ := operation.Description.Get()
+
+		id, err := r.insert(ctx, domain.Todo{Title: operation.Title, Description: description, UserID: userID})
+		if err != nil {
+			return domain.Todo{}, err
+		}
+
+		return domain.Todo{ID: id, Version: 1}, nil
+	}
+
+	todo, ok := r.todos[operation.ID]
+	if !ok {
+		return domain.Todo{ID: operation.ID}, domain.ErrNotFound
+	}
+
+	if todo.UserID != userID {
+		return domain.Todo{ID: operation.ID}, domain.ErrNotOwned
+	}
+
+	if operation.Version != 0 && operation.Version != todo.Version {
+		return domain.Todo{ID: operation.ID}, domain.ErrVersionMismatch
+	}
+
+	switch operation.Action {
+	case domain.TodoUpdate:
+		written, err := r.writeSeries(ctx, operation.ID, todo.Version, domain.AuditTodoUpdated,
+			batchUpdateWrite(operation))
+		if err != nil {
+			return domain.Todo{ID: operation.ID}, err
+		}
+
+		return written, nil
+	case domain.TodoComplete:
+		written, err := r.writeSeries(ctx, operation.ID, todo.Version, domain.AuditTodoCompleted, completeWrite)
+		if err != nil {
+			return domain.Todo{ID: operation.ID}, err
+		}
+
+		return written, nil
+	case domain.TodoDelete:
+		if err := r.record(ctx, domain.AuditTodoDeleted, operation.ID, &todo, nil); err != nil {
+			return domain.Todo{ID: operation.ID}, err
+		}
+
+		delete(r.todos, operation.ID)
+
+		return domain.Todo{ID: operation.ID}, nil
+	default:
+		return domain.Todo{ID: operation.ID}, fmt.Errorf("unknown todo action: %q", operation.Action)
+	}
+}
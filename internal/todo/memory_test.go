@@ -0,0 +1,39 @@
+package todo_test
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox"
+	"github.com/ferch5003/go-fiber-tutorial/internal/todo"
+	"github.com/ferch5003/go-fiber-tutorial/internal/todo/todotest"
+	"testing"
+)
+
+func TestMemoryRepository_Conformance(t *testing.T) {
+	todotest.RunRepositoryTests(t, func(t *testing.T) todo.Repository {
+		return todo.NewMemoryRepository(audit.NewMemoryRepository(), outbox.NewMemoryRepository())
+	})
+}
+
+func TestIndexSearcher_Conformance(t *testing.T) {
+	todotest.RunSearcherTests(t, func(t *testing.T) (todo.Repository, todo.Searcher) {
+		repository := todo.NewMemoryRepository(audit.NewMemoryRepository(), outbox.NewMemoryRepository())
+
+		return repository, todo.NewIndexSearcher(repository)
+	})
+}
+
+func TestMemoryRepository_AuditConformance(t *testing.T) {
+	todotest.RunAuditTests(t, func(t *testing.T) (todo.Repository, audit.Repository) {
+		events := audit.NewMemoryRepository()
+
+		return todo.NewMemoryRepository(events, outbox.NewMemoryRepository()), events
+	})
+}
+
+func TestMemoryRepository_OutboxConformance(t *testing.T) {
+	todotest.RunOutboxTests(t, func(t *testing.T) (todo.Repository, outbox.Repository) {
+		messages := outbox.NewMemoryRepository()
+
+		return todo.NewMemoryRepository(audit.NewMemoryRepository(), messages), messages
+	})
+}
@@ -0,0 +1,82 @@
+package todo
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"slices"
+)
+
+// _positionGap is the distance between the positions of consecutive todos when they are appended or
+// rebalanced. About ten moves between the same two todos fit in it before a rebalance.
+const _positionGap = 1024
+
+// todoPosition is what a move needs to know about the todos of the user.
+type todoPosition struct {
+	ID       int `db:"id"`
+	Position int `db:"position"`
+	Version  int `db:"version"`
+}
+
+// nextPosition returns the position of a todo appended after the todo at the last position.
+func nextPosition(lastPosition int) int {
+	return lastPosition + _positionGap
+}
+
+// planMove returns the new positions of the todos written by the move, by ID, from the todos of the user
+// ordered by position. The moved todo gets the position in the middle of its new neighbours; when there
+// is no room between them, every todo is rebalanced to _positionGap apart.
+func planMove(todos []todoPosition, move domain.TodoMove) (map[int]int, error) {
+	if move.BeforeID == move.ID || move.AfterID == move.ID {
+		return nil, domain.ErrInvalidMove
+	}
+
+	from := slices.IndexFunc(todos, func(todo todoPosition) bool { return todo.ID == move.ID })
+	if from < 0 {
+		return nil, domain.ErrNotFound
+	}
+
+	if move.Version != 0 && move.Version != todos[from].Version {
+		return nil, domain.ErrVersionMismatch
+	}
+
+	moved := todos[from]
+	others := slices.Delete(slices.Clone(todos), from, from+1)
+
+	neighbourID := move.BeforeID
+	if neighbourID == 0 {
+		neighbourID = move.AfterID
+	}
+
+	to := slices.IndexFunc(others, func(todo todoPosition) bool { return todo.ID == neighbourID })
+	if to < 0 {
+		return nil, domain.ErrNotFound
+	}
+
+	if move.AfterID != 0 {
+		to++
+	}
+
+	lower := 0
+	if to > 0 {
+		lower = others[to-1].Position
+	}
+
+	upper := lower + 2*_positionGap
+	if to < len(others) {
+		upper = others[to].Position
+	}
+
+	if upper-lower >= 2 {
+		return map[int]int{moved.ID: lower + (upper-lower)/2}, nil
+	}
+
+	ordered := slices.Insert(others, to, moved)
+
+	positions := make(map[int]int)
+	for i, todo := range ordered {
+		if position := (i + 1) * _positionGap; position != todo.Position || todo.ID == moved.ID {
+			positions[todo.ID] = position
+		}
+	}
+
+	return positions, nil
+}
@@ -0,0 +1,65 @@
+package todo
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestPlanMove_SuccessfulBetweenNeighbours(t *testing.T) {
+	// Given
+	todos := []todoPosition{{ID: 1, Position: 1024}, {ID: 2, Position: 2048}, {ID: 3, Position: 3072}}
+
+	// When
+	positions, err := planMove(todos, domain.TodoMove{ID: 1, AfterID: 2})
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, map[int]int{1: 2560}, positions)
+}
+
+func TestPlanMove_SuccessfulAfterLast(t *testing.T) {
+	// Given
+	todos := []todoPosition{{ID: 1, Position: 1024}, {ID: 2, Position: 2048}}
+
+	// When
+	positions, err := planMove(todos, domain.TodoMove{ID: 1, AfterID: 2})
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, map[int]int{1: 3072}, positions)
+}
+
+func TestPlanMove_SuccessfulRebalancesExhaustedGap(t *testing.T) {
+	// Given
+	todos := []todoPosition{{ID: 1, Position: 1024}, {ID: 2, Position: 1025}, {ID: 3, Position: 2048}}
+
+	// When
+	positions, err := planMove(todos, domain.TodoMove{ID: 3, BeforeID: 2})
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, map[int]int{2: 3072, 3: 2048}, positions)
+}
+
+func TestPlanMove_FailsDueToMissingNeighbour(t *testing.T) {
+	// Given
+	todos := []todoPosition{{ID: 1, Position: 1024}, {ID: 2, Position: 2048}}
+
+	// When
+	_, err := planMove(todos, domain.TodoMove{ID: 1, BeforeID: 3})
+
+	// Then
+	require.ErrorIs(t, err, domain.ErrNotFound)
+}
+
+func TestPlanMove_FailsDueToSameNeighbour(t *testing.T) {
+	// Given
+	todos := []todoPosition{{ID: 1, Position: 1024}}
+
+	// When
+	_, err := planMove(todos, domain.TodoMove{ID: 1, AfterID: 1})
+
+	// Then
+	require.ErrorIs(t, err, domain.ErrInvalidMove)
+}
@@ -0,0 +1,184 @@
+package todo
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/recurrence"
+)
+
+// seriesWrite is a write of a todo that may change its series. Repositories apply it in one
+// transaction, after checking the todo is still at the version it was planned from.
+type seriesWrite struct {
+	// todo is the new state of the todo.
+	todo domain.Todo
+
+	// next is the occurrence to create, if any.
+	next *domain.Todo
+
+	// following, if any, holds the title, description and recurrence of the pending occurrences of the
+	// series due after the todo. An empty recurrence keeps theirs.
+	following *domain.Todo
+}
+
+// nextOccurrence returns the occurrence after the todo, reporting false when the todo does not recur or
+// its series is over.
+func nextOccurrence(todo domain.Todo) (domain.Todo, bool, error) {
+	if !todo.Recurs() || todo.DueAt == nil {
+		return domain.Todo{}, false, nil
+	}
+
+	dueAt, ok, err := recurrence.Next(todo.Recurrence, *todo.DueAt)
+	if err != nil || !ok {
+		return domain.Todo{}, false, err
+	}
+
+	return domain.Todo{
+		Title:       todo.Title,
+		Description: todo.Description,
+		UserID:      todo.UserID,
+		Version:     1,
+		DueAt:       &dueAt,
+		Recurrence:  todo.Recurrence,
+		SeriesID:    todo.SeriesID,
+	}, true, nil
+}
+
+// completeWrite completes the todo, creating the next occurrence when it recurs. Completing a todo
+// again does not create another occurrence.
+func completeWrite(current domain.Todo) (seriesWrite, error) {
+	write := seriesWrite{todo: current}
+	write.todo.Completed = true
+
+	next, ok, err := nextOccurrence(current)
+	if err != nil {
+		return seriesWrite{}, err
+	}
+
+	if ok {
+		write.next = &next
+	}
+
+	return write, nil
+}
+
+// skipWrite moves the pending occurrence to the date of the next one.
+func skipWrite(current domain.Todo) (seriesWrite, error) {
+	if !current.Recurs() {
+		return seriesWrite{}, domain.ErrNotRecurring
+	}
+
+	next, ok, err := nextOccurrence(current)
+	if err != nil {
+		return seriesWrite{}, err
+	}
+
+	if !ok {
+		return seriesWrite{}, domain.ErrSeriesEnded
+	}
+
+	write := seriesWrite{todo: current}
+	write.todo.DueAt = next.DueAt
+
+	return write, nil
+}
+
+// endSeriesWrite makes the pending occurrence the last one of its series.
+func endSeriesWrite(current domain.Todo) (seriesWrite, error) {
+	if !current.Recurs() {
+		return seriesWrite{}, domain.ErrNotRecurring
+	}
+
+	write := seriesWrite{todo: current}
+	write.todo.Recurrence = ""
+
+	return write, nil
+}
+
+// updateWrite changes the todo to updated, which must keep its ID, user, version and series.
+//
+// Editing only this occurrence of a series detaches it, as an occurrence that does not recur, and the
+// series continues with the next occurrence, keeping the original title and description. Editing the
+// future occurrences changes the pending occurrence of the series too. Completing the pending occurrence
+// creates the next one, like completing it does, and a reopened occurrence no longer recurs, as its series
+// continued with the occurrence created when it was completed.
+func updateWrite(current domain.Todo, updated domain.Todo, scope domain.TodoScope) (seriesWrite, error) {
+	// Like the UPDATE statement, an update neither moves the todo nor gives it to another user.
+	updated.UserID = current.UserID
+	updated.Position = current.Position
+
+	inSeries := current.SeriesID != 0 && !current.Completed
+	recurrenceChanged := updated.Recurrence != current.Recurrence
+
+	switch {
+	case updated.Completed != current.Completed && current.Recurrence != "" && recurrenceChanged:
+		return seriesWrite{}, domain.ErrRecurrenceScope
+	case updated.Completed && current.Recurs():
+		pending := updated
+		pending.Completed = false
+
+		write, err := completeWrite(pending)
+		if err != nil {
+			return seriesWrite{}, err
+		}
+
+		return write, nil
+	case !updated.Completed && current.Completed && current.Recurrence != "":
+		updated.Recurrence = ""
+
+		return seriesWrite{todo: updated}, nil
+	case current.Recurs() && scope == domain.TodoScopeThis:
+		if recurrenceChanged {
+			return seriesWrite{}, domain.ErrRecurrenceScope
+		}
+
+		write := seriesWrite{todo: updated}
+		write.todo.Recurrence = ""
+
+		next, ok, err := nextOccurrence(current)
+		if err != nil {
+			return seriesWrite{}, err
+		}
+
+		if ok {
+			write.next = &next
+		}
+
+		return write, nil
+	case current.Recurs():
+		return seriesWrite{todo: updated}, nil
+	case inSeries && scope == domain.TodoScopeThis:
+		if recurrenceChanged {
+			return seriesWrite{}, domain.ErrRecurrenceScope
+		}
+
+		return seriesWrite{todo: updated}, nil
+	case inSeries:
+		// The occurrence was detached from the series, which recurs through a later occurrence.
+		write := seriesWrite{todo: updated, following: &updated}
+		write.todo.Recurrence = ""
+
+		return write, nil
+	default:
+		// A todo that starts recurring starts a series.
+		if updated.Recurrence != "" && updated.SeriesID == 0 {
+			updated.SeriesID = updated.ID
+		}
+
+		return seriesWrite{todo: updated}, nil
+	}
+}
+
+// batchUpdateWrite plans the update operation of a batch, which changes the todo like an update of only
+// this occurrence.
+func batchUpdateWrite(operation domain.TodoOperation) func(current domain.Todo) (seriesWrite, error) {
+	return func(current domain.Todo) (seriesWrite, error) {
+		updated := current
+		if operation.Title != "" {
+			updated.Title = operation.Title
+		}
+
+		operation.Description.Apply(&updated.Description)
+		operation.Completed.Apply(&updated.Completed)
+
+		return updateWrite(current, updated, domain.TodoScopeThis)
+	}
+}
@@ -2,59 +2,141 @@ package todo
 
 import (
 	"context"
+	stdsql "database/sql"
 	"errors"
+	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
 	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/sql"
 	"github.com/jmoiron/sqlx"
 )
 
 const (
-	_getAllTodosStmt = `SELECT todos.id, todos.title, todos.description, todos.completed, todos.user_id 
-						FROM todos
-						INNER JOIN users ON
-						users.id = todos.user_id
-						WHERE user_id = ?;`
-	_getTodoStmt = `SELECT id, title, description, completed, user_id 
-					FROM todos
-					WHERE id = ?;`
-	_saveTodoStmt = `INSERT INTO todos (title, description, user_id) 
-								VALUES (?, ?, ?);`
-	_updateTodoCompletedStmt = `UPDATE todos 
-								SET completed = true
-								WHERE id = ?;`
-	_deleteTodoStmt = `DELETE FROM todos WHERE id = ?;`
+	_todosTable   = "todos"
+	_saveTodoStmt = `INSERT INTO todos (title, description, user_id, due_at, recurrence, series_id, position,
+									completed, external_id)
+								VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);`
+
+	// _batchSavepoint isolates every operation of a best-effort batch, so a failing one is undone without
+	// aborting the transaction.
+	_batchSavepoint = "batch_operation"
 )
 
+// _todoColumns are the columns of a Todo.
+var _todoColumns = []string{
+	"id", "title", "description", "completed", "user_id", "version", "due_at", "recurrence", "series_id", "position",
+	"external_id",
+}
+
 type Repository interface {
 	// GetAll obtain all todos from the database of specific user.
 	GetAll(ctx context.Context, userID int) ([]domain.Todo, error)
 
+	// GetPage obtain at most limit todos of the user that follow after in the order of GetAll, starting
+	// from the first todo when after is zero.
+	GetPage(ctx context.Context, userID int, after domain.Todo, limit int) ([]domain.Todo, error)
+
 	// Get obtain one Todo by ID.
 	Get(ctx context.Context, id int) (domain.Todo, error)
 
 	// Save a new Todo into the database.
 	Save(ctx context.Context, todo domain.Todo) (int, error)
 
-	// Completed change the completed state to true.
-	Completed(ctx context.Context, id int) error
-
-	// Delete the Todo from the database.
-	Delete(ctx context.Context, id int) error
+	// SaveAll saves the todos of the user after its other todos, in order and inside one transaction,
+	// returning their IDs. Unlike Save, the completed state of the todos is kept. A non-empty external ID
+	// already used by a todo of the user results in domain.ErrDuplicateExternalID.
+	SaveAll(ctx context.Context, userID int, todos []domain.Todo) ([]int, error)
+
+	// ExternalIDs returns the ones of externalIDs that are the external ID of a todo of the user.
+	ExternalIDs(ctx context.Context, userID int, externalIDs []string) ([]string, error)
+
+	// Completed change the completed state to true, returning the completed Todo with the ID of the next
+	// occurrence it created, if any. A non-zero version must match the version of the Todo, otherwise
+	// domain.ErrVersionMismatch is returned.
+	Completed(ctx context.Context, id int, version int) (domain.Todo, error)
+
+	// Delete the Todo from the database. A non-zero version must match the version of the Todo,
+	// otherwise domain.ErrVersionMismatch is returned.
+	Delete(ctx context.Context, id int, version int) error
+
+	// Batch applies the operations on the todos of the user inside one transaction, returning the result
+	// of every operation. When atomic, a failing operation rolls back the whole batch and the others
+	// result in domain.ErrBatchAborted; otherwise, only the failing operations are not applied. The
+	// error is only returned when the batch could not run.
+	Batch(ctx context.Context, userID int, operations []domain.TodoOperation, atomic bool) (
+		[]domain.TodoOperationResult, error)
+
+	// Update writes the todo, changing the occurrences of its series selected by scope, and returns the
+	// written todo with the ID of the next occurrence it created, if any. A non-zero version of the todo
+	// must match, otherwise domain.ErrVersionMismatch is returned.
+	Update(ctx context.Context, todo domain.Todo, scope domain.TodoScope) (domain.Todo, error)
+
+	// Skip moves the pending occurrence of a series to the date of the next one, returning it. A non-zero
+	// version must match the version of the Todo, otherwise domain.ErrVersionMismatch is returned.
+	Skip(ctx context.Context, id int, version int) (domain.Todo, error)
+
+	// EndSeries makes the pending occurrence of a series the last one, returning it. A non-zero version
+	// must match the version of the Todo, otherwise domain.ErrVersionMismatch is returned.
+	EndSeries(ctx context.Context, id int, version int) (domain.Todo, error)
+
+	// Move places a todo of the user next to another one, returning it. Moves of the todos of a user are
+	// serialized, so concurrent moves never lose or duplicate a todo. domain.ErrNotFound is returned when
+	// the todos are not both of the user.
+	Move(ctx context.Context, move domain.TodoMove) (domain.Todo, error)
 }
 
 type repository struct {
-	conn *sqlx.DB
+	conn    *sqlx.DB
+	dialect sql.Dialect
 }
 
 func NewRepository(conn *sqlx.DB) Repository {
 	return &repository{
-		conn: conn,
+		conn:    conn,
+		dialect: sql.NewDialect(conn.DriverName()),
 	}
 }
 
 func (r repository) GetAll(ctx context.Context, userID int) ([]domain.Todo, error) {
 	todos := make([]domain.Todo, 0)
 
-	if err := r.conn.SelectContext(ctx, &todos, _getAllTodosStmt, userID); err != nil {
+	query, args, err := sql.Select(qualified(_todosTable, _todoColumns)...).
+		From(_todosTable).
+		Join("INNER JOIN users ON users.id = todos.user_id").
+		Where(sql.Eq("todos.user_id", userID)).
+		OrderBy(sql.Asc("todos.position"), sql.Asc("todos.id")).
+		Build(r.dialect)
+	if err != nil {
+		return make([]domain.Todo, 0), err
+	}
+
+	if err := sql.ConnFrom(ctx, r.conn).SelectContext(ctx, &todos, query, args...); err != nil {
+		return make([]domain.Todo, 0), err
+	}
+
+	return todos, nil
+}
+
+func (r repository) GetPage(ctx context.Context, userID int, after domain.Todo, limit int) ([]domain.Todo, error) {
+	todos := make([]domain.Todo, 0)
+
+	where := []sql.Cond{sql.Eq("user_id", userID)}
+	if after.ID != 0 {
+		where = append(where, sql.After([]string{"position", "id"}, after.Position, after.ID))
+	}
+
+	query, args, err := sql.Select(_todoColumns...).
+		From(_todosTable).
+		Where(where...).
+		OrderBy(sql.Asc("position"), sql.Asc("id")).
+		Limit(limit).
+		Build(r.dialect)
+	if err != nil {
+		return make([]domain.Todo, 0), err
+	}
+
+	if err := sql.ConnFrom(ctx, r.conn).SelectContext(ctx, &todos, query, args...); err != nil {
 		return make([]domain.Todo, 0), err
 	}
 
@@ -64,93 +146,486 @@ func (r repository) GetAll(ctx context.Context, userID int) ([]domain.Todo, erro
 func (r repository) Get(ctx context.Context, id int) (domain.Todo, error) {
 	var todo domain.Todo
 
-	if err := r.conn.GetContext(ctx, &todo, _getTodoStmt, id); err != nil {
+	query, args, err := selectTodo(id).Build(r.dialect)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	if err := sql.ConnFrom(ctx, r.conn).GetContext(ctx, &todo, query, args...); err != nil {
 		return domain.Todo{}, err
 	}
 
 	return todo, nil
 }
 
+// selectTodo selects the todo of the ID.
+func selectTodo(id int) *sql.SelectQuery {
+	return sql.Select(_todoColumns...).From(_todosTable).Where(sql.Eq("id", id))
+}
+
+// matchesVersion is true for the todo of the version, or for any when version is zero.
+func matchesVersion(version int) sql.Cond {
+	return sql.Expr("version = ? OR ? = 0", version, version)
+}
+
+// qualified returns the columns qualified by the table, for queries joining other tables.
+func qualified(table string, columns []string) []string {
+	qualifiedColumns := make([]string, 0, len(columns))
+	for _, column := range columns {
+		qualifiedColumns = append(qualifiedColumns, table+"."+column)
+	}
+
+	return qualifiedColumns
+}
+
 func (r repository) Save(ctx context.Context, todo domain.Todo) (int, error) {
-	tx, err := r.conn.Beginx()
+	todo.Completed = false // New todos are pending, only imported ones keep their state.
+
+	ids, err := r.SaveAll(ctx, todo.UserID, []domain.Todo{todo})
 	if err != nil {
 		return 0, err
 	}
 
-	stmt, err := tx.PreparexContext(ctx, _saveTodoStmt)
+	return ids[0], nil
+}
+
+func (r repository) SaveAll(ctx context.Context, userID int, todos []domain.Todo) ([]int, error) {
+	tx, err := sql.Begin(ctx, r.conn)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	defer func() {
-		err = stmt.Close()
-	}()
-
-	res, err := stmt.ExecContext(ctx, todo.Title, todo.Description, todo.UserID)
+	ids, err := r.insertAll(ctx, tx, userID, todos)
 	if err != nil {
 		if rollbackErr := tx.Rollback(); rollbackErr != nil {
-			return 0, rollbackErr
+			return nil, rollbackErr
 		}
 
-		return 0, err
+		return nil, err
 	}
 
 	if err := tx.Commit(); err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	id, err := res.LastInsertId()
+	return ids, nil
+}
+
+// insert saves the todo after the other todos of its user, see insertAll.
+func (r repository) insert(ctx context.Context, tx *sql.Tx, todo domain.Todo) (int, error) {
+	ids, err := r.insertAll(ctx, tx, todo.UserID, []domain.Todo{todo})
 	if err != nil {
 		return 0, err
 	}
 
-	return int(id), err
+	return ids[0], nil
 }
 
-func (r repository) Completed(ctx context.Context, id int) error {
-	tx, err := r.conn.Beginx()
+// insertAll saves the todos of the user after its other todos, in order, preparing the statement and
+// reading the last position once. The series of a todo starts when it recurs and is not an occurrence of
+// one.
+func (r repository) insertAll(ctx context.Context, tx *sql.Tx, userID int, todos []domain.Todo) ([]int, error) {
+	stmt, err := tx.PreparexContext(ctx, r.dialect.Rebind(r.dialect.InsertQuery(_saveTodoStmt)))
+	if err != nil {
+		return nil, err
+	}
+
+	defer stmt.Close()
+
+	query, args, err := sql.Select("COALESCE(MAX(position), 0)").
+		From(_todosTable).
+		Where(sql.Eq("user_id", userID)).
+		Build(r.dialect)
 	if err != nil {
+		return nil, err
+	}
+
+	var lastPosition int
+	if err := tx.GetContext(ctx, &lastPosition, query, args...); err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0, len(todos))
+
+	for _, todo := range todos {
+		todo.UserID = userID
+		todo.Position = nextPosition(lastPosition)
+		lastPosition = todo.Position
+
+		id, err := r.dialect.InsertedID(ctx, stmt, todo.Title, todo.Description, todo.UserID, todo.DueAt,
+			todo.Recurrence, todo.SeriesID, todo.Position, todo.Completed, todo.ExternalID)
+		if err != nil {
+			// Only the external ID is unique, and only when it is not empty.
+			if todo.ExternalID != "" && r.dialect.IsUniqueViolation(err) {
+				return nil, domain.ErrDuplicateExternalID
+			}
+
+			return nil, err
+		}
+
+		if todo.Recurrence != "" && todo.SeriesID == 0 {
+			query, args, err := sql.Update(_todosTable).
+				SetExpr("series_id", "id").
+				Where(sql.Eq("id", id)).
+				Build(r.dialect)
+			if err != nil {
+				return nil, err
+			}
+
+			if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+				return nil, err
+			}
+
+			todo.SeriesID = id
+		}
+
+		todo.ID = id
+		todo.Version = 1
+
+		if err := recordTodo(ctx, tx, domain.AuditTodoCreated, id, nil, &todo); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+func (r repository) ExternalIDs(ctx context.Context, userID int, externalIDs []string) ([]string, error) {
+	existing := make([]string, 0)
+
+	if len(externalIDs) == 0 {
+		return existing, nil
+	}
+
+	query, args, err := sql.Select("external_id").
+		From(_todosTable).
+		Where(sql.Eq("user_id", userID), sql.In("external_id", externalIDs)).
+		Build(r.dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := sql.ConnFrom(ctx, r.conn).SelectContext(ctx, &existing, query, args...); err != nil {
+		return nil, err
+	}
+
+	return existing, nil
+}
+
+// recordTodo appends the audit event and the outbox message of the action on the todo inside tx. before
+// is nil for a created todo, and after for a deleted one.
+func recordTodo(
+	ctx context.Context,
+	tx *sql.Tx,
+	action string,
+	id int,
+	before *domain.Todo,
+	after *domain.Todo) error {
+	event, err := audit.NewEvent(ctx, action, domain.AuditTodo, id, before, after)
+	if err != nil {
+		return err
+	}
+
+	if err := audit.Insert(ctx, tx, event); err != nil {
 		return err
 	}
 
-	stmt, err := tx.PreparexContext(ctx, _updateTodoCompletedStmt)
+	message, err := newMessage(action, id, before, after)
 	if err != nil {
 		return err
 	}
 
-	defer func() {
-		err = stmt.Close()
-	}()
+	return outbox.Insert(ctx, tx, message)
+}
+
+// newMessage returns the outbox message of the action on the todo, whose payload is the todo after the
+// action, or before it when it was deleted.
+func newMessage(action string, id int, before *domain.Todo, after *domain.Todo) (domain.OutboxMessage, error) {
+	subject := after
+	if subject == nil {
+		subject = before
+	}
+
+	return outbox.NewMessage(domain.OutboxTodo, id, action, subject)
+}
+
+func (r repository) Completed(ctx context.Context, id int, version int) (domain.Todo, error) {
+	completedTodo, err := r.writeSeries(ctx, id, version, domain.AuditTodoCompleted, completeWrite)
+
+	// Like before versions, completing a missing todo is not an error.
+	if errors.Is(err, stdsql.ErrNoRows) {
+		return domain.Todo{}, nil
+	}
+
+	return completedTodo, err
+}
+
+func (r repository) Update(ctx context.Context, todo domain.Todo, scope domain.TodoScope) (domain.Todo, error) {
+	plan := func(current domain.Todo) (seriesWrite, error) {
+		return updateWrite(current, todo, scope)
+	}
+
+	return r.writeSeries(ctx, todo.ID, todo.Version, domain.AuditTodoUpdated, plan)
+}
+
+func (r repository) Skip(ctx context.Context, id int, version int) (domain.Todo, error) {
+	return r.writeSeries(ctx, id, version, domain.AuditTodoSkipped, skipWrite)
+}
+
+func (r repository) EndSeries(ctx context.Context, id int, version int) (domain.Todo, error) {
+	return r.writeSeries(ctx, id, version, domain.AuditTodoSeriesEnded, endSeriesWrite)
+}
+
+// writeSeries applies the write planned from the current todo in one transaction, recording it as the
+// action.
+func (r repository) writeSeries(
+	ctx context.Context,
+	id int,
+	version int,
+	action string,
+	plan func(current domain.Todo) (seriesWrite, error)) (domain.Todo, error) {
+	tx, err := sql.Begin(ctx, r.conn)
+	if err != nil {
+		return domain.Todo{}, err
+	}
 
-	res, err := stmt.ExecContext(ctx, id)
+	written, err := r.applySeriesWrite(ctx, tx, id, version, action, plan)
 	if err != nil {
 		if rollbackErr := tx.Rollback(); rollbackErr != nil {
-			return rollbackErr
+			return domain.Todo{}, rollbackErr
 		}
 
-		return err
+		return domain.Todo{}, err
 	}
 
 	if err := tx.Commit(); err != nil {
+		return domain.Todo{}, err
+	}
+
+	return written, nil
+}
+
+// applySeriesWrite locks the todo to plan the write from it, so concurrent writes of the series wait,
+// and returns the written todo.
+func (r repository) applySeriesWrite(
+	ctx context.Context,
+	tx *sql.Tx,
+	id int,
+	version int,
+	action string,
+	plan func(current domain.Todo) (seriesWrite, error)) (domain.Todo, error) {
+	query, args, err := selectTodo(id).ForUpdate().Build(r.dialect)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	var current domain.Todo
+	if err := tx.GetContext(ctx, &current, query, args...); err != nil {
+		return domain.Todo{}, err
+	}
+
+	if version != 0 && version != current.Version {
+		return domain.Todo{}, domain.ErrVersionMismatch
+	}
+
+	write, err := plan(current)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	// Every column is written, so a todo may be completed again or have its description cleared.
+	written := write.todo
+	query, args, err = sql.Update(_todosTable).
+		Set("title", written.Title).
+		Set("description", written.Description).
+		Set("completed", written.Completed).
+		Set("due_at", written.DueAt).
+		Set("recurrence", written.Recurrence).
+		Set("series_id", written.SeriesID).
+		SetExpr("version", "version + 1").
+		Where(sql.Eq("id", id), sql.Eq("version", current.Version)).
+		Build(r.dialect)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		return domain.Todo{}, err
+	}
+
+	if following := write.following; following != nil {
+		// A recurrence is only changed on the occurrences that recur, and only to another recurrence.
+		query, args, err := sql.Update(_todosTable).
+			Set("title", following.Title).
+			Set("description", following.Description).
+			SetExpr("recurrence", "CASE WHEN recurrence = '' OR ? = '' THEN recurrence ELSE ? END",
+				following.Recurrence, following.Recurrence).
+			SetExpr("version", "version + 1").
+			Where(
+				sql.Eq("series_id", current.SeriesID),
+				sql.NotEq("id", id),
+				sql.Expr("completed = false"),
+				sql.Gt("due_at", current.DueAt),
+			).
+			Build(r.dialect)
+		if err != nil {
+			return domain.Todo{}, err
+		}
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return domain.Todo{}, err
+		}
+	}
+
+	if write.next != nil {
+		if written.NextID, err = r.insert(ctx, tx, *write.next); err != nil {
+			return domain.Todo{}, err
+		}
+	}
+
+	written.Version = current.Version + 1
+
+	if err := recordTodo(ctx, tx, action, id, &current, &written); err != nil {
+		return domain.Todo{}, err
+	}
+
+	return written, nil
+}
+
+func (r repository) Move(ctx context.Context, move domain.TodoMove) (domain.Todo, error) {
+	tx, err := sql.Begin(ctx, r.conn)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	movedTodo, err := r.move(ctx, tx, move)
+	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return domain.Todo{}, rollbackErr
+		}
+
+		return domain.Todo{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return domain.Todo{}, err
+	}
+
+	return movedTodo, nil
+}
+
+// move locks every todo of the user, as a rebalance may write all of them, and returns the moved todo.
+func (r repository) move(ctx context.Context, tx *sql.Tx, move domain.TodoMove) (domain.Todo, error) {
+	query, args, err := sql.Select("id", "position", "version").
+		From(_todosTable).
+		Where(sql.Eq("user_id", move.UserID)).
+		OrderBy(sql.Asc("position"), sql.Asc("id")).
+		ForUpdate().
+		Build(r.dialect)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	todos := make([]todoPosition, 0)
+	if err := tx.SelectContext(ctx, &todos, query, args...); err != nil {
+		return domain.Todo{}, err
+	}
+
+	positions, err := planMove(todos, move)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	for _, todo := range todos {
+		position, ok := positions[todo.ID]
+		if !ok {
+			continue
+		}
+
+		// Only the moved todo changes for the user; the others keep their order.
+		update := sql.Update(_todosTable).Set("position", position)
+		if todo.ID == move.ID {
+			update.SetExpr("version", "version + 1")
+		}
+
+		query, args, err := update.Where(sql.Eq("id", todo.ID)).Build(r.dialect)
+		if err != nil {
+			return domain.Todo{}, err
+		}
+
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return domain.Todo{}, err
+		}
+	}
+
+	query, args, err = selectTodo(move.ID).Build(r.dialect)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	var movedTodo domain.Todo
+	if err := tx.GetContext(ctx, &movedTodo, query, args...); err != nil {
+		return domain.Todo{}, err
+	}
+
+	// Only the position of the moved todo is recorded, the rebalanced ones keep their order.
+	before := movedTodo
+	for _, todo := range todos {
+		if todo.ID == move.ID {
+			before.Position = todo.Position
+		}
+	}
+
+	if err := recordTodo(ctx, tx, domain.AuditTodoMoved, move.ID, &before, &movedTodo); err != nil {
+		return domain.Todo{}, err
+	}
+
+	return movedTodo, nil
+}
+
+func (r repository) Delete(ctx context.Context, id int, version int) error {
+	tx, err := sql.Begin(ctx, r.conn)
+	if err != nil {
 		return err
 	}
 
-	_, err = res.RowsAffected()
+	// The deleted todo is recorded as it was; a missing one is not deleted, so it is not recorded.
+	var current domain.Todo
+
+	query, args, err := selectTodo(id).ForUpdate().Build(r.dialect)
 	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return rollbackErr
+		}
+
 		return err
 	}
 
-	return err
-}
+	if err := tx.GetContext(ctx, &current, query, args...); err != nil && !errors.Is(err, stdsql.ErrNoRows) {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return rollbackErr
+		}
 
-func (r repository) Delete(ctx context.Context, id int) error {
-	tx, err := r.conn.Beginx()
+		return err
+	}
+
+	query, args, err = sql.Delete(_todosTable).Where(sql.Eq("id", id), matchesVersion(version)).Build(r.dialect)
 	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return rollbackErr
+		}
+
 		return err
 	}
 
-	stmt, err := tx.PreparexContext(ctx, _deleteTodoStmt)
+	stmt, err := tx.PreparexContext(ctx, query)
 	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return rollbackErr
+		}
+
 		return err
 	}
 
@@ -158,7 +633,7 @@ func (r repository) Delete(ctx context.Context, id int) error {
 		err = stmt.Close()
 	}()
 
-	res, err := stmt.ExecContext(ctx, id)
+	res, err := stmt.ExecContext(ctx, args...)
 	if err != nil {
 		if rollbackErr := tx.Rollback(); rollbackErr != nil {
 			return rollbackErr
@@ -167,6 +642,24 @@ func (r repository) Delete(ctx context.Context, id int) error {
 		return err
 	}
 
+	if err := r.checkVersion(ctx, tx, res, id, version); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return rollbackErr
+		}
+
+		return err
+	}
+
+	if current.ID != 0 {
+		if err := recordTodo(ctx, tx, domain.AuditTodoDeleted, id, &current, nil); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return rollbackErr
+			}
+
+			return err
+		}
+	}
+
 	if err := tx.Commit(); err != nil {
 		return err
 	}
@@ -182,3 +675,264 @@ func (r repository) Delete(ctx context.Context, id int) error {
 
 	return nil
 }
+
+// checkVersion returns domain.ErrVersionMismatch when a versioned write did not affect the todo because
+// it exists with another version.
+func (r repository) checkVersion(ctx context.Context, tx *sql.Tx, res stdsql.Result, id int, version int) error {
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affect > 0 || version == 0 {
+		return nil
+	}
+
+	query, args, err := sql.Select("COUNT(*)").From(_todosTable).Where(sql.Eq("id", id)).Build(r.dialect)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	if err := tx.GetContext(ctx, &count, query, args...); err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return domain.ErrVersionMismatch
+	}
+
+	return nil
+}
+
+func (r repository) Batch(
+	ctx context.Context,
+	userID int,
+	operations []domain.TodoOperation,
+	atomic bool) ([]domain.TodoOperationResult, error) {
+	tx, err := sql.Begin(ctx, r.conn)
+	if err != nil {
+		return nil, err
+	}
+
+	owners, err := r.getOwners(ctx, tx, operations)
+	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return nil, rollbackErr
+		}
+
+		return nil, err
+	}
+
+	results := make([]domain.TodoOperationResult, len(operations))
+
+	apply := r.applyIsolatedOperation
+	if atomic {
+		apply = r.applyOperation
+	}
+
+	for i, operation := range operations {
+		written, err := apply(ctx, tx, userID, owners, operation)
+		results[i] = domain.TodoOperationResult{
+			Index:   i,
+			Action:  operation.Action,
+			ID:      written.ID,
+			Version: written.Version,
+			NextID:  written.NextID,
+			Err:     err,
+		}
+
+		if err != nil && atomic {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return nil, rollbackErr
+			}
+
+			return abortBatch(results, i, operations), nil
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// getOwners locks the todos written by the operations, obtaining them in one query.
+func (r repository) getOwners(
+	ctx context.Context,
+	tx *sql.Tx,
+	operations []domain.TodoOperation) (map[int]domain.Todo, error) {
+	owners := make(map[int]domain.Todo)
+
+	ids := make([]int, 0, len(operations))
+	for _, operation := range operations {
+		if operation.Action != domain.TodoCreate {
+			ids = append(ids, operation.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return owners, nil
+	}
+
+	query, args, err := sql.Select(_todoColumns...).
+		From(_todosTable).
+		Where(sql.In("id", ids)).
+		ForUpdate().
+		Build(r.dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make([]domain.Todo, 0, len(ids))
+	if err := tx.SelectContext(ctx, &selected, query, args...); err != nil {
+		return nil, err
+	}
+
+	for _, owner := range selected {
+		owners[owner.ID] = owner
+	}
+
+	return owners, nil
+}
+
+// applyIsolatedOperation applies the operation inside a savepoint, undoing it when it fails.
+func (r repository) applyIsolatedOperation(
+	ctx context.Context,
+	tx *sql.Tx,
+	userID int,
+	owners map[int]domain.Todo,
+	operation domain.TodoOperation) (domain.Todo, error) {
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT "+_batchSavepoint); err != nil {
+		return domain.Todo{ID: operation.ID}, err
+	}
+
+	written, err := r.applyOperation(ctx, tx, userID, owners, operation)
+	if err != nil {
+		if _, rollbackErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+_batchSavepoint); rollbackErr != nil {
+			return domain.Todo{ID: operation.ID}, rollbackErr
+		}
+
+		return domain.Todo{ID: operation.ID}, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT "+_batchSavepoint); err != nil {
+		return domain.Todo{ID: operation.ID}, err
+	}
+
+	return written, nil
+}
+
+// applyOperation returns the written todo, with its ID and new version, keeping owners up to date. Only
+// its ID is returned when the operation fails, and no version when the todo was deleted.
+func (r repository) applyOperation(
+	ctx context.Context,
+	tx *sql.Tx,
+	userID int,
+	owners map[int]domain.Todo,
+	operation domain.TodoOperation) (domain.Todo, error) {
+	if operation.Action == domain.TodoCreate {
+		description, _ := operation.Description.Get()
+
+		id, err := r.insert(ctx, tx, domain.Todo{Title: operation.Title, Description: description, UserID: userID})
+		if err != nil {
+			return domain.Todo{}, err
+		}
+
+		owners[id] = domain.Todo{
+			ID:          id,
+			Title:       operation.Title,
+			Description: description,
+			UserID:      userID,
+			Version:     1,
+		}
+
+		return domain.Todo{ID: id, Version: 1}, nil
+	}
+
+	owner, ok := owners[operation.ID]
+	if !ok {
+		return domain.Todo{ID: operation.ID}, domain.ErrNotFound
+	}
+
+	if owner.UserID != userID {
+		return domain.Todo{ID: operation.ID}, domain.ErrNotOwned
+	}
+
+	if operation.Version != 0 && operation.Version != owner.Version {
+		return domain.Todo{ID: operation.ID}, domain.ErrVersionMismatch
+	}
+
+	if operation.Action == domain.TodoComplete || operation.Action == domain.TodoUpdate {
+		action, plan := domain.AuditTodoCompleted, completeWrite
+		if operation.Action == domain.TodoUpdate {
+			action, plan = domain.AuditTodoUpdated, batchUpdateWrite(operation)
+		}
+
+		written, err := r.applySeriesWrite(ctx, tx, operation.ID, owner.Version, action, plan)
+		if err != nil {
+			return domain.Todo{ID: operation.ID}, err
+		}
+
+		owners[operation.ID] = written
+
+		return written, nil
+	}
+
+	if operation.Action != domain.TodoDelete {
+		return domain.Todo{ID: operation.ID}, fmt.Errorf("unknown todo action: %q", operation.Action)
+	}
+
+	query, args, err := sql.Delete(_todosTable).
+		Where(sql.Eq("id", operation.ID), matchesVersion(owner.Version)).
+		Build(r.dialect)
+	if err != nil {
+		return domain.Todo{ID: operation.ID}, err
+	}
+
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return domain.Todo{ID: operation.ID}, err
+	}
+
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return domain.Todo{ID: operation.ID}, err
+	}
+
+	// The selected rows are locked, so they only change through the batch.
+	if affect < 1 {
+		return domain.Todo{ID: operation.ID}, domain.ErrVersionMismatch
+	}
+
+	if err := recordTodo(ctx, tx, domain.AuditTodoDeleted, operation.ID, &owner, nil); err != nil {
+		return domain.Todo{ID: operation.ID}, err
+	}
+
+	delete(owners, operation.ID)
+
+	return domain.Todo{ID: operation.ID}, nil
+}
+
+// abortBatch marks every operation but the failed one as not applied, forgetting the IDs and versions
+// of the rolled back writes.
+func abortBatch(
+	results []domain.TodoOperationResult,
+	failed int,
+	operations []domain.TodoOperation) []domain.TodoOperationResult {
+	for i, operation := range operations {
+		if i == failed {
+			continue
+		}
+
+		results[i] = domain.TodoOperationResult{
+			Index:  i,
+			Action: operation.Action,
+			ID:     operation.ID,
+			Err:    domain.ErrBatchAborted,
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,200 @@
+package todo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/mysql/mysqltest"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/postgres/postgrestest"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/sql"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/sqlite/sqlitetest"
+	"github.com/ferch5003/go-fiber-tutorial/internal/todo"
+	"github.com/ferch5003/go-fiber-tutorial/internal/todo/todotest"
+	"github.com/ferch5003/go-fiber-tutorial/internal/user"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRepository_Conformance(t *testing.T) {
+	backends := []struct {
+		name     string
+		connect  func(t *testing.T) *sqlx.DB
+		truncate func(t *testing.T, conn *sqlx.DB)
+	}{
+		{name: "MySQL", connect: mysqltest.NewConnection, truncate: mysqltest.Truncate},
+		{name: "PostgreSQL", connect: postgrestest.NewConnection, truncate: postgrestest.Truncate},
+		{name: "SQLite", connect: sqlitetest.NewConnection, truncate: sqlitetest.Truncate},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			conn := backend.connect(t)
+
+			t.Run("Repository", func(t *testing.T) {
+				todotest.RunRepositoryTests(t, func(t *testing.T) todo.Repository {
+					backend.truncate(t, conn)
+					saveUsers(t, conn)
+
+					return todo.NewRepository(conn)
+				})
+			})
+
+			t.Run("Searcher", func(t *testing.T) {
+				todotest.RunSearcherTests(t, func(t *testing.T) (todo.Repository, todo.Searcher) {
+					backend.truncate(t, conn)
+					saveUsers(t, conn)
+
+					repository := todo.NewRepository(conn)
+
+					return repository, todo.NewSearcher(conn, repository)
+				})
+			})
+
+			t.Run("Audit", func(t *testing.T) {
+				todotest.RunAuditTests(t, func(t *testing.T) (todo.Repository, audit.Repository) {
+					backend.truncate(t, conn)
+					saveUsers(t, conn)
+
+					return todo.NewRepository(conn), audit.NewRepository(conn)
+				})
+			})
+
+			t.Run("Outbox", func(t *testing.T) {
+				todotest.RunOutboxTests(t, func(t *testing.T) (todo.Repository, outbox.Repository) {
+					backend.truncate(t, conn)
+					saveUsers(t, conn)
+
+					return todo.NewRepository(conn), outbox.NewRepository(conn)
+				})
+			})
+
+			t.Run("Transaction", func(t *testing.T) {
+				runTransactionTests(t, func(t *testing.T) *sqlx.DB {
+					backend.truncate(t, conn)
+
+					return conn
+				})
+			})
+		})
+	}
+}
+
+// runTransactionTests checks that the writes of the user and todo repositories inside WithinTx are one
+// unit, as they are when a user is created with their first todos.
+func runTransactionTests(t *testing.T, newConn func(t *testing.T) *sqlx.DB) {
+	newUser := domain.User{FirstName: "John", LastName: "Doe", Email: "john@doe.com", Password: "password"}
+
+	t.Run("CommitsEveryWrite", func(t *testing.T) {
+		// Given
+		conn := newConn(t)
+		ctx := context.Background()
+
+		users := user.NewRepository(conn)
+		todos := todo.NewRepository(conn)
+
+		var userID int
+
+		// When
+		err := sql.NewTransactor(conn).WithinTx(ctx, func(ctx context.Context) error {
+			var err error
+			if userID, err = users.Save(ctx, newUser); err != nil {
+				return err
+			}
+
+			// Reads inside the transaction see its writes.
+			if _, err := users.Get(ctx, userID); err != nil {
+				return err
+			}
+
+			_, err = todos.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: userID})
+
+			return err
+		})
+
+		// Then
+		require.NoError(t, err)
+
+		savedTodos, err := todos.GetAll(ctx, userID)
+		require.NoError(t, err)
+		require.Len(t, savedTodos, 1)
+	})
+
+	t.Run("RollsBackEveryWrite", func(t *testing.T) {
+		// Given
+		conn := newConn(t)
+		ctx := context.Background()
+
+		users := user.NewRepository(conn)
+		todos := todo.NewRepository(conn)
+
+		expectedError := errors.New("seeding failed")
+
+		// When
+		err := sql.NewTransactor(conn).WithinTx(ctx, func(ctx context.Context) error {
+			userID, err := users.Save(ctx, newUser)
+			if err != nil {
+				return err
+			}
+
+			if _, err := todos.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: userID}); err != nil {
+				return err
+			}
+
+			return expectedError
+		})
+
+		// Then
+		require.ErrorIs(t, err, expectedError)
+
+		savedUsers, err := users.GetAll(ctx)
+		require.NoError(t, err)
+		require.Empty(t, savedUsers)
+
+		events, err := audit.NewRepository(conn).List(ctx, domain.AuditFilter{})
+		require.NoError(t, err)
+		require.Empty(t, events)
+	})
+
+	t.Run("RollsBackAfterAnIgnoredFailingWrite", func(t *testing.T) {
+		// Given
+		conn := newConn(t)
+		ctx := context.Background()
+
+		users := user.NewRepository(conn)
+
+		// When
+		err := sql.NewTransactor(conn).WithinTx(ctx, func(ctx context.Context) error {
+			userID, err := users.Save(ctx, newUser)
+			if err != nil {
+				return err
+			}
+
+			// The update of a stale version fails and is ignored, but the transaction can not commit.
+			_ = users.Update(ctx, domain.User{ID: userID, FirstName: "Jane", Version: 5})
+
+			return nil
+		})
+
+		// Then
+		require.ErrorIs(t, err, sql.ErrRollbackOnly)
+
+		savedUsers, err := users.GetAll(ctx)
+		require.NoError(t, err)
+		require.Empty(t, savedUsers)
+	})
+}
+
+// saveUsers saves the users that todos of the conformance suites belong to.
+func saveUsers(t *testing.T, conn *sqlx.DB) {
+	t.Helper()
+
+	for _, userID := range []int{todotest.UserID, todotest.OtherUserID} {
+		_, err := conn.Exec(conn.Rebind(`INSERT INTO users (id, first_name, last_name, email, password)
+							VALUES (?, 'John', 'Doe', ?, 'password');`), userID, fmt.Sprintf("user%d@doe.com", userID))
+		require.NoError(t, err)
+	}
+}
@@ -6,12 +6,27 @@ import (
 	"fmt"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/data"
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/require"
 	"regexp"
 	"testing"
+	"time"
 )
 
+// todoRows are the rows of a query selecting whole todos.
+func todoRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{
+		"id", "title", "description", "completed", "user_id", "version", "due_at", "recurrence", "series_id",
+		"position",
+	})
+}
+
+// lastPositionRows is the result of the query of the last position of the todos of a user.
+func lastPositionRows(lastPosition int) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"position"}).AddRow(lastPosition)
+}
+
 func TestRepositoryGetAll_Successful(t *testing.T) {
 	// Given
 	db, mock, err := sqlmock.New()
@@ -93,6 +108,44 @@ func TestRepositoryGetAll_FailsDueToInvalidSelect(t *testing.T) {
 	require.ErrorContains(t, err, "with expected regexp")
 }
 
+func TestRepositoryGetPage_SuccessfulAfterTodo(t *testing.T) {
+	// Given
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+
+	ctx := context.Background()
+
+	after := domain.Todo{ID: 1, Position: _positionGap}
+	expectedTodos := []domain.Todo{
+		{
+			ID:       2,
+			Title:    "Lorem Ipsum",
+			Position: 2 * _positionGap,
+		},
+	}
+
+	rows := sqlmock.NewRows([]string{"id", "title", "position"}).
+		AddRow(expectedTodos[0].ID, expectedTodos[0].Title, expectedTodos[0].Position)
+	mock.ExpectQuery(regexp.QuoteMeta("WHERE (user_id = ? AND (position > ? OR (position = ? AND id > ?))) "+
+		"ORDER BY position, id LIMIT 2;")).
+		WithArgs(1, after.Position, after.Position, after.ID).
+		WillReturnRows(rows)
+
+	repository := NewRepository(dbx)
+
+	// When
+	todos, err := repository.GetPage(ctx, 1, after, 2)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, expectedTodos, todos)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestRepositoryGet_Successful(t *testing.T) {
 	// Given
 	db, mock, err := sqlmock.New()
@@ -179,7 +232,12 @@ func TestRepositorySave_Successful(t *testing.T) {
 	}
 	mock.ExpectBegin()
 	mock.ExpectPrepare(`INSERT INTO todos`)
-	mock.ExpectExec(`INSERT INTO todos`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(position\), 0\)`).WillReturnRows(lastPositionRows(0))
+	mock.ExpectExec(`INSERT INTO todos`).
+		WithArgs(todo.Title, todo.Description, todo.UserID, nil, "", 0, _positionGap, false, "").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO audit_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
 
 	repository := NewRepository(dbx)
@@ -194,6 +252,81 @@ func TestRepositorySave_Successful(t *testing.T) {
 	require.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestRepositorySave_FailsDueToFailingAuditInsert(t *testing.T) {
+	// Given
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+
+	ctx := context.Background()
+
+	todo := domain.Todo{
+		Title:       "Lorem",
+		Description: "Ipsum",
+		UserID:      1,
+	}
+
+	expectedError := errors.New("Error Code: 1146. Table 'audit_events' doesn't exist")
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`INSERT INTO todos`)
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(position\), 0\)`).WillReturnRows(lastPositionRows(0))
+	mock.ExpectExec(`INSERT INTO todos`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO audit_events`).WillReturnError(expectedError)
+	mock.ExpectRollback()
+
+	repository := NewRepository(dbx)
+
+	// When
+	todoID, err := repository.Save(ctx, todo)
+
+	// Then
+	require.ErrorIs(t, err, expectedError)
+	require.Zero(t, todoID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepositorySave_FailsDueToFailingOutboxInsert(t *testing.T) {
+	// Given
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+
+	ctx := context.Background()
+
+	todo := domain.Todo{
+		Title:       "Lorem",
+		Description: "Ipsum",
+		UserID:      1,
+	}
+
+	expectedError := errors.New("Error Code: 1146. Table 'outbox' doesn't exist")
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare(`INSERT INTO todos`)
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(position\), 0\)`).WillReturnRows(lastPositionRows(0))
+	mock.ExpectExec(`INSERT INTO todos`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO audit_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO outbox`).WillReturnError(expectedError)
+	mock.ExpectRollback()
+
+	repository := NewRepository(dbx)
+
+	// When
+	todoID, err := repository.Save(ctx, todo)
+
+	// Then
+	require.ErrorIs(t, err, expectedError)
+	require.Zero(t, todoID)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestRepositorySave_FailsDueToInvalidBeginTransaction(t *testing.T) {
 	// Given
 	db, mock, err := sqlmock.New()
@@ -243,14 +376,12 @@ func TestRepositorySave_FailsDueToInvalidPreparation(t *testing.T) {
 		Description: "Ipsum",
 		UserID:      1,
 	}
-	wrongQuery := regexp.QuoteMeta(`INSERT INTO todos (first_name, last_name, email, password)) VALUES ();`)
-	expectedError := errors.New(`Prepare: could not match actual sql: \"INSERT INTO todos (first_name, 
-										last_name, email, password) VALUES (?, ?, ?, ?);\" with expected 
-										regexp \"INSERT INTO todos \\(first_name, last_name, email, 
-										password\\)\\) VALUES \\(\\);\"`)
+	expectedError := errors.New("Prepare: could not match actual sql: \"INSERT INTO todos (title, description) " +
+		"VALUES (?, ?);\" with expected regexp \"INSERT INTO todos \\(title\\) VALUES \\(\\);\"")
 
 	mock.ExpectBegin()
-	mock.ExpectPrepare(wrongQuery).WillReturnError(expectedError)
+	mock.ExpectPrepare(`INSERT INTO todos`).WillReturnError(expectedError)
+	mock.ExpectRollback()
 
 	repository := NewRepository(dbx)
 
@@ -285,6 +416,7 @@ func TestRepositorySave_FailsDueToFailingExec(t *testing.T) {
 
 	mock.ExpectBegin()
 	mock.ExpectPrepare(`INSERT INTO todos`)
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(position\), 0\)`).WillReturnRows(lastPositionRows(0))
 	mock.ExpectExec(`INSERT INTO todos`).WillReturnError(expectedError)
 	mock.ExpectRollback()
 
@@ -323,6 +455,7 @@ func TestRepositorySave_FailsDueToFailingExecWithFailingRollback(t *testing.T) {
 
 	mock.ExpectBegin()
 	mock.ExpectPrepare(`INSERT INTO todos`)
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(position\), 0\)`).WillReturnRows(lastPositionRows(0))
 	mock.ExpectExec(`INSERT INTO todos`).WillReturnError(expectedExecError)
 	mock.ExpectRollback().WillReturnError(expectedRollbackError)
 
@@ -361,6 +494,7 @@ func TestRepositorySave_FailsDueToFailingCommit(t *testing.T) {
 
 	mock.ExpectBegin()
 	mock.ExpectPrepare(`INSERT INTO todos`)
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(position\), 0\)`).WillReturnRows(lastPositionRows(0))
 	mock.ExpectExec(`INSERT INTO todos`).WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit().WillReturnError(expectedError)
 
@@ -388,14 +522,89 @@ func TestRepositoryCompleted_Successful(t *testing.T) {
 
 	expectedUpdatedTodo := 1
 	mock.ExpectBegin()
-	mock.ExpectPrepare(`UPDATE todos`)
-	mock.ExpectExec(`UPDATE todos`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT id, title, description, completed, user_id, version, due_at, recurrence, series_id`).
+		WithArgs(expectedUpdatedTodo).
+		WillReturnRows(todoRows().AddRow(expectedUpdatedTodo, "Lorem", "Ipsum", false, 1, 1, nil, "", 0, _positionGap))
+	mock.ExpectExec(`UPDATE todos`).
+		WithArgs("Lorem", "Ipsum", true, nil, "", 0, expectedUpdatedTodo, 1).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO audit_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
 
 	repository := NewRepository(dbx)
 
 	// When
-	err = repository.Completed(ctx, expectedUpdatedTodo)
+	_, err = repository.Completed(ctx, expectedUpdatedTodo, 0)
+
+	// Then
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepositoryCompleted_SuccessfulCreatesNextOccurrence(t *testing.T) {
+	// Given
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+
+	ctx := context.Background()
+
+	expectedUpdatedTodo := 1
+	dueAt := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+	nextDueAt := dueAt.AddDate(0, 0, 1)
+	rule := "DTSTART:20240101T090000Z\nRRULE:FREQ=DAILY"
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, title, description, completed, user_id, version, due_at, recurrence, series_id`).
+		WithArgs(expectedUpdatedTodo).
+		WillReturnRows(todoRows().AddRow(expectedUpdatedTodo, "Lorem", "Ipsum", false, 1, 1, dueAt, rule, 1, _positionGap))
+	mock.ExpectExec(`UPDATE todos`).
+		WithArgs("Lorem", "Ipsum", true, dueAt, rule, 1, expectedUpdatedTodo, 1).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectPrepare(`INSERT INTO todos`)
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(position\), 0\)`).WillReturnRows(lastPositionRows(0))
+	mock.ExpectExec(`INSERT INTO todos`).
+		WithArgs("Lorem", "Ipsum", 1, nextDueAt, rule, 1, _positionGap, false, "").
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectExec(`INSERT INTO audit_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO audit_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	repository := NewRepository(dbx)
+
+	// When
+	_, err = repository.Completed(ctx, expectedUpdatedTodo, 0)
+
+	// Then
+	require.NoError(t, err)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepositoryCompleted_SuccessfulWithMissingTodo(t *testing.T) {
+	// Given
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+
+	ctx := context.Background()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, title`).WithArgs(1).WillReturnRows(todoRows())
+	mock.ExpectRollback()
+
+	repository := NewRepository(dbx)
+
+	// When
+	_, err = repository.Completed(ctx, 1, 0)
 
 	// Then
 	require.NoError(t, err)
@@ -422,13 +631,13 @@ func TestRepositoryCompleted_FailsDueToInvalidBeginTransaction(t *testing.T) {
 	repository := NewRepository(dbx)
 
 	// When
-	err = repository.Completed(ctx, expectedUpdatedTodo)
+	_, err = repository.Completed(ctx, expectedUpdatedTodo, 0)
 
 	// Then
 	require.ErrorContains(t, err, "You have an error in your SQL syntax")
 }
 
-func TestRepositoryCompleted_FailsDueToInvalidPreparation(t *testing.T) {
+func TestRepositoryCompleted_FailsDueToFailingLock(t *testing.T) {
 	// Given
 	db, mock, err := sqlmock.New()
 	require.NoError(t, err)
@@ -440,23 +649,20 @@ func TestRepositoryCompleted_FailsDueToInvalidPreparation(t *testing.T) {
 	ctx := context.Background()
 
 	expectedUpdatedTodo := 1
-	wrongQuery := regexp.QuoteMeta(`UPDATE todos SET  completed = true WHERE id = ??;`)
-	expectedError := errors.New(`Prepare: could not match actual sql: \"UPDATE todos 
-										SET completed = true
-										WHERE id = ?;\" with expected  regexp \"UPDATE todos 
-										SET completed = true WHERE id = ??;"`)
+	expectedError := errors.New("Error Code: 1205. Lock wait timeout exceeded")
 
 	mock.ExpectBegin()
-	mock.ExpectPrepare(wrongQuery).WillReturnError(expectedError)
+	mock.ExpectQuery(`SELECT id, title`).WillReturnError(expectedError)
+	mock.ExpectRollback()
 
 	repository := NewRepository(dbx)
 
 	// When
-	err = repository.Completed(ctx, expectedUpdatedTodo)
+	_, err = repository.Completed(ctx, expectedUpdatedTodo, 0)
 
 	// Then
-	require.ErrorContains(t, err, "Prepare: could not match actual sql")
-	require.ErrorContains(t, err, "with expected regexp")
+	require.ErrorIs(t, err, expectedError)
+	require.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestRepositoryCompleted_FailsDueToFailingExec(t *testing.T) {
@@ -475,14 +681,15 @@ func TestRepositoryCompleted_FailsDueToFailingExec(t *testing.T) {
 	expectedError := errors.New("Error Code: 1136. Column count doesn't match value count at row 1")
 
 	mock.ExpectBegin()
-	mock.ExpectPrepare(`UPDATE todos`)
+	mock.ExpectQuery(`SELECT id, title`).
+		WillReturnRows(todoRows().AddRow(expectedUpdatedTodo, "Lorem", "Ipsum", false, 1, 1, nil, "", 0, _positionGap))
 	mock.ExpectExec(`UPDATE todos`).WillReturnError(expectedError)
 	mock.ExpectRollback()
 
 	repository := NewRepository(dbx)
 
 	// When
-	err = repository.Completed(ctx, expectedUpdatedTodo)
+	_, err = repository.Completed(ctx, expectedUpdatedTodo, 0)
 
 	// Then
 	require.ErrorContains(t, err, "Error Code: 1136")
@@ -507,14 +714,15 @@ func TestRepositoryCompleted_FailsDueToFailingExecWithFailingRollback(t *testing
 		expectedExecError, "Rollack error")
 
 	mock.ExpectBegin()
-	mock.ExpectPrepare(`UPDATE todos`)
+	mock.ExpectQuery(`SELECT id, title`).
+		WillReturnRows(todoRows().AddRow(expectedUpdatedTodo, "Lorem", "Ipsum", false, 1, 1, nil, "", 0, _positionGap))
 	mock.ExpectExec(`UPDATE todos`).WillReturnError(expectedExecError)
 	mock.ExpectRollback().WillReturnError(expectedRollbackError)
 
 	repository := NewRepository(dbx)
 
 	// When
-	err = repository.Completed(ctx, expectedUpdatedTodo)
+	_, err = repository.Completed(ctx, expectedUpdatedTodo, 0)
 
 	// Then
 	require.ErrorContains(t, err, "update failed")
@@ -539,14 +747,15 @@ func TestRepositoryCompleted_FailsDueToFailingCommit(t *testing.T) {
 	expectedError := errors.New("sql: transaction has already been committed or rolled back")
 
 	mock.ExpectBegin()
-	mock.ExpectPrepare(`UPDATE todos`)
+	mock.ExpectQuery(`SELECT id, title`).
+		WillReturnRows(todoRows().AddRow(expectedUpdatedTodo, "Lorem", "Ipsum", false, 1, 1, nil, "", 0, _positionGap))
 	mock.ExpectExec(`UPDATE todos`).WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit().WillReturnError(expectedError)
 
 	repository := NewRepository(dbx)
 
 	// When
-	err = repository.Completed(ctx, expectedUpdatedTodo)
+	_, err = repository.Completed(ctx, expectedUpdatedTodo, 0)
 
 	// Then
 	require.ErrorContains(t, err, "sql")
@@ -566,14 +775,19 @@ func TestRepositoryDelete_Successful(t *testing.T) {
 
 	deletedTodoID := 1
 	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, title`).
+		WithArgs(deletedTodoID).
+		WillReturnRows(todoRows().AddRow(deletedTodoID, "Lorem", "Ipsum", false, 1, 1, nil, "", 0, _positionGap))
 	mock.ExpectPrepare(`DELETE FROM todos`)
 	mock.ExpectExec(`DELETE FROM todos`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO audit_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
 
 	repository := NewRepository(dbx)
 
 	// When
-	err = repository.Delete(ctx, deletedTodoID)
+	err = repository.Delete(ctx, deletedTodoID, 0)
 
 	// Then
 	require.NoError(t, err)
@@ -599,7 +813,7 @@ func TestRepositoryDelete_FailsDueToInvalidBeginTransaction(t *testing.T) {
 	repository := NewRepository(dbx)
 
 	// When
-	err = repository.Delete(ctx, deletedTodoID)
+	err = repository.Delete(ctx, deletedTodoID, 0)
 
 	// Then
 	require.ErrorContains(t, err, "You have an error in your SQL syntax")
@@ -617,21 +831,21 @@ func TestRepositoryDelete_FailsDueToInvalidPreparation(t *testing.T) {
 	ctx := context.Background()
 
 	deletedTodoID := 0
-	wrongQuery := regexp.QuoteMeta(`DELETE FROM todos WHERE id = ();`)
-	expectedError := errors.New(`Prepare: could not match actual sql: \"DELETE FROM todos WHERE id = ?;\" 
-										with expected regexp \"DELETE FROM todos WHERE id = ();\"`)
+	expectedError := errors.New("sql: statement could not be prepared")
 
 	mock.ExpectBegin()
-	mock.ExpectPrepare(wrongQuery).WillReturnError(expectedError)
+	mock.ExpectQuery(`SELECT id, title`).WithArgs(deletedTodoID).WillReturnRows(todoRows())
+	mock.ExpectPrepare(regexp.QuoteMeta(`DELETE FROM todos`)).WillReturnError(expectedError)
+	mock.ExpectRollback()
 
 	repository := NewRepository(dbx)
 
 	// When
-	err = repository.Delete(ctx, deletedTodoID)
+	err = repository.Delete(ctx, deletedTodoID, 0)
 
 	// Then
-	require.ErrorContains(t, err, "Prepare: could not match actual sql")
-	require.ErrorContains(t, err, "with expected regexp")
+	require.ErrorIs(t, err, expectedError)
+	require.NoError(t, mock.ExpectationsWereMet())
 }
 
 func TestRepositoryDelete_FailsDueToFailingExec(t *testing.T) {
@@ -649,6 +863,7 @@ func TestRepositoryDelete_FailsDueToFailingExec(t *testing.T) {
 	expectedError := errors.New("Error Code: 1136. Column count doesn't match value count at row 1")
 
 	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, title`).WithArgs(deletedTodoID).WillReturnRows(todoRows())
 	mock.ExpectPrepare(`DELETE FROM todos`)
 	mock.ExpectExec(`DELETE FROM todos`).WillReturnError(expectedError)
 	mock.ExpectRollback()
@@ -656,7 +871,7 @@ func TestRepositoryDelete_FailsDueToFailingExec(t *testing.T) {
 	repository := NewRepository(dbx)
 
 	// When
-	err = repository.Delete(ctx, deletedTodoID)
+	err = repository.Delete(ctx, deletedTodoID, 0)
 
 	// Then
 	require.ErrorContains(t, err, "Error Code: 1136")
@@ -680,6 +895,7 @@ func TestRepositoryDelete_FailsDueToFailingExecWithFailingRollback(t *testing.T)
 		expectedExecError, "Rollack error")
 
 	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, title`).WithArgs(deletedTodoID).WillReturnRows(todoRows())
 	mock.ExpectPrepare(`DELETE FROM todos`)
 	mock.ExpectExec(`DELETE FROM todos`).WillReturnError(expectedExecError)
 	mock.ExpectRollback().WillReturnError(expectedRollbackError)
@@ -687,7 +903,7 @@ func TestRepositoryDelete_FailsDueToFailingExecWithFailingRollback(t *testing.T)
 	repository := NewRepository(dbx)
 
 	// When
-	err = repository.Delete(ctx, deletedTodoID)
+	err = repository.Delete(ctx, deletedTodoID, 0)
 
 	// Then
 	require.ErrorContains(t, err, "delete failed")
@@ -712,6 +928,7 @@ func TestRepositoryDelete_FailsDueToFailingCommit(t *testing.T) {
 	expectedError := errors.New("sql: transaction has already been committed or rolled back")
 
 	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, title`).WithArgs(deletedTodoID).WillReturnRows(todoRows())
 	mock.ExpectPrepare(`DELETE FROM todos`)
 	mock.ExpectExec(`DELETE FROM todos`).WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit().WillReturnError(expectedError)
@@ -719,7 +936,7 @@ func TestRepositoryDelete_FailsDueToFailingCommit(t *testing.T) {
 	repository := NewRepository(dbx)
 
 	// When
-	err = repository.Delete(ctx, deletedTodoID)
+	err = repository.Delete(ctx, deletedTodoID, 0)
 
 	// Then
 	require.ErrorContains(t, err, "sql")
@@ -741,6 +958,7 @@ func TestRepositoryDelete_FailsDueToNoRowsAffected(t *testing.T) {
 	expectedError := errors.New("no rows affected")
 
 	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, title`).WithArgs(deletedTodoID).WillReturnRows(todoRows())
 	mock.ExpectPrepare(`DELETE FROM todos`)
 	mock.ExpectExec(`DELETE FROM todos`).WillReturnResult(sqlmock.NewResult(0, 0))
 	mock.ExpectCommit()
@@ -748,8 +966,326 @@ func TestRepositoryDelete_FailsDueToNoRowsAffected(t *testing.T) {
 	repository := NewRepository(dbx)
 
 	// When
-	err = repository.Delete(ctx, deletedTodoID)
+	err = repository.Delete(ctx, deletedTodoID, 0)
 
 	// Then
 	require.ErrorContains(t, err, expectedError.Error())
 }
+
+func TestRepositoryCompleted_FailsDueToStaleVersion(t *testing.T) {
+	// Given
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+
+	ctx := context.Background()
+
+	updatedTodoID := 1
+	staleVersion := 1
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, title`).
+		WithArgs(updatedTodoID).
+		WillReturnRows(todoRows().AddRow(updatedTodoID, "Lorem", "Ipsum", false, 1, staleVersion+1, nil, "", 0, _positionGap))
+	mock.ExpectRollback()
+
+	repository := NewRepository(dbx)
+
+	// When
+	_, err = repository.Completed(ctx, updatedTodoID, staleVersion)
+
+	// Then
+	require.ErrorIs(t, err, domain.ErrVersionMismatch)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepositoryDelete_FailsDueToStaleVersion(t *testing.T) {
+	// Given
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+
+	ctx := context.Background()
+
+	deletedTodoID := 1
+	staleVersion := 1
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, title`).
+		WithArgs(deletedTodoID).
+		WillReturnRows(todoRows().AddRow(deletedTodoID, "Lorem", "Ipsum", false, 1, 1, nil, "", 0, _positionGap))
+	mock.ExpectPrepare(`DELETE FROM todos`)
+	mock.ExpectExec(`DELETE FROM todos`).
+		WithArgs(deletedTodoID, staleVersion, staleVersion).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT COUNT`).WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
+
+	repository := NewRepository(dbx)
+
+	// When
+	err = repository.Delete(ctx, deletedTodoID, staleVersion)
+
+	// Then
+	require.ErrorIs(t, err, domain.ErrVersionMismatch)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepositoryBatch_Successful(t *testing.T) {
+	// Given
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+
+	ctx := context.Background()
+
+	userID := 1
+	operations := []domain.TodoOperation{
+		{Action: domain.TodoCreate, Title: "Lorem", Description: data.Some("Ipsum")},
+		{Action: domain.TodoComplete, ID: 2},
+		{Action: domain.TodoDelete, ID: 3, Version: 4},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, title, (.+) FROM todos WHERE id IN \(\?, \?\) FOR UPDATE;`).
+		WithArgs(2, 3).
+		WillReturnRows(todoRows().
+			AddRow(2, "Dolor", "Sit", false, userID, 1, nil, "", 0, _positionGap).
+			AddRow(3, "Amet", "Consectetur", false, userID, 4, nil, "", 0, 2*_positionGap))
+	mock.ExpectPrepare(`INSERT INTO todos`)
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(position\), 0\)`).WillReturnRows(lastPositionRows(0))
+	mock.ExpectExec(`INSERT INTO todos`).
+		WithArgs("Lorem", "Ipsum", userID, nil, "", 0, _positionGap, false, "").
+		WillReturnResult(sqlmock.NewResult(4, 1))
+	mock.ExpectExec(`INSERT INTO audit_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT id, title`).
+		WithArgs(2).
+		WillReturnRows(todoRows().AddRow(2, "Dolor", "Sit", false, userID, 1, nil, "", 0, _positionGap))
+	mock.ExpectExec(`UPDATE todos`).
+		WithArgs("Dolor", "Sit", true, nil, "", 0, 2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO audit_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`DELETE FROM todos`).WithArgs(3, 4, 4).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO audit_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	repository := NewRepository(dbx)
+
+	// When
+	results, err := repository.Batch(ctx, userID, operations, true)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, []domain.TodoOperationResult{
+		{Index: 0, Action: domain.TodoCreate, ID: 4, Version: 1},
+		{Index: 1, Action: domain.TodoComplete, ID: 2, Version: 2},
+		{Index: 2, Action: domain.TodoDelete, ID: 3},
+	}, results)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepositoryBatch_RollsBackAtomicBatch(t *testing.T) {
+	// Given
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+
+	ctx := context.Background()
+
+	userID := 1
+	operations := []domain.TodoOperation{
+		{Action: domain.TodoComplete, ID: 2},
+		{Action: domain.TodoDelete, ID: 3},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, title, (.+) FROM todos WHERE id IN`).
+		WithArgs(2, 3).
+		WillReturnRows(todoRows().
+			AddRow(2, "Dolor", "Sit", false, userID, 1, nil, "", 0, _positionGap).
+			AddRow(3, "Amet", "Consectetur", false, 2, 1, nil, "", 0, _positionGap))
+	mock.ExpectQuery(`SELECT id, title`).
+		WithArgs(2).
+		WillReturnRows(todoRows().AddRow(2, "Dolor", "Sit", false, userID, 1, nil, "", 0, _positionGap))
+	mock.ExpectExec(`UPDATE todos`).
+		WithArgs("Dolor", "Sit", true, nil, "", 0, 2, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO audit_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectRollback()
+
+	repository := NewRepository(dbx)
+
+	// When
+	results, err := repository.Batch(ctx, userID, operations, true)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, []domain.TodoOperationResult{
+		{Index: 0, Action: domain.TodoComplete, ID: 2, Err: domain.ErrBatchAborted},
+		{Index: 1, Action: domain.TodoDelete, ID: 3, Err: domain.ErrNotOwned},
+	}, results)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepositoryBatch_UndoesFailingOperationOfBestEffortBatch(t *testing.T) {
+	// Given
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+
+	ctx := context.Background()
+
+	userID := 1
+	operations := []domain.TodoOperation{
+		{Action: domain.TodoCreate, Title: "Lorem", Description: data.Some("Ipsum")},
+		{Action: domain.TodoCreate, Title: "Dolor", Description: data.Some("Sit")},
+	}
+
+	expectedError := errors.New("Error Code: 1406. Data too long for column 'title' at row 1")
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT batch_operation`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare(`INSERT INTO todos`)
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(position\), 0\)`).WillReturnRows(lastPositionRows(0))
+	mock.ExpectExec(`INSERT INTO todos`).WillReturnError(expectedError)
+	mock.ExpectExec(`ROLLBACK TO SAVEPOINT batch_operation`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SAVEPOINT batch_operation`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectPrepare(`INSERT INTO todos`)
+	mock.ExpectQuery(`SELECT COALESCE\(MAX\(position\), 0\)`).WillReturnRows(lastPositionRows(0))
+	mock.ExpectExec(`INSERT INTO todos`).WillReturnResult(sqlmock.NewResult(5, 1))
+	mock.ExpectExec(`INSERT INTO audit_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`RELEASE SAVEPOINT batch_operation`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	repository := NewRepository(dbx)
+
+	// When
+	results, err := repository.Batch(ctx, userID, operations, false)
+
+	// Then
+	require.NoError(t, err)
+	require.ErrorIs(t, results[0].Err, expectedError)
+	require.Equal(t, domain.TodoOperationResult{Index: 1, Action: domain.TodoCreate, ID: 5, Version: 1}, results[1])
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepositoryBatch_FailsDueToFailingOwnershipQuery(t *testing.T) {
+	// Given
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+
+	ctx := context.Background()
+
+	expectedError := errors.New("Error Code: 1205. Lock wait timeout exceeded")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, title, (.+) FROM todos WHERE id IN`).WillReturnError(expectedError)
+	mock.ExpectRollback()
+
+	repository := NewRepository(dbx)
+
+	// When
+	results, err := repository.Batch(ctx, 1, []domain.TodoOperation{{Action: domain.TodoDelete, ID: 1}}, false)
+
+	// Then
+	require.ErrorIs(t, err, expectedError)
+	require.Nil(t, results)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepositoryMove_Successful(t *testing.T) {
+	// Given
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+
+	ctx := context.Background()
+
+	move := domain.TodoMove{ID: 3, UserID: 1, BeforeID: 1}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, position, version FROM todos WHERE user_id = \? ORDER BY position, id FOR UPDATE`).
+		WithArgs(move.UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "position", "version"}).
+			AddRow(1, _positionGap, 1).
+			AddRow(2, 2*_positionGap, 1).
+			AddRow(3, 3*_positionGap, 1))
+	mock.ExpectExec(`UPDATE todos SET position = \?, version = version \+ 1 WHERE id = \?`).
+		WithArgs(_positionGap/2, move.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT id, title`).
+		WithArgs(move.ID).
+		WillReturnRows(todoRows().AddRow(move.ID, "Lorem", "Ipsum", false, 1, 2, nil, "", 0, _positionGap/2))
+	mock.ExpectExec(`INSERT INTO audit_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	repository := NewRepository(dbx)
+
+	// When
+	movedTodo, err := repository.Move(ctx, move)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, _positionGap/2, movedTodo.Position)
+	require.Equal(t, 2, movedTodo.Version)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRepositoryMove_FailsDueToStaleVersion(t *testing.T) {
+	// Given
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+
+	ctx := context.Background()
+
+	move := domain.TodoMove{ID: 2, UserID: 1, BeforeID: 1, Version: 1}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, position, version FROM todos`).
+		WithArgs(move.UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "position", "version"}).
+			AddRow(1, _positionGap, 1).
+			AddRow(2, 2*_positionGap, 2))
+	mock.ExpectRollback()
+
+	repository := NewRepository(dbx)
+
+	// When
+	_, err = repository.Move(ctx, move)
+
+	// Then
+	require.ErrorIs(t, err, domain.ErrVersionMismatch)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
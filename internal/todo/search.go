@@ -0,0 +1,237 @@
+package todo
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/sql"
+	"github.com/jmoiron/sqlx"
+	"html"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+const (
+	_searchTodosStmt = `SELECT id, title, description, completed, user_id, version, due_at, recurrence, series_id,
+							position,
+							MATCH (title, description) AGAINST (? IN NATURAL LANGUAGE MODE) AS score
+						FROM todos
+						WHERE user_id = ? AND MATCH (title, description) AGAINST (? IN NATURAL LANGUAGE MODE)
+						ORDER BY score DESC, id
+						LIMIT ? OFFSET ?;`
+	_countSearchTodosStmt = `SELECT COUNT(*)
+							FROM todos
+							WHERE user_id = ? AND MATCH (title, description) AGAINST (? IN NATURAL LANGUAGE MODE);`
+
+	// _snippetRadius is the number of bytes of text kept around the first match of a snippet.
+	_snippetRadius = 60
+
+	_highlightStart = "<mark>"
+	_highlightEnd   = "</mark>"
+	_ellipsis       = "…"
+)
+
+// Searcher finds the todos of a user matching a full-text query, from the most relevant one.
+type Searcher interface {
+	// Search returns the page of the search. Terms are the words of the query, and a todo matches when
+	// it contains any of them.
+	Search(ctx context.Context, search domain.TodoSearch) (domain.TodoSearchPage, error)
+}
+
+// NewSearcher creates the Searcher of the database: the FULLTEXT index of the todos on MySQL, and an
+// in-process index of the todos of the user on the other databases.
+func NewSearcher(conn *sqlx.DB, repository Repository) Searcher {
+	if sql.NewDialect(conn.DriverName()).Name() == sql.MySQL {
+		return NewFullTextSearcher(conn)
+	}
+
+	return NewIndexSearcher(repository)
+}
+
+type fullTextSearcher struct {
+	conn *sqlx.DB
+}
+
+// NewFullTextSearcher creates a Searcher on the MySQL FULLTEXT index of the title and description of
+// the todos, ranking them by its relevance.
+func NewFullTextSearcher(conn *sqlx.DB) Searcher {
+	return &fullTextSearcher{
+		conn: conn,
+	}
+}
+
+func (s fullTextSearcher) Search(ctx context.Context, search domain.TodoSearch) (domain.TodoSearchPage, error) {
+	page := newSearchPage(search)
+
+	terms := queryTerms(search.Query)
+	if len(terms) == 0 {
+		return page, nil
+	}
+
+	conn := sql.ConnFrom(ctx, s.conn)
+
+	if err := conn.GetContext(ctx, &page.Total, _countSearchTodosStmt, search.UserID, search.Query); err != nil {
+		return domain.TodoSearchPage{}, err
+	}
+
+	if page.Total <= search.Offset() {
+		return page, nil
+	}
+
+	var rankedTodos []struct {
+		domain.Todo
+		Score float64 `db:"score"`
+	}
+
+	err := conn.SelectContext(ctx, &rankedTodos, _searchTodosStmt,
+		search.Query, search.UserID, search.Query, search.PageSize, search.Offset())
+	if err != nil {
+		return domain.TodoSearchPage{}, err
+	}
+
+	for _, rankedTodo := range rankedTodos {
+		page.Hits = append(page.Hits, domain.TodoSearchHit{
+			Todo:    rankedTodo.Todo,
+			Score:   rankedTodo.Score,
+			Snippet: snippet(rankedTodo.Todo, terms),
+		})
+	}
+
+	return page, nil
+}
+
+func newSearchPage(search domain.TodoSearch) domain.TodoSearchPage {
+	return domain.TodoSearchPage{
+		Hits:     make([]domain.TodoSearchHit, 0),
+		Page:     search.Page,
+		PageSize: search.PageSize,
+	}
+}
+
+// span is the position of a word in a text, in bytes.
+type span struct {
+	start int
+	end   int
+}
+
+// wordSpans returns the words of the text, which are runs of letters and numbers.
+func wordSpans(text string) []span {
+	spans := make([]span, 0)
+	start := -1
+
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			if start < 0 {
+				start = i
+			}
+
+			continue
+		}
+
+		if start >= 0 {
+			spans = append(spans, span{start: start, end: i})
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		spans = append(spans, span{start: start, end: len(text)})
+	}
+
+	return spans
+}
+
+// tokenize returns the terms of the text, which are its lower-cased words.
+func tokenize(text string) []string {
+	spans := wordSpans(text)
+
+	terms := make([]string, 0, len(spans))
+	for _, wordSpan := range spans {
+		terms = append(terms, strings.ToLower(text[wordSpan.start:wordSpan.end]))
+	}
+
+	return terms
+}
+
+// queryTerms returns the distinct terms of the query.
+func queryTerms(query string) []string {
+	terms := tokenize(query)
+	slices.Sort(terms)
+
+	return slices.Compact(terms)
+}
+
+// snippet highlights the terms in the description of the todo, or in its title when the description
+// does not contain any of them.
+func snippet(todo domain.Todo, terms []string) string {
+	for _, text := range []string{todo.Description, todo.Title} {
+		if highlighted, ok := highlight(text, terms); ok {
+			return highlighted
+		}
+	}
+
+	return html.EscapeString(todo.Title)
+}
+
+// highlight escapes the text around the first term found, marking every term, and reports whether a
+// term was found. Text farther than _snippetRadius from the first term is cut at word boundaries.
+func highlight(text string, terms []string) (string, bool) {
+	spans := wordSpans(text)
+
+	isTerm := func(wordSpan span) bool {
+		_, found := slices.BinarySearch(terms, strings.ToLower(text[wordSpan.start:wordSpan.end]))
+		return found
+	}
+
+	first := slices.IndexFunc(spans, isTerm)
+	if first < 0 {
+		return "", false
+	}
+
+	from := first
+	for from > 0 && spans[first].start-spans[from-1].start <= _snippetRadius {
+		from--
+	}
+
+	to := first
+	for to < len(spans)-1 && spans[to+1].end-spans[first].end <= _snippetRadius {
+		to++
+	}
+
+	start, end := spans[from].start, spans[to].end
+	if from == 0 {
+		start = 0
+	}
+
+	if to == len(spans)-1 {
+		end = len(text)
+	}
+
+	var builder strings.Builder
+	if start > 0 {
+		builder.WriteString(_ellipsis)
+	}
+
+	last := start
+
+	for _, wordSpan := range spans[from : to+1] {
+		if !isTerm(wordSpan) {
+			continue
+		}
+
+		builder.WriteString(html.EscapeString(text[last:wordSpan.start]))
+		builder.WriteString(_highlightStart)
+		builder.WriteString(html.EscapeString(text[wordSpan.start:wordSpan.end]))
+		builder.WriteString(_highlightEnd)
+
+		last = wordSpan.end
+	}
+
+	builder.WriteString(html.EscapeString(text[last:end]))
+
+	if end < len(text) {
+		builder.WriteString(_ellipsis)
+	}
+
+	return builder.String(), true
+}
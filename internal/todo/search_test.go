@@ -0,0 +1,179 @@
+package todo
+
+import (
+	"context"
+	"errors"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestNewSearcher_Successful(t *testing.T) {
+	db, _, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	tests := []struct {
+		driverName string
+		expected   Searcher
+	}{
+		{driverName: "mysql", expected: &fullTextSearcher{}},
+		{driverName: "sqlite", expected: &indexSearcher{}},
+		{driverName: "pgx", expected: &indexSearcher{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.driverName, func(t *testing.T) {
+			// When
+			searcher := NewSearcher(sqlx.NewDb(db, tt.driverName), NewMemoryRepository(audit.NewMemoryRepository(), outbox.NewMemoryRepository()))
+
+			// Then
+			require.IsType(t, tt.expected, searcher)
+		})
+	}
+}
+
+func TestFullTextSearcherSearch_Successful(t *testing.T) {
+	// Given
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+
+	search := domain.TodoSearch{UserID: 1, Query: "lorem dolor", Page: 2, PageSize: 1}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\)`).
+		WithArgs(search.UserID, search.Query).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectQuery(`MATCH \(title, description\) AGAINST \(\? IN NATURAL LANGUAGE MODE\) AS score`).
+		WithArgs(search.Query, search.UserID, search.Query, 1, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "title", "description", "completed", "user_id", "version", "score"}).
+			AddRow(3, "Lorem", "Ipsum dolor sit amet", false, 1, 1, 0.5))
+
+	searcher := NewFullTextSearcher(dbx)
+
+	// When
+	page, err := searcher.Search(context.Background(), search)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, domain.TodoSearchPage{
+		Hits: []domain.TodoSearchHit{{
+			Todo:    domain.Todo{ID: 3, Title: "Lorem", Description: "Ipsum dolor sit amet", UserID: 1, Version: 1},
+			Score:   0.5,
+			Snippet: "Ipsum <mark>dolor</mark> sit amet",
+		}},
+		Total:    2,
+		Page:     2,
+		PageSize: 1,
+	}, page)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFullTextSearcherSearch_SuccessfulSkipsQueryAfterLastPage(t *testing.T) {
+	// Given
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+
+	search := domain.TodoSearch{UserID: 1, Query: "lorem", Page: 3, PageSize: 10}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\)`).
+		WithArgs(search.UserID, search.Query).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(20))
+
+	searcher := NewFullTextSearcher(dbx)
+
+	// When
+	page, err := searcher.Search(context.Background(), search)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, 20, page.Total)
+	require.Empty(t, page.Hits)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestFullTextSearcherSearch_FailsDueToQueryError(t *testing.T) {
+	// Given
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	dbx := sqlx.NewDb(db, "sqlmock")
+
+	expectedError := errors.New("Error Code: 1191. Can't find FULLTEXT index matching the column list")
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\)`).WillReturnError(expectedError)
+
+	searcher := NewFullTextSearcher(dbx)
+
+	// When
+	_, err = searcher.Search(context.Background(), domain.TodoSearch{UserID: 1, Query: "lorem", Page: 1, PageSize: 10})
+
+	// Then
+	require.ErrorIs(t, err, expectedError)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryTerms_Successful(t *testing.T) {
+	// When
+	terms := queryTerms("Café, café and  CRÈME-brûlée 2024!")
+
+	// Then
+	require.Equal(t, []string{"2024", "and", "brûlée", "café", "crème"}, terms)
+}
+
+func TestSnippet_Successful(t *testing.T) {
+	tests := []struct {
+		name     string
+		todo     domain.Todo
+		terms    []string
+		expected string
+	}{
+		{
+			name:     "MarksEveryTermOfDescription",
+			todo:     domain.Todo{Title: "Lorem", Description: "Ipsum dolor sit amet, dolor"},
+			terms:    []string{"amet", "dolor"},
+			expected: "Ipsum <mark>dolor</mark> sit <mark>amet</mark>, <mark>dolor</mark>",
+		},
+		{
+			name:     "FallsBackToTitle",
+			todo:     domain.Todo{Title: "Lorem ipsum", Description: "Dolor"},
+			terms:    []string{"ipsum"},
+			expected: "Lorem <mark>ipsum</mark>",
+		},
+		{
+			name: "CutsTextFarFromFirstTerm",
+			todo: domain.Todo{
+				Title:       "Lorem",
+				Description: strings.Repeat("before ", 20) + "needle" + strings.Repeat(" after", 20),
+			},
+			terms: []string{"needle"},
+			expected: "…" + strings.TrimPrefix(strings.Repeat(" before", 8), " ") + " <mark>needle</mark>" +
+				strings.Repeat(" after", 10) + "…",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// When
+			obtainedSnippet := snippet(tt.todo, tt.terms)
+
+			// Then
+			require.Equal(t, tt.expected, obtainedSnippet)
+		})
+	}
+}
@@ -3,32 +3,85 @@ package todo
 import (
 	"context"
 	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/recurrence"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/sql"
+	"strings"
+	"time"
 )
 
+// Service manages the todos of the users. Decorators acting on its writes implement every method instead
+// of embedding it, so a new method does not compile until they decide how to handle it.
 type Service interface {
 	// GetAll obtain all todos from the database of specific user.
 	GetAll(ctx context.Context, userID int) ([]domain.Todo, error)
 
+	// GetPage obtain at most limit todos of the user that follow after in the order of GetAll, starting
+	// from the first todo when after is zero.
+	GetPage(ctx context.Context, userID int, after domain.Todo, limit int) ([]domain.Todo, error)
+
 	// Get obtain one Todo by ID.
 	Get(ctx context.Context, id int) (domain.Todo, error)
 
-	// Save a new Todo into the database.
+	// Save a new Todo into the database, returning it as stored, placed after the other todos of its user.
+	// A recurring Todo starts a series, with its due date as first occurrence.
 	Save(ctx context.Context, todo domain.Todo) (domain.Todo, error)
 
-	// Completed change the completed state to true.
-	Completed(ctx context.Context, id int) error
+	// Completed change the completed state to true, returning the completed Todo with the ID of the next
+	// occurrence it created, if any. A non-zero version must match the version of the Todo, otherwise
+	// domain.ErrVersionMismatch is returned.
+	Completed(ctx context.Context, id int, version int) (domain.Todo, error)
+
+	// Delete the Todo from the database. A non-zero version must match the version of the Todo,
+	// otherwise domain.ErrVersionMismatch is returned.
+	Delete(ctx context.Context, id int, version int) error
+
+	// Batch applies the operations on the todos of the user inside one transaction. When atomic, a
+	// failing operation rolls back the whole batch; otherwise, only the failing operations are not
+	// applied. The result of every operation is returned.
+	Batch(ctx context.Context, userID int, operations []domain.TodoOperation, atomic bool) (
+		[]domain.TodoOperationResult, error)
+
+	// Search the todos of the user matching a full-text query.
+	Search(ctx context.Context, search domain.TodoSearch) (domain.TodoSearchPage, error)
+
+	// Update writes the changes to the Todo, for the occurrences of its series selected by scope, returning
+	// it with the ID of the next occurrence it created, if any. Empty changes are not written, returning
+	// the Todo as it is. A non-zero version of changes must match the version of the Todo, otherwise
+	// domain.ErrVersionMismatch is returned.
+	Update(ctx context.Context, changes domain.TodoChanges, scope domain.TodoScope) (domain.Todo, error)
+
+	// Skip moves the pending occurrence of a series to the date of the next one. A non-zero version must
+	// match the version of the Todo, otherwise domain.ErrVersionMismatch is returned.
+	Skip(ctx context.Context, id int, version int) (domain.Todo, error)
+
+	// EndSeries makes the pending occurrence of a series the last one. A non-zero version must match the
+	// version of the Todo, otherwise domain.ErrVersionMismatch is returned.
+	EndSeries(ctx context.Context, id int, version int) (domain.Todo, error)
 
-	// Delete the Todo from the database.
-	Delete(ctx context.Context, id int) error
+	// Move places a todo of the user right before or after another of its todos. A non-zero version of
+	// the move must match the version of the Todo, otherwise domain.ErrVersionMismatch is returned.
+	Move(ctx context.Context, move domain.TodoMove) (domain.Todo, error)
+
+	// Import validates the rows of the import and, unless it is a dry run, saves the valid ones inside one
+	// transaction. A row without a title, with an invalid schedule, or whose external ID was already
+	// imported or repeats an earlier row is not saved. The result of every row is returned.
+	Import(ctx context.Context, todoImport domain.TodoImport) ([]domain.TodoImportResult, error)
 }
 
+// _importChunkSize is how many todos an import saves per insert batch.
+const _importChunkSize = 200
+
 type service struct {
 	repository Repository
+	searcher   Searcher
+	transactor sql.Transactor
 }
 
-func NewService(repository Repository) Service {
+func NewService(repository Repository, searcher Searcher, transactor sql.Transactor) Service {
 	return &service{
 		repository: repository,
+		searcher:   searcher,
+		transactor: transactor,
 	}
 }
 
@@ -36,25 +89,267 @@ func (s service) GetAll(ctx context.Context, userID int) ([]domain.Todo, error)
 	return s.repository.GetAll(ctx, userID)
 }
 
+func (s service) GetPage(ctx context.Context, userID int, after domain.Todo, limit int) ([]domain.Todo, error) {
+	return s.repository.GetPage(ctx, userID, after, limit)
+}
+
 func (s service) Get(ctx context.Context, id int) (domain.Todo, error) {
 	return s.repository.Get(ctx, id)
 }
 
+// Save reads the todo back in the transaction that saved it, returning the position the repository
+// placed it at.
 func (s service) Save(ctx context.Context, todo domain.Todo) (domain.Todo, error) {
-	id, err := s.repository.Save(ctx, todo)
+	todo, err := normalizeSchedule(todo)
 	if err != nil {
 		return domain.Todo{}, err
 	}
 
-	todo.ID = id
+	var saved domain.Todo
 
-	return todo, nil
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		id, err := s.repository.Save(ctx, todo)
+		if err != nil {
+			return err
+		}
+
+		saved, err = s.repository.Get(ctx, id)
+
+		return err
+	})
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	return saved, nil
 }
 
-func (s service) Completed(ctx context.Context, id int) error {
-	return s.repository.Completed(ctx, id)
+func (s service) Completed(ctx context.Context, id int, version int) (domain.Todo, error) {
+	return s.repository.Completed(ctx, id, version)
 }
 
-func (s service) Delete(ctx context.Context, id int) error {
-	return s.repository.Delete(ctx, id)
+func (s service) Delete(ctx context.Context, id int, version int) error {
+	return s.repository.Delete(ctx, id, version)
+}
+
+func (s service) Batch(
+	ctx context.Context,
+	userID int,
+	operations []domain.TodoOperation,
+	atomic bool) ([]domain.TodoOperationResult, error) {
+	return s.repository.Batch(ctx, userID, operations, atomic)
+}
+
+func (s service) Search(ctx context.Context, search domain.TodoSearch) (domain.TodoSearchPage, error) {
+	return s.searcher.Search(ctx, search)
+}
+
+// Update reads the todo and writes the changes to it in one transaction, so they are applied to the
+// todo that was read.
+func (s service) Update(
+	ctx context.Context,
+	changes domain.TodoChanges,
+	scope domain.TodoScope) (domain.Todo, error) {
+	var updated domain.Todo
+
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		updated, err = s.update(ctx, changes, scope)
+
+		return err
+	})
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	return updated, nil
+}
+
+func (s service) update(ctx context.Context, changes domain.TodoChanges, scope domain.TodoScope) (domain.Todo, error) {
+	todo, err := s.repository.Get(ctx, changes.ID)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	if changes.Version != 0 && changes.Version != todo.Version {
+		return domain.Todo{}, domain.ErrVersionMismatch
+	}
+
+	// Nothing is written for empty changes, so the version, the audit log and the outbox are left as is.
+	if changes.IsEmpty() {
+		return todo, nil
+	}
+
+	if changes.Title != "" {
+		todo.Title = changes.Title
+	}
+
+	changes.Description.Apply(&todo.Description)
+	changes.Completed.Apply(&todo.Completed)
+
+	if changes.DueAt != nil {
+		// Kept in its time zone until it anchors the series, normalizeSchedule stores it in UTC.
+		dueAt := *changes.DueAt
+		todo.DueAt = &dueAt
+	}
+
+	// Moving a single occurrence keeps the series where it was; otherwise, the series is anchored to the
+	// new due date.
+	reschedulesSeries := changes.DueAt != nil && scope == domain.TodoScopeFuture
+
+	if changes.Recurrence != "" || (reschedulesSeries && todo.Recurrence != "") {
+		// A due date in UTC, like the stored one, keeps the time zone of the series.
+		if todo.DueAt != nil && todo.DueAt.Location() == time.UTC {
+			dueAt := todo.DueAt.In(recurrence.Location(todo.Recurrence))
+			todo.DueAt = &dueAt
+		}
+
+		if changes.Recurrence != "" {
+			todo.Recurrence = changes.Recurrence
+		}
+
+		if todo, err = normalizeSchedule(todo); err != nil {
+			return domain.Todo{}, err
+		}
+	} else if todo.DueAt != nil {
+		dueAt := todo.DueAt.UTC().Truncate(time.Second)
+		todo.DueAt = &dueAt
+	}
+
+	return s.repository.Update(ctx, todo, scope)
+}
+
+func (s service) Skip(ctx context.Context, id int, version int) (domain.Todo, error) {
+	return s.repository.Skip(ctx, id, version)
+}
+
+func (s service) EndSeries(ctx context.Context, id int, version int) (domain.Todo, error) {
+	return s.repository.EndSeries(ctx, id, version)
+}
+
+func (s service) Move(ctx context.Context, move domain.TodoMove) (domain.Todo, error) {
+	return s.repository.Move(ctx, move)
+}
+
+func (s service) Import(ctx context.Context, todoImport domain.TodoImport) ([]domain.TodoImportResult, error) {
+	var results []domain.TodoImportResult
+
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		results, err = s.importRows(ctx, todoImport)
+
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (s service) importRows(ctx context.Context, todoImport domain.TodoImport) ([]domain.TodoImportResult, error) {
+	externalIDs := make([]string, 0, len(todoImport.Rows))
+	for _, row := range todoImport.Rows {
+		if row.Err == nil && row.Todo.ExternalID != "" {
+			externalIDs = append(externalIDs, row.Todo.ExternalID)
+		}
+	}
+
+	existing, err := s.repository.ExternalIDs(ctx, todoImport.UserID, externalIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	imported := make(map[string]bool, len(existing)+len(externalIDs))
+	for _, externalID := range existing {
+		imported[externalID] = true
+	}
+
+	results := make([]domain.TodoImportResult, len(todoImport.Rows))
+	todos := make([]domain.Todo, 0, len(todoImport.Rows))
+	saved := make([]int, 0, len(todoImport.Rows)) // Index in results of every todo.
+
+	for i, row := range todoImport.Rows {
+		results[i] = domain.TodoImportResult{Row: row.Row, ExternalID: row.Todo.ExternalID, Err: row.Err}
+		if row.Err != nil {
+			continue
+		}
+
+		todo, err := validateImported(row.Todo, imported)
+		if err != nil {
+			results[i].Err = err
+
+			continue
+		}
+
+		if todo.ExternalID != "" {
+			imported[todo.ExternalID] = true
+		}
+
+		todos = append(todos, todo)
+		saved = append(saved, i)
+	}
+
+	if todoImport.DryRun {
+		return results, nil
+	}
+
+	for start := 0; start < len(todos); start += _importChunkSize {
+		end := min(start+_importChunkSize, len(todos))
+
+		ids, err := s.repository.SaveAll(ctx, todoImport.UserID, todos[start:end])
+		if err != nil {
+			return nil, err
+		}
+
+		for j, id := range ids {
+			results[saved[start+j]].ID = id
+		}
+	}
+
+	return results, nil
+}
+
+// validateImported returns the todo as it is saved, or why it can not be. imported holds the external IDs
+// of the todos of the user, and of the earlier rows.
+func validateImported(todo domain.Todo, imported map[string]bool) (domain.Todo, error) {
+	todo.Title = strings.TrimSpace(todo.Title)
+	if todo.Title == "" {
+		return domain.Todo{}, domain.ErrMissingTitle
+	}
+
+	if todo.ExternalID != "" && imported[todo.ExternalID] {
+		return domain.Todo{}, domain.ErrDuplicateExternalID
+	}
+
+	return normalizeSchedule(todo)
+}
+
+// normalizeSchedule stores the due date of the todo in UTC, to the second, and anchors its recurrence
+// to it, in the time zone of the due date. A recurring todo must have a due date.
+func normalizeSchedule(todo domain.Todo) (domain.Todo, error) {
+	if todo.DueAt == nil {
+		if todo.Recurrence != "" {
+			return domain.Todo{}, domain.ErrMissingDueDate
+		}
+
+		return todo, nil
+	}
+
+	start := todo.DueAt.Truncate(time.Second)
+	dueAt := start.UTC()
+	todo.DueAt = &dueAt
+
+	if todo.Recurrence == "" {
+		return todo, nil
+	}
+
+	rule, err := recurrence.Normalize(todo.Recurrence, start)
+	if err != nil {
+		return domain.Todo{}, err
+	}
+
+	todo.Recurrence = rule
+
+	return todo, nil
 }
@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/sql"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"testing"
+	"time"
 )
 
 type mockRepository struct {
@@ -18,6 +20,15 @@ func (mr *mockRepository) GetAll(ctx context.Context, userID int) ([]domain.Todo
 	return args.Get(0).([]domain.Todo), args.Error(1)
 }
 
+func (mr *mockRepository) GetPage(
+	ctx context.Context,
+	userID int,
+	after domain.Todo,
+	limit int) ([]domain.Todo, error) {
+	args := mr.Called(ctx, userID, after, limit)
+	return args.Get(0).([]domain.Todo), args.Error(1)
+}
+
 func (mr *mockRepository) Get(ctx context.Context, id int) (domain.Todo, error) {
 	args := mr.Called(ctx, id)
 	return args.Get(0).(domain.Todo), args.Error(1)
@@ -28,16 +39,64 @@ func (mr *mockRepository) Save(ctx context.Context, todo domain.Todo) (int, erro
 	return args.Int(0), args.Error(1)
 }
 
-func (mr *mockRepository) Completed(ctx context.Context, id int) error {
-	args := mr.Called(ctx, id)
-	return args.Error(0)
+func (mr *mockRepository) SaveAll(ctx context.Context, userID int, todos []domain.Todo) ([]int, error) {
+	args := mr.Called(ctx, userID, todos)
+	return args.Get(0).([]int), args.Error(1)
 }
 
-func (mr *mockRepository) Delete(ctx context.Context, id int) error {
-	args := mr.Called(ctx, id)
+func (mr *mockRepository) ExternalIDs(ctx context.Context, userID int, externalIDs []string) ([]string, error) {
+	args := mr.Called(ctx, userID, externalIDs)
+	return args.Get(0).([]string), args.Error(1)
+}
+
+func (mr *mockRepository) Completed(ctx context.Context, id int, version int) (domain.Todo, error) {
+	args := mr.Called(ctx, id, version)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (mr *mockRepository) Delete(ctx context.Context, id int, version int) error {
+	args := mr.Called(ctx, id, version)
 	return args.Error(0)
 }
 
+func (mr *mockRepository) Batch(
+	ctx context.Context,
+	userID int,
+	operations []domain.TodoOperation,
+	atomic bool) ([]domain.TodoOperationResult, error) {
+	args := mr.Called(ctx, userID, operations, atomic)
+	return args.Get(0).([]domain.TodoOperationResult), args.Error(1)
+}
+
+func (mr *mockRepository) Update(ctx context.Context, todo domain.Todo, scope domain.TodoScope) (domain.Todo, error) {
+	args := mr.Called(ctx, todo, scope)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (mr *mockRepository) Skip(ctx context.Context, id int, version int) (domain.Todo, error) {
+	args := mr.Called(ctx, id, version)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (mr *mockRepository) EndSeries(ctx context.Context, id int, version int) (domain.Todo, error) {
+	args := mr.Called(ctx, id, version)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+func (mr *mockRepository) Move(ctx context.Context, move domain.TodoMove) (domain.Todo, error) {
+	args := mr.Called(ctx, move)
+	return args.Get(0).(domain.Todo), args.Error(1)
+}
+
+type mockSearcher struct {
+	mock.Mock
+}
+
+func (ms *mockSearcher) Search(ctx context.Context, search domain.TodoSearch) (domain.TodoSearchPage, error) {
+	args := ms.Called(ctx, search)
+	return args.Get(0).(domain.TodoSearchPage), args.Error(1)
+}
+
 func TestServiceGetAll_Successful(t *testing.T) {
 	// Given
 	expectedUserID := 1
@@ -61,7 +120,7 @@ func TestServiceGetAll_Successful(t *testing.T) {
 	mr := new(mockRepository)
 	mr.On("GetAll", mock.Anything, expectedUserID).Return(expectedTodos, nil)
 
-	service := NewService(mr)
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
 
 	// When
 	todos, err := service.GetAll(context.Background(), expectedUserID)
@@ -80,7 +139,7 @@ func TestServiceGetAll_SuccessfulWithZeroTodos(t *testing.T) {
 	mr := new(mockRepository)
 	mr.On("GetAll", mock.Anything, expectedUserID).Return(expectedTodos, nil)
 
-	service := NewService(mr)
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
 
 	// When
 	todos, err := service.GetAll(context.Background(), expectedUserID)
@@ -100,7 +159,7 @@ func TestServiceGetAll_FailsDueToRepositoryError(t *testing.T) {
 	mr := new(mockRepository)
 	mr.On("GetAll", mock.Anything, expectedUserID).Return(expectedTodos, expectedError)
 
-	service := NewService(mr)
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
 
 	// When
 	todos, err := service.GetAll(context.Background(), expectedUserID)
@@ -126,7 +185,7 @@ func TestServiceGet_Successful(t *testing.T) {
 	mr := new(mockRepository)
 	mr.On("Get", mock.Anything, expectedTodo.ID).Return(expectedTodo, nil)
 
-	service := NewService(mr)
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
 
 	// When
 	todo, err := service.Get(context.Background(), expectedTodo.ID)
@@ -145,7 +204,7 @@ func TestServiceGet_FailsDueToRepositoryError(t *testing.T) {
 	mr := new(mockRepository)
 	mr.On("Get", mock.Anything, nonExistingID).Return(expectedTodo, expectedError)
 
-	service := NewService(mr)
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
 
 	// When
 	todo, err := service.Get(context.Background(), nonExistingID)
@@ -167,17 +226,70 @@ func TestServiceSave_Successful(t *testing.T) {
 		UserID:      expectedUserID,
 	}
 
+	storedTodo := expectedTodo
+	storedTodo.Version = 1
+	storedTodo.Position = 1024
+
 	mr := new(mockRepository)
 	mr.On("Save", mock.Anything, expectedTodo).Return(expectedTodo.ID, nil)
+	mr.On("Get", mock.Anything, expectedTodo.ID).Return(storedTodo, nil)
 
-	service := NewService(mr)
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
 
 	// When
 	todo, err := service.Save(context.Background(), expectedTodo)
 
 	// Then
 	require.NoError(t, err)
-	require.Equal(t, expectedTodo, todo)
+	require.Equal(t, storedTodo, todo)
+}
+
+func TestServiceSave_SuccessfulStartsSeries(t *testing.T) {
+	// Given
+	dueAt := time.Date(2024, time.January, 1, 9, 0, 0, 500, time.FixedZone("UTC-5", -5*60*60))
+	expectedDueAt := time.Date(2024, time.January, 1, 14, 0, 0, 0, time.UTC)
+
+	newTodo := domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: 1, DueAt: &dueAt, Recurrence: "daily"}
+	expectedTodo := domain.Todo{
+		Title:       "Lorem",
+		Description: "Ipsum",
+		UserID:      1,
+		DueAt:       &expectedDueAt,
+		Recurrence:  "DTSTART;TZID=UTC-05:00:20240101T090000\nRRULE:FREQ=DAILY",
+	}
+
+	storedTodo := expectedTodo
+	storedTodo.ID = 3
+	storedTodo.Version = 1
+	storedTodo.SeriesID = 3
+	storedTodo.Position = 1024
+
+	mr := new(mockRepository)
+	mr.On("Save", mock.Anything, expectedTodo).Return(3, nil)
+	mr.On("Get", mock.Anything, 3).Return(storedTodo, nil)
+
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
+
+	// When
+	todo, err := service.Save(context.Background(), newTodo)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, storedTodo, todo)
+}
+
+func TestServiceSave_FailsDueToMissingDueDate(t *testing.T) {
+	// Given
+	mr := new(mockRepository)
+
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
+
+	// When
+	_, err := service.Save(context.Background(), domain.Todo{Title: "Lorem", UserID: 1, Recurrence: "daily"})
+
+	// Then
+	require.ErrorIs(t, err, domain.ErrMissingDueDate)
+	mr.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
 }
 
 func TestServiceSave_FailsDueToRepositoryError(t *testing.T) {
@@ -188,7 +300,7 @@ func TestServiceSave_FailsDueToRepositoryError(t *testing.T) {
 	mr := new(mockRepository)
 	mr.On("Save", mock.Anything, expectedTodo).Return(0, expectedError)
 
-	service := NewService(mr)
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
 
 	// When
 	todo, err := service.Save(context.Background(), expectedTodo)
@@ -208,15 +320,16 @@ func TestServiceCompleted_Successful(t *testing.T) {
 	}
 
 	mr := new(mockRepository)
-	mr.On("Completed", mock.Anything, expectedTodo.ID).Return(nil)
+	mr.On("Completed", mock.Anything, expectedTodo.ID, 0).Return(expectedTodo, nil)
 
-	service := NewService(mr)
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
 
 	// When
-	err := service.Completed(context.Background(), expectedTodo.ID)
+	completedTodo, err := service.Completed(context.Background(), expectedTodo.ID, 0)
 
 	// Then
 	require.NoError(t, err)
+	require.Equal(t, expectedTodo, completedTodo)
 }
 
 func TestServiceCompleted_FailsDueToRepositoryError(t *testing.T) {
@@ -229,12 +342,12 @@ func TestServiceCompleted_FailsDueToRepositoryError(t *testing.T) {
 	expectedError := errors.New("Error Code: 1054. Unknown column 'wrong' in 'field list'")
 
 	mr := new(mockRepository)
-	mr.On("Completed", mock.Anything, expectedTodo.ID).Return(expectedError)
+	mr.On("Completed", mock.Anything, expectedTodo.ID, 0).Return(domain.Todo{}, expectedError)
 
-	service := NewService(mr)
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
 
 	// When
-	err := service.Completed(context.Background(), expectedTodo.ID)
+	_, err := service.Completed(context.Background(), expectedTodo.ID, 0)
 
 	// Then
 	require.ErrorContains(t, err, "Error Code: 1054")
@@ -246,12 +359,12 @@ func TestServiceDelete_Successful(t *testing.T) {
 	expectedTodoID := 1
 
 	mr := new(mockRepository)
-	mr.On("Delete", mock.Anything, expectedTodoID).Return(nil)
+	mr.On("Delete", mock.Anything, expectedTodoID, 0).Return(nil)
 
-	service := NewService(mr)
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
 
 	// When
-	err := service.Delete(context.Background(), expectedTodoID)
+	err := service.Delete(context.Background(), expectedTodoID, 0)
 
 	// Then
 	require.NoError(t, err)
@@ -263,14 +376,456 @@ func TestServiceDelete_FailsDueToRepositoryError(t *testing.T) {
 	expectedError := errors.New("Error Code: 1054. Unknown column 'wrong' in 'field list'")
 
 	mr := new(mockRepository)
-	mr.On("Delete", mock.Anything, expectedTodoID).Return(expectedError)
+	mr.On("Delete", mock.Anything, expectedTodoID, 0).Return(expectedError)
 
-	service := NewService(mr)
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
 
 	// When
-	err := service.Delete(context.Background(), expectedTodoID)
+	err := service.Delete(context.Background(), expectedTodoID, 0)
 
 	// Then
 	require.ErrorContains(t, err, "Error Code: 1054")
 	require.ErrorContains(t, err, "Unknown column 'wrong' in 'field list'")
 }
+
+func TestServiceBatch_Successful(t *testing.T) {
+	// Given
+	operations := []domain.TodoOperation{{Action: domain.TodoDelete, ID: 1}}
+	expectedResults := []domain.TodoOperationResult{{Index: 0, Action: domain.TodoDelete, ID: 1}}
+
+	mr := new(mockRepository)
+	mr.On("Batch", mock.Anything, 1, operations, true).Return(expectedResults, nil)
+
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
+
+	// When
+	results, err := service.Batch(context.Background(), 1, operations, true)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, expectedResults, results)
+}
+
+func TestServiceBatch_FailsDueToRepositoryError(t *testing.T) {
+	// Given
+	expectedError := errors.New("Error Code: 1205. Lock wait timeout exceeded")
+
+	mr := new(mockRepository)
+	mr.On("Batch", mock.Anything, 1, mock.Anything, false).Return([]domain.TodoOperationResult(nil), expectedError)
+
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
+
+	// When
+	results, err := service.Batch(context.Background(), 1, []domain.TodoOperation{{Action: domain.TodoDelete, ID: 1}}, false)
+
+	// Then
+	require.ErrorIs(t, err, expectedError)
+	require.Nil(t, results)
+}
+
+func TestServiceSearch_Successful(t *testing.T) {
+	// Given
+	search := domain.TodoSearch{UserID: 1, Query: "lorem", Page: 1, PageSize: 20}
+	expectedPage := domain.TodoSearchPage{
+		Hits: []domain.TodoSearchHit{{
+			Todo:    domain.Todo{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: 1, Version: 1},
+			Score:   1.5,
+			Snippet: "<mark>Lorem</mark>",
+		}},
+		Total:    1,
+		Page:     1,
+		PageSize: 20,
+	}
+
+	ms := new(mockSearcher)
+	ms.On("Search", mock.Anything, search).Return(expectedPage, nil)
+
+	service := NewService(new(mockRepository), ms, sql.NewNopTransactor())
+
+	// When
+	page, err := service.Search(context.Background(), search)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, expectedPage, page)
+}
+
+func TestServiceSearch_FailsDueToSearcherError(t *testing.T) {
+	// Given
+	expectedError := errors.New("Error Code: 1191. Can't find FULLTEXT index matching the column list")
+
+	ms := new(mockSearcher)
+	ms.On("Search", mock.Anything, mock.Anything).Return(domain.TodoSearchPage{}, expectedError)
+
+	service := NewService(new(mockRepository), ms, sql.NewNopTransactor())
+
+	// When
+	_, err := service.Search(context.Background(), domain.TodoSearch{UserID: 1, Query: "lorem", Page: 1, PageSize: 20})
+
+	// Then
+	require.ErrorIs(t, err, expectedError)
+}
+
+func TestServiceUpdate_Successful(t *testing.T) {
+	// Given
+	dueAt := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+	current := domain.Todo{
+		ID:          1,
+		Title:       "Lorem",
+		Description: "Ipsum",
+		UserID:      1,
+		Version:     2,
+		DueAt:       &dueAt,
+		Recurrence:  "DTSTART:20240101T090000Z\nRRULE:FREQ=DAILY",
+		SeriesID:    1,
+	}
+
+	expectedTodo := current
+	expectedTodo.Title = "Dolor"
+	expectedTodo.Recurrence = "DTSTART:20240101T090000Z\nRRULE:FREQ=WEEKLY"
+
+	updatedTodo := expectedTodo
+	updatedTodo.Version = 3
+
+	mr := new(mockRepository)
+	mr.On("Get", mock.Anything, 1).Return(current, nil)
+	mr.On("Update", mock.Anything, expectedTodo, domain.TodoScopeFuture).Return(updatedTodo, nil)
+
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
+
+	// When
+	todo, err := service.Update(context.Background(),
+		domain.TodoChanges{ID: 1, Title: "Dolor", Recurrence: "weekly", Version: 2}, domain.TodoScopeFuture)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, updatedTodo, todo)
+}
+
+func TestServiceUpdate_SuccessfulReschedulesSeries(t *testing.T) {
+	// Given
+	dueAt := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+	movedDueAt := time.Date(2024, time.January, 2, 10, 0, 0, 0, time.UTC)
+	current := domain.Todo{
+		ID:         1,
+		Title:      "Lorem",
+		UserID:     1,
+		Version:    1,
+		DueAt:      &dueAt,
+		Recurrence: "DTSTART:20240101T090000Z\nRRULE:FREQ=WEEKLY",
+		SeriesID:   1,
+	}
+
+	tests := []struct {
+		scope              domain.TodoScope
+		expectedRecurrence string
+	}{
+		{scope: domain.TodoScopeThis, expectedRecurrence: "DTSTART:20240101T090000Z\nRRULE:FREQ=WEEKLY"},
+		{scope: domain.TodoScopeFuture, expectedRecurrence: "DTSTART:20240102T100000Z\nRRULE:FREQ=WEEKLY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.scope), func(t *testing.T) {
+			expectedTodo := current
+			expectedTodo.DueAt = &movedDueAt
+			expectedTodo.Recurrence = tt.expectedRecurrence
+
+			mr := new(mockRepository)
+			mr.On("Get", mock.Anything, 1).Return(current, nil)
+			mr.On("Update", mock.Anything, expectedTodo, tt.scope).Return(expectedTodo, nil)
+
+			service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
+
+			// When
+			_, err := service.Update(context.Background(), domain.TodoChanges{ID: 1, DueAt: &movedDueAt}, tt.scope)
+
+			// Then
+			require.NoError(t, err)
+			mr.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceUpdate_SuccessfulKeepsTheTimeZoneOfTheSeries(t *testing.T) {
+	// Given
+	dueAt := time.Date(2024, time.January, 1, 7, 0, 0, 0, time.UTC)
+	utcDueAt := time.Date(2024, time.January, 2, 22, 0, 0, 0, time.UTC)
+	offsetDueAt := time.Date(2024, time.January, 3, 0, 0, 0, 0, time.FixedZone("", -3*60*60))
+	current := domain.Todo{
+		ID:         1,
+		Title:      "Lorem",
+		UserID:     1,
+		Version:    1,
+		DueAt:      &dueAt,
+		Recurrence: "DTSTART;TZID=UTC+02:00:20240101T090000\nRRULE:FREQ=DAILY",
+		SeriesID:   1,
+	}
+
+	tests := map[string]struct {
+		dueAt              *time.Time
+		expectedDueAt      time.Time
+		expectedRecurrence string
+	}{
+		"UTCDueDate": {
+			dueAt:              &utcDueAt,
+			expectedDueAt:      time.Date(2024, time.January, 2, 22, 0, 0, 0, time.UTC),
+			expectedRecurrence: "DTSTART;TZID=UTC+02:00:20240103T000000\nRRULE:FREQ=WEEKLY",
+		},
+		"DueDateWithOffset": {
+			dueAt:              &offsetDueAt,
+			expectedDueAt:      time.Date(2024, time.January, 3, 3, 0, 0, 0, time.UTC),
+			expectedRecurrence: "DTSTART;TZID=UTC-03:00:20240103T000000\nRRULE:FREQ=WEEKLY",
+		},
+		"SameDueDate": {
+			expectedDueAt:      dueAt,
+			expectedRecurrence: "DTSTART;TZID=UTC+02:00:20240101T090000\nRRULE:FREQ=WEEKLY",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			expectedTodo := current
+			expectedTodo.DueAt = &test.expectedDueAt
+			expectedTodo.Recurrence = test.expectedRecurrence
+
+			mr := new(mockRepository)
+			mr.On("Get", mock.Anything, 1).Return(current, nil)
+			mr.On("Update", mock.Anything, expectedTodo, domain.TodoScopeFuture).Return(expectedTodo, nil)
+
+			service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
+
+			// When
+			_, err := service.Update(context.Background(),
+				domain.TodoChanges{ID: 1, DueAt: test.dueAt, Recurrence: "weekly"}, domain.TodoScopeFuture)
+
+			// Then
+			require.NoError(t, err)
+			mr.AssertExpectations(t)
+		})
+	}
+}
+
+func TestServiceUpdate_SuccessfulSkipsEmptyChanges(t *testing.T) {
+	// Given
+	current := domain.Todo{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: 1, Version: 2}
+
+	mr := new(mockRepository)
+	mr.On("Get", mock.Anything, 1).Return(current, nil)
+
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
+
+	// When
+	todo, err := service.Update(context.Background(), domain.TodoChanges{ID: 1, Version: 2}, domain.TodoScopeThis)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, current, todo)
+	mr.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestServiceUpdate_FailsDueToStaleVersion(t *testing.T) {
+	// Given
+	mr := new(mockRepository)
+	mr.On("Get", mock.Anything, 1).Return(domain.Todo{ID: 1, Title: "Lorem", UserID: 1, Version: 2}, nil)
+
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
+
+	// When
+	_, err := service.Update(context.Background(),
+		domain.TodoChanges{ID: 1, Title: "Dolor", Version: 1}, domain.TodoScopeThis)
+
+	// Then
+	require.ErrorIs(t, err, domain.ErrVersionMismatch)
+	mr.AssertNotCalled(t, "Update", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestServiceUpdate_FailsDueToInvalidRecurrence(t *testing.T) {
+	// Given
+	dueAt := time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+	mr := new(mockRepository)
+	mr.On("Get", mock.Anything, 1).Return(domain.Todo{ID: 1, Title: "Lorem", UserID: 1, Version: 1, DueAt: &dueAt}, nil)
+
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
+
+	// When
+	_, err := service.Update(context.Background(),
+		domain.TodoChanges{ID: 1, Recurrence: "sometimes"}, domain.TodoScopeFuture)
+
+	// Then
+	require.ErrorIs(t, err, domain.ErrInvalidRecurrence)
+}
+
+func TestServiceSkip_Successful(t *testing.T) {
+	// Given
+	expectedTodo := domain.Todo{ID: 1, Title: "Lorem", UserID: 1, Version: 2}
+
+	mr := new(mockRepository)
+	mr.On("Skip", mock.Anything, 1, 1).Return(expectedTodo, nil)
+
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
+
+	// When
+	todo, err := service.Skip(context.Background(), 1, 1)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, expectedTodo, todo)
+}
+
+func TestServiceEndSeries_FailsDueToRepositoryError(t *testing.T) {
+	// Given
+	mr := new(mockRepository)
+	mr.On("EndSeries", mock.Anything, 1, 0).Return(domain.Todo{}, domain.ErrNotRecurring)
+
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
+
+	// When
+	_, err := service.EndSeries(context.Background(), 1, 0)
+
+	// Then
+	require.ErrorIs(t, err, domain.ErrNotRecurring)
+}
+
+func TestServiceMove_Successful(t *testing.T) {
+	// Given
+	move := domain.TodoMove{ID: 2, UserID: 1, BeforeID: 1}
+	expectedTodo := domain.Todo{ID: 2, Title: "Lorem", UserID: 1, Version: 2, Position: 512}
+
+	mr := new(mockRepository)
+	mr.On("Move", mock.Anything, move).Return(expectedTodo, nil)
+
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
+
+	// When
+	todo, err := service.Move(context.Background(), move)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, expectedTodo, todo)
+}
+
+func TestServiceImport_Successful(t *testing.T) {
+	// Given
+	readError := errors.New(`parse error on line 2, column 5: bare " in non-quoted field`)
+	todoImport := domain.TodoImport{
+		UserID: 1,
+		Rows: []domain.TodoImportRow{
+			{Row: 1, Todo: domain.Todo{Title: " Lorem ", Description: "Ipsum", Completed: true, ExternalID: "a"}},
+			{Row: 2, Err: readError},
+			{Row: 3, Todo: domain.Todo{Title: " ", ExternalID: "b"}},
+			{Row: 4, Todo: domain.Todo{Title: "Imported", ExternalID: "c"}},
+			{Row: 5, Todo: domain.Todo{Title: "Repeated", ExternalID: "a"}},
+			{Row: 6, Todo: domain.Todo{Title: "Dolor"}},
+		},
+	}
+
+	mr := new(mockRepository)
+	mr.On("ExternalIDs", mock.Anything, 1, []string{"a", "b", "c", "a"}).Return([]string{"c"}, nil)
+	mr.On("SaveAll", mock.Anything, 1, []domain.Todo{
+		{Title: "Lorem", Description: "Ipsum", Completed: true, ExternalID: "a"},
+		{Title: "Dolor"},
+	}).Return([]int{10, 11}, nil)
+
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
+
+	// When
+	results, err := service.Import(context.Background(), todoImport)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, []domain.TodoImportResult{
+		{Row: 1, ExternalID: "a", ID: 10},
+		{Row: 2, Err: readError},
+		{Row: 3, ExternalID: "b", Err: domain.ErrMissingTitle},
+		{Row: 4, ExternalID: "c", Err: domain.ErrDuplicateExternalID},
+		{Row: 5, ExternalID: "a", Err: domain.ErrDuplicateExternalID},
+		{Row: 6, ID: 11},
+	}, results)
+}
+
+func TestServiceImport_SuccessfulWithDryRun(t *testing.T) {
+	// Given
+	todoImport := domain.TodoImport{
+		UserID: 1,
+		Rows: []domain.TodoImportRow{
+			{Row: 1, Todo: domain.Todo{Title: "Lorem", ExternalID: "a"}},
+			{Row: 2, Todo: domain.Todo{Title: "Ipsum", Recurrence: "FREQ=DAILY"}},
+		},
+		DryRun: true,
+	}
+
+	mr := new(mockRepository)
+	mr.On("ExternalIDs", mock.Anything, 1, []string{"a"}).Return([]string{}, nil)
+
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
+
+	// When
+	results, err := service.Import(context.Background(), todoImport)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, []domain.TodoImportResult{
+		{Row: 1, ExternalID: "a"},
+		{Row: 2, Err: domain.ErrMissingDueDate},
+	}, results)
+	mr.AssertNotCalled(t, "SaveAll", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestServiceImport_SuccessfulInChunks(t *testing.T) {
+	// Given
+	rows := make([]domain.TodoImportRow, _importChunkSize+1)
+	for i := range rows {
+		rows[i] = domain.TodoImportRow{Row: i + 1, Todo: domain.Todo{Title: "Lorem"}}
+	}
+
+	firstIDs := make([]int, _importChunkSize)
+	for i := range firstIDs {
+		firstIDs[i] = i + 1
+	}
+
+	mr := new(mockRepository)
+	mr.On("ExternalIDs", mock.Anything, 1, []string{}).Return([]string{}, nil)
+	mr.On("SaveAll", mock.Anything, 1, mock.MatchedBy(func(todos []domain.Todo) bool {
+		return len(todos) == _importChunkSize
+	})).Return(firstIDs, nil).Once()
+	mr.On("SaveAll", mock.Anything, 1, mock.MatchedBy(func(todos []domain.Todo) bool {
+		return len(todos) == 1
+	})).Return([]int{_importChunkSize + 1}, nil).Once()
+
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
+
+	// When
+	results, err := service.Import(context.Background(), domain.TodoImport{UserID: 1, Rows: rows})
+
+	// Then
+	require.NoError(t, err)
+	require.Len(t, results, _importChunkSize+1)
+
+	for i, result := range results {
+		require.Equal(t, i+1, result.ID)
+	}
+
+	mr.AssertExpectations(t)
+}
+
+func TestServiceImport_FailsDueToRepositoryError(t *testing.T) {
+	// Given
+	expectedError := errors.New("Error Code: 1205. Lock wait timeout exceeded")
+
+	mr := new(mockRepository)
+	mr.On("ExternalIDs", mock.Anything, 1, []string{}).Return([]string{}, nil)
+	mr.On("SaveAll", mock.Anything, 1, mock.Anything).Return([]int(nil), expectedError)
+
+	service := NewService(mr, new(mockSearcher), sql.NewNopTransactor())
+
+	// When
+	results, err := service.Import(context.Background(), domain.TodoImport{
+		UserID: 1,
+		Rows:   []domain.TodoImportRow{{Row: 1, Todo: domain.Todo{Title: "Lorem"}}},
+	})
+
+	// Then
+	require.ErrorIs(t, err, expectedError)
+	require.Nil(t, results)
+}
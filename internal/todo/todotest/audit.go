@@ -0,0 +1,209 @@
+package todotest
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/data"
+	"github.com/ferch5003/go-fiber-tutorial/internal/todo"
+	"github.com/stretchr/testify/require"
+	"slices"
+	"testing"
+)
+
+// AuditFactory creates an empty repository for every test, along with the audit repository its writes
+// are recorded into.
+type AuditFactory func(t *testing.T) (todo.Repository, audit.Repository)
+
+// _source is who writes the todos of the audit suite.
+var _source = audit.Source{ActorID: UserID, RequestID: "request", IP: "192.0.2.1"}
+
+// RunAuditTests checks every write of the repositories created by newRepositories records what it
+// changed, and only when it is applied.
+func RunAuditTests(t *testing.T, newRepositories AuditFactory) {
+	t.Run("SaveRecordsCreation", func(t *testing.T) {
+		// Given
+		repository, events := newRepositories(t)
+		ctx := audit.WithSource(context.Background(), _source)
+
+		// When
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		// Then
+		history := requireHistory(t, events, id, domain.AuditTodoCreated)
+
+		event := history[0]
+		require.Equal(t, _source.ActorID, event.ActorID)
+		require.Equal(t, _source.RequestID, event.RequestID)
+		require.Equal(t, _source.IP, event.IP)
+		require.Equal(t, domain.AuditChange{After: "Lorem"}, event.Changes["title"])
+		require.Equal(t, domain.AuditChange{After: "Ipsum"}, event.Changes["description"])
+		require.NotContains(t, event.Changes, "version")
+	})
+
+	t.Run("UpdateRecordsChangedFields", func(t *testing.T) {
+		// Given
+		repository, events := newRepositories(t)
+		ctx := audit.WithSource(context.Background(), _source)
+
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		// When
+		_, err = repository.Update(ctx, domain.Todo{ID: id, Title: "Dolor", Description: "Ipsum", UserID: UserID},
+			domain.TodoScopeThis)
+		require.NoError(t, err)
+
+		// Then
+		history := requireHistory(t, events, id, domain.AuditTodoCreated, domain.AuditTodoUpdated)
+
+		require.Equal(t, domain.AuditChanges{
+			"title": {Before: "Lorem", After: "Dolor"},
+		}, history[1].Changes)
+	})
+
+	t.Run("CompletedRecordsCompletion", func(t *testing.T) {
+		// Given
+		repository, events := newRepositories(t)
+		ctx := audit.WithSource(context.Background(), _source)
+
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		// When
+		_, err = repository.Completed(ctx, id, 0)
+		require.NoError(t, err)
+
+		// Then
+		history := requireHistory(t, events, id, domain.AuditTodoCreated, domain.AuditTodoCompleted)
+
+		require.Equal(t, domain.AuditChanges{
+			"completed": {Before: false, After: true},
+		}, history[1].Changes)
+	})
+
+	t.Run("MoveRecordsPosition", func(t *testing.T) {
+		// Given
+		repository, events := newRepositories(t)
+		ctx := audit.WithSource(context.Background(), _source)
+
+		ids := saveTodos(t, repository, 2)
+
+		// When
+		_, err := repository.Move(ctx, domain.TodoMove{ID: ids[1], UserID: UserID, BeforeID: ids[0]})
+		require.NoError(t, err)
+
+		// Then
+		history := requireHistory(t, events, ids[1], domain.AuditTodoCreated, domain.AuditTodoMoved)
+
+		require.Contains(t, history[1].Changes, "position")
+	})
+
+	t.Run("DeleteRecordsDeletion", func(t *testing.T) {
+		// Given
+		repository, events := newRepositories(t)
+		ctx := audit.WithSource(context.Background(), _source)
+
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		// When
+		err = repository.Delete(ctx, id, 0)
+		require.NoError(t, err)
+
+		// Then
+		history := requireHistory(t, events, id, domain.AuditTodoCreated, domain.AuditTodoDeleted)
+
+		require.Equal(t, domain.AuditChange{Before: "Lorem"}, history[1].Changes["title"])
+	})
+
+	t.Run("FailedWriteRecordsNothing", func(t *testing.T) {
+		// Given
+		repository, events := newRepositories(t)
+		ctx := audit.WithSource(context.Background(), _source)
+
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		// When
+		err = repository.Delete(ctx, id, 2)
+
+		// Then
+		require.ErrorIs(t, err, domain.ErrVersionMismatch)
+
+		requireHistory(t, events, id, domain.AuditTodoCreated)
+	})
+
+	t.Run("BatchRecordsAppliedOperations", func(t *testing.T) {
+		// Given
+		repository, events := newRepositories(t)
+		ctx := audit.WithSource(context.Background(), _source)
+
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		operations := []domain.TodoOperation{
+			{Action: domain.TodoUpdate, ID: id, Title: "Dolor"},
+			{Action: domain.TodoComplete, ID: id},
+		}
+
+		// When
+		results, err := repository.Batch(ctx, UserID, operations, true)
+		require.NoError(t, err)
+
+		// Then
+		for _, result := range results {
+			require.NoError(t, result.Err)
+		}
+
+		requireHistory(t, events, id, domain.AuditTodoCreated, domain.AuditTodoUpdated, domain.AuditTodoCompleted)
+	})
+
+	t.Run("AbortedBatchRecordsNothing", func(t *testing.T) {
+		// Given
+		repository, events := newRepositories(t)
+		ctx := audit.WithSource(context.Background(), _source)
+
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		operations := []domain.TodoOperation{
+			{Action: domain.TodoComplete, ID: id},
+			{Action: domain.TodoCreate, Title: "Dolor", Description: data.Some("Sit")},
+			{Action: domain.TodoDelete, ID: id + 100},
+		}
+
+		// When
+		results, err := repository.Batch(ctx, UserID, operations, true)
+		require.NoError(t, err)
+
+		// Then
+		require.ErrorIs(t, results[2].Err, domain.ErrNotFound)
+
+		requireHistory(t, events, id, domain.AuditTodoCreated)
+
+		recorded, err := events.List(context.Background(), domain.AuditFilter{Entity: domain.AuditTodo})
+		require.NoError(t, err)
+		require.Len(t, recorded, 1)
+	})
+}
+
+// requireHistory asserts the actions recorded for the todo, from the oldest, and returns their events.
+func requireHistory(t *testing.T, events audit.Repository, id int, expectedActions ...string) []domain.AuditEvent {
+	t.Helper()
+
+	history, err := events.List(context.Background(), domain.AuditFilter{Entity: domain.AuditTodo, EntityID: id})
+	require.NoError(t, err)
+
+	slices.Reverse(history)
+
+	obtainedActions := make([]string, 0, len(history))
+	for _, event := range history {
+		obtainedActions = append(obtainedActions, event.Action)
+	}
+
+	require.Equal(t, expectedActions, obtainedActions)
+
+	return history
+}
@@ -0,0 +1,154 @@
+package todotest
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// runImportTests checks the batch inserts and the external ID lookups imports rely on.
+func runImportTests(t *testing.T, newRepository RepositoryFactory) {
+	t.Run("SaveAllKeepsCompletedAndExternalIDs", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		existingID, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		// When
+		ids, err := repository.SaveAll(ctx, UserID, []domain.Todo{
+			{Title: "First", Description: "Todo", Completed: true, ExternalID: "first"},
+			{Title: "Second", Description: "Todo", ExternalID: "second"},
+		})
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, ids, 2)
+
+		todos, err := repository.GetAll(ctx, UserID)
+		require.NoError(t, err)
+		require.Equal(t, []domain.Todo{
+			{ID: existingID, Title: "Lorem", Description: "Ipsum", UserID: UserID, Version: 1},
+			{ID: ids[0], Title: "First", Description: "Todo", Completed: true, UserID: UserID, Version: 1,
+				ExternalID: "first"},
+			{ID: ids[1], Title: "Second", Description: "Todo", UserID: UserID, Version: 1, ExternalID: "second"},
+		}, withoutPositions(t, todos))
+	})
+
+	t.Run("SaveAllStartsSeries", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		dueAt := _firstDueAt
+
+		// When
+		ids, err := repository.SaveAll(ctx, UserID, []domain.Todo{
+			{Title: "Lorem", Description: "Ipsum", DueAt: &dueAt, Recurrence: normalize(t, "FREQ=DAILY")},
+		})
+		require.NoError(t, err)
+
+		// Then
+		obtainedTodo, err := repository.Get(ctx, ids[0])
+		require.NoError(t, err)
+		require.Equal(t, ids[0], obtainedTodo.SeriesID)
+		requireDueAt(t, _firstDueAt, obtainedTodo)
+	})
+
+	t.Run("SaveAllWithoutTodos", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+
+		// When
+		ids, err := repository.SaveAll(context.Background(), UserID, nil)
+
+		// Then
+		require.NoError(t, err)
+		require.Empty(t, ids)
+	})
+
+	t.Run("SaveAllFailsDueToDuplicateExternalID", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		ids, err := repository.SaveAll(ctx, UserID, []domain.Todo{
+			{Title: "First", Description: "Todo", ExternalID: "first"},
+		})
+		require.NoError(t, err)
+
+		// When
+		_, err = repository.SaveAll(ctx, UserID, []domain.Todo{
+			{Title: "Second", Description: "Todo", ExternalID: "second"},
+			{Title: "Again", Description: "Todo", ExternalID: "first"},
+		})
+
+		// Then
+		require.ErrorIs(t, err, domain.ErrDuplicateExternalID)
+
+		todos, err := repository.GetAll(ctx, UserID)
+		require.NoError(t, err)
+		require.Len(t, todos, 1)
+		require.Equal(t, ids[0], todos[0].ID)
+	})
+
+	t.Run("SaveAllRepeatsEmptyAndOtherUsersExternalIDs", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		_, err := repository.SaveAll(ctx, OtherUserID, []domain.Todo{
+			{Title: "Other", Description: "Todo", ExternalID: "first"},
+		})
+		require.NoError(t, err)
+
+		// When
+		ids, err := repository.SaveAll(ctx, UserID, []domain.Todo{
+			{Title: "First", Description: "Todo", ExternalID: "first"},
+			{Title: "Second", Description: "Todo"},
+			{Title: "Third", Description: "Todo"},
+		})
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, ids, 3)
+	})
+
+	t.Run("ExternalIDsOnlyReturnsTheOnesOfTheUser", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		_, err := repository.SaveAll(ctx, UserID, []domain.Todo{
+			{Title: "First", Description: "Todo", ExternalID: "first"},
+			{Title: "Second", Description: "Todo", ExternalID: "second"},
+		})
+		require.NoError(t, err)
+
+		_, err = repository.SaveAll(ctx, OtherUserID, []domain.Todo{
+			{Title: "Other", Description: "Todo", ExternalID: "other"},
+		})
+		require.NoError(t, err)
+
+		// When
+		existing, err := repository.ExternalIDs(ctx, UserID, []string{"second", "other", "missing"})
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, []string{"second"}, existing)
+	})
+
+	t.Run("ExternalIDsWithoutIDs", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+
+		// When
+		existing, err := repository.ExternalIDs(context.Background(), UserID, nil)
+
+		// Then
+		require.NoError(t, err)
+		require.Empty(t, existing)
+	})
+}
@@ -0,0 +1,117 @@
+package todotest
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox"
+	"github.com/ferch5003/go-fiber-tutorial/internal/todo"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// OutboxFactory creates an empty repository for every test, along with the outbox repository its writes
+// are written into.
+type OutboxFactory func(t *testing.T) (todo.Repository, outbox.Repository)
+
+// RunOutboxTests checks every write of the repositories created by newRepositories writes its outbox
+// message, in order, and only when it is applied.
+func RunOutboxTests(t *testing.T, newRepositories OutboxFactory) {
+	t.Run("WritesMessagesInOrder", func(t *testing.T) {
+		// Given
+		repository, messages := newRepositories(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		// When
+		_, err = repository.Completed(ctx, id, 0)
+		require.NoError(t, err)
+
+		err = repository.Delete(ctx, id, 0)
+		require.NoError(t, err)
+
+		// Then
+		pending := requireMessages(t, messages, domain.AuditTodoCreated, domain.AuditTodoCompleted,
+			domain.AuditTodoDeleted)
+
+		for _, message := range pending {
+			require.Equal(t, domain.OutboxTodo, message.AggregateType)
+			require.Equal(t, id, message.AggregateID)
+			require.Nil(t, message.PublishedAt)
+		}
+
+		var created domain.Todo
+		require.NoError(t, json.Unmarshal(pending[0].Payload, &created))
+		require.Equal(t, id, created.ID)
+		require.Equal(t, "Lorem", created.Title)
+		require.Equal(t, 1, created.Version)
+
+		// A deleted todo is described as it was.
+		var deleted domain.Todo
+		require.NoError(t, json.Unmarshal(pending[2].Payload, &deleted))
+		require.Equal(t, id, deleted.ID)
+		require.True(t, deleted.Completed)
+	})
+
+	t.Run("FailedWriteWritesNothing", func(t *testing.T) {
+		// Given
+		repository, messages := newRepositories(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		// When
+		err = repository.Delete(ctx, id, 2)
+
+		// Then
+		require.ErrorIs(t, err, domain.ErrVersionMismatch)
+
+		requireMessages(t, messages, domain.AuditTodoCreated)
+	})
+
+	t.Run("AbortedBatchWritesNothing", func(t *testing.T) {
+		// Given
+		repository, messages := newRepositories(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		operations := []domain.TodoOperation{
+			{Action: domain.TodoComplete, ID: id},
+			{Action: domain.TodoDelete, ID: id + 100},
+		}
+
+		// When
+		results, err := repository.Batch(ctx, UserID, operations, true)
+		require.NoError(t, err)
+
+		// Then
+		require.ErrorIs(t, results[1].Err, domain.ErrNotFound)
+
+		requireMessages(t, messages, domain.AuditTodoCreated)
+	})
+}
+
+// requireMessages asserts the event types of the pending messages, in order, and returns them.
+func requireMessages(
+	t *testing.T,
+	messages outbox.Repository,
+	expectedEventTypes ...string) []domain.OutboxMessage {
+	t.Helper()
+
+	pending, err := messages.Pending(context.Background(), 100)
+	require.NoError(t, err)
+
+	obtainedEventTypes := make([]string, 0, len(pending))
+	for _, message := range pending {
+		obtainedEventTypes = append(obtainedEventTypes, message.EventType)
+	}
+
+	require.Equal(t, expectedEventTypes, obtainedEventTypes)
+
+	return pending
+}
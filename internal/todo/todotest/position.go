@@ -0,0 +1,233 @@
+package todotest
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/todo"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+)
+
+// runPositionTests checks todos are listed in the order they are moved to.
+func runPositionTests(t *testing.T, newRepository RepositoryFactory) {
+	t.Run("MoveBefore", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		ids := saveTodos(t, repository, 3)
+
+		// When
+		movedTodo, err := repository.Move(ctx, domain.TodoMove{ID: ids[2], UserID: UserID, BeforeID: ids[0]})
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, ids[2], movedTodo.ID)
+		require.Equal(t, 2, movedTodo.Version)
+
+		requireOrder(t, repository, ids[2], ids[0], ids[1])
+	})
+
+	t.Run("MoveAfter", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		ids := saveTodos(t, repository, 3)
+
+		// When
+		_, err := repository.Move(ctx, domain.TodoMove{ID: ids[0], UserID: UserID, AfterID: ids[1]})
+
+		// Then
+		require.NoError(t, err)
+
+		requireOrder(t, repository, ids[1], ids[0], ids[2])
+	})
+
+	t.Run("MoveAfterLast", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		ids := saveTodos(t, repository, 3)
+
+		// When
+		_, err := repository.Move(ctx, domain.TodoMove{ID: ids[0], UserID: UserID, AfterID: ids[2]})
+		require.NoError(t, err)
+
+		newID, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		// Then
+		requireOrder(t, repository, ids[1], ids[2], ids[0], newID)
+	})
+
+	t.Run("GetPageFollowsTheOrderOfMoves", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		ids := saveTodos(t, repository, 3)
+
+		_, err := repository.Save(ctx, domain.Todo{Title: "Other", Description: "Todo", UserID: OtherUserID})
+		require.NoError(t, err)
+
+		_, err = repository.Move(ctx, domain.TodoMove{ID: ids[2], UserID: UserID, BeforeID: ids[0]})
+		require.NoError(t, err)
+
+		// When
+		firstPage, err := repository.GetPage(ctx, UserID, domain.Todo{}, 2)
+		require.NoError(t, err)
+		require.Len(t, firstPage, 2)
+
+		lastPage, err := repository.GetPage(ctx, UserID, firstPage[1], 2)
+		require.NoError(t, err)
+
+		// Then
+		obtainedIDs := make([]int, 0, len(ids))
+		for _, todo := range append(firstPage, lastPage...) {
+			obtainedIDs = append(obtainedIDs, todo.ID)
+		}
+
+		require.Equal(t, []int{ids[2], ids[0], ids[1]}, obtainedIDs)
+	})
+
+	t.Run("MoveRebalancesExhaustedGaps", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		ids := saveTodos(t, repository, 3)
+
+		// When
+		// Halving the gap between the first two todos eventually leaves no room between them.
+		for i := range 15 {
+			movedID := ids[1+i%2]
+
+			_, err := repository.Move(ctx, domain.TodoMove{ID: movedID, UserID: UserID, AfterID: ids[0]})
+			require.NoError(t, err)
+		}
+
+		// Then
+		requireOrder(t, repository, ids[0], ids[1], ids[2])
+	})
+
+	t.Run("MoveFailsDueToStaleVersion", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		ids := saveTodos(t, repository, 2)
+
+		// When
+		_, err := repository.Move(ctx, domain.TodoMove{ID: ids[1], UserID: UserID, BeforeID: ids[0], Version: 2})
+
+		// Then
+		require.ErrorIs(t, err, domain.ErrVersionMismatch)
+
+		requireOrder(t, repository, ids[0], ids[1])
+	})
+
+	t.Run("MoveFailsDueToTodoOfOtherUser", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		ids := saveTodos(t, repository, 2)
+
+		otherID, err := repository.Save(ctx, domain.Todo{Title: "Other", Description: "Todo", UserID: OtherUserID})
+		require.NoError(t, err)
+
+		// When
+		_, err = repository.Move(ctx, domain.TodoMove{ID: ids[1], UserID: UserID, BeforeID: otherID})
+
+		// Then
+		require.ErrorIs(t, err, domain.ErrNotFound)
+
+		requireOrder(t, repository, ids[0], ids[1])
+	})
+
+	t.Run("MoveFailsDueToSameNeighbour", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		ids := saveTodos(t, repository, 2)
+
+		// When
+		_, err := repository.Move(ctx, domain.TodoMove{ID: ids[1], UserID: UserID, BeforeID: ids[1]})
+
+		// Then
+		require.ErrorIs(t, err, domain.ErrInvalidMove)
+	})
+
+	t.Run("ConcurrentMovesKeepEveryTodo", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		const moves = 20
+
+		ids := saveTodos(t, repository, 4)
+
+		wg := &sync.WaitGroup{}
+
+		// When
+		for i := range moves {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				_, err := repository.Move(ctx, domain.TodoMove{ID: ids[i%4], UserID: UserID, AfterID: ids[(i+1)%4]})
+				require.NoError(t, err)
+			}()
+		}
+
+		wg.Wait()
+
+		// Then
+		todos, err := repository.GetAll(ctx, UserID)
+		require.NoError(t, err)
+		require.Len(t, todos, len(ids))
+
+		obtainedIDs := make([]int, 0, len(todos))
+		for _, todo := range withoutPositions(t, todos) {
+			obtainedIDs = append(obtainedIDs, todo.ID)
+		}
+
+		require.ElementsMatch(t, ids, obtainedIDs)
+	})
+}
+
+// saveTodos saves count todos of UserID, returning their IDs in the order they were saved.
+func saveTodos(t *testing.T, repository todo.Repository, count int) []int {
+	t.Helper()
+
+	ids := make([]int, 0, count)
+
+	for range count {
+		id, err := repository.Save(context.Background(), domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+// requireOrder asserts GetAll lists the todos of UserID in the expected order.
+func requireOrder(t *testing.T, repository todo.Repository, expectedIDs ...int) {
+	t.Helper()
+
+	todos, err := repository.GetAll(context.Background(), UserID)
+	require.NoError(t, err)
+
+	obtainedIDs := make([]int, 0, len(todos))
+	for _, todo := range withoutPositions(t, todos) {
+		obtainedIDs = append(obtainedIDs, todo.ID)
+	}
+
+	require.Equal(t, expectedIDs, obtainedIDs)
+}
@@ -0,0 +1,455 @@
+package todotest
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/recurrence"
+	"github.com/ferch5003/go-fiber-tutorial/internal/todo"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+// _firstDueAt is the due date of the first occurrence of the series of the suite.
+var _firstDueAt = time.Date(2024, time.January, 1, 9, 0, 0, 0, time.UTC)
+
+// runRecurrenceTests checks the occurrences of recurring todos are written like the service expects.
+func runRecurrenceTests(t *testing.T, newRepository RepositoryFactory) {
+	t.Run("SaveStartsSeries", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		// When
+		id := saveSeries(t, repository, "FREQ=DAILY")
+
+		obtainedTodo, err := repository.Get(ctx, id)
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, id, obtainedTodo.SeriesID)
+		requireDueAt(t, _firstDueAt, obtainedTodo)
+	})
+
+	t.Run("CompletedCreatesNextOccurrence", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id := saveSeries(t, repository, "FREQ=DAILY")
+
+		// When
+		completedTodo, err := repository.Completed(ctx, id, 1)
+
+		// Then
+		require.NoError(t, err)
+		require.True(t, completedTodo.Completed)
+		require.Equal(t, 2, completedTodo.Version)
+
+		todos, err := repository.GetAll(ctx, UserID)
+		require.NoError(t, err)
+		require.Len(t, todos, 2)
+
+		require.True(t, todos[0].Completed)
+		require.Equal(t, 2, todos[0].Version)
+		require.Zero(t, todos[0].NextID)
+
+		next := todos[1]
+		require.Equal(t, next.ID, completedTodo.NextID)
+		require.False(t, next.Completed)
+		require.Equal(t, 1, next.Version)
+		require.Equal(t, id, next.SeriesID)
+		require.Equal(t, todos[0].Recurrence, next.Recurrence)
+		require.Equal(t, "Lorem", next.Title)
+		requireDueAt(t, _firstDueAt.AddDate(0, 0, 1), next)
+	})
+
+	t.Run("CompletedCreatesNextOccurrenceOnTheWeekdayOfTheSeries", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		// Tuesday at 01:00 in UTC+02:00 is still Monday in UTC.
+		start := time.Date(2026, time.October, 20, 1, 0, 0, 0, time.FixedZone("", 2*60*60))
+		rule, err := recurrence.Normalize("FREQ=WEEKLY;BYDAY=TU", start)
+		require.NoError(t, err)
+
+		dueAt := start.UTC()
+
+		id, err := repository.Save(ctx, domain.Todo{
+			Title:      "Lorem",
+			UserID:     UserID,
+			DueAt:      &dueAt,
+			Recurrence: rule,
+		})
+		require.NoError(t, err)
+
+		// When
+		_, err = repository.Completed(ctx, id, 1)
+
+		// Then
+		require.NoError(t, err)
+
+		todos, err := repository.GetAll(ctx, UserID)
+		require.NoError(t, err)
+		require.Len(t, todos, 2)
+		requireDueAt(t, time.Date(2026, time.October, 26, 23, 0, 0, 0, time.UTC), todos[1])
+	})
+
+	t.Run("CompletedTwiceCreatesOneOccurrence", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id := saveSeries(t, repository, "FREQ=DAILY")
+
+		// When
+		_, err := repository.Completed(ctx, id, 0)
+		require.NoError(t, err)
+		_, err = repository.Completed(ctx, id, 0)
+		require.NoError(t, err)
+
+		// Then
+		todos, err := repository.GetAll(ctx, UserID)
+		require.NoError(t, err)
+		require.Len(t, todos, 2)
+	})
+
+	t.Run("CompletedLastOccurrenceEndsSeries", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id := saveSeries(t, repository, "FREQ=WEEKLY;COUNT=2")
+
+		_, err := repository.Completed(ctx, id, 0)
+		require.NoError(t, err)
+
+		todos, err := repository.GetAll(ctx, UserID)
+		require.NoError(t, err)
+		require.Len(t, todos, 2)
+		requireDueAt(t, _firstDueAt.AddDate(0, 0, 7), todos[1])
+
+		// When
+		_, err = repository.Completed(ctx, todos[1].ID, 0)
+
+		// Then
+		require.NoError(t, err)
+
+		todos, err = repository.GetAll(ctx, UserID)
+		require.NoError(t, err)
+		require.Len(t, todos, 2)
+	})
+
+	t.Run("SkipMovesOccurrenceToNextDate", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id := saveSeries(t, repository, "FREQ=WEEKLY")
+
+		// When
+		skippedTodo, err := repository.Skip(ctx, id, 1)
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, 2, skippedTodo.Version)
+		requireDueAt(t, _firstDueAt.AddDate(0, 0, 7), skippedTodo)
+
+		todos, err := repository.GetAll(ctx, UserID)
+		require.NoError(t, err)
+		require.Len(t, todos, 1)
+		requireDueAt(t, _firstDueAt.AddDate(0, 0, 7), todos[0])
+	})
+
+	t.Run("SkipFailsDueToTodoNotRecurring", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		// When
+		_, err = repository.Skip(ctx, id, 0)
+
+		// Then
+		require.ErrorIs(t, err, domain.ErrNotRecurring)
+	})
+
+	t.Run("SkipFailsDueToSeriesEnded", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id := saveSeries(t, repository, "FREQ=DAILY;COUNT=1")
+
+		// When
+		_, err := repository.Skip(ctx, id, 0)
+
+		// Then
+		require.ErrorIs(t, err, domain.ErrSeriesEnded)
+
+		obtainedTodo, err := repository.Get(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, 1, obtainedTodo.Version)
+	})
+
+	t.Run("EndSeriesStopsOccurrences", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id := saveSeries(t, repository, "FREQ=DAILY")
+
+		// When
+		endedTodo, err := repository.EndSeries(ctx, id, 1)
+
+		// Then
+		require.NoError(t, err)
+		require.Empty(t, endedTodo.Recurrence)
+		require.Equal(t, id, endedTodo.SeriesID)
+
+		_, err = repository.Completed(ctx, id, 0)
+		require.NoError(t, err)
+
+		todos, err := repository.GetAll(ctx, UserID)
+		require.NoError(t, err)
+		require.Len(t, todos, 1)
+	})
+
+	t.Run("UpdateThisOccurrenceDetachesIt", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id := saveSeries(t, repository, "FREQ=DAILY")
+
+		current, err := repository.Get(ctx, id)
+		require.NoError(t, err)
+
+		changed := current
+		changed.Title = "Changed"
+
+		// When
+		updatedTodo, err := repository.Update(ctx, changed, domain.TodoScopeThis)
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, "Changed", updatedTodo.Title)
+		require.Empty(t, updatedTodo.Recurrence)
+		require.Equal(t, 2, updatedTodo.Version)
+
+		todos, err := repository.GetAll(ctx, UserID)
+		require.NoError(t, err)
+		require.Len(t, todos, 2)
+
+		next := todos[1]
+		require.Equal(t, "Lorem", next.Title)
+		require.Equal(t, current.Recurrence, next.Recurrence)
+		require.Equal(t, id, next.SeriesID)
+		requireDueAt(t, _firstDueAt.AddDate(0, 0, 1), next)
+	})
+
+	t.Run("UpdateCompletingOccurrenceCreatesNextOne", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id := saveSeries(t, repository, "FREQ=DAILY")
+
+		current, err := repository.Get(ctx, id)
+		require.NoError(t, err)
+
+		changed := current
+		changed.Completed = true
+
+		// When
+		updatedTodo, err := repository.Update(ctx, changed, domain.TodoScopeFuture)
+
+		// Then
+		require.NoError(t, err)
+		require.True(t, updatedTodo.Completed)
+
+		todos, err := repository.GetAll(ctx, UserID)
+		require.NoError(t, err)
+		require.Len(t, todos, 2)
+
+		next := todos[1]
+		require.Equal(t, next.ID, updatedTodo.NextID)
+		require.False(t, next.Completed)
+		require.Equal(t, id, next.SeriesID)
+		requireDueAt(t, _firstDueAt.AddDate(0, 0, 1), next)
+	})
+
+	t.Run("BatchCompletingOccurrenceReturnsNextOne", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id := saveSeries(t, repository, "FREQ=DAILY")
+		operations := []domain.TodoOperation{{Action: domain.TodoComplete, ID: id}}
+
+		// When
+		results, err := repository.Batch(ctx, UserID, operations, false)
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, results, 1)
+		require.NoError(t, results[0].Err)
+
+		todos, err := repository.GetAll(ctx, UserID)
+		require.NoError(t, err)
+		require.Len(t, todos, 2)
+		require.Equal(t, todos[1].ID, results[0].NextID)
+		require.Equal(t, id, todos[1].SeriesID)
+	})
+
+	t.Run("UpdateReopeningOccurrenceStopsItRecurring", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id := saveSeries(t, repository, "FREQ=DAILY")
+		_, err := repository.Completed(ctx, id, 1)
+		require.NoError(t, err)
+
+		current, err := repository.Get(ctx, id)
+		require.NoError(t, err)
+
+		changed := current
+		changed.Completed = false
+
+		// When
+		updatedTodo, err := repository.Update(ctx, changed, domain.TodoScopeThis)
+
+		// Then
+		require.NoError(t, err)
+		require.False(t, updatedTodo.Completed)
+		require.Empty(t, updatedTodo.Recurrence)
+
+		// The series continues with the occurrence created when the todo was completed.
+		todos, err := repository.GetAll(ctx, UserID)
+		require.NoError(t, err)
+		require.Len(t, todos, 2)
+		require.True(t, todos[1].Recurs())
+	})
+
+	t.Run("UpdateThisOccurrenceFailsDueToRecurrenceChange", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id := saveSeries(t, repository, "FREQ=DAILY")
+
+		current, err := repository.Get(ctx, id)
+		require.NoError(t, err)
+
+		changed := current
+		changed.Recurrence = normalize(t, "FREQ=WEEKLY")
+
+		// When
+		_, err = repository.Update(ctx, changed, domain.TodoScopeThis)
+
+		// Then
+		require.ErrorIs(t, err, domain.ErrRecurrenceScope)
+	})
+
+	t.Run("UpdateFutureOccurrencesChangesSeries", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id := saveSeries(t, repository, "FREQ=DAILY")
+
+		current, err := repository.Get(ctx, id)
+		require.NoError(t, err)
+
+		detached := current
+		detached.Title = "Detached"
+
+		_, err = repository.Update(ctx, detached, domain.TodoScopeThis)
+		require.NoError(t, err)
+
+		current, err = repository.Get(ctx, id)
+		require.NoError(t, err)
+
+		changed := current
+		changed.Title = "Changed"
+		changed.Recurrence = normalize(t, "FREQ=WEEKLY")
+
+		// When
+		updatedTodo, err := repository.Update(ctx, changed, domain.TodoScopeFuture)
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, "Changed", updatedTodo.Title)
+		require.Empty(t, updatedTodo.Recurrence)
+
+		todos, err := repository.GetAll(ctx, UserID)
+		require.NoError(t, err)
+		require.Len(t, todos, 2)
+
+		next := todos[1]
+		require.Equal(t, "Changed", next.Title)
+		require.Equal(t, changed.Recurrence, next.Recurrence)
+		require.Equal(t, 2, next.Version)
+	})
+
+	t.Run("UpdateFailsDueToStaleVersion", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id := saveSeries(t, repository, "FREQ=DAILY")
+
+		current, err := repository.Get(ctx, id)
+		require.NoError(t, err)
+
+		changed := current
+		changed.Title = "Changed"
+		changed.Version = 2
+
+		// When
+		_, err = repository.Update(ctx, changed, domain.TodoScopeFuture)
+
+		// Then
+		require.ErrorIs(t, err, domain.ErrVersionMismatch)
+	})
+}
+
+// normalize returns the rule of a series starting at _firstDueAt.
+func normalize(t *testing.T, rule string) string {
+	t.Helper()
+
+	normalized, err := recurrence.Normalize(rule, _firstDueAt)
+	require.NoError(t, err)
+
+	return normalized
+}
+
+// saveSeries saves the first occurrence of a series of the rule, due at _firstDueAt, returning its ID.
+func saveSeries(t *testing.T, repository todo.Repository, rule string) int {
+	t.Helper()
+
+	dueAt := _firstDueAt
+
+	id, err := repository.Save(context.Background(), domain.Todo{
+		Title:       "Lorem",
+		Description: "Ipsum",
+		UserID:      UserID,
+		DueAt:       &dueAt,
+		Recurrence:  normalize(t, rule),
+	})
+	require.NoError(t, err)
+
+	return id
+}
+
+// requireDueAt asserts the todo is due at the expected time, whatever the location the storage returns.
+func requireDueAt(t *testing.T, expected time.Time, todo domain.Todo) {
+	t.Helper()
+
+	require.NotNil(t, todo.DueAt)
+	require.True(t, expected.Equal(*todo.DueAt), "expected due at %s, got %s", expected, *todo.DueAt)
+}
@@ -0,0 +1,146 @@
+package todotest
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/todo"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// SearcherFactory creates an empty repository for every test, along with the searcher of its todos.
+// Implementations backed by a database must make sure the users UserID and OtherUserID exist.
+type SearcherFactory func(t *testing.T) (todo.Repository, todo.Searcher)
+
+// RunSearcherTests runs the conformance suite against the searchers created by newSearcher. Every test
+// stores todos that do not match, as databases may ignore terms found in most rows.
+func RunSearcherTests(t *testing.T, newSearcher SearcherFactory) {
+	t.Run("SearchMatchesTodosOfTheUser", func(t *testing.T) {
+		// Given
+		repository, searcher := newSearcher(t)
+		ctx := context.Background()
+
+		groceriesID := saveTodo(t, repository, "Buy groceries", "Milk and bread", UserID)
+		saveTodo(t, repository, "Buy groceries", "Eggs and butter", OtherUserID)
+		saveTodo(t, repository, "Walk dog", "Around the park", UserID)
+		saveTodo(t, repository, "Call plumber", "Leaking kitchen sink", UserID)
+
+		// When
+		page, err := searcher.Search(ctx, domain.TodoSearch{UserID: UserID, Query: "groceries", Page: 1, PageSize: 10})
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, 1, page.Total)
+		require.Equal(t, 1, page.Page)
+		require.Equal(t, 10, page.PageSize)
+		require.Len(t, page.Hits, 1)
+		require.Equal(t, groceriesID, page.Hits[0].Todo.ID)
+		require.Equal(t, UserID, page.Hits[0].Todo.UserID)
+		require.Positive(t, page.Hits[0].Score)
+		require.Equal(t, "Buy <mark>groceries</mark>", page.Hits[0].Snippet)
+	})
+
+	t.Run("SearchHighlightsDescription", func(t *testing.T) {
+		// Given
+		repository, searcher := newSearcher(t)
+		ctx := context.Background()
+
+		id := saveTodo(t, repository, "Fix bug", "<b>Urgent</b> crash on startup", UserID)
+		saveTodo(t, repository, "Walk dog", "Around the park", UserID)
+		saveTodo(t, repository, "Call plumber", "Leaking kitchen sink", UserID)
+
+		// When
+		page, err := searcher.Search(ctx, domain.TodoSearch{UserID: UserID, Query: "URGENT", Page: 1, PageSize: 10})
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, page.Hits, 1)
+		require.Equal(t, id, page.Hits[0].Todo.ID)
+		require.Equal(t, "&lt;b&gt;<mark>Urgent</mark>&lt;/b&gt; crash on startup", page.Hits[0].Snippet)
+	})
+
+	t.Run("SearchRanksTodosMatchingMoreTermsFirst", func(t *testing.T) {
+		// Given
+		repository, searcher := newSearcher(t)
+		ctx := context.Background()
+
+		saveTodo(t, repository, "Paint kitchen", "Blue walls", UserID)
+		fenceID := saveTodo(t, repository, "Paint fence", "White paint for the garden fence", UserID)
+		saveTodo(t, repository, "Water plants", "Garden roses", UserID)
+		saveTodo(t, repository, "Call plumber", "Leaking kitchen sink", UserID)
+		saveTodo(t, repository, "Walk dog", "Around the park", UserID)
+
+		// When
+		page, err := searcher.Search(ctx, domain.TodoSearch{UserID: UserID, Query: "paint garden fence", Page: 1, PageSize: 10})
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, 3, page.Total)
+		require.Len(t, page.Hits, 3)
+		require.Equal(t, fenceID, page.Hits[0].Todo.ID)
+
+		for i := 1; i < len(page.Hits); i++ {
+			require.GreaterOrEqual(t, page.Hits[i-1].Score, page.Hits[i].Score)
+		}
+	})
+
+	t.Run("SearchPaginatesHits", func(t *testing.T) {
+		// Given
+		repository, searcher := newSearcher(t)
+		ctx := context.Background()
+
+		reportIDs := []int{
+			saveTodo(t, repository, "Write report", "Quarterly numbers", UserID),
+			saveTodo(t, repository, "Review report", "Annual budget", UserID),
+			saveTodo(t, repository, "Send report", "Board members", UserID),
+		}
+		saveTodo(t, repository, "Walk dog", "Around the park", UserID)
+		saveTodo(t, repository, "Call plumber", "Leaking kitchen sink", UserID)
+		saveTodo(t, repository, "Water plants", "Garden roses", UserID)
+
+		// When
+		foundIDs := make([]int, 0)
+
+		for pageNumber := 1; pageNumber <= 3; pageNumber++ {
+			page, err := searcher.Search(ctx, domain.TodoSearch{UserID: UserID, Query: "report", Page: pageNumber, PageSize: 2})
+			require.NoError(t, err)
+
+			// Then
+			require.Equal(t, 3, page.Total)
+			require.Equal(t, pageNumber, page.Page)
+			require.Len(t, page.Hits, min(2, max(0, 3-(pageNumber-1)*2)))
+
+			for _, hit := range page.Hits {
+				foundIDs = append(foundIDs, hit.Todo.ID)
+			}
+		}
+
+		require.ElementsMatch(t, reportIDs, foundIDs)
+	})
+
+	t.Run("SearchWithoutTermsFindsNothing", func(t *testing.T) {
+		// Given
+		repository, searcher := newSearcher(t)
+		ctx := context.Background()
+
+		saveTodo(t, repository, "Walk dog", "Around the park", UserID)
+
+		// When
+		page, err := searcher.Search(ctx, domain.TodoSearch{UserID: UserID, Query: " !? ", Page: 1, PageSize: 10})
+
+		// Then
+		require.NoError(t, err)
+		require.Zero(t, page.Total)
+		require.NotNil(t, page.Hits)
+		require.Empty(t, page.Hits)
+	})
+}
+
+func saveTodo(t *testing.T, repository todo.Repository, title, description string, userID int) int {
+	t.Helper()
+
+	id, err := repository.Save(context.Background(), domain.Todo{Title: title, Description: description, UserID: userID})
+	require.NoError(t, err)
+
+	return id
+}
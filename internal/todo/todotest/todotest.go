@@ -0,0 +1,544 @@
+// Package todotest provides a conformance suite that every todo.Repository implementation must pass.
+package todotest
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/data"
+	"github.com/ferch5003/go-fiber-tutorial/internal/todo"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+)
+
+const (
+	// UserID is an existing user that todos can belong to.
+	UserID = 1
+
+	// OtherUserID is a second existing user, to check todos are not mixed between users.
+	OtherUserID = 2
+)
+
+// RepositoryFactory creates an empty repository for every test. Implementations backed by a database
+// must make sure the users UserID and OtherUserID exist.
+type RepositoryFactory func(t *testing.T) todo.Repository
+
+// RunRepositoryTests runs the conformance suite against the repositories created by newRepository.
+func RunRepositoryTests(t *testing.T, newRepository RepositoryFactory) {
+	t.Run("SaveAndGet", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		newTodo := domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID}
+
+		// When
+		id, err := repository.Save(ctx, newTodo)
+		require.NoError(t, err)
+
+		obtainedTodo, err := repository.Get(ctx, id)
+
+		// Then
+		require.NoError(t, err)
+		require.Positive(t, id)
+
+		require.Positive(t, obtainedTodo.Position)
+
+		newTodo.ID = id
+		newTodo.Version = 1
+		newTodo.Position = obtainedTodo.Position
+		require.Equal(t, newTodo, obtainedTodo)
+	})
+
+	t.Run("SaveAssignsIncreasingIDs", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		// When
+		firstID, err := repository.Save(ctx, domain.Todo{Title: "First", Description: "Todo", UserID: UserID})
+		require.NoError(t, err)
+
+		secondID, err := repository.Save(ctx, domain.Todo{Title: "Second", Description: "Todo", UserID: UserID})
+		require.NoError(t, err)
+
+		// Then
+		require.Greater(t, secondID, firstID)
+	})
+
+	t.Run("SaveIgnoresCompleted", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		// When
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", Completed: true, UserID: UserID})
+		require.NoError(t, err)
+
+		obtainedTodo, err := repository.Get(ctx, id)
+
+		// Then
+		require.NoError(t, err)
+		require.False(t, obtainedTodo.Completed)
+	})
+
+	t.Run("GetFailsDueToMissingTodo", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+
+		// When
+		obtainedTodo, err := repository.Get(context.Background(), 1000)
+
+		// Then
+		require.Error(t, err)
+		require.Empty(t, obtainedTodo)
+	})
+
+	t.Run("GetAllOnlyReturnsTodosOfTheUser", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		firstID, err := repository.Save(ctx, domain.Todo{Title: "First", Description: "Todo", UserID: UserID})
+		require.NoError(t, err)
+
+		_, err = repository.Save(ctx, domain.Todo{Title: "Other", Description: "Todo", UserID: OtherUserID})
+		require.NoError(t, err)
+
+		secondID, err := repository.Save(ctx, domain.Todo{Title: "Second", Description: "Todo", UserID: UserID})
+		require.NoError(t, err)
+
+		// When
+		todos, err := repository.GetAll(ctx, UserID)
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, []domain.Todo{
+			{ID: firstID, Title: "First", Description: "Todo", UserID: UserID, Version: 1},
+			{ID: secondID, Title: "Second", Description: "Todo", UserID: UserID, Version: 1},
+		}, withoutPositions(t, todos))
+	})
+
+	t.Run("GetAllReturnsEmptyListWithoutTodos", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+
+		// When
+		todos, err := repository.GetAll(context.Background(), UserID)
+
+		// Then
+		require.NoError(t, err)
+		require.NotNil(t, todos)
+		require.Empty(t, todos)
+	})
+
+	t.Run("Completed", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		// When
+		_, err = repository.Completed(ctx, id, 0)
+		require.NoError(t, err)
+
+		obtainedTodo, err := repository.Get(ctx, id)
+
+		// Then
+		require.NoError(t, err)
+		require.True(t, obtainedTodo.Completed)
+		require.Equal(t, 2, obtainedTodo.Version)
+	})
+
+	t.Run("CompletedWithCurrentVersion", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		// When
+		_, err = repository.Completed(ctx, id, 1)
+		require.NoError(t, err)
+
+		obtainedTodo, err := repository.Get(ctx, id)
+
+		// Then
+		require.NoError(t, err)
+		require.True(t, obtainedTodo.Completed)
+		require.Equal(t, 2, obtainedTodo.Version)
+	})
+
+	t.Run("CompletedFailsDueToStaleVersion", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		_, err = repository.Completed(ctx, id, 1)
+		require.NoError(t, err)
+
+		// When
+		_, err = repository.Completed(ctx, id, 1)
+
+		// Then
+		require.ErrorIs(t, err, domain.ErrVersionMismatch)
+
+		obtainedTodo, err := repository.Get(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, 2, obtainedTodo.Version)
+	})
+
+	t.Run("UpdateClearsDescription", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		current, err := repository.Get(ctx, id)
+		require.NoError(t, err)
+
+		changed := current
+		changed.Description = ""
+
+		// When
+		_, err = repository.Update(ctx, changed, domain.TodoScopeThis)
+		require.NoError(t, err)
+
+		obtainedTodo, err := repository.Get(ctx, id)
+
+		// Then
+		require.NoError(t, err)
+		require.Empty(t, obtainedTodo.Description)
+		require.Equal(t, "Lorem", obtainedTodo.Title)
+		require.Equal(t, 2, obtainedTodo.Version)
+	})
+
+	t.Run("UpdateReopensCompletedTodo", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+		_, err = repository.Completed(ctx, id, 1)
+		require.NoError(t, err)
+
+		current, err := repository.Get(ctx, id)
+		require.NoError(t, err)
+
+		changed := current
+		changed.Completed = false
+
+		// When
+		_, err = repository.Update(ctx, changed, domain.TodoScopeThis)
+		require.NoError(t, err)
+
+		obtainedTodo, err := repository.Get(ctx, id)
+
+		// Then
+		require.NoError(t, err)
+		require.False(t, obtainedTodo.Completed)
+		require.Equal(t, 3, obtainedTodo.Version)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		// When
+		err = repository.Delete(ctx, id, 0)
+		require.NoError(t, err)
+
+		_, err = repository.Get(ctx, id)
+
+		// Then
+		require.Error(t, err)
+	})
+
+	t.Run("DeleteFailsDueToStaleVersion", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		_, err = repository.Completed(ctx, id, 0)
+		require.NoError(t, err)
+
+		// When
+		err = repository.Delete(ctx, id, 1)
+
+		// Then
+		require.ErrorIs(t, err, domain.ErrVersionMismatch)
+
+		_, err = repository.Get(ctx, id)
+		require.NoError(t, err)
+	})
+
+	t.Run("DeleteFailsDueToMissingTodo", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+
+		// When
+		err := repository.Delete(context.Background(), 1000, 1)
+
+		// Then
+		require.Error(t, err)
+		require.NotErrorIs(t, err, domain.ErrVersionMismatch)
+	})
+
+	t.Run("ConcurrentSaves", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		const saves = 20
+
+		wg := &sync.WaitGroup{}
+		ids := make(chan int, saves)
+
+		// When
+		for range saves {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+				require.NoError(t, err)
+
+				ids <- id
+			}()
+		}
+
+		wg.Wait()
+		close(ids)
+
+		// Then
+		uniqueIDs := make(map[int]struct{})
+		for id := range ids {
+			uniqueIDs[id] = struct{}{}
+		}
+
+		todos, err := repository.GetAll(ctx, UserID)
+		require.NoError(t, err)
+		require.Len(t, uniqueIDs, saves)
+		require.Len(t, todos, saves)
+	})
+
+	t.Run("BatchAppliesEveryOperation", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		updatedID, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		completedID, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		deletedID, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		operations := []domain.TodoOperation{
+			{Action: domain.TodoCreate, Title: "Created", Description: data.Some("Todo")},
+			{Action: domain.TodoUpdate, ID: updatedID, Version: 1, Title: "Updated"},
+			{Action: domain.TodoComplete, ID: completedID},
+			{Action: domain.TodoDelete, ID: deletedID, Version: 1},
+		}
+
+		// When
+		results, err := repository.Batch(ctx, UserID, operations, true)
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, results, len(operations))
+
+		for i, result := range results {
+			require.NoError(t, result.Err)
+			require.Equal(t, i, result.Index)
+			require.Equal(t, operations[i].Action, result.Action)
+		}
+
+		require.Equal(t, 1, results[0].Version)
+		require.Equal(t, 2, results[1].Version)
+		require.Equal(t, 2, results[2].Version)
+		require.Zero(t, results[3].Version)
+
+		todos, err := repository.GetAll(ctx, UserID)
+		require.NoError(t, err)
+		require.Equal(t, []domain.Todo{
+			{ID: updatedID, Title: "Updated", Description: "Ipsum", UserID: UserID, Version: 2},
+			{ID: completedID, Title: "Lorem", Description: "Ipsum", Completed: true, UserID: UserID, Version: 2},
+			{ID: results[0].ID, Title: "Created", Description: "Todo", UserID: UserID, Version: 1},
+		}, withoutPositions(t, todos))
+	})
+
+	t.Run("BatchChainsOperationsOnSameTodo", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		operations := []domain.TodoOperation{
+			{Action: domain.TodoUpdate, ID: id, Version: 1, Description: data.Some("Dolor")},
+			{Action: domain.TodoComplete, ID: id, Version: 2},
+			{Action: domain.TodoDelete, ID: id, Version: 3},
+			{Action: domain.TodoComplete, ID: id},
+		}
+
+		// When
+		results, err := repository.Batch(ctx, UserID, operations, false)
+
+		// Then
+		require.NoError(t, err)
+		require.NoError(t, results[0].Err)
+		require.NoError(t, results[1].Err)
+		require.NoError(t, results[2].Err)
+		require.ErrorIs(t, results[3].Err, domain.ErrNotFound)
+
+		_, err = repository.Get(ctx, id)
+		require.Error(t, err)
+	})
+
+	t.Run("BatchUpdateClearsDescriptionAndReopensTodo", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+		_, err = repository.Completed(ctx, id, 1)
+		require.NoError(t, err)
+
+		operations := []domain.TodoOperation{
+			{Action: domain.TodoUpdate, ID: id, Version: 2, Description: data.Some(""), Completed: data.Some(false)},
+			{Action: domain.TodoUpdate, ID: id, Version: 3, Title: "Dolor"},
+		}
+
+		// When
+		results, err := repository.Batch(ctx, UserID, operations, true)
+
+		// Then
+		require.NoError(t, err)
+		require.NoError(t, results[0].Err)
+		require.NoError(t, results[1].Err)
+		require.Equal(t, 4, results[1].Version)
+
+		obtainedTodo, err := repository.Get(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, "Dolor", obtainedTodo.Title)
+		require.Empty(t, obtainedTodo.Description)
+		require.False(t, obtainedTodo.Completed)
+		require.Equal(t, 4, obtainedTodo.Version)
+	})
+
+	t.Run("BatchAtomicRollsBackEveryOperation", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		operations := []domain.TodoOperation{
+			{Action: domain.TodoCreate, Title: "Created", Description: data.Some("Todo")},
+			{Action: domain.TodoComplete, ID: id},
+			{Action: domain.TodoDelete, ID: 1000},
+			{Action: domain.TodoDelete, ID: id},
+		}
+
+		// When
+		results, err := repository.Batch(ctx, UserID, operations, true)
+
+		// Then
+		require.NoError(t, err)
+		require.ErrorIs(t, results[0].Err, domain.ErrBatchAborted)
+		require.Zero(t, results[0].ID)
+		require.ErrorIs(t, results[1].Err, domain.ErrBatchAborted)
+		require.ErrorIs(t, results[2].Err, domain.ErrNotFound)
+		require.ErrorIs(t, results[3].Err, domain.ErrBatchAborted)
+
+		todos, err := repository.GetAll(ctx, UserID)
+		require.NoError(t, err)
+		require.Equal(t, []domain.Todo{
+			{ID: id, Title: "Lorem", Description: "Ipsum", UserID: UserID, Version: 1},
+		}, withoutPositions(t, todos))
+	})
+
+	t.Run("BatchBestEffortSkipsFailingOperations", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		staleID, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: UserID})
+		require.NoError(t, err)
+
+		otherID, err := repository.Save(ctx, domain.Todo{Title: "Lorem", Description: "Ipsum", UserID: OtherUserID})
+		require.NoError(t, err)
+
+		operations := []domain.TodoOperation{
+			{Action: domain.TodoComplete, ID: staleID, Version: 2},
+			{Action: domain.TodoDelete, ID: otherID},
+			{Action: domain.TodoCreate, Title: "Created", Description: data.Some("Todo")},
+		}
+
+		// When
+		results, err := repository.Batch(ctx, UserID, operations, false)
+
+		// Then
+		require.NoError(t, err)
+		require.ErrorIs(t, results[0].Err, domain.ErrVersionMismatch)
+		require.ErrorIs(t, results[1].Err, domain.ErrNotOwned)
+		require.NoError(t, results[2].Err)
+
+		todos, err := repository.GetAll(ctx, UserID)
+		require.NoError(t, err)
+		require.Equal(t, []domain.Todo{
+			{ID: staleID, Title: "Lorem", Description: "Ipsum", UserID: UserID, Version: 1},
+			{ID: results[2].ID, Title: "Created", Description: "Todo", UserID: UserID, Version: 1},
+		}, withoutPositions(t, todos))
+
+		otherTodos, err := repository.GetAll(ctx, OtherUserID)
+		require.NoError(t, err)
+		require.Len(t, otherTodos, 1)
+	})
+
+	runRecurrenceTests(t, newRepository)
+	runPositionTests(t, newRepository)
+	runImportTests(t, newRepository)
+}
+
+// withoutPositions asserts the todos are ordered by strictly increasing positions, returning them without
+// their positions to compare the rest.
+func withoutPositions(t *testing.T, todos []domain.Todo) []domain.Todo {
+	t.Helper()
+
+	unpositioned := make([]domain.Todo, 0, len(todos))
+
+	for i, todo := range todos {
+		if i > 0 {
+			require.Greater(t, todo.Position, todos[i-1].Position)
+		}
+
+		todo.Position = 0
+		unpositioned = append(unpositioned, todo)
+	}
+
+	return unpositioned
+}
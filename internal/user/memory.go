@@ -0,0 +1,210 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox"
+	"slices"
+	"sync"
+)
+
+var errDuplicatedEmail = errors.New("duplicated email")
+
+type memoryRepository struct {
+	mutex    *sync.RWMutex
+	lastID   int
+	users    map[int]domain.User
+	events   audit.Repository
+	messages outbox.Repository
+}
+
+// NewMemoryRepository creates a thread-safe Repository that keeps users in memory, appending the audit
+// events of its writes to events and their outbox messages to messages. It behaves like the MySQL
+// Repository, so it can replace it in development and tests.
+func NewMemoryRepository(events audit.Repository, messages outbox.Repository) Repository {
+	return &memoryRepository{
+		mutex:    &sync.RWMutex{},
+		users:    make(map[int]domain.User),
+		events:   events,
+		messages: messages,
+	}
+}
+
+func (r *memoryRepository) GetAll(_ context.Context) ([]domain.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	users := make([]domain.User, 0, len(r.users))
+
+	for _, user := range r.users {
+		users = append(users, withoutPassword(user))
+	}
+
+	slices.SortFunc(users, func(a, b domain.User) int {
+		return a.ID - b.ID
+	})
+
+	return users, nil
+}
+
+func (r *memoryRepository) Get(_ context.Context, id int) (domain.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return domain.User{}, sql.ErrNoRows
+	}
+
+	return withoutPassword(user), nil
+}
+
+func (r *memoryRepository) GetByEmail(_ context.Context, email string) (domain.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+
+	return domain.User{}, sql.ErrNoRows
+}
+
+func (r *memoryRepository) Save(ctx context.Context, user domain.User) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.emailTaken(user.Email, 0) {
+		return 0, errDuplicatedEmail
+	}
+
+	user.ID = r.lastID + 1
+	user.Version = 1
+
+	if err := r.record(ctx, domain.AuditUserCreated, user.ID, nil, &user); err != nil {
+		return 0, err
+	}
+
+	r.lastID = user.ID
+	r.users[user.ID] = user
+
+	return user.ID, nil
+}
+
+func (r *memoryRepository) Update(ctx context.Context, user domain.User) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Like the dynamic UPDATE statement, only non-zero names and email are changed.
+	if user.FirstName == "" && user.LastName == "" && user.Email == "" {
+		return errors.New("no rows is going to be updated. User is empty")
+	}
+
+	if user.Email != "" && r.emailTaken(user.Email, user.ID) {
+		return errDuplicatedEmail
+	}
+
+	currentUser, ok := r.users[user.ID]
+	if !ok {
+		return nil
+	}
+
+	if user.Version != 0 && currentUser.Version != user.Version {
+		return domain.ErrVersionMismatch
+	}
+
+	storedUser := currentUser
+
+	if user.FirstName != "" {
+		storedUser.FirstName = user.FirstName
+	}
+
+	if user.LastName != "" {
+		storedUser.LastName = user.LastName
+	}
+
+	if user.Email != "" {
+		storedUser.Email = user.Email
+	}
+
+	storedUser.Version++
+
+	if err := r.record(ctx, domain.AuditUserUpdated, user.ID, &currentUser, &storedUser); err != nil {
+		return err
+	}
+
+	r.users[user.ID] = storedUser
+
+	return nil
+}
+
+func (r *memoryRepository) Delete(ctx context.Context, id int, version int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	storedUser, ok := r.users[id]
+	if !ok {
+		return errors.New("no rows affected")
+	}
+
+	if version != 0 && storedUser.Version != version {
+		return domain.ErrVersionMismatch
+	}
+
+	if err := r.record(ctx, domain.AuditUserDeleted, id, &storedUser, nil); err != nil {
+		return err
+	}
+
+	delete(r.users, id)
+
+	return nil
+}
+
+// record appends the audit event and the outbox message of the action on the user, before the user is
+// written like in the transaction of the MySQL Repository. before is nil for a created user, and after for
+// a deleted one.
+func (r *memoryRepository) record(
+	ctx context.Context,
+	action string,
+	id int,
+	before *domain.User,
+	after *domain.User) error {
+	event, err := audit.NewEvent(ctx, action, domain.AuditUser, id, before, after)
+	if err != nil {
+		return err
+	}
+
+	message, err := newMessage(action, id, before, after)
+	if err != nil {
+		return err
+	}
+
+	if err := r.events.Append(ctx, event); err != nil {
+		return err
+	}
+
+	return r.messages.Append(ctx, message)
+}
+
+// emailTaken reports if another user than the one with exceptID has the email. Callers hold the lock.
+func (r *memoryRepository) emailTaken(email string, exceptID int) bool {
+	for _, user := range r.users {
+		if user.Email == email && user.ID != exceptID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// withoutPassword mirrors the queries that do not select the password.
+func withoutPassword(user domain.User) domain.User {
+	user.Password = ""
+
+	return user
+}
@@ -0,0 +1,23 @@
+package user_test
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox"
+	"github.com/ferch5003/go-fiber-tutorial/internal/user"
+	"github.com/ferch5003/go-fiber-tutorial/internal/user/usertest"
+	"testing"
+)
+
+func TestMemoryRepository_Conformance(t *testing.T) {
+	usertest.RunRepositoryTests(t, func(t *testing.T) user.Repository {
+		return user.NewMemoryRepository(audit.NewMemoryRepository(), outbox.NewMemoryRepository())
+	})
+}
+
+func TestMemoryRepository_OutboxConformance(t *testing.T) {
+	usertest.RunOutboxTests(t, func(t *testing.T) (user.Repository, outbox.Repository) {
+		messages := outbox.NewMemoryRepository()
+
+		return user.NewMemoryRepository(audit.NewMemoryRepository(), messages), messages
+	})
+}
@@ -2,20 +2,26 @@ package user
 
 import (
 	"context"
+	stdsql "database/sql"
 	"errors"
-	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
 	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox"
 	"github.com/ferch5003/go-fiber-tutorial/internal/platform/sql"
 	"github.com/jmoiron/sqlx"
 )
 
 const (
-	_getAllUsersStmt    = `SELECT id, first_name, last_name, email FROM users;`
-	_getUserByIDStmt    = `SELECT id, first_name, last_name, email FROM users WHERE id = ?;`
-	_getUserByEmailStmt = `SELECT id, first_name, last_name, email, password FROM users WHERE email = ?;`
-	_saveUserStmt       = `INSERT INTO users (first_name, last_name, email, password) VALUES (?, ?, ?, ?);`
-	_updateUserStmt     = `UPDATE users SET %s WHERE id = ?;`
-	_deleteUserStmt     = `DELETE FROM users WHERE id = ?;`
+	_usersTable   = "users"
+	_saveUserStmt = `INSERT INTO users (first_name, last_name, email, password) VALUES (?, ?, ?, ?);`
+)
+
+var (
+	// _userColumns are the columns of a User, but the password.
+	_userColumns = []string{"id", "first_name", "last_name", "email", "version"}
+
+	// _credentialsColumns are the columns of a User, with the password.
+	_credentialsColumns = []string{"id", "first_name", "last_name", "email", "password", "version"}
 )
 
 type Repository interface {
@@ -31,25 +37,36 @@ type Repository interface {
 	// Save a new User into the database.
 	Save(ctx context.Context, user domain.User) (int, error)
 
-	// Update data from the User.
+	// Update data from the User. A non-zero user.Version must match the version of the User, otherwise
+	// domain.ErrVersionMismatch is returned.
 	Update(ctx context.Context, user domain.User) error
 
-	// Delete the User from the database.
-	Delete(ctx context.Context, id int) error
+	// Delete the User from the database. A non-zero version must match the version of the User,
+	// otherwise domain.ErrVersionMismatch is returned.
+	Delete(ctx context.Context, id int, version int) error
 }
 
 type repository struct {
-	conn *sqlx.DB
+	conn    *sqlx.DB
+	dialect sql.Dialect
 }
 
 func NewRepository(conn *sqlx.DB) Repository {
-	return &repository{conn: conn}
+	return &repository{
+		conn:    conn,
+		dialect: sql.NewDialect(conn.DriverName()),
+	}
 }
 
 func (r *repository) GetAll(ctx context.Context) ([]domain.User, error) {
 	users := make([]domain.User, 0)
 
-	if err := r.conn.SelectContext(ctx, &users, _getAllUsersStmt); err != nil {
+	query, args, err := sql.Select(_userColumns...).From(_usersTable).OrderBy(sql.Asc("id")).Build(r.dialect)
+	if err != nil {
+		return make([]domain.User, 0), err
+	}
+
+	if err := sql.ConnFrom(ctx, r.conn).SelectContext(ctx, &users, query, args...); err != nil {
 		return make([]domain.User, 0), err
 	}
 
@@ -59,7 +76,12 @@ func (r *repository) GetAll(ctx context.Context) ([]domain.User, error) {
 func (r *repository) Get(ctx context.Context, id int) (domain.User, error) {
 	var user domain.User
 
-	if err := r.conn.GetContext(ctx, &user, _getUserByIDStmt, id); err != nil {
+	query, args, err := sql.Select(_userColumns...).From(_usersTable).Where(sql.Eq("id", id)).Build(r.dialect)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	if err := sql.ConnFrom(ctx, r.conn).GetContext(ctx, &user, query, args...); err != nil {
 		return domain.User{}, err
 	}
 
@@ -69,7 +91,12 @@ func (r *repository) Get(ctx context.Context, id int) (domain.User, error) {
 func (r *repository) GetByEmail(ctx context.Context, email string) (domain.User, error) {
 	var user domain.User
 
-	if err := r.conn.GetContext(ctx, &user, _getUserByEmailStmt, email); err != nil {
+	query, args, err := sql.Select(_credentialsColumns...).From(_usersTable).Where(sql.Eq("email", email)).Build(r.dialect)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	if err := sql.ConnFrom(ctx, r.conn).GetContext(ctx, &user, query, args...); err != nil {
 		return domain.User{}, err
 	}
 
@@ -77,12 +104,12 @@ func (r *repository) GetByEmail(ctx context.Context, email string) (domain.User,
 }
 
 func (r *repository) Save(ctx context.Context, user domain.User) (int, error) {
-	tx, err := r.conn.Beginx()
+	tx, err := sql.Begin(ctx, r.conn)
 	if err != nil {
 		return 0, err
 	}
 
-	stmt, err := tx.PreparexContext(ctx, _saveUserStmt)
+	stmt, err := tx.PreparexContext(ctx, r.dialect.Rebind(r.dialect.InsertQuery(_saveUserStmt)))
 	if err != nil {
 		return 0, err
 	}
@@ -91,7 +118,7 @@ func (r *repository) Save(ctx context.Context, user domain.User) (int, error) {
 		err = stmt.Close()
 	}()
 
-	res, err := stmt.ExecContext(ctx, user.FirstName, user.LastName, user.Email, user.Password)
+	id, err := r.dialect.InsertedID(ctx, stmt, user.FirstName, user.LastName, user.Email, user.Password)
 	if err != nil {
 		if rollbackErr := tx.Rollback(); rollbackErr != nil {
 			return 0, rollbackErr
@@ -100,33 +127,55 @@ func (r *repository) Save(ctx context.Context, user domain.User) (int, error) {
 		return 0, err
 	}
 
-	if err = tx.Commit(); err != nil {
+	user.ID = id
+	user.Version = 1
+
+	if err := recordUser(ctx, tx, domain.AuditUserCreated, id, nil, &user); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return 0, rollbackErr
+		}
+
 		return 0, err
 	}
 
-	id, err := res.LastInsertId()
-	if err != nil {
+	if err = tx.Commit(); err != nil {
 		return 0, err
 	}
 
-	return int(id), err
+	return id, err
 }
 
 func (r *repository) Update(ctx context.Context, user domain.User) error {
-	columns := []string{"first_name", "last_name", "email"}
+	// Every field of a user is required, so the empty ones are the fields that are not changed.
+	update := sql.Update(_usersTable).
+		Set("first_name", sql.NonZero(user.FirstName)).
+		Set("last_name", sql.NonZero(user.LastName)).
+		Set("email", sql.NonZero(user.Email))
+	if update.Empty() {
+		return errors.New("no rows is going to be updated. User is empty")
+	}
 
-	tx, err := r.conn.Beginx()
+	query, args, err := update.
+		SetExpr("version", "version + 1").
+		Where(sql.Eq("id", user.ID), matchesVersion(user.Version)).
+		Build(r.dialect)
 	if err != nil {
 		return err
 	}
 
-	dynamicQuery, values := sql.DynamicQuery(columns, user)
-	if len(dynamicQuery) <= 0 {
-		return errors.New("no rows is going to be updated. User is empty")
+	tx, err := sql.Begin(ctx, r.conn)
+	if err != nil {
+		return err
 	}
 
-	values = append(values, user.ID)
-	query := fmt.Sprintf(_updateUserStmt, dynamicQuery)
+	current, err := r.lock(ctx, tx, user.ID)
+	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return rollbackErr
+		}
+
+		return err
+	}
 
 	stmt, err := tx.PreparexContext(ctx, query)
 	if err != nil {
@@ -137,7 +186,7 @@ func (r *repository) Update(ctx context.Context, user domain.User) error {
 		err = stmt.Close()
 	}()
 
-	res, err := stmt.ExecContext(ctx, values...)
+	res, err := stmt.ExecContext(ctx, args...)
 	if err != nil {
 		if rollbackErr := tx.Rollback(); rollbackErr != nil {
 			return rollbackErr
@@ -146,25 +195,63 @@ func (r *repository) Update(ctx context.Context, user domain.User) error {
 		return err
 	}
 
-	if err = tx.Commit(); err != nil {
+	if err := r.checkVersion(ctx, tx, res, user.ID, user.Version); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return rollbackErr
+		}
+
 		return err
 	}
 
-	_, err = res.RowsAffected()
+	if current.ID != 0 {
+		updated := current
+		if user.FirstName != "" {
+			updated.FirstName = user.FirstName
+		}
+
+		if user.LastName != "" {
+			updated.LastName = user.LastName
+		}
+
+		if user.Email != "" {
+			updated.Email = user.Email
+		}
+
+		updated.Version++
+
+		if err := recordUser(ctx, tx, domain.AuditUserUpdated, user.ID, &current, &updated); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return rollbackErr
+			}
+
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *repository) Delete(ctx context.Context, id int, version int) error {
+	query, args, err := sql.Delete(_usersTable).Where(sql.Eq("id", id), matchesVersion(version)).Build(r.dialect)
 	if err != nil {
 		return err
 	}
 
-	return err
-}
+	tx, err := sql.Begin(ctx, r.conn)
+	if err != nil {
+		return err
+	}
 
-func (r *repository) Delete(ctx context.Context, id int) error {
-	tx, err := r.conn.Beginx()
+	current, err := r.lock(ctx, tx, id)
 	if err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return rollbackErr
+		}
+
 		return err
 	}
 
-	stmt, err := tx.PreparexContext(ctx, _deleteUserStmt)
+	stmt, err := tx.PreparexContext(ctx, query)
 	if err != nil {
 		return err
 	}
@@ -173,7 +260,7 @@ func (r *repository) Delete(ctx context.Context, id int) error {
 		err = stmt.Close()
 	}()
 
-	res, err := stmt.ExecContext(ctx, id)
+	res, err := stmt.ExecContext(ctx, args...)
 	if err != nil {
 		if rollbackErr := tx.Rollback(); rollbackErr != nil {
 			return rollbackErr
@@ -182,6 +269,24 @@ func (r *repository) Delete(ctx context.Context, id int) error {
 		return err
 	}
 
+	if err := r.checkVersion(ctx, tx, res, id, version); err != nil {
+		if rollbackErr := tx.Rollback(); rollbackErr != nil {
+			return rollbackErr
+		}
+
+		return err
+	}
+
+	if current.ID != 0 {
+		if err := recordUser(ctx, tx, domain.AuditUserDeleted, id, &current, nil); err != nil {
+			if rollbackErr := tx.Rollback(); rollbackErr != nil {
+				return rollbackErr
+			}
+
+			return err
+		}
+	}
+
 	if err = tx.Commit(); err != nil {
 		return err
 	}
@@ -197,3 +302,106 @@ func (r *repository) Delete(ctx context.Context, id int) error {
 
 	return err
 }
+
+// checkVersion returns domain.ErrVersionMismatch when a versioned write did not affect the user because
+// it exists with another version.
+func (r *repository) checkVersion(ctx context.Context, tx *sql.Tx, res stdsql.Result, id int, version int) error {
+	affect, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affect > 0 || version == 0 {
+		return nil
+	}
+
+	query, args, err := sql.Select("COUNT(*)").From(_usersTable).Where(sql.Eq("id", id)).Build(r.dialect)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	if err := tx.GetContext(ctx, &count, query, args...); err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return domain.ErrVersionMismatch
+	}
+
+	return nil
+}
+
+// lock obtains the user before it is written, locking it until tx ends, to record how it was. A missing
+// user is returned empty, as it is not written.
+func (r *repository) lock(ctx context.Context, tx *sql.Tx, id int) (domain.User, error) {
+	var user domain.User
+
+	query, args, err := sql.Select(_userColumns...).From(_usersTable).Where(sql.Eq("id", id)).ForUpdate().Build(r.dialect)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	if err := tx.GetContext(ctx, &user, query, args...); err != nil && !errors.Is(err, stdsql.ErrNoRows) {
+		return domain.User{}, err
+	}
+
+	return user, nil
+}
+
+// matchesVersion is true for the user of the version, or for any when version is zero.
+func matchesVersion(version int) sql.Cond {
+	return sql.Expr("version = ? OR ? = 0", version, version)
+}
+
+// recordUser appends the audit event and the outbox message of the action on the user inside tx. before
+// is nil for a created user, and after for a deleted one.
+func recordUser(
+	ctx context.Context,
+	tx *sql.Tx,
+	action string,
+	id int,
+	before *domain.User,
+	after *domain.User) error {
+	event, err := audit.NewEvent(ctx, action, domain.AuditUser, id, before, after)
+	if err != nil {
+		return err
+	}
+
+	if err := audit.Insert(ctx, tx, event); err != nil {
+		return err
+	}
+
+	message, err := newMessage(action, id, before, after)
+	if err != nil {
+		return err
+	}
+
+	return outbox.Insert(ctx, tx, message)
+}
+
+// messageSubject is the payload of the outbox messages of a user, which never includes the password.
+type messageSubject struct {
+	ID        int    `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	Version   int    `json:"version"`
+}
+
+// newMessage returns the outbox message of the action on the user, whose payload is the user after the
+// action, or before it when it was deleted.
+func newMessage(action string, id int, before *domain.User, after *domain.User) (domain.OutboxMessage, error) {
+	user := after
+	if user == nil {
+		user = before
+	}
+
+	return outbox.NewMessage(domain.OutboxUser, id, action, messageSubject{
+		ID:        id,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Email:     user.Email,
+		Version:   user.Version,
+	})
+}
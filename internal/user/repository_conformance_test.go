@@ -0,0 +1,44 @@
+package user_test
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/mysql/mysqltest"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/postgres/postgrestest"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/sqlite/sqlitetest"
+	"github.com/ferch5003/go-fiber-tutorial/internal/user"
+	"github.com/ferch5003/go-fiber-tutorial/internal/user/usertest"
+	"github.com/jmoiron/sqlx"
+	"testing"
+)
+
+func TestRepository_Conformance(t *testing.T) {
+	backends := []struct {
+		name     string
+		connect  func(t *testing.T) *sqlx.DB
+		truncate func(t *testing.T, conn *sqlx.DB)
+	}{
+		{name: "MySQL", connect: mysqltest.NewConnection, truncate: mysqltest.Truncate},
+		{name: "PostgreSQL", connect: postgrestest.NewConnection, truncate: postgrestest.Truncate},
+		{name: "SQLite", connect: sqlitetest.NewConnection, truncate: sqlitetest.Truncate},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			conn := backend.connect(t)
+
+			usertest.RunRepositoryTests(t, func(t *testing.T) user.Repository {
+				backend.truncate(t, conn)
+
+				return user.NewRepository(conn)
+			})
+
+			t.Run("Outbox", func(t *testing.T) {
+				usertest.RunOutboxTests(t, func(t *testing.T) (user.Repository, outbox.Repository) {
+					backend.truncate(t, conn)
+
+					return user.NewRepository(conn), outbox.NewRepository(conn)
+				})
+			})
+		})
+	}
+}
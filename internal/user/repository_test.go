@@ -239,6 +239,8 @@ func TestRepositorySave_Successful(t *testing.T) {
 	mock.ExpectBegin()
 	mock.ExpectPrepare(`INSERT INTO users`)
 	mock.ExpectExec(`INSERT INTO users`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO audit_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
 
 	repository := NewRepository(dbx)
@@ -457,8 +459,14 @@ func TestRepositoryUpdate_Successful(t *testing.T) {
 		Email:     "john@example.com",
 	}
 	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, first_name, last_name, email, version FROM users`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "version"}).
+			AddRow(1, "John", "Smith", "john@example.com", 1))
 	mock.ExpectPrepare(`UPDATE users`)
 	mock.ExpectExec(`UPDATE users`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO audit_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
 
 	repository := NewRepository(dbx)
@@ -550,6 +558,10 @@ func TestRepositoryUpdate_FailsDueToInvalidPreparation(t *testing.T) {
 										WHERE id = ?;"`)
 
 	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, first_name, last_name, email, version FROM users`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "version"}).
+			AddRow(1, "John", "Smith", "john@example.com", 1))
 	mock.ExpectPrepare(wrongQuery).WillReturnError(expectedError)
 
 	repository := NewRepository(dbx)
@@ -583,6 +595,10 @@ func TestRepositoryUpdate_FailsDueToFailingExec(t *testing.T) {
 	expectedError := errors.New("Error Code: 1136. Column count doesn't match value count at row 1")
 
 	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, first_name, last_name, email, version FROM users`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "version"}).
+			AddRow(1, "John", "Smith", "john@example.com", 1))
 	mock.ExpectPrepare(`UPDATE users`)
 	mock.ExpectExec(`UPDATE users`).WillReturnError(expectedError)
 	mock.ExpectRollback()
@@ -620,6 +636,10 @@ func TestRepositoryUpdate_FailsDueToFailingExecWithFailingRollback(t *testing.T)
 		expectedExecError, "Rollack error")
 
 	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, first_name, last_name, email, version FROM users`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "version"}).
+			AddRow(1, "John", "Smith", "john@example.com", 1))
 	mock.ExpectPrepare(`UPDATE users`)
 	mock.ExpectExec(`UPDATE users`).WillReturnError(expectedExecError)
 	mock.ExpectRollback().WillReturnError(expectedRollbackError)
@@ -657,8 +677,14 @@ func TestRepositoryUpdate_FailsDueToFailingCommit(t *testing.T) {
 	expectedError := errors.New("sql: transaction has already been committed or rolled back")
 
 	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, first_name, last_name, email, version FROM users`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "version"}).
+			AddRow(1, "John", "Smith", "john@example.com", 1))
 	mock.ExpectPrepare(`UPDATE users`)
 	mock.ExpectExec(`UPDATE users`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO audit_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit().WillReturnError(expectedError)
 
 	repository := NewRepository(dbx)
@@ -684,14 +710,20 @@ func TestRepositoryDelete_Successful(t *testing.T) {
 
 	deletedUserID := 1
 	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, first_name, last_name, email, version FROM users`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "version"}).
+			AddRow(1, "John", "Smith", "john@example.com", 1))
 	mock.ExpectPrepare(`DELETE FROM users`)
 	mock.ExpectExec(`DELETE FROM users`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO audit_events`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO outbox`).WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
 
 	repository := NewRepository(dbx)
 
 	// When
-	err = repository.Delete(ctx, deletedUserID)
+	err = repository.Delete(ctx, deletedUserID, 0)
 
 	// Then
 	require.NoError(t, err)
@@ -717,7 +749,7 @@ func TestRepositoryDelete_FailsDueToInvalidBeginTransaction(t *testing.T) {
 	repository := NewRepository(dbx)
 
 	// When
-	err = repository.Delete(ctx, deletedUserID)
+	err = repository.Delete(ctx, deletedUserID, 0)
 
 	// Then
 	require.ErrorContains(t, err, "You have an error in your SQL syntax")
@@ -740,12 +772,15 @@ func TestRepositoryDelete_FailsDueToInvalidPreparation(t *testing.T) {
 										with expected regexp \"DELETE FROM users WHERE id = ();\"`)
 
 	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, first_name, last_name, email, version FROM users`).
+		WithArgs(0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "version"}))
 	mock.ExpectPrepare(wrongQuery).WillReturnError(expectedError)
 
 	repository := NewRepository(dbx)
 
 	// When
-	err = repository.Delete(ctx, deletedUserID)
+	err = repository.Delete(ctx, deletedUserID, 0)
 
 	// Then
 	require.ErrorContains(t, err, "Prepare: could not match actual sql")
@@ -767,6 +802,9 @@ func TestRepositoryDelete_FailsDueToFailingExec(t *testing.T) {
 	expectedError := errors.New("Error Code: 1136. Column count doesn't match value count at row 1")
 
 	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, first_name, last_name, email, version FROM users`).
+		WithArgs(0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "version"}))
 	mock.ExpectPrepare(`DELETE FROM users`)
 	mock.ExpectExec(`DELETE FROM users`).WillReturnError(expectedError)
 	mock.ExpectRollback()
@@ -774,7 +812,7 @@ func TestRepositoryDelete_FailsDueToFailingExec(t *testing.T) {
 	repository := NewRepository(dbx)
 
 	// When
-	err = repository.Delete(ctx, deletedUserID)
+	err = repository.Delete(ctx, deletedUserID, 0)
 
 	// Then
 	require.ErrorContains(t, err, "Error Code: 1136")
@@ -798,6 +836,9 @@ func TestRepositoryDelete_FailsDueToFailingExecWithFailingRollback(t *testing.T)
 		expectedExecError, "Rollack error")
 
 	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, first_name, last_name, email, version FROM users`).
+		WithArgs(0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "version"}))
 	mock.ExpectPrepare(`DELETE FROM users`)
 	mock.ExpectExec(`DELETE FROM users`).WillReturnError(expectedExecError)
 	mock.ExpectRollback().WillReturnError(expectedRollbackError)
@@ -805,7 +846,7 @@ func TestRepositoryDelete_FailsDueToFailingExecWithFailingRollback(t *testing.T)
 	repository := NewRepository(dbx)
 
 	// When
-	err = repository.Delete(ctx, deletedUserID)
+	err = repository.Delete(ctx, deletedUserID, 0)
 
 	// Then
 	require.ErrorContains(t, err, "delete failed")
@@ -830,6 +871,9 @@ func TestRepositoryDelete_FailsDueToFailingCommit(t *testing.T) {
 	expectedError := errors.New("sql: transaction has already been committed or rolled back")
 
 	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, first_name, last_name, email, version FROM users`).
+		WithArgs(0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "version"}))
 	mock.ExpectPrepare(`DELETE FROM users`)
 	mock.ExpectExec(`DELETE FROM users`).WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit().WillReturnError(expectedError)
@@ -837,7 +881,7 @@ func TestRepositoryDelete_FailsDueToFailingCommit(t *testing.T) {
 	repository := NewRepository(dbx)
 
 	// When
-	err = repository.Delete(ctx, deletedUserID)
+	err = repository.Delete(ctx, deletedUserID, 0)
 
 	// Then
 	require.ErrorContains(t, err, "sql")
@@ -859,6 +903,9 @@ func TestRepositoryDelete_FailsDueToNoRowsAffected(t *testing.T) {
 	expectedError := errors.New("no rows affected")
 
 	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, first_name, last_name, email, version FROM users`).
+		WithArgs(0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "first_name", "last_name", "email", "version"}))
 	mock.ExpectPrepare(`DELETE FROM users`)
 	mock.ExpectExec(`DELETE FROM users`).WillReturnResult(sqlmock.NewResult(0, 0))
 	mock.ExpectCommit()
@@ -866,7 +913,7 @@ func TestRepositoryDelete_FailsDueToNoRowsAffected(t *testing.T) {
 	repository := NewRepository(dbx)
 
 	// When
-	err = repository.Delete(ctx, deletedUserID)
+	err = repository.Delete(ctx, deletedUserID, 0)
 
 	// Then
 	require.ErrorContains(t, err, expectedError.Error())
@@ -2,6 +2,9 @@ package user
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
 	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
 )
 
@@ -18,20 +21,28 @@ type Service interface {
 	// Save a new User.
 	Save(ctx context.Context, user domain.User) (domain.User, error)
 
-	// Update data from the User.
+	// Update data from the User. A non-zero user.Version must match the version of the User, otherwise
+	// domain.ErrVersionMismatch is returned. The returned User has the version after the update.
 	Update(ctx context.Context, user domain.User) (domain.User, error)
 
-	// Delete the User.
-	Delete(ctx context.Context, id int) error
+	// Delete the User. A non-zero version must match the version of the User, otherwise
+	// domain.ErrVersionMismatch is returned.
+	Delete(ctx context.Context, id int, version int) error
+
+	// Login returns the User of the email when password is its password, otherwise
+	// domain.ErrInvalidCredentials is returned. Both outcomes are recorded in the audit trail.
+	Login(ctx context.Context, email string, password string) (domain.User, error)
 }
 
 type service struct {
 	repository Repository
+	events     audit.Repository
 }
 
-func NewService(repository Repository) Service {
+func NewService(repository Repository, events audit.Repository) Service {
 	return &service{
 		repository: repository,
+		events:     events,
 	}
 }
 
@@ -54,6 +65,7 @@ func (s service) Save(ctx context.Context, user domain.User) (domain.User, error
 	}
 
 	user.ID = id
+	user.Version = 1
 
 	return user, nil
 }
@@ -63,9 +75,50 @@ func (s service) Update(ctx context.Context, user domain.User) (domain.User, err
 		return domain.User{}, err
 	}
 
+	// The version is unknown after an unconditional update.
+	if user.Version != 0 {
+		user.Version++
+	}
+
 	return user, nil
 }
 
-func (s service) Delete(ctx context.Context, id int) error {
-	return s.repository.Delete(ctx, id)
+func (s service) Delete(ctx context.Context, id int, version int) error {
+	return s.repository.Delete(ctx, id, version)
+}
+
+func (s service) Login(ctx context.Context, email string, password string) (domain.User, error) {
+	user, err := s.repository.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return domain.User{}, err
+	}
+
+	if err != nil || user.ValidatePassword(password) != nil {
+		// The email is recorded as well, as it may not belong to any user.
+		attempt := struct {
+			Email string `json:"email"`
+		}{Email: email}
+
+		if err := s.record(ctx, domain.AuditUserLoginFailed, user.ID, attempt); err != nil {
+			return domain.User{}, err
+		}
+
+		return domain.User{}, domain.ErrInvalidCredentials
+	}
+
+	if err := s.record(audit.WithActor(ctx, user.ID), domain.AuditUserLoggedIn, user.ID, nil); err != nil {
+		return domain.User{}, err
+	}
+
+	return user, nil
+}
+
+// record appends the audit event of a login attempt on the user, with the details of the attempt.
+func (s service) record(ctx context.Context, action string, userID int, attempt any) error {
+	event, err := audit.NewEvent(ctx, action, domain.AuditUser, userID, nil, attempt)
+	if err != nil {
+		return err
+	}
+
+	return s.events.Append(ctx, event)
 }
@@ -2,7 +2,9 @@ package user
 
 import (
 	"context"
+	"database/sql"
 	"errors"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
 	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
@@ -38,8 +40,8 @@ func (mr *mockRepository) Update(ctx context.Context, user domain.User) error {
 	return args.Error(0)
 }
 
-func (mr *mockRepository) Delete(ctx context.Context, id int) error {
-	args := mr.Called(ctx, id)
+func (mr *mockRepository) Delete(ctx context.Context, id int, version int) error {
+	args := mr.Called(ctx, id, version)
 	return args.Error(0)
 }
 
@@ -63,7 +65,7 @@ func TestServiceGetAll_Successful(t *testing.T) {
 	mr := new(mockRepository)
 	mr.On("GetAll", mock.Anything).Return(expectedUsers, nil)
 
-	service := NewService(mr)
+	service := NewService(mr, audit.NewMemoryRepository())
 
 	// When
 	users, err := service.GetAll(context.Background())
@@ -81,7 +83,7 @@ func TestServiceGetAll_SuccessfulWithZeroUsers(t *testing.T) {
 	mr := new(mockRepository)
 	mr.On("GetAll", mock.Anything).Return(expectedUsers, nil)
 
-	service := NewService(mr)
+	service := NewService(mr, audit.NewMemoryRepository())
 
 	// When
 	users, err := service.GetAll(context.Background())
@@ -100,7 +102,7 @@ func TestServiceGetAll_FailsDueToRepositoryError(t *testing.T) {
 	mr := new(mockRepository)
 	mr.On("GetAll", mock.Anything).Return(expectedUsers, expectedError)
 
-	service := NewService(mr)
+	service := NewService(mr, audit.NewMemoryRepository())
 
 	// When
 	users, err := service.GetAll(context.Background())
@@ -124,7 +126,7 @@ func TestServiceGet_Successful(t *testing.T) {
 	mr := new(mockRepository)
 	mr.On("Get", mock.Anything, expectedUser.ID).Return(expectedUser, nil)
 
-	service := NewService(mr)
+	service := NewService(mr, audit.NewMemoryRepository())
 
 	// When
 	user, err := service.Get(context.Background(), expectedUser.ID)
@@ -143,7 +145,7 @@ func TestServiceGet_FailsDueToRepositoryError(t *testing.T) {
 	mr := new(mockRepository)
 	mr.On("Get", mock.Anything, nonExistingID).Return(expectedUser, expectedError)
 
-	service := NewService(mr)
+	service := NewService(mr, audit.NewMemoryRepository())
 
 	// When
 	user, err := service.Get(context.Background(), nonExistingID)
@@ -164,7 +166,7 @@ func TestServiceGetByEmail_Successful(t *testing.T) {
 	mr := new(mockRepository)
 	mr.On("GetByEmail", mock.Anything, expectedUser.Email).Return(expectedUser, nil)
 
-	service := NewService(mr)
+	service := NewService(mr, audit.NewMemoryRepository())
 
 	// When
 	user, err := service.GetByEmail(context.Background(), expectedUser.Email)
@@ -182,7 +184,7 @@ func TestServiceGetByEmail_FailsDueToRepositoryError(t *testing.T) {
 	mr := new(mockRepository)
 	mr.On("GetByEmail", mock.Anything, expectedUser.Email).Return(expectedUser, expectedError)
 
-	service := NewService(mr)
+	service := NewService(mr, audit.NewMemoryRepository())
 
 	// When
 	user, err := service.GetByEmail(context.Background(), expectedUser.Email)
@@ -206,13 +208,15 @@ func TestServiceSave_Successful(t *testing.T) {
 	mr := new(mockRepository)
 	mr.On("Save", mock.Anything, expectedUser).Return(expectedUser.ID, nil)
 
-	service := NewService(mr)
+	service := NewService(mr, audit.NewMemoryRepository())
 
 	// When
 	user, err := service.Save(context.Background(), expectedUser)
 
 	// Then
 	require.NoError(t, err)
+
+	expectedUser.Version = 1
 	require.Equal(t, expectedUser, user)
 }
 
@@ -224,7 +228,7 @@ func TestServiceSave_FailsDueToRepositoryError(t *testing.T) {
 	mr := new(mockRepository)
 	mr.On("Save", mock.Anything, expectedUser).Return(0, expectedError)
 
-	service := NewService(mr)
+	service := NewService(mr, audit.NewMemoryRepository())
 
 	// When
 	user, err := service.Save(context.Background(), expectedUser)
@@ -247,7 +251,7 @@ func TestServiceUpdate_Successful(t *testing.T) {
 	mr := new(mockRepository)
 	mr.On("Update", mock.Anything, expectedUser).Return(nil)
 
-	service := NewService(mr)
+	service := NewService(mr, audit.NewMemoryRepository())
 
 	// When
 	user, err := service.Update(context.Background(), expectedUser)
@@ -265,7 +269,7 @@ func TestServiceUpdate_FailsDueToRepositoryError(t *testing.T) {
 	mr := new(mockRepository)
 	mr.On("Update", mock.Anything, expectedUser).Return(expectedError)
 
-	service := NewService(mr)
+	service := NewService(mr, audit.NewMemoryRepository())
 
 	// When
 	user, err := service.Update(context.Background(), expectedUser)
@@ -281,12 +285,12 @@ func TestServiceDelete_Successful(t *testing.T) {
 	expectedUserID := 1
 
 	mr := new(mockRepository)
-	mr.On("Delete", mock.Anything, expectedUserID).Return(nil)
+	mr.On("Delete", mock.Anything, expectedUserID, 0).Return(nil)
 
-	service := NewService(mr)
+	service := NewService(mr, audit.NewMemoryRepository())
 
 	// When
-	err := service.Delete(context.Background(), expectedUserID)
+	err := service.Delete(context.Background(), expectedUserID, 0)
 
 	// Then
 	require.NoError(t, err)
@@ -298,14 +302,110 @@ func TestServiceDelete_FailsDueToRepositoryError(t *testing.T) {
 	expectedError := errors.New("Error Code: 1054. Unknown column 'wrong' in 'field list'")
 
 	mr := new(mockRepository)
-	mr.On("Delete", mock.Anything, expectedUserID).Return(expectedError)
+	mr.On("Delete", mock.Anything, expectedUserID, 0).Return(expectedError)
 
-	service := NewService(mr)
+	service := NewService(mr, audit.NewMemoryRepository())
 
 	// When
-	err := service.Delete(context.Background(), expectedUserID)
+	err := service.Delete(context.Background(), expectedUserID, 0)
 
 	// Then
 	require.ErrorContains(t, err, "Error Code: 1054")
 	require.ErrorContains(t, err, "Unknown column 'wrong' in 'field list'")
 }
+
+func TestServiceLogin_SuccessfulRecordsLogin(t *testing.T) {
+	// Given
+	user := domain.User{ID: 1, FirstName: "Jhon", LastName: "Smith", Email: "john@example.com", Password: "12345678"}
+	err := user.HashPassword()
+	require.NoError(t, err)
+
+	mr := new(mockRepository)
+	mr.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+
+	events := audit.NewMemoryRepository()
+	service := NewService(mr, events)
+
+	// When
+	loggedUser, err := service.Login(context.Background(), user.Email, "12345678")
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, user, loggedUser)
+
+	recorded, err := events.List(context.Background(), domain.AuditFilter{})
+	require.NoError(t, err)
+	require.Len(t, recorded, 1)
+	require.Equal(t, domain.AuditUserLoggedIn, recorded[0].Action)
+	require.Equal(t, user.ID, recorded[0].ActorID)
+	require.Equal(t, user.ID, recorded[0].EntityID)
+}
+
+func TestServiceLogin_FailsDueToWrongPassword(t *testing.T) {
+	// Given
+	user := domain.User{ID: 1, FirstName: "Jhon", LastName: "Smith", Email: "john@example.com", Password: "12345678"}
+	err := user.HashPassword()
+	require.NoError(t, err)
+
+	mr := new(mockRepository)
+	mr.On("GetByEmail", mock.Anything, user.Email).Return(user, nil)
+
+	events := audit.NewMemoryRepository()
+	service := NewService(mr, events)
+
+	// When
+	_, err = service.Login(context.Background(), user.Email, "bad_password")
+
+	// Then
+	require.ErrorIs(t, err, domain.ErrInvalidCredentials)
+
+	recorded, err := events.List(context.Background(), domain.AuditFilter{})
+	require.NoError(t, err)
+	require.Len(t, recorded, 1)
+	require.Equal(t, domain.AuditUserLoginFailed, recorded[0].Action)
+	require.Zero(t, recorded[0].ActorID)
+	require.Equal(t, user.ID, recorded[0].EntityID)
+	require.Equal(t, domain.AuditChanges{"email": {After: user.Email}}, recorded[0].Changes)
+}
+
+func TestServiceLogin_FailsDueToUnknownEmail(t *testing.T) {
+	// Given
+	mr := new(mockRepository)
+	mr.On("GetByEmail", mock.Anything, "john@example.com").Return(domain.User{}, sql.ErrNoRows)
+
+	events := audit.NewMemoryRepository()
+	service := NewService(mr, events)
+
+	// When
+	_, err := service.Login(context.Background(), "john@example.com", "12345678")
+
+	// Then
+	require.ErrorIs(t, err, domain.ErrInvalidCredentials)
+
+	recorded, err := events.List(context.Background(), domain.AuditFilter{})
+	require.NoError(t, err)
+	require.Len(t, recorded, 1)
+	require.Equal(t, domain.AuditUserLoginFailed, recorded[0].Action)
+	require.Zero(t, recorded[0].EntityID)
+}
+
+func TestServiceLogin_FailsDueToRepositoryError(t *testing.T) {
+	// Given
+	expectedError := errors.New("Error Code: 1054. Unknown column 'wrong' in 'field list'")
+
+	mr := new(mockRepository)
+	mr.On("GetByEmail", mock.Anything, "john@example.com").Return(domain.User{}, expectedError)
+
+	events := audit.NewMemoryRepository()
+	service := NewService(mr, events)
+
+	// When
+	_, err := service.Login(context.Background(), "john@example.com", "12345678")
+
+	// Then
+	require.ErrorIs(t, err, expectedError)
+
+	recorded, err := events.List(context.Background(), domain.AuditFilter{})
+	require.NoError(t, err)
+	require.Empty(t, recorded)
+}
@@ -0,0 +1,80 @@
+package usertest
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox"
+	"github.com/ferch5003/go-fiber-tutorial/internal/user"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// OutboxFactory creates an empty repository for every test, along with the outbox repository its writes
+// are written into.
+type OutboxFactory func(t *testing.T) (user.Repository, outbox.Repository)
+
+// RunOutboxTests checks every write of the repositories created by newRepositories writes its outbox
+// message, in order, without the password of the user.
+func RunOutboxTests(t *testing.T, newRepositories OutboxFactory) {
+	t.Run("WritesMessagesInOrder", func(t *testing.T) {
+		// Given
+		repository, messages := newRepositories(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, newUser("john@doe.com"))
+		require.NoError(t, err)
+
+		// When
+		err = repository.Update(ctx, domain.User{ID: id, FirstName: "Jane"})
+		require.NoError(t, err)
+
+		err = repository.Delete(ctx, id, 0)
+		require.NoError(t, err)
+
+		// Then
+		pending, err := messages.Pending(ctx, 100)
+		require.NoError(t, err)
+		require.Len(t, pending, 3)
+
+		expectedEventTypes := []string{domain.AuditUserCreated, domain.AuditUserUpdated, domain.AuditUserDeleted}
+
+		for i, message := range pending {
+			require.Equal(t, expectedEventTypes[i], message.EventType)
+			require.Equal(t, domain.OutboxUser, message.AggregateType)
+			require.Equal(t, id, message.AggregateID)
+
+			var payload map[string]any
+			require.NoError(t, json.Unmarshal(message.Payload, &payload))
+			require.NotContains(t, payload, "password")
+			require.EqualValues(t, id, payload["id"])
+		}
+
+		var updated domain.User
+		require.NoError(t, json.Unmarshal(pending[1].Payload, &updated))
+		require.Equal(t, "Jane", updated.FirstName)
+		require.Equal(t, "Doe", updated.LastName)
+		require.Equal(t, "john@doe.com", updated.Email)
+		require.Equal(t, 2, updated.Version)
+	})
+
+	t.Run("FailedWriteWritesNothing", func(t *testing.T) {
+		// Given
+		repository, messages := newRepositories(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, newUser("john@doe.com"))
+		require.NoError(t, err)
+
+		// When
+		err = repository.Delete(ctx, id, 2)
+
+		// Then
+		require.ErrorIs(t, err, domain.ErrVersionMismatch)
+
+		pending, err := messages.Pending(ctx, 100)
+		require.NoError(t, err)
+		require.Len(t, pending, 1)
+		require.Equal(t, domain.AuditUserCreated, pending[0].EventType)
+	})
+}
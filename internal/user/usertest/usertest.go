@@ -0,0 +1,312 @@
+// Package usertest provides a conformance suite that every user.Repository implementation must pass.
+package usertest
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/user"
+	"github.com/stretchr/testify/require"
+	"sync"
+	"testing"
+)
+
+// RepositoryFactory creates an empty repository for every test.
+type RepositoryFactory func(t *testing.T) user.Repository
+
+func newUser(email string) domain.User {
+	return domain.User{
+		FirstName: "John",
+		LastName:  "Doe",
+		Email:     email,
+		Password:  "hashed_password",
+	}
+}
+
+// RunRepositoryTests runs the conformance suite against the repositories created by newRepository.
+func RunRepositoryTests(t *testing.T, newRepository RepositoryFactory) {
+	t.Run("SaveAndGet", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		newUser := newUser("john@doe.com")
+
+		// When
+		id, err := repository.Save(ctx, newUser)
+		require.NoError(t, err)
+
+		obtainedUser, err := repository.Get(ctx, id)
+
+		// Then
+		require.NoError(t, err)
+		require.Positive(t, id)
+		require.Equal(t, domain.User{
+			ID:        id,
+			FirstName: newUser.FirstName,
+			LastName:  newUser.LastName,
+			Email:     newUser.Email,
+			Version:   1,
+		}, obtainedUser) // The password is never exposed by Get.
+	})
+
+	t.Run("GetByEmailIncludesPassword", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		newUser := newUser("john@doe.com")
+
+		id, err := repository.Save(ctx, newUser)
+		require.NoError(t, err)
+
+		// When
+		obtainedUser, err := repository.GetByEmail(ctx, newUser.Email)
+
+		// Then
+		require.NoError(t, err)
+
+		newUser.ID = id
+		newUser.Version = 1
+		require.Equal(t, newUser, obtainedUser)
+	})
+
+	t.Run("GetFailsDueToMissingUser", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		// When
+		byID, errByID := repository.Get(ctx, 1000)
+		byEmail, errByEmail := repository.GetByEmail(ctx, "missing@doe.com")
+
+		// Then
+		require.Error(t, errByID)
+		require.Empty(t, byID)
+		require.Error(t, errByEmail)
+		require.Empty(t, byEmail)
+	})
+
+	t.Run("GetAll", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		firstID, err := repository.Save(ctx, newUser("john@doe.com"))
+		require.NoError(t, err)
+
+		secondID, err := repository.Save(ctx, newUser("jane@doe.com"))
+		require.NoError(t, err)
+
+		// When
+		users, err := repository.GetAll(ctx)
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, []domain.User{
+			{ID: firstID, FirstName: "John", LastName: "Doe", Email: "john@doe.com", Version: 1},
+			{ID: secondID, FirstName: "John", LastName: "Doe", Email: "jane@doe.com", Version: 1},
+		}, users)
+	})
+
+	t.Run("SaveFailsDueToDuplicatedEmail", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		_, err := repository.Save(ctx, newUser("john@doe.com"))
+		require.NoError(t, err)
+
+		// When
+		_, err = repository.Save(ctx, newUser("john@doe.com"))
+
+		// Then
+		require.Error(t, err)
+	})
+
+	t.Run("UpdateOnlyChangesNonZeroFields", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, newUser("john@doe.com"))
+		require.NoError(t, err)
+
+		// When
+		err = repository.Update(ctx, domain.User{ID: id, FirstName: "Johnny", Password: "ignored"})
+		require.NoError(t, err)
+
+		obtainedUser, err := repository.GetByEmail(ctx, "john@doe.com")
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, domain.User{
+			ID:        id,
+			FirstName: "Johnny",
+			LastName:  "Doe",
+			Email:     "john@doe.com",
+			Password:  "hashed_password",
+			Version:   2,
+		}, obtainedUser)
+	})
+
+	t.Run("UpdateWithCurrentVersion", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, newUser("john@doe.com"))
+		require.NoError(t, err)
+
+		// When
+		err = repository.Update(ctx, domain.User{ID: id, FirstName: "Johnny", Version: 1})
+		require.NoError(t, err)
+
+		obtainedUser, err := repository.Get(ctx, id)
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, "Johnny", obtainedUser.FirstName)
+		require.Equal(t, 2, obtainedUser.Version)
+	})
+
+	t.Run("UpdateFailsDueToStaleVersion", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, newUser("john@doe.com"))
+		require.NoError(t, err)
+
+		require.NoError(t, repository.Update(ctx, domain.User{ID: id, FirstName: "Johnny", Version: 1}))
+
+		// When
+		err = repository.Update(ctx, domain.User{ID: id, LastName: "Doeson", Version: 1})
+
+		// Then
+		require.ErrorIs(t, err, domain.ErrVersionMismatch)
+
+		obtainedUser, err := repository.Get(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, "Doe", obtainedUser.LastName)
+		require.Equal(t, 2, obtainedUser.Version)
+	})
+
+	t.Run("UpdateFailsDueToEmptyUser", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, newUser("john@doe.com"))
+		require.NoError(t, err)
+
+		// When
+		err = repository.Update(ctx, domain.User{ID: id})
+
+		// Then
+		require.Error(t, err)
+	})
+
+	t.Run("UpdateFailsDueToDuplicatedEmail", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		_, err := repository.Save(ctx, newUser("john@doe.com"))
+		require.NoError(t, err)
+
+		id, err := repository.Save(ctx, newUser("jane@doe.com"))
+		require.NoError(t, err)
+
+		// When
+		err = repository.Update(ctx, domain.User{ID: id, Email: "john@doe.com"})
+
+		// Then
+		require.Error(t, err)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, newUser("john@doe.com"))
+		require.NoError(t, err)
+
+		// When
+		err = repository.Delete(ctx, id, 1)
+		require.NoError(t, err)
+
+		_, err = repository.Get(ctx, id)
+
+		// Then
+		require.Error(t, err)
+	})
+
+	t.Run("DeleteFailsDueToStaleVersion", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id, err := repository.Save(ctx, newUser("john@doe.com"))
+		require.NoError(t, err)
+
+		// When
+		err = repository.Delete(ctx, id, 2)
+
+		// Then
+		require.ErrorIs(t, err, domain.ErrVersionMismatch)
+
+		_, err = repository.Get(ctx, id)
+		require.NoError(t, err)
+	})
+
+	t.Run("DeleteFailsDueToMissingUser", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+
+		// When
+		err := repository.Delete(context.Background(), 1000, 0)
+
+		// Then
+		require.Error(t, err)
+	})
+
+	t.Run("ConcurrentSavesWithSameEmail", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		const saves = 10
+
+		wg := &sync.WaitGroup{}
+		errs := make(chan error, saves)
+
+		// When
+		for range saves {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				_, err := repository.Save(ctx, newUser("john@doe.com"))
+				errs <- err
+			}()
+		}
+
+		wg.Wait()
+		close(errs)
+
+		// Then
+		succeeded := 0
+		for err := range errs {
+			if err == nil {
+				succeeded++
+			}
+		}
+
+		users, err := repository.GetAll(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 1, succeeded)
+		require.Len(t, users, 1)
+	})
+}
@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"net"
+	"net/netip"
+	"net/url"
+	"syscall"
+)
+
+// checkURL returns domain.ErrWebhookForbiddenURL when the host of the URL is, or resolves to, an address
+// that is not public. A host that does not resolve is accepted, since the address of every delivery is
+// checked again when dialing it.
+func checkURL(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+
+	if addr, err := netip.ParseAddr(parsed.Hostname()); err == nil {
+		return checkAddr(addr)
+	}
+
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", parsed.Hostname())
+	if err != nil {
+		return nil
+	}
+
+	for _, addr := range addrs {
+		if err := checkAddr(addr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkAddr returns domain.ErrWebhookForbiddenURL for the loopback, private, link-local, multicast and
+// unspecified addresses, like 127.0.0.1, 10.0.0.1 or 169.254.169.254.
+func checkAddr(addr netip.Addr) error {
+	addr = addr.Unmap()
+
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() || addr.IsLinkLocalUnicast() ||
+		addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return domain.ErrWebhookForbiddenURL
+	}
+
+	return nil
+}
+
+// controlDial is the net.Dialer Control of the deliveries. It checks the address after the host was
+// resolved, so a host resolving to a public address when registered can not resolve to a private one
+// when delivered.
+func controlDial(_ string, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+
+	return checkAddr(addrPort.Addr())
+}
@@ -0,0 +1,396 @@
+package webhook
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/jobs"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/logging"
+	"github.com/gofiber/fiber/v2/utils"
+	"go.uber.org/zap"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	_defaultWorkers     = 4
+	_defaultQueueSize   = 1024
+	_defaultMaxAttempts = 5
+	_defaultBackoff     = 5 * time.Second
+	_defaultTimeout     = 10 * time.Second
+	_defaultMaxFailures = 5
+
+	// _maxResponseSize is the part of the responses that is read, so connections can be reused.
+	_maxResponseSize = 64 << 10
+)
+
+// DispatcherConfig tunes the Dispatcher, zero fields take their default.
+type DispatcherConfig struct {
+	Workers     int           // Deliveries attempted at the same time, defaults to 4.
+	QueueSize   int           // Events and first attempts waiting for a worker, defaults to 1024.
+	MaxAttempts int           // Attempts of a delivery before it fails, defaults to 5.
+	Backoff     time.Duration // Wait before the first retry, doubled on every other one, defaults to 5s.
+	Timeout     time.Duration // Of every attempt, defaults to 10s.
+	MaxFailures int           // Failed deliveries in a row that disable a webhook, defaults to 5.
+	AllowLocal  bool          // Deliver to loopback, private and link-local addresses, only for tests.
+}
+
+// Notification is the body of a delivery. Its ID identifies the event, so it is the same on every
+// delivery of the event and receivers can ignore the ones they already processed.
+type Notification struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// job is the work of a worker: the event of a user to deliver to its webhooks, or the first attempt of
+// a delivery.
+type job struct {
+	userID     int
+	event      string
+	payload    []byte
+	deliveryID int
+}
+
+// Dispatcher delivers the events to the webhooks in the background, so the writes that raised them do
+// not wait for the endpoints. The first attempt of a delivery is made by its workers, the retries are
+// queued as DeliverJob in the jobs, so they survive restarts and run on any worker.
+type Dispatcher struct {
+	repository Repository
+	jobs       jobs.Queue
+	config     DispatcherConfig
+	client     *http.Client
+
+	mutex   sync.RWMutex
+	stopped bool
+	queue   chan job
+	workers sync.WaitGroup
+}
+
+func NewDispatcher(repository Repository, jobsQueue jobs.Queue, config DispatcherConfig) *Dispatcher {
+	config.Workers = cmp.Or(config.Workers, _defaultWorkers)
+	config.QueueSize = cmp.Or(config.QueueSize, _defaultQueueSize)
+	config.MaxAttempts = cmp.Or(config.MaxAttempts, _defaultMaxAttempts)
+	config.Backoff = cmp.Or(config.Backoff, _defaultBackoff)
+	config.Timeout = cmp.Or(config.Timeout, _defaultTimeout)
+	config.MaxFailures = cmp.Or(config.MaxFailures, _defaultMaxFailures)
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !config.AllowLocal {
+		// Deliveries connect to the endpoints themselves, never through a proxy, so every address they
+		// dial is checked.
+		transport.Proxy = nil
+		transport.DialContext = (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			Control:   controlDial,
+		}).DialContext
+	}
+
+	return &Dispatcher{
+		repository: repository,
+		jobs:       jobsQueue,
+		config:     config,
+		client: &http.Client{
+			Transport: transport,
+			// A redirect is a failed attempt, endpoints must answer themselves.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		queue: make(chan job, config.QueueSize),
+	}
+}
+
+// checkURL returns domain.ErrWebhookForbiddenURL when the deliveries to the URL would be refused.
+func (d *Dispatcher) checkURL(ctx context.Context, rawURL string) error {
+	if d.config.AllowLocal {
+		return nil
+	}
+
+	return checkURL(ctx, rawURL)
+}
+
+// Start the workers.
+func (d *Dispatcher) Start(_ context.Context) error {
+	for range d.config.Workers {
+		d.workers.Add(1)
+
+		go func() {
+			defer d.workers.Done()
+
+			for job := range d.queue {
+				d.work(job)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// Stop accepting events and wait until the queued ones are delivered, or ctx is done. Retries are in
+// the jobs, they are attempted by the Worker.
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	d.mutex.Lock()
+
+	if !d.stopped {
+		d.stopped = true
+
+		close(d.queue)
+	}
+
+	d.mutex.Unlock()
+
+	drained := make(chan struct{})
+
+	go func() {
+		d.workers.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Notify queues the event of the user for its active webhooks that subscribed to it. The data is the
+// subject of the event, sent as the data of the Notification.
+func (d *Dispatcher) Notify(ctx context.Context, userID int, event string, data any) {
+	payload, err := json.Marshal(Notification{
+		ID:        utils.UUIDv4(),
+		Type:      event,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	})
+	if err != nil {
+		logging.FromContext(ctx).Warn("Error encoding webhook notification", zap.String("event", event), zap.Error(err))
+
+		return
+	}
+
+	if !d.enqueue(job{userID: userID, event: event, payload: payload}) {
+		logging.FromContext(ctx).Warn("Dropped webhook notification",
+			zap.String("event", event),
+			zap.Int("user_id", userID))
+	}
+}
+
+// Deliver queues an attempt of the saved delivery. Dropped deliveries stay pending, until
+// RequeuePendingJob queues them again.
+func (d *Dispatcher) Deliver(ctx context.Context, deliveryID int) {
+	if !d.enqueue(job{deliveryID: deliveryID}) {
+		logging.FromContext(ctx).Warn("Dropped webhook delivery", zap.Int("delivery_id", deliveryID))
+	}
+}
+
+// enqueue the job without waiting, reporting false when the queue is full or the Dispatcher stopped.
+func (d *Dispatcher) enqueue(job job) bool {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	if d.stopped {
+		return false
+	}
+
+	select {
+	case d.queue <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+func (d *Dispatcher) work(job job) {
+	ctx := context.Background()
+
+	if job.deliveryID != 0 {
+		d.attempt(ctx, job.deliveryID)
+
+		return
+	}
+
+	webhooks, err := d.repository.GetAll(ctx, job.userID)
+	if err != nil {
+		logging.FromContext(ctx).Warn("Error obtaining webhooks", zap.Int("user_id", job.userID), zap.Error(err))
+
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Active || !webhook.Receives(job.event) {
+			continue
+		}
+
+		now := time.Now().UTC()
+
+		delivery, err := d.repository.SaveDelivery(ctx, domain.WebhookDelivery{
+			WebhookID: webhook.ID,
+			Event:     job.event,
+			Payload:   job.payload,
+			Status:    domain.WebhookDeliveryPending,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+		if err != nil {
+			logging.FromContext(ctx).Warn("Error saving webhook delivery",
+				zap.Int("webhook_id", webhook.ID),
+				zap.Error(err))
+
+			continue
+		}
+
+		d.attempt(ctx, delivery.ID)
+	}
+}
+
+// attempt the pending delivery, queueing a retry when it fails and attempts are left.
+func (d *Dispatcher) attempt(ctx context.Context, deliveryID int) {
+	delivery, err := d.repository.GetDelivery(ctx, deliveryID)
+	if errors.Is(err, domain.ErrNotFound) {
+		// The webhook was deleted along with its deliveries.
+		return
+	}
+
+	if err != nil {
+		logging.FromContext(ctx).Warn("Error obtaining webhook delivery",
+			zap.Int("delivery_id", deliveryID),
+			zap.Error(err))
+
+		return
+	}
+
+	if delivery.Status != domain.WebhookDeliveryPending {
+		// Queued again by RequeuePendingJob while its retry was waiting.
+		return
+	}
+
+	webhook, err := d.repository.Get(ctx, delivery.WebhookID)
+	if errors.Is(err, domain.ErrNotFound) {
+		return
+	}
+
+	if err != nil {
+		logging.FromContext(ctx).Warn("Error obtaining webhook",
+			zap.Int("webhook_id", delivery.WebhookID),
+			zap.Error(err))
+
+		return
+	}
+
+	if !webhook.Active {
+		// Disabled while the delivery waited for a retry.
+		delivery.Status = domain.WebhookDeliveryFailed
+		delivery.Error = domain.ErrWebhookDisabled.Error()
+		delivery.UpdatedAt = time.Now().UTC()
+
+		d.updateDelivery(ctx, delivery)
+
+		return
+	}
+
+	delivery.StatusCode, err = d.send(ctx, webhook, delivery)
+	delivery.Attempts++
+	delivery.Error = ""
+	delivery.UpdatedAt = time.Now().UTC()
+
+	switch {
+	case err == nil:
+		delivery.Status = domain.WebhookDeliverySucceeded
+	case delivery.Attempts < d.config.MaxAttempts:
+		delivery.Status = domain.WebhookDeliveryPending
+		delivery.Error = err.Error()
+	default:
+		delivery.Status = domain.WebhookDeliveryFailed
+		delivery.Error = err.Error()
+	}
+
+	if !d.updateDelivery(ctx, delivery) {
+		return
+	}
+
+	switch delivery.Status {
+	case domain.WebhookDeliverySucceeded:
+		err = d.repository.RecordSuccess(ctx, webhook.ID)
+	case domain.WebhookDeliveryPending:
+		d.retry(ctx, delivery)
+	case domain.WebhookDeliveryFailed:
+		err = d.repository.RecordFailure(ctx, webhook.ID, d.config.MaxFailures)
+	}
+
+	if err != nil {
+		logging.FromContext(ctx).Warn("Error recording webhook outcome",
+			zap.Int("webhook_id", webhook.ID),
+			zap.Error(err))
+	}
+}
+
+// send the delivery to the webhook, returning the status code of the response, if any.
+func (d *Dispatcher) send(ctx context.Context, webhook domain.Webhook, delivery domain.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderDelivery, strconv.Itoa(delivery.ID))
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(webhook.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, _maxResponseSize))
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp.StatusCode, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// retry the delivery after the backoff of its attempts. When it can not be queued, it stays pending
+// until RequeuePendingJob queues it again.
+func (d *Dispatcher) retry(ctx context.Context, delivery domain.WebhookDelivery) {
+	delay := d.config.Backoff << (delivery.Attempts - 1)
+
+	if err := DeliverJob.Enqueue(ctx, d.jobs, Deliver{DeliveryID: delivery.ID}, jobs.Delay(delay)); err != nil {
+		logging.FromContext(ctx).Warn("Error queueing webhook delivery retry",
+			zap.Int("delivery_id", delivery.ID),
+			zap.Error(err))
+	}
+}
+
+// updateDelivery stores the outcome of the delivery, reporting whether it was stored.
+func (d *Dispatcher) updateDelivery(ctx context.Context, delivery domain.WebhookDelivery) bool {
+	if err := d.repository.UpdateDelivery(ctx, delivery); err != nil {
+		logging.FromContext(ctx).Warn("Error updating webhook delivery",
+			zap.Int("delivery_id", delivery.ID),
+			zap.Error(err))
+
+		return false
+	}
+
+	return true
+}
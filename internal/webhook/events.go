@@ -0,0 +1,65 @@
+package webhook
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/pubsub"
+	"github.com/ferch5003/go-fiber-tutorial/internal/user"
+)
+
+type todoPublisher struct {
+	webhooks Service
+}
+
+// NewTodoPublisher returns a pubsub.Publisher notifying the webhooks of the todo events. The events are
+// returned without ID, the ID of a Notification is given when it is built.
+func NewTodoPublisher(webhooks Service) pubsub.Publisher {
+	return &todoPublisher{
+		webhooks: webhooks,
+	}
+}
+
+func (p todoPublisher) Publish(ctx context.Context, event domain.TodoEvent) (domain.TodoEvent, error) {
+	p.webhooks.Notify(ctx, event.UserID, event.Type, event.Todo)
+
+	return event, nil
+}
+
+// userData is the data of the user events, the user without their password.
+type userData struct {
+	ID        int    `json:"id"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
+	Version   int    `json:"version"`
+}
+
+type userService struct {
+	user.Service
+	webhooks Service
+}
+
+// NewUserService decorates user.Service notifying the webhooks of the updated users.
+func NewUserService(next user.Service, webhooks Service) user.Service {
+	return &userService{
+		Service:  next,
+		webhooks: webhooks,
+	}
+}
+
+func (s userService) Update(ctx context.Context, user domain.User) (domain.User, error) {
+	updatedUser, err := s.Service.Update(ctx, user)
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	s.webhooks.Notify(ctx, updatedUser.ID, domain.WebhookUserUpdated, userData{
+		ID:        updatedUser.ID,
+		FirstName: updatedUser.FirstName,
+		LastName:  updatedUser.LastName,
+		Email:     updatedUser.Email,
+		Version:   updatedUser.Version,
+	})
+
+	return updatedUser, nil
+}
@@ -0,0 +1,77 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ferch5003/go-fiber-tutorial/internal/audit"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/outbox"
+	"github.com/ferch5003/go-fiber-tutorial/internal/user"
+	"github.com/ferch5003/go-fiber-tutorial/internal/webhook"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestTodoPublisherPublish_Successful(t *testing.T) {
+	// Given
+	service, _ := newService(t, webhook.DispatcherConfig{})
+	receiver := newReceiver(t)
+
+	created := createWebhook(t, service, receiver.URL, domain.TodoEventCompleted)
+	publisher := webhook.NewTodoPublisher(service)
+
+	event := domain.TodoEvent{
+		Type:   domain.TodoEventCompleted,
+		UserID: _userID,
+		Todo:   domain.Todo{ID: 1, Title: "Lorem", UserID: _userID, Completed: true, Version: 2},
+	}
+
+	// When
+	published, err := publisher.Publish(context.Background(), event)
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, event, published)
+
+	waitForDeliveries(t, service, created.ID, domain.WebhookDeliverySucceeded)
+}
+
+func TestUserServiceUpdate_SuccessfulNotifiesWithoutPassword(t *testing.T) {
+	// Given
+	service, _ := newService(t, webhook.DispatcherConfig{})
+	receiver := newReceiver(t)
+
+	created := createWebhook(t, service, receiver.URL, domain.WebhookUserUpdated)
+
+	events := audit.NewMemoryRepository()
+	repository := user.NewMemoryRepository(events, outbox.NewMemoryRepository())
+	users := webhook.NewUserService(user.NewService(repository, events), service)
+
+	ctx := context.Background()
+
+	savedUser, err := users.Save(ctx, domain.User{
+		FirstName: "John",
+		LastName:  "Doe",
+		Email:     "john@doe.com",
+		Password:  "password123",
+	})
+	require.NoError(t, err)
+	require.Equal(t, _userID, savedUser.ID)
+
+	savedUser.FirstName = "Jane"
+
+	// When
+	_, err = users.Update(ctx, savedUser)
+
+	// Then
+	require.NoError(t, err)
+
+	waitForDeliveries(t, service, created.ID, domain.WebhookDeliverySucceeded)
+
+	var notification struct {
+		Data map[string]any `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(receiver.received()[0].body, &notification))
+	require.Equal(t, "Jane", notification.Data["first_name"])
+	require.NotContains(t, notification.Data, "password")
+}
@@ -0,0 +1,115 @@
+package webhook
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/jobs"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/logging"
+	"go.uber.org/zap"
+	"time"
+)
+
+const (
+	// _purgeDeliveriesSpec purges the deliveries every day at 03:00 UTC.
+	_purgeDeliveriesSpec = "0 3 * * *"
+
+	// _deliveriesRetentionDays is how long the deliveries are kept in their log.
+	_deliveriesRetentionDays = 30
+
+	// _requeuePendingSpec queues the stale pending deliveries again every 5 minutes.
+	_requeuePendingSpec = "@every 5m"
+
+	// _pendingStaleMinutes is how long a delivery stays pending before it is queued again, longer than
+	// the backoff of its retries.
+	_pendingStaleMinutes = 15
+
+	// _requeuePendingLimit is the number of deliveries queued again by every job, the rest are by the
+	// next ones.
+	_requeuePendingLimit = 500
+)
+
+// Deliver attempts a pending delivery, it is how the Dispatcher retries the failed ones.
+type Deliver struct {
+	DeliveryID int `json:"delivery_id"`
+}
+
+var DeliverJob = jobs.Type[Deliver]("webhook.deliver")
+
+// NewDeliverHandler creates the handler of the DeliverJob. Its failures are retried by the dispatcher
+// along the attempts of the delivery, not by the Worker.
+func NewDeliverHandler(dispatcher *Dispatcher) jobs.Handler {
+	return DeliverJob.Handler(func(ctx context.Context, payload Deliver) error {
+		dispatcher.attempt(ctx, payload.DeliveryID)
+
+		return nil
+	})
+}
+
+// RequeuePending queues again the deliveries pending for longer than StaleMinutes, whose attempt was
+// lost: dropped by a full dispatcher, not queued, or interrupted by a restart.
+type RequeuePending struct {
+	StaleMinutes int `json:"stale_minutes"`
+}
+
+var RequeuePendingJob = jobs.Type[RequeuePending]("webhook.requeue_pending")
+
+// NewRequeuePendingHandler creates the handler of the RequeuePendingJob.
+func NewRequeuePendingHandler(repository Repository, queue jobs.Queue) jobs.Handler {
+	return RequeuePendingJob.Handler(func(ctx context.Context, payload RequeuePending) error {
+		before := time.Now().UTC().Add(-time.Duration(payload.StaleMinutes) * time.Minute)
+
+		deliveries, err := repository.GetPendingDeliveriesBefore(ctx, before, _requeuePendingLimit)
+		if err != nil {
+			return err
+		}
+
+		for _, delivery := range deliveries {
+			if err := DeliverJob.Enqueue(ctx, queue, Deliver{DeliveryID: delivery.ID}); err != nil {
+				return err
+			}
+		}
+
+		if len(deliveries) > 0 {
+			logging.FromContext(ctx).Info("Queued stale webhook deliveries", zap.Int("queued", len(deliveries)))
+		}
+
+		return nil
+	})
+}
+
+// NewRequeuePendingSchedule creates the Schedule queueing again the deliveries pending for more than 15
+// minutes every 5 minutes.
+func NewRequeuePendingSchedule() (jobs.Schedule, error) {
+	return RequeuePendingJob.Schedule(_requeuePendingSpec, RequeuePending{
+		StaleMinutes: _pendingStaleMinutes,
+	})
+}
+
+// PurgeDeliveries deletes the deliveries older than the retention.
+type PurgeDeliveries struct {
+	RetentionDays int `json:"retention_days"`
+}
+
+var PurgeDeliveriesJob = jobs.Type[PurgeDeliveries]("webhook.purge_deliveries")
+
+// NewPurgeDeliveriesHandler creates the handler of the PurgeDeliveriesJob.
+func NewPurgeDeliveriesHandler(repository Repository) jobs.Handler {
+	return PurgeDeliveriesJob.Handler(func(ctx context.Context, payload PurgeDeliveries) error {
+		before := time.Now().UTC().AddDate(0, 0, -payload.RetentionDays)
+
+		deleted, err := repository.DeleteDeliveriesBefore(ctx, before)
+		if err != nil {
+			return err
+		}
+
+		logging.FromContext(ctx).Info("Purged webhook deliveries", zap.Int("deleted", deleted))
+
+		return nil
+	})
+}
+
+// NewPurgeDeliveriesSchedule creates the Schedule purging the deliveries older than 30 days every day.
+func NewPurgeDeliveriesSchedule() (jobs.Schedule, error) {
+	return PurgeDeliveriesJob.Schedule(_purgeDeliveriesSpec, PurgeDeliveries{
+		RetentionDays: _deliveriesRetentionDays,
+	})
+}
@@ -0,0 +1,135 @@
+package webhook_test
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/jobs"
+	"github.com/ferch5003/go-fiber-tutorial/internal/webhook"
+	"github.com/stretchr/testify/require"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPurgeDeliveriesHandler_SuccessfulDeletesOldDeliveries(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	repository := webhook.NewMemoryRepository()
+
+	saved, err := repository.Save(ctx, domain.Webhook{UserID: _userID, URL: "https://example.com", Active: true})
+	require.NoError(t, err)
+
+	for _, age := range []time.Duration{31 * 24 * time.Hour, time.Hour} {
+		_, err := repository.SaveDelivery(ctx, domain.WebhookDelivery{
+			WebhookID: saved.ID,
+			Event:     domain.TodoEventCreated,
+			Status:    domain.WebhookDeliverySucceeded,
+			CreatedAt: time.Now().UTC().Add(-age),
+		})
+		require.NoError(t, err)
+	}
+
+	queue := jobs.NewMemoryQueue()
+
+	worker, err := jobs.NewWorker(queue, jobs.WorkerConfig{}, []jobs.Handler{
+		webhook.NewPurgeDeliveriesHandler(repository),
+	}, nil)
+	require.NoError(t, err)
+	require.NoError(t, worker.Start(ctx))
+
+	t.Cleanup(func() {
+		_ = worker.Stop(context.Background())
+	})
+
+	// When
+	err = webhook.PurgeDeliveriesJob.Enqueue(ctx, queue, webhook.PurgeDeliveries{RetentionDays: 30})
+
+	// Then
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		deliveries, err := repository.GetDeliveries(ctx, saved.ID, 10)
+		require.NoError(t, err)
+
+		return len(deliveries) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestNewPurgeDeliveriesSchedule_Successful(t *testing.T) {
+	// When
+	schedule, err := webhook.NewPurgeDeliveriesSchedule()
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "webhook.purge_deliveries 0 3 * * *", schedule.Name)
+}
+
+func TestRequeuePendingHandler_SuccessfulDeliversStaleDeliveries(t *testing.T) {
+	// Given
+	ctx := context.Background()
+	repository := webhook.NewMemoryRepository()
+	queue := jobs.NewMemoryQueue()
+	receiver := newReceiver(t)
+
+	saved, err := repository.Save(ctx, domain.Webhook{UserID: _userID, URL: receiver.URL, Active: true})
+	require.NoError(t, err)
+
+	ids := make(map[time.Duration]int)
+
+	for _, age := range []time.Duration{time.Hour, time.Minute} {
+		delivery, err := repository.SaveDelivery(ctx, domain.WebhookDelivery{
+			WebhookID: saved.ID,
+			Event:     domain.TodoEventCreated,
+			Payload:   domain.WebhookPayload(`{"id":"event"}`),
+			Status:    domain.WebhookDeliveryPending,
+			CreatedAt: time.Now().UTC().Add(-age),
+			UpdatedAt: time.Now().UTC().Add(-age),
+		})
+		require.NoError(t, err)
+
+		ids[age] = delivery.ID
+	}
+
+	dispatcher := webhook.NewDispatcher(repository, queue, webhook.DispatcherConfig{AllowLocal: true})
+
+	worker, err := jobs.NewWorker(queue, jobs.WorkerConfig{}, []jobs.Handler{
+		webhook.NewDeliverHandler(dispatcher),
+		webhook.NewRequeuePendingHandler(repository, queue),
+	}, nil)
+	require.NoError(t, err)
+	require.NoError(t, worker.Start(ctx))
+
+	t.Cleanup(func() {
+		_ = worker.Stop(context.Background())
+	})
+
+	// When
+	err = webhook.RequeuePendingJob.Enqueue(ctx, queue, webhook.RequeuePending{StaleMinutes: 15})
+
+	// Then
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		delivery, err := repository.GetDelivery(ctx, ids[time.Hour])
+		require.NoError(t, err)
+
+		return delivery.Status == domain.WebhookDeliverySucceeded
+	}, 5*time.Second, 10*time.Millisecond)
+
+	recent, err := repository.GetDelivery(ctx, ids[time.Minute])
+	require.NoError(t, err)
+	require.Equal(t, domain.WebhookDeliveryPending, recent.Status)
+
+	requests := receiver.received()
+	require.Len(t, requests, 1)
+	require.Equal(t, strconv.Itoa(ids[time.Hour]), requests[0].header.Get(webhook.HeaderDelivery))
+}
+
+func TestNewRequeuePendingSchedule_Successful(t *testing.T) {
+	// When
+	schedule, err := webhook.NewRequeuePendingSchedule()
+
+	// Then
+	require.NoError(t, err)
+	require.Equal(t, "webhook.requeue_pending @every 5m", schedule.Name)
+}
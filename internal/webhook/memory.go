@@ -0,0 +1,264 @@
+package webhook
+
+import (
+	"cmp"
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"slices"
+	"sync"
+	"time"
+)
+
+type memoryRepository struct {
+	mutex          *sync.RWMutex
+	lastID         int
+	lastDeliveryID int
+	webhooks       map[int]domain.Webhook
+	deliveries     map[int]domain.WebhookDelivery
+}
+
+// NewMemoryRepository creates a thread-safe Repository that keeps webhooks and their deliveries in
+// memory. It behaves like the SQL Repository, so it can replace it in development and tests.
+func NewMemoryRepository() Repository {
+	return &memoryRepository{
+		mutex:      &sync.RWMutex{},
+		webhooks:   make(map[int]domain.Webhook),
+		deliveries: make(map[int]domain.WebhookDelivery),
+	}
+}
+
+func (r *memoryRepository) Save(_ context.Context, webhook domain.Webhook) (domain.Webhook, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.lastID++
+	webhook.ID = r.lastID
+	webhook.Events = slices.Clone(webhook.Events)
+
+	r.webhooks[webhook.ID] = webhook
+
+	return webhook, nil
+}
+
+func (r *memoryRepository) Get(_ context.Context, id int) (domain.Webhook, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return domain.Webhook{}, domain.ErrNotFound
+	}
+
+	webhook.Events = slices.Clone(webhook.Events)
+
+	return webhook, nil
+}
+
+func (r *memoryRepository) GetAll(_ context.Context, userID int) ([]domain.Webhook, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	webhooks := make([]domain.Webhook, 0)
+
+	for _, webhook := range r.webhooks {
+		if webhook.UserID == userID {
+			webhook.Events = slices.Clone(webhook.Events)
+			webhooks = append(webhooks, webhook)
+		}
+	}
+
+	slices.SortFunc(webhooks, func(a, b domain.Webhook) int {
+		return a.ID - b.ID
+	})
+
+	return webhooks, nil
+}
+
+func (r *memoryRepository) Update(_ context.Context, webhook domain.Webhook) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored, ok := r.webhooks[webhook.ID]
+	if !ok {
+		return nil
+	}
+
+	stored.URL = webhook.URL
+	stored.Events = slices.Clone(webhook.Events)
+	stored.Active = webhook.Active
+	stored.Failures = webhook.Failures
+
+	r.webhooks[webhook.ID] = stored
+
+	return nil
+}
+
+func (r *memoryRepository) Delete(_ context.Context, id int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.webhooks, id)
+
+	// Like the foreign key, deleting the webhook deletes its deliveries.
+	for deliveryID, delivery := range r.deliveries {
+		if delivery.WebhookID == id {
+			delete(r.deliveries, deliveryID)
+		}
+	}
+
+	return nil
+}
+
+func (r *memoryRepository) RecordFailure(_ context.Context, id int, maxFailures int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return nil
+	}
+
+	webhook.Failures++
+	if webhook.Failures >= maxFailures {
+		webhook.Active = false
+	}
+
+	r.webhooks[id] = webhook
+
+	return nil
+}
+
+func (r *memoryRepository) RecordSuccess(_ context.Context, id int) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	webhook, ok := r.webhooks[id]
+	if !ok {
+		return nil
+	}
+
+	webhook.Failures = 0
+	r.webhooks[id] = webhook
+
+	return nil
+}
+
+func (r *memoryRepository) SaveDelivery(
+	_ context.Context,
+	delivery domain.WebhookDelivery) (domain.WebhookDelivery, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.webhooks[delivery.WebhookID]; !ok {
+		// Like the foreign key, a delivery needs its webhook.
+		return domain.WebhookDelivery{}, domain.ErrNotFound
+	}
+
+	r.lastDeliveryID++
+	delivery.ID = r.lastDeliveryID
+	delivery.Payload = slices.Clone(delivery.Payload)
+
+	r.deliveries[delivery.ID] = delivery
+
+	return delivery, nil
+}
+
+func (r *memoryRepository) GetDelivery(_ context.Context, id int) (domain.WebhookDelivery, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	delivery, ok := r.deliveries[id]
+	if !ok {
+		return domain.WebhookDelivery{}, domain.ErrNotFound
+	}
+
+	return delivery, nil
+}
+
+func (r *memoryRepository) GetDeliveries(
+	_ context.Context,
+	webhookID int,
+	limit int) ([]domain.WebhookDelivery, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	deliveries := make([]domain.WebhookDelivery, 0)
+
+	for _, delivery := range r.deliveries {
+		if delivery.WebhookID == webhookID {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	slices.SortFunc(deliveries, func(a, b domain.WebhookDelivery) int {
+		return b.ID - a.ID
+	})
+
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+
+	return deliveries, nil
+}
+
+func (r *memoryRepository) GetPendingDeliveriesBefore(
+	_ context.Context,
+	before time.Time,
+	limit int) ([]domain.WebhookDelivery, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	deliveries := make([]domain.WebhookDelivery, 0)
+
+	for _, delivery := range r.deliveries {
+		if delivery.Status == domain.WebhookDeliveryPending && delivery.UpdatedAt.Before(before) {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+
+	slices.SortFunc(deliveries, func(a, b domain.WebhookDelivery) int {
+		return cmp.Or(a.UpdatedAt.Compare(b.UpdatedAt), a.ID-b.ID)
+	})
+
+	if len(deliveries) > limit {
+		deliveries = deliveries[:limit]
+	}
+
+	return deliveries, nil
+}
+
+func (r *memoryRepository) UpdateDelivery(_ context.Context, delivery domain.WebhookDelivery) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored, ok := r.deliveries[delivery.ID]
+	if !ok {
+		return nil
+	}
+
+	stored.Status = delivery.Status
+	stored.Attempts = delivery.Attempts
+	stored.StatusCode = delivery.StatusCode
+	stored.Error = delivery.Error
+	stored.UpdatedAt = delivery.UpdatedAt
+
+	r.deliveries[delivery.ID] = stored
+
+	return nil
+}
+
+func (r *memoryRepository) DeleteDeliveriesBefore(_ context.Context, before time.Time) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	deleted := 0
+
+	for id, delivery := range r.deliveries {
+		if delivery.CreatedAt.Before(before) {
+			delete(r.deliveries, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
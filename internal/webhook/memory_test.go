@@ -0,0 +1,13 @@
+package webhook_test
+
+import (
+	"github.com/ferch5003/go-fiber-tutorial/internal/webhook"
+	"github.com/ferch5003/go-fiber-tutorial/internal/webhook/webhooktest"
+	"testing"
+)
+
+func TestMemoryRepository_Conformance(t *testing.T) {
+	webhooktest.RunRepositoryTests(t, func(t *testing.T) webhook.Repository {
+		return webhook.NewMemoryRepository()
+	})
+}
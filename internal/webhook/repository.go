@@ -0,0 +1,266 @@
+package webhook
+
+import (
+	"context"
+	stdsql "database/sql"
+	"errors"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/sql"
+	"github.com/jmoiron/sqlx"
+	"time"
+)
+
+const (
+	_webhookColumns = `id, user_id, url, events, secret, active, failures, created_at`
+	_getWebhookStmt = `SELECT ` + _webhookColumns + `
+						FROM webhooks
+						WHERE id = ?;`
+	_getAllWebhooksStmt = `SELECT ` + _webhookColumns + `
+							FROM webhooks
+							WHERE user_id = ?
+							ORDER BY id;`
+	_saveWebhookStmt = `INSERT INTO webhooks (user_id, url, events, secret, active, failures, created_at)
+							VALUES (?, ?, ?, ?, ?, ?, ?);`
+	_updateWebhookStmt = `UPDATE webhooks SET url = ?, events = ?, active = ?, failures = ? WHERE id = ?;`
+	_deleteWebhookStmt = `DELETE FROM webhooks WHERE id = ?;`
+
+	// The failures are read before they are incremented by every database, as active is set first.
+	_recordWebhookFailureStmt = `UPDATE webhooks
+									SET active = CASE WHEN failures + 1 >= ? THEN false ELSE active END,
+										failures = failures + 1
+									WHERE id = ?;`
+	_recordWebhookSuccessStmt = `UPDATE webhooks SET failures = 0 WHERE id = ?;`
+
+	_deliveryColumns = `id, webhook_id, event, payload, status, attempts, status_code, error, created_at,
+							updated_at`
+	_getDeliveryStmt = `SELECT ` + _deliveryColumns + `
+						FROM webhook_deliveries
+						WHERE id = ?;`
+	_getDeliveriesStmt = `SELECT ` + _deliveryColumns + `
+							FROM webhook_deliveries
+							WHERE webhook_id = ?
+							ORDER BY id DESC
+							LIMIT ?;`
+	_saveDeliveryStmt = `INSERT INTO webhook_deliveries (webhook_id, event, payload, status, attempts, status_code,
+							error, created_at, updated_at)
+							VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);`
+	_getPendingDeliveriesBeforeStmt = `SELECT ` + _deliveryColumns + `
+										FROM webhook_deliveries
+										WHERE status = ? AND updated_at < ?
+										ORDER BY updated_at, id
+										LIMIT ?;`
+	_updateDeliveryStmt = `UPDATE webhook_deliveries
+							SET status = ?, attempts = ?, status_code = ?, error = ?, updated_at = ?
+							WHERE id = ?;`
+	_deleteDeliveriesBeforeStmt = `DELETE FROM webhook_deliveries WHERE created_at < ?;`
+)
+
+type Repository interface {
+	// Save a new Webhook, returning it with its ID.
+	Save(ctx context.Context, webhook domain.Webhook) (domain.Webhook, error)
+
+	// Get obtain one Webhook by ID, or domain.ErrNotFound.
+	Get(ctx context.Context, id int) (domain.Webhook, error)
+
+	// GetAll obtain the webhooks of the user, from the oldest.
+	GetAll(ctx context.Context, userID int) ([]domain.Webhook, error)
+
+	// Update the URL, events, state and failures of the Webhook.
+	Update(ctx context.Context, webhook domain.Webhook) error
+
+	// Delete the Webhook along with its deliveries.
+	Delete(ctx context.Context, id int) error
+
+	// RecordFailure counts a failed delivery of the Webhook, disabling it when it failed maxFailures
+	// deliveries in a row.
+	RecordFailure(ctx context.Context, id int, maxFailures int) error
+
+	// RecordSuccess resets the failed deliveries of the Webhook.
+	RecordSuccess(ctx context.Context, id int) error
+
+	// SaveDelivery saves a new delivery, returning it with its ID.
+	SaveDelivery(ctx context.Context, delivery domain.WebhookDelivery) (domain.WebhookDelivery, error)
+
+	// GetDelivery obtain one delivery by ID, or domain.ErrNotFound.
+	GetDelivery(ctx context.Context, id int) (domain.WebhookDelivery, error)
+
+	// GetDeliveries obtain the last deliveries of the Webhook, from the newest.
+	GetDeliveries(ctx context.Context, webhookID int, limit int) ([]domain.WebhookDelivery, error)
+
+	// GetPendingDeliveriesBefore obtain the pending deliveries last updated before the time, from the
+	// oldest.
+	GetPendingDeliveriesBefore(ctx context.Context, before time.Time, limit int) ([]domain.WebhookDelivery, error)
+
+	// UpdateDelivery updates the status, attempts and outcome of the last attempt of the delivery.
+	UpdateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error
+
+	// DeleteDeliveriesBefore deletes the deliveries created before the time, returning how many.
+	DeleteDeliveriesBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+type repository struct {
+	conn    *sqlx.DB
+	dialect sql.Dialect
+}
+
+func NewRepository(conn *sqlx.DB) Repository {
+	return &repository{
+		conn:    conn,
+		dialect: sql.NewDialect(conn.DriverName()),
+	}
+}
+
+func (r repository) Save(ctx context.Context, webhook domain.Webhook) (domain.Webhook, error) {
+	stmt, err := sql.ConnFrom(ctx, r.conn).PreparexContext(ctx, r.dialect.Rebind(r.dialect.InsertQuery(_saveWebhookStmt)))
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+
+	defer stmt.Close()
+
+	webhook.ID, err = r.dialect.InsertedID(ctx, stmt, webhook.UserID, webhook.URL, webhook.Events, webhook.Secret,
+		webhook.Active, webhook.Failures, webhook.CreatedAt)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+func (r repository) Get(ctx context.Context, id int) (domain.Webhook, error) {
+	var webhook domain.Webhook
+
+	err := sql.ConnFrom(ctx, r.conn).GetContext(ctx, &webhook, r.dialect.Rebind(_getWebhookStmt), id)
+	if errors.Is(err, stdsql.ErrNoRows) {
+		return domain.Webhook{}, domain.ErrNotFound
+	}
+
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+
+	return webhook, nil
+}
+
+func (r repository) GetAll(ctx context.Context, userID int) ([]domain.Webhook, error) {
+	webhooks := make([]domain.Webhook, 0)
+
+	query := r.dialect.Rebind(_getAllWebhooksStmt)
+	if err := sql.ConnFrom(ctx, r.conn).SelectContext(ctx, &webhooks, query, userID); err != nil {
+		return make([]domain.Webhook, 0), err
+	}
+
+	return webhooks, nil
+}
+
+func (r repository) Update(ctx context.Context, webhook domain.Webhook) error {
+	_, err := sql.ConnFrom(ctx, r.conn).ExecContext(ctx, r.dialect.Rebind(_updateWebhookStmt),
+		webhook.URL, webhook.Events, webhook.Active, webhook.Failures, webhook.ID)
+
+	return err
+}
+
+func (r repository) Delete(ctx context.Context, id int) error {
+	_, err := sql.ConnFrom(ctx, r.conn).ExecContext(ctx, r.dialect.Rebind(_deleteWebhookStmt), id)
+
+	return err
+}
+
+func (r repository) RecordFailure(ctx context.Context, id int, maxFailures int) error {
+	_, err := sql.ConnFrom(ctx, r.conn).ExecContext(ctx, r.dialect.Rebind(_recordWebhookFailureStmt), maxFailures, id)
+
+	return err
+}
+
+func (r repository) RecordSuccess(ctx context.Context, id int) error {
+	_, err := sql.ConnFrom(ctx, r.conn).ExecContext(ctx, r.dialect.Rebind(_recordWebhookSuccessStmt), id)
+
+	return err
+}
+
+func (r repository) SaveDelivery(
+	ctx context.Context,
+	delivery domain.WebhookDelivery) (domain.WebhookDelivery, error) {
+	stmt, err := sql.ConnFrom(ctx, r.conn).PreparexContext(ctx, r.dialect.Rebind(r.dialect.InsertQuery(_saveDeliveryStmt)))
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	defer stmt.Close()
+
+	delivery.ID, err = r.dialect.InsertedID(ctx, stmt, delivery.WebhookID, delivery.Event, delivery.Payload,
+		delivery.Status, delivery.Attempts, delivery.StatusCode, delivery.Error, delivery.CreatedAt,
+		delivery.UpdatedAt)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	return delivery, nil
+}
+
+func (r repository) GetDelivery(ctx context.Context, id int) (domain.WebhookDelivery, error) {
+	var delivery domain.WebhookDelivery
+
+	err := sql.ConnFrom(ctx, r.conn).GetContext(ctx, &delivery, r.dialect.Rebind(_getDeliveryStmt), id)
+	if errors.Is(err, stdsql.ErrNoRows) {
+		return domain.WebhookDelivery{}, domain.ErrNotFound
+	}
+
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	return delivery, nil
+}
+
+func (r repository) GetDeliveries(
+	ctx context.Context,
+	webhookID int,
+	limit int) ([]domain.WebhookDelivery, error) {
+	deliveries := make([]domain.WebhookDelivery, 0)
+
+	query := r.dialect.Rebind(_getDeliveriesStmt)
+	err := sql.ConnFrom(ctx, r.conn).SelectContext(ctx, &deliveries, query, webhookID, limit)
+	if err != nil {
+		return make([]domain.WebhookDelivery, 0), err
+	}
+
+	return deliveries, nil
+}
+
+func (r repository) GetPendingDeliveriesBefore(
+	ctx context.Context,
+	before time.Time,
+	limit int) ([]domain.WebhookDelivery, error) {
+	deliveries := make([]domain.WebhookDelivery, 0)
+
+	query := r.dialect.Rebind(_getPendingDeliveriesBeforeStmt)
+	err := sql.ConnFrom(ctx, r.conn).SelectContext(ctx, &deliveries, query, domain.WebhookDeliveryPending,
+		before.UTC(), limit)
+	if err != nil {
+		return make([]domain.WebhookDelivery, 0), err
+	}
+
+	return deliveries, nil
+}
+
+func (r repository) UpdateDelivery(ctx context.Context, delivery domain.WebhookDelivery) error {
+	_, err := sql.ConnFrom(ctx, r.conn).ExecContext(ctx, r.dialect.Rebind(_updateDeliveryStmt),
+		delivery.Status, delivery.Attempts, delivery.StatusCode, delivery.Error, delivery.UpdatedAt, delivery.ID)
+
+	return err
+}
+
+func (r repository) DeleteDeliveriesBefore(ctx context.Context, before time.Time) (int, error) {
+	result, err := sql.ConnFrom(ctx, r.conn).ExecContext(ctx, r.dialect.Rebind(_deleteDeliveriesBeforeStmt), before.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(deleted), nil
+}
@@ -0,0 +1,49 @@
+package webhook_test
+
+import (
+	"fmt"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/mysql/mysqltest"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/postgres/postgrestest"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/sqlite/sqlitetest"
+	"github.com/ferch5003/go-fiber-tutorial/internal/webhook"
+	"github.com/ferch5003/go-fiber-tutorial/internal/webhook/webhooktest"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestRepository_Conformance(t *testing.T) {
+	backends := []struct {
+		name     string
+		connect  func(t *testing.T) *sqlx.DB
+		truncate func(t *testing.T, conn *sqlx.DB)
+	}{
+		{name: "MySQL", connect: mysqltest.NewConnection, truncate: mysqltest.Truncate},
+		{name: "PostgreSQL", connect: postgrestest.NewConnection, truncate: postgrestest.Truncate},
+		{name: "SQLite", connect: sqlitetest.NewConnection, truncate: sqlitetest.Truncate},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			conn := backend.connect(t)
+
+			webhooktest.RunRepositoryTests(t, func(t *testing.T) webhook.Repository {
+				backend.truncate(t, conn)
+				saveUsers(t, conn)
+
+				return webhook.NewRepository(conn)
+			})
+		})
+	}
+}
+
+// saveUsers saves the users that webhooks of the conformance suite belong to.
+func saveUsers(t *testing.T, conn *sqlx.DB) {
+	t.Helper()
+
+	for _, userID := range []int{webhooktest.UserID, webhooktest.OtherUserID} {
+		_, err := conn.Exec(conn.Rebind(`INSERT INTO users (id, first_name, last_name, email, password)
+							VALUES (?, 'John', 'Doe', ?, 'password');`), userID, fmt.Sprintf("user%d@doe.com", userID))
+		require.NoError(t, err)
+	}
+}
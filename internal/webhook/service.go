@@ -0,0 +1,176 @@
+// Package webhook notifies the endpoints registered by the users of the events they subscribed to.
+// Deliveries are signed with the secret of their webhook, retried with exponential backoff and logged,
+// and a webhook failing too many deliveries in a row is disabled until its user enables it again.
+package webhook
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"time"
+)
+
+type Service interface {
+	// Create a Webhook for its user, generating the secret that signs its deliveries. The returned
+	// Webhook is the only one with the secret. A URL pointing to a loopback, private or link-local
+	// address results in domain.ErrWebhookForbiddenURL.
+	Create(ctx context.Context, webhook domain.Webhook) (domain.Webhook, error)
+
+	// Get obtain one Webhook by ID, or domain.ErrNotFound.
+	Get(ctx context.Context, id int) (domain.Webhook, error)
+
+	// GetAll obtain the webhooks of the user, from the oldest.
+	GetAll(ctx context.Context, userID int) ([]domain.Webhook, error)
+
+	// Update the URL, events and state of the Webhook. Enabling it again forgets its failures. Like in
+	// Create, the URL must not point to a loopback, private or link-local address.
+	Update(ctx context.Context, webhook domain.Webhook) (domain.Webhook, error)
+
+	// Delete the Webhook along with its deliveries.
+	Delete(ctx context.Context, id int) error
+
+	// Deliveries obtain the last deliveries of the Webhook, from the newest.
+	Deliveries(ctx context.Context, webhookID int, limit int) ([]domain.WebhookDelivery, error)
+
+	// GetDelivery obtain one delivery by ID, or domain.ErrNotFound.
+	GetDelivery(ctx context.Context, id int) (domain.WebhookDelivery, error)
+
+	// Redeliver sends the event of the delivery again, as a new delivery. Returns
+	// domain.ErrWebhookDisabled when its Webhook is disabled.
+	Redeliver(ctx context.Context, deliveryID int) (domain.WebhookDelivery, error)
+
+	// Notify the webhooks of the user that subscribed to the event, in the background. The data is the
+	// subject of the event.
+	Notify(ctx context.Context, userID int, event string, data any)
+}
+
+type service struct {
+	repository Repository
+	dispatcher *Dispatcher
+}
+
+func NewService(repository Repository, dispatcher *Dispatcher) Service {
+	return &service{
+		repository: repository,
+		dispatcher: dispatcher,
+	}
+}
+
+func (s service) Create(ctx context.Context, webhook domain.Webhook) (domain.Webhook, error) {
+	if err := s.dispatcher.checkURL(ctx, webhook.URL); err != nil {
+		return domain.Webhook{}, err
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+
+	webhook.Secret = secret
+	webhook.Active = true
+	webhook.Failures = 0
+	webhook.CreatedAt = time.Now().UTC()
+
+	return s.repository.Save(ctx, webhook)
+}
+
+func (s service) Get(ctx context.Context, id int) (domain.Webhook, error) {
+	webhook, err := s.repository.Get(ctx, id)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+
+	webhook.Secret = ""
+
+	return webhook, nil
+}
+
+func (s service) GetAll(ctx context.Context, userID int) ([]domain.Webhook, error) {
+	webhooks, err := s.repository.GetAll(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range webhooks {
+		webhooks[i].Secret = ""
+	}
+
+	return webhooks, nil
+}
+
+func (s service) Update(ctx context.Context, webhook domain.Webhook) (domain.Webhook, error) {
+	if err := s.dispatcher.checkURL(ctx, webhook.URL); err != nil {
+		return domain.Webhook{}, err
+	}
+
+	stored, err := s.repository.Get(ctx, webhook.ID)
+	if err != nil {
+		return domain.Webhook{}, err
+	}
+
+	stored.URL = webhook.URL
+	stored.Events = webhook.Events
+
+	if webhook.Active && !stored.Active {
+		stored.Failures = 0
+	}
+
+	stored.Active = webhook.Active
+
+	if err := s.repository.Update(ctx, stored); err != nil {
+		return domain.Webhook{}, err
+	}
+
+	stored.Secret = ""
+
+	return stored, nil
+}
+
+func (s service) Delete(ctx context.Context, id int) error {
+	return s.repository.Delete(ctx, id)
+}
+
+func (s service) Deliveries(ctx context.Context, webhookID int, limit int) ([]domain.WebhookDelivery, error) {
+	return s.repository.GetDeliveries(ctx, webhookID, limit)
+}
+
+func (s service) GetDelivery(ctx context.Context, id int) (domain.WebhookDelivery, error) {
+	return s.repository.GetDelivery(ctx, id)
+}
+
+func (s service) Redeliver(ctx context.Context, deliveryID int) (domain.WebhookDelivery, error) {
+	delivery, err := s.repository.GetDelivery(ctx, deliveryID)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	webhook, err := s.repository.Get(ctx, delivery.WebhookID)
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	if !webhook.Active {
+		return domain.WebhookDelivery{}, domain.ErrWebhookDisabled
+	}
+
+	now := time.Now().UTC()
+
+	redelivery, err := s.repository.SaveDelivery(ctx, domain.WebhookDelivery{
+		WebhookID: delivery.WebhookID,
+		Event:     delivery.Event,
+		Payload:   delivery.Payload,
+		Status:    domain.WebhookDeliveryPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+
+	s.dispatcher.Deliver(ctx, redelivery.ID)
+
+	return redelivery, nil
+}
+
+func (s service) Notify(ctx context.Context, userID int, event string, data any) {
+	s.dispatcher.Notify(ctx, userID, event, data)
+}
@@ -0,0 +1,493 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/platform/jobs"
+	"github.com/ferch5003/go-fiber-tutorial/internal/webhook"
+	"github.com/stretchr/testify/require"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const _userID = 1
+
+// receivedRequest is a delivery received by a receiver.
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// receiver is a webhook endpoint answering the status codes of its attempts, then 200.
+type receiver struct {
+	*httptest.Server
+
+	mutex    sync.Mutex
+	statuses []int
+	requests []receivedRequest
+}
+
+func newReceiver(t *testing.T, statuses ...int) *receiver {
+	t.Helper()
+
+	r := &receiver{statuses: statuses}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+
+		r.mutex.Lock()
+		defer r.mutex.Unlock()
+
+		r.requests = append(r.requests, receivedRequest{header: req.Header, body: body})
+
+		status := http.StatusOK
+		if len(r.statuses) > 0 {
+			status, r.statuses = r.statuses[0], r.statuses[1:]
+		}
+
+		w.WriteHeader(status)
+	}))
+
+	t.Cleanup(r.Close)
+
+	return r
+}
+
+func (r *receiver) received() []receivedRequest {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return append([]receivedRequest(nil), r.requests...)
+}
+
+// newService creates a Service whose deliveries are retried right away by a jobs worker, stopped when the
+// test finishes. Deliveries to local addresses are allowed, for the receivers.
+func newService(t *testing.T, config webhook.DispatcherConfig) (webhook.Service, webhook.Repository) {
+	t.Helper()
+
+	if config.Backoff == 0 {
+		config.Backoff = time.Millisecond
+	}
+
+	config.AllowLocal = true
+
+	repository := webhook.NewMemoryRepository()
+	queue := jobs.NewMemoryQueue()
+	dispatcher := webhook.NewDispatcher(repository, queue, config)
+
+	worker, err := jobs.NewWorker(queue, jobs.WorkerConfig{}, []jobs.Handler{
+		webhook.NewDeliverHandler(dispatcher),
+	}, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, dispatcher.Start(context.Background()))
+	require.NoError(t, worker.Start(context.Background()))
+
+	t.Cleanup(func() {
+		require.NoError(t, worker.Stop(context.Background()))
+		require.NoError(t, dispatcher.Stop(context.Background()))
+	})
+
+	return webhook.NewService(repository, dispatcher), repository
+}
+
+func createWebhook(t *testing.T, service webhook.Service, url string, events ...string) domain.Webhook {
+	t.Helper()
+
+	created, err := service.Create(context.Background(), domain.Webhook{UserID: _userID, URL: url, Events: events})
+	require.NoError(t, err)
+
+	return created
+}
+
+// waitForDeliveries waits until the webhook has the deliveries with the statuses, from the newest.
+func waitForDeliveries(t *testing.T, service webhook.Service, webhookID int, statuses ...string) []domain.WebhookDelivery {
+	t.Helper()
+
+	var deliveries []domain.WebhookDelivery
+
+	require.Eventually(t, func() bool {
+		var err error
+
+		deliveries, err = service.Deliveries(context.Background(), webhookID, 10)
+		require.NoError(t, err)
+
+		if len(deliveries) != len(statuses) {
+			return false
+		}
+
+		for i, delivery := range deliveries {
+			if delivery.Status != statuses[i] {
+				return false
+			}
+		}
+
+		return true
+	}, 5*time.Second, 5*time.Millisecond)
+
+	return deliveries
+}
+
+func TestSign_Successful(t *testing.T) {
+	// Given
+	body := []byte(`{"id":"event"}`)
+
+	// When
+	signature := webhook.Sign("whsec_secret", "1725181200", body)
+
+	// Then
+	require.True(t, strings.HasPrefix(signature, "sha256="))
+	require.True(t, webhook.Verify("whsec_secret", "1725181200", body, signature))
+	require.False(t, webhook.Verify("whsec_other", "1725181200", body, signature))
+	require.False(t, webhook.Verify("whsec_secret", "1725181201", body, signature))
+}
+
+func TestServiceCreate_SuccessfulGeneratesSecret(t *testing.T) {
+	// Given
+	service, _ := newService(t, webhook.DispatcherConfig{})
+
+	// When
+	first := createWebhook(t, service, "https://example.com/webhook", domain.TodoEventCreated)
+	second := createWebhook(t, service, "https://example.com/webhook", domain.TodoEventCreated)
+
+	// Then
+	require.True(t, first.Active)
+	require.True(t, strings.HasPrefix(first.Secret, "whsec_"))
+	require.NotEqual(t, first.Secret, second.Secret)
+
+	obtained, err := service.Get(context.Background(), first.ID)
+	require.NoError(t, err)
+	require.Empty(t, obtained.Secret)
+}
+
+func TestServiceCreate_FailsDueToLocalURL(t *testing.T) {
+	urls := map[string]string{
+		"Loopback":       "http://127.0.0.1:8080/webhook",
+		"LoopbackIPv6":   "http://[::1]/webhook",
+		"Localhost":      "http://localhost/webhook",
+		"Private":        "https://10.0.0.1/webhook",
+		"LinkLocal":      "http://169.254.169.254/latest/meta-data",
+		"Unspecified":    "http://0.0.0.0/webhook",
+		"MappedLoopback": "http://[::ffff:127.0.0.1]/webhook",
+	}
+
+	for name, url := range urls {
+		t.Run(name, func(t *testing.T) {
+			// Given
+			repository := webhook.NewMemoryRepository()
+			dispatcher := webhook.NewDispatcher(repository, jobs.NewMemoryQueue(), webhook.DispatcherConfig{})
+			service := webhook.NewService(repository, dispatcher)
+
+			// When
+			_, err := service.Create(context.Background(), domain.Webhook{UserID: _userID, URL: url,
+				Events: domain.WebhookFilter{domain.TodoEventCreated}})
+
+			// Then
+			require.ErrorIs(t, err, domain.ErrWebhookForbiddenURL)
+
+			webhooks, err := service.GetAll(context.Background(), _userID)
+			require.NoError(t, err)
+			require.Empty(t, webhooks)
+		})
+	}
+}
+
+func TestServiceUpdate_FailsDueToLocalURL(t *testing.T) {
+	// Given
+	repository := webhook.NewMemoryRepository()
+	dispatcher := webhook.NewDispatcher(repository, jobs.NewMemoryQueue(), webhook.DispatcherConfig{})
+	service := webhook.NewService(repository, dispatcher)
+
+	created := createWebhook(t, service, "https://203.0.113.10/webhook", domain.TodoEventCreated)
+	created.URL = "http://169.254.169.254/latest/meta-data"
+
+	// When
+	_, err := service.Update(context.Background(), created)
+
+	// Then
+	require.ErrorIs(t, err, domain.ErrWebhookForbiddenURL)
+
+	obtained, err := service.Get(context.Background(), created.ID)
+	require.NoError(t, err)
+	require.Equal(t, "https://203.0.113.10/webhook", obtained.URL)
+}
+
+func TestServiceNotify_FailsDueToLocalAddressWhenDialing(t *testing.T) {
+	// Given
+	repository := webhook.NewMemoryRepository()
+	dispatcher := webhook.NewDispatcher(repository, jobs.NewMemoryQueue(), webhook.DispatcherConfig{
+		MaxAttempts: 1,
+	})
+	service := webhook.NewService(repository, dispatcher)
+
+	require.NoError(t, dispatcher.Start(context.Background()))
+
+	t.Cleanup(func() {
+		require.NoError(t, dispatcher.Stop(context.Background()))
+	})
+
+	receiver := newReceiver(t)
+
+	// Saved like a host that resolved to a public address when it was registered.
+	saved, err := repository.Save(context.Background(), domain.Webhook{
+		UserID: _userID,
+		URL:    receiver.URL,
+		Events: domain.WebhookFilter{domain.TodoEventCreated},
+		Secret: "whsec_secret",
+		Active: true,
+	})
+	require.NoError(t, err)
+
+	// When
+	service.Notify(context.Background(), _userID, domain.TodoEventCreated, domain.Todo{ID: 1, UserID: _userID})
+
+	// Then
+	deliveries := waitForDeliveries(t, service, saved.ID, domain.WebhookDeliveryFailed)
+	require.Contains(t, deliveries[0].Error, domain.ErrWebhookForbiddenURL.Error())
+	require.Empty(t, receiver.received())
+}
+
+func TestServiceNotify_SuccessfulDeliversSignedEvent(t *testing.T) {
+	// Given
+	service, _ := newService(t, webhook.DispatcherConfig{})
+	receiver := newReceiver(t)
+
+	created := createWebhook(t, service, receiver.URL, domain.TodoEventCreated)
+	todo := domain.Todo{ID: 1, Title: "Lorem", Description: "Ipsum", UserID: _userID, Version: 1}
+
+	// When
+	service.Notify(context.Background(), _userID, domain.TodoEventCreated, todo)
+
+	// Then
+	deliveries := waitForDeliveries(t, service, created.ID, domain.WebhookDeliverySucceeded)
+	require.Equal(t, 1, deliveries[0].Attempts)
+	require.Equal(t, http.StatusOK, deliveries[0].StatusCode)
+
+	requests := receiver.received()
+	require.Len(t, requests, 1)
+
+	header := requests[0].header
+	require.Equal(t, domain.TodoEventCreated, header.Get(webhook.HeaderEvent))
+	require.Equal(t, strconv.Itoa(deliveries[0].ID), header.Get(webhook.HeaderDelivery))
+	require.True(t, webhook.Verify(created.Secret, header.Get(webhook.HeaderTimestamp), requests[0].body,
+		header.Get(webhook.HeaderSignature)))
+
+	var notification struct {
+		ID   string      `json:"id"`
+		Type string      `json:"type"`
+		Data domain.Todo `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(requests[0].body, &notification))
+	require.NotEmpty(t, notification.ID)
+	require.Equal(t, domain.TodoEventCreated, notification.Type)
+	require.Equal(t, todo, notification.Data)
+}
+
+func TestServiceNotify_SuccessfulSkipsOtherEventsAndDisabledWebhooks(t *testing.T) {
+	// Given
+	service, _ := newService(t, webhook.DispatcherConfig{})
+	receiver := newReceiver(t)
+
+	subscribed := createWebhook(t, service, receiver.URL, domain.TodoEventDeleted)
+	otherEvents := createWebhook(t, service, receiver.URL, domain.TodoEventCreated)
+
+	disabled := createWebhook(t, service, receiver.URL, domain.TodoEventDeleted)
+	disabled.Active = false
+	_, err := service.Update(context.Background(), disabled)
+	require.NoError(t, err)
+
+	// When
+	service.Notify(context.Background(), _userID, domain.TodoEventDeleted, domain.Todo{ID: 1, UserID: _userID})
+
+	// Then
+	waitForDeliveries(t, service, subscribed.ID, domain.WebhookDeliverySucceeded)
+
+	for _, id := range []int{otherEvents.ID, disabled.ID} {
+		deliveries, err := service.Deliveries(context.Background(), id, 10)
+		require.NoError(t, err)
+		require.Empty(t, deliveries)
+	}
+}
+
+func TestServiceNotify_SuccessfulRetriesFailedAttempts(t *testing.T) {
+	// Given
+	service, _ := newService(t, webhook.DispatcherConfig{MaxAttempts: 3})
+	receiver := newReceiver(t, http.StatusInternalServerError, http.StatusBadGateway)
+
+	created := createWebhook(t, service, receiver.URL, domain.TodoEventCreated)
+
+	// When
+	service.Notify(context.Background(), _userID, domain.TodoEventCreated, domain.Todo{ID: 1, UserID: _userID})
+
+	// Then
+	deliveries := waitForDeliveries(t, service, created.ID, domain.WebhookDeliverySucceeded)
+	require.Equal(t, 3, deliveries[0].Attempts)
+	require.Empty(t, deliveries[0].Error)
+
+	// Every attempt is the same delivery of the same event.
+	requests := receiver.received()
+	require.Len(t, requests, 3)
+
+	for _, request := range requests {
+		require.Equal(t, strconv.Itoa(deliveries[0].ID), request.header.Get(webhook.HeaderDelivery))
+		require.Equal(t, requests[0].body, request.body)
+	}
+}
+
+func TestServiceNotify_FailsAfterMaxAttemptsAndDisablesWebhook(t *testing.T) {
+	// Given
+	service, _ := newService(t, webhook.DispatcherConfig{MaxAttempts: 2, MaxFailures: 2})
+	receiver := newReceiver(t, http.StatusInternalServerError, http.StatusInternalServerError,
+		http.StatusInternalServerError, http.StatusInternalServerError)
+
+	created := createWebhook(t, service, receiver.URL, domain.TodoEventCreated)
+	ctx := context.Background()
+
+	// When
+	service.Notify(ctx, _userID, domain.TodoEventCreated, domain.Todo{ID: 1, UserID: _userID})
+	waitForDeliveries(t, service, created.ID, domain.WebhookDeliveryFailed)
+
+	failedOnce, err := service.Get(ctx, created.ID)
+	require.NoError(t, err)
+
+	service.Notify(ctx, _userID, domain.TodoEventCreated, domain.Todo{ID: 2, UserID: _userID})
+
+	// Then
+	deliveries := waitForDeliveries(t, service, created.ID, domain.WebhookDeliveryFailed,
+		domain.WebhookDeliveryFailed)
+	require.Equal(t, 2, deliveries[0].Attempts)
+	require.Equal(t, http.StatusInternalServerError, deliveries[0].StatusCode)
+	require.Equal(t, "unexpected status code 500", deliveries[0].Error)
+
+	require.True(t, failedOnce.Active)
+	require.Equal(t, 1, failedOnce.Failures)
+
+	disabled, err := service.Get(ctx, created.ID)
+	require.NoError(t, err)
+	require.False(t, disabled.Active)
+
+	// Disabled webhooks are not notified anymore.
+	service.Notify(ctx, _userID, domain.TodoEventCreated, domain.Todo{ID: 3, UserID: _userID})
+	require.Never(t, func() bool {
+		return len(receiver.received()) > 4
+	}, 50*time.Millisecond, 5*time.Millisecond)
+}
+
+func TestServiceRedeliver_Successful(t *testing.T) {
+	// Given
+	service, _ := newService(t, webhook.DispatcherConfig{MaxAttempts: 1})
+	receiver := newReceiver(t, http.StatusInternalServerError)
+
+	created := createWebhook(t, service, receiver.URL, domain.TodoEventCreated)
+
+	service.Notify(context.Background(), _userID, domain.TodoEventCreated, domain.Todo{ID: 1, UserID: _userID})
+	failed := waitForDeliveries(t, service, created.ID, domain.WebhookDeliveryFailed)[0]
+
+	// When
+	redelivery, err := service.Redeliver(context.Background(), failed.ID)
+
+	// Then
+	require.NoError(t, err)
+	require.NotEqual(t, failed.ID, redelivery.ID)
+	require.Equal(t, domain.WebhookDeliveryPending, redelivery.Status)
+
+	deliveries := waitForDeliveries(t, service, created.ID, domain.WebhookDeliverySucceeded,
+		domain.WebhookDeliveryFailed)
+	require.Equal(t, redelivery.ID, deliveries[0].ID)
+
+	// The event is the same, so the receiver can tell it is a redelivery.
+	requests := receiver.received()
+	require.Len(t, requests, 2)
+	require.Equal(t, requests[0].body, requests[1].body)
+}
+
+func TestServiceRedeliver_FailsDueToDisabledWebhook(t *testing.T) {
+	// Given
+	service, repository := newService(t, webhook.DispatcherConfig{MaxAttempts: 1, MaxFailures: 1})
+	receiver := newReceiver(t, http.StatusInternalServerError)
+
+	created := createWebhook(t, service, receiver.URL, domain.TodoEventCreated)
+
+	service.Notify(context.Background(), _userID, domain.TodoEventCreated, domain.Todo{ID: 1, UserID: _userID})
+	failed := waitForDeliveries(t, service, created.ID, domain.WebhookDeliveryFailed)[0]
+
+	require.Eventually(t, func() bool {
+		disabled, err := repository.Get(context.Background(), created.ID)
+		require.NoError(t, err)
+
+		return !disabled.Active
+	}, 5*time.Second, 5*time.Millisecond)
+
+	// When
+	_, err := service.Redeliver(context.Background(), failed.ID)
+
+	// Then
+	require.ErrorIs(t, err, domain.ErrWebhookDisabled)
+}
+
+func TestServiceUpdate_SuccessfulEnablingResetsFailures(t *testing.T) {
+	// Given
+	service, repository := newService(t, webhook.DispatcherConfig{})
+	ctx := context.Background()
+
+	created := createWebhook(t, service, "https://example.com/webhook", domain.TodoEventCreated)
+	require.NoError(t, repository.RecordFailure(ctx, created.ID, 1))
+
+	created.Active = true
+
+	// When
+	updated, err := service.Update(ctx, created)
+
+	// Then
+	require.NoError(t, err)
+	require.True(t, updated.Active)
+	require.Zero(t, updated.Failures)
+	require.Empty(t, updated.Secret)
+
+	stored, err := repository.Get(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, created.Secret, stored.Secret)
+	require.Zero(t, stored.Failures)
+}
+
+func TestDispatcherStop_SuccessfulDeliversQueuedEvents(t *testing.T) {
+	// Given
+	repository := webhook.NewMemoryRepository()
+	dispatcher := webhook.NewDispatcher(repository, jobs.NewMemoryQueue(), webhook.DispatcherConfig{
+		Workers:    1,
+		AllowLocal: true,
+	})
+	service := webhook.NewService(repository, dispatcher)
+	receiver := newReceiver(t)
+
+	created := createWebhook(t, service, receiver.URL, domain.TodoEventCreated)
+	ctx := context.Background()
+
+	for id := range 3 {
+		service.Notify(ctx, _userID, domain.TodoEventCreated, domain.Todo{ID: id + 1, UserID: _userID})
+	}
+
+	require.NoError(t, dispatcher.Start(ctx))
+
+	// When
+	err := dispatcher.Stop(ctx)
+
+	// Then
+	require.NoError(t, err)
+	require.Len(t, receiver.received(), 3)
+
+	deliveries, err := service.Deliveries(ctx, created.ID, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 3)
+
+	// Stopped dispatchers drop the events.
+	service.Notify(ctx, _userID, domain.TodoEventCreated, domain.Todo{ID: 4, UserID: _userID})
+	require.Len(t, receiver.received(), 3)
+}
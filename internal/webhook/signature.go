@@ -0,0 +1,49 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp" // Unix seconds of the attempt.
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+const (
+	_signaturePrefix = "sha256="
+	_secretPrefix    = "whsec_"
+	_secretSize      = 32
+)
+
+// Sign returns the signature of a delivery: the hex encoded HMAC-SHA256 of the timestamp, a dot and the
+// body, keyed with the secret of the webhook and prefixed with "sha256=". The timestamp is signed so
+// receivers can reject replayed deliveries.
+func Sign(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return _signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of the delivery, comparing in constant time.
+func Verify(secret string, timestamp string, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// newSecret generates the random secret that signs the deliveries of a webhook.
+func newSecret() (string, error) {
+	secret := make([]byte, _secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return _secretPrefix + hex.EncodeToString(secret), nil
+}
@@ -0,0 +1,408 @@
+// Package webhooktest provides a conformance suite that every webhook.Repository implementation must pass.
+package webhooktest
+
+import (
+	"context"
+	"github.com/ferch5003/go-fiber-tutorial/internal/domain"
+	"github.com/ferch5003/go-fiber-tutorial/internal/webhook"
+	"github.com/stretchr/testify/require"
+	"testing"
+	"time"
+)
+
+const (
+	// UserID is an existing user that webhooks can belong to.
+	UserID = 1
+
+	// OtherUserID is a second existing user, to check webhooks are not mixed between users.
+	OtherUserID = 2
+)
+
+// _createdAt is when the webhooks and deliveries of the suite are created.
+var _createdAt = time.Date(2024, time.September, 1, 9, 0, 0, 0, time.UTC)
+
+// RepositoryFactory creates an empty repository for every test. Implementations backed by a database
+// must make sure the users UserID and OtherUserID exist.
+type RepositoryFactory func(t *testing.T) webhook.Repository
+
+// RunRepositoryTests runs the conformance suite against the repositories created by newRepository.
+func RunRepositoryTests(t *testing.T, newRepository RepositoryFactory) {
+	t.Run("SaveAndGet", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		newWebhook := newWebhook(UserID, domain.TodoEventCreated, domain.WebhookUserUpdated)
+
+		// When
+		saved, err := repository.Save(ctx, newWebhook)
+		require.NoError(t, err)
+
+		obtained, err := repository.Get(ctx, saved.ID)
+
+		// Then
+		require.NoError(t, err)
+		require.Positive(t, saved.ID)
+
+		newWebhook.ID = saved.ID
+		require.Equal(t, newWebhook, saved)
+		requireWebhook(t, newWebhook, obtained)
+	})
+
+	t.Run("GetNotFound", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+
+		// When
+		_, err := repository.Get(context.Background(), 1)
+
+		// Then
+		require.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("GetAllOfTheUser", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		first := saveWebhook(t, repository, UserID)
+		saveWebhook(t, repository, OtherUserID)
+		second := saveWebhook(t, repository, UserID)
+
+		// When
+		webhooks, err := repository.GetAll(ctx, UserID)
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, webhooks, 2)
+		require.Equal(t, first, webhooks[0].ID)
+		require.Equal(t, second, webhooks[1].ID)
+	})
+
+	t.Run("GetAllEmpty", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+
+		// When
+		webhooks, err := repository.GetAll(context.Background(), UserID)
+
+		// Then
+		require.NoError(t, err)
+		require.NotNil(t, webhooks)
+		require.Empty(t, webhooks)
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		saved, err := repository.Save(ctx, newWebhook(UserID, domain.TodoEventCreated))
+		require.NoError(t, err)
+
+		saved.URL = "https://example.com/updated"
+		saved.Events = domain.WebhookFilter{domain.TodoEventDeleted}
+		saved.Active = false
+		saved.Failures = 3
+
+		// When
+		err = repository.Update(ctx, saved)
+		require.NoError(t, err)
+
+		obtained, err := repository.Get(ctx, saved.ID)
+
+		// Then
+		require.NoError(t, err)
+		requireWebhook(t, saved, obtained)
+	})
+
+	t.Run("DeleteWithDeliveries", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id := saveWebhook(t, repository, UserID)
+		deliveryID := saveDelivery(t, repository, id)
+
+		// When
+		err := repository.Delete(ctx, id)
+		require.NoError(t, err)
+
+		// Then
+		_, err = repository.Get(ctx, id)
+		require.ErrorIs(t, err, domain.ErrNotFound)
+
+		_, err = repository.GetDelivery(ctx, deliveryID)
+		require.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("RecordFailureDisablesAfterMaxFailures", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id := saveWebhook(t, repository, UserID)
+
+		// When
+		require.NoError(t, repository.RecordFailure(ctx, id, 2))
+
+		failedOnce, err := repository.Get(ctx, id)
+		require.NoError(t, err)
+
+		require.NoError(t, repository.RecordFailure(ctx, id, 2))
+
+		failedTwice, err := repository.Get(ctx, id)
+		require.NoError(t, err)
+
+		// Then
+		require.True(t, failedOnce.Active)
+		require.Equal(t, 1, failedOnce.Failures)
+
+		require.False(t, failedTwice.Active)
+		require.Equal(t, 2, failedTwice.Failures)
+	})
+
+	t.Run("RecordSuccessResetsFailures", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id := saveWebhook(t, repository, UserID)
+		require.NoError(t, repository.RecordFailure(ctx, id, 5))
+
+		// When
+		err := repository.RecordSuccess(ctx, id)
+		require.NoError(t, err)
+
+		obtained, err := repository.Get(ctx, id)
+
+		// Then
+		require.NoError(t, err)
+		require.True(t, obtained.Active)
+		require.Zero(t, obtained.Failures)
+	})
+
+	t.Run("SaveAndGetDelivery", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		delivery := newDelivery(saveWebhook(t, repository, UserID))
+
+		// When
+		saved, err := repository.SaveDelivery(ctx, delivery)
+		require.NoError(t, err)
+
+		obtained, err := repository.GetDelivery(ctx, saved.ID)
+
+		// Then
+		require.NoError(t, err)
+		require.Positive(t, saved.ID)
+
+		delivery.ID = saved.ID
+		requireDelivery(t, delivery, obtained)
+	})
+
+	t.Run("SaveDeliveryOfMissingWebhook", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+
+		// When
+		_, err := repository.SaveDelivery(context.Background(), newDelivery(1))
+
+		// Then
+		require.Error(t, err)
+	})
+
+	t.Run("GetDeliveryNotFound", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+
+		// When
+		_, err := repository.GetDelivery(context.Background(), 1)
+
+		// Then
+		require.ErrorIs(t, err, domain.ErrNotFound)
+	})
+
+	t.Run("GetDeliveriesFromNewest", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id := saveWebhook(t, repository, UserID)
+		otherID := saveWebhook(t, repository, UserID)
+
+		saveDelivery(t, repository, id)
+		second := saveDelivery(t, repository, id)
+		saveDelivery(t, repository, otherID)
+		third := saveDelivery(t, repository, id)
+
+		// When
+		deliveries, err := repository.GetDeliveries(ctx, id, 2)
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+		require.Equal(t, third, deliveries[0].ID)
+		require.Equal(t, second, deliveries[1].ID)
+	})
+
+	t.Run("GetPendingDeliveriesBeforeFromOldest", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id := saveWebhook(t, repository, UserID)
+
+		older := newDelivery(id)
+		older.UpdatedAt = _createdAt.Add(-2 * time.Hour)
+		older, err := repository.SaveDelivery(ctx, older)
+		require.NoError(t, err)
+
+		old := newDelivery(id)
+		old.UpdatedAt = _createdAt.Add(-time.Hour)
+		old, err = repository.SaveDelivery(ctx, old)
+		require.NoError(t, err)
+
+		succeeded := newDelivery(id)
+		succeeded.Status = domain.WebhookDeliverySucceeded
+		succeeded.UpdatedAt = _createdAt.Add(-time.Hour)
+		_, err = repository.SaveDelivery(ctx, succeeded)
+		require.NoError(t, err)
+
+		saveDelivery(t, repository, id)
+
+		// When
+		deliveries, err := repository.GetPendingDeliveriesBefore(ctx, _createdAt.Add(-time.Minute), 10)
+
+		// Then
+		require.NoError(t, err)
+		require.Len(t, deliveries, 2)
+		requireDelivery(t, older, deliveries[0])
+		requireDelivery(t, old, deliveries[1])
+
+		limited, err := repository.GetPendingDeliveriesBefore(ctx, _createdAt.Add(-time.Minute), 1)
+		require.NoError(t, err)
+		require.Len(t, limited, 1)
+		require.Equal(t, older.ID, limited[0].ID)
+	})
+
+	t.Run("UpdateDelivery", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		saved, err := repository.SaveDelivery(ctx, newDelivery(saveWebhook(t, repository, UserID)))
+		require.NoError(t, err)
+
+		saved.Status = domain.WebhookDeliveryFailed
+		saved.Attempts = 5
+		saved.StatusCode = 500
+		saved.Error = "unexpected status code 500"
+		saved.UpdatedAt = _createdAt.Add(time.Hour)
+
+		// When
+		err = repository.UpdateDelivery(ctx, saved)
+		require.NoError(t, err)
+
+		obtained, err := repository.GetDelivery(ctx, saved.ID)
+
+		// Then
+		require.NoError(t, err)
+		requireDelivery(t, saved, obtained)
+	})
+
+	t.Run("DeleteDeliveriesBefore", func(t *testing.T) {
+		// Given
+		repository := newRepository(t)
+		ctx := context.Background()
+
+		id := saveWebhook(t, repository, UserID)
+
+		old := newDelivery(id)
+		old.CreatedAt = _createdAt.Add(-48 * time.Hour)
+		_, err := repository.SaveDelivery(ctx, old)
+		require.NoError(t, err)
+
+		recent := saveDelivery(t, repository, id)
+
+		// When
+		deleted, err := repository.DeleteDeliveriesBefore(ctx, _createdAt.Add(-24*time.Hour))
+
+		// Then
+		require.NoError(t, err)
+		require.Equal(t, 1, deleted)
+
+		deliveries, err := repository.GetDeliveries(ctx, id, 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		require.Equal(t, recent, deliveries[0].ID)
+	})
+}
+
+func newWebhook(userID int, events ...string) domain.Webhook {
+	return domain.Webhook{
+		UserID:    userID,
+		URL:       "https://example.com/webhook",
+		Events:    events,
+		Secret:    "whsec_secret",
+		Active:    true,
+		CreatedAt: _createdAt,
+	}
+}
+
+func newDelivery(webhookID int) domain.WebhookDelivery {
+	return domain.WebhookDelivery{
+		WebhookID: webhookID,
+		Event:     domain.TodoEventCreated,
+		Payload:   domain.WebhookPayload(`{"id":"event","type":"todo.created","data":{"id":1}}`),
+		Status:    domain.WebhookDeliveryPending,
+		CreatedAt: _createdAt,
+		UpdatedAt: _createdAt,
+	}
+}
+
+// saveWebhook saves a webhook of the user, returning its ID.
+func saveWebhook(t *testing.T, repository webhook.Repository, userID int) int {
+	t.Helper()
+
+	saved, err := repository.Save(context.Background(), newWebhook(userID, domain.TodoEventCreated))
+	require.NoError(t, err)
+
+	return saved.ID
+}
+
+// saveDelivery saves a pending delivery to the webhook, returning its ID.
+func saveDelivery(t *testing.T, repository webhook.Repository, webhookID int) int {
+	t.Helper()
+
+	saved, err := repository.SaveDelivery(context.Background(), newDelivery(webhookID))
+	require.NoError(t, err)
+
+	return saved.ID
+}
+
+// requireWebhook compares the webhooks, with their creation time in any location.
+func requireWebhook(t *testing.T, expected, actual domain.Webhook) {
+	t.Helper()
+
+	require.True(t, expected.CreatedAt.Equal(actual.CreatedAt))
+
+	actual.CreatedAt = expected.CreatedAt
+	require.Equal(t, expected, actual)
+}
+
+// requireDelivery compares the deliveries, with their times in any location.
+func requireDelivery(t *testing.T, expected, actual domain.WebhookDelivery) {
+	t.Helper()
+
+	require.True(t, expected.CreatedAt.Equal(actual.CreatedAt))
+	require.True(t, expected.UpdatedAt.Equal(actual.UpdatedAt))
+	require.JSONEq(t, string(expected.Payload), string(actual.Payload))
+
+	actual.CreatedAt = expected.CreatedAt
+	actual.UpdatedAt = expected.UpdatedAt
+	actual.Payload = expected.Payload
+	require.Equal(t, expected, actual)
+}
@@ -0,0 +1,4 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE todos ADD FULLTEXT INDEX todos_fulltext (title, description);
+-- +goose StatementEnd
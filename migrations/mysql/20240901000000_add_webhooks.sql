@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS webhooks (
+   id INT PRIMARY KEY AUTO_INCREMENT,
+   user_id INT NOT NULL,
+   url VARCHAR(2048) NOT NULL,
+   events TEXT NOT NULL,
+   secret VARCHAR(255) NOT NULL,
+   active BOOLEAN NOT NULL DEFAULT TRUE,
+   failures INT NOT NULL DEFAULT 0,
+   created_at DATETIME NOT NULL,
+   FOREIGN KEY (user_id)
+   REFERENCES users(id)
+   ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+   id INT PRIMARY KEY AUTO_INCREMENT,
+   webhook_id INT NOT NULL,
+   event VARCHAR(64) NOT NULL,
+   payload TEXT NOT NULL,
+   status VARCHAR(16) NOT NULL,
+   attempts INT NOT NULL DEFAULT 0,
+   status_code INT NOT NULL DEFAULT 0,
+   error TEXT NOT NULL,
+   created_at DATETIME NOT NULL,
+   updated_at DATETIME NOT NULL,
+   FOREIGN KEY (webhook_id)
+   REFERENCES webhooks(id)
+   ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX webhook_deliveries_webhook_id ON webhook_deliveries (webhook_id);
+-- +goose StatementEnd
@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE todos ADD COLUMN external_id VARCHAR(255) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- MySQL has no partial indexes, so the unique index is on a column that stores NULL instead of an empty
+-- external ID, and NULLs never conflict.
+-- +goose StatementBegin
+ALTER TABLE todos ADD COLUMN unique_external_id VARCHAR(255) AS (NULLIF(external_id, '')) STORED;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX todos_user_id_external_id ON todos (user_id, unique_external_id);
+-- +goose StatementEnd
@@ -0,0 +1,4 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX webhook_deliveries_status_updated_at ON webhook_deliveries (status, updated_at);
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS users (
+   id SERIAL PRIMARY KEY,
+   first_name VARCHAR(255) NOT NULL,
+   last_name VARCHAR(255) NOT NULL,
+   email VARCHAR(255) NOT NULL UNIQUE,
+   password VARCHAR(255) NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS todos (
+   id SERIAL PRIMARY KEY,
+   title VARCHAR(255) NOT NULL,
+   description TEXT,
+   completed BOOLEAN NOT NULL DEFAULT FALSE,
+   user_id INT NOT NULL,
+   FOREIGN KEY (user_id)
+   REFERENCES users(id)
+   ON DELETE CASCADE
+);
+-- +goose StatementEnd
@@ -0,0 +1,8 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE todos ADD COLUMN external_id VARCHAR(255) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX todos_user_id_external_id ON todos (user_id, external_id) WHERE external_id <> '';
+-- +goose StatementEnd
@@ -0,0 +1,8 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE outbox ADD COLUMN attempts INT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE outbox ADD COLUMN dead_lettered_at TIMESTAMPTZ NULL;
+-- +goose StatementEnd
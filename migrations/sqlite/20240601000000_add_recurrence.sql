@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE todos ADD COLUMN due_at DATETIME NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE todos ADD COLUMN recurrence VARCHAR(512) NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE todos ADD COLUMN series_id INT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX todos_series_id ON todos (series_id);
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE todos ADD COLUMN position BIGINT NOT NULL DEFAULT 0;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE todos SET position = id * 1024;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX todos_user_id_position ON todos (user_id, position);
+-- +goose StatementEnd
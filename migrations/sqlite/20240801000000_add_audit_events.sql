@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS audit_events (
+   id INTEGER PRIMARY KEY AUTOINCREMENT,
+   actor_id INT NOT NULL DEFAULT 0,
+   action VARCHAR(64) NOT NULL,
+   entity VARCHAR(32) NOT NULL,
+   entity_id INT NOT NULL DEFAULT 0,
+   changes TEXT NOT NULL,
+   request_id VARCHAR(128) NOT NULL DEFAULT '',
+   ip VARCHAR(64) NOT NULL DEFAULT '',
+   created_at DATETIME NOT NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX audit_events_entity ON audit_events (entity, entity_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX audit_events_actor_id ON audit_events (actor_id);
+-- +goose StatementEnd
@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS outbox (
+   id INTEGER PRIMARY KEY AUTOINCREMENT,
+   aggregate_type VARCHAR(32) NOT NULL,
+   aggregate_id INT NOT NULL,
+   event_type VARCHAR(64) NOT NULL,
+   payload TEXT NOT NULL,
+   created_at DATETIME NOT NULL,
+   published_at DATETIME NULL
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX outbox_published_at ON outbox (published_at, id);
+-- +goose StatementEnd